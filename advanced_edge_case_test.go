@@ -583,12 +583,18 @@ func TestRequestBodyPreparation(t *testing.T) {
 		config: Config{RetryEnabled: true},
 	}
 
-	preparedBody, err := rt.prepareRequestBody(req)
+	preparedBody, err := rt.prepareRequestBody(req, rt.config)
 	require.NoError(t, err)
-	require.NotNil(t, preparedBody)
+	require.True(t, preparedBody.replayable)
 
-	// Проверяем, что подготовленное тело содержит оригинальные данные
-	assert.Equal(t, originalData, preparedBody)
+	// Request has GetBody, so the body is replayed via the factory instead of buffering
+	require.NotNil(t, preparedBody.getBody)
+	assert.Nil(t, preparedBody.data)
+	replayed, err := preparedBody.getBody()
+	require.NoError(t, err)
+	replayedData, err := io.ReadAll(replayed)
+	require.NoError(t, err)
+	assert.Equal(t, originalData, replayedData)
 
 	// Проверяем, что оригинальное тело все еще читаемо
 	originalData2, err := io.ReadAll(req.Body)
@@ -608,9 +614,11 @@ func TestNilBodyPreparation(t *testing.T) {
 		config: Config{RetryEnabled: true},
 	}
 
-	preparedBody, err := rt.prepareRequestBody(req)
+	preparedBody, err := rt.prepareRequestBody(req, rt.config)
 	require.NoError(t, err)
-	assert.Nil(t, preparedBody)
+	assert.Nil(t, preparedBody.data)
+	assert.Nil(t, preparedBody.getBody)
+	assert.True(t, preparedBody.replayable)
 }
 
 // TestContentLengthPreservationOnRetryAttempts tests ContentLength preservation
@@ -0,0 +1,433 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned by Enqueue when AsyncQueueConfig.MaxQueueSize requests are pending.
+var ErrQueueFull = errors.New("async request queue is full")
+
+// QueuedRequest is a request waiting for asynchronous delivery, in the form saved by a QueueStore.
+type QueuedRequest struct {
+	ID          string      `json:"id"`
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	EnqueuedAt  time.Time   `json:"enqueued_at"`
+	Attempts    int         `json:"attempts"`
+	NextAttempt time.Time   `json:"next_attempt"`
+	LastError   string      `json:"last_error,omitempty"`
+}
+
+// QueueStore persists queued requests so deliveries survive process restarts.
+// Implementations must be safe for concurrent use.
+type QueueStore interface {
+	// Save inserts or replaces the request with the same ID
+	Save(ctx context.Context, req QueuedRequest) error
+	// Delete removes a delivered or failed request
+	Delete(ctx context.Context, id string) error
+	// Load returns the pending requests when the queue starts
+	Load(ctx context.Context) ([]QueuedRequest, error)
+}
+
+// MemoryQueueStore is the in-memory QueueStore used by default. It doesn't survive restarts.
+type MemoryQueueStore struct {
+	mu       sync.Mutex
+	requests map[string]QueuedRequest
+}
+
+// NewMemoryQueueStore creates an empty in-memory queue store.
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{requests: make(map[string]QueuedRequest)}
+}
+
+// Save implements the QueueStore interface.
+func (s *MemoryQueueStore) Save(_ context.Context, req QueuedRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[req.ID] = req
+	return nil
+}
+
+// Delete implements the QueueStore interface.
+func (s *MemoryQueueStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.requests, id)
+	return nil
+}
+
+// Load implements the QueueStore interface.
+func (s *MemoryQueueStore) Load(_ context.Context) ([]QueuedRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]QueuedRequest, 0, len(s.requests))
+	for _, req := range s.requests {
+		requests = append(requests, req)
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].EnqueuedAt.Before(requests[j].EnqueuedAt) })
+	return requests, nil
+}
+
+// AsyncQueueConfig contains settings of the queue behind Client.Enqueue.
+type AsyncQueueConfig struct {
+	// Workers is the number of concurrent deliveries (default: 4)
+	Workers int
+
+	// MaxQueueSize is the maximum number of pending requests (default: 1000)
+	MaxQueueSize int
+
+	// MaxAttempts is the maximum number of deliveries of a request (default: 5).
+	// Each delivery uses the client retry policy as well
+	MaxAttempts int
+
+	// BaseDelay is the delay before the second delivery (default: 1s)
+	BaseDelay time.Duration
+
+	// MaxDelay is the maximum delay between deliveries (default: 5m)
+	MaxDelay time.Duration
+
+	// Schedule lists the delays before the second, third and later deliveries, replacing
+	// BaseDelay and MaxDelay; the last delay repeats. MaxAttempts defaults to len(Schedule)+1
+	Schedule []time.Duration
+
+	// Store persists pending requests (default: NewMemoryQueueStore()). With a store set the
+	// queue starts with the client and resumes the requests returned by Store.Load
+	Store QueueStore
+
+	// BeforeDelivery is called with the request of every delivery, e.g. to sign it with a fresh
+	// timestamp. An error fails the delivery without sending the request
+	BeforeDelivery func(req *http.Request, queued QueuedRequest) error
+
+	// IsDelivered reports whether the response completes the delivery (default: 2xx status)
+	IsDelivered func(resp *http.Response) bool
+
+	// OnDelivered is called after a successful delivery; the body is closed when it returns
+	OnDelivered func(req QueuedRequest, resp *http.Response)
+
+	// OnFailed is called when a request is dropped after MaxAttempts deliveries
+	OnFailed func(req QueuedRequest, err error)
+}
+
+// withDefaults applies default values to the queue configuration.
+func (c AsyncQueueConfig) withDefaults() AsyncQueueConfig {
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.MaxQueueSize <= 0 {
+		c.MaxQueueSize = 1000
+	}
+	if c.MaxAttempts <= 0 && len(c.Schedule) > 0 {
+		c.MaxAttempts = len(c.Schedule) + 1
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.BaseDelay <= 0 {
+		c.BaseDelay = time.Second
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = 5 * time.Minute
+	}
+	if c.Store == nil {
+		c.Store = NewMemoryQueueStore()
+	}
+	if c.IsDelivered == nil {
+		c.IsDelivered = func(resp *http.Response) bool {
+			return resp.StatusCode >= 200 && resp.StatusCode < 300
+		}
+	}
+	return c
+}
+
+// delay returns the delay before the next delivery of a request delivered attempts times.
+func (c AsyncQueueConfig) delay(attempts int) time.Duration {
+	if len(c.Schedule) > 0 {
+		return c.Schedule[min(attempts, len(c.Schedule))-1]
+	}
+	return CalculateBackoffDelay(attempts+1, c.BaseDelay, c.MaxDelay, 0)
+}
+
+// asyncQueue delivers queued requests with a fixed number of workers.
+type asyncQueue struct {
+	client *Client
+	config AsyncQueueConfig
+	clock  Clock
+
+	ready chan string // IDs of requests due for delivery
+
+	mu       sync.Mutex
+	pending  map[string]QueuedRequest
+	contexts map[string]context.Context // contexts of requests enqueued by this process
+	stopped  bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newAsyncQueue creates the queue, resumes the stored requests and starts the workers.
+func newAsyncQueue(client *Client, config AsyncQueueConfig) (*asyncQueue, error) {
+	config = config.withDefaults()
+	q := &asyncQueue{
+		client:   client,
+		config:   config,
+		clock:    clockOrDefault(client.config.Clock),
+		ready:    make(chan string, config.MaxQueueSize),
+		pending:  make(map[string]QueuedRequest),
+		contexts: make(map[string]context.Context),
+		stop:     make(chan struct{}),
+	}
+	stored, err := config.Store.Load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range stored {
+		q.pending[req.ID] = req
+		q.schedule(req)
+	}
+	for range config.Workers {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q, nil
+}
+
+// Enqueue queues the request for asynchronous delivery and returns its ID. The body is read
+// and the request options are applied immediately. Header, URL and body options are saved
+// in the QueueStore; context options such as WithRequestTimeout and the context values only
+// apply to deliveries by this process. Cancelling the request context doesn't cancel the delivery.
+// Delivery results are reported by AsyncQueueConfig.OnDelivered and OnFailed.
+func (c *Client) Enqueue(req *http.Request, opts ...RequestOption) (string, error) {
+	if c.drain.isClosed() {
+		return "", &ClientClosedError{Method: req.Method, URL: req.URL.String()}
+	}
+	if c.metricsErr != nil {
+		return "", c.metricsErr
+	}
+	q, err := c.startQueue()
+	if err != nil {
+		return "", err
+	}
+
+	req = req.Clone(context.WithoutCancel(req.Context()))
+	applyOptions(req, opts)
+	req, err = c.resolveURL(req)
+	if err != nil {
+		return "", err
+	}
+	// The stored URL is already expanded: drop the parameters so the delivery keeps the template
+	req = req.WithContext(context.WithValue(req.Context(), pathParamsKey{}, nil))
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	now := q.clock.Now()
+	queued := QueuedRequest{
+		ID:          newRequestID(),
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Header:      req.Header.Clone(),
+		Body:        body,
+		EnqueuedAt:  now,
+		NextAttempt: now,
+	}
+	if err := q.add(req.Context(), queued); err != nil {
+		return "", err
+	}
+	return queued.ID, nil
+}
+
+// QueueLength returns the number of requests queued by Enqueue and not yet delivered or dropped.
+func (c *Client) QueueLength() int {
+	if q := c.startedQueue(); q != nil {
+		return q.pendingCount()
+	}
+	return 0
+}
+
+// startQueue returns the queue of the client, starting it on first use.
+func (c *Client) startQueue() (*asyncQueue, error) {
+	c.queueOnce.Do(func() {
+		queue, err := newAsyncQueue(c, c.config.AsyncQueue)
+		c.queueMu.Lock()
+		c.queue, c.queueErr = queue, err
+		c.queueMu.Unlock()
+	})
+	return c.queue, c.queueErr
+}
+
+// startedQueue returns the queue of the client, or nil if it isn't started.
+func (c *Client) startedQueue() *asyncQueue {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	return c.queue
+}
+
+// add saves the request and schedules its first delivery.
+func (q *asyncQueue) add(ctx context.Context, req QueuedRequest) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopped {
+		return &ClientClosedError{Method: req.Method, URL: req.URL}
+	}
+	if len(q.pending) >= q.config.MaxQueueSize {
+		return ErrQueueFull
+	}
+	if err := q.config.Store.Save(ctx, req); err != nil {
+		return err
+	}
+	q.pending[req.ID] = req
+	q.contexts[req.ID] = ctx
+	q.schedule(req)
+	return nil
+}
+
+// schedule sends the request ID to the workers when its next attempt is due.
+func (q *asyncQueue) schedule(req QueuedRequest) {
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		if delay := req.NextAttempt.Sub(q.clock.Now()); delay > 0 {
+			timer := q.clock.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-q.stop:
+				return
+			case <-timer.C():
+			}
+		}
+		select {
+		case <-q.stop:
+		case q.ready <- req.ID:
+		}
+	}()
+}
+
+// work delivers due requests until the queue is stopped.
+func (q *asyncQueue) work() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case id := <-q.ready:
+			q.deliver(id)
+		}
+	}
+}
+
+// deliver sends the request once and records the outcome.
+func (q *asyncQueue) deliver(id string) {
+	q.mu.Lock()
+	queued, ok := q.pending[id]
+	ctx := q.contexts[id]
+	q.mu.Unlock()
+	if !ok {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, queued.Method, queued.URL, bytes.NewReader(queued.Body))
+	if err == nil {
+		req.Header = queued.Header.Clone()
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+		if q.config.BeforeDelivery != nil {
+			err = q.config.BeforeDelivery(req, queued)
+		}
+	}
+	if err == nil {
+		var resp *http.Response
+		resp, err = q.client.Do(req)
+		if IsClientClosedError(err) {
+			// The client is closing: the request stays in the store for the next start
+			return
+		}
+		if err == nil {
+			if q.config.IsDelivered(resp) {
+				q.delivered(queued, resp)
+				return
+			}
+			err = NewHTTPError(resp, req)
+			closeResponseBody(resp)
+		}
+	}
+	q.failed(queued, err)
+}
+
+// delivered removes the delivered request and reports it.
+func (q *asyncQueue) delivered(queued QueuedRequest, resp *http.Response) {
+	defer closeResponseBody(resp)
+	queued.Attempts++
+	q.remove(queued.ID)
+	if q.config.OnDelivered != nil {
+		q.config.OnDelivered(queued, resp)
+	}
+}
+
+// failed schedules the next delivery, or drops the request after the last attempt.
+func (q *asyncQueue) failed(queued QueuedRequest, err error) {
+	queued.Attempts++
+	queued.LastError = err.Error()
+	if queued.Attempts >= q.config.MaxAttempts {
+		q.remove(queued.ID)
+		if q.config.OnFailed != nil {
+			q.config.OnFailed(queued, err)
+		}
+		return
+	}
+
+	queued.NextAttempt = q.clock.Now().Add(q.config.delay(queued.Attempts))
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_ = q.config.Store.Save(context.Background(), queued)
+	q.pending[queued.ID] = queued
+	if !q.stopped {
+		q.schedule(queued)
+	}
+}
+
+// remove deletes the request from the queue and the store.
+func (q *asyncQueue) remove(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_ = q.config.Store.Delete(context.Background(), id)
+	delete(q.pending, id)
+	delete(q.contexts, id)
+}
+
+// close stops the workers after the running deliveries. Pending requests stay in the store.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return
+	}
+	q.stopped = true
+	close(q.stop)
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// pendingCount returns the number of requests waiting for delivery.
+func (q *asyncQueue) pendingCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
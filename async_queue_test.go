@@ -0,0 +1,226 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnqueue_DeliversWithRetries(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusAccepted},
+	)
+	defer server.Close()
+
+	delivered := make(chan QueuedRequest, 1)
+	client := New(Config{
+		AsyncQueue: AsyncQueueConfig{
+			BaseDelay: time.Millisecond,
+			OnDelivered: func(req QueuedRequest, resp *http.Response) {
+				assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+				delivered <- req
+			},
+		},
+	}, "test-enqueue")
+	defer client.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/hooks", strings.NewReader(`{"event":"paid"}`))
+	require.NoError(t, err)
+	id, err := client.Enqueue(req, WithHeader("X-Event", "paid"))
+	require.NoError(t, err)
+
+	select {
+	case queued := <-delivered:
+		assert.Equal(t, id, queued.ID)
+		assert.Equal(t, 2, queued.Attempts)
+		assert.Contains(t, queued.LastError, "503")
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not delivered")
+	}
+	assert.Equal(t, `{"event":"paid"}`, server.GetLastRequest().Body)
+	assert.Equal(t, "paid", server.GetLastRequest().Headers["X-Event"])
+	assert.Equal(t, 0, client.QueueLength())
+}
+
+func TestEnqueue_FailsAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusInternalServerError})
+	defer server.Close()
+
+	failed := make(chan error, 1)
+	client := New(Config{
+		AsyncQueue: AsyncQueueConfig{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			OnFailed: func(req QueuedRequest, err error) {
+				assert.Equal(t, 3, req.Attempts)
+				failed <- err
+			},
+		},
+	}, "test-enqueue-failed")
+	defer client.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Enqueue(req)
+	require.NoError(t, err)
+
+	select {
+	case err := <-failed:
+		assert.True(t, IsHTTPError(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not dropped")
+	}
+	assert.Equal(t, 3, server.GetRequestCount())
+}
+
+func TestEnqueue_ResumesStoredRequests(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusInternalServerError},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	store := NewMemoryQueueStore()
+	first := New(Config{AsyncQueue: AsyncQueueConfig{Store: store, BaseDelay: time.Hour}}, "test-enqueue-store")
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/items/1", strings.NewReader("payload"))
+	require.NoError(t, err)
+	id, err := first.Enqueue(req)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return server.GetRequestCount() == 1 }, 5*time.Second, 5*time.Millisecond)
+	require.NoError(t, first.Close())
+
+	stored, err := store.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, id, stored[0].ID)
+	assert.Equal(t, 1, stored[0].Attempts)
+
+	// A restarted process picks up the stored request
+	stored[0].NextAttempt = time.Time{}
+	require.NoError(t, store.Save(context.Background(), stored[0]))
+	delivered := make(chan string, 1)
+	second := New(Config{AsyncQueue: AsyncQueueConfig{
+		Store:       store,
+		OnDelivered: func(req QueuedRequest, _ *http.Response) { delivered <- req.ID },
+	}}, "test-enqueue-store")
+	defer second.Close()
+
+	select {
+	case got := <-delivered:
+		assert.Equal(t, id, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stored request was not delivered")
+	}
+	assert.Equal(t, "payload", server.GetLastRequest().Body)
+	stored, err = store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
+
+func TestEnqueue_QueueFull(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := New(Config{AsyncQueue: AsyncQueueConfig{MaxQueueSize: 1}}, "test-enqueue-full")
+	defer client.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Enqueue(req)
+	require.NoError(t, err)
+	_, err = client.Enqueue(req)
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.Equal(t, 1, client.QueueLength())
+}
+
+func TestEnqueue_ScheduleAndBeforeDelivery(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusInternalServerError})
+	defer server.Close()
+
+	failed := make(chan QueuedRequest, 1)
+	var prepared []int
+	client := New(Config{
+		AsyncQueue: AsyncQueueConfig{
+			Schedule: []time.Duration{time.Millisecond, 2 * time.Millisecond},
+			BeforeDelivery: func(req *http.Request, queued QueuedRequest) error {
+				prepared = append(prepared, queued.Attempts)
+				req.Header.Set("X-Attempt", strconv.Itoa(queued.Attempts+1))
+				return nil
+			},
+			OnFailed: func(req QueuedRequest, _ error) { failed <- req },
+		},
+	}, "test-enqueue-schedule")
+	defer client.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Enqueue(req)
+	require.NoError(t, err)
+
+	// MaxAttempts defaults to the first delivery and one per scheduled delay
+	select {
+	case queued := <-failed:
+		assert.Equal(t, 3, queued.Attempts)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not dropped")
+	}
+	assert.Equal(t, []int{0, 1, 2}, prepared)
+	assert.Equal(t, "3", server.GetLastRequest().Headers["X-Attempt"])
+
+	config := AsyncQueueConfig{Schedule: []time.Duration{time.Second, time.Minute}}
+	assert.Equal(t, time.Second, config.delay(1))
+	assert.Equal(t, time.Minute, config.delay(2))
+	assert.Equal(t, time.Minute, config.delay(5))
+}
+
+func TestEnqueue_KeepsPathTemplate(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusAccepted})
+	defer server.Close()
+
+	templates := make(chan string, 1)
+	delivered := make(chan struct{}, 1)
+	client := New(Config{
+		BaseURL: server.URL,
+		Middlewares: []Middleware{MiddlewareFunc(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			template, _ := PathTemplateFromContext(req.Context())
+			templates <- template
+			return next(req)
+		})},
+		AsyncQueue: AsyncQueueConfig{
+			OnDelivered: func(QueuedRequest, *http.Response) { delivered <- struct{}{} },
+		},
+	}, "test-enqueue-template")
+	defer client.Close()
+
+	req, err := http.NewRequest(http.MethodPost, "/users/{id}", nil)
+	require.NoError(t, err)
+	_, err = client.Enqueue(req, WithPathParam("id", 42))
+	require.NoError(t, err)
+
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not delivered")
+	}
+	assert.Equal(t, "/users/{id}", <-templates)
+	assert.Equal(t, "/users/42", server.GetLastRequest().URL)
+}
@@ -0,0 +1,129 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultCredentialHeaders lists request headers carrying credentials when
+// Config.AuthRejectionCooldown is enabled.
+var DefaultCredentialHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"Cookie",
+}
+
+// AuthRejectedError is returned without contacting the server for requests whose credentials
+// were rejected with 401 Unauthorized or 403 Forbidden less than Config.AuthRejectionCooldown ago.
+type AuthRejectedError struct {
+	Method string
+	URL    string
+	// StatusCode is the status of the rejected request
+	StatusCode int
+	// Until is the time the cool-down ends
+	Until time.Time
+}
+
+// Error implements the error interface.
+func (e *AuthRejectedError) Error() string {
+	return fmt.Sprintf("credentials rejected with status %d, request %s %s not sent until %s",
+		e.StatusCode, e.Method, e.URL, e.Until.Format(time.RFC3339))
+}
+
+// IsAuthRejectedError checks if a request was short-circuited after its credentials were rejected.
+func IsAuthRejectedError(err error) bool {
+	var rejected *AuthRejectedError
+	return errors.As(err, &rejected)
+}
+
+// authRejections remembers rejected credentials for a cool-down. A 401 rejects the credentials
+// for the whole host, a 403 only for the method and path of the request.
+type authRejections struct {
+	cooldown time.Duration
+	headers  []string
+
+	mu      sync.Mutex
+	entries map[string]authRejection
+}
+
+// authRejection is a remembered rejection.
+type authRejection struct {
+	status int
+	until  time.Time
+}
+
+// newAuthRejections creates the cache, or returns nil when cooldown is not positive.
+func newAuthRejections(cooldown time.Duration, headers []string) *authRejections {
+	if cooldown <= 0 {
+		return nil
+	}
+	return &authRejections{
+		cooldown: cooldown,
+		headers:  headers,
+		entries:  make(map[string]authRejection),
+	}
+}
+
+// check returns *AuthRejectedError when the credentials of the request are cooling down.
+func (a *authRejections) check(req *http.Request, now time.Time) error {
+	credentials := a.credentialHash(req)
+	host := getHost(req.URL)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, key := range []string{
+		host + " " + credentials,
+		host + " " + credentials + " " + req.Method + " " + req.URL.Path,
+	} {
+		if entry, ok := a.entries[key]; ok && now.Before(entry.until) {
+			return &AuthRejectedError{
+				Method:     req.Method,
+				URL:        req.URL.String(),
+				StatusCode: entry.status,
+				Until:      entry.until,
+			}
+		}
+	}
+	return nil
+}
+
+// record remembers the credentials of the request when the response rejected them.
+// It reports whether the rejection was recorded.
+func (a *authRejections) record(req *http.Request, resp *http.Response, now time.Time) bool {
+	if resp == nil || (resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden) {
+		return false
+	}
+
+	key := getHost(req.URL) + " " + a.credentialHash(req)
+	if resp.StatusCode == http.StatusForbidden {
+		key += " " + req.Method + " " + req.URL.Path
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for existing, entry := range a.entries {
+		if !now.Before(entry.until) {
+			delete(a.entries, existing)
+		}
+	}
+	a.entries[key] = authRejection{status: resp.StatusCode, until: now.Add(a.cooldown)}
+	return true
+}
+
+// credentialHash returns a hash of the credential headers of the request, so the cache
+// doesn't keep secrets in memory.
+func (a *authRejections) credentialHash(req *http.Request) string {
+	hash := sha256.New()
+	for _, name := range a.headers {
+		for _, value := range req.Header.Values(name) {
+			_, _ = hash.Write([]byte(name + ":" + value + "\n"))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthRejectionCooldown_ShortCircuitsRejectedCredentials(t *testing.T) {
+	t.Parallel()
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	clock := NewFakeClock(time.Unix(1000, 0))
+	client := New(Config{AuthRejectionCooldown: time.Minute, Clock: clock}, "test-auth-rejection")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL+"/a", WithBearerToken("bad"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// The same credentials are rejected for every path of the host
+	_, err = client.Get(context.Background(), server.URL+"/b", WithBearerToken("bad"))
+	var rejected *AuthRejectedError
+	require.ErrorAs(t, err, &rejected)
+	assert.True(t, IsAuthRejectedError(err))
+	assert.Equal(t, http.StatusUnauthorized, rejected.StatusCode)
+	assert.Equal(t, clock.Now().Add(time.Minute), rejected.Until)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// Other credentials still reach the server
+	resp, err = client.Get(context.Background(), server.URL+"/a", WithBearerToken("good"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	clock.Advance(time.Minute)
+	resp, err = client.Get(context.Background(), server.URL+"/a", WithBearerToken("bad"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
+
+func TestAuthRejectionCooldown_ForbiddenBlocksEndpoint(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{AuthRejectionCooldown: time.Minute}, "test-auth-rejection-forbidden")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL+"/admin", WithHeader("X-Api-Key", "k"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = client.Get(context.Background(), server.URL+"/admin?page=2", WithHeader("X-Api-Key", "k"))
+	assert.True(t, IsAuthRejectedError(err))
+
+	// 403 is specific to the endpoint
+	resp, err = client.Get(context.Background(), server.URL+"/users", WithHeader("X-Api-Key", "k"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestAuthRejections_CredentialHash(t *testing.T) {
+	t.Parallel()
+	rejections := newAuthRejections(time.Minute, DefaultCredentialHeaders)
+	assert.Nil(t, newAuthRejections(0, DefaultCredentialHeaders))
+
+	first, _ := http.NewRequest(http.MethodGet, "http://api.example.com", nil)
+	first.Header.Set("Authorization", "Bearer a")
+	second := first.Clone(context.Background())
+	second.Header.Set("Accept", "application/json")
+	third := first.Clone(context.Background())
+	third.Header.Set("Authorization", "Bearer b")
+
+	assert.Equal(t, rejections.credentialHash(first), rejections.credentialHash(second))
+	assert.NotEqual(t, rejections.credentialHash(first), rejections.credentialHash(third))
+	assert.NotContains(t, rejections.credentialHash(first), "Bearer")
+}
@@ -0,0 +1,39 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+)
+
+// defaultRetryDrainLimit is the number of body bytes read from a discarded response
+// before closing it.
+const defaultRetryDrainLimit = 64 << 10
+
+// DiscardBody reads up to 64KB of the response body and closes it, so the connection
+// returns to the pool instead of being closed. Use it for responses whose body isn't needed,
+// e.g. error responses; longer bodies are closed without reading the rest.
+func DiscardBody(resp *http.Response) {
+	discardBody(resp, defaultRetryDrainLimit)
+}
+
+// discardBody reads up to limit bytes of the response body and closes it.
+// A non-positive limit closes the body without reading it.
+func discardBody(resp *http.Response, limit int64) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	if limit > 0 {
+		_, _ = io.CopyN(io.Discard, resp.Body, limit)
+	}
+	_ = resp.Body.Close()
+}
+
+// drainAndClose reads up to 64KB of the remaining body so the connection can be reused,
+// then closes it. Longer bodies are closed without reading the rest.
+func drainAndClose(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	_, _ = io.CopyN(io.Discard, body, defaultRetryDrainLimit)
+	_ = body.Close()
+}
@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingServer returns a server failing the first request with a 503 body and
+// the number of connections it accepted.
+func countingServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests, conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, strings.Repeat("unavailable ", 100))
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server, &conns
+}
+
+func TestRetryDrainLimit_ReusesConnection(t *testing.T) {
+	t.Parallel()
+	for name, tt := range map[string]struct {
+		limit int64
+		conns int32
+	}{
+		"default drains":  {limit: 0, conns: 1},
+		"negative closes": {limit: -1, conns: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			server, conns := countingServer(t)
+			client := New(Config{
+				RetryEnabled:    true,
+				RetryConfig:     RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+				RetryDrainLimit: tt.limit,
+			}, "test-retry-drain")
+			defer client.Close()
+
+			resp, err := client.Get(context.Background(), server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, "ok", readBody(t, resp))
+			assert.Equal(t, tt.conns, atomic.LoadInt32(conns))
+		})
+	}
+}
+
+func TestDiscardBody(t *testing.T) {
+	t.Parallel()
+	server, conns := countingServer(t)
+	client := New(Config{}, "test-discard-body")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	DiscardBody(resp)
+	DiscardBody(nil)
+
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", readBody(t, resp))
+	assert.Equal(t, int32(1), atomic.LoadInt32(conns))
+}
+
+// endlessBody is a body that never ends, recording the bytes read and whether it was closed.
+type endlessBody struct {
+	read   int64
+	closed bool
+}
+
+func (b *endlessBody) Read(p []byte) (int, error) {
+	b.read += int64(len(p))
+	return len(p), nil
+}
+
+func (b *endlessBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestDrainAndClose_Limit(t *testing.T) {
+	t.Parallel()
+	body := &endlessBody{}
+	drainAndClose(body)
+	assert.True(t, body.closed)
+	assert.LessOrEqual(t, body.read, int64(defaultRetryDrainLimit))
+	drainAndClose(nil)
+}
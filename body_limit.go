@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+)
+
+// limitedBody wraps a response body and fails with BodyTooLargeError once more than
+// limit bytes have been read. The limit applies to the bytes handed to the caller,
+// i.e. after transparent decompression, which also guards against gzip bombs.
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+	url       string
+	exceeded  bool
+}
+
+// newLimitedBody wraps resp.Body with the given size limit.
+func newLimitedBody(resp *http.Response, limit int64) *limitedBody {
+	body := &limitedBody{
+		ReadCloser: resp.Body,
+		limit:      limit,
+		remaining:  limit,
+	}
+	if resp.Request != nil {
+		body.url = resp.Request.URL.String()
+	}
+
+	// Fail fast when the server declares a body larger than allowed
+	body.exceeded = resp.ContentLength > limit
+
+	return body
+}
+
+// Read implements io.Reader, enforcing the size limit.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, b.tooLarge()
+	}
+
+	if b.remaining <= 0 {
+		// Probe for one more byte to distinguish "exactly at limit" from "over limit"
+		var probe [1]byte
+		n, err := b.ReadCloser.Read(probe[:])
+		if n > 0 {
+			b.exceeded = true
+			return 0, b.tooLarge()
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// tooLarge builds the error returned once the limit is exceeded.
+func (b *limitedBody) tooLarge() error {
+	return &BodyTooLargeError{Limit: b.limit, URL: b.url}
+}
+
+// limitResponseBody applies Config.MaxResponseBodyBytes to the response returned to the caller.
+func (rt *RoundTripper) limitResponseBody(resp *http.Response) {
+	limit := rt.config.MaxResponseBodyBytes
+	if limit <= 0 || resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+	resp.Body = newLimitedBody(resp, limit)
+}
@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxResponseBodyBytes_WithinLimit(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	client := New(Config{MaxResponseBodyBytes: 10}, "test-body-limit-ok")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(body))
+}
+
+func TestMaxResponseBodyBytes_Exceeded(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// Flush to force chunked encoding without Content-Length
+		_, _ = w.Write([]byte(strings.Repeat("a", 8)))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte(strings.Repeat("b", 8)))
+	}))
+	defer server.Close()
+
+	client := New(Config{MaxResponseBodyBytes: 10}, "test-body-limit-exceeded")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.Error(t, err)
+	assert.True(t, IsBodyTooLargeError(err))
+	assert.Len(t, body, 10)
+
+	var tooLarge *BodyTooLargeError
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, int64(10), tooLarge.Limit)
+	assert.Equal(t, server.URL, tooLarge.URL)
+}
+
+func TestMaxResponseBodyBytes_DeclaredContentLength(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	client := New(Config{MaxResponseBodyBytes: 50}, "test-body-limit-declared")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.True(t, IsBodyTooLargeError(err))
+	assert.Empty(t, body)
+}
+
+func TestMaxResponseBodyBytes_GzipBomb(t *testing.T) {
+	t.Parallel()
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = gz.Write(make([]byte, 1<<20))
+	_ = gz.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	client := New(Config{MaxResponseBodyBytes: 64 * 1024}, "test-body-limit-gzip")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.True(t, IsBodyTooLargeError(err))
+	assert.LessOrEqual(t, len(body), 64*1024)
+}
@@ -0,0 +1,551 @@
+package httpclient
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default cache settings.
+const (
+	defaultCacheMaxEntries   = 1000
+	defaultCacheMaxBodyBytes = 1 << 20
+)
+
+// CacheStatusHeader is set on responses passing through CacheMiddleware.
+const CacheStatusHeader = "X-Cache"
+
+// Values of CacheStatusHeader.
+const (
+	CacheStatusHit   = "HIT"   // fresh cached response
+	CacheStatusMiss  = "MISS"  // response fetched from upstream
+	CacheStatusStale = "STALE" // stale response served while revalidating or instead of an error
+	// CacheStatusRevalidated marks a cached response confirmed by a 304 Not Modified from upstream
+	CacheStatusRevalidated = "REVALIDATED"
+)
+
+// CachedResponse is a stored response.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	StoredAt   time.Time
+
+	// TTL is how long the response stays fresh
+	TTL time.Duration
+
+	// StaleWhileRevalidate and StaleIfError extend usability of a stale response
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+
+	// VaryHeaders holds request header values listed in the response Vary header
+	VaryHeaders map[string]string
+}
+
+// CacheStore stores cached responses. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+	Delete(key string)
+}
+
+// CacheConfig contains response cache settings.
+type CacheConfig struct {
+	// Store keeps cached responses (default: in-memory LRU with 1000 entries)
+	Store CacheStore
+
+	// DefaultTTL is the freshness lifetime of responses without Cache-Control max-age.
+	// Zero means such responses are not cached
+	DefaultTTL time.Duration
+
+	// StaleWhileRevalidate serves a stale response for this long after it expires while
+	// a background request refreshes it. The response directive of the same name overrides it
+	StaleWhileRevalidate time.Duration
+
+	// StaleIfError serves a stale response for this long after it expires when upstream
+	// fails with an error or 5xx status, e.g. while the circuit breaker is open.
+	// The response directive of the same name overrides it
+	StaleIfError time.Duration
+
+	// MaxBodyBytes is the largest response body that is cached (default: 1MB)
+	MaxBodyBytes int64
+
+	// Clock is the source of time for freshness, e.g. the Config.Clock of the client (default: real time)
+	Clock Clock
+}
+
+// withDefaults applies default values to the cache configuration.
+func (cc CacheConfig) withDefaults() CacheConfig {
+	if cc.Store == nil {
+		cc.Store = NewMemoryCacheStore(defaultCacheMaxEntries)
+	}
+
+	if cc.MaxBodyBytes <= 0 {
+		cc.MaxBodyBytes = defaultCacheMaxBodyBytes
+	}
+
+	return cc
+}
+
+// CacheMiddleware caches successful GET responses following Cache-Control, with
+// stale-while-revalidate and stale-if-error support (RFC 5861). Expired responses with an
+// ETag or Last-Modified header are revalidated with a conditional request, and fresh ones
+// answer the conditional requests of callers (WithIfNoneMatch, WithIfModifiedSince) with
+// 304 Not Modified.
+type CacheMiddleware struct {
+	config CacheConfig
+	clock  Clock
+
+	mu           sync.Mutex
+	revalidating map[string]struct{}
+}
+
+// NewCacheMiddleware creates a response cache middleware.
+func NewCacheMiddleware(config CacheConfig) *CacheMiddleware {
+	return &CacheMiddleware{
+		config:       config.withDefaults(),
+		clock:        clockOrDefault(config.Clock),
+		revalidating: make(map[string]struct{}),
+	}
+}
+
+// Process implements the Middleware interface.
+func (m *CacheMiddleware) Process(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	if req.Method != http.MethodGet || !isCacheableRequest(req) {
+		return next(req)
+	}
+
+	reqDirectives := parseCacheControl(req.Header.Get("Cache-Control"))
+	if _, ok := reqDirectives["no-store"]; ok {
+		return next(req)
+	}
+
+	key := cacheKey(req)
+	entry, found := m.config.Store.Get(key)
+	if found && !entry.matchesVary(req) {
+		found = false
+	}
+	if _, ok := reqDirectives["no-cache"]; ok {
+		found = false
+	}
+
+	if found {
+		age := m.clock.Now().Sub(entry.StoredAt)
+		switch {
+		case age < entry.TTL && entry.notModified(req):
+			return entry.notModifiedResponse(req), nil
+		case age < entry.TTL:
+			return entry.response(req, CacheStatusHit), nil
+		case age < entry.TTL+entry.StaleWhileRevalidate:
+			m.revalidate(key, req, next, entry)
+			return entry.response(req, CacheStatusStale), nil
+		}
+	}
+
+	upstream, validating := req, false
+	if found {
+		upstream, validating = entry.conditionalRequest(req)
+	}
+	resp, err := next(upstream)
+	if validating && err == nil && resp.StatusCode == http.StatusNotModified {
+		drainAndClose(resp.Body)
+		return m.refresh(key, entry, resp).response(req, CacheStatusRevalidated), nil
+	}
+	if found && m.canServeStaleOnError(entry, resp, err) {
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+		return entry.response(req, CacheStatusStale), nil
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	return m.store(key, req, resp), nil
+}
+
+// isCacheableRequest reports whether the response to the request may come from or go to the
+// cache: responses to authenticated requests belong to one caller, and ranged requests expect
+// a part of the resource, not a cached full response.
+func isCacheableRequest(req *http.Request) bool {
+	for _, name := range []string{"Authorization", "Range", "If-Range"} {
+		if req.Header.Get(name) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// canServeStaleOnError reports whether a stale entry replaces a failed upstream response.
+func (m *CacheMiddleware) canServeStaleOnError(entry *CachedResponse, resp *http.Response, err error) bool {
+	if err == nil && resp.StatusCode < http.StatusInternalServerError {
+		return false
+	}
+	return m.clock.Now().Sub(entry.StoredAt) < entry.TTL+entry.StaleIfError
+}
+
+// revalidate refreshes the entry in the background, at most once per key at a time.
+func (m *CacheMiddleware) revalidate(
+	key string,
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+	entry *CachedResponse,
+) {
+	m.mu.Lock()
+	if _, running := m.revalidating[key]; running {
+		m.mu.Unlock()
+		return
+	}
+	m.revalidating[key] = struct{}{}
+	m.mu.Unlock()
+
+	// The caller may cancel its context as soon as it gets the stale response
+	bgReq := req.Clone(context.WithoutCancel(req.Context()))
+	go func() {
+		defer func() {
+			m.mu.Lock()
+			delete(m.revalidating, key)
+			m.mu.Unlock()
+		}()
+
+		upstream, validating := entry.conditionalRequest(bgReq)
+		resp, err := next(upstream)
+		if err != nil {
+			return
+		}
+		if validating && resp.StatusCode == http.StatusNotModified {
+			drainAndClose(resp.Body)
+			m.refresh(key, entry, resp)
+			return
+		}
+		resp = m.store(key, bgReq, resp)
+		drainAndClose(resp.Body)
+	}()
+}
+
+// store caches a cacheable response and returns it with a replayable body.
+func (m *CacheMiddleware) store(key string, req *http.Request, resp *http.Response) *http.Response {
+	ttl, swr, sie, ok := m.freshness(resp)
+	if !ok || resp.ContentLength > m.config.MaxBodyBytes {
+		setCacheStatus(resp, CacheStatusMiss)
+		return resp
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, m.config.MaxBodyBytes+1))
+	if err != nil || int64(len(data)) > m.config.MaxBodyBytes {
+		// Too large or broken: hand back what was read followed by the rest
+		resp.Body = &replayBody{
+			Reader: io.MultiReader(bytes.NewReader(data), resp.Body),
+			closer: resp.Body,
+		}
+		setCacheStatus(resp, CacheStatusMiss)
+		return resp
+	}
+	_ = resp.Body.Close()
+
+	entry := &CachedResponse{
+		StatusCode:           resp.StatusCode,
+		Header:               resp.Header.Clone(),
+		Body:                 data,
+		StoredAt:             m.clock.Now(),
+		TTL:                  ttl,
+		StaleWhileRevalidate: swr,
+		StaleIfError:         sie,
+		VaryHeaders:          varyHeaders(req, resp),
+	}
+	m.config.Store.Set(key, entry)
+
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	setCacheStatus(resp, CacheStatusMiss)
+	return resp
+}
+
+// refresh stores a copy of the entry confirmed by a 304 response, updated with its headers,
+// and returns it. An entry the 304 response makes uncacheable is removed.
+func (m *CacheMiddleware) refresh(key string, entry *CachedResponse, notModified *http.Response) *CachedResponse {
+	refreshed := *entry
+	refreshed.Header = entry.Header.Clone()
+	for name, values := range notModified.Header {
+		if name == "Content-Length" || name == "Content-Type" {
+			continue
+		}
+		refreshed.Header[name] = values
+	}
+
+	ttl, swr, sie, ok := m.freshness(&http.Response{StatusCode: http.StatusOK, Header: refreshed.Header})
+	if !ok {
+		m.config.Store.Delete(key)
+		return &refreshed
+	}
+	refreshed.StoredAt = m.clock.Now()
+	refreshed.TTL, refreshed.StaleWhileRevalidate, refreshed.StaleIfError = ttl, swr, sie
+	m.config.Store.Set(key, &refreshed)
+	return &refreshed
+}
+
+// freshness returns the cache lifetimes of a response and whether it can be cached.
+func (m *CacheMiddleware) freshness(resp *http.Response) (ttl, swr, sie time.Duration, ok bool) {
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Vary") == "*" {
+		return 0, 0, 0, false
+	}
+
+	directives := parseCacheControl(resp.Header.Get("Cache-Control"))
+	// The cache is shared by every caller of the client, so private responses are not stored
+	for _, name := range []string{"no-store", "no-cache", "private"} {
+		if _, found := directives[name]; found {
+			return 0, 0, 0, false
+		}
+	}
+
+	ttl = m.config.DefaultTTL
+	if maxAge, found := directiveSeconds(directives, "max-age"); found {
+		ttl = maxAge
+	}
+	if ttl <= 0 {
+		return 0, 0, 0, false
+	}
+
+	swr = m.config.StaleWhileRevalidate
+	if value, found := directiveSeconds(directives, "stale-while-revalidate"); found {
+		swr = value
+	}
+	sie = m.config.StaleIfError
+	if value, found := directiveSeconds(directives, "stale-if-error"); found {
+		sie = value
+	}
+	return ttl, swr, sie, true
+}
+
+// response builds an *http.Response from the cached entry.
+func (e *CachedResponse) response(req *http.Request, status string) *http.Response {
+	resp := &http.Response{
+		Status:        strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+	setCacheStatus(resp, status)
+	return resp
+}
+
+// notModifiedResponse builds a 304 Not Modified response with the validators of the cached entry.
+func (e *CachedResponse) notModifiedResponse(req *http.Request) *http.Response {
+	header := make(http.Header)
+	for _, name := range []string{"Cache-Control", "Date", "ETag", "Expires", "Last-Modified", "Vary"} {
+		if values := e.Header.Values(name); len(values) > 0 {
+			header[name] = values
+		}
+	}
+	resp := &http.Response{
+		Status:     strconv.Itoa(http.StatusNotModified) + " " + http.StatusText(http.StatusNotModified),
+		StatusCode: http.StatusNotModified,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       http.NoBody,
+		Request:    req,
+	}
+	setCacheStatus(resp, CacheStatusHit)
+	return resp
+}
+
+// notModified reports whether the conditional headers of the request match the cached entry.
+func (e *CachedResponse) notModified(req *http.Request) bool {
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		return etagMatches(match, e.Header.Get("ETag"))
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(e.Header.Get("Last-Modified"))
+	return err == nil && !modified.After(since)
+}
+
+// conditionalRequest returns a copy of the request validating the entry with its ETag and
+// Last-Modified, and whether it does. Requests with their own conditional headers are unchanged.
+func (e *CachedResponse) conditionalRequest(req *http.Request) (*http.Request, bool) {
+	etag, lastModified := e.Header.Get("ETag"), e.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" ||
+		req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return req, false
+	}
+	req = req.Clone(req.Context())
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	return req, true
+}
+
+// etagMatches reports whether the If-None-Match header matches the ETag using the weak
+// comparison of RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesVary reports whether the request has the same Vary header values as the cached one.
+func (e *CachedResponse) matchesVary(req *http.Request) bool {
+	for name, value := range e.VaryHeaders {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// varyHeaders records request header values named by the response Vary header.
+func varyHeaders(req *http.Request, resp *http.Response) map[string]string {
+	var values map[string]string
+	for _, vary := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]string)
+			}
+			values[name] = req.Header.Get(name)
+		}
+	}
+	return values
+}
+
+// setCacheStatus marks the response with the cache outcome.
+func setCacheStatus(resp *http.Response, status string) {
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	resp.Header.Set(CacheStatusHeader, status)
+}
+
+// cacheKey identifies cached responses by method and URL.
+func cacheKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String()
+}
+
+// parseCacheControl parses a Cache-Control header into lowercased directives.
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return directives
+}
+
+// directiveSeconds returns a Cache-Control directive value in seconds as a duration.
+func directiveSeconds(directives map[string]string, name string) (time.Duration, bool) {
+	value, found := directives[name]
+	if !found {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// MemoryCacheStore is an in-memory LRU CacheStore.
+type MemoryCacheStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+// memoryCacheItem is an element of the LRU list.
+type memoryCacheItem struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewMemoryCacheStore creates an in-memory LRU store with at most maxEntries responses.
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &MemoryCacheStore{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached response for key.
+func (s *MemoryCacheStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*memoryCacheItem).resp, true
+}
+
+// Set stores the response, evicting the least recently used entry when full.
+func (s *MemoryCacheStore) Set(key string, resp *CachedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		elem.Value.(*memoryCacheItem).resp = resp
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	s.items[key] = s.order.PushFront(&memoryCacheItem{key: key, resp: resp})
+	if s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+// Delete removes the response for key.
+func (s *MemoryCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.order.Remove(elem)
+		delete(s.items, key)
+	}
+}
@@ -0,0 +1,293 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cachingTestClient builds a client with a cache middleware driven by a fake clock.
+func cachingTestClient(t *testing.T, config CacheConfig) (*Client, *FakeClock) {
+	t.Helper()
+	clock := NewFakeClock(time.Now())
+	config.Clock = clock
+	cache := NewCacheMiddleware(config)
+
+	client := New(Config{Middlewares: []Middleware{cache}}, "test-cache")
+	t.Cleanup(func() { client.Close() })
+	return client, clock
+}
+
+// getBody executes a GET and returns the cache status and body.
+func getBody(t *testing.T, client *Client, url string) (string, string, int) {
+	t.Helper()
+	resp, err := client.Get(context.Background(), url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.Header.Get(CacheStatusHeader), string(body), resp.StatusCode
+}
+
+func TestCacheMiddleware_HitAndExpiry(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = fmt.Fprintf(w, "v%d", n)
+	}))
+	defer server.Close()
+
+	client, clock := cachingTestClient(t, CacheConfig{})
+
+	status, body, _ := getBody(t, client, server.URL)
+	assert.Equal(t, CacheStatusMiss, status)
+	assert.Equal(t, "v1", body)
+
+	status, body, _ = getBody(t, client, server.URL)
+	assert.Equal(t, CacheStatusHit, status)
+	assert.Equal(t, "v1", body)
+
+	clock.Advance(61 * time.Second)
+	status, body, _ = getBody(t, client, server.URL)
+	assert.Equal(t, CacheStatusMiss, status)
+	assert.Equal(t, "v2", body)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheMiddleware_StaleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	refreshed := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=30")
+		_, _ = fmt.Fprintf(w, "v%d", n)
+		if n == 2 {
+			refreshed <- struct{}{}
+		}
+	}))
+	defer server.Close()
+
+	client, clock := cachingTestClient(t, CacheConfig{})
+	getBody(t, client, server.URL)
+
+	// Expired but within the revalidation window: stale data now, refresh in background
+	clock.Advance(15 * time.Second)
+	status, body, _ := getBody(t, client, server.URL)
+	assert.Equal(t, CacheStatusStale, status)
+	assert.Equal(t, "v1", body)
+
+	select {
+	case <-refreshed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("background revalidation did not happen")
+	}
+
+	require.Eventually(t, func() bool {
+		status, body, _ = getBody(t, client, server.URL)
+		return status == CacheStatusHit && body == "v2"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestCacheMiddleware_RevalidatesWithETag(t *testing.T) {
+	t.Parallel()
+	var calls, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=10")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = io.WriteString(w, "payload")
+	}))
+	defer server.Close()
+
+	client, clock := cachingTestClient(t, CacheConfig{})
+	getBody(t, client, server.URL)
+
+	clock.Advance(15 * time.Second)
+	status, body, code := getBody(t, client, server.URL)
+	assert.Equal(t, CacheStatusRevalidated, status)
+	assert.Equal(t, "payload", body)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+
+	// The 304 response renewed the freshness lifetime
+	clock.Advance(5 * time.Second)
+	status, _, _ = getBody(t, client, server.URL)
+	assert.Equal(t, CacheStatusHit, status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCacheMiddleware_StaleIfError(t *testing.T) {
+	t.Parallel()
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("good"))
+	}))
+	defer server.Close()
+
+	client, clock := cachingTestClient(t, CacheConfig{DefaultTTL: 10 * time.Second, StaleIfError: time.Minute})
+	getBody(t, client, server.URL)
+
+	failing.Store(true)
+	clock.Advance(30 * time.Second)
+	status, body, code := getBody(t, client, server.URL)
+	assert.Equal(t, CacheStatusStale, status)
+	assert.Equal(t, "good", body)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Beyond the stale-if-error window the upstream error is returned
+	clock.Advance(time.Minute)
+	_, _, code = getBody(t, client, server.URL)
+	assert.Equal(t, http.StatusBadGateway, code)
+}
+
+func TestCacheMiddleware_StaleIfErrorOnTransportError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("cached"))
+	}))
+	url := server.URL
+
+	client, clock := cachingTestClient(t, CacheConfig{DefaultTTL: time.Second, StaleIfError: time.Minute})
+	getBody(t, client, url)
+
+	server.Close()
+	clock.Advance(5 * time.Second)
+	status, body, _ := getBody(t, client, url)
+	assert.Equal(t, CacheStatusStale, status)
+	assert.Equal(t, "cached", body)
+}
+
+func TestCacheMiddleware_RespectsNoStoreAndVary(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "no-store")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	client, _ := cachingTestClient(t, CacheConfig{})
+
+	getBody(t, client, server.URL+"/private")
+	status, _, _ := getBody(t, client, server.URL+"/private")
+	assert.Equal(t, CacheStatusMiss, status)
+
+	get := func(lang string) (string, string) {
+		resp, err := client.Get(context.Background(), server.URL+"/page", WithHeader("Accept-Language", lang))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.Header.Get(CacheStatusHeader), string(body)
+	}
+
+	_, body := get("en")
+	assert.Equal(t, "en", body)
+	status, body = get("ru")
+	assert.Equal(t, CacheStatusMiss, status)
+	assert.Equal(t, "ru", body)
+	status, body = get("ru")
+	assert.Equal(t, CacheStatusHit, status)
+	assert.Equal(t, "ru", body)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestCacheMiddleware_SkipsPrivateAuthenticatedAndRangedRequests(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		}
+		_, _ = fmt.Fprintf(w, "%s%s", r.Header.Get("Authorization"), r.Header.Get("Range"))
+	}))
+	defer server.Close()
+
+	client, _ := cachingTestClient(t, CacheConfig{DefaultTTL: time.Minute})
+	get := func(path string, opts ...RequestOption) (string, string) {
+		resp, err := client.Get(context.Background(), server.URL+path, opts...)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.Header.Get(CacheStatusHeader), string(body)
+	}
+
+	getBody(t, client, server.URL+"/private")
+	status, _, _ := getBody(t, client, server.URL+"/private")
+	assert.Equal(t, CacheStatusMiss, status)
+
+	// A cached public response is not served to authenticated or ranged requests,
+	// and their responses are not stored
+	getBody(t, client, server.URL+"/shared")
+	_, body := get("/shared", WithBearerToken("alice"))
+	assert.Equal(t, "Bearer alice", body)
+	_, body = get("/shared", WithBearerToken("bob"))
+	assert.Equal(t, "Bearer bob", body)
+	_, body = get("/shared", WithHeader("Range", "bytes=0-9"))
+	assert.Equal(t, "bytes=0-9", body)
+	status, body = get("/shared")
+	assert.Equal(t, CacheStatusHit, status)
+	assert.Empty(t, body)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+}
+
+func TestMemoryCacheStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	store := NewMemoryCacheStore(2)
+	store.Set("a", &CachedResponse{StatusCode: 1})
+	store.Set("b", &CachedResponse{StatusCode: 2})
+
+	_, ok := store.Get("a")
+	require.True(t, ok)
+
+	store.Set("c", &CachedResponse{StatusCode: 3})
+	_, ok = store.Get("b")
+	assert.False(t, ok, "b was least recently used")
+	_, ok = store.Get("a")
+	assert.True(t, ok)
+
+	store.Delete("a")
+	_, ok = store.Get("a")
+	assert.False(t, ok)
+}
+
+func TestParseCacheControl(t *testing.T) {
+	t.Parallel()
+	directives := parseCacheControl(`public, Max-Age=120, stale-if-error="600"`)
+	maxAge, ok := directiveSeconds(directives, "max-age")
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Minute, maxAge)
+
+	sie, ok := directiveSeconds(directives, "stale-if-error")
+	require.True(t, ok)
+	assert.Equal(t, 10*time.Minute, sie)
+
+	_, ok = directiveSeconds(directives, "s-maxage")
+	assert.False(t, ok)
+}
@@ -0,0 +1,179 @@
+package httpclient
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Canonicalize returns the canonical form of a request shared by request signers and
+// signature validators:
+//
+//	METHOD
+//	/normalized/path
+//	sorted=query&with=duplicates
+//	name:value        (one line per signed header)
+//	                  (empty line)
+//	name;other-name   (signed header names)
+//	hex(SHA-256(body))
+//
+// The path has dot segments resolved and is percent-encoded as in RFC 3986: unreserved
+// characters are never encoded and everything else always is, with uppercase hex digits.
+// Empty segments are kept. Query parameters are encoded the same way and sorted bytewise
+// by encoded key and then by encoded value, so duplicate keys in any order give the same
+// form; "+" in the query means a space. Signed header names are lowercased and sorted;
+// their values are trimmed, inner whitespace is collapsed and repeated headers are
+// joined with ",". The "host" header comes from req.Host or the URL. Missing headers
+// have empty values.
+//
+// The body is read through req.GetBody when set, or buffered and restored otherwise, so
+// the request stays sendable and the result is the same for every retry attempt and for
+// redirects replaying the body.
+func Canonicalize(req *http.Request, signedHeaders ...string) ([]byte, error) {
+	path, err := canonicalPath(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	query, err := canonicalQuery(req.URL.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readBodyForSigning(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	names := make([]string, 0, len(signedHeaders))
+	for _, name := range signedHeaders {
+		names = append(names, strings.ToLower(strings.TrimSpace(name)))
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	var buf bytes.Buffer
+	buf.WriteString(strings.ToUpper(req.Method))
+	buf.WriteByte('\n')
+	buf.WriteString(path)
+	buf.WriteByte('\n')
+	buf.WriteString(query)
+	buf.WriteByte('\n')
+	for _, name := range names {
+		buf.WriteString(name)
+		buf.WriteByte(':')
+		buf.WriteString(canonicalHeaderValue(req, name))
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	buf.WriteString(strings.Join(names, ";"))
+	buf.WriteByte('\n')
+	sum := sha256.Sum256(body)
+	buf.WriteString(hex.EncodeToString(sum[:]))
+	return buf.Bytes(), nil
+}
+
+// canonicalPath returns the escaped path with dot segments removed.
+func canonicalPath(u *url.URL) (string, error) {
+	escaped := u.EscapedPath()
+	if escaped == "" {
+		return "/", nil
+	}
+
+	segments := strings.Split(strings.TrimPrefix(escaped, "/"), "/")
+	out := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", fmt.Errorf("invalid request path %q: %w", escaped, err)
+		}
+		last := i == len(segments)-1
+		switch decoded {
+		case ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, rfc3986Escape(decoded))
+		}
+	}
+	return "/" + strings.Join(out, "/"), nil
+}
+
+// canonicalQuery returns the query parameters sorted by key and value.
+func canonicalQuery(rawQuery string) (string, error) {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("invalid request query %q: %w", rawQuery, err)
+	}
+
+	type pair struct{ key, value string }
+	pairs := make([]pair, 0, len(values))
+	for key, list := range values {
+		for _, value := range list {
+			pairs = append(pairs, pair{rfc3986Escape(key), rfc3986Escape(value)})
+		}
+	}
+	slices.SortFunc(pairs, func(a, b pair) int {
+		return cmp.Or(strings.Compare(a.key, b.key), strings.Compare(a.value, b.value))
+	})
+
+	var b strings.Builder
+	for i, p := range pairs {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(p.key)
+		b.WriteByte('=')
+		b.WriteString(p.value)
+	}
+	return b.String(), nil
+}
+
+// canonicalHeaderValue returns the trimmed values of the header joined with ",".
+func canonicalHeaderValue(req *http.Request, name string) string {
+	if name == "host" {
+		host := req.Host
+		if host == "" {
+			host = req.URL.Host
+		}
+		return strings.ToLower(host)
+	}
+
+	values := req.Header.Values(name)
+	normalized := make([]string, len(values))
+	for i, value := range values {
+		normalized[i] = strings.Join(strings.Fields(value), " ")
+	}
+	return strings.Join(normalized, ",")
+}
+
+// rfc3986Escape percent-encodes everything but the unreserved characters of RFC 3986.
+func rfc3986Escape(s string) string {
+	const upperHex = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(upperHex[c>>4])
+		b.WriteByte(upperHex[c&15])
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// canonicalVector is a test vector of testdata/canonicalize.json, shared with signers in other languages.
+type canonicalVector struct {
+	Name          string              `json:"name"`
+	Method        string              `json:"method"`
+	URL           string              `json:"url"`
+	Headers       map[string][]string `json:"headers"`
+	Body          string              `json:"body"`
+	SignedHeaders []string            `json:"signed_headers"`
+	Canonical     string              `json:"canonical"`
+}
+
+func TestCanonicalize_Vectors(t *testing.T) {
+	t.Parallel()
+	data, err := os.ReadFile("testdata/canonicalize.json")
+	require.NoError(t, err)
+	var vectors []canonicalVector
+	require.NoError(t, json.Unmarshal(data, &vectors))
+	require.NotEmpty(t, vectors)
+
+	for _, v := range vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			req, err := http.NewRequest(v.Method, v.URL, strings.NewReader(v.Body))
+			require.NoError(t, err)
+			for name, values := range v.Headers {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+
+			got, err := Canonicalize(req, v.SignedHeaders...)
+			require.NoError(t, err)
+			assert.Equal(t, v.Canonical, string(got))
+		})
+	}
+}
+
+func TestCanonicalize_DuplicateQueryKeyOrder(t *testing.T) {
+	t.Parallel()
+	first := httptest.NewRequest(http.MethodGet, "http://example.com/?id=2&id=10&id=1&x=%41", nil)
+	second := httptest.NewRequest(http.MethodGet, "http://example.com/?x=A&id=1&id=2&id=10", nil)
+
+	a, err := Canonicalize(first)
+	require.NoError(t, err)
+	b, err := Canonicalize(second)
+	require.NoError(t, err)
+	assert.Equal(t, string(a), string(b))
+	assert.Contains(t, string(a), "\nid=1&id=10&id=2&x=A\n")
+}
+
+func TestCanonicalize_Errors(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	req.URL.RawQuery = "a=%zz"
+	_, err := Canonicalize(req)
+	assert.ErrorContains(t, err, "invalid request query")
+}
+
+func TestCanonicalize_StableAcrossRetries(t *testing.T) {
+	t.Parallel()
+	var calls int
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var forms []string
+	config := retryBodyConfig()
+	config.AttemptMiddlewares = []AttemptMiddleware{AttemptMiddlewareFunc(func(req *http.Request, _ int) error {
+		form, err := Canonicalize(req, "host", "content-type")
+		forms = append(forms, string(form))
+		return err
+	})}
+	client := New(config, "test-canonicalize-retries")
+	defer client.Close()
+
+	// An opaque reader has no GetBody, so the body is buffered before signing
+	body := &opaqueReader{strings.NewReader(`{"id":1}`)}
+	resp, err := client.Put(context.Background(), server.URL+"/items?b=2&a=1", body, WithContentType("application/json"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	require.Len(t, forms, 2)
+	assert.Equal(t, forms[0], forms[1])
+	assert.Contains(t, forms[0], "\n/items\na=1&b=2\ncontent-type:application/json\n")
+	assert.Equal(t, []string{`{"id":1}`, `{"id":1}`}, bodies)
+}
@@ -0,0 +1,236 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/md5" //nolint:gosec // MD5 is only used to verify Content-MD5 and Digest headers
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ChecksumAlgorithm is a hash algorithm for WithExpectedChecksum, named as in RFC 9530.
+type ChecksumAlgorithm string
+
+// Supported checksum algorithms.
+const (
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+	ChecksumSHA256 ChecksumAlgorithm = "sha-256"
+	ChecksumSHA512 ChecksumAlgorithm = "sha-512"
+)
+
+// newHash returns a new hash of the algorithm, or nil for unknown algorithms.
+func (a ChecksumAlgorithm) newHash() hash.Hash {
+	switch a {
+	case ChecksumMD5:
+		return md5.New() //nolint:gosec // see import
+	case ChecksumSHA256:
+		return sha256.New()
+	case ChecksumSHA512:
+		return sha512.New()
+	default:
+		return nil
+	}
+}
+
+// expectedChecksum is the checksum a response body must match.
+type expectedChecksum struct {
+	algorithm ChecksumAlgorithm
+	sum       []byte
+	// err is set when the expected value can't be decoded
+	err error
+}
+
+// newExpectedChecksum decodes the hex or base64 value for the algorithm.
+func newExpectedChecksum(algorithm ChecksumAlgorithm, value string) *expectedChecksum {
+	algorithm = ChecksumAlgorithm(strings.ToLower(string(algorithm)))
+	checksum := &expectedChecksum{algorithm: algorithm}
+	h := algorithm.newHash()
+	if h == nil {
+		checksum.err = NewConfigurationError("WithExpectedChecksum", algorithm, "is not a supported checksum algorithm")
+		return checksum
+	}
+	if sum, err := hex.DecodeString(value); err == nil && len(sum) == h.Size() {
+		checksum.sum = sum
+		return checksum
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding} {
+		if sum, err := encoding.DecodeString(value); err == nil && len(sum) == h.Size() {
+			checksum.sum = sum
+			return checksum
+		}
+	}
+	checksum.err = NewConfigurationError("WithExpectedChecksum", value, "is not a hex or base64 "+string(algorithm)+" checksum")
+	return checksum
+}
+
+// mismatch returns the error for a body with the actual checksum, or nil if it matches.
+func (c *expectedChecksum) mismatch(actual []byte) error {
+	if bytes.Equal(actual, c.sum) {
+		return nil
+	}
+	return &ChecksumMismatchError{
+		Algorithm: string(c.algorithm),
+		Expected:  hex.EncodeToString(c.sum),
+		Actual:    hex.EncodeToString(actual),
+	}
+}
+
+// WithExpectedChecksum verifies that the response body matches the hex or base64 checksum,
+// e.g. of an artifact published with its SHA-256. Reading the body returns
+// *ChecksumMismatchError instead of io.EOF when it doesn't match. Only 2xx responses other
+// than 206 Partial Content are verified. With RetryConfig.RetryChecksumMismatch the body is
+// read and verified within each attempt, so a corrupted transfer is retried.
+func WithExpectedChecksum(algorithm ChecksumAlgorithm, value string) RequestOption {
+	checksum := newExpectedChecksum(algorithm, value)
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.checksum = checksum
+		})
+	}
+}
+
+// requestChecksum returns the checksum expected for the response of the request, or nil.
+func requestChecksum(req *http.Request) *expectedChecksum {
+	if overrides := getRequestOverrides(req.Context()); overrides != nil {
+		return overrides.checksum
+	}
+	return nil
+}
+
+// isChecksummedStatus checks if the response carries the full representation.
+func isChecksummedStatus(status int) bool {
+	return status >= 200 && status < 300 && status != http.StatusPartialContent
+}
+
+// checksumBody hashes the response body and verifies the checksum at EOF.
+type checksumBody struct {
+	io.ReadCloser
+	expected *expectedChecksum
+	hash     hash.Hash
+	err      error
+}
+
+// Read implements io.Reader, returning *ChecksumMismatchError instead of io.EOF on mismatch.
+func (b *checksumBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if mismatch := b.expected.mismatch(b.hash.Sum(nil)); mismatch != nil {
+			b.err = mismatch
+			return n, mismatch
+		}
+	}
+	return n, err
+}
+
+// verifyChecksum wraps the body of the final response to verify WithExpectedChecksum.
+func verifyChecksum(req *http.Request, resp *http.Response, verified bool) *http.Response {
+	checksum := requestChecksum(req)
+	if checksum == nil || verified || resp == nil || resp.Body == nil || !isChecksummedStatus(resp.StatusCode) {
+		return resp
+	}
+	resp.Body = &checksumBody{ReadCloser: resp.Body, expected: checksum, hash: checksum.algorithm.newHash()}
+	return resp
+}
+
+// verifyAttemptChecksum reads the body of an attempt and verifies WithExpectedChecksum, so
+// RetryConfig.RetryChecksumMismatch retries a mismatch. The verified body is kept in memory;
+// bodies longer than a positive limit fail with *BodyTooLargeError instead.
+func verifyAttemptChecksum(req *http.Request, resp *http.Response, limit int64) (*http.Response, error) {
+	checksum := requestChecksum(req)
+	if checksum == nil || !isChecksummedStatus(resp.StatusCode) {
+		return resp, nil
+	}
+	var reader io.Reader = resp.Body
+	if limit > 0 {
+		reader = newLimitedBody(resp, limit)
+	}
+	h := checksum.algorithm.newHash()
+	var body bytes.Buffer
+	_, err := io.Copy(io.MultiWriter(&body, h), reader)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := checksum.mismatch(h.Sum(nil)); err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(&body)
+	return resp, nil
+}
+
+// checksumRetried reports whether checksums are verified within attempts.
+func checksumRetried(config Config) bool {
+	return config.RetryEnabled && config.RetryConfig.RetryChecksumMismatch
+}
+
+// digestFromHeaders returns the strongest checksum announced by the Repr-Digest,
+// Content-Digest (RFC 9530), Digest (RFC 3230) or Content-MD5 headers of the response.
+func digestFromHeaders(header http.Header) *expectedChecksum {
+	var best *expectedChecksum
+	consider := func(algorithm, value string) {
+		checksum := newExpectedChecksum(ChecksumAlgorithm(algorithm), value)
+		if checksum.err != nil {
+			return
+		}
+		if best == nil || checksum.algorithm.strength() > best.algorithm.strength() {
+			best = checksum
+		}
+	}
+
+	for _, name := range []string{"Repr-Digest", "Content-Digest"} {
+		for _, field := range splitHeaderList(header.Values(name)) {
+			if algorithm, value, ok := strings.Cut(field, "="); ok {
+				consider(algorithm, strings.Trim(value, ":"))
+			}
+		}
+		if best != nil {
+			return best
+		}
+	}
+	for _, field := range splitHeaderList(header.Values("Digest")) {
+		if algorithm, value, ok := strings.Cut(field, "="); ok {
+			consider(algorithm, value)
+		}
+	}
+	if best == nil {
+		if value := header.Get("Content-MD5"); value != "" {
+			consider(string(ChecksumMD5), value)
+		}
+	}
+	return best
+}
+
+// splitHeaderList splits comma-separated header values into trimmed, non-empty elements.
+func splitHeaderList(values []string) []string {
+	var elements []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			if element = strings.TrimSpace(element); element != "" {
+				elements = append(elements, element)
+			}
+		}
+	}
+	return elements
+}
+
+// strength orders the algorithms to prefer the strongest announced checksum.
+func (a ChecksumAlgorithm) strength() int {
+	switch a {
+	case ChecksumSHA512:
+		return 3
+	case ChecksumSHA256:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,259 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // Content-MD5 test
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const checksumPayload = "artifact contents"
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func sha256Base64(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestWithExpectedChecksum(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Body: checksumPayload})
+	defer server.Close()
+
+	client := New(Config{}, "test-checksum")
+	defer client.Close()
+
+	for _, value := range []string{sha256Hex(checksumPayload), sha256Base64(checksumPayload)} {
+		resp, err := client.Get(context.Background(), server.URL, WithExpectedChecksum(ChecksumSHA256, value))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, checksumPayload, string(body))
+	}
+
+	resp, err := client.Get(context.Background(), server.URL, WithExpectedChecksum(ChecksumSHA256, sha256Hex("other")))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	var mismatch *ChecksumMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "sha-256", mismatch.Algorithm)
+	assert.Equal(t, sha256Hex("other"), mismatch.Expected)
+	assert.Equal(t, sha256Hex(checksumPayload), mismatch.Actual)
+	assert.True(t, IsChecksumMismatchError(err))
+}
+
+func TestWithExpectedChecksum_InvalidValue(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Body: checksumPayload})
+	defer server.Close()
+
+	client := New(Config{}, "test-checksum-invalid")
+	defer client.Close()
+
+	for _, opt := range []RequestOption{
+		WithExpectedChecksum(ChecksumSHA256, "not-a-checksum"),
+		WithExpectedChecksum(ChecksumAlgorithm("crc32"), "00000000"),
+	} {
+		_, err := client.Get(context.Background(), server.URL, opt)
+		var configErr *ConfigurationError
+		require.ErrorAs(t, err, &configErr)
+		assert.Equal(t, "WithExpectedChecksum", configErr.Field)
+	}
+	assert.Zero(t, server.GetRequestCount())
+}
+
+func TestWithExpectedChecksum_RetriesMismatch(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusOK, Body: "corrupted"},
+		TestResponse{StatusCode: http.StatusOK, Body: checksumPayload},
+	)
+	defer server.Close()
+
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			MaxAttempts:           2,
+			BaseDelay:             time.Millisecond,
+			MaxDelay:              time.Millisecond,
+			RetryChecksumMismatch: true,
+		},
+	}, "test-checksum-retry")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL, WithExpectedChecksum(ChecksumSHA256, sha256Hex(checksumPayload)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, checksumPayload, string(body))
+	assert.Equal(t, 2, server.GetRequestCount())
+}
+
+func TestWithExpectedChecksum_RetryLimitsBufferedBody(t *testing.T) {
+	t.Parallel()
+	large := string(bytes.Repeat([]byte("x"), 4096))
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Body: large})
+	defer server.Close()
+
+	client := New(Config{
+		MaxResponseBodyBytes: 1024,
+		RetryEnabled:         true,
+		RetryConfig: RetryConfig{
+			MaxAttempts:           2,
+			BaseDelay:             time.Millisecond,
+			MaxDelay:              time.Millisecond,
+			RetryChecksumMismatch: true,
+		},
+	}, "test-checksum-retry-limit")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL, WithExpectedChecksum(ChecksumSHA256, sha256Hex(large)))
+	assert.True(t, IsBodyTooLargeError(err))
+	assert.Equal(t, 1, server.GetRequestCount())
+}
+
+func TestGetRetryReason_Checksum(t *testing.T) {
+	t.Parallel()
+	err := &ChecksumMismatchError{Algorithm: "sha-256"}
+	assert.Equal(t, RetryReasonChecksum, getRetryReasonWithConfig(RetryConfig{RetryChecksumMismatch: true}, err, 0))
+	assert.Empty(t, getRetryReasonWithConfig(RetryConfig{}, err, 0))
+}
+
+func TestDigestFromHeaders(t *testing.T) {
+	t.Parallel()
+	sha256Sum := sha256.Sum256([]byte(checksumPayload))
+	sha512Sum := sha512.Sum512([]byte(checksumPayload))
+	md5Sum := md5.Sum([]byte(checksumPayload)) //nolint:gosec // Content-MD5 test
+	b64 := base64.StdEncoding.EncodeToString
+
+	tests := []struct {
+		name      string
+		header    http.Header
+		algorithm ChecksumAlgorithm
+		sum       []byte
+	}{
+		{
+			name:      "repr digest",
+			header:    http.Header{"Repr-Digest": {"sha-256=:" + b64(sha256Sum[:]) + ":"}},
+			algorithm: ChecksumSHA256,
+			sum:       sha256Sum[:],
+		},
+		{
+			name:      "strongest content digest",
+			header:    http.Header{"Content-Digest": {"sha-256=:" + b64(sha256Sum[:]) + ":, sha-512=:" + b64(sha512Sum[:]) + ":"}},
+			algorithm: ChecksumSHA512,
+			sum:       sha512Sum[:],
+		},
+		{
+			name:      "legacy digest",
+			header:    http.Header{"Digest": {"MD5=" + b64(md5Sum[:]) + ",SHA-256=" + b64(sha256Sum[:])}},
+			algorithm: ChecksumSHA256,
+			sum:       sha256Sum[:],
+		},
+		{
+			name:      "content md5",
+			header:    http.Header{"Content-Md5": {b64(md5Sum[:])}},
+			algorithm: ChecksumMD5,
+			sum:       md5Sum[:],
+		},
+		{
+			name:   "unsupported algorithm",
+			header: http.Header{"Digest": {"SHA=" + b64(sha256Sum[:20])}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			digest := digestFromHeaders(tt.header)
+			if tt.algorithm == "" {
+				assert.Nil(t, digest)
+				return
+			}
+			require.NotNil(t, digest)
+			assert.Equal(t, tt.algorithm, digest.algorithm)
+			assert.Equal(t, tt.sum, digest.sum)
+		})
+	}
+}
+
+func TestDownload_VerifiesDigestHeader(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		digest := sha256Base64(checksumPayload)
+		if r.URL.Query().Has("corrupted") {
+			digest = sha256Base64("other")
+		}
+		w.Header().Set("Repr-Digest", "sha-256=:"+digest+":")
+		_, _ = w.Write([]byte(checksumPayload))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-download-digest")
+	defer client.Close()
+
+	var dst bytes.Buffer
+	_, err := client.Download(context.Background(), server.URL, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, checksumPayload, dst.String())
+
+	_, err = client.Download(context.Background(), server.URL+"?corrupted", &bytes.Buffer{})
+	var mismatch *ChecksumMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "sha-256", mismatch.Algorithm)
+
+	_, err = client.Download(context.Background(), server.URL+"?corrupted", &bytes.Buffer{}, WithoutDigestVerification())
+	require.NoError(t, err)
+}
+
+func TestDownload_DigestCoversResumes(t *testing.T) {
+	t.Parallel()
+	sum := sha256.Sum256(downloadPayload)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+		if requests.Add(1) == 1 {
+			// Cut the first response in half
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", "65536")
+			_, _ = w.Write(downloadPayload[:20000])
+			w.(http.Flusher).Flush()
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		http.ServeContent(w, r, "payload.bin", time.Time{}, bytes.NewReader(downloadPayload))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-download-digest-resume")
+	defer client.Close()
+
+	var dst bytes.Buffer
+	result, err := client.Download(context.Background(), server.URL, &dst)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Resumes)
+	assert.Equal(t, downloadPayload, dst.Bytes())
+}
@@ -30,6 +30,9 @@ const (
 	CircuitBreakerHalfOpen
 )
 
+// circuitBreakerStates lists all states, e.g. to reset state gauges.
+var circuitBreakerStates = []CircuitBreakerState{CircuitBreakerClosed, CircuitBreakerOpen, CircuitBreakerHalfOpen}
+
 func (s CircuitBreakerState) String() string {
 	switch s {
 	case CircuitBreakerClosed:
@@ -56,6 +59,12 @@ type SimpleCircuitBreaker struct {
 	timeout               time.Duration
 	lastFailureTime       time.Time
 	onStateChangeCallback func(from, to CircuitBreakerState)
+	window                *rollingWindow // nil unless the error-rate strategy is used
+	listeners             map[int]func(from, to CircuitBreakerState)
+	nextListener          int
+	errorRateThreshold    float64
+	minimumRequests       int
+	clock                 Clock
 }
 
 // CircuitBreakerConfig contains configuration for a circuit breaker.
@@ -65,6 +74,12 @@ type CircuitBreakerConfig struct {
 	SuccessThreshold int           // Number of successful attempts to close from half-open state
 	Timeout          time.Duration // Wait time before transitioning to half-open state
 	OnStateChange    func(from, to CircuitBreakerState)
+	Clock            Clock // Source of time for the open timeout (default: real time)
+
+	Strategy           CircuitBreakerStrategy // How failures open the breaker (default: consecutive failures)
+	Window             time.Duration          // Rolling window of the error-rate strategy (default: 10s)
+	ErrorRateThreshold float64                // Share of failures in the window that opens the breaker, 0..1 (default: 0.5)
+	MinimumRequests    int                    // Requests in the window before the error rate is evaluated (default: 20)
 }
 
 type strictReadCloser struct {
@@ -96,7 +111,12 @@ func newStrictReadCloser(b []byte) io.ReadCloser {
 
 // NewSimpleCircuitBreaker creates a new circuit breaker with default settings.
 func NewSimpleCircuitBreaker() *SimpleCircuitBreaker {
-	return NewCircuitBreakerWithConfig(CircuitBreakerConfig{
+	return NewCircuitBreakerWithConfig(defaultCircuitBreakerConfig())
+}
+
+// defaultCircuitBreakerConfig returns the configuration of NewSimpleCircuitBreaker.
+func defaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
 		FailStatusCodes: nil,
 		OnStateChange: func(_, _ CircuitBreakerState) {
 			// Empty handler by default
@@ -104,19 +124,37 @@ func NewSimpleCircuitBreaker() *SimpleCircuitBreaker {
 		FailureThreshold: defaultFailureThreshold,
 		SuccessThreshold: defaultSuccessThreshold,
 		Timeout:          defaultCircuitTimeout,
-	})
+	}
 }
 
 // NewCircuitBreakerWithConfig creates a new circuit breaker with custom configuration.
 func NewCircuitBreakerWithConfig(config CircuitBreakerConfig) *SimpleCircuitBreaker {
-	return &SimpleCircuitBreaker{
+	cb := &SimpleCircuitBreaker{
 		state:                 CircuitBreakerClosed,
 		failStatuses:          config.FailStatusCodes,
 		failureThreshold:      config.FailureThreshold,
 		successThreshold:      config.SuccessThreshold,
 		timeout:               config.Timeout,
 		onStateChangeCallback: config.OnStateChange,
+		clock:                 clockOrDefault(config.Clock),
 	}
+
+	if config.Strategy == CircuitBreakerErrorRate {
+		if config.Window <= 0 {
+			config.Window = defaultCircuitWindow
+		}
+		if config.ErrorRateThreshold <= 0 {
+			config.ErrorRateThreshold = defaultErrorRateThreshold
+		}
+		if config.MinimumRequests <= 0 {
+			config.MinimumRequests = defaultMinimumRequests
+		}
+		cb.window = newRollingWindow(config.Window)
+		cb.errorRateThreshold = config.ErrorRateThreshold
+		cb.minimumRequests = config.MinimumRequests
+	}
+
+	return cb
 }
 
 // Execute executes a function through the circuit breaker.
@@ -200,9 +238,12 @@ func (cb *SimpleCircuitBreaker) Reset() {
 	cb.failureCount = 0
 	cb.successCount = 0
 	cb.lastFailureTime = time.Time{}
+	if cb.window != nil {
+		cb.window.reset()
+	}
 
-	if cb.onStateChangeCallback != nil && oldState != CircuitBreakerClosed {
-		cb.onStateChangeCallback(oldState, CircuitBreakerClosed)
+	if oldState != CircuitBreakerClosed {
+		cb.notifyStateChange(oldState, CircuitBreakerClosed)
 	}
 }
 
@@ -222,7 +263,7 @@ func (cb *SimpleCircuitBreaker) canExecuteAndGetLastFailResponse() (bool, *http.
 		return true, lastFailResp
 	case CircuitBreakerOpen:
 		// Check if we should transition to half-open state
-		if time.Since(cb.lastFailureTime) > cb.timeout {
+		if cb.clock.Now().Sub(cb.lastFailureTime) > cb.timeout {
 			cb.setState(CircuitBreakerHalfOpen)
 			return true, lastFailResp
 		}
@@ -290,6 +331,11 @@ func (cb *SimpleCircuitBreaker) updateStateOnResult(isSuccess bool) {
 
 // handleClosedState handles the result in Closed state.
 func (cb *SimpleCircuitBreaker) handleClosedState(isSuccess bool) {
+	now := cb.clock.Now()
+	if cb.window != nil {
+		cb.window.record(now, !isSuccess)
+	}
+
 	if isSuccess {
 		cb.failureCount = 0
 		return
@@ -297,10 +343,10 @@ func (cb *SimpleCircuitBreaker) handleClosedState(isSuccess bool) {
 
 	// Handle unsuccessful result
 	cb.failureCount++
-	cb.lastFailureTime = time.Now()
+	cb.lastFailureTime = now
 
 	// Check if we need to open the circuit breaker
-	if cb.shouldOpenCircuit() {
+	if cb.shouldOpenCircuit(now) {
 		cb.setState(CircuitBreakerOpen)
 	}
 }
@@ -329,11 +375,16 @@ func (cb *SimpleCircuitBreaker) handleFailureInHalfOpen() {
 	cb.setState(CircuitBreakerOpen)
 	cb.failureCount++
 	cb.successCount = 0
-	cb.lastFailureTime = time.Now()
+	cb.lastFailureTime = cb.clock.Now()
 }
 
 // shouldOpenCircuit determines if the circuit breaker should be opened.
-func (cb *SimpleCircuitBreaker) shouldOpenCircuit() bool {
+func (cb *SimpleCircuitBreaker) shouldOpenCircuit(now time.Time) bool {
+	if cb.window != nil {
+		successes, failures := cb.window.counts(now)
+		total := successes + failures
+		return total >= cb.minimumRequests && float64(failures) >= cb.errorRateThreshold*float64(total)
+	}
 	return cb.failureThreshold > 0 && cb.failureCount >= cb.failureThreshold
 }
 
@@ -421,8 +472,44 @@ func (cb *SimpleCircuitBreaker) setState(newState CircuitBreakerState) {
 	oldState := cb.state
 	cb.state = newState
 
-	if cb.onStateChangeCallback != nil && oldState != newState {
-		cb.onStateChangeCallback(oldState, newState)
+	// The window starts over when the breaker closes again
+	if newState == CircuitBreakerOpen && cb.window != nil {
+		cb.window.reset()
+	}
+
+	if oldState != newState {
+		cb.notifyStateChange(oldState, newState)
+	}
+}
+
+// notifyStateChange calls the OnStateChange callback and the internal listeners.
+// Must be called under mutex lock.
+func (cb *SimpleCircuitBreaker) notifyStateChange(from, to CircuitBreakerState) {
+	if cb.onStateChangeCallback != nil {
+		cb.onStateChangeCallback(from, to)
+	}
+	for _, listener := range cb.listeners {
+		listener(from, to)
+	}
+}
+
+// addStateListener registers a state change listener in addition to OnStateChange,
+// e.g. for client metrics. The returned function removes the listener.
+func (cb *SimpleCircuitBreaker) addStateListener(listener func(from, to CircuitBreakerState)) func() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.listeners == nil {
+		cb.listeners = make(map[int]func(from, to CircuitBreakerState))
+	}
+	id := cb.nextListener
+	cb.nextListener++
+	cb.listeners[id] = listener
+
+	return func() {
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+		delete(cb.listeners, id)
 	}
 }
 
@@ -2,9 +2,11 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,6 +15,7 @@ import (
 
 	"crypto/tls"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -906,3 +909,89 @@ type errorReader struct{}
 func (e *errorReader) Read(p []byte) (n int, err error) {
 	return 0, io.ErrUnexpectedEOF
 }
+
+// circuitBreakerMetric returns the value of a circuit breaker metric with the given labels.
+func circuitBreakerMetric(t *testing.T, reg *prometheus.Registry, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			matched := 0
+			for _, label := range m.GetLabel() {
+				if v, ok := labels[label.GetName()]; ok && v == label.GetValue() {
+					matched++
+				}
+			}
+			if matched != len(labels) {
+				continue
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+// TestCircuitBreakerMetrics verifies that the RoundTripper exports breaker state,
+// transitions and short-circuited requests without OnStateChange
+func TestCircuitBreakerMetrics(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	cb := NewCircuitBreakerWithConfig(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		SuccessThreshold: 1,
+		Timeout:          time.Minute,
+	})
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		CircuitBreakerEnable: true,
+		CircuitBreaker:       cb,
+	}, "test-cb-metrics")
+
+	// Two failures open the breaker, the third request is short-circuited
+	for range 3 {
+		if resp, _ := client.Get(context.Background(), server.URL); resp != nil {
+			resp.Body.Close()
+		}
+	}
+	require.Equal(t, CircuitBreakerOpen, cb.State())
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	host := getHost(serverURL)
+	state := func(s CircuitBreakerState) float64 {
+		return circuitBreakerMetric(t, reg, MetricCircuitBreakerState, map[string]string{
+			"client_name": "test-cb-metrics", "host": host, "state": s.String(),
+		})
+	}
+	assert.Equal(t, 1.0, state(CircuitBreakerOpen))
+	assert.Equal(t, 0.0, state(CircuitBreakerClosed))
+	assert.Equal(t, 0.0, state(CircuitBreakerHalfOpen))
+
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, MetricCircuitBreakerTransitions, map[string]string{
+		"client_name": "test-cb-metrics", "from": "closed", "to": "open",
+	}))
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, MetricCircuitBreakerShortCircuits, map[string]string{
+		"client_name": "test-cb-metrics", "method": http.MethodGet, "host": host,
+	}))
+
+	// Transitions after Close are not recorded anymore
+	require.NoError(t, client.Close())
+	cb.Reset()
+	assert.Equal(t, 0.0, circuitBreakerMetric(t, reg, MetricCircuitBreakerTransitions, map[string]string{
+		"client_name": "test-cb-metrics", "from": "open", "to": "closed",
+	}))
+}
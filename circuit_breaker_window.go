@@ -0,0 +1,82 @@
+package httpclient
+
+import "time"
+
+// windowBuckets is the number of buckets the rolling window is split into.
+const windowBuckets = 10
+
+// CircuitBreakerStrategy defines how failures in the closed state open the circuit breaker.
+type CircuitBreakerStrategy int
+
+const (
+	// CircuitBreakerConsecutiveFailures opens after FailureThreshold failures in a row.
+	CircuitBreakerConsecutiveFailures CircuitBreakerStrategy = iota
+	// CircuitBreakerErrorRate opens when the share of failures over the rolling Window
+	// reaches ErrorRateThreshold, once at least MinimumRequests were made in it.
+	CircuitBreakerErrorRate
+)
+
+// String returns the strategy name.
+func (s CircuitBreakerStrategy) String() string {
+	switch s {
+	case CircuitBreakerConsecutiveFailures:
+		return "consecutive-failures"
+	case CircuitBreakerErrorRate:
+		return "error-rate"
+	default:
+		return "unknown"
+	}
+}
+
+// windowBucket counts results within one slice of the rolling window.
+type windowBucket struct {
+	start     time.Time
+	successes int
+	failures  int
+}
+
+// rollingWindow counts successes and failures over the last window duration.
+// It is not safe for concurrent use, the circuit breaker guards it with its mutex.
+type rollingWindow struct {
+	window  time.Duration
+	width   time.Duration
+	buckets [windowBuckets]windowBucket
+}
+
+// newRollingWindow creates a rolling window of the given duration.
+func newRollingWindow(window time.Duration) *rollingWindow {
+	return &rollingWindow{
+		window: window,
+		width:  max(window/windowBuckets, time.Millisecond),
+	}
+}
+
+// record adds a result to the bucket of the current time.
+func (w *rollingWindow) record(now time.Time, failed bool) {
+	start := now.Truncate(w.width)
+	bucket := &w.buckets[(start.UnixNano()/int64(w.width))%windowBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = windowBucket{start: start}
+	}
+	if failed {
+		bucket.failures++
+	} else {
+		bucket.successes++
+	}
+}
+
+// counts returns the results recorded within the window.
+func (w *rollingWindow) counts(now time.Time) (successes, failures int) {
+	for _, bucket := range w.buckets {
+		if !bucket.start.IsZero() && now.Sub(bucket.start) < w.window {
+			successes += bucket.successes
+			failures += bucket.failures
+		}
+	}
+	return successes, failures
+}
+
+// reset forgets all recorded results.
+func (w *rollingWindow) reset() {
+	w.buckets = [windowBuckets]windowBucket{}
+}
@@ -0,0 +1,134 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// executeResults runs the given number of successful and failed calls through the breaker.
+func executeResults(cb *SimpleCircuitBreaker, successes, failures int) {
+	for range successes {
+		_, _ = cb.Execute(func() (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		})
+	}
+	for range failures {
+		_, _ = cb.Execute(func() (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})
+	}
+}
+
+func TestRollingWindow_Counts(t *testing.T) {
+	t.Parallel()
+	w := newRollingWindow(time.Second)
+	start := time.Unix(1000, 0)
+
+	w.record(start, false)
+	w.record(start.Add(100*time.Millisecond), true)
+	w.record(start.Add(900*time.Millisecond), true)
+
+	successes, failures := w.counts(start.Add(950 * time.Millisecond))
+	assert.Equal(t, 1, successes)
+	assert.Equal(t, 2, failures)
+
+	// The first bucket slides out of the window
+	successes, failures = w.counts(start.Add(1050 * time.Millisecond))
+	assert.Equal(t, 0, successes)
+	assert.Equal(t, 2, failures)
+
+	// A bucket reused after a full cycle starts from zero
+	w.record(start.Add(2*time.Second), false)
+	successes, failures = w.counts(start.Add(2 * time.Second))
+	assert.Equal(t, 1, successes)
+	assert.Equal(t, 0, failures)
+
+	w.reset()
+	successes, failures = w.counts(start.Add(2 * time.Second))
+	assert.Zero(t, successes+failures)
+}
+
+func TestCircuitBreakerErrorRate_Defaults(t *testing.T) {
+	t.Parallel()
+	cb := NewCircuitBreakerWithConfig(CircuitBreakerConfig{Strategy: CircuitBreakerErrorRate})
+
+	require.NotNil(t, cb.window)
+	assert.Equal(t, defaultCircuitWindow, cb.window.window)
+	assert.Equal(t, defaultErrorRateThreshold, cb.errorRateThreshold)
+	assert.Equal(t, defaultMinimumRequests, cb.minimumRequests)
+
+	assert.Nil(t, NewCircuitBreakerWithConfig(CircuitBreakerConfig{}).window)
+}
+
+func TestCircuitBreakerErrorRate_MinimumRequests(t *testing.T) {
+	t.Parallel()
+	cb := NewCircuitBreakerWithConfig(CircuitBreakerConfig{
+		Strategy:           CircuitBreakerErrorRate,
+		ErrorRateThreshold: 0.5,
+		MinimumRequests:    10,
+		SuccessThreshold:   1,
+		Timeout:            time.Minute,
+	})
+
+	// All failures, but not enough volume to judge
+	executeResults(cb, 0, 9)
+	assert.Equal(t, CircuitBreakerClosed, cb.State())
+
+	executeResults(cb, 0, 1)
+	assert.Equal(t, CircuitBreakerOpen, cb.State())
+}
+
+func TestCircuitBreakerErrorRate_ToleratesBursts(t *testing.T) {
+	t.Parallel()
+	cb := NewCircuitBreakerWithConfig(CircuitBreakerConfig{
+		Strategy:           CircuitBreakerErrorRate,
+		ErrorRateThreshold: 0.5,
+		MinimumRequests:    10,
+		FailureThreshold:   3, // ignored by the error-rate strategy
+	})
+
+	// A burst of consecutive failures below the error rate keeps the breaker closed
+	executeResults(cb, 15, 5)
+	assert.Equal(t, CircuitBreakerClosed, cb.State())
+
+	executeResults(cb, 0, 10)
+	assert.Equal(t, CircuitBreakerOpen, cb.State())
+}
+
+func TestCircuitBreakerErrorRate_WindowRestartsAfterRecovery(t *testing.T) {
+	t.Parallel()
+	cb := NewCircuitBreakerWithConfig(CircuitBreakerConfig{
+		Strategy:           CircuitBreakerErrorRate,
+		ErrorRateThreshold: 0.5,
+		MinimumRequests:    4,
+		SuccessThreshold:   1,
+		Timeout:            10 * time.Millisecond,
+	})
+
+	executeResults(cb, 0, 4)
+	require.Equal(t, CircuitBreakerOpen, cb.State())
+
+	time.Sleep(20 * time.Millisecond)
+	executeResults(cb, 1, 0)
+	require.Equal(t, CircuitBreakerClosed, cb.State())
+
+	// Failures from before the opening are forgotten
+	executeResults(cb, 0, 2)
+	assert.Equal(t, CircuitBreakerClosed, cb.State())
+
+	cb.Reset()
+	executeResults(cb, 0, 3)
+	assert.Equal(t, CircuitBreakerClosed, cb.State())
+}
+
+func TestCircuitBreakerStrategyString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "consecutive-failures", CircuitBreakerConsecutiveFailures.String())
+	assert.Equal(t, "error-rate", CircuitBreakerErrorRate.String())
+	assert.Equal(t, "unknown", CircuitBreakerStrategy(42).String())
+}
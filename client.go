@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Client represents an HTTP client with automatic metrics and retry mechanism.
@@ -17,33 +19,56 @@ type Client struct {
 	metrics    *Metrics
 	tracer     *Tracer
 	name       string
+	limiter    *RateLimiterRoundTripper // nil unless rate limiting is enabled
+	drain      *drainTracker            // in-flight requests awaited by Shutdown
+	baseURL    *url.URL                 // nil unless Config.BaseURL is set
+	baseURLErr error                    // the problem of an invalid Config.BaseURL
+	// queue delivers requests of Enqueue; it starts on first use or with AsyncQueueConfig.Store
+	queue     *asyncQueue
+	queueErr  error
+	queueOnce sync.Once
+	queueMu   sync.Mutex
+	// metricsHandle exposes the backend and registries of metrics
+	metricsHandle MetricsHandle
+	metricsErr    error // the name rejected by Config.UniqueMetricsNames
+	// stopBreakerMetrics unregisters the circuit breaker transitions listener
+	stopBreakerMetrics func()
+	// pool tracks connections of the client's own transport; nil unless enabled by TransportTuning
+	pool       *connPool
+	stopReaper func()
+	// events delivers lifecycle events to the handlers of OnEvent
+	events *eventBus
+	// parent owns the transport and limits shared by a client created by Scope
+	parent *Client
+	// scopes are the open clients created by Scope, closed with the client
+	scopes   map[*Client]struct{}
+	scopesMu sync.Mutex
+	// probes caches the results of Probe per host, shared with scoped clients
+	probes *probeCache
+	// keepAlive pings Config.KeepAlive.Hosts; nil when there are none
+	keepAlive *keepAlivePinger
 }
 
 // New creates a new HTTP client with the specified configuration.
-func New(config Config, meterName string) *Client {
-	// Apply default values
+// Optional ClientOption values are applied on top of config.
+func New(config Config, meterName string, opts ...ClientOption) *Client {
+	// Apply options and default values
+	applyClientOptions(&config, opts)
+	ownTransport := config.Transport == nil
 	config = config.withDefaults()
 
 	// Set default meter name if not provided
 	if meterName == "" {
 		meterName = "http-client"
 	}
+	if config.UserAgent == "" {
+		config.UserAgent = defaultUserAgent(meterName)
+	}
 
 	// Initialize metrics
-	var metrics *Metrics
-	if config.MetricsEnabled == nil || *config.MetricsEnabled {
-		// Select metrics provider based on configuration
-		var provider MetricsProvider
-		switch config.MetricsBackend {
-		case MetricsBackendOpenTelemetry:
-			provider = NewOpenTelemetryMetricsProvider(meterName, config.OTelMeterProvider)
-		default: // Prometheus by default
-			provider = NewPrometheusMetricsProvider(meterName, config.PrometheusRegisterer)
-		}
-		metrics = NewMetricsWithProvider(meterName, provider)
-	} else {
-		metrics = NewMetricsWithProvider(meterName, NewNoopMetricsProvider())
-	}
+	metricsHandle, metricsErr := newMetricsHandle(config, meterName)
+	metrics := NewMetricsWithProvider(meterName, metricsHandle.Provider)
+	metrics.dropHost = config.MetricsLabels.DropHost
 
 	// Initialize tracing (optional)
 	var tracer *Tracer
@@ -54,9 +79,39 @@ func New(config Config, meterName string) *Client {
 	// Build RoundTripper chain from bottom to top
 	transport := config.Transport
 
+	// Track connections of the client's own transport
+	var pool *connPool
+	tuning := config.TransportTuning
+	if own, ok := transport.(*http.Transport); ok && ownTransport && (tuning.TrackConnections || tuning.ConnMaxLifetime > 0) {
+		pool = newConnPool(tuning.ConnMaxLifetime)
+		own.DialContext = pool.dialContext(own.DialContext)
+		transport = &poolRoundTripper{base: own, pool: pool}
+	}
+
+	// Wrap the base transport with TransportMiddleware
+	transport = wrapTransport(transport, config.TransportMiddleware)
+
 	// Add Rate Limiter if enabled
+	events := newEventBus(nil)
+	var limiter *RateLimiterRoundTripper
 	if config.RateLimiterEnabled {
-		transport = NewRateLimiterRoundTripper(transport, config.RateLimiterConfig)
+		limiter = NewRateLimiterRoundTripper(transport, config.RateLimiterConfig)
+		limiter.onWait = func(req *http.Request, waited time.Duration) {
+			addRateLimitWait(req.Context(), waited)
+			if config.Logger != nil {
+				logEvent(req.Context(), config.Logger, config.LogLevels.RateLimiter, "waited for rate limiter",
+					"client", meterName, "method", req.Method, "host", getHost(req.URL), "waited", waited)
+			}
+			if events.active() {
+				events.emit(RateLimited{Request: req, Waited: waited})
+			}
+		}
+		if limiter.throttle != nil {
+			limiter.throttle.onChange = func(host string, throttled bool) {
+				metrics.SetThrottled(context.Background(), host, throttled)
+			}
+		}
+		transport = limiter
 	}
 
 	// Circuit Breaker is integrated in RoundTripper.doTransport(), no need to modify transport
@@ -67,6 +122,28 @@ func New(config Config, meterName string) *Client {
 		config:  config,
 		metrics: metrics,
 		tracer:  tracer,
+		stats:   newClientStats(),
+		drain:   newDrainTracker(),
+		events:  events,
+		latency: newLatencyTracker(),
+		slo:     newSLOTracker(config.SLOs, config.OnSLOChange),
+
+		authRejections: newAuthRejections(config.AuthRejectionCooldown, config.CredentialHeaders),
+	}
+	if config.DeduplicateInflight {
+		rt.inflight = newInflightGroup(config.DeduplicateHeaders)
+	}
+	if config.MaxInflight > 0 {
+		rt.queue = newRequestQueue(config.MaxInflight, config.MaxQueueDepth)
+	}
+	if config.RetryBudgetEnabled {
+		rt.retryBudget = newRetryBudget(config.RetryBudget)
+	}
+	if len(config.Failover.Targets) > 0 {
+		rt.failover = newFailoverGroup(config.Failover, config.Clock)
+	}
+	if config.LoadBalancer.Resolver != nil {
+		rt.balancer = newLoadBalancer(config.LoadBalancer, config.PerTryTimeout)
 	}
 
 	// Create HTTP client
@@ -75,13 +152,59 @@ func New(config Config, meterName string) *Client {
 		Timeout:   config.Timeout,
 	}
 
-	return &Client{
-		httpClient: httpClient,
-		config:     config,
-		metrics:    metrics,
-		tracer:     tracer,
-		name:       meterName,
+	client := &Client{
+		httpClient:    httpClient,
+		config:        config,
+		metrics:       metrics,
+		metricsHandle: metricsHandle,
+		metricsErr:    metricsErr,
+		tracer:        tracer,
+		name:          meterName,
+		limiter:       limiter,
+		drain:         rt.drain,
+		pool:          pool,
+		events:        events,
+		probes:        newProbeCache(),
+	}
+	if pool != nil && pool.maxLifetime > 0 {
+		client.stopReaper = pool.startReaper()
 	}
+	client.keepAlive = client.startKeepAlive()
+	client.baseURL, client.baseURLErr = parseBaseURL(config.BaseURL)
+	httpClient.CheckRedirect = client.checkRedirect
+
+	// Count breaker transitions; custom CircuitBreaker implementations export only the state
+	if cb, ok := config.CircuitBreaker.(*SimpleCircuitBreaker); ok && config.CircuitBreakerEnable {
+		client.stopBreakerMetrics = cb.addStateListener(func(from, to CircuitBreakerState) {
+			metrics.RecordCircuitBreakerTransition(context.Background(), from, to)
+			if events.active() {
+				events.emit(CircuitStateChanged{From: from, To: to, Time: clockOrDefault(config.Clock).Now()})
+			}
+			logEvent(context.Background(), config.Logger, config.LogLevels.CircuitBreaker,
+				"circuit breaker state changed", "client", meterName, "from", from.String(), "to", to.String())
+		})
+	}
+	if config.AsyncQueue.Store != nil {
+		// Resume the stored requests; a load error is returned by Enqueue
+		_, _ = client.startQueue()
+	}
+
+	return client
+}
+
+// NewValidated creates a new HTTP client like New, but first checks that the timeouts
+// leave room for every attempt and returns a *DeadlineBudgetError otherwise.
+func NewValidated(config Config, meterName string, opts ...ClientOption) (*Client, error) {
+	applyClientOptions(&config, opts)
+	if err := config.ValidateTimeouts(); err != nil {
+		return nil, err
+	}
+	client := New(config, meterName)
+	if client.metricsErr != nil {
+		_ = client.Close()
+		return nil, client.metricsErr
+	}
+	return client, nil
 }
 
 // Get executes a GET request.
@@ -91,7 +214,7 @@ func (c *Client) Get(ctx context.Context, url string, opts ...RequestOption) (*h
 		return nil, err
 	}
 	applyOptions(req, opts)
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
 // Post executes a POST request.
@@ -101,7 +224,7 @@ func (c *Client) Post(ctx context.Context, url string, body io.Reader, opts ...R
 		return nil, err
 	}
 	applyOptions(req, opts)
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
 // Put executes a PUT request.
@@ -111,7 +234,7 @@ func (c *Client) Put(ctx context.Context, url string, body io.Reader, opts ...Re
 		return nil, err
 	}
 	applyOptions(req, opts)
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
 // Delete executes a DELETE request.
@@ -121,7 +244,7 @@ func (c *Client) Delete(ctx context.Context, url string, opts ...RequestOption)
 		return nil, err
 	}
 	applyOptions(req, opts)
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
 // Head executes a HEAD request.
@@ -131,7 +254,7 @@ func (c *Client) Head(ctx context.Context, url string, opts ...RequestOption) (*
 		return nil, err
 	}
 	applyOptions(req, opts)
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
 // Patch executes a PATCH request.
@@ -141,12 +264,37 @@ func (c *Client) Patch(ctx context.Context, url string, body io.Reader, opts ...
 		return nil, err
 	}
 	applyOptions(req, opts)
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
 // Do executes an HTTP request.
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
-	return c.httpClient.Do(req)
+	return c.do(req)
+}
+
+// do resolves the URL against Config.BaseURL and WithPathParam values and sends the
+// request through the underlying http.Client.
+// A per-request timeout set by WithRequestTimeout or WithProfile replaces the client-wide
+// Config.Timeout. Errors are translated by Config.ErrorTranslator.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.metricsErr != nil {
+		return nil, c.metricsErr
+	}
+	req, err := c.resolveURL(req)
+	if err != nil {
+		return nil, c.translateError(err)
+	}
+	if req, err = c.applyProfile(req); err != nil {
+		return nil, c.translateError(err)
+	}
+	httpClient := c.httpClient
+	if overrides := getRequestOverrides(req.Context()); overrides != nil && overrides.timeout > 0 {
+		withTimeout := *c.httpClient
+		withTimeout.Timeout = overrides.timeout
+		httpClient = &withTimeout
+	}
+	resp, err := httpClient.Do(req)
+	return resp, c.translateError(err)
 }
 
 // PostForm executes a POST request with form data.
@@ -159,12 +307,36 @@ func (c *Client) GetConfig() Config {
 	return c.config
 }
 
-// Close releases client resources.
+// GetThrottleState returns the hosts currently throttled after 429 responses or exhausted quotas.
+// It is empty unless rate limiting with RateLimiterConfig.AdaptiveThrottling or
+// PreemptiveThrottling is enabled.
+func (c *Client) GetThrottleState() []ThrottleState {
+	if c.limiter == nil {
+		return nil
+	}
+	return c.limiter.ThrottleState()
+}
+
+// GetTargetState returns the health of the failover targets in configuration order.
+// It is empty unless Config.Failover has targets.
+func (c *Client) GetTargetState() []TargetState {
+	rt, ok := c.httpClient.Transport.(*RoundTripper)
+	if !ok || rt.failover == nil {
+		return nil
+	}
+	return rt.failover.states()
+}
+
+// Close rejects new requests with *ClientClosedError, closes idle connections and releases
+// client resources. With Config.DrainTimeout it first waits up to the timeout for in-flight
+// requests as Shutdown does.
 func (c *Client) Close() error {
-	if c.metrics != nil {
-		return c.metrics.Close()
+	if c.config.DrainTimeout <= 0 {
+		return c.release()
 	}
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.DrainTimeout)
+	defer cancel()
+	return c.Shutdown(ctx)
 }
 
 // GetWithHeaders executes a GET request with headers.
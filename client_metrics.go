@@ -0,0 +1,242 @@
+package httpclient
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// latencyBuckets is the number of buckets of a latencyHistogram.
+const latencyBuckets = 512
+
+// latencyGrowth is the ratio between the upper bounds of adjacent buckets,
+// so percentiles are accurate within 5%.
+const latencyGrowth = 1.05
+
+// ClientMetrics is an in-process snapshot of the client statistics returned by Client.GetMetrics.
+// Requests are counted once, including all their attempts; latencies cover all attempts.
+type ClientMetrics struct {
+	RequestStats
+
+	// ByHost breaks the statistics down by request host, without the port as in metrics labels
+	ByHost map[string]RequestStats
+
+	// ByStatus counts requests by final status code; 0 counts requests failed with an error
+	ByStatus map[int]int64
+
+	// LastError describes the last failed request, nil if no request failed
+	LastError *LastErrorInfo
+}
+
+// RequestStats contains request counters and latencies.
+type RequestStats struct {
+	// TotalRequests is the number of finished requests
+	TotalRequests int64
+
+	// FailedRequests is the number of requests failed with an error or a 5xx status
+	FailedRequests int64
+
+	// Retries is the number of retried attempts
+	Retries int64
+
+	// AverageLatency is the mean request latency
+	AverageLatency time.Duration
+
+	// P50Latency, P95Latency and P99Latency are latency percentiles
+	P50Latency time.Duration
+	P95Latency time.Duration
+	P99Latency time.Duration
+
+	// MaxLatency is the highest request latency
+	MaxLatency time.Duration
+}
+
+// ErrorRate returns the share of failed requests, 0 without requests.
+func (s RequestStats) ErrorRate() float64 {
+	if s.TotalRequests == 0 {
+		return 0
+	}
+	return float64(s.FailedRequests) / float64(s.TotalRequests)
+}
+
+// LastErrorInfo describes a failed request.
+type LastErrorInfo struct {
+	Time       time.Time
+	Method     string
+	Host       string
+	StatusCode int   // 0 if the request failed with an error
+	Err        error // nil for 5xx responses
+}
+
+// GetMetrics returns a snapshot of the request statistics collected since the client was created.
+func (c *Client) GetMetrics() ClientMetrics {
+	rt, ok := c.httpClient.Transport.(*RoundTripper)
+	if !ok || rt.stats == nil {
+		return ClientMetrics{ByHost: map[string]RequestStats{}, ByStatus: map[int]int64{}}
+	}
+	return rt.stats.snapshot()
+}
+
+// clientStats collects the statistics of Client.GetMetrics.
+type clientStats struct {
+	mu        sync.Mutex
+	total     requestCounters
+	hosts     map[string]*requestCounters
+	statuses  map[int]int64
+	lastError *LastErrorInfo
+}
+
+// requestCounters are the counters of a host or of all requests.
+type requestCounters struct {
+	requests int64
+	failed   int64
+	retries  int64
+	latency  latencyHistogram
+}
+
+// newClientStats creates empty statistics.
+func newClientStats() *clientStats {
+	return &clientStats{
+		hosts:    make(map[string]*requestCounters),
+		statuses: make(map[int]int64),
+	}
+}
+
+// host returns the counters of the host; callers hold the lock.
+func (s *clientStats) host(host string) *requestCounters {
+	counters, ok := s.hosts[host]
+	if !ok {
+		counters = &requestCounters{}
+		s.hosts[host] = counters
+	}
+	return counters
+}
+
+// recordRequest records a finished request.
+func (s *clientStats) recordRequest(method, host string, resp *http.Response, err error, latency time.Duration) {
+	if s == nil {
+		return
+	}
+	status := 0
+	if err == nil && resp != nil {
+		status = resp.StatusCode
+	}
+	failed := err != nil || status >= http.StatusInternalServerError
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[status]++
+	for _, counters := range []*requestCounters{&s.total, s.host(host)} {
+		counters.requests++
+		if failed {
+			counters.failed++
+		}
+		counters.latency.observe(latency)
+	}
+	if failed {
+		s.lastError = &LastErrorInfo{Time: time.Now(), Method: method, Host: host, StatusCode: status, Err: err}
+	}
+}
+
+// recordRetry records a retried attempt.
+func (s *clientStats) recordRetry(host string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total.retries++
+	s.host(host).retries++
+}
+
+// snapshot returns a copy of the statistics.
+func (s *clientStats) snapshot() ClientMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	metrics := ClientMetrics{
+		RequestStats: s.total.stats(),
+		ByHost:       make(map[string]RequestStats, len(s.hosts)),
+		ByStatus:     make(map[int]int64, len(s.statuses)),
+	}
+	for host, counters := range s.hosts {
+		metrics.ByHost[host] = counters.stats()
+	}
+	for status, count := range s.statuses {
+		metrics.ByStatus[status] = count
+	}
+	if s.lastError != nil {
+		lastError := *s.lastError
+		metrics.LastError = &lastError
+	}
+	return metrics
+}
+
+// stats converts the counters to RequestStats.
+func (c *requestCounters) stats() RequestStats {
+	return RequestStats{
+		TotalRequests:  c.requests,
+		FailedRequests: c.failed,
+		Retries:        c.retries,
+		AverageLatency: c.latency.mean(),
+		P50Latency:     c.latency.quantile(0.5),
+		P95Latency:     c.latency.quantile(0.95),
+		P99Latency:     c.latency.quantile(0.99),
+		MaxLatency:     c.latency.max,
+	}
+}
+
+// latencyHistogram is an HDR-style histogram with exponentially growing buckets
+// from 1µs to several hours.
+type latencyHistogram struct {
+	counts [latencyBuckets]int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// observe adds a latency to the histogram.
+func (h *latencyHistogram) observe(latency time.Duration) {
+	h.counts[latencyBucket(latency)]++
+	h.count++
+	h.sum += latency
+	h.max = max(h.max, latency)
+}
+
+// mean returns the average latency.
+func (h *latencyHistogram) mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// quantile returns the upper bound of the bucket holding the q-th latency, capped by the maximum.
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(h.count)))
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank && i < latencyBuckets-1 {
+			return min(latencyBucketBound(i), h.max)
+		}
+	}
+	return h.max
+}
+
+// latencyBucket returns the index of the bucket holding the latency.
+func latencyBucket(latency time.Duration) int {
+	if latency <= time.Microsecond {
+		return 0
+	}
+	index := int(math.Ceil(math.Log(float64(latency)/float64(time.Microsecond)) / math.Log(latencyGrowth)))
+	return min(index, latencyBuckets-1)
+}
+
+// latencyBucketBound returns the upper bound of the bucket.
+func latencyBucketBound(index int) time.Duration {
+	return time.Duration(float64(time.Microsecond) * math.Pow(latencyGrowth, float64(index)))
+}
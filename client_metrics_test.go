@@ -0,0 +1,93 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetMetrics(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+		TestResponse{StatusCode: http.StatusInternalServerError},
+	)
+	defer server.Close()
+
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}, "test-get-metrics")
+	defer client.Close()
+
+	assert.Zero(t, client.GetMetrics().TotalRequests)
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	resp, err = client.Get(context.Background(), server.URL, WithNoRetry())
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	metrics := client.GetMetrics()
+	assert.Equal(t, int64(2), metrics.TotalRequests)
+	assert.Equal(t, int64(1), metrics.FailedRequests)
+	assert.Equal(t, int64(1), metrics.Retries)
+	assert.InDelta(t, 0.5, metrics.ErrorRate(), 0.001)
+	assert.Equal(t, map[int]int64{http.StatusOK: 1, http.StatusInternalServerError: 1}, metrics.ByStatus)
+	assert.Positive(t, metrics.P50Latency)
+	assert.LessOrEqual(t, metrics.P99Latency, metrics.MaxLatency)
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	host := metrics.ByHost[serverURL.Hostname()]
+	assert.Equal(t, int64(2), host.TotalRequests)
+	assert.Equal(t, int64(1), host.Retries)
+
+	require.NotNil(t, metrics.LastError)
+	assert.Equal(t, http.StatusInternalServerError, metrics.LastError.StatusCode)
+	assert.Equal(t, http.MethodGet, metrics.LastError.Method)
+	assert.NoError(t, metrics.LastError.Err)
+}
+
+func TestClient_GetMetricsTransportError(t *testing.T) {
+	t.Parallel()
+	errDial := errors.New("dial failed")
+	client := New(Config{Transport: &mockRoundTripper{errors: []error{errDial}}}, "test-get-metrics-error")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), "http://example.com/items")
+	require.Error(t, err)
+
+	metrics := client.GetMetrics()
+	assert.Equal(t, int64(1), metrics.ByStatus[0])
+	assert.Equal(t, int64(1), metrics.ByHost["example.com"].FailedRequests)
+	require.NotNil(t, metrics.LastError)
+	assert.ErrorIs(t, metrics.LastError.Err, errDial)
+	assert.Equal(t, "example.com", metrics.LastError.Host)
+}
+
+func TestLatencyHistogram_Quantiles(t *testing.T) {
+	t.Parallel()
+	var h latencyHistogram
+	assert.Zero(t, h.quantile(0.5))
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+
+	assert.InEpsilon(t, float64(50*time.Millisecond), float64(h.quantile(0.5)), 0.05)
+	assert.InEpsilon(t, float64(95*time.Millisecond), float64(h.quantile(0.95)), 0.05)
+	assert.InEpsilon(t, float64(99*time.Millisecond), float64(h.quantile(0.99)), 0.05)
+	assert.Equal(t, 100*time.Millisecond, h.quantile(1))
+	assert.Equal(t, 50500*time.Microsecond, h.mean())
+
+	h.observe(24 * time.Hour)
+	assert.Equal(t, 24*time.Hour, h.quantile(1))
+}
@@ -0,0 +1,57 @@
+package httpclient
+
+import "crypto/x509"
+
+// ClientOption is a functional option for configuring the client at construction time.
+// Options are applied to the Config passed to New before defaults are filled in.
+type ClientOption func(*Config)
+
+// applyClientOptions applies all ClientOption to the configuration.
+func applyClientOptions(config *Config, opts []ClientOption) {
+	for _, opt := range opts {
+		opt(config)
+	}
+}
+
+// WithLogger sets the logger of the client, e.g. NewSlogLogger(slog.Default()).
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithTransportTuning sets connection pool and dialer settings for the client's transport.
+func WithTransportTuning(tuning TransportTuning) ClientOption {
+	return func(c *Config) {
+		c.TransportTuning = tuning
+	}
+}
+
+// WithClientCertificate sets the client certificate and key PEM files used for mutual TLS.
+func WithClientCertificate(certFile, keyFile string) ClientOption {
+	return func(c *Config) {
+		c.TLSConfig.CertFile = certFile
+		c.TLSConfig.KeyFile = keyFile
+	}
+}
+
+// WithRootCAs sets the root certificates used to verify servers.
+func WithRootCAs(pool *x509.CertPool) ClientOption {
+	return func(c *Config) {
+		c.TLSConfig.RootCAs = pool
+	}
+}
+
+// WithInsecureSkipVerify disables server certificate verification. Use only in tests.
+func WithInsecureSkipVerify(skip bool) ClientOption {
+	return func(c *Config) {
+		c.TLSConfig.InsecureSkipVerify = skip
+	}
+}
+
+// WithTLSMinVersion sets the minimum TLS version, e.g. tls.VersionTLS13.
+func WithTLSMinVersion(version uint16) ClientOption {
+	return func(c *Config) {
+		c.TLSConfig.MinVersion = version
+	}
+}
@@ -0,0 +1,56 @@
+package httpclient
+
+import "time"
+
+// Clock provides the current time and timers to retry backoff, rate limiters and
+// circuit breakers, so tests can control time with a FakeClock instead of sleeping.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTimer creates a timer that fires after d
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock.
+type Timer interface {
+	// C returns the channel the time is sent on when the timer fires
+	C() <-chan time.Time
+	// Stop prevents the timer from firing and reports whether it was pending
+	Stop() bool
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+// Now returns time.Now().
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer returns a time.Timer.
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// realTimer adapts time.Timer to the Timer interface.
+type realTimer struct {
+	timer *time.Timer
+}
+
+// C returns the timer channel.
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Stop stops the timer.
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// clockOrDefault returns clock, or the real clock when it is nil.
+func clockOrDefault(clock Clock) Clock {
+	if clock == nil {
+		return realClock{}
+	}
+	return clock
+}
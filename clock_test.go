@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock_Timers(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	first := clock.NewTimer(time.Second)
+	second := clock.NewTimer(time.Minute)
+	assert.True(t, second.Stop())
+	assert.False(t, second.Stop())
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-first.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, start.Add(time.Second), <-first.C())
+	assert.False(t, first.Stop())
+	assert.Equal(t, start.Add(time.Second), clock.Now())
+
+	// Expired timers fire at once
+	assert.Equal(t, clock.Now(), <-clock.NewTimer(0).C())
+}
+
+func TestClock_RetryDelay(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	client := New(Config{
+		Timeout:      time.Hour,
+		Clock:        clock,
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Minute},
+	}, "test-clock-retry")
+	defer client.Close()
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		done <- result{resp, err}
+	}()
+
+	// The first retry is immediate, the second one waits for the fake clock instead of a minute
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+
+	res := <-done
+	require.NoError(t, res.err)
+	_ = res.resp.Body.Close()
+	assert.Equal(t, http.StatusOK, res.resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestClock_CircuitBreakerTimeout(t *testing.T) {
+	t.Parallel()
+	clock := NewFakeClock(time.Now())
+	cb := NewCircuitBreakerWithConfig(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		SuccessThreshold: 1,
+		Timeout:          time.Minute,
+		Clock:            clock,
+	})
+
+	_, err := cb.Execute(func() (*http.Response, error) { return nil, errors.New("down") })
+	require.Error(t, err)
+	assert.Equal(t, CircuitBreakerOpen, cb.State())
+
+	_, err = cb.Execute(func() (*http.Response, error) { return &http.Response{StatusCode: http.StatusOK}, nil })
+	assert.ErrorIs(t, err, ErrCircuitBreakerOpen)
+
+	clock.Advance(time.Minute + time.Second)
+	_, err = cb.Execute(func() (*http.Response, error) { return &http.Response{StatusCode: http.StatusOK}, nil })
+	require.NoError(t, err)
+	assert.Equal(t, CircuitBreakerClosed, cb.State())
+}
+
+func TestClock_TokenBucketWait(t *testing.T) {
+	t.Parallel()
+	clock := NewFakeClock(time.Now())
+	limiter := NewTokenBucketLimiterWithClock(1, 1, clock)
+	assert.True(t, limiter.Allow())
+	assert.False(t, limiter.Allow())
+
+	done := make(chan error, 1)
+	go func() { done <- limiter.Wait(context.Background()) }()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	require.NoError(t, <-done)
+	assert.False(t, limiter.Allow())
+}
+
+func TestConfig_ClockPropagation(t *testing.T) {
+	t.Parallel()
+	clock := NewFakeClock(time.Now())
+	config := Config{Clock: clock, CircuitBreakerEnable: true, RateLimiterEnabled: true}.withDefaults()
+
+	assert.Equal(t, clock, config.RateLimiterConfig.Clock)
+	cb, ok := config.CircuitBreaker.(*SimpleCircuitBreaker)
+	require.True(t, ok)
+	assert.Equal(t, clock, cb.clock)
+}
@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// defaultCompressMinBytes is the default smallest request body that gets compressed.
+const defaultCompressMinBytes = 1024
+
+// WithGzipBody gzip-compresses the request body of this request when it is at least
+// Config.CompressMinBytes long, even if Config.CompressRequests is disabled.
+func WithGzipBody() RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.compress = true
+		})
+	}
+}
+
+// compressRequestBody returns a copy of the request with a gzip-compressed body.
+// The compressed body is kept in memory with a GetBody factory, so retries replay it
+// without compressing again. Small bodies and bodies that already have a
+// Content-Encoding are left as is.
+func compressRequestBody(req *http.Request, config Config) (*http.Request, error) {
+	if !config.CompressRequests || config.Passthrough || req.Body == nil || req.Body == http.NoBody ||
+		req.Header.Get("Content-Encoding") != "" {
+		return req, nil
+	}
+
+	minBytes := config.CompressMinBytes
+	if req.ContentLength > 0 && req.ContentLength < minBytes {
+		return req, nil
+	}
+
+	// Read a prefix to check the size of bodies with unknown length
+	prefix := make([]byte, minBytes)
+	n, err := io.ReadFull(req.Body, prefix)
+	prefix = prefix[:n]
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		// Smaller than the threshold: send the already read bytes uncompressed
+		_ = req.Body.Close()
+		small := req.Clone(req.Context())
+		setBytesBody(small, prefix)
+		return small, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(prefix); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(zw, req.Body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	_ = req.Body.Close()
+
+	compressed := req.Clone(req.Context())
+	data := buf.Bytes()
+	setBytesBody(compressed, data)
+	compressed.Header.Set("Content-Encoding", "gzip")
+	return compressed, nil
+}
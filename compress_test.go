@@ -0,0 +1,163 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compressionRecorder records the decoded body and Content-Encoding of every request.
+type compressionRecorder struct {
+	mu        sync.Mutex
+	encodings []string
+	bodies    []string
+	wireSizes []int
+}
+
+func (c *compressionRecorder) server(t *testing.T, statuses ...int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		body := raw
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(bytes.NewReader(raw))
+			require.NoError(t, err)
+			body, err = io.ReadAll(zr)
+			require.NoError(t, err)
+		}
+
+		c.mu.Lock()
+		c.encodings = append(c.encodings, r.Header.Get("Content-Encoding"))
+		c.bodies = append(c.bodies, string(body))
+		c.wireSizes = append(c.wireSizes, len(raw))
+		attempt := len(c.bodies)
+		c.mu.Unlock()
+
+		status := http.StatusOK
+		if attempt <= len(statuses) {
+			status = statuses[attempt-1]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCompressRequests_GzipsLargeBodiesAcrossRetries(t *testing.T) {
+	t.Parallel()
+	recorder := &compressionRecorder{}
+	server := recorder.server(t, http.StatusServiceUnavailable)
+
+	client := New(Config{
+		CompressRequests: true,
+		RetryEnabled:     true,
+		RetryConfig:      RetryConfig{MaxAttempts: 2, BaseDelay: 1},
+	}, "test-compress")
+	defer client.Close()
+
+	payload := strings.Repeat(`{"level":"info","msg":"shipping logs"}`, 500)
+	resp, err := client.Post(context.Background(), server.URL, nil, WithJSONBody(payload), WithIdempotencyKey("batch-1"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Len(t, recorder.bodies, 2)
+	for i := range recorder.bodies {
+		assert.Equal(t, "gzip", recorder.encodings[i])
+		assert.True(t, payload == recorder.bodies[i], "decoded body differs")
+		assert.Less(t, recorder.wireSizes[i], len(payload)/10)
+	}
+}
+
+func TestCompressRequests_SkipsSmallBodies(t *testing.T) {
+	t.Parallel()
+	recorder := &compressionRecorder{}
+	server := recorder.server(t)
+
+	client := New(Config{CompressRequests: true}, "test-compress-small")
+	defer client.Close()
+
+	// Unknown length: the size is checked by reading a prefix
+	resp, err := client.Post(context.Background(), server.URL, nil, WithRawBody(opaqueReader{Reader: strings.NewReader("tiny")}))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Len(t, recorder.bodies, 1)
+	assert.Empty(t, recorder.encodings[0])
+	assert.Equal(t, "tiny", recorder.bodies[0])
+}
+
+func TestCompressRequestBody_KeepsCallerRequest(t *testing.T) {
+	t.Parallel()
+	body := opaqueReader{Reader: strings.NewReader("tiny")}
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", body)
+	require.NoError(t, err)
+	original := req.Body
+
+	sent, err := compressRequestBody(req, Config{CompressRequests: true, CompressMinBytes: defaultCompressMinBytes})
+	require.NoError(t, err)
+	assert.NotSame(t, req, sent)
+	assert.Equal(t, original, req.Body)
+	assert.Equal(t, int64(0), req.ContentLength)
+	data, err := io.ReadAll(sent.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "tiny", string(data))
+	assert.Equal(t, int64(4), sent.ContentLength)
+}
+
+func TestWithGzipBody_PerRequest(t *testing.T) {
+	t.Parallel()
+	recorder := &compressionRecorder{}
+	server := recorder.server(t)
+
+	client := New(Config{CompressMinBytes: 16}, "test-compress-option")
+	defer client.Close()
+
+	payload := strings.Repeat("a", 4096)
+	resp, err := client.Post(context.Background(), server.URL, nil,
+		WithRawBody(opaqueReader{Reader: strings.NewReader(payload)}), WithGzipBody())
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = client.Post(context.Background(), server.URL, nil, WithTextBody(payload))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Len(t, recorder.bodies, 2)
+	assert.Equal(t, []string{"gzip", ""}, recorder.encodings)
+	assert.Equal(t, payload, recorder.bodies[0])
+	assert.Equal(t, payload, recorder.bodies[1])
+}
+
+func TestCompressRequests_KeepsExistingEncoding(t *testing.T) {
+	t.Parallel()
+	recorder := &compressionRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		recorder.mu.Lock()
+		recorder.encodings = append(recorder.encodings, r.Header.Get("Content-Encoding"))
+		recorder.bodies = append(recorder.bodies, string(raw))
+		recorder.mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := New(Config{CompressRequests: true, CompressMinBytes: 1}, "test-compress-encoded")
+	defer client.Close()
+
+	resp, err := client.Post(context.Background(), server.URL, nil,
+		WithTextBody("already encoded"), WithHeader("Content-Encoding", "br"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, []string{"br"}, recorder.encodings)
+	assert.Equal(t, []string{"already encoded"}, recorder.bodies)
+}
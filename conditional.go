@@ -0,0 +1,59 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Validators are the ETag and Last-Modified of a response, sent back by ConditionalGet
+// to fetch the resource only when it has changed.
+type Validators struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// ValidatorsFromResponse returns the validators of the response. Missing or invalid
+// headers give zero values.
+func ValidatorsFromResponse(resp *http.Response) Validators {
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: lastModified,
+	}
+}
+
+// IsZero reports whether there are no validators, e.g. before the first fetch.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified.IsZero()
+}
+
+// ConditionalGet executes a GET request with If-None-Match and If-Modified-Since built from
+// the validators and reports whether the server answered 304 Not Modified. The body of a 304
+// response is closed; otherwise the caller reads and closes it and keeps
+// ValidatorsFromResponse(resp) for the next poll. With CacheMiddleware fresh cached responses
+// answer without a request to the server.
+func (c *Client) ConditionalGet(
+	ctx context.Context,
+	url string,
+	validators Validators,
+	opts ...RequestOption,
+) (notModified bool, resp *http.Response, err error) {
+	conditional := make([]RequestOption, 0, len(opts)+2)
+	if validators.ETag != "" {
+		conditional = append(conditional, WithIfNoneMatch(validators.ETag))
+	}
+	if !validators.LastModified.IsZero() {
+		conditional = append(conditional, WithIfModifiedSince(validators.LastModified))
+	}
+
+	resp, err = c.Get(ctx, url, append(conditional, opts...)...)
+	if err != nil {
+		return false, resp, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		closeResponseBody(resp)
+		return true, resp, nil
+	}
+	return false, resp, nil
+}
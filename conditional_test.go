@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalGet_Polling(t *testing.T) {
+	t.Parallel()
+	lastModified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			assert.Equal(t, lastModified.Format(http.TimeFormat), r.Header.Get("If-Modified-Since"))
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = io.WriteString(w, "payload")
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-conditional-get")
+	defer client.Close()
+
+	var validators Validators
+	assert.True(t, validators.IsZero())
+	notModified, resp, err := client.ConditionalGet(context.Background(), server.URL, validators)
+	require.NoError(t, err)
+	assert.False(t, notModified)
+	assert.Equal(t, "payload", readBody(t, resp))
+	validators = ValidatorsFromResponse(resp)
+	assert.Equal(t, Validators{ETag: `"v1"`, LastModified: lastModified}, validators)
+
+	notModified, resp, err = client.ConditionalGet(context.Background(), server.URL, validators)
+	require.NoError(t, err)
+	assert.True(t, notModified)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+}
+
+func TestConditionalOptions(t *testing.T) {
+	t.Parallel()
+	for etag, want := range map[string]string{
+		"abc":     `"abc"`,
+		`"abc"`:   `"abc"`,
+		`W/"abc"`: `W/"abc"`,
+		"*":       "*",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		WithIfNoneMatch(etag)(req)
+		assert.Equal(t, want, req.Header.Get("If-None-Match"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	WithIfModifiedSince(time.Date(2024, 5, 1, 14, 0, 0, 0, time.FixedZone("CEST", 2*3600)))(req)
+	assert.Equal(t, "Wed, 01 May 2024 12:00:00 GMT", req.Header.Get("If-Modified-Since"))
+}
+
+func TestConditionalGet_WithCache(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, "payload")
+	}))
+	defer server.Close()
+
+	client, _ := cachingTestClient(t, CacheConfig{})
+	notModified, resp, err := client.ConditionalGet(context.Background(), server.URL, Validators{})
+	require.NoError(t, err)
+	assert.False(t, notModified)
+	validators := ValidatorsFromResponse(resp)
+	_ = resp.Body.Close()
+
+	// The fresh cached response answers the poll without a request
+	notModified, resp, err = client.ConditionalGet(context.Background(), server.URL, validators)
+	require.NoError(t, err)
+	assert.True(t, notModified)
+	assert.Equal(t, CacheStatusHit, resp.Header.Get(CacheStatusHeader))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
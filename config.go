@@ -2,11 +2,13 @@ package httpclient
 
 import (
 	"net/http"
+	"net/url"
 	"slices"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 // Default constants for configuration.
@@ -21,10 +23,18 @@ const (
 	defaultMaxDelay    = 2 * time.Second
 	defaultJitter      = 0.2
 
+	// defaultMaxRetryAfter caps the delay a server can request with Retry-After.
+	defaultMaxRetryAfter = 30 * time.Second
+
 	// Default CircuitBreaker settings.
 	defaultFailureThreshold = 5
 	defaultSuccessThreshold = 3
 	defaultCircuitTimeout   = 60 * time.Second
+
+	// Default error-rate CircuitBreaker strategy settings.
+	defaultCircuitWindow      = 10 * time.Second
+	defaultErrorRateThreshold = 0.5
+	defaultMinimumRequests    = 20
 )
 
 // Config contains HTTP client configuration.
@@ -35,37 +45,292 @@ type Config struct {
 	// PerTryTimeout is the timeout for each attempt
 	PerTryTimeout time.Duration
 
-	// Transport is the base HTTP transport (optional)
+	// DrainTimeout is how long Close waits for in-flight requests to finish (default: 0, no wait)
+	DrainTimeout time.Duration
+
+	// Clock is the source of time for retry delays, the retry budget, the default
+	// circuit breaker and the rate limiter (default: real time). Tests use a FakeClock
+	Clock Clock
+
+	// ValidateDeadlines fails requests with *DeadlineBudgetError when the timeouts leave no
+	// room for retries: Timeout shorter than PerTryTimeout*MaxAttempts, or a context deadline
+	// expiring within PerTryTimeout (see also Config.ValidateTimeouts and NewValidated)
+	ValidateDeadlines bool
+
+	// Transport is the base HTTP transport (optional).
+	// When nil, the client builds its own http.Transport configured by TransportTuning.
 	Transport http.RoundTripper
 
+	// TransportMiddleware wraps the base transport (the first one is the outermost). Wrappers
+	// run for every attempt inside the retry loop, below the rate limiter, while metrics,
+	// tracing and the circuit breaker stay outside, e.g. for httptrace collectors or proxies
+	TransportMiddleware []func(http.RoundTripper) http.RoundTripper
+
+	// TransportTuning contains connection pool settings for the transport built by the client
+	TransportTuning TransportTuning
+
+	// ProxyURL is the proxy for the transport built by the client, e.g. "http://proxy:3128".
+	// When both ProxyURL and ProxyFunc are empty, HTTP_PROXY/HTTPS_PROXY/NO_PROXY are used
+	ProxyURL string
+
+	// ProxyFunc selects a proxy for each request and takes precedence over ProxyURL
+	ProxyFunc func(*http.Request) (*url.URL, error)
+
+	// NoProxy lists hosts that are reached directly, in NO_PROXY format:
+	// "example.com" (and subdomains), ".example.com" (subdomains only), "host:8080", "10.0.0.0/8", "*"
+	NoProxy []string
+
+	// TLSConfig contains TLS and mutual TLS settings for the transport built by the client
+	TLSConfig TLSConfig
+
 	// RetryEnabled enables/disables retry mechanism
 	RetryEnabled bool
 
 	// RetryConfig is the retry mechanism configuration
 	RetryConfig RetryConfig
 
+	// RetryBudgetEnabled caps retries to a share of the requests of the client,
+	// so an upstream outage doesn't multiply the traffic
+	RetryBudgetEnabled bool
+
+	// RetryBudget is the retry budget configuration
+	RetryBudget RetryBudgetConfig
+
 	// TracingEnabled enables/disables OpenTelemetry tracing
 	TracingEnabled bool
 
+	// TracePropagator injects the trace context of the request span into outgoing headers,
+	// e.g. propagation.TraceContext{} for W3C traceparent/tracestate or a B3 propagator
+	// (default: otel.GetTextMapPropagator())
+	TracePropagator propagation.TextMapPropagator
+
+	// TracingOptions customizes span names, attributes and error status
+	TracingOptions TracingOptions
+
+	// HTTPTraceEnabled enables connection-level tracing via net/http/httptrace:
+	// DNS, connect, TLS handshake and time-to-first-byte durations are recorded
+	// as span events and http_client_phase_duration_seconds metrics
+	HTTPTraceEnabled bool
+
 	// MaxResponseBytes limits the maximum response size
+	//
+	// Deprecated: the value is not enforced, use MaxResponseBodyBytes instead.
 	MaxResponseBytes *int64
 
+	// MaxResponseBodyBytes limits the number of response body bytes the caller can read.
+	// Reading past the limit returns *BodyTooLargeError. The limit is applied after
+	// transparent decompression, protecting against gzip bombs. Zero means unlimited.
+	MaxResponseBodyBytes int64
+
+	// RetryDrainLimit is the number of body bytes read from a response that is retried
+	// before closing it, so its connection is reused by the next attempt
+	// (default: 64KB, negative: close without reading)
+	RetryDrainLimit int64
+
 	// CircuitBreakerEnable enables/disables CircuitBreaker usage
 	CircuitBreakerEnable bool
 
 	// CircuitBreaker is a configurable automatic circuit breaker
 	CircuitBreaker CircuitBreaker
 
+	// Failover sends requests to replicated endpoints, skipping unhealthy ones
+	Failover FailoverConfig
+
+	// LoadBalancer spreads requests to logical services across their resolved endpoints
+	LoadBalancer LoadBalancerConfig
+
+	// Fallback produces the result of failed requests: transport errors after retries,
+	// an open circuit breaker, or retryable statuses once retries are exhausted
+	Fallback FallbackFunc
+
+	// ErrorTranslator maps the errors returned by request methods to application errors,
+	// e.g. NewCodeTranslator() for *TranslatedError with codes for API consumers.
+	// Nil returns the errors unchanged
+	ErrorTranslator ErrorTranslator
+
+	// OnRetryAttempt is called before waiting for each retry, e.g. to log the failed attempt
+	OnRetryAttempt func(info RetryAttemptInfo)
+
+	// OnRequestFinished is called once per request after all attempts and the fallback
+	OnRequestFinished func(info RequestFinishedInfo)
+
+	// Logger receives retry decisions, circuit breaker transitions, rate limiter waits and
+	// canonical request log lines (see LogLevels.Request)
+	// (default: no logging)
+	Logger Logger
+
+	// LogLevels sets the level of each kind of logged event
+	LogLevels LogLevels
+
+	// SLOs track latency and error rate objectives of endpoints from the client's own requests,
+	// exported as SLO metrics and returned by Client.SLOStatus
+	SLOs []SLO
+
+	// OnSLOChange is called when an SLO starts burning or recovers, see SLOStatus.Burning
+	OnSLOChange func(status SLOStatus)
+
 	// RateLimiterEnabled enables/disables rate limiting
 	RateLimiterEnabled bool
 
 	// RateLimiterConfig is the rate limiter configuration
 	RateLimiterConfig RateLimiterConfig
 
+	// BaseURL is joined with relative request URLs, e.g. "https://api.example.com/v1"
+	// and "/users/{id}" give "https://api.example.com/v1/users/{id}"
+	BaseURL string
+
+	// AsyncQueue configures the queue of requests sent asynchronously by Client.Enqueue
+	AsyncQueue AsyncQueueConfig
+
+	// ProbeTTL is how long Client.Probe results are cached per host (default: 5m)
+	ProbeTTL time.Duration
+
+	// DefaultHeaders are added to requests that don't set them
+	DefaultHeaders map[string]string
+
+	// HeaderPolicy contains headers set on or removed from every request
+	HeaderPolicy HeaderPolicy
+
+	// UserAgent is added to requests without a User-Agent header
+	// (default: "<clientName>/<version> (github.com/rurick/http-client)")
+	UserAgent string
+
+	// Middlewares wrap every request executed by the client (the first one is the outermost)
+	Middlewares []Middleware
+
+	// AttemptMiddlewares update the request of every physical attempt, including retries
+	AttemptMiddlewares []AttemptMiddleware
+
+	// RequestValidators check every request after Middlewares and before the first attempt
+	// (in order); a failure is returned as *RequestValidationError without sending the request
+	RequestValidators []func(*http.Request) error
+
+	// ResponseInterceptors process the final response of every request (in order)
+	ResponseInterceptors []ResponseInterceptor
+
+	// ResponseSchemas validates the successful JSON responses of endpoints against JSON Schemas.
+	// Keys are "METHOD path" or "path", where path is the path template of WithPathParam
+	// requests (e.g. "GET /users/{id}") or the URL path; WithResponseSchema takes precedence
+	ResponseSchemas map[string]*JSONSchema
+
+	// SchemaValidation selects what happens to responses violating their schema
+	// (default: SchemaValidationEnforce); SchemaValidationOff skips validation, e.g. in production
+	SchemaValidation SchemaValidationMode
+
+	// OnSchemaViolation is called for responses violating their schema in SchemaValidationReport mode
+	OnSchemaViolation func(err *SchemaValidationError)
+
+	// Decoders decodes the bodies of typed responses by Content-Type in Response.Decode
+	// (default: DefaultDecoders)
+	Decoders *DecoderRegistry
+
+	// HedgingEnabled enables/disables hedged (parallel backup) requests for idempotent methods
+	HedgingEnabled bool
+
+	// HedgingConfig is the hedged requests configuration
+	HedgingConfig HedgingConfig
+
+	// DeduplicateInflight collapses concurrent identical GET/HEAD requests (same method, URL
+	// and DeduplicateHeaders values) into a single upstream call whose buffered response
+	// is shared by all callers. Ranged and conditional requests and requests with
+	// per-request options such as WithRequestTimeout are never collapsed
+	DeduplicateInflight bool
+
+	// DeduplicateHeaders lists request headers that distinguish deduplicated requests
+	// (default: DefaultDeduplicateHeaders)
+	DeduplicateHeaders []string
+
+	// AuthRejectionCooldown short-circuits requests whose credentials the server rejected:
+	// for this period requests with the same CredentialHeaders values fail with
+	// *AuthRejectedError instead of reaching the server. A 401 blocks the credentials for
+	// the host, a 403 only for the method and path (default: 0 - disabled)
+	AuthRejectionCooldown time.Duration
+
+	// CredentialHeaders lists request headers identifying the credentials of a request
+	// (default: DefaultCredentialHeaders)
+	CredentialHeaders []string
+
+	// MaxInflight limits concurrent requests of the client (default: 0 - unlimited).
+	// Excess requests wait in a queue ordered by WithPriority
+	MaxInflight int
+
+	// MaxQueueDepth limits requests waiting for a slot when MaxInflight is set (default: 100).
+	// When the queue is full, the newest lower-priority waiter is shed with *QueueFullError,
+	// or the new request is rejected if nothing has a lower priority. Negative disables queueing
+	MaxQueueDepth int
+
+	// PriorityHeaders sends the priority set by WithPriority upstream as an RFC 9218 Priority
+	// header: PriorityHigh as "u=1" and PriorityLow as "u=5". Requests with a Priority header,
+	// e.g. set by WithPriorityHeader, keep it
+	PriorityHeaders bool
+
+	// CompressRequests gzip-compresses request bodies of at least CompressMinBytes
+	// and sets Content-Encoding: gzip
+	CompressRequests bool
+
+	// CompressMinBytes is the smallest body compressed by CompressRequests or WithGzipBody
+	// (default: 1024)
+	CompressMinBytes int64
+
+	// AcceptEncoding lists response encodings requested from the server in preference order,
+	// e.g. []string{"br", "zstd", "gzip"}. Responses are decoded by the client; "gzip" and
+	// "deflate" are built in, other encodings need a decoder in ContentDecoders.
+	// Empty keeps the transport's transparent gzip handling
+	AcceptEncoding []string
+
+	// ContentDecoders maps a Content-Encoding (lowercase) to its decoder, e.g. an adapter
+	// for andybalholm/brotli or klauspost/compress/zstd
+	ContentDecoders map[string]ContentDecoder
+
+	// Passthrough leaves content codings untouched for proxy-like services: Accept-Encoding is
+	// sent as the caller set it, responses keep their Content-Encoding, Content-Length and raw
+	// bytes, and request bodies are never compressed. The client's own transport disables
+	// transparent gzip; a custom http.Transport must set DisableCompression
+	Passthrough bool
+
+	// ResumeDownloads resumes GET response bodies that fail mid-stream, e.g. after a
+	// connection reset, with a Range request from the last received byte instead of failing
+	// the read. It applies to 200 responses with Accept-Ranges: bytes, a strong ETag or
+	// Last-Modified (sent as If-Range, so a changed resource is never stitched together)
+	// and no content coding
+	ResumeDownloads bool
+
+	// MaxDownloadResumes limits how many times a response body is resumed (default: 3)
+	MaxDownloadResumes int
+
+	// Profiles are named presets of timeouts, retries and metric labels selected per request
+	// with WithProfile, e.g. one map shared by all clients of a service
+	Profiles map[string]RequestProfile
+
+	// KeepAlive pings critical hosts in the background to keep their connections warm
+	// and reports their reachability, see Client.GetReachability
+	KeepAlive KeepAliveConfig
+
+	// MaxRedirects is the maximum number of redirects followed per request (default: 10).
+	// A negative value disables following redirects
+	MaxRedirects int
+
+	// RedirectPolicy is called before following a redirect, after MaxRedirects and
+	// RedirectAuthPolicy were applied. Returning http.ErrUseLastResponse stops and
+	// returns the redirect response; other errors fail the request
+	RedirectPolicy func(req *http.Request, via []*http.Request) error
+
+	// RedirectAuthPolicy controls credentials on redirects to another origin
+	// (default: RedirectAuthDefault, the net/http behavior)
+	RedirectAuthPolicy RedirectAuthPolicy
+
+	// WebSocketDialer establishes connections for Client.DialWebSocket, usually an adapter
+	// around gorilla/websocket or nhooyr.io/websocket
+	WebSocketDialer WebSocketDialer
+
 	// MetricsEnabled enables/disables metrics collection
 	// Default is true - metrics are enabled
 	MetricsEnabled *bool
 
+	// MetricsSampleRate is the fraction of requests recorded in metrics, from 0 to 1
+	// (default: 1, every request). Sampled counters and histograms undercount by the rate
+	MetricsSampleRate float64
+
 	// MetricsBackend selects the metrics backend
 	// Default is "otel"
 	MetricsBackend MetricsBackend
@@ -74,6 +339,15 @@ type Config struct {
 	// If nil, prometheus.DefaultRegisterer is used
 	PrometheusRegisterer prometheus.Registerer
 
+	// PrometheusRegisterers are additional registerers the metrics are registered in, with any
+	// backend, e.g. a custom registry next to prometheus.DefaultRegisterer
+	PrometheusRegisterers []prometheus.Registerer
+
+	// UniqueMetricsNames rejects a client name already used by an open client with Prometheus
+	// metrics in the same registerer: NewValidated returns the *ConfigurationError and requests
+	// of a client created by New fail with it. By default such clients share their series
+	UniqueMetricsNames bool
+
 	// OTelMeterProvider is an optional OpenTelemetry metrics provider
 	// If nil, otel.GetMeterProvider() is used
 	OTelMeterProvider metric.MeterProvider
@@ -82,6 +356,12 @@ type Config struct {
 	// Default is false to avoid high cardinality with dynamic paths containing IDs
 	// When false, path label will be set to "-" in all metrics
 	IncludePathInMetrics bool
+
+	// MetricsLabels controls host and path labels and adds static labels to metrics
+	MetricsLabels MetricsLabelsConfig
+
+	// MetricsBuckets sets histogram buckets of the Prometheus backend
+	MetricsBuckets MetricsBuckets
 }
 
 // RetryConfig contains retry mechanism settings.
@@ -104,18 +384,92 @@ type RetryConfig struct {
 	// RetryStatusCodes is the list of HTTP status codes for retry
 	RetryStatusCodes []int
 
+	// RetryInProgress retries 409 Conflict and 425 Too Early responses to requests with an
+	// Idempotency-Key: the server still processes an earlier attempt, and a later one gets its result
+	RetryInProgress bool
+
+	// RetryChecksumMismatch retries responses whose body doesn't match WithExpectedChecksum.
+	// The body of each attempt is read into memory to verify it before it's returned, so each
+	// request holds its whole decompressed body; set MaxResponseBodyBytes to bound it
+	RetryChecksumMismatch bool
+
 	// RespectRetryAfter respects the Retry-After header
 	RespectRetryAfter bool
+
+	// MaxRetryAfter caps the delay requested by Retry-After (default: 30s, negative: no cap)
+	MaxRetryAfter time.Duration
+
+	// OnLongRetryAfter decides how to handle a Retry-After delay over MaxRetryAfter:
+	// wait MaxRetryAfter, give up and return the response, or wait the whole delay
+	// (default: RetryAfterCap)
+	OnLongRetryAfter func(resp *http.Response, delay time.Duration) RetryAfterDecision
+
+	// RetryIf retries attempts the status codes and error classification don't,
+	// e.g. specific errors (RetryOnErrors) or error codes in the body (RetryOnJSONField).
+	// Method, attempt and deadline limits still apply
+	RetryIf func(resp *http.Response, err error) bool
+
+	// TimeBudget retries for as long as another attempt is expected to finish within this time
+	// since the request started (or before the context deadline, if earlier), instead of a fixed
+	// number of attempts. The attempt duration is estimated from the moving average latency of
+	// the host. MaxAttempts still caps the attempts (default with TimeBudget: 100)
+	TimeBudget time.Duration
+
+	// MaxBufferedBodyBytes is the largest request body buffered in memory for replay.
+	// Larger bodies without GetBody (see WithBodyProvider) are sent only once.
+	// Zero means no limit.
+	MaxBufferedBodyBytes int64
 }
 
 // RateLimiterConfig contains rate limiter settings.
-// Rate limiter works globally for all client requests.
+// The global limit applies to all client requests that don't match an endpoint limit.
 type RateLimiterConfig struct {
 	// RequestsPerSecond is the maximum number of requests per second.
 	RequestsPerSecond float64
 
 	// BurstCapacity is the bucket size for peak requests.
 	BurstCapacity int
+
+	// Endpoints defines separate buckets keyed by "host", "host/path" or "/path" patterns,
+	// e.g. "api.example.com/v1/search". Hosts may start with "*." to match subdomains,
+	// paths match on segment boundaries. The most specific pattern wins and matching
+	// requests use only that bucket.
+	Endpoints map[string]EndpointRateLimit
+
+	// Registry shares the bucket of each host with the other clients of the registry, e.g.
+	// DefaultLimiterRegistry for all clients of the process. Requests not matching Endpoints
+	// take their tokens from the shared bucket of their host instead of the client's bucket
+	Registry *LimiterRegistry
+
+	// AdaptiveThrottling lowers the rate of requests to a host that responded with
+	// 429 Too Many Requests for the period given by Retry-After or RateLimit-* headers
+	AdaptiveThrottling bool
+
+	// PreemptiveThrottling lowers the rate of requests to a host whose responses announce
+	// a remaining quota (RateLimit-Remaining or X-RateLimit-Remaining) at or below
+	// PreemptiveThreshold, spreading the remaining requests until the quota resets
+	PreemptiveThrottling bool
+
+	// PreemptiveThreshold is the remaining quota that starts preemptive throttling (default: 0)
+	PreemptiveThreshold int
+
+	// ThrottlePeriod is the throttle period when a 429 response has no hints (default: 1s)
+	ThrottlePeriod time.Duration
+
+	// MaxThrottlePeriod caps the throttle period announced by the server (default: 1m)
+	MaxThrottlePeriod time.Duration
+
+	// Clock is the source of time of the token buckets and throttling (default: Config.Clock or real time)
+	Clock Clock
+}
+
+// EndpointRateLimit is the rate limit of requests matching a RateLimiterConfig.Endpoints pattern.
+type EndpointRateLimit struct {
+	// RequestsPerSecond is the maximum number of requests per second.
+	RequestsPerSecond float64
+
+	// BurstCapacity is the bucket size for peak requests (default: RequestsPerSecond, at least 1).
+	BurstCapacity int
 }
 
 // withDefaults applies default values to the configuration.
@@ -129,21 +483,79 @@ func (c Config) withDefaults() Config {
 	}
 
 	if c.Transport == nil {
-		c.Transport = http.DefaultTransport
+		c.TransportTuning = c.TransportTuning.withDefaults()
+		c.Transport = newTransport(c)
 	}
 
 	if c.RetryEnabled {
 		c.RetryConfig = c.RetryConfig.withDefaults()
 	}
 
+	c.LogLevels = c.LogLevels.withDefaults()
+	c.TracingOptions = c.TracingOptions.withDefaults()
+
+	if c.RetryBudgetEnabled {
+		c.RetryBudget = c.RetryBudget.withDefaults()
+	}
+
 	// Circuit breaker is disabled by default. If enabled and not set, use a simple one.
 	if c.CircuitBreakerEnable && c.CircuitBreaker == nil {
-		c.CircuitBreaker = NewSimpleCircuitBreaker()
+		cbConfig := defaultCircuitBreakerConfig()
+		cbConfig.Clock = c.Clock
+		c.CircuitBreaker = NewCircuitBreakerWithConfig(cbConfig)
+	}
+
+	if len(c.Failover.Targets) > 0 {
+		c.Failover = c.Failover.withDefaults()
+	}
+
+	if c.LoadBalancer.Resolver != nil {
+		c.LoadBalancer = c.LoadBalancer.withDefaults()
 	}
 
 	// Rate limiter is disabled by default
 	if c.RateLimiterEnabled {
 		c.RateLimiterConfig = c.RateLimiterConfig.withDefaults()
+		if c.RateLimiterConfig.Clock == nil {
+			c.RateLimiterConfig.Clock = c.Clock
+		}
+	}
+
+	// Hedging is disabled by default
+	if c.HedgingEnabled {
+		c.HedgingConfig = c.HedgingConfig.withDefaults()
+	}
+
+	if c.MaxInflight > 0 && c.MaxQueueDepth == 0 {
+		c.MaxQueueDepth = defaultMaxQueueDepth
+	}
+
+	if c.MaxRedirects == 0 {
+		c.MaxRedirects = defaultMaxRedirects
+	}
+
+	if c.RetryDrainLimit == 0 {
+		c.RetryDrainLimit = defaultRetryDrainLimit
+	}
+
+	if c.CompressMinBytes == 0 {
+		c.CompressMinBytes = defaultCompressMinBytes
+	}
+
+	if c.MaxDownloadResumes == 0 {
+		c.MaxDownloadResumes = defaultDownloadMaxResumes
+	}
+
+	if c.ProbeTTL == 0 {
+		c.ProbeTTL = defaultProbeTTL
+	}
+
+	if c.DeduplicateInflight && c.DeduplicateHeaders == nil {
+		c.DeduplicateHeaders = DefaultDeduplicateHeaders
+	}
+
+	if c.AuthRejectionCooldown > 0 && c.CredentialHeaders == nil {
+		c.CredentialHeaders = DefaultCredentialHeaders
 	}
 
 	// Metrics are enabled by default with OpenTelemetry backend
@@ -160,6 +572,9 @@ func (c Config) withDefaults() Config {
 
 // withDefaults applies default values to the retry configuration.
 func (rc RetryConfig) withDefaults() RetryConfig {
+	if rc.MaxAttempts == 0 && rc.TimeBudget > 0 {
+		rc.MaxAttempts = defaultTimeBudgetMaxAttempts
+	}
 	if rc.MaxAttempts == 0 {
 		rc.MaxAttempts = defaultMaxAttempts
 	}
@@ -195,6 +610,10 @@ func (rc RetryConfig) withDefaults() RetryConfig {
 		rc.RespectRetryAfter = true
 	}
 
+	if rc.MaxRetryAfter == 0 {
+		rc.MaxRetryAfter = defaultMaxRetryAfter
+	}
+
 	return rc
 }
 
@@ -230,5 +649,22 @@ func (rl RateLimiterConfig) withDefaults() RateLimiterConfig {
 		rl.BurstCapacity = int(rl.RequestsPerSecond) // bucket size equals rate
 	}
 
+	if rl.ThrottlePeriod <= 0 {
+		rl.ThrottlePeriod = defaultThrottlePeriod
+	}
+
+	if rl.MaxThrottlePeriod <= 0 {
+		rl.MaxThrottlePeriod = defaultMaxThrottlePeriod
+	}
+
 	return rl
 }
+
+// withDefaults applies default values to the endpoint rate limit.
+func (el EndpointRateLimit) withDefaults() EndpointRateLimit {
+	if el.BurstCapacity == 0 {
+		el.BurstCapacity = max(int(el.RequestsPerSecond), 1)
+	}
+
+	return el
+}
@@ -0,0 +1,475 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the settings of Config loaded by ConfigFromFile and ConfigFromEnv.
+// Unset fields keep the defaults applied by New.
+type fileConfig struct {
+	Timeout               *configDuration `json:"timeout" yaml:"timeout"`
+	PerTryTimeout         *configDuration `json:"per_try_timeout" yaml:"per_try_timeout"`
+	DrainTimeout          *configDuration `json:"drain_timeout" yaml:"drain_timeout"`
+	MaxResponseBodyBytes  *int64          `json:"max_response_body_bytes" yaml:"max_response_body_bytes"`
+	TracingEnabled        *bool           `json:"tracing_enabled" yaml:"tracing_enabled"`
+	SchemaValidation      *string         `json:"schema_validation" yaml:"schema_validation"`
+	AuthRejectionCooldown *configDuration `json:"auth_rejection_cooldown" yaml:"auth_rejection_cooldown"`
+
+	Retry          retryFileConfig          `json:"retry" yaml:"retry"`
+	RateLimiter    rateLimiterFileConfig    `json:"rate_limiter" yaml:"rate_limiter"`
+	CircuitBreaker circuitBreakerFileConfig `json:"circuit_breaker" yaml:"circuit_breaker"`
+	Metrics        metricsFileConfig        `json:"metrics" yaml:"metrics"`
+}
+
+// retryFileConfig is the retry section of fileConfig.
+type retryFileConfig struct {
+	Enabled           *bool           `json:"enabled" yaml:"enabled"`
+	MaxAttempts       *int            `json:"max_attempts" yaml:"max_attempts"`
+	BaseDelay         *configDuration `json:"base_delay" yaml:"base_delay"`
+	MaxDelay          *configDuration `json:"max_delay" yaml:"max_delay"`
+	Jitter            *float64        `json:"jitter" yaml:"jitter"`
+	Methods           []string        `json:"methods" yaml:"methods"`
+	StatusCodes       []int           `json:"status_codes" yaml:"status_codes"`
+	RespectRetryAfter *bool           `json:"respect_retry_after" yaml:"respect_retry_after"`
+	MaxRetryAfter     *configDuration `json:"max_retry_after" yaml:"max_retry_after"`
+	RetryInProgress   *bool           `json:"retry_in_progress" yaml:"retry_in_progress"`
+	TimeBudget        *configDuration `json:"time_budget" yaml:"time_budget"`
+}
+
+// rateLimiterFileConfig is the rate_limiter section of fileConfig.
+type rateLimiterFileConfig struct {
+	Enabled              *bool    `json:"enabled" yaml:"enabled"`
+	RequestsPerSecond    *float64 `json:"requests_per_second" yaml:"requests_per_second"`
+	BurstCapacity        *int     `json:"burst_capacity" yaml:"burst_capacity"`
+	AdaptiveThrottling   *bool    `json:"adaptive_throttling" yaml:"adaptive_throttling"`
+	PreemptiveThrottling *bool    `json:"preemptive_throttling" yaml:"preemptive_throttling"`
+	PreemptiveThreshold  *int     `json:"preemptive_threshold" yaml:"preemptive_threshold"`
+}
+
+// circuitBreakerFileConfig is the circuit_breaker section of fileConfig.
+type circuitBreakerFileConfig struct {
+	Enabled            *bool           `json:"enabled" yaml:"enabled"`
+	Strategy           *string         `json:"strategy" yaml:"strategy"`
+	FailureThreshold   *int            `json:"failure_threshold" yaml:"failure_threshold"`
+	SuccessThreshold   *int            `json:"success_threshold" yaml:"success_threshold"`
+	Timeout            *configDuration `json:"timeout" yaml:"timeout"`
+	Window             *configDuration `json:"window" yaml:"window"`
+	ErrorRateThreshold *float64        `json:"error_rate_threshold" yaml:"error_rate_threshold"`
+	MinimumRequests    *int            `json:"minimum_requests" yaml:"minimum_requests"`
+}
+
+// metricsFileConfig is the metrics section of fileConfig.
+type metricsFileConfig struct {
+	Enabled     *bool   `json:"enabled" yaml:"enabled"`
+	Backend     *string `json:"backend" yaml:"backend"`
+	IncludePath *bool   `json:"include_path" yaml:"include_path"`
+}
+
+// Circuit breaker strategies in configuration files and environment variables.
+const (
+	circuitBreakerStrategyConsecutive = "consecutive"
+	circuitBreakerStrategyErrorRate   = "error_rate"
+)
+
+// Schema validation modes in configuration files and environment variables.
+const (
+	schemaValidationEnforce = "enforce"
+	schemaValidationReport  = "report"
+	schemaValidationOff     = "off"
+)
+
+// configDuration is a duration written as a string such as "1.5s" or "300ms".
+type configDuration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *configDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %s", data)
+	}
+	return d.parse(s)
+}
+
+// UnmarshalYAML parses a duration string.
+func (d *configDuration) UnmarshalYAML(value *yaml.Node) error {
+	return d.parse(value.Value)
+}
+
+// parse parses a duration string.
+func (d *configDuration) parse(s string) error {
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = configDuration(duration)
+	return nil
+}
+
+// ConfigFromFile loads the configuration from a JSON (.json) or YAML (.yaml, .yml) file.
+// Durations are strings such as "5s"; unknown keys are errors. Unset values keep the
+// defaults of New. The result is checked with Validate, and all problems are returned.
+//
+// The keys are timeout, per_try_timeout, drain_timeout, auth_rejection_cooldown,
+// max_response_body_bytes, tracing_enabled, schema_validation (enforce, report or off)
+// and the sections
+//   - retry: enabled, max_attempts, base_delay, max_delay, jitter, methods, status_codes,
+//     respect_retry_after, max_retry_after, retry_in_progress, time_budget
+//   - rate_limiter: enabled, requests_per_second, burst_capacity, adaptive_throttling,
+//     preemptive_throttling, preemptive_threshold
+//   - circuit_breaker: enabled, strategy (consecutive or error_rate), failure_threshold,
+//     success_threshold, timeout, window, error_rate_threshold, minimum_requests
+//   - metrics: enabled, backend (prometheus or otel), include_path
+func ConfigFromFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var fc fileConfig
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&fc)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&fc)
+	default:
+		return Config{}, NewConfigurationError("path", path, "unsupported file extension "+ext)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return fc.config()
+}
+
+// ConfigFromEnv loads the configuration from environment variables named after the keys of
+// ConfigFromFile in upper case, joined with "_" to the section and the prefix, e.g. with the
+// prefix "PAYMENTS": PAYMENTS_TIMEOUT=10s, PAYMENTS_RETRY_MAX_ATTEMPTS=5,
+// PAYMENTS_RETRY_STATUS_CODES=502,503 or PAYMENTS_METRICS_BACKEND=otel. Lists are
+// comma-separated. The result is checked with Validate, and all problems are returned.
+func ConfigFromEnv(prefix string) (Config, error) {
+	var fc fileConfig
+	if errs := loadEnv(reflect.ValueOf(&fc).Elem(), strings.TrimSuffix(prefix, "_")); len(errs) > 0 {
+		return Config{}, errors.Join(errs...)
+	}
+	return fc.config()
+}
+
+// loadEnv sets the fields of a fileConfig section from environment variables.
+func loadEnv(section reflect.Value, prefix string) []error {
+	var errs []error
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Type().Field(i)
+		name := strings.ToUpper(strings.Split(field.Tag.Get("json"), ",")[0])
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		if field.Type.Kind() == reflect.Struct {
+			errs = append(errs, loadEnv(section.Field(i), name)...)
+			continue
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setEnvValue(section.Field(i), raw); err != nil {
+			errs = append(errs, NewConfigurationError(name, raw, err.Error()))
+		}
+	}
+	return errs
+}
+
+// setEnvValue parses raw into a pointer or slice field of fileConfig.
+func setEnvValue(field reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	if field.Kind() == reflect.Slice {
+		parts := strings.Split(raw, ",")
+		slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := parseEnvScalar(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	value := reflect.New(field.Type().Elem())
+	if err := parseEnvScalar(value.Elem(), raw); err != nil {
+		return err
+	}
+	field.Set(value)
+	return nil
+}
+
+// parseEnvScalar parses raw into a bool, number, string or duration value.
+func parseEnvScalar(value reflect.Value, raw string) error {
+	if value.Type() == reflect.TypeOf(configDuration(0)) {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(duration))
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		value.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		value.SetFloat(parsed)
+	default:
+		value.SetString(raw)
+	}
+	return nil
+}
+
+// config converts the loaded settings to a validated Config.
+func (fc fileConfig) config() (Config, error) {
+	var errs []error
+	config := Config{
+		Timeout:               durationValue(fc.Timeout),
+		PerTryTimeout:         durationValue(fc.PerTryTimeout),
+		DrainTimeout:          durationValue(fc.DrainTimeout),
+		MaxResponseBodyBytes:  deref(fc.MaxResponseBodyBytes),
+		TracingEnabled:        deref(fc.TracingEnabled),
+		AuthRejectionCooldown: durationValue(fc.AuthRejectionCooldown),
+		RetryEnabled:          deref(fc.Retry.Enabled),
+		RetryConfig: RetryConfig{
+			MaxAttempts:       deref(fc.Retry.MaxAttempts),
+			BaseDelay:         durationValue(fc.Retry.BaseDelay),
+			MaxDelay:          durationValue(fc.Retry.MaxDelay),
+			Jitter:            deref(fc.Retry.Jitter),
+			RetryMethods:      fc.Retry.Methods,
+			RetryStatusCodes:  fc.Retry.StatusCodes,
+			RespectRetryAfter: deref(fc.Retry.RespectRetryAfter),
+			MaxRetryAfter:     durationValue(fc.Retry.MaxRetryAfter),
+			RetryInProgress:   deref(fc.Retry.RetryInProgress),
+			TimeBudget:        durationValue(fc.Retry.TimeBudget),
+		},
+		RateLimiterEnabled: deref(fc.RateLimiter.Enabled),
+		RateLimiterConfig: RateLimiterConfig{
+			RequestsPerSecond:    deref(fc.RateLimiter.RequestsPerSecond),
+			BurstCapacity:        deref(fc.RateLimiter.BurstCapacity),
+			AdaptiveThrottling:   deref(fc.RateLimiter.AdaptiveThrottling),
+			PreemptiveThrottling: deref(fc.RateLimiter.PreemptiveThrottling),
+			PreemptiveThreshold:  deref(fc.RateLimiter.PreemptiveThreshold),
+		},
+		CircuitBreakerEnable: deref(fc.CircuitBreaker.Enabled),
+		MetricsEnabled:       fc.Metrics.Enabled,
+		MetricsBackend:       MetricsBackend(deref(fc.Metrics.Backend)),
+		IncludePathInMetrics: deref(fc.Metrics.IncludePath),
+	}
+
+	switch mode := deref(fc.SchemaValidation); mode {
+	case "", schemaValidationEnforce:
+	case schemaValidationReport:
+		config.SchemaValidation = SchemaValidationReport
+	case schemaValidationOff:
+		config.SchemaValidation = SchemaValidationOff
+	default:
+		errs = append(errs, NewConfigurationError("schema_validation", mode,
+			"must be "+schemaValidationEnforce+", "+schemaValidationReport+" or "+schemaValidationOff))
+	}
+
+	cb := fc.CircuitBreaker
+	cbConfig := defaultCircuitBreakerConfig()
+	overlay(&cbConfig.FailureThreshold, cb.FailureThreshold)
+	overlay(&cbConfig.SuccessThreshold, cb.SuccessThreshold)
+	overlay(&cbConfig.Timeout, (*time.Duration)(cb.Timeout))
+	overlay(&cbConfig.Window, (*time.Duration)(cb.Window))
+	overlay(&cbConfig.ErrorRateThreshold, cb.ErrorRateThreshold)
+	overlay(&cbConfig.MinimumRequests, cb.MinimumRequests)
+	switch strategy := deref(cb.Strategy); strategy {
+	case "", circuitBreakerStrategyConsecutive:
+	case circuitBreakerStrategyErrorRate:
+		cbConfig.Strategy = CircuitBreakerErrorRate
+	default:
+		errs = append(errs, NewConfigurationError("circuit_breaker.strategy", strategy,
+			"must be "+circuitBreakerStrategyConsecutive+" or "+circuitBreakerStrategyErrorRate))
+	}
+	errs = append(errs, cbConfig.validate()...)
+	cbConfigured := cb.Strategy != nil || cb.FailureThreshold != nil || cb.SuccessThreshold != nil ||
+		cb.Timeout != nil || cb.Window != nil || cb.ErrorRateThreshold != nil || cb.MinimumRequests != nil
+	if config.CircuitBreakerEnable && cbConfigured {
+		config.CircuitBreaker = NewCircuitBreakerWithConfig(cbConfig)
+	}
+
+	if err := config.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return Config{}, errors.Join(errs...)
+	}
+	return config, nil
+}
+
+// deref returns the value of an optional setting, or the zero value.
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
+
+// overlay sets dst to an optional value when it's set.
+func overlay[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// durationValue returns an optional duration, or zero.
+func durationValue(d *configDuration) time.Duration {
+	return time.Duration(deref(d))
+}
+
+// Validate checks the configuration and returns all problems joined with errors.Join,
+// each a *ConfigurationError or *DeadlineBudgetError. Zero values are valid and select defaults.
+func (c Config) Validate() error {
+	var errs []error
+	nonNegative := func(field string, d time.Duration) {
+		if d < 0 {
+			errs = append(errs, NewConfigurationError(field, d, "must not be negative"))
+		}
+	}
+	nonNegative("Timeout", c.Timeout)
+	nonNegative("PerTryTimeout", c.PerTryTimeout)
+	nonNegative("DrainTimeout", c.DrainTimeout)
+	nonNegative("AuthRejectionCooldown", c.AuthRejectionCooldown)
+	if c.MaxResponseBodyBytes < 0 {
+		errs = append(errs, NewConfigurationError("MaxResponseBodyBytes", c.MaxResponseBodyBytes, "must not be negative"))
+	}
+
+	if _, err := parseBaseURL(c.BaseURL); err != nil {
+		errs = append(errs, err)
+	}
+
+	retry := c.RetryConfig
+	if retry.MaxAttempts < 0 {
+		errs = append(errs, NewConfigurationError("RetryConfig.MaxAttempts", retry.MaxAttempts, "must not be negative"))
+	}
+	nonNegative("RetryConfig.BaseDelay", retry.BaseDelay)
+	nonNegative("RetryConfig.MaxDelay", retry.MaxDelay)
+	nonNegative("RetryConfig.TimeBudget", retry.TimeBudget)
+	if retry.BaseDelay > 0 && retry.MaxDelay > 0 && retry.BaseDelay > retry.MaxDelay {
+		errs = append(errs, NewConfigurationError("RetryConfig.BaseDelay", retry.BaseDelay, "must not exceed MaxDelay"))
+	}
+	if retry.Jitter < 0 || retry.Jitter > 1 {
+		errs = append(errs, NewConfigurationError("RetryConfig.Jitter", retry.Jitter, "must be between 0 and 1"))
+	}
+	for _, code := range retry.RetryStatusCodes {
+		if code < 100 || code > 599 {
+			errs = append(errs, NewConfigurationError("RetryConfig.RetryStatusCodes", code, "is not an HTTP status code"))
+		}
+	}
+
+	if c.RateLimiterEnabled && c.RateLimiterConfig.RequestsPerSecond < 0 {
+		errs = append(errs, NewConfigurationError("RateLimiterConfig.RequestsPerSecond",
+			c.RateLimiterConfig.RequestsPerSecond, "must not be negative"))
+	}
+	if c.RateLimiterConfig.BurstCapacity < 0 {
+		errs = append(errs, NewConfigurationError("RateLimiterConfig.BurstCapacity",
+			c.RateLimiterConfig.BurstCapacity, "must not be negative"))
+	}
+	if c.RateLimiterConfig.PreemptiveThreshold < 0 {
+		errs = append(errs, NewConfigurationError("RateLimiterConfig.PreemptiveThreshold",
+			c.RateLimiterConfig.PreemptiveThreshold, "must not be negative"))
+	}
+
+	errs = append(errs, validateRequestLabels(c.MetricsLabels)...)
+	errs = append(errs, validateSLOs(c.SLOs)...)
+	errs = append(errs, validateProfiles(c.Profiles)...)
+
+	if c.MetricsSampleRate < 0 || c.MetricsSampleRate > 1 {
+		errs = append(errs, NewConfigurationError("MetricsSampleRate", c.MetricsSampleRate, "must be between 0 and 1"))
+	}
+
+	for _, host := range c.KeepAlive.Hosts {
+		if _, err := warmupURL(host); err != nil {
+			errs = append(errs, NewConfigurationError("KeepAlive.Hosts", host, "must be an http or https origin"))
+		}
+	}
+	switch c.KeepAlive.Method {
+	case "", http.MethodHead, http.MethodOptions:
+	default:
+		errs = append(errs, NewConfigurationError("KeepAlive.Method", c.KeepAlive.Method, "must be HEAD or OPTIONS"))
+	}
+
+	if c.Passthrough {
+		if transport, ok := c.Transport.(*http.Transport); ok && !transport.DisableCompression {
+			errs = append(errs, NewConfigurationError("Transport.DisableCompression", transport.DisableCompression,
+				"must be true with Passthrough"))
+		}
+		if c.CompressRequests || len(c.AcceptEncoding) > 0 {
+			errs = append(errs, NewConfigurationError("Passthrough", c.Passthrough,
+				"conflicts with CompressRequests and AcceptEncoding"))
+		}
+	}
+
+	switch c.MetricsBackend {
+	case "", MetricsBackendPrometheus, MetricsBackendOpenTelemetry:
+	default:
+		errs = append(errs, NewConfigurationError("MetricsBackend", c.MetricsBackend,
+			fmt.Sprintf("must be %q or %q", MetricsBackendPrometheus, MetricsBackendOpenTelemetry)))
+	}
+
+	// Only explicit timeouts are compared: the defaults with retries don't fit the budget
+	maxAttempts := validatedAttempts(c.RetryEnabled, retry.withDefaults())
+	if err := validateTimeoutBudget(c.Timeout, c.PerTryTimeout, maxAttempts); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// validate returns the problems of the circuit breaker settings.
+func (c CircuitBreakerConfig) validate() []error {
+	var errs []error
+	if c.FailureThreshold < 0 {
+		errs = append(errs, NewConfigurationError("circuit_breaker.failure_threshold", c.FailureThreshold, "must not be negative"))
+	}
+	if c.SuccessThreshold < 0 {
+		errs = append(errs, NewConfigurationError("circuit_breaker.success_threshold", c.SuccessThreshold, "must not be negative"))
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, NewConfigurationError("circuit_breaker.timeout", c.Timeout, "must not be negative"))
+	}
+	if c.Window < 0 {
+		errs = append(errs, NewConfigurationError("circuit_breaker.window", c.Window, "must not be negative"))
+	}
+	if c.ErrorRateThreshold < 0 || c.ErrorRateThreshold > 1 {
+		errs = append(errs, NewConfigurationError("circuit_breaker.error_rate_threshold",
+			c.ErrorRateThreshold, "must be between 0 and 1"))
+	}
+	if c.MinimumRequests < 0 {
+		errs = append(errs, NewConfigurationError("circuit_breaker.minimum_requests", c.MinimumRequests, "must not be negative"))
+	}
+	return errs
+}
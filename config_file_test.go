@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFile writes a configuration file into a temporary directory.
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// assertBreakerDefaults checks that the breaker opens after the given number of failures, then needs
+// the open timeout and defaultSuccessThreshold successes to close.
+func assertBreakerDefaults(t *testing.T, breaker CircuitBreaker, failures int, timeout time.Duration) {
+	t.Helper()
+	cb, ok := breaker.(*SimpleCircuitBreaker)
+	require.True(t, ok)
+	clock := NewFakeClock(time.Now())
+	cb.clock = clock
+	fail := func() (*http.Response, error) { return nil, errors.New("connection refused") }
+	succeed := func() (*http.Response, error) { return &http.Response{StatusCode: http.StatusOK}, nil }
+
+	for range failures - 1 {
+		_, _ = cb.Execute(fail)
+	}
+	require.Equal(t, CircuitBreakerClosed, cb.State())
+	_, _ = cb.Execute(fail)
+	require.Equal(t, CircuitBreakerOpen, cb.State())
+
+	clock.Advance(timeout)
+	_, err := cb.Execute(succeed)
+	require.ErrorIs(t, err, ErrCircuitBreakerOpen)
+	clock.Advance(time.Nanosecond)
+	for range defaultSuccessThreshold - 1 {
+		_, err = cb.Execute(succeed)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, CircuitBreakerHalfOpen, cb.State())
+	_, _ = cb.Execute(succeed)
+	assert.Equal(t, CircuitBreakerClosed, cb.State())
+}
+
+func TestConfigFromFile_YAML(t *testing.T) {
+	t.Parallel()
+	path := writeConfigFile(t, "client.yaml", `
+timeout: 20s
+per_try_timeout: 5s
+retry:
+  enabled: true
+  max_attempts: 4
+  base_delay: 200ms
+  status_codes: [502, 503]
+rate_limiter:
+  enabled: true
+  requests_per_second: 50
+circuit_breaker:
+  enabled: true
+  strategy: error_rate
+  window: 30s
+metrics:
+  backend: otel
+`)
+	config, err := ConfigFromFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 20*time.Second, config.Timeout)
+	assert.Equal(t, 5*time.Second, config.PerTryTimeout)
+	assert.True(t, config.RetryEnabled)
+	assert.Equal(t, 4, config.RetryConfig.MaxAttempts)
+	assert.Equal(t, 200*time.Millisecond, config.RetryConfig.BaseDelay)
+	assert.Equal(t, []int{502, 503}, config.RetryConfig.RetryStatusCodes)
+	assert.True(t, config.RateLimiterEnabled)
+	assert.InDelta(t, 50.0, config.RateLimiterConfig.RequestsPerSecond, 0.001)
+	assert.True(t, config.CircuitBreakerEnable)
+	assertBreakerDefaults(t, config.CircuitBreaker, defaultMinimumRequests, defaultCircuitTimeout)
+	assert.Equal(t, MetricsBackendOpenTelemetry, config.MetricsBackend)
+	assert.Nil(t, config.MetricsEnabled)
+
+	client := New(config, "test-config-file")
+	defer client.Close()
+}
+
+func TestConfigFromFile_JSON(t *testing.T) {
+	t.Parallel()
+	path := writeConfigFile(t, "client.json", `{"timeout": "3s", "metrics": {"enabled": false}}`)
+	config, err := ConfigFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Second, config.Timeout)
+	require.NotNil(t, config.MetricsEnabled)
+	assert.False(t, *config.MetricsEnabled)
+
+	config, err = ConfigFromFile(writeConfigFile(t, "breaker.json",
+		`{"circuit_breaker": {"enabled": true, "timeout": "2s"}}`))
+	require.NoError(t, err)
+	assertBreakerDefaults(t, config.CircuitBreaker, defaultFailureThreshold, 2*time.Second)
+
+	_, err = ConfigFromFile(writeConfigFile(t, "typo.json", `{"timout": "3s"}`))
+	assert.ErrorContains(t, err, `unknown field "timout"`)
+	_, err = ConfigFromFile(writeConfigFile(t, "client.toml", `timeout = "3s"`))
+	assert.ErrorContains(t, err, "unsupported file extension .toml")
+}
+
+func TestConfigFromFile_AllProblems(t *testing.T) {
+	t.Parallel()
+	path := writeConfigFile(t, "client.yml", `
+timeout: -1s
+retry:
+  jitter: 1.5
+circuit_breaker:
+  strategy: random
+metrics:
+  backend: statsd
+`)
+	_, err := ConfigFromFile(path)
+	require.Error(t, err)
+
+	var fields []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var configErr *ConfigurationError
+		if errors.As(e, &configErr) {
+			fields = append(fields, configErr.Field)
+		}
+	}
+	assert.Contains(t, fields, "circuit_breaker.strategy")
+	assert.Contains(t, err.Error(), "Timeout")
+	assert.Contains(t, err.Error(), "RetryConfig.Jitter")
+	assert.Contains(t, err.Error(), "MetricsBackend")
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("PAYMENTS_TIMEOUT", "10s")
+	t.Setenv("PAYMENTS_RETRY_ENABLED", "true")
+	t.Setenv("PAYMENTS_RETRY_MAX_ATTEMPTS", "2")
+	t.Setenv("PAYMENTS_RETRY_METHODS", "GET, PUT")
+	t.Setenv("PAYMENTS_CIRCUIT_BREAKER_ENABLED", "1")
+	t.Setenv("PAYMENTS_CIRCUIT_BREAKER_FAILURE_THRESHOLD", "7")
+	t.Setenv("PAYMENTS_METRICS_INCLUDE_PATH", "true")
+	t.Setenv("PAYMENTS_SCHEMA_VALIDATION", "off")
+
+	config, err := ConfigFromEnv("PAYMENTS_")
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, config.Timeout)
+	assert.True(t, config.RetryEnabled)
+	assert.Equal(t, 2, config.RetryConfig.MaxAttempts)
+	assert.Equal(t, []string{"GET", "PUT"}, config.RetryConfig.RetryMethods)
+	assert.True(t, config.CircuitBreakerEnable)
+	assertBreakerDefaults(t, config.CircuitBreaker, 7, defaultCircuitTimeout)
+	assert.True(t, config.IncludePathInMetrics)
+	assert.Equal(t, SchemaValidationOff, config.SchemaValidation)
+}
+
+func TestConfigFromEnv_InvalidValues(t *testing.T) {
+	t.Setenv("BROKEN_TIMEOUT", "ten seconds")
+	t.Setenv("BROKEN_RETRY_MAX_ATTEMPTS", "many")
+
+	_, err := ConfigFromEnv("BROKEN")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "BROKEN_TIMEOUT")
+	assert.Contains(t, err.Error(), "configuration error in field 'BROKEN_RETRY_MAX_ATTEMPTS': invalid integer")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, Config{}.Validate())
+	require.NoError(t, Config{RetryEnabled: true}.Validate(), "defaults are not checked against the timeout budget")
+
+	err := Config{
+		Timeout:       time.Second,
+		PerTryTimeout: time.Second,
+		RetryEnabled:  true,
+		RetryConfig:   RetryConfig{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Millisecond},
+	}.Validate()
+	var budgetErr *DeadlineBudgetError
+	assert.ErrorAs(t, err, &budgetErr)
+	assert.Contains(t, err.Error(), "must not exceed MaxDelay")
+}
@@ -0,0 +1,294 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// minReapInterval bounds how often the reaper checks connection lifetimes.
+const minReapInterval = 10 * time.Millisecond
+
+// PoolStats describes the connections of the transport built by the client to a single
+// address. See TransportTuning.TrackConnections.
+type PoolStats struct {
+	// Addr is the dialed host:port (the proxy address for proxied requests)
+	Addr string
+	// Open is the number of open connections
+	Open int
+	// Active is the number of connections serving requests
+	Active int
+	// Idle is the number of connections waiting in the pool
+	Idle int
+	// Opened and Closed count connections opened and closed since the client was created
+	Opened int64
+	Closed int64
+	// Reaped counts idle connections closed because they exceeded ConnMaxLifetime
+	Reaped int64
+	// Acquired counts connections handed to requests, Reused those taken from the pool
+	Acquired int64
+	Reused   int64
+	// WaitTime is the total time requests waited for a connection, including dials
+	WaitTime time.Duration
+	// MaxWait is the longest wait for a connection
+	MaxWait time.Duration
+}
+
+// AvgWait returns the average time requests waited for a connection.
+func (s PoolStats) AvgWait() time.Duration {
+	if s.Acquired == 0 {
+		return 0
+	}
+	return s.WaitTime / time.Duration(s.Acquired)
+}
+
+// connPool tracks the connections dialed by the transport and the requests using them.
+type connPool struct {
+	maxLifetime time.Duration
+
+	mu    sync.Mutex
+	addrs map[string]*addrPool
+}
+
+// addrPool is the state of the connections to a single address.
+type addrPool struct {
+	conns map[*pooledConn]struct{}
+	stats PoolStats
+}
+
+// pooledConn is a tracked connection.
+type pooledConn struct {
+	net.Conn
+	pool    *connPool
+	addr    string
+	created time.Time
+	// streams and closed are guarded by pool.mu
+	streams int
+	closed  bool
+}
+
+// newConnPool creates a tracker closing idle connections older than maxLifetime (zero: never).
+func newConnPool(maxLifetime time.Duration) *connPool {
+	return &connPool{maxLifetime: maxLifetime, addrs: make(map[string]*addrPool)}
+}
+
+// dialContext wraps a dial function to track the connections it opens.
+func (p *connPool) dialContext(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		pc := &pooledConn{Conn: conn, pool: p, addr: addr, created: time.Now()}
+		p.mu.Lock()
+		ap := p.addrPool(addr)
+		ap.conns[pc] = struct{}{}
+		ap.stats.Opened++
+		p.mu.Unlock()
+		return pc, nil
+	}
+}
+
+// addrPool returns the state of the address, creating it. p.mu must be held.
+func (p *connPool) addrPool(addr string) *addrPool {
+	ap := p.addrs[addr]
+	if ap == nil {
+		ap = &addrPool{conns: make(map[*pooledConn]struct{}), stats: PoolStats{Addr: addr}}
+		p.addrs[addr] = ap
+	}
+	return ap
+}
+
+// Close closes the connection and stops tracking it.
+func (c *pooledConn) Close() error {
+	c.pool.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		ap := c.pool.addrs[c.addr]
+		delete(ap.conns, c)
+		ap.stats.Closed++
+	}
+	c.pool.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// acquire records a request getting the connection after waiting for it.
+func (p *connPool) acquire(info httptrace.GotConnInfo, wait time.Duration) *pooledConn {
+	pc := unwrapPooledConn(info.Conn)
+	if pc == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc.streams++
+	stats := &p.addrPool(pc.addr).stats
+	stats.Acquired++
+	if info.Reused {
+		stats.Reused++
+	}
+	stats.WaitTime += wait
+	stats.MaxWait = max(stats.MaxWait, wait)
+	return pc
+}
+
+// release records a request done with the connection.
+func (p *connPool) release(pc *pooledConn) {
+	p.mu.Lock()
+	pc.streams--
+	p.mu.Unlock()
+}
+
+// unwrapPooledConn returns the tracked connection under a TLS connection, if any.
+func unwrapPooledConn(conn net.Conn) *pooledConn {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	pc, _ := conn.(*pooledConn)
+	return pc
+}
+
+// stats returns the state of every address ordered by address.
+func (p *connPool) stats() []PoolStats {
+	p.mu.Lock()
+	result := make([]PoolStats, 0, len(p.addrs))
+	for _, ap := range p.addrs {
+		stats := ap.stats
+		for pc := range ap.conns {
+			stats.Open++
+			if pc.streams > 0 {
+				stats.Active++
+			} else {
+				stats.Idle++
+			}
+		}
+		result = append(result, stats)
+	}
+	p.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Addr < result[j].Addr })
+	return result
+}
+
+// reap closes idle connections older than the maximum lifetime.
+func (p *connPool) reap(now time.Time) {
+	var expired []*pooledConn
+	p.mu.Lock()
+	for _, ap := range p.addrs {
+		for pc := range ap.conns {
+			if pc.streams == 0 && now.Sub(pc.created) >= p.maxLifetime {
+				expired = append(expired, pc)
+				ap.stats.Reaped++
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	for _, pc := range expired {
+		_ = pc.Close()
+	}
+}
+
+// startReaper closes expired idle connections in the background until stop is called.
+func (p *connPool) startReaper() (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(max(p.maxLifetime/4, minReapInterval))
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				p.reap(now)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// poolRoundTripper records which tracked connection serves each request.
+type poolRoundTripper struct {
+	base http.RoundTripper
+	pool *connPool
+}
+
+// RoundTrip sends the request and releases its connection when the response body is done.
+func (rt *poolRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var (
+		conn    *pooledConn
+		getConn time.Time
+	)
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) { getConn = time.Now() },
+		GotConn: func(info httptrace.GotConnInfo) {
+			// The transport may retry a request on another connection
+			if conn != nil {
+				rt.pool.release(conn)
+			}
+			conn = rt.pool.acquire(info, time.Since(getConn))
+		},
+	}
+	resp, err := rt.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if conn == nil {
+		return resp, err
+	}
+	if err != nil || resp.Body == nil || resp.Body == http.NoBody {
+		rt.pool.release(conn)
+		return resp, err
+	}
+	resp.Body = &pooledBody{ReadCloser: resp.Body, pool: rt.pool, conn: conn}
+	return resp, nil
+}
+
+// CloseIdleConnections closes idle connections of the base transport.
+func (rt *poolRoundTripper) CloseIdleConnections() {
+	closeIdleConnections(rt.base)
+}
+
+// pooledBody releases the connection of a response once the body is read or closed.
+type pooledBody struct {
+	io.ReadCloser
+	pool *connPool
+	conn *pooledConn
+	once sync.Once
+}
+
+// Read reads the body and releases the connection at EOF.
+func (b *pooledBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.done()
+	}
+	return n, err
+}
+
+// Close closes the body and releases the connection.
+func (b *pooledBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+// done releases the connection once.
+func (b *pooledBody) done() {
+	b.once.Do(func() { b.pool.release(b.conn) })
+}
+
+// PoolStats returns the connections of the transport built by the client per dialed address.
+// It returns nil unless TransportTuning.TrackConnections or ConnMaxLifetime is set and
+// Config.Transport is nil.
+func (c *Client) PoolStats() []PoolStats {
+	if c.pool == nil {
+		return nil
+	}
+	return c.pool.stats()
+}
@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolStats_TracksConnections(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stream" {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-release
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := New(Config{TransportTuning: TransportTuning{TrackConnections: true}}, "test-pool-stats")
+	defer client.Close()
+
+	for range 3 {
+		resp, err := client.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	stats := client.PoolStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, mustHostPort(t, server.URL), stats[0].Addr)
+	assert.Equal(t, 1, stats[0].Open)
+	assert.Equal(t, 1, stats[0].Idle)
+	assert.Equal(t, 0, stats[0].Active)
+	assert.Equal(t, int64(1), stats[0].Opened)
+	assert.Equal(t, int64(3), stats[0].Acquired)
+	assert.Equal(t, int64(2), stats[0].Reused)
+	assert.Positive(t, stats[0].MaxWait)
+	assert.LessOrEqual(t, stats[0].AvgWait(), stats[0].MaxWait)
+
+	resp, err := client.Get(context.Background(), server.URL+"/stream")
+	require.NoError(t, err)
+	stats = client.PoolStats()
+	assert.Equal(t, 1, stats[0].Active)
+	assert.Equal(t, 0, stats[0].Idle)
+
+	close(release)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, resp.Body.Close())
+	stats = client.PoolStats()
+	assert.Equal(t, 0, stats[0].Active)
+	assert.Equal(t, 1, stats[0].Idle)
+}
+
+func TestPoolStats_Reaper(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Body: "ok"})
+	defer server.Close()
+
+	client := New(Config{TransportTuning: TransportTuning{ConnMaxLifetime: 50 * time.Millisecond}}, "test-pool-reaper")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, 1, client.PoolStats()[0].Open)
+
+	require.Eventually(t, func() bool {
+		return client.PoolStats()[0].Open == 0
+	}, 2*time.Second, 10*time.Millisecond)
+	stats := client.PoolStats()[0]
+	assert.Equal(t, int64(1), stats.Reaped)
+	assert.Equal(t, int64(1), stats.Closed)
+
+	// The transport dials a new connection after the reaped one
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, int64(2), client.PoolStats()[0].Opened)
+}
+
+func TestPoolStats_ReaperSkipsActiveConnections(t *testing.T) {
+	t.Parallel()
+	pool := newConnPool(time.Millisecond)
+	active := &pooledConn{pool: pool, addr: "example.com:80", created: time.Now().Add(-time.Hour), streams: 1}
+	pool.addrPool(active.addr).conns[active] = struct{}{}
+
+	pool.reap(time.Now())
+	stats := pool.stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, 1, stats[0].Active)
+	assert.Zero(t, stats[0].Reaped)
+}
+
+func TestPoolStats_Disabled(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-pool-stats-disabled")
+	defer client.Close()
+	assert.Nil(t, client.PoolStats())
+
+	custom := New(Config{
+		Transport:       &http.Transport{},
+		TransportTuning: TransportTuning{TrackConnections: true},
+	}, "test-pool-stats-custom")
+	defer custom.Close()
+	assert.Nil(t, custom.PoolStats())
+}
+
+func TestPoolStats_AvgWait(t *testing.T) {
+	t.Parallel()
+	assert.Zero(t, PoolStats{}.AvgWait())
+	assert.Equal(t, 20*time.Millisecond, PoolStats{Acquired: 3, WaitTime: 60 * time.Millisecond}.AvgWait())
+}
+
+// mustHostPort returns the host:port of rawURL.
+func mustHostPort(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u.Host
+}
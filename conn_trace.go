@@ -0,0 +1,112 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// phaseTiming is the measured duration of a single connection phase.
+type phaseTiming struct {
+	phase    string
+	duration time.Duration
+}
+
+// connTrace collects connection phase timings of a single attempt via net/http/httptrace.
+type connTrace struct {
+	mu           sync.Mutex
+	getConn      time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+// withConnTrace attaches a new connTrace to the context.
+func withConnTrace(ctx context.Context) (context.Context, *connTrace) {
+	ct := &connTrace{}
+	return httptrace.WithClientTrace(ctx, ct.clientTrace()), ct
+}
+
+// clientTrace returns httptrace hooks recording phase timestamps.
+func (ct *connTrace) clientTrace() *httptrace.ClientTrace {
+	mark := func(field *time.Time) {
+		ct.mu.Lock()
+		if field.IsZero() {
+			*field = time.Now()
+		}
+		ct.mu.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		GetConn: func(string) { mark(&ct.getConn) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			ct.mu.Lock()
+			ct.reused = info.Reused
+			ct.mu.Unlock()
+		},
+		DNSStart:             func(httptrace.DNSStartInfo) { mark(&ct.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { mark(&ct.dnsDone) },
+		ConnectStart:         func(string, string) { mark(&ct.connectStart) },
+		ConnectDone:          func(string, string, error) { mark(&ct.connectDone) },
+		TLSHandshakeStart:    func() { mark(&ct.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { mark(&ct.tlsDone) },
+		GotFirstResponseByte: func() { mark(&ct.firstByte) },
+	}
+}
+
+// phases returns durations of the phases that actually happened during the attempt.
+// Reused connections report only time to first byte.
+func (ct *connTrace) phases() []phaseTiming {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	var timings []phaseTiming
+	add := func(phase string, start, end time.Time) {
+		if !start.IsZero() && !end.IsZero() && !end.Before(start) {
+			timings = append(timings, phaseTiming{phase: phase, duration: end.Sub(start)})
+		}
+	}
+
+	add(PhaseDNS, ct.dnsStart, ct.dnsDone)
+	add(PhaseConnect, ct.connectStart, ct.connectDone)
+	add(PhaseTLS, ct.tlsStart, ct.tlsDone)
+	add(PhaseTTFB, ct.getConn, ct.firstByte)
+
+	return timings
+}
+
+// connectionReused reports whether the attempt used a pooled connection.
+func (ct *connTrace) connectionReused() bool {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.reused
+}
+
+// recordConnTrace exports phase timings as metrics and span events.
+func (rt *RoundTripper) recordConnTrace(retryCtx *retryContext, attempt int, ct *connTrace) {
+	method := retryCtx.originalReq.Method
+	for _, timing := range ct.phases() {
+		rt.metrics.RecordPhaseDuration(retryCtx.ctx, timing.duration.Seconds(), timing.phase, method, retryCtx.host)
+		if retryCtx.span != nil {
+			retryCtx.span.AddEvent("http.phase", trace.WithAttributes(
+				attribute.String("http.phase", timing.phase),
+				attribute.Float64("http.phase_duration_seconds", timing.duration.Seconds()),
+				attribute.Int("http.attempt", attempt),
+			))
+		}
+	}
+
+	if retryCtx.span != nil {
+		retryCtx.span.SetAttributes(attribute.Bool("http.connection_reused", ct.connectionReused()))
+	}
+}
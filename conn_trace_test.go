@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// phaseSampleCounts gathers http_client_phase_duration_seconds sample counts by phase.
+func phaseSampleCounts(t *testing.T, reg *prometheus.Registry) map[string]uint64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != MetricPhaseDuration {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "phase" {
+					counts[label.GetValue()] += m.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return counts
+}
+
+func TestHTTPTrace_RecordsPhaseMetrics(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		Transport:            server.Client().Transport,
+		HTTPTraceEnabled:     true,
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+	}, "test-httptrace")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	counts := phaseSampleCounts(t, reg)
+	assert.Equal(t, uint64(1), counts[PhaseConnect])
+	assert.Equal(t, uint64(1), counts[PhaseTLS])
+	assert.Equal(t, uint64(1), counts[PhaseTTFB])
+
+	// A reused connection only reports time to first byte
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	counts = phaseSampleCounts(t, reg)
+	assert.Equal(t, uint64(1), counts[PhaseConnect])
+	assert.Equal(t, uint64(2), counts[PhaseTTFB])
+}
+
+func TestHTTPTrace_DisabledByDefault(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+	}, "test-httptrace-disabled")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Empty(t, phaseSampleCounts(t, reg))
+}
+
+func TestConnTrace_Phases(t *testing.T) {
+	t.Parallel()
+	base := time.Now()
+	ct := &connTrace{
+		getConn:      base,
+		connectStart: base.Add(time.Millisecond),
+		connectDone:  base.Add(3 * time.Millisecond),
+		firstByte:    base.Add(10 * time.Millisecond),
+	}
+
+	phases := ct.phases()
+	require.Len(t, phases, 2)
+	assert.Equal(t, phaseTiming{phase: PhaseConnect, duration: 2 * time.Millisecond}, phases[0])
+	assert.Equal(t, phaseTiming{phase: PhaseTTFB, duration: 10 * time.Millisecond}, phases[1])
+}
@@ -0,0 +1,90 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeadlineBudgetError reports timeouts that leave no room for the configured attempts:
+// Config.Timeout shorter than PerTryTimeout*MaxAttempts, or a context deadline
+// that expires before a retry could follow a timed-out attempt.
+type DeadlineBudgetError struct {
+	// Available is Config.Timeout, or the time left until the context deadline
+	Available time.Duration
+	// Required is the time the attempts may take
+	Required time.Duration
+	// PerTryTimeout is the timeout of a single attempt
+	PerTryTimeout time.Duration
+	// MaxAttempts is the number of attempts configured
+	MaxAttempts int
+	// ContextDeadline reports whether Available comes from the request context
+	ContextDeadline bool
+}
+
+// Error implements the error interface.
+func (e *DeadlineBudgetError) Error() string {
+	if e.ContextDeadline {
+		return fmt.Sprintf("context deadline in %v leaves no time for retries after a per-try timeout of %v",
+			e.Available, e.PerTryTimeout)
+	}
+	return fmt.Sprintf("timeout %v is shorter than per-try timeout %v x %d attempts = %v",
+		e.Available, e.PerTryTimeout, e.MaxAttempts, e.Required)
+}
+
+// ValidateTimeouts checks that Config.Timeout covers PerTryTimeout for every attempt
+// and returns a *DeadlineBudgetError otherwise. Unset values are validated with their defaults.
+func (c Config) ValidateTimeouts() error {
+	timeout, perTry := c.Timeout, c.PerTryTimeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	if perTry == 0 {
+		perTry = defaultPerTryTimeout
+	}
+	return validateTimeoutBudget(timeout, perTry, validatedAttempts(c.RetryEnabled, c.RetryConfig.withDefaults()))
+}
+
+// validateTimeoutBudget checks that timeout covers perTry for every attempt.
+func validateTimeoutBudget(timeout, perTry time.Duration, maxAttempts int) error {
+	if timeout <= 0 || perTry <= 0 {
+		return nil
+	}
+	required := perTry * time.Duration(maxAttempts)
+	if timeout >= required {
+		return nil
+	}
+	return &DeadlineBudgetError{
+		Available:     timeout,
+		Required:      required,
+		PerTryTimeout: perTry,
+		MaxAttempts:   maxAttempts,
+	}
+}
+
+// validateDeadline checks the request against the configured timeouts when
+// Config.ValidateDeadlines is set: the timeout budget of its retry policy and
+// the time left until the context deadline.
+func validateDeadline(ctx context.Context, config Config, maxAttempts int) error {
+	if !config.ValidateDeadlines {
+		return nil
+	}
+	if err := validateTimeoutBudget(config.Timeout, config.PerTryTimeout, maxAttempts); err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok || maxAttempts <= 1 {
+		return nil
+	}
+	if remaining := time.Until(deadline); remaining <= config.PerTryTimeout {
+		return &DeadlineBudgetError{
+			Available:       remaining,
+			Required:        config.PerTryTimeout * time.Duration(maxAttempts),
+			PerTryTimeout:   config.PerTryTimeout,
+			MaxAttempts:     maxAttempts,
+			ContextDeadline: true,
+		}
+	}
+	return nil
+}
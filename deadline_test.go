@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ValidateTimeouts(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		config   Config
+		required time.Duration
+	}{
+		{
+			name:   "no retries",
+			config: Config{Timeout: time.Second, PerTryTimeout: time.Second},
+		},
+		{
+			name: "timeout covers attempts",
+			config: Config{
+				Timeout: 6 * time.Second, PerTryTimeout: 2 * time.Second,
+				RetryEnabled: true, RetryConfig: RetryConfig{MaxAttempts: 3},
+			},
+		},
+		{
+			name: "timeout shorter than attempts",
+			config: Config{
+				Timeout: 5 * time.Second, PerTryTimeout: 2 * time.Second,
+				RetryEnabled: true, RetryConfig: RetryConfig{MaxAttempts: 4},
+			},
+			required: 8 * time.Second,
+		},
+		{
+			name:     "defaults with retries",
+			config:   Config{RetryEnabled: true},
+			required: 6 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.config.ValidateTimeouts()
+			if tc.required == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var budgetErr *DeadlineBudgetError
+			require.True(t, errors.As(err, &budgetErr))
+			assert.Equal(t, tc.required, budgetErr.Required)
+			assert.False(t, budgetErr.ContextDeadline)
+			assert.Contains(t, err.Error(), "is shorter than per-try timeout")
+		})
+	}
+}
+
+func TestNewValidated(t *testing.T) {
+	t.Parallel()
+	client, err := NewValidated(Config{
+		Timeout: time.Second, PerTryTimeout: time.Second,
+		RetryEnabled: true, RetryConfig: RetryConfig{MaxAttempts: 2},
+	}, "test-new-validated")
+	assert.Nil(t, client)
+	var budgetErr *DeadlineBudgetError
+	assert.True(t, errors.As(err, &budgetErr))
+
+	client, err = NewValidated(Config{Timeout: time.Second, PerTryTimeout: time.Second}, "test-new-validated")
+	require.NoError(t, err)
+	require.NotNil(t, client)
+	assert.NoError(t, client.Close())
+}
+
+func TestValidateDeadlines_PerRequest(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		Timeout:           10 * time.Second,
+		PerTryTimeout:     time.Second,
+		RetryEnabled:      true,
+		RetryConfig:       RetryConfig{MaxAttempts: 3},
+		ValidateDeadlines: true,
+	}, "test-validate-deadlines")
+	defer client.Close()
+
+	// A context deadline within PerTryTimeout leaves no room for a retry
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err := client.Get(ctx, server.URL)
+	var budgetErr *DeadlineBudgetError
+	require.True(t, errors.As(err, &budgetErr))
+	assert.True(t, budgetErr.ContextDeadline)
+	assert.Equal(t, time.Second, budgetErr.PerTryTimeout)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	// Without retries the deadline is not checked
+	resp, err := client.Get(ctx, server.URL, WithRetryPolicy(RetryConfig{MaxAttempts: 1}))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
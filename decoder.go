@@ -0,0 +1,160 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrUnsupportedContentType is returned when no decoder is registered for the response Content-Type.
+var ErrUnsupportedContentType = errors.New("no decoder for content type")
+
+// Decoder decodes a response body into a value.
+type Decoder interface {
+	Decode(data []byte, v any) error
+}
+
+// DecoderFunc adapts a function to the Decoder interface.
+type DecoderFunc func(data []byte, v any) error
+
+// Decode calls f(data, v).
+func (f DecoderFunc) Decode(data []byte, v any) error {
+	return f(data, v)
+}
+
+// DecoderRegistry selects a Decoder by the media type of the response Content-Type.
+// Media types with a structured syntax suffix, e.g. application/problem+json, fall back
+// to the decoder of application/json or application/xml. It is safe for concurrent use.
+type DecoderRegistry struct {
+	mu       sync.RWMutex
+	decoders map[string]Decoder
+	order    []string
+}
+
+// DefaultDecoders is the registry used by DecodeResponse and by clients without Config.Decoders.
+var DefaultDecoders = NewDecoderRegistry()
+
+// NewDecoderRegistry creates a registry with decoders for JSON, XML, MessagePack, Protocol
+// Buffers (the value must be a proto.Message) and URL-encoded forms (the value must be
+// *url.Values or *map[string]string).
+func NewDecoderRegistry() *DecoderRegistry {
+	r := &DecoderRegistry{decoders: make(map[string]Decoder)}
+	r.Register("application/json", DecoderFunc(json.Unmarshal))
+	r.Register("application/xml", DecoderFunc(xml.Unmarshal))
+	r.Register("text/xml", DecoderFunc(xml.Unmarshal))
+	r.Register("application/msgpack", DecoderFunc(msgpack.Unmarshal))
+	r.Register("application/x-msgpack", DecoderFunc(msgpack.Unmarshal))
+	r.Register("application/vnd.msgpack", DecoderFunc(msgpack.Unmarshal))
+	r.Register("application/protobuf", DecoderFunc(decodeProtobuf))
+	r.Register("application/x-protobuf", DecoderFunc(decodeProtobuf))
+	r.Register("application/vnd.google.protobuf", DecoderFunc(decodeProtobuf))
+	r.Register("application/x-www-form-urlencoded", DecoderFunc(decodeForm))
+	return r
+}
+
+// RegisterDecoder registers the decoder for the media type in DefaultDecoders.
+func RegisterDecoder(mediaType string, d Decoder) {
+	DefaultDecoders.Register(mediaType, d)
+}
+
+// Register sets the decoder of the media type, e.g. "application/cbor", replacing an existing one.
+func (r *DecoderRegistry) Register(mediaType string, d Decoder) {
+	mediaType = strings.ToLower(mediaType)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.decoders[mediaType]; !exists {
+		r.order = append(r.order, mediaType)
+	}
+	r.decoders[mediaType] = d
+}
+
+// Lookup returns the decoder of a Content-Type header value, ignoring its parameters.
+func (r *DecoderRegistry) Lookup(contentType string) (Decoder, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if d, ok := r.decoders[mediaType]; ok {
+		return d, true
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		d, ok := r.decoders["application/"+mediaType[i+1:]]
+		return d, ok
+	}
+	return nil, false
+}
+
+// Accept returns the registered media types as an Accept header value, in registration
+// order, e.g. for WithAccept(registry.Accept()).
+func (r *DecoderRegistry) Accept() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return strings.Join(r.order, ", ")
+}
+
+// Decode decodes data of the Content-Type into v.
+func (r *DecoderRegistry) Decode(contentType string, data []byte, v any) error {
+	d, ok := r.Lookup(contentType)
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnsupportedContentType, contentType)
+	}
+	if err := d.Decode(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", contentType, err)
+	}
+	return nil
+}
+
+// DecodeResponse reads the response body, closes it and decodes it into v with the
+// DefaultDecoders decoder of its Content-Type. The status code is not checked.
+func DecodeResponse(resp *http.Response, v any) error {
+	var data []byte
+	if resp.Body != nil {
+		defer drainAndClose(resp.Body)
+		var err error
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return err
+		}
+	}
+	return DefaultDecoders.Decode(resp.Header.Get("Content-Type"), data, v)
+}
+
+// decodeProtobuf decodes a Protocol Buffers message.
+func decodeProtobuf(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// decodeForm decodes a URL-encoded form into *url.Values or *map[string]string.
+func decodeForm(data []byte, v any) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	switch target := v.(type) {
+	case *url.Values:
+		*target = values
+	case *map[string]string:
+		*target = make(map[string]string, len(values))
+		for key := range values {
+			(*target)[key] = values.Get(key)
+		}
+	default:
+		return fmt.Errorf("%T is not *url.Values or *map[string]string", v)
+	}
+	return nil
+}
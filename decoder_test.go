@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// decoderTestResponse builds a response with the body and Content-Type.
+func decoderTestResponse(contentType string, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{contentType}},
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+	}
+}
+
+func TestDecodeResponse_BuiltInDecoders(t *testing.T) {
+	t.Parallel()
+	type item struct {
+		ID   int    `json:"id" xml:"id" msgpack:"id"`
+		Name string `json:"name" xml:"name" msgpack:"name"`
+	}
+	packed, err := msgpack.Marshal(item{ID: 3, Name: "packed"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		contentType string
+		body        string
+		want        item
+	}{
+		{"application/json; charset=utf-8", `{"id":1,"name":"json"}`, item{1, "json"}},
+		{"application/problem+json", `{"id":2,"name":"problem"}`, item{2, "problem"}},
+		{"text/xml", `<item><id>4</id><name>xml</name></item>`, item{4, "xml"}},
+		{"application/msgpack", string(packed), item{3, "packed"}},
+	}
+	for _, tt := range tests {
+		var got item
+		require.NoError(t, DecodeResponse(decoderTestResponse(tt.contentType, []byte(tt.body)), &got), tt.contentType)
+		assert.Equal(t, tt.want, got, tt.contentType)
+	}
+
+	data, err := proto.Marshal(wrapperspb.String("proto"))
+	require.NoError(t, err)
+	var message wrapperspb.StringValue
+	require.NoError(t, DecodeResponse(decoderTestResponse("application/x-protobuf", data), &message))
+	assert.Equal(t, "proto", message.GetValue())
+
+	var form url.Values
+	require.NoError(t, DecodeResponse(decoderTestResponse("application/x-www-form-urlencoded", []byte("a=1&a=2&b=x")), &form))
+	assert.Equal(t, url.Values{"a": {"1", "2"}, "b": {"x"}}, form)
+	var fields map[string]string
+	require.NoError(t, DecodeResponse(decoderTestResponse("application/x-www-form-urlencoded", []byte("a=1&b=x")), &fields))
+	assert.Equal(t, map[string]string{"a": "1", "b": "x"}, fields)
+}
+
+func TestDecodeResponse_Errors(t *testing.T) {
+	t.Parallel()
+	var v map[string]any
+	err := DecodeResponse(decoderTestResponse("text/csv", []byte("a,b")), &v)
+	assert.ErrorIs(t, err, ErrUnsupportedContentType)
+
+	err = DecodeResponse(decoderTestResponse("application/json", []byte("{")), &v)
+	assert.ErrorContains(t, err, "failed to decode application/json response")
+
+	err = DecodeResponse(decoderTestResponse("application/protobuf", nil), &v)
+	assert.ErrorContains(t, err, "is not a proto.Message")
+}
+
+func TestResponse_DecodeWithCustomRegistry(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "text/csv"},
+		Body:       "id,name\n1,widget\n",
+	})
+	defer server.Close()
+
+	decoders := NewDecoderRegistry()
+	decoders.Register("text/csv", DecoderFunc(func(data []byte, v any) error {
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		*v.(*[][]string) = records
+		return err
+	}))
+	assert.True(t, strings.HasPrefix(decoders.Accept(), "application/json, "))
+	assert.True(t, strings.HasSuffix(decoders.Accept(), ", text/csv"))
+
+	client := New(Config{Decoders: decoders}, "test-response-decode")
+	defer client.Close()
+
+	resp, err := client.Execute(context.Background(), http.MethodGet, server.URL, nil, WithAccept(decoders.Accept()))
+	require.NoError(t, err)
+	var records [][]string
+	require.NoError(t, resp.Decode(&records))
+	assert.Equal(t, [][]string{{"id", "name"}, {"1", "widget"}}, records)
+	assert.Contains(t, server.GetLastRequest().Headers["Accept"], "text/csv")
+
+	_, ok := DefaultDecoders.Lookup("text/csv")
+	assert.False(t, ok)
+}
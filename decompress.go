@@ -0,0 +1,135 @@
+package httpclient
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ContentDecoder wraps a response body compressed with a Content-Encoding in a decompressing reader.
+type ContentDecoder func(r io.Reader) (io.ReadCloser, error)
+
+// builtinDecoders are available without registering them in Config.ContentDecoders.
+var builtinDecoders = map[string]ContentDecoder{
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	},
+}
+
+// WithoutDecompression returns the response body as received: the client neither decodes it
+// nor lets the transport decompress gzip transparently. Content-Encoding is kept in the response.
+func WithoutDecompression() RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.noDecompress = true
+		})
+	}
+}
+
+// contentDecoder returns the decoder for an encoding from the configuration or the built-ins.
+func contentDecoder(config Config, encoding string) ContentDecoder {
+	encoding = strings.ToLower(encoding)
+	if decoder, ok := config.ContentDecoders[encoding]; ok {
+		return decoder
+	}
+	return builtinDecoders[encoding]
+}
+
+// acceptEncoding returns the Accept-Encoding value for the configured encodings that have a decoder.
+func acceptEncoding(config Config) string {
+	encodings := make([]string, 0, len(config.AcceptEncoding))
+	for _, encoding := range config.AcceptEncoding {
+		if contentDecoder(config, encoding) != nil {
+			encodings = append(encodings, strings.ToLower(encoding))
+		}
+	}
+	return strings.Join(encodings, ", ")
+}
+
+// prepareAcceptEncoding sets Accept-Encoding on a copy of the request and reports whether
+// the client has to decode the response. Requests with their own Accept-Encoding are left
+// alone, like in http.Transport, and so are all requests in Config.Passthrough mode.
+func prepareAcceptEncoding(req *http.Request, config Config) (*http.Request, bool) {
+	if config.Passthrough {
+		return req, false
+	}
+	noDecompress := false
+	if overrides := getRequestOverrides(req.Context()); overrides != nil {
+		noDecompress = overrides.noDecompress
+	}
+	if req.Header.Get("Accept-Encoding") != "" || req.Method == http.MethodHead {
+		return req, false
+	}
+
+	value := acceptEncoding(config)
+	switch {
+	case noDecompress && value == "":
+		// Setting the header ourselves disables transparent gzip in http.Transport
+		value = "gzip"
+	case value == "":
+		return req, false
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", value)
+	return req, !noDecompress
+}
+
+// decodeResponseBody replaces a compressed response body with a decompressing reader.
+// Content-Length no longer describes the body, so it is removed as http.Transport does for gzip.
+func decodeResponseBody(resp *http.Response, config Config) {
+	if resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+	encoding := strings.TrimSpace(resp.Header.Get("Content-Encoding"))
+	if encoding == "" || strings.Contains(encoding, ",") {
+		return
+	}
+	decoder := contentDecoder(config, encoding)
+	if decoder == nil {
+		return
+	}
+
+	resp.Body = &decodedBody{body: resp.Body, decoder: decoder}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// decodedBody lazily creates the decoder on first read, so an invalid stream surfaces
+// as a read error instead of failing the request.
+type decodedBody struct {
+	body    io.ReadCloser
+	decoder ContentDecoder
+	reader  io.ReadCloser
+	err     error
+}
+
+// Read implements io.Reader.
+func (d *decodedBody) Read(p []byte) (int, error) {
+	if d.reader == nil && d.err == nil {
+		d.reader, d.err = d.decoder(d.body)
+		if d.err != nil {
+			// Decoders may return a typed nil reader together with the error
+			d.reader = nil
+		}
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.reader.Read(p)
+}
+
+// Close closes the decoder and the underlying body.
+func (d *decodedBody) Close() error {
+	if d.reader != nil {
+		_ = d.reader.Close()
+	}
+	return d.body.Close()
+}
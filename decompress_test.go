@@ -0,0 +1,156 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flateDecoder stands in for a third-party decoder such as brotli.
+func flateDecoder(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+// newEncodingServer compresses the payload with the first encoding the client accepts.
+func newEncodingServer(t *testing.T, payload string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var accepted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := r.Header.Get("Accept-Encoding")
+		accepted = append(accepted, accept)
+
+		var buf bytes.Buffer
+		first, _, _ := strings.Cut(accept, ",")
+		switch first {
+		case "br":
+			fw, _ := flate.NewWriter(&buf, flate.BestCompression)
+			_, _ = fw.Write([]byte(payload))
+			_ = fw.Close()
+		case "gzip":
+			gw := gzip.NewWriter(&buf)
+			_, _ = gw.Write([]byte(payload))
+			_ = gw.Close()
+		default:
+			_, _ = w.Write([]byte(payload))
+			return
+		}
+		w.Header().Set("Content-Encoding", first)
+		_, _ = w.Write(buf.Bytes())
+	}))
+	t.Cleanup(server.Close)
+	return server, &accepted
+}
+
+func TestAcceptEncoding_DecodesRegisteredEncoding(t *testing.T) {
+	t.Parallel()
+	payload := strings.Repeat("compressible ", 200)
+	server, accepted := newEncodingServer(t, payload)
+
+	client := New(Config{
+		AcceptEncoding:  []string{"br", "zstd", "gzip"},
+		ContentDecoders: map[string]ContentDecoder{"br": flateDecoder},
+	}, "test-decompress")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	// zstd has no decoder, so it isn't advertised
+	assert.Equal(t, []string{"br, gzip"}, *accepted)
+	assert.Equal(t, payload, string(body))
+	assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, int64(-1), resp.ContentLength)
+	assert.True(t, resp.Uncompressed)
+}
+
+func TestAcceptEncoding_BuiltinGzip(t *testing.T) {
+	t.Parallel()
+	server, _ := newEncodingServer(t, "hello gzip")
+
+	client := New(Config{AcceptEncoding: []string{"gzip"}}, "test-decompress-gzip")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello gzip", string(body))
+}
+
+func TestWithoutDecompression(t *testing.T) {
+	t.Parallel()
+	server, _ := newEncodingServer(t, "keep me compressed")
+
+	client := New(Config{
+		AcceptEncoding:  []string{"br"},
+		ContentDecoders: map[string]ContentDecoder{"br": flateDecoder},
+	}, "test-decompress-optout")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL, WithoutDecompression())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "br", resp.Header.Get("Content-Encoding"))
+	decoded, err := io.ReadAll(flate.NewReader(bytes.NewReader(raw)))
+	require.NoError(t, err)
+	assert.Equal(t, "keep me compressed", string(decoded))
+}
+
+func TestWithoutDecompression_DisablesTransparentGzip(t *testing.T) {
+	t.Parallel()
+	server, _ := newEncodingServer(t, "raw gzip")
+
+	client := New(Config{}, "test-decompress-optout-gzip")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL, WithoutDecompression())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+}
+
+func TestAcceptEncoding_ExplicitHeaderIsNotDecoded(t *testing.T) {
+	t.Parallel()
+	server, accepted := newEncodingServer(t, "explicit")
+
+	client := New(Config{AcceptEncoding: []string{"gzip"}}, "test-decompress-explicit")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL, WithHeader("Accept-Encoding", "identity"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"identity"}, *accepted)
+	assert.Equal(t, "explicit", string(body))
+}
+
+func TestDecodedBody_InvalidStream(t *testing.T) {
+	t.Parallel()
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": {"gzip"}},
+		Body:   io.NopCloser(strings.NewReader("not gzip")),
+	}
+	decodeResponseBody(resp, Config{})
+
+	_, err := io.ReadAll(resp.Body)
+	assert.Error(t, err)
+	assert.NoError(t, resp.Body.Close())
+}
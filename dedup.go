@@ -0,0 +1,163 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultDeduplicateHeaders lists request headers that distinguish otherwise identical requests
+// when Config.DeduplicateInflight is enabled.
+var DefaultDeduplicateHeaders = []string{
+	"Accept",
+	"Accept-Encoding",
+	"Accept-Language",
+	"Authorization",
+	"Cookie",
+}
+
+// inflightCall is an upstream request shared by concurrent identical requests.
+type inflightCall struct {
+	done chan struct{}
+	resp *http.Response
+	body []byte
+	err  error
+
+	// waiters counts the callers still waiting, guarded by inflightGroup.mu
+	waiters int
+	// cancel cancels the shared request once every caller has gone
+	cancel context.CancelFunc
+}
+
+// inflightGroup collapses concurrent identical requests into a single upstream call.
+type inflightGroup struct {
+	headers []string
+
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+// newInflightGroup creates a group keyed by method, URL and the specified headers.
+func newInflightGroup(headers []string) *inflightGroup {
+	return &inflightGroup{
+		headers: headers,
+		calls:   make(map[string]*inflightCall),
+	}
+}
+
+// do executes fn once for all concurrent requests with the same key.
+// The shared request runs detached from the context of the caller that started it and is
+// cancelled only when every caller has gone. The shared response body is buffered and every
+// caller receives its own copy.
+func (g *inflightGroup) do(req *http.Request, fn func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	key := g.key(req)
+
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+		call = &inflightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go g.run(key, call, req.WithContext(ctx), fn)
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.response(req)
+	case <-req.Context().Done():
+		g.leave(key, call)
+		return nil, req.Context().Err()
+	}
+}
+
+// run executes the shared request and buffers its response.
+func (g *inflightGroup) run(key string, call *inflightCall, req *http.Request, fn func(*http.Request) (*http.Response, error)) {
+	defer call.cancel()
+
+	call.resp, call.err = fn(req)
+	if call.err != nil {
+		closeResponseBody(call.resp)
+	} else if call.resp.Body != nil {
+		call.body, call.err = io.ReadAll(call.resp.Body)
+		_ = call.resp.Body.Close()
+	}
+
+	g.mu.Lock()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+	close(call.done)
+}
+
+// leave removes a caller whose context is done, cancelling the shared request when it was
+// the last one. Later identical requests start a new call.
+func (g *inflightGroup) leave(key string, call *inflightCall) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+	call.cancel()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+}
+
+// key identifies identical requests.
+func (g *inflightGroup) key(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(req.URL.String())
+	for _, name := range g.headers {
+		b.WriteByte('\n')
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(req.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// response returns a private copy of the shared response for req.
+func (c *inflightCall) response(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	resp := *c.resp
+	resp.Header = c.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(c.body))
+	resp.ContentLength = int64(len(c.body))
+	resp.Request = req
+	return &resp, nil
+}
+
+// rangeConditionalHeaders make the response depend on the caller's view of the resource.
+var rangeConditionalHeaders = []string{
+	"Range", "If-Range", "If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since",
+}
+
+// isDeduplicable reports whether the request may share an upstream call with others.
+// Ranged and conditional requests and requests with per-request options are sent on their
+// own, since their response or the way it is fetched is specific to the caller.
+func isDeduplicable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	for _, name := range rangeConditionalHeaders {
+		if req.Header.Get(name) != "" {
+			return false
+		}
+	}
+	return getRequestOverrides(req.Context()) == nil
+}
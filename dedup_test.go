@@ -0,0 +1,219 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newGatedServer counts requests and holds them until release is closed.
+func newGatedServer(t *testing.T) (*httptest.Server, *int32, chan struct{}) {
+	t.Helper()
+	var calls int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("X-Auth", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("shared body"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls, release
+}
+
+func TestDeduplicateInflight_CollapsesIdenticalGets(t *testing.T) {
+	t.Parallel()
+	server, calls, release := newGatedServer(t)
+
+	client := New(Config{DeduplicateInflight: true}, "test-dedup")
+	defer client.Close()
+
+	const callers = 10
+	var wg sync.WaitGroup
+	bodies := make([]string, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := client.Get(context.Background(), server.URL+"/resource")
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			bodies[i] = string(body)
+		}(i)
+	}
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(calls) == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond) // let the remaining callers join the in-flight request
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	for i := 0; i < callers; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "shared body", bodies[i])
+	}
+}
+
+func TestDeduplicateInflight_DistinguishesHeaders(t *testing.T) {
+	t.Parallel()
+	server, calls, release := newGatedServer(t)
+
+	client := New(Config{DeduplicateInflight: true}, "test-dedup-headers")
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	auth := make([]string, 2)
+	for i, token := range []string{"alice", "bob"} {
+		wg.Add(1)
+		go func(i int, token string) {
+			defer wg.Done()
+			resp, err := client.Get(context.Background(), server.URL, WithBearerToken(token))
+			if !assert.NoError(t, err) {
+				return
+			}
+			resp.Body.Close()
+			auth[i] = resp.Header.Get("X-Auth")
+		}(i, token)
+	}
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(calls) == 2 }, time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, []string{"Bearer alice", "Bearer bob"}, auth)
+}
+
+func TestDeduplicateInflight_DisabledByDefault(t *testing.T) {
+	t.Parallel()
+	server, calls, release := newGatedServer(t)
+	close(release)
+
+	client := New(Config{}, "test-dedup-disabled")
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(context.Background(), server.URL)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestIsDeduplicable(t *testing.T) {
+	t.Parallel()
+	get, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	post, _ := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	assert.True(t, isDeduplicable(get))
+	assert.False(t, isDeduplicable(post))
+}
+
+func TestDeduplicateInflight_SkipsRangedAndOverriddenRequests(t *testing.T) {
+	t.Parallel()
+	server, calls, release := newGatedServer(t)
+
+	client := New(Config{DeduplicateInflight: true}, "test-dedup-ranges")
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for _, opt := range []RequestOption{
+		WithHeader("Range", "bytes=0-9"),
+		WithHeader("Range", "bytes=10-19"),
+		WithIfNoneMatch(`"v1"`),
+		WithNoRetry(),
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(context.Background(), server.URL, opt)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(calls) == 4 }, time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+}
+
+func TestDeduplicateInflight_DetachedFromFirstCaller(t *testing.T) {
+	t.Parallel()
+	server, calls, release := newGatedServer(t)
+
+	client := New(Config{DeduplicateInflight: true}, "test-dedup-detached")
+	defer client.Close()
+
+	// The first caller gives up while the shared call is still running
+	first, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := client.Get(first, server.URL)
+		firstErr <- err
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(calls) == 1 }, time.Second, 5*time.Millisecond)
+
+	second := make(chan string, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if !assert.NoError(t, err) {
+			second <- ""
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		second <- string(body)
+	}()
+
+	require.ErrorIs(t, <-firstErr, context.DeadlineExceeded)
+	close(release)
+	assert.Equal(t, "shared body", <-second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestDeduplicateInflight_CancelledWhenAllCallersLeave(t *testing.T) {
+	t.Parallel()
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	defer server.Close()
+
+	client := New(Config{DeduplicateInflight: true}, "test-dedup-cancel")
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	_, err := client.Get(ctx, server.URL)
+	require.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the shared request was not cancelled")
+	}
+}
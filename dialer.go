@@ -0,0 +1,215 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// AddressFamily defines which IP addresses of a host are dialed, and in what order.
+type AddressFamily int
+
+const (
+	// AddressFamilyAny dials addresses in resolver order, racing the other family after
+	// FallbackDelay (Happy Eyeballs, RFC 8305), like the default Go dialer.
+	AddressFamilyAny AddressFamily = iota
+	// AddressFamilyPreferIPv4 dials IPv4 addresses first and IPv6 addresses after FallbackDelay.
+	AddressFamilyPreferIPv4
+	// AddressFamilyPreferIPv6 dials IPv6 addresses first and IPv4 addresses after FallbackDelay.
+	AddressFamilyPreferIPv6
+	// AddressFamilyIPv4Only dials only IPv4 addresses.
+	AddressFamilyIPv4Only
+	// AddressFamilyIPv6Only dials only IPv6 addresses.
+	AddressFamilyIPv6Only
+)
+
+// String returns the address family name.
+func (f AddressFamily) String() string {
+	switch f {
+	case AddressFamilyAny:
+		return "any"
+	case AddressFamilyPreferIPv4:
+		return "prefer-ipv4"
+	case AddressFamilyPreferIPv6:
+		return "prefer-ipv6"
+	case AddressFamilyIPv4Only:
+		return "ipv4-only"
+	case AddressFamilyIPv6Only:
+		return "ipv6-only"
+	default:
+		return "unknown"
+	}
+}
+
+// dualStackDialer dials TCP connections following TransportTuning.AddressFamily and
+// TransportTuning.StaticAddresses.
+type dualStackDialer struct {
+	dialer   *net.Dialer
+	family   AddressFamily
+	static   map[string][]string
+	resolver *net.Resolver
+}
+
+// newDialContext returns the DialContext of the transport built by the client.
+func newDialContext(tuning TransportTuning) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:       tuning.DialTimeout,
+		KeepAlive:     tuning.KeepAlive,
+		FallbackDelay: tuning.FallbackDelay,
+	}
+	if tuning.AddressFamily == AddressFamilyAny && len(tuning.StaticAddresses) == 0 {
+		return dialer.DialContext
+	}
+
+	static := make(map[string][]string, len(tuning.StaticAddresses))
+	for host, addrs := range tuning.StaticAddresses {
+		static[strings.ToLower(host)] = addrs
+	}
+	d := &dualStackDialer{dialer: dialer, family: tuning.AddressFamily, static: static, resolver: net.DefaultResolver}
+	return d.DialContext
+}
+
+// DialContext resolves the host, orders its addresses by family and dials them.
+func (d *dualStackDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	if pinned, ok := d.static[strings.ToLower(host)]; ok {
+		for _, s := range pinned {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, &net.AddrError{Err: "invalid static address", Addr: s}
+			}
+			ips = append(ips, ip)
+		}
+	} else if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		resolved, err := d.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range resolved {
+			ips = append(ips, a.IP)
+		}
+	}
+
+	primaries, fallbacks := d.partition(ips, port)
+	if len(primaries) == 0 {
+		return nil, &net.AddrError{Err: "no " + d.family.String() + " address", Addr: host}
+	}
+	return d.dialParallel(ctx, network, primaries, fallbacks)
+}
+
+// partition splits the addresses into the preferred family and the fallback family.
+// Without a preference the family of the first address is preferred.
+func (d *dualStackDialer) partition(ips []net.IP, port string) (primaries, fallbacks []string) {
+	if len(ips) == 0 {
+		return nil, nil
+	}
+	preferIPv4 := ips[0].To4() != nil
+	switch d.family {
+	case AddressFamilyPreferIPv4, AddressFamilyIPv4Only:
+		preferIPv4 = true
+	case AddressFamilyPreferIPv6, AddressFamilyIPv6Only:
+		preferIPv4 = false
+	}
+	only := d.family == AddressFamilyIPv4Only || d.family == AddressFamilyIPv6Only
+
+	for _, ip := range ips {
+		addr := net.JoinHostPort(ip.String(), port)
+		if (ip.To4() != nil) == preferIPv4 {
+			primaries = append(primaries, addr)
+		} else if !only {
+			fallbacks = append(fallbacks, addr)
+		}
+	}
+	return primaries, fallbacks
+}
+
+// dialParallel dials the primaries and, after the fallback delay, races the fallbacks
+// against them. The first connection wins.
+func (d *dualStackDialer) dialParallel(ctx context.Context, network string, primaries, fallbacks []string) (net.Conn, error) {
+	if len(fallbacks) == 0 || d.dialer.FallbackDelay < 0 {
+		return d.dialSerial(ctx, network, append(primaries, fallbacks...))
+	}
+
+	type dialResult struct {
+		conn    net.Conn
+		err     error
+		primary bool
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan dialResult, 2)
+	dial := func(addrs []string, primary bool) {
+		conn, err := d.dialSerial(ctx, network, addrs)
+		results <- dialResult{conn: conn, err: err, primary: primary}
+	}
+	go dial(primaries, true)
+
+	delay := d.dialer.FallbackDelay
+	if delay == 0 {
+		delay = 300 * time.Millisecond
+	}
+	fallbackTimer := time.NewTimer(delay)
+	defer fallbackTimer.Stop()
+
+	var primaryErr, fallbackErr error
+	pending, fallbackStarted := 1, false
+	startFallback := func() {
+		if !fallbackStarted {
+			fallbackStarted = true
+			pending++
+			go dial(fallbacks, false)
+		}
+	}
+	for pending > 0 {
+		select {
+		case <-fallbackTimer.C:
+			startFallback()
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				// Close the loser if it connects before noticing the cancellation
+				go func(n int) {
+					for range n {
+						if late := <-results; late.conn != nil {
+							_ = late.conn.Close()
+						}
+					}
+				}(pending)
+				return res.conn, nil
+			}
+			if res.primary {
+				primaryErr = res.err
+				fallbackTimer.Stop()
+				startFallback()
+			} else {
+				fallbackErr = res.err
+			}
+		}
+	}
+	return nil, errors.Join(primaryErr, fallbackErr)
+}
+
+// dialSerial dials the addresses in order and returns the first connection.
+func (d *dualStackDialer) dialSerial(ctx context.Context, network string, addrs []string) (net.Conn, error) {
+	var errs []error
+	for _, addr := range addrs {
+		conn, err := d.dialer.DialContext(ctx, network, addr)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
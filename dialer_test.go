@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialer_StaticAddresses(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	client := New(Config{
+		TransportTuning: TransportTuning{
+			AddressFamily:   AddressFamilyPreferIPv6,
+			FallbackDelay:   50 * time.Millisecond,
+			StaticAddresses: map[string][]string{"API.test": {"::1", "127.0.0.1"}},
+		},
+	}, "test-dialer-static")
+	defer client.Close()
+
+	// Nothing listens on ::1, so the IPv4 fallback connects
+	resp, err := client.Get(context.Background(), "http://api.test:"+serverURL.Port()+"/pinned")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 1, server.GetRequestCount())
+	assert.Equal(t, "/pinned", server.GetLastRequest().URL)
+}
+
+func TestDialer_AddressFamilyOnly(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	client := New(Config{
+		TransportTuning: TransportTuning{
+			AddressFamily:   AddressFamilyIPv6Only,
+			StaticAddresses: map[string][]string{"api.test": {"127.0.0.1"}},
+		},
+	}, "test-dialer-only")
+	defer client.Close()
+
+	_, err = client.Get(context.Background(), "http://api.test:"+serverURL.Port())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no ipv6-only address")
+	assert.Equal(t, 0, server.GetRequestCount())
+}
+
+func TestDualStackDialer_Partition(t *testing.T) {
+	t.Parallel()
+	ips := []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::2")}
+
+	tests := []struct {
+		family    AddressFamily
+		primaries []string
+		fallbacks []string
+	}{
+		{AddressFamilyAny, []string{"[2001:db8::1]:443", "[2001:db8::2]:443"}, []string{"192.0.2.1:443"}},
+		{AddressFamilyPreferIPv4, []string{"192.0.2.1:443"}, []string{"[2001:db8::1]:443", "[2001:db8::2]:443"}},
+		{AddressFamilyIPv4Only, []string{"192.0.2.1:443"}, nil},
+		{AddressFamilyIPv6Only, []string{"[2001:db8::1]:443", "[2001:db8::2]:443"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.family.String(), func(t *testing.T) {
+			t.Parallel()
+			d := &dualStackDialer{family: tt.family}
+			primaries, fallbacks := d.partition(ips, "443")
+			assert.Equal(t, tt.primaries, primaries)
+			assert.Equal(t, tt.fallbacks, fallbacks)
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default download settings.
+const (
+	defaultDownloadMaxResumes = 3
+	downloadBufferSize        = 32 * 1024
+)
+
+// errResourceChanged is returned when the resource changes between resumed requests.
+var errResourceChanged = errors.New("resource changed during download")
+
+// errRangesUnsupported is returned when a download would resume from a server known not to
+// support Range requests.
+var errRangesUnsupported = errors.New("server does not support Range requests")
+
+// DownloadProgress describes the state of a download.
+type DownloadProgress struct {
+	// Written is the number of bytes written to the destination, including WithResumeFrom offset
+	Written int64
+	// Total is the full size of the resource, or -1 if unknown
+	Total int64
+}
+
+// DownloadResult describes a completed download.
+type DownloadResult struct {
+	// Written is the number of bytes written by this call
+	Written int64
+	// Total is the full size of the resource, or -1 if unknown
+	Total int64
+	// Resumes is the number of times the download was resumed with a Range request
+	Resumes int
+	// Checksum is the hex-encoded checksum when WithDownloadChecksum was used
+	Checksum string
+}
+
+// DownloadOption configures Client.Download.
+type DownloadOption func(*downloadOptions)
+
+// downloadOptions holds Download settings.
+type downloadOptions struct {
+	progress         func(DownloadProgress)
+	bytesPerSecond   int64
+	checksum         hash.Hash
+	expectedChecksum string
+	maxResumes       int
+	offset           int64
+	requestOpts      []RequestOption
+	noDigest         bool
+}
+
+// WithDownloadProgress sets a callback invoked after every chunk written to the destination.
+func WithDownloadProgress(fn func(DownloadProgress)) DownloadOption {
+	return func(o *downloadOptions) {
+		o.progress = fn
+	}
+}
+
+// WithBandwidthLimit limits the download speed to bytesPerSecond.
+func WithBandwidthLimit(bytesPerSecond int64) DownloadOption {
+	return func(o *downloadOptions) {
+		o.bytesPerSecond = bytesPerSecond
+	}
+}
+
+// WithDownloadChecksum computes a checksum of the downloaded bytes with h and compares it to
+// the hex-encoded expected value, returning *ChecksumMismatchError on mismatch.
+// An empty expected value only computes the checksum. With WithResumeFrom the checksum
+// covers only the bytes downloaded by this call.
+func WithDownloadChecksum(h hash.Hash, expected string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.checksum = h
+		o.expectedChecksum = strings.ToLower(expected)
+	}
+}
+
+// WithoutDigestVerification disables verifying the download against the Repr-Digest,
+// Content-Digest, Digest or Content-MD5 header of the response.
+func WithoutDigestVerification() DownloadOption {
+	return func(o *downloadOptions) {
+		o.noDigest = true
+	}
+}
+
+// WithMaxResumes sets how many times an interrupted download is resumed with a Range request
+// (default: 3). Zero disables resuming.
+func WithMaxResumes(n int) DownloadOption {
+	return func(o *downloadOptions) {
+		o.maxResumes = n
+	}
+}
+
+// WithResumeFrom continues a previous partial download: the first request asks for bytes
+// starting at offset, and dst is expected to already contain the first offset bytes.
+func WithResumeFrom(offset int64) DownloadOption {
+	return func(o *downloadOptions) {
+		o.offset = offset
+	}
+}
+
+// WithDownloadRequestOptions applies request options (headers, auth) to every download request.
+func WithDownloadRequestOptions(opts ...RequestOption) DownloadOption {
+	return func(o *downloadOptions) {
+		o.requestOpts = append(o.requestOpts, opts...)
+	}
+}
+
+// ChecksumMismatchError is returned when downloaded content doesn't match the expected checksum.
+// Expected and Actual are hex-encoded.
+type ChecksumMismatchError struct {
+	// Algorithm names the algorithm, e.g. "sha-256"; empty for WithDownloadChecksum
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+// Error implements the error interface.
+func (e *ChecksumMismatchError) Error() string {
+	if e.Algorithm != "" {
+		return fmt.Sprintf("%s checksum mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+	}
+	return fmt.Sprintf("checksum mismatch: expected %s, got %s", e.Expected, e.Actual)
+}
+
+// IsChecksumMismatchError checks if content didn't match its expected checksum.
+func IsChecksumMismatchError(err error) bool {
+	var mismatch *ChecksumMismatchError
+	return errors.As(err, &mismatch)
+}
+
+// Download streams the resource at url into dst. Interrupted transfers are resumed with
+// Range requests when the server supports them, validated with If-Range so that a changed
+// resource is never stitched together. Range support found by an earlier Probe of the host
+// takes precedence over the Accept-Ranges header of the response. A download starting at
+// offset zero is verified against the strongest checksum announced by the Repr-Digest,
+// Content-Digest, Digest or Content-MD5 header, returning *ChecksumMismatchError on
+// mismatch (see WithoutDigestVerification).
+func (c *Client) Download(
+	ctx context.Context, url string, dst io.Writer, opts ...DownloadOption,
+) (*DownloadResult, error) {
+	o := downloadOptions{maxResumes: defaultDownloadMaxResumes}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.offset > 0 && c.rangeSupport(url) == RangeSupportNone {
+		return &DownloadResult{Total: -1}, errRangesUnsupported
+	}
+
+	d := &download{
+		client:    c,
+		ctx:       ctx,
+		url:       url,
+		opts:      o,
+		dst:       dst,
+		offset:    o.offset,
+		total:     -1,
+		startTime: clockOrDefault(c.config.Clock).Now(),
+	}
+	if o.checksum != nil {
+		d.dst = io.MultiWriter(dst, o.checksum)
+	}
+
+	result, err := d.run()
+	if err != nil {
+		return result, err
+	}
+
+	if o.checksum != nil {
+		result.Checksum = hex.EncodeToString(o.checksum.Sum(nil))
+		if o.expectedChecksum != "" && result.Checksum != o.expectedChecksum {
+			return result, &ChecksumMismatchError{Expected: o.expectedChecksum, Actual: result.Checksum}
+		}
+	}
+	if d.digest != nil {
+		if err := d.digest.mismatch(d.digestHash.Sum(nil)); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// download holds the state of a single Download call.
+type download struct {
+	client    *Client
+	ctx       context.Context
+	url       string
+	opts      downloadOptions
+	dst       io.Writer
+	offset    int64
+	total     int64
+	validator string // ETag or Last-Modified used for If-Range
+	written   int64
+	startTime time.Time
+	// digest is the checksum announced by the response headers, verified with digestHash
+	digest     *expectedChecksum
+	digestHash hash.Hash
+}
+
+// run downloads the resource, resuming after read errors.
+func (d *download) run() (*DownloadResult, error) {
+	resumes := 0
+	for {
+		resumable, err := d.fetch()
+		if err == nil {
+			return d.result(resumes), nil
+		}
+
+		if downloadErrorFinal(err) || !resumable || resumes >= d.opts.maxResumes || d.ctx.Err() != nil {
+			return d.result(resumes), err
+		}
+		resumes++
+	}
+}
+
+// downloadErrorFinal reports whether a failed transfer must not be resumed: the destination
+// failed, the response exceeded Config.MaxResponseBodyBytes, which applies to every Range
+// response separately, or the body didn't match its checksum.
+func downloadErrorFinal(err error) bool {
+	var writeErr *downloadWriteError
+	return errors.As(err, &writeErr) || IsBodyTooLargeError(err) || IsChecksumMismatchError(err)
+}
+
+// result builds the DownloadResult.
+func (d *download) result(resumes int) *DownloadResult {
+	return &DownloadResult{Written: d.written, Total: d.total, Resumes: resumes}
+}
+
+// fetch performs one request starting at the current offset and copies the body.
+// It reports whether the failed transfer can be resumed with a Range request.
+func (d *download) fetch() (bool, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return false, err
+	}
+	applyOptions(req, d.opts.requestOpts)
+	if req.Header.Get("Accept-Encoding") == "" {
+		// Byte ranges must refer to the stored representation, not a compressed one
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+	if d.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.offset))
+		if d.validator != "" {
+			req.Header.Set("If-Range", d.validator)
+		}
+	}
+
+	resp, err := d.client.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer drainAndClose(resp.Body)
+
+	rangeRequested := d.offset > 0
+	switch {
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && rangeRequested &&
+		d.offset == contentRangeTotal(resp):
+		// Nothing left to download
+		return false, nil
+	case resp.StatusCode == http.StatusPartialContent && rangeRequested:
+		if start := contentRangeStart(resp); start != d.offset {
+			return false, fmt.Errorf("unexpected Content-Range start %d, expected %d", start, d.offset)
+		}
+		if total := contentRangeTotal(resp); total >= 0 {
+			d.total = total
+		}
+	case resp.StatusCode == http.StatusOK && rangeRequested:
+		if d.validator != "" {
+			return false, errResourceChanged
+		}
+		return false, fmt.Errorf("server ignored Range request for %s", d.url)
+	case resp.StatusCode == http.StatusOK:
+		d.total = resp.ContentLength
+		if !d.opts.noDigest {
+			d.setDigest(resp.Header)
+		}
+	default:
+		httpErr := NewHTTPError(resp, req)
+		httpErr.Body, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return false, httpErr
+	}
+
+	if d.validator == "" {
+		d.validator = rangeValidator(resp)
+	}
+	ranges := d.client.rangeSupport(d.url)
+	resumable := d.validator != "" && ranges != RangeSupportNone && (resp.StatusCode == http.StatusPartialContent ||
+		ranges == RangeSupportBytes || strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes"))
+
+	return resumable, d.copyBody(resp.Body)
+}
+
+// setDigest starts verifying the full representation against its announced checksum. It's
+// called for 200 responses only, which start at offset zero.
+func (d *download) setDigest(header http.Header) {
+	d.digestHash = nil
+	if d.digest = digestFromHeaders(header); d.digest != nil {
+		d.digestHash = d.digest.algorithm.newHash()
+	}
+}
+
+// downloadWriteError marks errors from the destination writer, which can't be resumed.
+type downloadWriteError struct {
+	err error
+}
+
+func (e *downloadWriteError) Error() string { return "failed to write download: " + e.err.Error() }
+func (e *downloadWriteError) Unwrap() error { return e.err }
+
+// copyBody copies the body into the destination with progress reporting and throttling.
+func (d *download) copyBody(body io.Reader) error {
+	buf := make([]byte, downloadBufferSize)
+	if d.opts.bytesPerSecond > 0 && int64(len(buf)) > d.opts.bytesPerSecond {
+		buf = buf[:d.opts.bytesPerSecond]
+	}
+
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			if _, err := d.dst.Write(buf[:n]); err != nil {
+				return &downloadWriteError{err: err}
+			}
+			if d.digestHash != nil {
+				d.digestHash.Write(buf[:n])
+			}
+			d.offset += int64(n)
+			d.written += int64(n)
+			if d.opts.progress != nil {
+				d.opts.progress(DownloadProgress{Written: d.offset, Total: d.total})
+			}
+			if err := d.throttle(); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			if d.total >= 0 && d.offset < d.total {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// throttle sleeps long enough to keep the average speed under the bandwidth limit,
+// measured with Config.Clock.
+func (d *download) throttle() error {
+	if d.opts.bytesPerSecond <= 0 {
+		return nil
+	}
+
+	clock := clockOrDefault(d.client.config.Clock)
+	expected := time.Duration(float64(d.written) / float64(d.opts.bytesPerSecond) * float64(time.Second))
+	wait := expected - clock.Now().Sub(d.startTime)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := clock.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-d.ctx.Done():
+		return d.ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// contentRangeTotal returns the full resource size from Content-Range, or -1 if unknown.
+func contentRangeTotal(resp *http.Response) int64 {
+	contentRange := resp.Header.Get("Content-Range")
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok || total == "*" {
+		return -1
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+// contentRangeStart returns the first byte position from a "bytes start-end/total" header.
+func contentRangeStart(resp *http.Response) int64 {
+	contentRange := strings.TrimPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	start, _, _ := strings.Cut(contentRange, "-")
+	value, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+// rangeValidator returns a strong validator usable in If-Range: a strong ETag or Last-Modified.
+func rangeValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
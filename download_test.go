@@ -0,0 +1,241 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var downloadPayload = []byte(strings.Repeat("0123456789abcdef", 4096)) // 64KB
+
+// newFlakyDownloadServer serves downloadPayload, cutting the first response in half.
+// etags returns the ETag for each request, allowing the resource to "change".
+func newFlakyDownloadServer(t *testing.T, etags func(n int32) string) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		w.Header().Set("ETag", etags(n))
+		if n == 1 {
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", "65536")
+			_, _ = w.Write(downloadPayload[:20000])
+			w.(http.Flusher).Flush()
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		http.ServeContent(w, r, "payload.bin", time.Time{}, bytes.NewReader(downloadPayload))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestDownload_ProgressAndChecksum(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "payload.bin", time.Time{}, bytes.NewReader(downloadPayload))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-download")
+	defer client.Close()
+
+	sum := sha256.Sum256(downloadPayload)
+	var last DownloadProgress
+	var dst bytes.Buffer
+	result, err := client.Download(context.Background(), server.URL, &dst,
+		WithDownloadProgress(func(p DownloadProgress) { last = p }),
+		WithDownloadChecksum(sha256.New(), hex.EncodeToString(sum[:])),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, downloadPayload, dst.Bytes())
+	assert.Equal(t, int64(len(downloadPayload)), result.Written)
+	assert.Equal(t, int64(len(downloadPayload)), result.Total)
+	assert.Equal(t, 0, result.Resumes)
+	assert.Equal(t, hex.EncodeToString(sum[:]), result.Checksum)
+	assert.Equal(t, DownloadProgress{Written: int64(len(downloadPayload)), Total: int64(len(downloadPayload))}, last)
+}
+
+func TestDownload_ResumesWithRange(t *testing.T) {
+	t.Parallel()
+	server, requests := newFlakyDownloadServer(t, func(int32) string { return `"v1"` })
+
+	client := New(Config{}, "test-download-resume")
+	defer client.Close()
+
+	var dst bytes.Buffer
+	result, err := client.Download(context.Background(), server.URL, &dst)
+	require.NoError(t, err)
+
+	assert.Equal(t, downloadPayload, dst.Bytes())
+	assert.Equal(t, 1, result.Resumes)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+}
+
+func TestDownload_ResourceChanged(t *testing.T) {
+	t.Parallel()
+	server, _ := newFlakyDownloadServer(t, func(n int32) string {
+		if n == 1 {
+			return `"v1"`
+		}
+		return `"v2"`
+	})
+
+	client := New(Config{}, "test-download-changed")
+	defer client.Close()
+
+	_, err := client.Download(context.Background(), server.URL, &bytes.Buffer{})
+	assert.ErrorIs(t, err, errResourceChanged)
+}
+
+func TestDownload_NoResumeWhenDisabled(t *testing.T) {
+	t.Parallel()
+	server, requests := newFlakyDownloadServer(t, func(int32) string { return `"v1"` })
+
+	client := New(Config{}, "test-download-no-resume")
+	defer client.Close()
+
+	result, err := client.Download(context.Background(), server.URL, &bytes.Buffer{}, WithMaxResumes(0))
+	require.Error(t, err)
+	assert.Equal(t, int64(20000), result.Written)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestDownload_ResumeFromOffset(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "bytes=60000-", r.Header.Get("Range"))
+		http.ServeContent(w, r, "payload.bin", time.Time{}, bytes.NewReader(downloadPayload))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-download-offset")
+	defer client.Close()
+
+	dst := bytes.NewBuffer(append([]byte(nil), downloadPayload[:60000]...))
+	result, err := client.Download(context.Background(), server.URL, dst, WithResumeFrom(60000))
+	require.NoError(t, err)
+	assert.Equal(t, downloadPayload, dst.Bytes())
+	assert.Equal(t, int64(len(downloadPayload)-60000), result.Written)
+}
+
+func TestDownload_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Body: "content"})
+	defer server.Close()
+
+	client := New(Config{}, "test-download-checksum")
+	defer client.Close()
+
+	_, err := client.Download(context.Background(), server.URL, &bytes.Buffer{},
+		WithDownloadChecksum(sha256.New(), "DEADBEEF"))
+	var mismatch *ChecksumMismatchError
+	require.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "deadbeef", mismatch.Expected)
+}
+
+func TestDownload_BandwidthLimit(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Body: strings.Repeat("x", 3000)})
+	defer server.Close()
+
+	client := New(Config{}, "test-download-throttle")
+	defer client.Close()
+
+	start := time.Now()
+	_, err := client.Download(context.Background(), server.URL, &bytes.Buffer{}, WithBandwidthLimit(10000))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 250*time.Millisecond)
+}
+
+func TestDownload_BandwidthLimitFakeClock(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Body: strings.Repeat("x", 3000)})
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	client := New(Config{Clock: clock}, "test-download-throttle-clock")
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Download(context.Background(), server.URL, &bytes.Buffer{}, WithBandwidthLimit(100))
+		done <- err
+	}()
+
+	// 3000 bytes at 100 B/s take 30 s of the fake clock
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+			return
+		case <-time.After(time.Millisecond):
+			clock.Advance(time.Second)
+		case <-timeout:
+			t.Fatal("download did not finish")
+		}
+	}
+}
+
+func TestDownload_BodyTooLargeNotResumed(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Accept-Ranges", "bytes")
+		start := 0
+		if r.Header.Get("Range") != "" {
+			start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.Header.Get("Range"), "bytes="), "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(downloadPayload)-1, len(downloadPayload)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		// Without Content-Length the limit is only found while reading
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(downloadPayload[start:])
+	}))
+	defer server.Close()
+
+	client := New(Config{MaxResponseBodyBytes: 10000}, "test-download-too-large")
+	defer client.Close()
+
+	// Each Range response would fit the limit: the download stops at the first one
+	var dst bytes.Buffer
+	result, err := client.Download(context.Background(), server.URL, &dst, WithMaxResumes(10))
+	require.ErrorAs(t, err, new(*BodyTooLargeError))
+	assert.Equal(t, 0, result.Resumes)
+	assert.Equal(t, int32(1), requests.Load())
+	assert.LessOrEqual(t, dst.Len(), 10000)
+}
+
+func TestDownload_HTTPError(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusNotFound, Body: "no such file"})
+	defer server.Close()
+
+	client := New(Config{}, "test-download-404")
+	defer client.Close()
+
+	_, err := client.Download(context.Background(), server.URL, &bytes.Buffer{})
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	assert.Equal(t, "no such file", string(httpErr.Body))
+}
@@ -0,0 +1,152 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// drainTracker counts in-flight requests and rejects new ones once the client is closed.
+type drainTracker struct {
+	mu      sync.Mutex
+	closed  bool
+	active  int
+	drained chan struct{} // closed when the tracker is closed and no request is active
+}
+
+// newDrainTracker creates an open tracker.
+func newDrainTracker() *drainTracker {
+	return &drainTracker{drained: make(chan struct{})}
+}
+
+// begin registers a request and reports false once the tracker is closed.
+func (d *drainTracker) begin() bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// end unregisters a request.
+func (d *drainTracker) end() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.closed && d.active == 0 {
+		close(d.drained)
+	}
+}
+
+// isClosed reports whether the tracker is closed.
+func (d *drainTracker) isClosed() bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.closed
+}
+
+// close rejects new requests and returns a channel closed once no request is active.
+func (d *drainTracker) close() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		d.closed = true
+		if d.active == 0 {
+			close(d.drained)
+		}
+	}
+	return d.drained
+}
+
+// track keeps the request in flight until the response body is closed.
+func (d *drainTracker) track(resp *http.Response, err error) *http.Response {
+	if d == nil {
+		return resp
+	}
+	if err != nil || resp == nil || resp.Body == nil {
+		d.end()
+		return resp
+	}
+	resp.Body = &drainTrackedBody{ReadCloser: resp.Body, tracker: d}
+	return resp
+}
+
+// drainTrackedBody ends the tracked request when the body is closed.
+type drainTrackedBody struct {
+	io.ReadCloser
+	tracker *drainTracker
+	once    sync.Once
+}
+
+// Close closes the underlying body and ends the request.
+func (b *drainTrackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.tracker.end)
+	return err
+}
+
+// closeIdleConnections closes idle connections of transports supporting it.
+func closeIdleConnections(transport http.RoundTripper) {
+	if closer, ok := transport.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// Shutdown rejects new requests with *ClientClosedError, waits until in-flight requests
+// finish and their response bodies are closed or ctx is done, then closes idle connections
+// and releases client resources. It returns an error wrapping ctx.Err() if ctx is done first.
+func (c *Client) Shutdown(ctx context.Context) error {
+	if c.parent != nil {
+		return c.release()
+	}
+	var err error
+	select {
+	case <-c.drain.close():
+	case <-ctx.Done():
+		err = fmt.Errorf("in-flight requests did not finish: %w", ctx.Err())
+	}
+	return errors.Join(err, c.release())
+}
+
+// release stops the Enqueue workers, rejects new requests, closes idle connections and
+// releases client resources. A scoped client only stops its Enqueue workers and releases
+// its metrics and those of its scopes.
+func (c *Client) release() error {
+	if q := c.startedQueue(); q != nil {
+		q.close()
+	}
+	if c.parent != nil {
+		c.parent.scopesMu.Lock()
+		delete(c.parent.scopes, c)
+		c.parent.scopesMu.Unlock()
+		c.closeScopes()
+		return c.metrics.Close()
+	}
+	c.drain.close()
+	c.closeScopes()
+	if c.stopReaper != nil {
+		c.stopReaper()
+	}
+	if c.keepAlive != nil {
+		c.keepAlive.close()
+	}
+	c.httpClient.CloseIdleConnections()
+	if c.stopBreakerMetrics != nil {
+		c.stopBreakerMetrics()
+	}
+	if c.metrics != nil {
+		return c.metrics.Close()
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingServer answers requests once release is closed and reports received requests.
+func blockingServer(t *testing.T) (server *httptest.Server, received, release chan struct{}) {
+	t.Helper()
+	received = make(chan struct{}, 1)
+	release = make(chan struct{})
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		received <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("done"))
+	}))
+	t.Cleanup(server.Close)
+	return server, received, release
+}
+
+func TestClient_ShutdownWaitsForInflight(t *testing.T) {
+	t.Parallel()
+	server, received, release := blockingServer(t)
+	client := New(Config{}, "test-shutdown-drain")
+
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		results <- result{body: string(body), err: err}
+	}()
+	<-received
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- client.Shutdown(context.Background())
+	}()
+	require.Eventually(t, client.drain.isClosed, time.Second, time.Millisecond)
+
+	_, err := client.Get(context.Background(), server.URL)
+	var closed *ClientClosedError
+	require.ErrorAs(t, err, &closed)
+	assert.Equal(t, http.MethodGet, closed.Method)
+	assert.True(t, IsClientClosedError(err))
+
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown returned before the in-flight request finished")
+	default:
+	}
+
+	close(release)
+	res := <-results
+	require.NoError(t, res.err)
+	assert.Equal(t, "done", res.body)
+	assert.NoError(t, <-shutdown)
+}
+
+func TestClient_ShutdownDeadline(t *testing.T) {
+	t.Parallel()
+	server, received, release := blockingServer(t)
+	defer close(release)
+	client := New(Config{DrainTimeout: 20 * time.Millisecond}, "test-shutdown-deadline")
+
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-received
+
+	start := time.Now()
+	err := client.Close()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClient_ShutdownWaitsForBody(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Body: "payload"})
+	defer server.Close()
+	client := New(Config{}, "test-shutdown-body")
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, client.Shutdown(ctx), context.DeadlineExceeded, "the unread body keeps the request in flight")
+
+	require.NoError(t, resp.Body.Close())
+	assert.NoError(t, client.Shutdown(context.Background()))
+}
+
+func TestClient_CloseWithoutDrainTimeout(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-close-reject")
+	require.NoError(t, client.Close())
+
+	_, err := client.Get(context.Background(), "http://example.com")
+	assert.True(t, IsClientClosedError(err))
+}
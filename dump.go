@@ -0,0 +1,277 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default dump settings.
+const (
+	defaultDumpMaxBodyBytes = 4096
+	redactedValue           = "[REDACTED]"
+)
+
+// DefaultRedactedHeaders lists headers whose values are never written by DumpMiddleware.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"Idempotency-Key",
+}
+
+// DumpConfig contains settings for DumpMiddleware.
+type DumpConfig struct {
+	// Logf receives the formatted dumps (default: log.Printf).
+	// Install the middleware only when debug logging is wanted.
+	Logf func(format string, args ...interface{})
+
+	// IncludeBody enables dumping of request and response bodies.
+	IncludeBody bool
+
+	// MaxBodyBytes truncates dumped bodies (default: 4096).
+	MaxBodyBytes int
+
+	// RedactHeaders lists headers whose values are replaced with [REDACTED]
+	// (default: DefaultRedactedHeaders).
+	RedactHeaders []string
+
+	// RedactJSONFields lists dot-separated JSON paths whose values are replaced with [REDACTED],
+	// e.g. "password" or "card.number". A "*" segment matches any object key or array element.
+	RedactJSONFields []string
+}
+
+// withDefaults applies default values to the dump configuration.
+func (dc DumpConfig) withDefaults() DumpConfig {
+	if dc.Logf == nil {
+		dc.Logf = log.Printf
+	}
+
+	if dc.MaxBodyBytes <= 0 {
+		dc.MaxBodyBytes = defaultDumpMaxBodyBytes
+	}
+
+	if dc.RedactHeaders == nil {
+		dc.RedactHeaders = DefaultRedactedHeaders
+	}
+
+	return dc
+}
+
+// DumpMiddleware logs full request and response headers and, optionally, bodies
+// with sensitive headers and JSON fields redacted.
+type DumpMiddleware struct {
+	config        DumpConfig
+	redactHeaders map[string]struct{}
+	redactPaths   [][]string
+}
+
+// NewDumpMiddleware creates a new request/response dump middleware.
+func NewDumpMiddleware(config DumpConfig) *DumpMiddleware {
+	config = config.withDefaults()
+
+	redactHeaders := make(map[string]struct{}, len(config.RedactHeaders))
+	for _, h := range config.RedactHeaders {
+		redactHeaders[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	redactPaths := make([][]string, 0, len(config.RedactJSONFields))
+	for _, field := range config.RedactJSONFields {
+		redactPaths = append(redactPaths, strings.Split(field, "."))
+	}
+
+	return &DumpMiddleware{
+		config:        config,
+		redactHeaders: redactHeaders,
+		redactPaths:   redactPaths,
+	}
+}
+
+// Process implements the Middleware interface.
+func (dm *DumpMiddleware) Process(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	var reqBody []byte
+	var reqTruncated bool
+	if dm.config.IncludeBody && req.Body != nil && req.Body != http.NoBody {
+		// The body is replaced on a shallow copy, the request may be the caller's
+		peeked := *req
+		reqBody, reqTruncated, peeked.Body = dm.peekBody(req.Body)
+		req = &peeked
+	}
+	dm.config.Logf("%s", dm.formatRequest(req, reqBody, reqTruncated))
+
+	start := time.Now()
+	resp, err := next(req)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		dm.config.Logf("<-- %s %s error after %v: %v", req.Method, req.URL.Redacted(), elapsed, err)
+	}
+	if resp == nil {
+		return resp, err
+	}
+
+	var respBody []byte
+	var respTruncated bool
+	if dm.config.IncludeBody && resp.Body != nil && resp.Body != http.NoBody {
+		respBody, respTruncated, resp.Body = dm.peekBody(resp.Body)
+	}
+	dm.config.Logf("%s", dm.formatResponse(req, resp, elapsed, respBody, respTruncated))
+
+	return resp, err
+}
+
+// peekBody reads up to MaxBodyBytes from body and returns a reader replaying the whole stream.
+func (dm *DumpMiddleware) peekBody(body io.ReadCloser) ([]byte, bool, io.ReadCloser) {
+	prefix, err := io.ReadAll(io.LimitReader(body, int64(dm.config.MaxBodyBytes)+1))
+	truncated := len(prefix) > dm.config.MaxBodyBytes
+
+	replay := &replayBody{
+		Reader: io.MultiReader(bytes.NewReader(prefix), body),
+		closer: body,
+	}
+	if err != nil {
+		replay.Reader = io.MultiReader(bytes.NewReader(prefix), errReader{err: err})
+	}
+
+	if truncated {
+		prefix = prefix[:dm.config.MaxBodyBytes]
+	}
+	return prefix, truncated, replay
+}
+
+// formatRequest renders the request dump.
+func (dm *DumpMiddleware) formatRequest(req *http.Request, body []byte, truncated bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--> %s %s\n", req.Method, req.URL.Redacted())
+	dm.writeHeaders(&b, req.Header)
+	dm.writeBody(&b, req.Header, body, truncated)
+	return b.String()
+}
+
+// formatResponse renders the response dump.
+func (dm *DumpMiddleware) formatResponse(
+	req *http.Request, resp *http.Response, elapsed time.Duration, body []byte, truncated bool,
+) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<-- %s %s %s (%v)\n", resp.Status, req.Method, req.URL.Redacted(), elapsed)
+	dm.writeHeaders(&b, resp.Header)
+	dm.writeBody(&b, resp.Header, body, truncated)
+	return b.String()
+}
+
+// writeHeaders writes headers in a stable order, redacting sensitive values.
+func (dm *DumpMiddleware) writeHeaders(b *strings.Builder, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		value := strings.Join(header[k], ", ")
+		if _, ok := dm.redactHeaders[http.CanonicalHeaderKey(k)]; ok {
+			value = redactedValue
+		}
+		fmt.Fprintf(b, "%s: %s\n", k, value)
+	}
+}
+
+// writeBody writes the body, redacting JSON fields when configured.
+func (dm *DumpMiddleware) writeBody(b *strings.Builder, header http.Header, body []byte, truncated bool) {
+	if !dm.config.IncludeBody || len(body) == 0 {
+		return
+	}
+
+	b.WriteString("\n")
+	if len(dm.redactPaths) > 0 && strings.Contains(header.Get("Content-Type"), "json") {
+		redacted, ok := dm.redactJSON(body)
+		if !ok {
+			// A partial or invalid document cannot be redacted safely
+			fmt.Fprintf(b, "[%d bytes of JSON omitted: cannot redact]\n", len(body))
+			return
+		}
+		body = redacted
+	}
+
+	b.Write(body)
+	if truncated {
+		b.WriteString("...[truncated]")
+	}
+	b.WriteString("\n")
+}
+
+// redactJSON replaces configured JSON paths with [REDACTED].
+func (dm *DumpMiddleware) redactJSON(body []byte) ([]byte, bool) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, false
+	}
+
+	for _, path := range dm.redactPaths {
+		doc = redactJSONPath(doc, path)
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// redactJSONPath walks a decoded JSON document and redacts values at path.
+func redactJSONPath(node interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return redactedValue
+	}
+
+	segment, rest := path[0], path[1:]
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if segment == "*" || segment == key {
+				v[key] = redactJSONPath(child, rest)
+			}
+		}
+	case []interface{}:
+		// Arrays are transparent unless the segment explicitly matches every element
+		for i, child := range v {
+			if segment == "*" {
+				v[i] = redactJSONPath(child, rest)
+			} else {
+				v[i] = redactJSONPath(child, path)
+			}
+		}
+	}
+	return node
+}
+
+// replayBody replays a peeked prefix followed by the rest of the original stream.
+type replayBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+// Close closes the original body.
+func (r *replayBody) Close() error {
+	return r.closer.Close()
+}
+
+// errReader always returns the stored error.
+type errReader struct {
+	err error
+}
+
+// Read implements io.Reader.
+func (e errReader) Read(_ []byte) (int, error) {
+	return 0, e.err
+}
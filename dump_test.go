@@ -0,0 +1,168 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dumpCollector collects dump output for assertions.
+type dumpCollector struct {
+	mu    sync.Mutex
+	dumps []string
+}
+
+func (c *dumpCollector) logf(format string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dumps = append(c.dumps, fmt.Sprintf(format, args...))
+}
+
+func (c *dumpCollector) all() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return strings.Join(c.dumps, "\n")
+}
+
+func TestDumpMiddleware_RedactsHeaders(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Trace", "abc")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	collector := &dumpCollector{}
+	client := New(Config{
+		Middlewares: []Middleware{NewDumpMiddleware(DumpConfig{Logf: collector.logf})},
+	}, "test-dump-headers")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL,
+		WithBearerToken("super-secret"), WithIdempotencyKey("key-123"), WithHeader("X-Visible", "yes"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	out := collector.all()
+	assert.Contains(t, out, "--> GET "+server.URL)
+	assert.Contains(t, out, "<-- 200 OK GET "+server.URL)
+	assert.Contains(t, out, "Authorization: [REDACTED]")
+	assert.Contains(t, out, "Idempotency-Key: [REDACTED]")
+	assert.Contains(t, out, "Set-Cookie: [REDACTED]")
+	assert.Contains(t, out, "X-Visible: yes")
+	assert.Contains(t, out, "X-Trace: abc")
+	assert.NotContains(t, out, "super-secret")
+	assert.NotContains(t, out, "key-123")
+	assert.NotContains(t, out, "session=secret")
+}
+
+func TestDumpMiddleware_RedactsJSONFieldsAndPreservesBodies(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"user":"john","password":"p4ss","card":{"number":"4111"}}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items":[{"token":"t1"},{"token":"t2"}],"ok":true}`))
+	}))
+	defer server.Close()
+
+	collector := &dumpCollector{}
+	client := New(Config{
+		Middlewares: []Middleware{NewDumpMiddleware(DumpConfig{
+			Logf:             collector.logf,
+			IncludeBody:      true,
+			RedactJSONFields: []string{"password", "card.number", "items.*.token"},
+		})},
+	}, "test-dump-json")
+	defer client.Close()
+
+	resp, err := client.Post(context.Background(), server.URL, nil,
+		WithJSONBody(`{"user":"john","password":"p4ss","card":{"number":"4111"}}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items":[{"token":"t1"},{"token":"t2"}],"ok":true}`, string(body))
+
+	out := collector.all()
+	assert.Contains(t, out, `"user":"john"`)
+	assert.Contains(t, out, `"ok":true`)
+	assert.NotContains(t, out, "p4ss")
+	assert.NotContains(t, out, "4111")
+	assert.NotContains(t, out, `"t1"`)
+	assert.NotContains(t, out, `"t2"`)
+}
+
+func TestDumpMiddleware_KeepsCallerRequest(t *testing.T) {
+	t.Parallel()
+	collector := &dumpCollector{}
+	dm := NewDumpMiddleware(DumpConfig{Logf: collector.logf, IncludeBody: true})
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", strings.NewReader("payload"))
+	require.NoError(t, err)
+	original := req.Body
+
+	_, err = dm.Process(req, func(sent *http.Request) (*http.Response, error) {
+		assert.NotSame(t, req, sent)
+		body, err := io.ReadAll(sent.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "payload", string(body))
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: sent}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, original, req.Body)
+	assert.Contains(t, collector.all(), "payload")
+}
+
+func TestDumpMiddleware_TruncatesBody(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("z", 100)))
+	}))
+	defer server.Close()
+
+	collector := &dumpCollector{}
+	client := New(Config{
+		Middlewares: []Middleware{NewDumpMiddleware(DumpConfig{
+			Logf:         collector.logf,
+			IncludeBody:  true,
+			MaxBodyBytes: 10,
+		})},
+	}, "test-dump-truncate")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Len(t, body, 100)
+
+	out := collector.all()
+	assert.Contains(t, out, strings.Repeat("z", 10)+"...[truncated]")
+	assert.NotContains(t, out, strings.Repeat("z", 11))
+}
+
+func TestRedactJSONPath(t *testing.T) {
+	t.Parallel()
+	dm := NewDumpMiddleware(DumpConfig{RedactJSONFields: []string{"*.secret"}})
+
+	out, ok := dm.redactJSON([]byte(`{"a":{"secret":1,"open":2},"b":{"secret":3}}`))
+	require.True(t, ok)
+	assert.JSONEq(t, `{"a":{"secret":"[REDACTED]","open":2},"b":{"secret":"[REDACTED]"}}`, string(out))
+
+	_, ok = dm.redactJSON([]byte(`{"truncated":`))
+	assert.False(t, ok)
+}
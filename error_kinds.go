@@ -0,0 +1,146 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// Sentinel errors classifying failed requests. Errors returned by the client match them with
+// errors.Is, e.g. errors.Is(err, httpclient.ErrTimeout), while errors.As still reaches the
+// underlying *net.DNSError, *TimeoutError or *HTTPError. An error can match several of them:
+// a dial timeout is both ErrTimeout and ErrConnection.
+var (
+	// ErrTimeout matches requests that ran out of time: Config.Timeout, PerTryTimeout,
+	// a context deadline or a network timeout
+	ErrTimeout = errors.New("request timed out")
+	// ErrConnection matches requests whose connection could not be established or broke,
+	// including DNS and TLS failures
+	ErrConnection = errors.New("connection failed")
+	// ErrDNS matches host name resolution failures
+	ErrDNS = errors.New("DNS lookup failed")
+	// ErrTLS matches TLS handshake and certificate verification failures
+	ErrTLS = errors.New("TLS handshake failed")
+	// ErrRateLimited matches requests that waited too long for the rate limiter and
+	// *HTTPError or *MaxAttemptsExceededError with a 429 Too Many Requests status
+	ErrRateLimited = errors.New("rate limited")
+	// ErrCircuitOpen matches requests rejected by an open circuit breaker
+	ErrCircuitOpen = ErrCircuitBreakerOpen
+	// ErrMaxAttempts matches *MaxAttemptsExceededError
+	ErrMaxAttempts = errors.New("max attempts exceeded")
+)
+
+// RequestError is a failed request classified by the sentinel errors. Its message is the
+// message of the underlying error.
+type RequestError struct {
+	Method string
+	URL    string
+	Host   string
+	// Kinds lists the sentinel errors matched by the failure, most specific first
+	Kinds []error
+	Err   error
+}
+
+// Error implements the error interface.
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the sentinel errors and the underlying error for errors.Is and errors.As.
+func (e *RequestError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Kinds)+1)
+	errs = append(errs, e.Kinds...)
+	return append(errs, e.Err)
+}
+
+// classifyRequestError wraps err in a *RequestError when it matches sentinel errors it doesn't
+// match yet. kinds are added to the ones found in err.
+func classifyRequestError(req *http.Request, err error, kinds ...error) error {
+	if err == nil {
+		return nil
+	}
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) && len(kinds) == 0 {
+		return err
+	}
+
+	var missing []error
+	for _, kind := range append(kinds, errorKinds(err)...) {
+		if !errors.Is(err, kind) && !slices.Contains(missing, kind) {
+			missing = append(missing, kind)
+		}
+	}
+	if len(missing) == 0 {
+		return err
+	}
+	return &RequestError{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Host:   getHost(req.URL),
+		Kinds:  missing,
+		Err:    err,
+	}
+}
+
+// errorKinds returns the sentinel errors describing err, most specific first.
+func errorKinds(err error) []error {
+	var kinds []error
+	if isDNSError(err) {
+		kinds = append(kinds, ErrDNS)
+	}
+	if isTLSError(err) {
+		kinds = append(kinds, ErrTLS)
+	}
+	connection := len(kinds) > 0 || isConnectionError(err)
+	if isTimeoutError(err) {
+		kinds = append(kinds, ErrTimeout)
+	}
+	if connection {
+		kinds = append(kinds, ErrConnection)
+	}
+	return kinds
+}
+
+// isDNSError checks if an error is a host name resolution failure.
+func isDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) || strings.Contains(err.Error(), "no such host")
+}
+
+// isTLSError checks if an error is a TLS handshake or certificate failure.
+func isTLSError(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "tls: ") || strings.Contains(msg, "x509: ")
+}
+
+// isConnectionError checks if a connection could not be established or broke.
+func isConnectionError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	return isPreConnectError(err)
+}
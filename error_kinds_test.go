@@ -0,0 +1,144 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closedPortURL returns the URL of a port nothing listens on.
+func closedPortURL(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return "http://" + addr + "/orders"
+}
+
+func TestErrorKinds_Connection(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-error-kinds-connection")
+	defer client.Close()
+
+	url := closedPortURL(t)
+	_, err := client.Get(context.Background(), url)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrConnection)
+	assert.NotErrorIs(t, err, ErrTimeout)
+	assert.NotErrorIs(t, err, ErrDNS)
+
+	var requestErr *RequestError
+	require.ErrorAs(t, err, &requestErr)
+	assert.Equal(t, http.MethodGet, requestErr.Method)
+	assert.Equal(t, url, requestErr.URL)
+	var opErr *net.OpError
+	assert.ErrorAs(t, err, &opErr)
+}
+
+func TestErrorKinds_DNS(t *testing.T) {
+	t.Parallel()
+	transport := &http.Transport{
+		DialContext: func(_ context.Context, _, addr string) (net.Conn, error) {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}}
+		},
+	}
+	client := New(Config{Transport: transport}, "test-error-kinds-dns")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), "http://missing.example/")
+	assert.ErrorIs(t, err, ErrDNS)
+	assert.ErrorIs(t, err, ErrConnection)
+	var dnsErr *net.DNSError
+	assert.ErrorAs(t, err, &dnsErr)
+}
+
+func TestErrorKinds_TLS(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+
+	client := New(Config{}, "test-error-kinds-tls")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrTLS)
+	assert.ErrorIs(t, err, ErrConnection)
+}
+
+func TestErrorKinds_Timeout(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		Timeout:       time.Second,
+		PerTryTimeout: 20 * time.Millisecond,
+		RetryEnabled:  true,
+		RetryConfig:   RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}, "test-error-kinds-timeout")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.NotErrorIs(t, err, ErrConnection)
+	var timeoutErr *TimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+}
+
+func TestErrorKinds_RateLimited(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	client := New(Config{
+		RateLimiterEnabled: true,
+		RateLimiterConfig:  RateLimiterConfig{RequestsPerSecond: 0.1, BurstCapacity: 1},
+	}, "test-error-kinds-rate-limited")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.Get(ctx, server.URL)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestErrorKinds_StatusErrors(t *testing.T) {
+	t.Parallel()
+	assert.ErrorIs(t, &HTTPError{StatusCode: http.StatusTooManyRequests}, ErrRateLimited)
+	assert.NotErrorIs(t, &HTTPError{StatusCode: http.StatusServiceUnavailable}, ErrRateLimited)
+	maxErr := &MaxAttemptsExceededError{MaxAttempts: 3, LastError: io.ErrUnexpectedEOF, LastStatus: http.StatusTooManyRequests}
+	assert.ErrorIs(t, maxErr, ErrMaxAttempts)
+	assert.ErrorIs(t, maxErr, ErrRateLimited)
+	assert.ErrorIs(t, maxErr, io.ErrUnexpectedEOF)
+	assert.ErrorIs(t, &TimeoutError{}, ErrTimeout)
+	assert.ErrorIs(t, ErrCircuitBreakerOpen, ErrCircuitOpen)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	assert.Nil(t, classifyRequestError(req, nil))
+	assert.Equal(t, context.Canceled, classifyRequestError(req, context.Canceled))
+
+	classified := classifyRequestError(req, maxErr)
+	var requestErr *RequestError
+	require.ErrorAs(t, classified, &requestErr)
+	assert.Equal(t, []error{ErrConnection}, requestErr.Kinds)
+	assert.Same(t, classified, classifyRequestError(req, classified))
+}
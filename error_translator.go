@@ -0,0 +1,198 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// ErrorTranslator maps the errors of the client to application errors in one place, so that
+// handlers don't each inspect transport and HTTP errors. It is set as Config.ErrorTranslator.
+type ErrorTranslator interface {
+	// TranslateError returns the application error for err; nil keeps err
+	TranslateError(err error) error
+}
+
+// ErrorTranslatorFunc adapts a function to ErrorTranslator.
+type ErrorTranslatorFunc func(err error) error
+
+// TranslateError calls f(err).
+func (f ErrorTranslatorFunc) TranslateError(err error) error {
+	return f(err)
+}
+
+// TranslatedError is an application error produced by CodeTranslator. Code, StatusCode and
+// Message are meant for API consumers, Err and Suggestions for developer logs.
+type TranslatedError struct {
+	// Code identifies the error for API consumers and message catalogs, e.g. "upstream.timeout"
+	Code string
+	// StatusCode is the HTTP status to answer API consumers with
+	StatusCode int
+	// Message is the default English message, safe to show to API consumers
+	Message string
+	// Suggestions explain how to fix the failure, e.g. those of *TimeoutError
+	Suggestions []string
+	// Err is the original error
+	Err error
+}
+
+// Error implements the error interface.
+func (e *TranslatedError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+}
+
+// Unwrap returns the original error for errors.Is and errors.As.
+func (e *TranslatedError) Unwrap() error {
+	return e.Err
+}
+
+// IsTranslatedError checks if an error is a translated error.
+func IsTranslatedError(err error) bool {
+	var translated *TranslatedError
+	return errors.As(err, &translated)
+}
+
+// ErrorRule maps the errors matched by Match to an application error.
+type ErrorRule struct {
+	// Match reports whether the rule applies to the error, see MatchKind and MatchStatus
+	Match func(err error) bool
+	// Code identifies the error, e.g. "payment.declined"
+	Code string
+	// StatusCode is the HTTP status for API consumers
+	StatusCode int
+	// Message is the default English message
+	Message string
+}
+
+// MatchKind matches errors that are any of the kinds with errors.Is, e.g. ErrTimeout.
+func MatchKind(kinds ...error) func(err error) bool {
+	return func(err error) bool {
+		return slices.ContainsFunc(kinds, func(kind error) bool { return errors.Is(err, kind) })
+	}
+}
+
+// MatchStatus matches errors carrying one of the HTTP statuses: *HTTPError,
+// *AuthRejectedError and the last status of *MaxAttemptsExceededError and
+// *RetryBudgetExhaustedError.
+func MatchStatus(codes ...int) func(err error) bool {
+	return func(err error) bool {
+		return slices.Contains(codes, errorStatus(err))
+	}
+}
+
+// matchStatusClass matches errors carrying a status in [min, max].
+func matchStatusClass(minStatus, maxStatus int) func(err error) bool {
+	return func(err error) bool {
+		status := errorStatus(err)
+		return status >= minStatus && status <= maxStatus
+	}
+}
+
+// errorStatus returns the HTTP status carried by err, or 0.
+func errorStatus(err error) int {
+	var (
+		httpErr     *HTTPError
+		rejectedErr *AuthRejectedError
+		attemptsErr *MaxAttemptsExceededError
+		budgetErr   *RetryBudgetExhaustedError
+	)
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode
+	case errors.As(err, &rejectedErr):
+		return rejectedErr.StatusCode
+	case errors.As(err, &attemptsErr):
+		return attemptsErr.LastStatus
+	case errors.As(err, &budgetErr):
+		return budgetErr.LastStatus
+	}
+	return 0
+}
+
+// DefaultErrorRules are the rules CodeTranslator applies after its own, most specific first.
+var DefaultErrorRules = []ErrorRule{
+	{Match: MatchKind(context.Canceled), Code: "request.cancelled", StatusCode: 499,
+		Message: "the request was cancelled"},
+	{Match: MatchKind(ErrCircuitOpen), Code: "upstream.unavailable", StatusCode: http.StatusServiceUnavailable,
+		Message: "an upstream service is temporarily unavailable"},
+	{Match: MatchKind(ErrRateLimited), Code: "upstream.rate_limited", StatusCode: http.StatusServiceUnavailable,
+		Message: "an upstream service is overloaded, try again later"},
+	{Match: MatchKind(ErrTimeout), Code: "upstream.timeout", StatusCode: http.StatusGatewayTimeout,
+		Message: "an upstream service did not respond in time"},
+	{Match: MatchKind(ErrConnection), Code: "upstream.unreachable", StatusCode: http.StatusBadGateway,
+		Message: "an upstream service is unreachable"},
+	{Match: MatchStatus(http.StatusNotFound), Code: "upstream.not_found", StatusCode: http.StatusNotFound,
+		Message: "the requested resource was not found"},
+	{Match: MatchStatus(http.StatusUnauthorized, http.StatusForbidden), Code: "upstream.unauthorized",
+		StatusCode: http.StatusBadGateway, Message: "an upstream service rejected the credentials"},
+	{Match: matchStatusClass(400, 499), Code: "upstream.rejected", StatusCode: http.StatusBadGateway,
+		Message: "an upstream service rejected the request"},
+}
+
+// fallbackErrorRule translates errors matched by no rule.
+var fallbackErrorRule = ErrorRule{
+	Code: "upstream.error", StatusCode: http.StatusBadGateway, Message: "an upstream service failed",
+}
+
+// CodeTranslator translates errors to *TranslatedError with the first matching ErrorRule:
+// its own rules, then DefaultErrorRules, then the "upstream.error" code.
+type CodeTranslator struct {
+	rules []ErrorRule
+}
+
+// NewCodeTranslator creates a translator checking the rules before DefaultErrorRules.
+func NewCodeTranslator(rules ...ErrorRule) *CodeTranslator {
+	return &CodeTranslator{rules: append(slices.Clone(rules), DefaultErrorRules...)}
+}
+
+// TranslateError implements ErrorTranslator.
+func (t *CodeTranslator) TranslateError(err error) error {
+	if translated := t.Translate(err); translated != nil {
+		return translated
+	}
+	return nil
+}
+
+// Translate returns the translated error, or nil for a nil err. Errors that are already
+// translated are returned as is, so handlers can translate every error they get.
+func (t *CodeTranslator) Translate(err error) *TranslatedError {
+	if err == nil {
+		return nil
+	}
+	var translated *TranslatedError
+	if errors.As(err, &translated) {
+		return translated
+	}
+
+	rule := fallbackErrorRule
+	for _, r := range t.rules {
+		if r.Match != nil && r.Match(err) {
+			rule = r
+			break
+		}
+	}
+	translated = &TranslatedError{
+		Code:       rule.Code,
+		StatusCode: rule.StatusCode,
+		Message:    rule.Message,
+		Err:        err,
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		translated.Suggestions = timeoutErr.Suggestions
+	}
+	return translated
+}
+
+// translateError applies Config.ErrorTranslator to an error returned by the client.
+func (c *Client) translateError(err error) error {
+	if err == nil || c.config.ErrorTranslator == nil {
+		return err
+	}
+	if translated := c.config.ErrorTranslator.TranslateError(err); translated != nil {
+		return translated
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeTranslator_DefaultRules(t *testing.T) {
+	t.Parallel()
+	translator := NewCodeTranslator()
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/orders", nil)
+
+	tests := []struct {
+		name   string
+		err    error
+		code   string
+		status int
+	}{
+		{"cancelled", fmt.Errorf("get: %w", context.Canceled), "request.cancelled", 499},
+		{"circuit open", ErrCircuitOpen, "upstream.unavailable", http.StatusServiceUnavailable},
+		{"timeout", NewTimeoutError(req, Config{Timeout: time.Second}, 1, 1, time.Second, "overall", context.DeadlineExceeded),
+			"upstream.timeout", http.StatusGatewayTimeout},
+		{"dns", classifyRequestError(req, errors.New("dial tcp: lookup api.example.com: no such host")),
+			"upstream.unreachable", http.StatusBadGateway},
+		{"rate limited", &HTTPError{StatusCode: http.StatusTooManyRequests}, "upstream.rate_limited", http.StatusServiceUnavailable},
+		{"not found", &HTTPError{StatusCode: http.StatusNotFound}, "upstream.not_found", http.StatusNotFound},
+		{"unauthorized", &AuthRejectedError{StatusCode: http.StatusForbidden}, "upstream.unauthorized", http.StatusBadGateway},
+		{"rejected", &HTTPError{StatusCode: http.StatusConflict}, "upstream.rejected", http.StatusBadGateway},
+		{"exhausted", &MaxAttemptsExceededError{MaxAttempts: 3, LastStatus: http.StatusBadGateway}, "upstream.error", http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			translated := translator.Translate(tt.err)
+			require.NotNil(t, translated)
+			assert.Equal(t, tt.code, translated.Code)
+			assert.Equal(t, tt.status, translated.StatusCode)
+			assert.NotEmpty(t, translated.Message)
+			assert.ErrorIs(t, translated, tt.err)
+		})
+	}
+
+	timeoutErr := translator.Translate(tests[2].err)
+	assert.NotEmpty(t, timeoutErr.Suggestions)
+	assert.Nil(t, translator.Translate(nil))
+	assert.Same(t, timeoutErr, translator.Translate(fmt.Errorf("handler: %w", timeoutErr)))
+}
+
+func TestCodeTranslator_CustomRules(t *testing.T) {
+	t.Parallel()
+	translator := NewCodeTranslator(ErrorRule{
+		Match:      MatchStatus(http.StatusPaymentRequired),
+		Code:       "payment.declined",
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    "the payment was declined",
+	})
+
+	translated := translator.Translate(&HTTPError{StatusCode: http.StatusPaymentRequired})
+	assert.Equal(t, "payment.declined", translated.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, translated.StatusCode)
+	assert.Equal(t, "upstream.rejected", translator.Translate(&HTTPError{StatusCode: http.StatusBadRequest}).Code)
+}
+
+func TestClient_ErrorTranslator(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusNotFound, Body: `{"error":"missing"}`})
+	defer server.Close()
+
+	client := New(Config{ErrorTranslator: NewCodeTranslator()}, "test-error-translator")
+	defer client.Close()
+
+	// Status errors of the JSON helpers
+	var target map[string]any
+	err := client.GetJSON(context.Background(), server.URL, &target)
+	var translated *TranslatedError
+	require.ErrorAs(t, err, &translated)
+	assert.Equal(t, "upstream.not_found", translated.Code)
+	assert.True(t, IsHTTPError(err))
+
+	// Transport errors
+	_, err = client.Get(context.Background(), "http://127.0.0.1:1/")
+	require.ErrorAs(t, err, &translated)
+	assert.Equal(t, "upstream.unreachable", translated.Code)
+	assert.ErrorIs(t, err, ErrConnection)
+
+	// A translator returning nil keeps the error
+	keep := New(Config{ErrorTranslator: ErrorTranslatorFunc(func(error) error { return nil })}, "test-error-translator-keep")
+	defer keep.Close()
+	_, err = keep.Get(context.Background(), "http://127.0.0.1:1/")
+	require.Error(t, err)
+	assert.False(t, IsTranslatedError(err))
+}
@@ -22,6 +22,11 @@ func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d %s: %s %s", e.StatusCode, e.Status, e.Method, e.URL)
 }
 
+// Is reports whether a 429 Too Many Requests error matches ErrRateLimited.
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrRateLimited && e.StatusCode == http.StatusTooManyRequests
+}
+
 // IsHTTPError checks if an error is an HTTP error.
 func IsHTTPError(err error) bool {
 	var httpErr *HTTPError
@@ -59,6 +64,33 @@ func (e *MaxAttemptsExceededError) Unwrap() error {
 	return e.LastError
 }
 
+// Is reports whether the error matches ErrMaxAttempts, or ErrRateLimited after a last
+// 429 Too Many Requests status.
+func (e *MaxAttemptsExceededError) Is(target error) bool {
+	return target == ErrMaxAttempts || target == ErrRateLimited && e.LastStatus == http.StatusTooManyRequests
+}
+
+// RetryBudgetExhaustedError is returned when a request needs a retry
+// but the retry budget of the client is spent (see Config.RetryBudgetEnabled).
+type RetryBudgetExhaustedError struct {
+	Attempts   int
+	LastError  error
+	LastStatus int
+}
+
+// Error implements the error interface.
+func (e *RetryBudgetExhaustedError) Error() string {
+	if e.LastError != nil {
+		return fmt.Sprintf("retry budget exhausted after %d attempt(s), last error: %v", e.Attempts, e.LastError)
+	}
+	return fmt.Sprintf("retry budget exhausted after %d attempt(s), last status: %d", e.Attempts, e.LastStatus)
+}
+
+// Unwrap returns the last error for errors.Unwrap support.
+func (e *RetryBudgetExhaustedError) Unwrap() error {
+	return e.LastError
+}
+
 // TimeoutExceededError represents a timeout exceeded error.
 type TimeoutExceededError struct {
 	Timeout time.Duration
@@ -70,6 +102,43 @@ func (e *TimeoutExceededError) Error() string {
 	return fmt.Sprintf("timeout exceeded: %v elapsed, %v allowed", e.Elapsed, e.Timeout)
 }
 
+// ClientClosedError is returned for requests sent after Client.Close or Client.Shutdown.
+type ClientClosedError struct {
+	Method string
+	URL    string
+}
+
+// Error implements the error interface.
+func (e *ClientClosedError) Error() string {
+	return fmt.Sprintf("client is closed: %s %s", e.Method, e.URL)
+}
+
+// IsClientClosedError checks if a request was rejected because the client is closed.
+func IsClientClosedError(err error) bool {
+	var closed *ClientClosedError
+	return errors.As(err, &closed)
+}
+
+// BodyTooLargeError is returned while reading a response body that exceeds Config.MaxResponseBodyBytes.
+type BodyTooLargeError struct {
+	Limit int64
+	URL   string
+}
+
+// Error implements the error interface.
+func (e *BodyTooLargeError) Error() string {
+	if e.URL == "" {
+		return fmt.Sprintf("response body exceeds limit of %d bytes", e.Limit)
+	}
+	return fmt.Sprintf("response body exceeds limit of %d bytes: %s", e.Limit, e.URL)
+}
+
+// IsBodyTooLargeError checks if an error is caused by an oversized response body.
+func IsBodyTooLargeError(err error) bool {
+	var tooLarge *BodyTooLargeError
+	return errors.As(err, &tooLarge)
+}
+
 // ConfigurationError represents a configuration error.
 type ConfigurationError struct {
 	Field   string
@@ -132,6 +201,11 @@ func (e *TimeoutError) Unwrap() error {
 	return e.OriginalErr
 }
 
+// Is reports whether the error matches ErrTimeout, which it always does.
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
 // NewTimeoutError creates a detailed timeout error.
 func NewTimeoutError(
 	req *http.Request,
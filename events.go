@@ -0,0 +1,186 @@
+package httpclient
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event is a request lifecycle event passed to the handlers of Client.OnEvent. It is one of
+// RequestStarted, AttemptStarted, AttemptFinished, RetryScheduled, CircuitStateChanged,
+// RateLimited and RequestFinished; handlers pick the events they need with a type switch.
+type Event interface {
+	// EventName returns the name of the event, e.g. "request_started"
+	EventName() string
+}
+
+// RequestStarted is emitted when the client starts a request, before rate limiting and retries.
+type RequestStarted struct {
+	Request *http.Request
+	Time    time.Time
+}
+
+// AttemptStarted is emitted before an attempt is sent.
+type AttemptStarted struct {
+	Request *http.Request
+	// Attempt is the number of the attempt, starting at 1
+	Attempt int
+	Time    time.Time
+}
+
+// AttemptFinished is emitted when an attempt gets response headers or fails.
+type AttemptFinished struct {
+	Request *http.Request
+	Attempt int
+	// StatusCode is the status of the response, zero without a response
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+}
+
+// RetryScheduled is emitted when a failed attempt is retried after Delay.
+type RetryScheduled struct {
+	Request *http.Request
+	// Attempt is the number of the failed attempt
+	Attempt int
+	// Reason is the retry reason, as in the http_client_retries_total metric
+	Reason     string
+	StatusCode int
+	Err        error
+	Delay      time.Duration
+}
+
+// CircuitStateChanged is emitted when the circuit breaker of the client changes state.
+// Only the built-in SimpleCircuitBreaker reports transitions.
+type CircuitStateChanged struct {
+	From CircuitBreakerState
+	To   CircuitBreakerState
+	Time time.Time
+}
+
+// RateLimited is emitted when an attempt waited for the rate limiter.
+type RateLimited struct {
+	Request *http.Request
+	Waited  time.Duration
+}
+
+// RequestFinished is emitted when the request is done after all attempts and the fallback.
+type RequestFinished struct {
+	Request  *http.Request
+	Attempts int
+	// StatusCode is the status of the returned response, zero without a response
+	StatusCode int
+	Err        error
+	// Elapsed is the time until the response headers of the last attempt (or the error)
+	Elapsed time.Duration
+}
+
+// EventName implements Event.
+func (RequestStarted) EventName() string { return "request_started" }
+
+// EventName implements Event.
+func (AttemptStarted) EventName() string { return "attempt_started" }
+
+// EventName implements Event.
+func (AttemptFinished) EventName() string { return "attempt_finished" }
+
+// EventName implements Event.
+func (RetryScheduled) EventName() string { return "retry_scheduled" }
+
+// EventName implements Event.
+func (CircuitStateChanged) EventName() string { return "circuit_state_changed" }
+
+// EventName implements Event.
+func (RateLimited) EventName() string { return "rate_limited" }
+
+// EventName implements Event.
+func (RequestFinished) EventName() string { return "request_finished" }
+
+// OnEvent subscribes handler to the lifecycle events of the requests of the client, e.g. for APM
+// agents or dashboards. Handlers run synchronously on the request goroutine, so they must be
+// fast and must not block. Handlers of a client also receive the events of its scoped clients.
+// The returned function unsubscribes the handler.
+func (c *Client) OnEvent(handler func(Event)) (unsubscribe func()) {
+	return c.events.subscribe(handler)
+}
+
+// eventBus delivers events to the handlers subscribed with Client.OnEvent.
+type eventBus struct {
+	mu     sync.Mutex
+	nextID int
+	// handlers is replaced on every change, so emit reads it without locking
+	handlers atomic.Pointer[[]eventHandler]
+	// parent also receives the events of a scoped client
+	parent *eventBus
+}
+
+// eventHandler is a subscribed handler.
+type eventHandler struct {
+	id int
+	fn func(Event)
+}
+
+// newEventBus creates a bus forwarding events to parent, if any.
+func newEventBus(parent *eventBus) *eventBus {
+	return &eventBus{parent: parent}
+}
+
+// subscribe adds the handler and returns the function removing it.
+func (b *eventBus) subscribe(fn func(Event)) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	var handlers []eventHandler
+	if current := b.handlers.Load(); current != nil {
+		handlers = slices.Clone(*current)
+	}
+	handlers = append(handlers, eventHandler{id: id, fn: fn})
+	b.handlers.Store(&handlers)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.unsubscribe(id) })
+	}
+}
+
+// unsubscribe removes the handler with the id.
+func (b *eventBus) unsubscribe(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	handlers := slices.DeleteFunc(slices.Clone(*b.handlers.Load()), func(h eventHandler) bool {
+		return h.id == id
+	})
+	b.handlers.Store(&handlers)
+}
+
+// active reports whether any handler receives the events, so callers build events only then.
+func (b *eventBus) active() bool {
+	for ; b != nil; b = b.parent {
+		if handlers := b.handlers.Load(); handlers != nil && len(*handlers) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// emit passes the event to the handlers of the bus and its parents.
+func (b *eventBus) emit(event Event) {
+	for ; b != nil; b = b.parent {
+		if handlers := b.handlers.Load(); handlers != nil {
+			for _, h := range *handlers {
+				h.fn(event)
+			}
+		}
+	}
+}
+
+// statusCode returns the status of the response, zero without a response.
+func statusCode(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,188 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventRecorder collects the events of a client.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *eventRecorder) record(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.events))
+	for _, event := range r.events {
+		names = append(names, event.EventName())
+	}
+	return names
+}
+
+func TestOnEvent_RetryLifecycle(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}, "test-events-retry")
+	defer client.Close()
+	recorder := &eventRecorder{}
+	client.OnEvent(recorder.record)
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, []string{
+		"request_started",
+		"attempt_started", "attempt_finished", "retry_scheduled",
+		"attempt_started", "attempt_finished",
+		"request_finished",
+	}, recorder.names())
+
+	events := recorder.events
+	assert.Equal(t, http.StatusServiceUnavailable, events[2].(AttemptFinished).StatusCode)
+	retry := events[3].(RetryScheduled)
+	assert.Equal(t, 1, retry.Attempt)
+	assert.Equal(t, "status", retry.Reason)
+	assert.Equal(t, 2, events[4].(AttemptStarted).Attempt)
+	finished := events[6].(RequestFinished)
+	assert.Equal(t, 2, finished.Attempts)
+	assert.Equal(t, http.StatusOK, finished.StatusCode)
+	assert.Equal(t, server.URL, finished.Request.URL.String())
+}
+
+func TestOnEvent_FakeClock(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		clock.Advance(time.Second)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		Clock:                clock,
+		CircuitBreakerEnable: true,
+		CircuitBreaker:       NewCircuitBreakerWithConfig(CircuitBreakerConfig{FailureThreshold: 1, Clock: clock}),
+	}, "test-events-clock")
+	defer client.Close()
+	recorder := &eventRecorder{}
+	client.OnEvent(recorder.record)
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// Every timestamp and duration of the event stream comes from the Clock
+	events := make(map[string]Event)
+	for _, event := range recorder.events {
+		events[event.EventName()] = event
+	}
+	require.Len(t, events, 5)
+	assert.Equal(t, start, events["request_started"].(RequestStarted).Time)
+	assert.Equal(t, start, events["attempt_started"].(AttemptStarted).Time)
+	assert.Equal(t, time.Second, events["attempt_finished"].(AttemptFinished).Duration)
+	assert.Equal(t, start.Add(time.Second), events["circuit_state_changed"].(CircuitStateChanged).Time)
+	assert.Equal(t, time.Second, events["request_finished"].(RequestFinished).Elapsed)
+}
+
+func TestOnEvent_RateLimitedAndCircuit(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusInternalServerError})
+	defer server.Close()
+
+	client := New(Config{
+		RateLimiterEnabled:   true,
+		RateLimiterConfig:    RateLimiterConfig{RequestsPerSecond: 50, BurstCapacity: 1},
+		CircuitBreakerEnable: true,
+		CircuitBreaker:       NewCircuitBreakerWithConfig(CircuitBreakerConfig{FailureThreshold: 2, Timeout: time.Minute}),
+	}, "test-events-limits")
+	defer client.Close()
+	recorder := &eventRecorder{}
+	client.OnEvent(recorder.record)
+
+	for range 2 {
+		resp, err := client.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	var limited *RateLimited
+	var changed *CircuitStateChanged
+	for _, event := range recorder.events {
+		switch e := event.(type) {
+		case RateLimited:
+			limited = &e
+		case CircuitStateChanged:
+			changed = &e
+		}
+	}
+	require.NotNil(t, limited)
+	assert.Positive(t, limited.Waited)
+	require.NotNil(t, changed)
+	assert.Equal(t, CircuitBreakerClosed, changed.From)
+	assert.Equal(t, CircuitBreakerOpen, changed.To)
+}
+
+func TestOnEvent_UnsubscribeAndScope(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	client := New(Config{}, "test-events-scope")
+	defer client.Close()
+	scoped := client.Scope("test-events-scope-child", ScopeConfig{})
+
+	parentEvents, scopeEvents := &eventRecorder{}, &eventRecorder{}
+	unsubscribe := client.OnEvent(parentEvents.record)
+	scoped.OnEvent(scopeEvents.record)
+
+	resp, err := scoped.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Len(t, scopeEvents.names(), 4)
+	assert.Equal(t, scopeEvents.names(), parentEvents.names())
+
+	// Events of the parent don't reach the handlers of the scoped client
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Len(t, scopeEvents.names(), 4)
+	assert.Len(t, parentEvents.names(), 8)
+
+	unsubscribe()
+	unsubscribe()
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Len(t, parentEvents.names(), 8)
+	assert.False(t, client.events.active())
+}
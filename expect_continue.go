@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultExpectContinueTimeout is how long a request with Expect: 100-continue waits for the
+// server's interim response before sending the body anyway.
+const defaultExpectContinueTimeout = time.Second
+
+// WithExpectContinue sends the request with Expect: 100-continue, so the body is sent only after
+// the server's interim 100 Continue response. Large uploads rejected by the server (e.g. 401 or
+// 413) then don't waste bandwidth. Without an interim response the body is sent after
+// TransportTuning.ExpectContinueTimeout. Servers answering 417 Expectation Failed get the request
+// again without the expectation.
+func WithExpectContinue() RequestOption {
+	return WithHeader("Expect", "100-continue")
+}
+
+// expectsContinue checks if the request waits for a 100 Continue response.
+func expectsContinue(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get("Expect"), "100-continue")
+}
+
+// withoutExpect returns a shallow copy of the request without the Expect header.
+func withoutExpect(req *http.Request) *http.Request {
+	r := *req
+	r.Header = req.Header.Clone()
+	r.Header.Del("Expect")
+	return &r
+}
+
+// resendWithoutExpect sends the attempt again without the Expect header after a 417
+// Expectation Failed response, if the body can be sent again. Later attempts of the request
+// don't send the expectation either.
+func (rt *RoundTripper) resendWithoutExpect(
+	retryCtx *retryContext, req *http.Request, resp *http.Response,
+) (*http.Response, error) {
+	body, err := retryCtx.newAttemptBody()
+	if err != nil || body == nil && retryCtx.originalLength != 0 {
+		// The body was consumed and can't be sent again
+		return resp, nil
+	}
+	discardBody(resp, rt.config.RetryDrainLimit)
+
+	retryCtx.noExpect = true
+	retry := withoutExpect(req)
+	retry.Body = body
+	retry.ContentLength = retryCtx.originalLength
+	return rt.doHedgedTransport(retryCtx, retry)
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	io.Reader
+	read atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read.Add(int64(n))
+	return n, err
+}
+
+func TestWithExpectContinue_BodyNotSentOnRejection(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// Rejecting without reading the body makes the server skip 100 Continue
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-expect-continue",
+		WithTransportTuning(TransportTuning{ExpectContinueTimeout: 5 * time.Second}))
+	defer client.Close()
+
+	body := &countingReader{Reader: strings.NewReader(strings.Repeat("x", 1<<20))}
+	start := time.Now()
+	resp, err := client.Post(context.Background(), server.URL, body, WithExpectContinue())
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Zero(t, body.read.Load())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestWithExpectContinue_ExpectationFailed(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var expects, bodies []string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var body []byte
+		if req.Body != nil {
+			body, _ = io.ReadAll(req.Body)
+			_ = req.Body.Close()
+		}
+		mu.Lock()
+		expects = append(expects, req.Header.Get("Expect"))
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		status := http.StatusServiceUnavailable
+		switch {
+		case req.Header.Get("Expect") != "":
+			status = http.StatusExpectationFailed
+		case len(expects) == 3:
+			status = http.StatusCreated
+		}
+		return &http.Response{StatusCode: status, Body: http.NoBody, Header: http.Header{}, Request: req}, nil
+	})
+
+	client := New(Config{
+		Transport:    transport,
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			MaxAttempts:  2,
+			BaseDelay:    time.Millisecond,
+			MaxDelay:     time.Millisecond,
+			RetryMethods: []string{http.MethodPut},
+		},
+	}, "test-expect-continue-417")
+	defer client.Close()
+
+	resp, err := client.Put(context.Background(), "http://uploads.example.com/media", strings.NewReader("video"),
+		WithExpectContinue())
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// The 417 is resent at once without the expectation, and the retry doesn't expect either
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []string{"100-continue", "", ""}, expects)
+	assert.Equal(t, []string{"video", "video", "video"}, bodies)
+}
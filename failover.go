@@ -0,0 +1,285 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultFailoverCooldown is how long a failed target is skipped.
+	defaultFailoverCooldown = 30 * time.Second
+	// defaultFailoverFailureThreshold is the number of consecutive failures that mark a target unhealthy.
+	defaultFailoverFailureThreshold = 1
+)
+
+// FailoverPolicy defines how a healthy target is chosen for each attempt.
+type FailoverPolicy int
+
+const (
+	// FailoverSequential sends requests to the first healthy target in the list,
+	// so the primary is used again once its cooldown ends.
+	FailoverSequential FailoverPolicy = iota
+	// FailoverRoundRobin rotates requests across healthy targets.
+	FailoverRoundRobin
+	// FailoverWeighted spreads requests across healthy targets in proportion to their weights.
+	FailoverWeighted
+)
+
+// String returns the policy name.
+func (p FailoverPolicy) String() string {
+	switch p {
+	case FailoverSequential:
+		return "sequential"
+	case FailoverRoundRobin:
+		return "round-robin"
+	case FailoverWeighted:
+		return "weighted"
+	default:
+		return "unknown"
+	}
+}
+
+// FailoverTarget is a base URL serving the same API as the other targets.
+type FailoverTarget struct {
+	// URL is the base URL, e.g. "https://eu.api.example.com/v2"
+	URL string
+	// Weight is the share of requests with FailoverWeighted (default: 1)
+	Weight int
+}
+
+// FailoverConfig contains settings for sending requests to replicated endpoints.
+type FailoverConfig struct {
+	// Targets are the base URLs, the first one is the primary.
+	// Requests to the primary (or with a relative URL) are routed to a healthy target
+	Targets []FailoverTarget
+
+	// Policy selects a healthy target for each attempt (default: FailoverSequential)
+	Policy FailoverPolicy
+
+	// Cooldown is how long an unhealthy target is skipped (default: 30s)
+	Cooldown time.Duration
+
+	// FailureThreshold is the number of consecutive failures that mark a target unhealthy (default: 1)
+	FailureThreshold int
+}
+
+// withDefaults applies default values to the failover configuration.
+func (fc FailoverConfig) withDefaults() FailoverConfig {
+	if fc.Cooldown <= 0 {
+		fc.Cooldown = defaultFailoverCooldown
+	}
+
+	if fc.FailureThreshold <= 0 {
+		fc.FailureThreshold = defaultFailoverFailureThreshold
+	}
+
+	return fc
+}
+
+// TargetState describes the health of a failover target.
+type TargetState struct {
+	// URL is the target base URL
+	URL string
+	// Healthy reports whether the target receives requests
+	Healthy bool
+	// UnhealthyUntil is the end of the cooldown of an unhealthy target
+	UnhealthyUntil time.Time
+	// ConsecutiveFailures is the number of failed attempts since the last success
+	ConsecutiveFailures int
+}
+
+// failoverTarget is a target with its health.
+type failoverTarget struct {
+	raw            string
+	url            *url.URL
+	weight         int
+	currentWeight  int // smooth weighted round-robin state
+	failures       int
+	unhealthyUntil time.Time
+}
+
+// failoverGroup routes attempts to healthy targets and tracks their health.
+type failoverGroup struct {
+	policy    FailoverPolicy
+	cooldown  time.Duration
+	threshold int
+	err       error // invalid target URL, returned for every routed request
+	clock     Clock
+
+	mu      sync.Mutex
+	targets []*failoverTarget
+	next    int // next index for FailoverRoundRobin
+}
+
+// newFailoverGroup creates a failover group for the configured targets that measures
+// cooldowns with clock (nil for real time).
+func newFailoverGroup(config FailoverConfig, clock Clock) *failoverGroup {
+	config = config.withDefaults()
+	g := &failoverGroup{
+		policy:    config.Policy,
+		cooldown:  config.Cooldown,
+		threshold: config.FailureThreshold,
+		clock:     clockOrDefault(clock),
+	}
+	for _, target := range config.Targets {
+		u, err := url.Parse(target.URL)
+		if err == nil && (u.Scheme == "" || u.Host == "") {
+			err = errors.New("missing scheme or host")
+		}
+		if err != nil && g.err == nil {
+			g.err = fmt.Errorf("invalid failover target %q: %w", target.URL, err)
+		}
+		g.targets = append(g.targets, &failoverTarget{
+			raw:    target.URL,
+			url:    u,
+			weight: max(target.Weight, 1),
+		})
+	}
+	return g
+}
+
+// route rewrites the request URL to a healthy target. Requests to other hosts or outside
+// the base path of the primary are not routed and get a nil target. The request must own its URL.
+func (g *failoverGroup) route(req *http.Request) (*failoverTarget, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	primary := g.targets[0].url
+	if req.URL.Host != "" && !strings.EqualFold(req.URL.Host, primary.Host) {
+		return nil, nil
+	}
+
+	// Relative URLs are resolved against the target itself
+	path := req.URL.EscapedPath()
+	if req.URL.Host != "" {
+		prefix := strings.TrimSuffix(primary.EscapedPath(), "/")
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			return nil, nil
+		}
+		path = path[len(prefix):]
+	}
+
+	target := g.pick(g.clock.Now())
+	rawPath := joinURLPath(target.url.EscapedPath(), path)
+	decoded, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *req.URL
+	u.Scheme = target.url.Scheme
+	u.Host = target.url.Host
+	u.Path, u.RawPath = decoded, rawPath
+	req.URL = &u
+	req.Host = ""
+	return target, nil
+}
+
+// joinURLPath joins a base path and a request path with a single slash.
+func joinURLPath(base, path string) string {
+	if path == "" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// pick selects a healthy target according to the policy. When all targets are unhealthy,
+// the one whose cooldown ends first is used.
+func (g *failoverGroup) pick(now time.Time) *failoverTarget {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	healthy := make([]*failoverTarget, 0, len(g.targets))
+	for _, target := range g.targets {
+		if !now.Before(target.unhealthyUntil) {
+			healthy = append(healthy, target)
+		}
+	}
+	if len(healthy) == 0 {
+		soonest := g.targets[0]
+		for _, target := range g.targets[1:] {
+			if target.unhealthyUntil.Before(soonest.unhealthyUntil) {
+				soonest = target
+			}
+		}
+		return soonest
+	}
+
+	switch g.policy {
+	case FailoverRoundRobin:
+		target := healthy[g.next%len(healthy)]
+		g.next++
+		return target
+	case FailoverWeighted:
+		return pickWeighted(healthy)
+	default:
+		return healthy[0]
+	}
+}
+
+// pickWeighted implements smooth weighted round-robin over the targets.
+func pickWeighted(targets []*failoverTarget) *failoverTarget {
+	total := 0
+	var best *failoverTarget
+	for _, target := range targets {
+		target.currentWeight += target.weight
+		total += target.weight
+		if best == nil || target.currentWeight > best.currentWeight {
+			best = target
+		}
+	}
+	best.currentWeight -= total
+	return best
+}
+
+// record updates the target health with the attempt result.
+func (g *failoverGroup) record(target *failoverTarget, resp *http.Response, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !isTargetFailure(resp, err) {
+		target.failures = 0
+		target.unhealthyUntil = time.Time{}
+		return
+	}
+
+	target.failures++
+	if target.failures >= g.threshold {
+		target.unhealthyUntil = g.clock.Now().Add(g.cooldown)
+	}
+}
+
+// isTargetFailure reports whether the attempt result indicates an unhealthy target:
+// any error (including an open circuit breaker), 429 or a 5xx status.
+func isTargetFailure(resp *http.Response, err error) bool {
+	if err != nil || resp == nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// states returns the health of all targets in configuration order.
+func (g *failoverGroup) states() []TargetState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	states := make([]TargetState, 0, len(g.targets))
+	for _, target := range g.targets {
+		state := TargetState{
+			URL:                 target.raw,
+			Healthy:             !now.Before(target.unhealthyUntil),
+			ConsecutiveFailures: target.failures,
+		}
+		if !state.Healthy {
+			state.UnhealthyUntil = target.unhealthyUntil
+		}
+		states = append(states, state)
+	}
+	return states
+}
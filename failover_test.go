@@ -0,0 +1,173 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTargetServer answers with the given status and counts requests; the body is the request path.
+func newTargetServer(t *testing.T, status *int32) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(int(atomic.LoadInt32(status)))
+		_, _ = w.Write([]byte(r.URL.RequestURI()))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestFailover_SequentialWithCooldown(t *testing.T) {
+	t.Parallel()
+	primaryStatus, replicaStatus := int32(http.StatusServiceUnavailable), int32(http.StatusOK)
+	primary, primaryCalls := newTargetServer(t, &primaryStatus)
+	replica, replicaCalls := newTargetServer(t, &replicaStatus)
+
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		Failover: FailoverConfig{
+			Targets:  []FailoverTarget{{URL: primary.URL + "/api"}, {URL: replica.URL + "/v2"}},
+			Cooldown: 200 * time.Millisecond,
+		},
+	}, "test-failover-sequential")
+	defer client.Close()
+
+	// The retry goes to the replica, keeping the path relative to the base URL
+	resp, body := getRedirectBody(t, client, primary.URL+"/api/users?id=1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/v2/users?id=1", body)
+	assert.Equal(t, int32(1), atomic.LoadInt32(primaryCalls))
+
+	states := client.GetTargetState()
+	require.Len(t, states, 2)
+	assert.False(t, states[0].Healthy)
+	assert.Equal(t, 1, states[0].ConsecutiveFailures)
+	assert.True(t, states[1].Healthy)
+
+	// The primary is skipped during the cooldown
+	_, body = getRedirectBody(t, client, "/status")
+	assert.Equal(t, "/v2/status", body)
+	assert.Equal(t, int32(1), atomic.LoadInt32(primaryCalls))
+
+	// and used again once it ends
+	atomic.StoreInt32(&primaryStatus, http.StatusOK)
+	time.Sleep(250 * time.Millisecond)
+	_, body = getRedirectBody(t, client, primary.URL+"/api/users")
+	assert.Equal(t, "/api/users", body)
+	assert.Equal(t, int32(2), atomic.LoadInt32(replicaCalls))
+	assert.True(t, client.GetTargetState()[0].Healthy)
+}
+
+func TestFailover_OtherHostsNotRouted(t *testing.T) {
+	t.Parallel()
+	status := int32(http.StatusOK)
+	primary, primaryCalls := newTargetServer(t, &status)
+	other, otherCalls := newTargetServer(t, &status)
+
+	client := New(Config{
+		Failover: FailoverConfig{Targets: []FailoverTarget{{URL: primary.URL}}},
+	}, "test-failover-other")
+	defer client.Close()
+
+	_, body := getRedirectBody(t, client, other.URL+"/ping")
+	assert.Equal(t, "/ping", body)
+	assert.Equal(t, int32(0), atomic.LoadInt32(primaryCalls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(otherCalls))
+}
+
+func TestFailoverGroup_Policies(t *testing.T) {
+	t.Parallel()
+	targets := []FailoverTarget{
+		{URL: "https://a.example.com", Weight: 3},
+		{URL: "https://b.example.com"},
+		{URL: "https://c.example.com"},
+	}
+	sequence := func(g *failoverGroup, n int) []string {
+		hosts := make([]string, 0, n)
+		for range n {
+			hosts = append(hosts, g.pick(time.Now()).url.Host)
+		}
+		return hosts
+	}
+
+	g := newFailoverGroup(FailoverConfig{Targets: targets, Policy: FailoverRoundRobin}, nil)
+	assert.Equal(t, []string{"a.example.com", "b.example.com", "c.example.com", "a.example.com"}, sequence(g, 4))
+
+	g = newFailoverGroup(FailoverConfig{Targets: targets, Policy: FailoverWeighted}, nil)
+	assert.Equal(t, []string{
+		"a.example.com", "b.example.com", "a.example.com", "c.example.com", "a.example.com",
+	}, sequence(g, 5))
+
+	g = newFailoverGroup(FailoverConfig{Targets: targets, FailureThreshold: 2, Cooldown: time.Minute}, nil)
+	g.record(g.targets[0], nil, context.DeadlineExceeded)
+	assert.Equal(t, "a.example.com", g.pick(time.Now()).url.Host)
+	g.record(g.targets[0], &http.Response{StatusCode: http.StatusBadGateway}, nil)
+	assert.Equal(t, "b.example.com", g.pick(time.Now()).url.Host)
+
+	// With every target unhealthy the one recovering first is used
+	g.record(g.targets[1], nil, context.DeadlineExceeded)
+	g.record(g.targets[1], nil, context.DeadlineExceeded)
+	g.record(g.targets[2], nil, context.DeadlineExceeded)
+	g.record(g.targets[2], nil, context.DeadlineExceeded)
+	assert.Equal(t, "a.example.com", g.pick(time.Now()).url.Host)
+}
+
+func TestFailoverGroup_Route(t *testing.T) {
+	t.Parallel()
+	clock := NewFakeClock(time.Now())
+	g := newFailoverGroup(FailoverConfig{
+		Targets:  []FailoverTarget{{URL: "https://a.example.com/api"}, {URL: "https://b.example.com/v2"}},
+		Cooldown: time.Minute,
+	}, clock)
+	route := func(rawURL string) string {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		target, err := g.route(req)
+		require.NoError(t, err)
+		if target == nil {
+			return ""
+		}
+		return req.URL.String()
+	}
+
+	assert.Equal(t, "https://a.example.com/api/files/a%2Fb", route("https://a.example.com/api/files/a%2Fb"))
+	assert.Equal(t, "https://a.example.com/api", route("https://a.example.com/api"))
+	// Paths sharing only a prefix with the base path are not routed
+	assert.Empty(t, route("https://a.example.com/apix/y"))
+
+	// The cooldown is measured with the clock
+	g.record(g.targets[0], nil, context.DeadlineExceeded)
+	assert.Equal(t, "https://b.example.com/v2/files/a%2Fb?q=1", route("https://a.example.com/api/files/a%2Fb?q=1"))
+	clock.Advance(time.Minute)
+	assert.Equal(t, "https://a.example.com/api/users", route("/users"))
+	assert.True(t, g.states()[0].Healthy)
+}
+
+func TestFailover_InvalidTarget(t *testing.T) {
+	t.Parallel()
+	client := New(Config{
+		Failover: FailoverConfig{Targets: []FailoverTarget{{URL: "replica:8080"}}},
+	}, "test-failover-invalid")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), "http://replica:8080/")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid failover target")
+}
+
+func TestFailoverPolicyString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "sequential", FailoverSequential.String())
+	assert.Equal(t, "round-robin", FailoverRoundRobin.String())
+	assert.Equal(t, "weighted", FailoverWeighted.String())
+	assert.Equal(t, "unknown", FailoverPolicy(9).String())
+}
@@ -0,0 +1,45 @@
+package httpclient
+
+import (
+	"net/http"
+)
+
+// FallbackFunc produces the result of a request that failed: a transport error after
+// all retries (including ErrCircuitBreakerOpen), or a retryable status once retries are
+// exhausted, in which case err is an *HTTPError. It may return a default response,
+// the response of a secondary provider, or an error.
+type FallbackFunc func(req *http.Request, err error) (*http.Response, error)
+
+// WithFallback sets the fallback of this request, replacing Config.Fallback.
+func WithFallback(fallback FallbackFunc) RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.fallback = fallback
+		})
+	}
+}
+
+// applyFallback replaces a failed result with the result of the configured fallback.
+func applyFallback(req *http.Request, resp *http.Response, err error, config Config) (*http.Response, error) {
+	if config.Fallback == nil {
+		return resp, err
+	}
+
+	if err == nil {
+		if resp == nil || !config.RetryEnabled || !config.RetryConfig.isStatusRetryable(resp.StatusCode) {
+			return resp, nil
+		}
+		err = NewHTTPError(resp, req)
+	}
+
+	closeResponseBody(resp)
+	fallbackResp, fallbackErr := config.Fallback(req, err)
+	if fallbackResp == nil && fallbackErr == nil {
+		// Nothing to substitute, keep the original error
+		return nil, err
+	}
+	if fallbackResp != nil && fallbackResp.Request == nil {
+		fallbackResp.Request = req
+	}
+	return fallbackResp, fallbackErr
+}
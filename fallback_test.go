@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticFallback returns a fallback answering "cached" and storing the error it was called with.
+func staticFallback(got *error) FallbackFunc {
+	return func(req *http.Request, err error) (*http.Response, error) {
+		*got = err
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("cached")),
+		}, nil
+	}
+}
+
+// readBody reads and closes the response body.
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestFallback_TransportError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	var got error
+	client := New(Config{Fallback: staticFallback(&got)}, "test-fallback-error")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), url)
+	require.NoError(t, err)
+	assert.Equal(t, "cached", readBody(t, resp))
+	assert.Error(t, got)
+}
+
+func TestFallback_RetriesExhausted(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var got error
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		Fallback:     staticFallback(&got),
+	}, "test-fallback-retries")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "cached", readBody(t, resp))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(got, &httpErr))
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+}
+
+func TestFallback_CircuitOpen(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var got error
+	client := New(Config{
+		CircuitBreakerEnable: true,
+		CircuitBreaker: NewCircuitBreakerWithConfig(CircuitBreakerConfig{
+			FailureThreshold: 1,
+			SuccessThreshold: 1,
+			Timeout:          time.Minute,
+		}),
+		Fallback: staticFallback(&got),
+	}, "test-fallback-circuit")
+	defer client.Close()
+
+	// Without retries a 500 is returned as is and opens the breaker
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.NoError(t, got)
+
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "cached", readBody(t, resp))
+	assert.ErrorIs(t, got, ErrCircuitBreakerOpen)
+}
+
+func TestFallback_NotCalledOnSuccess(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	called := false
+	client := New(Config{
+		RetryEnabled: true,
+		Fallback: func(req *http.Request, err error) (*http.Response, error) {
+			called = true
+			return nil, err
+		},
+	}, "test-fallback-success")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.False(t, called)
+}
+
+func TestWithFallback(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	var configFallback, requestFallback error
+	client := New(Config{Fallback: staticFallback(&configFallback)}, "test-fallback-option")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), url, WithFallback(staticFallback(&requestFallback)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Error(t, requestFallback)
+	assert.NoError(t, configFallback)
+
+	// A fallback returning nothing keeps the original error
+	_, err = client.Get(context.Background(), url, WithFallback(func(*http.Request, error) (*http.Response, error) {
+		return nil, nil
+	}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FaultInjectedHeader marks responses produced or modified by FaultInjectionMiddleware.
+const FaultInjectedHeader = "X-Fault-Injected"
+
+// Fault types reported in FaultInjectedHeader.
+const (
+	FaultServerError   = "server-error"
+	FaultTruncatedBody = "truncated-body"
+)
+
+// ErrFaultInjected is wrapped by all errors injected by FaultInjectionMiddleware.
+var ErrFaultInjected = errors.New("injected fault")
+
+// errInjectedReset looks like a connection reset to the retry logic.
+var errInjectedReset = fmt.Errorf("connection reset by peer: %w", ErrFaultInjected)
+
+// FaultInjectionConfig configures FaultInjectionMiddleware.
+// Percentages are in the range 0-100 and apply to every attempt matching Filter.
+// Connection resets, server errors and truncated bodies exclude each other;
+// latency is rolled separately and delays the other faults.
+type FaultInjectionConfig struct {
+	// Enabled must be set to inject faults, a disabled middleware passes requests through
+	Enabled bool
+
+	// Filter selects the requests faults are injected into (default: all requests)
+	Filter func(req *http.Request) bool
+
+	// LatencyPercent is the share of attempts delayed by Latency
+	LatencyPercent float64
+	// Latency is the injected delay
+	Latency time.Duration
+
+	// ResetPercent is the share of attempts failing with a connection reset
+	ResetPercent float64
+
+	// ServerErrorPercent is the share of attempts answered with ServerErrorStatus
+	ServerErrorPercent float64
+	// ServerErrorStatus is the injected status code (default: 503)
+	ServerErrorStatus int
+
+	// TruncatePercent is the share of responses whose body ends with io.ErrUnexpectedEOF
+	// after half of its bytes
+	TruncatePercent float64
+}
+
+// withDefaults returns a copy of the configuration with default values.
+func (c FaultInjectionConfig) withDefaults() FaultInjectionConfig {
+	if c.ServerErrorStatus == 0 {
+		c.ServerErrorStatus = http.StatusServiceUnavailable
+	}
+	return c
+}
+
+// FaultInjectionMiddleware injects latency, connection resets, 5xx responses and
+// truncated bodies for chaos testing of retry and circuit breaker settings.
+// Faults are injected into every physical attempt, so the retry loop, the circuit
+// breaker and the metrics see them like real failures. Never enable it in production.
+type FaultInjectionMiddleware struct {
+	config  FaultInjectionConfig
+	enabled atomic.Bool
+	random  func() float64 // returns values in [0, 1)
+}
+
+// NewFaultInjectionMiddleware creates a fault injection middleware. Add it to Config.Middlewares.
+func NewFaultInjectionMiddleware(config FaultInjectionConfig) *FaultInjectionMiddleware {
+	m := &FaultInjectionMiddleware{
+		config: config.withDefaults(),
+		random: rand.Float64,
+	}
+	m.enabled.Store(config.Enabled)
+	return m
+}
+
+// SetEnabled turns fault injection on or off at runtime.
+func (m *FaultInjectionMiddleware) SetEnabled(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// Enabled reports whether faults are injected.
+func (m *FaultInjectionMiddleware) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Process implements the Middleware interface. Faults are injected per attempt in interceptAttempt.
+func (m *FaultInjectionMiddleware) Process(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	return next(req)
+}
+
+// interceptAttempt injects faults into a single attempt.
+func (m *FaultInjectionMiddleware) interceptAttempt(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	if !m.Enabled() || (m.config.Filter != nil && !m.config.Filter(req)) {
+		return next(req)
+	}
+
+	if m.config.Latency > 0 && m.roll(m.config.LatencyPercent) {
+		timer := time.NewTimer(m.config.Latency)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	// A single roll picks at most one of the exclusive faults
+	roll := m.random() * 100
+	switch {
+	case roll < m.config.ResetPercent:
+		return nil, errInjectedReset
+	case roll < m.config.ResetPercent+m.config.ServerErrorPercent:
+		return m.serverError(req), nil
+	case roll < m.config.ResetPercent+m.config.ServerErrorPercent+m.config.TruncatePercent:
+		resp, err := next(req)
+		if err != nil {
+			return resp, err
+		}
+		return truncateResponse(resp)
+	default:
+		return next(req)
+	}
+}
+
+// roll reports whether a fault with the percentage is injected.
+func (m *FaultInjectionMiddleware) roll(percent float64) bool {
+	return m.random()*100 < percent
+}
+
+// serverError builds the injected server error response.
+func (m *FaultInjectionMiddleware) serverError(req *http.Request) *http.Response {
+	status := m.config.ServerErrorStatus
+	body := ErrFaultInjected.Error()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{FaultInjectedHeader: {FaultServerError}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// truncateResponse replaces the body with its first half followed by io.ErrUnexpectedEOF.
+func truncateResponse(resp *http.Response) (*http.Response, error) {
+	if resp.Body == nil {
+		return resp, nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(io.MultiReader(
+		bytes.NewReader(data[:len(data)/2]),
+		errReader{err: fmt.Errorf("%w: %w", io.ErrUnexpectedEOF, ErrFaultInjected)},
+	))
+	resp.Header = resp.Header.Clone()
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	resp.Header.Set(FaultInjectedHeader, FaultTruncatedBody)
+	return resp, nil
+}
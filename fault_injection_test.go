@@ -0,0 +1,135 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFaultInjectionServer(t *testing.T, calls *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFaultInjection_Disabled(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := newFaultInjectionServer(t, &calls)
+	fault := NewFaultInjectionMiddleware(FaultInjectionConfig{ResetPercent: 100})
+	client := New(Config{Middlewares: []Middleware{fault}}, "test-fault-disabled")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	fault.SetEnabled(true)
+	assert.True(t, fault.Enabled())
+	_, err = client.Get(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrFaultInjected)
+}
+
+func TestFaultInjection_ResetIsRetried(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := newFaultInjectionServer(t, &calls)
+	fault := NewFaultInjectionMiddleware(FaultInjectionConfig{Enabled: true, ResetPercent: 50})
+	var rolls int32
+	// The first attempt is reset, the retry goes through
+	fault.random = func() float64 {
+		if atomic.AddInt32(&rolls, 1) == 1 {
+			return 0.1
+		}
+		return 0.9
+	}
+	client := New(Config{
+		Middlewares:  []Middleware{fault},
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}, "test-fault-reset")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", readBody(t, resp))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestFaultInjection_ServerErrorOpensCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := newFaultInjectionServer(t, &calls)
+	fault := NewFaultInjectionMiddleware(FaultInjectionConfig{
+		Enabled:            true,
+		ServerErrorPercent: 100,
+		ServerErrorStatus:  http.StatusBadGateway,
+		Filter:             func(req *http.Request) bool { return strings.HasPrefix(req.URL.Path, "/chaos") },
+	})
+	client := New(Config{
+		Middlewares:          []Middleware{fault},
+		CircuitBreakerEnable: true,
+		CircuitBreaker: NewCircuitBreakerWithConfig(CircuitBreakerConfig{
+			FailureThreshold: 1,
+			SuccessThreshold: 1,
+			Timeout:          time.Minute,
+		}),
+	}, "test-fault-server-error")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL+"/chaos")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, FaultServerError, resp.Header.Get(FaultInjectedHeader))
+	_ = resp.Body.Close()
+
+	_, err = client.Get(context.Background(), server.URL+"/other")
+	assert.ErrorIs(t, err, ErrCircuitBreakerOpen)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
+
+func TestFaultInjection_TruncatedBody(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := newFaultInjectionServer(t, &calls)
+	fault := NewFaultInjectionMiddleware(FaultInjectionConfig{Enabled: true, TruncatePercent: 100})
+	client := New(Config{Middlewares: []Middleware{fault}}, "test-fault-truncate")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, FaultTruncatedBody, resp.Header.Get(FaultInjectedHeader))
+	data, err := io.ReadAll(resp.Body)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.ErrorIs(t, err, ErrFaultInjected)
+	assert.Equal(t, "01234", string(data))
+}
+
+func TestFaultInjection_Latency(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := newFaultInjectionServer(t, &calls)
+	fault := NewFaultInjectionMiddleware(FaultInjectionConfig{Enabled: true, LatencyPercent: 100, Latency: time.Minute})
+	client := New(Config{Middlewares: []Middleware{fault}}, "test-fault-latency")
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.Get(ctx, server.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,296 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultFileHeaderCheckInterval is how often FileHeaderProvider checks the file for changes.
+const defaultFileHeaderCheckInterval = 10 * time.Second
+
+// HeaderProvider resolves headers at request time, so credentials such as API keys
+// are not embedded in the client configuration and rotate without restarting it.
+// Implementations must be safe for concurrent use.
+type HeaderProvider interface {
+	Headers(ctx context.Context) (http.Header, error)
+}
+
+// HeaderProviderFunc adapts a function to the HeaderProvider interface.
+type HeaderProviderFunc func(ctx context.Context) (http.Header, error)
+
+// Headers implements the HeaderProvider interface.
+func (f HeaderProviderFunc) Headers(ctx context.Context) (http.Header, error) {
+	return f(ctx)
+}
+
+// headerInvalidator is implemented by providers that cache headers and support forced refresh.
+type headerInvalidator interface {
+	Invalidate()
+}
+
+// StaticHeaders returns a provider of fixed headers.
+func StaticHeaders(headers map[string]string) HeaderProvider {
+	static := make(http.Header, len(headers))
+	for name, value := range headers {
+		static.Set(name, value)
+	}
+	return HeaderProviderFunc(func(context.Context) (http.Header, error) {
+		return static.Clone(), nil
+	})
+}
+
+// EnvHeaders returns a provider reading headers from environment variables on every
+// request. Values are expanded with os.Expand, e.g. {"Authorization": "Bearer ${API_TOKEN}"};
+// an unset variable fails the request.
+func EnvHeaders(headers map[string]string) HeaderProvider {
+	return HeaderProviderFunc(func(context.Context) (http.Header, error) {
+		resolved := make(http.Header, len(headers))
+		for name, value := range headers {
+			var missing string
+			expanded := os.Expand(value, func(key string) string {
+				env, ok := os.LookupEnv(key)
+				if !ok && missing == "" {
+					missing = key
+				}
+				return env
+			})
+			if missing != "" {
+				return nil, fmt.Errorf("header %s: environment variable %s is not set", name, missing)
+			}
+			resolved.Set(name, expanded)
+		}
+		return resolved, nil
+	})
+}
+
+// FileHeaderConfig contains settings of FileHeaderProvider.
+type FileHeaderConfig struct {
+	// Header is the name of the header, e.g. "Authorization" or "X-API-Key"
+	Header string
+
+	// Path is the file holding the secret, e.g. a mounted Kubernetes secret or a file
+	// rendered by Vault Agent. Surrounding whitespace is trimmed
+	Path string
+
+	// Prefix is prepended to the secret, e.g. "Bearer "
+	Prefix string
+
+	// CheckInterval is how often the file is checked for changes (default: 10s)
+	CheckInterval time.Duration
+
+	// Clock is the source of time for CheckInterval (default: real time)
+	Clock Clock
+}
+
+// withDefaults applies default values to the file header configuration.
+func (fc FileHeaderConfig) withDefaults() FileHeaderConfig {
+	if fc.CheckInterval <= 0 {
+		fc.CheckInterval = defaultFileHeaderCheckInterval
+	}
+	return fc
+}
+
+// FileHeaderProvider reads a header value from a file and reloads it when the file
+// changes, so secrets rotated on disk are picked up without restarting the client.
+// It is safe for concurrent use.
+type FileHeaderProvider struct {
+	config FileHeaderConfig
+	clock  Clock
+
+	mu        sync.Mutex
+	value     string
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+// NewFileHeaderProvider creates a provider of the header stored in config.Path.
+func NewFileHeaderProvider(config FileHeaderConfig) *FileHeaderProvider {
+	return &FileHeaderProvider{
+		config: config.withDefaults(),
+		clock:  clockOrDefault(config.Clock),
+	}
+}
+
+// Headers implements the HeaderProvider interface. The file is checked at most once per
+// CheckInterval and read again only when its modification time or size changed.
+func (p *FileHeaderProvider) Headers(context.Context) (http.Header, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	if p.checkedAt.IsZero() || now.Sub(p.checkedAt) >= p.config.CheckInterval {
+		if err := p.reload(); err != nil {
+			return nil, err
+		}
+		p.checkedAt = now
+	}
+
+	headers := make(http.Header, 1)
+	headers.Set(p.config.Header, p.config.Prefix+p.value)
+	return headers, nil
+}
+
+// Invalidate makes the next call check the file, e.g. after the server rejected the secret.
+func (p *FileHeaderProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.checkedAt = time.Time{}
+}
+
+// reload reads the file if it changed since the last read.
+func (p *FileHeaderProvider) reload() error {
+	info, err := os.Stat(p.config.Path)
+	if err != nil {
+		return fmt.Errorf("header %s: %w", p.config.Header, err)
+	}
+	if p.value != "" && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.config.Path)
+	if err != nil {
+		return fmt.Errorf("header %s: %w", p.config.Header, err)
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" {
+		return fmt.Errorf("header %s: file %s is empty", p.config.Header, p.config.Path)
+	}
+	p.value, p.modTime, p.size = value, info.ModTime(), info.Size()
+	return nil
+}
+
+// CachedHeaderProvider caches the headers of a slow source, such as a Vault lookup,
+// for a fixed time. Concurrent callers wait for a single lookup. It is safe for concurrent use.
+type CachedHeaderProvider struct {
+	source HeaderProvider
+	ttl    time.Duration
+	clock  Clock
+
+	mu        sync.Mutex
+	headers   http.Header
+	fetchedAt time.Time
+}
+
+// NewCachedHeaderProvider creates a provider caching the headers of source for ttl.
+func NewCachedHeaderProvider(source HeaderProvider, ttl time.Duration) *CachedHeaderProvider {
+	return NewCachedHeaderProviderWithClock(source, ttl, nil)
+}
+
+// NewCachedHeaderProviderWithClock creates a provider caching the headers of source for ttl
+// measured by clock (nil for real time).
+func NewCachedHeaderProviderWithClock(source HeaderProvider, ttl time.Duration, clock Clock) *CachedHeaderProvider {
+	return &CachedHeaderProvider{
+		source: source,
+		ttl:    ttl,
+		clock:  clockOrDefault(clock),
+	}
+}
+
+// Headers implements the HeaderProvider interface.
+func (p *CachedHeaderProvider) Headers(ctx context.Context) (http.Header, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.headers != nil && p.clock.Now().Sub(p.fetchedAt) < p.ttl {
+		return p.headers.Clone(), nil
+	}
+
+	headers, err := p.source.Headers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.headers, p.fetchedAt = headers, p.clock.Now()
+	return headers.Clone(), nil
+}
+
+// Invalidate drops the cached headers, so the next call asks the source again.
+func (p *CachedHeaderProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.headers = nil
+	if invalidator, ok := p.source.(headerInvalidator); ok {
+		invalidator.Invalidate()
+	}
+}
+
+// HeaderMiddleware sets the headers of a HeaderProvider on every request.
+// When the server answers 401 and the provider supports Invalidate, the headers are
+// resolved again and the request is retried once, provided its body can be replayed.
+type HeaderMiddleware struct {
+	provider HeaderProvider
+}
+
+// NewHeaderMiddleware creates a middleware that sets the headers resolved by provider.
+func NewHeaderMiddleware(provider HeaderProvider) *HeaderMiddleware {
+	return &HeaderMiddleware{provider: provider}
+}
+
+// Process implements the Middleware interface.
+func (m *HeaderMiddleware) Process(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	headers, err := m.provider.Headers(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve request headers: %w", err)
+	}
+
+	resp, err := next(withProvidedHeaders(req, headers))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	invalidator, ok := m.provider.(headerInvalidator)
+	if !ok {
+		return resp, nil
+	}
+	retryReq, ok := replayableRequest(req)
+	if !ok {
+		return resp, nil
+	}
+
+	invalidator.Invalidate()
+	headers, err = m.provider.Headers(req.Context())
+	if err != nil {
+		drainAndClose(retryReq.Body)
+		return resp, nil
+	}
+	drainAndClose(resp.Body)
+
+	return next(withProvidedHeaders(retryReq, headers))
+}
+
+// withProvidedHeaders returns a copy of the request with the headers set.
+func withProvidedHeaders(req *http.Request, headers http.Header) *http.Request {
+	provided := req.Clone(req.Context())
+	for name, values := range headers {
+		provided.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	return provided
+}
+
+// replayableRequest returns a copy of the request with a fresh body, or false when the
+// body can't be replayed.
+func replayableRequest(req *http.Request) (*http.Request, bool) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil, false
+	}
+
+	replay := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		replay.Body = body
+	}
+	return replay, true
+}
@@ -0,0 +1,166 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticHeaders(t *testing.T) {
+	t.Parallel()
+	provider := StaticHeaders(map[string]string{"x-api-key": "k1"})
+
+	headers, err := provider.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, http.Header{"X-Api-Key": {"k1"}}, headers)
+
+	// Callers can't modify the static headers
+	headers.Set("X-Api-Key", "changed")
+	headers, err = provider.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "k1", headers.Get("X-Api-Key"))
+}
+
+func TestEnvHeaders(t *testing.T) {
+	t.Setenv("HTTPCLIENT_TEST_TOKEN", "t1")
+	provider := EnvHeaders(map[string]string{"Authorization": "Bearer ${HTTPCLIENT_TEST_TOKEN}"})
+
+	headers, err := provider.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer t1", headers.Get("Authorization"))
+
+	// Rotated values are picked up by the next request
+	t.Setenv("HTTPCLIENT_TEST_TOKEN", "t2")
+	headers, err = provider.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer t2", headers.Get("Authorization"))
+
+	_, err = EnvHeaders(map[string]string{"X-Api-Key": "$HTTPCLIENT_TEST_MISSING"}).Headers(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "environment variable HTTPCLIENT_TEST_MISSING is not set")
+}
+
+func TestFileHeaderProvider_ReloadsChangedFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("secret-1\n"), 0o600))
+
+	clock := NewFakeClock(time.Unix(1000, 0))
+	provider := NewFileHeaderProvider(FileHeaderConfig{Header: "Authorization", Path: path, Prefix: "Bearer ", Clock: clock})
+
+	headers, err := provider.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret-1", headers.Get("Authorization"))
+
+	// The file is checked once per CheckInterval
+	require.NoError(t, os.WriteFile(path, []byte("secret-22"), 0o600))
+	headers, err = provider.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret-1", headers.Get("Authorization"))
+
+	clock.Advance(defaultFileHeaderCheckInterval)
+	headers, err = provider.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret-22", headers.Get("Authorization"))
+
+	require.NoError(t, os.WriteFile(path, []byte("secret-333"), 0o600))
+	provider.Invalidate()
+	headers, err = provider.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer secret-333", headers.Get("Authorization"))
+
+	_, err = NewFileHeaderProvider(FileHeaderConfig{Header: "X-Api-Key", Path: path + ".missing"}).Headers(context.Background())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCachedHeaderProvider(t *testing.T) {
+	t.Parallel()
+	var lookups int32
+	source := HeaderProviderFunc(func(context.Context) (http.Header, error) {
+		n := atomic.AddInt32(&lookups, 1)
+		if n == 3 {
+			return nil, errors.New("vault is unavailable")
+		}
+		return http.Header{"X-Api-Key": {strings.Repeat("k", int(n))}}, nil
+	})
+
+	clock := NewFakeClock(time.Unix(1000, 0))
+	provider := NewCachedHeaderProviderWithClock(source, time.Minute, clock)
+
+	for i := 0; i < 3; i++ {
+		headers, err := provider.Headers(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "k", headers.Get("X-Api-Key"))
+	}
+
+	clock.Advance(time.Minute)
+	headers, err := provider.Headers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "kk", headers.Get("X-Api-Key"))
+
+	provider.Invalidate()
+	_, err = provider.Headers(context.Background())
+	assert.EqualError(t, err, "vault is unavailable")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&lookups))
+}
+
+func TestHeaderMiddleware_RotatesOn401(t *testing.T) {
+	t.Parallel()
+	var bodies []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("X-Api-Key") != "kk" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	var lookups int32
+	provider := NewCachedHeaderProvider(HeaderProviderFunc(func(context.Context) (http.Header, error) {
+		n := atomic.AddInt32(&lookups, 1)
+		return http.Header{"X-Api-Key": {strings.Repeat("k", int(n))}}, nil
+	}), time.Hour)
+
+	client := New(Config{Middlewares: []Middleware{NewHeaderMiddleware(provider)}}, "test-header-provider")
+	defer client.Close()
+
+	resp, err := client.Post(context.Background(), api.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"payload", "payload"}, bodies)
+
+	// The rotated key stays cached
+	resp, err = client.Get(context.Background(), api.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&lookups))
+}
+
+func TestHeaderMiddleware_ProviderErrorFailsRequest(t *testing.T) {
+	t.Parallel()
+	provider := HeaderProviderFunc(func(context.Context) (http.Header, error) {
+		return nil, errors.New("vault is unavailable")
+	})
+	client := New(Config{Middlewares: []Middleware{NewHeaderMiddleware(provider)}}, "test-header-provider-error")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), "http://api.invalid")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to resolve request headers: vault is unavailable")
+}
@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"net/http"
+	"runtime/debug"
+)
+
+// modulePath is the import path of the package, used in the automatic User-Agent.
+const modulePath = "github.com/rurick/http-client"
+
+// HeaderPolicy contains headers the client enforces on every request, after request
+// options and before middlewares, so signing middlewares see the final headers.
+type HeaderPolicy struct {
+	// Set contains headers replacing the values set by the caller
+	Set map[string]string
+
+	// Forbidden lists headers removed from every request, e.g. internal debug headers
+	Forbidden []string
+}
+
+// defaultUserAgent returns the automatic User-Agent "<clientName>/<version> (<module>)".
+func defaultUserAgent(clientName string) string {
+	return clientName + "/" + moduleVersion() + " (" + modulePath + ")"
+}
+
+// moduleVersion returns the version of the module in the running binary, or "devel".
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	module := &info.Main
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			module = dep
+			break
+		}
+	}
+	if module.Path != modulePath || module.Version == "" || module.Version == "(devel)" {
+		return "devel"
+	}
+	return module.Version
+}
+
+// applyHeaders adds Config.DefaultHeaders, the User-Agent and the Priority header when absent
+// and enforces Config.HeaderPolicy.
+func (rt *RoundTripper) applyHeaders(req *http.Request) *http.Request {
+	policy := rt.config.HeaderPolicy
+	var priority string
+	if rt.config.PriorityHeaders {
+		priority = requestPriority(req.Context()).priorityParams().String()
+	}
+	if len(rt.config.DefaultHeaders) == 0 && rt.config.UserAgent == "" && priority == "" &&
+		len(policy.Set) == 0 && len(policy.Forbidden) == 0 {
+		return req
+	}
+
+	// A shallow copy with its own headers: unlike Clone it shares the URL, which the
+	// client never modifies in place
+	clone := *req
+	clone.Header = req.Header.Clone()
+	if clone.Header == nil {
+		clone.Header = make(http.Header)
+	}
+	req = &clone
+	for name, value := range rt.config.DefaultHeaders {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	if rt.config.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", rt.config.UserAgent)
+	}
+	if priority != "" && req.Header.Get(PriorityHeaderName) == "" {
+		req.Header.Set(PriorityHeaderName, priority)
+	}
+	for name, value := range policy.Set {
+		req.Header.Set(name, value)
+	}
+	for _, name := range policy.Forbidden {
+		req.Header.Del(name)
+	}
+	return req
+}
@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaders_DefaultsAndPolicy(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	var signed http.Header
+	client := New(Config{
+		DefaultHeaders: map[string]string{"Accept": "application/json", "X-Team": "payments"},
+		HeaderPolicy: HeaderPolicy{
+			Set:       map[string]string{"X-Env": "prod"},
+			Forbidden: []string{"X-Debug"},
+		},
+		Middlewares: []Middleware{MiddlewareFunc(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			signed = req.Header.Clone()
+			return next(req)
+		})},
+	}, "test-headers-policy")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL,
+		WithHeader("X-Team", "billing"),
+		WithHeader("X-Env", "dev"),
+		WithHeader("X-Debug", "1"),
+	)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	received := server.GetLastRequest().Headers
+	assert.Equal(t, "application/json", received["Accept"])
+	assert.Equal(t, "billing", received["X-Team"], "default headers don't replace request values")
+	assert.Equal(t, "prod", received["X-Env"], "policy headers replace request values")
+	assert.NotContains(t, received, "X-Debug")
+	assert.Equal(t, "prod", signed.Get("X-Env"), "middlewares see the final headers")
+}
+
+func TestHeaders_UserAgent(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	client := New(Config{}, "orders")
+	defer client.Close()
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "orders/devel (github.com/rurick/http-client)", server.GetLastRequest().Headers["User-Agent"])
+
+	resp, err = client.Get(context.Background(), server.URL, WithUserAgent("cli/2.0"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "cli/2.0", server.GetLastRequest().Headers["User-Agent"])
+
+	custom := New(Config{UserAgent: "orders-service/1.4.2"}, "orders")
+	defer custom.Close()
+	resp, err = custom.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "orders-service/1.4.2", server.GetLastRequest().Headers["User-Agent"])
+}
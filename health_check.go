@@ -0,0 +1,227 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrUnhealthyResponse is returned by LastError when a probe response is not healthy.
+var ErrUnhealthyResponse = errors.New("unhealthy health check response")
+
+// HealthCheckConfig contains settings of a HealthChecker.
+type HealthCheckConfig struct {
+	// Method is the HTTP method of probes (default: GET)
+	Method string
+
+	// Timeout limits a single probe (default: the probe interval)
+	Timeout time.Duration
+
+	// IsHealthy reports whether a probe response is healthy (default: 2xx status)
+	IsHealthy func(resp *http.Response) bool
+
+	// FailureThreshold is the number of consecutive failed probes marking a healthy endpoint unhealthy (default: 1)
+	FailureThreshold int
+
+	// SuccessThreshold is the number of consecutive successful probes marking an unhealthy endpoint healthy (default: 1)
+	SuccessThreshold int
+}
+
+// withDefaults returns a copy of the configuration with default values.
+func (c HealthCheckConfig) withDefaults(interval time.Duration) HealthCheckConfig {
+	if c.Method == "" {
+		c.Method = http.MethodGet
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = interval
+	}
+	if c.IsHealthy == nil {
+		c.IsHealthy = func(resp *http.Response) bool {
+			return resp.StatusCode >= 200 && resp.StatusCode < 300
+		}
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 1
+	}
+	if c.SuccessThreshold <= 0 {
+		c.SuccessThreshold = 1
+	}
+	return c
+}
+
+// HealthChecker probes an endpoint in the background through the client, so probes share its
+// transport, middlewares, metrics and circuit breaker: failed probes open the breaker and a
+// successful probe closes it after the breaker timeout. The endpoint is unhealthy until the
+// first successful probe. HealthChecker is an http.Handler for readiness probes.
+type HealthChecker struct {
+	client   *Client
+	url      string
+	interval time.Duration
+	config   HealthCheckConfig
+
+	mu        sync.RWMutex
+	healthy   bool
+	lastErr   error
+	latency   time.Duration
+	lastCheck time.Time
+	failures  int
+	successes int
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// HealthChecker starts probing the URL every interval until Stop is called.
+func (c *Client) HealthChecker(url string, interval time.Duration, config HealthCheckConfig) *HealthChecker {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	h := &HealthChecker{
+		client:   c,
+		url:      url,
+		interval: interval,
+		config:   config.withDefaults(interval),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// run probes the endpoint until the checker is stopped.
+func (h *HealthChecker) run() {
+	defer close(h.done)
+	clock := clockOrDefault(h.client.config.Clock)
+	for {
+		h.Check(context.Background())
+		timer := clock.NewTimer(h.interval)
+		select {
+		case <-h.stop:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+// Check probes the endpoint immediately and returns the probe error.
+func (h *HealthChecker) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := h.probe(ctx)
+	h.record(err, time.Since(start))
+	return err
+}
+
+// probe sends a single probe request without retries.
+func (h *HealthChecker) probe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, h.config.Method, h.url, nil)
+	if err != nil {
+		return err
+	}
+	WithNoRetry()(req)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if !h.config.IsHealthy(resp) {
+		return fmt.Errorf("%w: status %d", ErrUnhealthyResponse, resp.StatusCode)
+	}
+	return nil
+}
+
+// record updates the state with a probe result.
+func (h *HealthChecker) record(err error, latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastErr = err
+	h.latency = latency
+	h.lastCheck = time.Now()
+	if err != nil {
+		h.successes = 0
+		h.failures++
+		if h.failures >= h.config.FailureThreshold {
+			h.healthy = false
+		}
+		return
+	}
+	h.failures = 0
+	h.successes++
+	if h.successes >= h.config.SuccessThreshold {
+		h.healthy = true
+	}
+}
+
+// Healthy reports whether the endpoint is healthy.
+func (h *HealthChecker) Healthy() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.healthy
+}
+
+// LastError returns the error of the last probe, nil if it succeeded.
+func (h *HealthChecker) LastError() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastErr
+}
+
+// Latency returns the duration of the last probe.
+func (h *HealthChecker) Latency() time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.latency
+}
+
+// LastCheck returns the time of the last probe, zero before the first probe finishes.
+func (h *HealthChecker) LastCheck() time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastCheck
+}
+
+// Stop stops probing and waits for a running probe to finish.
+func (h *HealthChecker) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+	})
+	<-h.done
+}
+
+// healthCheckStatus is the JSON body served by HealthChecker.
+type healthCheckStatus struct {
+	Healthy   bool   `json:"healthy"`
+	URL       string `json:"url"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ServeHTTP responds with 200 OK when the endpoint is healthy and 503 Service Unavailable
+// otherwise, with the state as JSON.
+func (h *HealthChecker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.mu.RLock()
+	status := healthCheckStatus{Healthy: h.healthy, URL: h.url, LatencyMs: h.latency.Milliseconds()}
+	if h.lastErr != nil {
+		status.Error = h.lastErr.Error()
+	}
+	h.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if status.Healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitFirstProbe waits until the background probe of the checker has finished.
+func waitFirstProbe(t *testing.T, h *HealthChecker) {
+	t.Helper()
+	require.Eventually(t, func() bool { return !h.LastCheck().IsZero() }, time.Second, time.Millisecond)
+}
+
+func TestHealthChecker_Thresholds(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusOK},
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	client := New(Config{RetryEnabled: true}, "test-health-thresholds")
+	defer client.Close()
+
+	checker := client.HealthChecker(server.URL+"/health", time.Hour, HealthCheckConfig{FailureThreshold: 2})
+	defer checker.Stop()
+	waitFirstProbe(t, checker)
+	assert.True(t, checker.Healthy())
+	assert.NoError(t, checker.LastError())
+	assert.Positive(t, checker.Latency())
+
+	err := checker.Check(context.Background())
+	assert.ErrorIs(t, err, ErrUnhealthyResponse)
+	assert.True(t, checker.Healthy(), "one failure is below the threshold")
+
+	require.Error(t, checker.Check(context.Background()))
+	assert.False(t, checker.Healthy())
+	assert.ErrorIs(t, checker.LastError(), ErrUnhealthyResponse)
+
+	require.NoError(t, checker.Check(context.Background()))
+	assert.True(t, checker.Healthy())
+	assert.Equal(t, 4, server.GetRequestCount(), "probes are not retried")
+}
+
+func TestHealthChecker_Handler(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusInternalServerError})
+	defer server.Close()
+
+	client := New(Config{}, "test-health-handler")
+	defer client.Close()
+
+	checker := client.HealthChecker(server.URL, time.Hour, HealthCheckConfig{})
+	defer checker.Stop()
+	waitFirstProbe(t, checker)
+
+	recorder := httptest.NewRecorder()
+	checker.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), `"healthy":false`)
+	assert.Contains(t, recorder.Body.String(), "status 500")
+}
+
+func TestHealthChecker_FeedsCircuitBreaker(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusServiceUnavailable})
+	defer server.Close()
+
+	breaker := NewCircuitBreakerWithConfig(CircuitBreakerConfig{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Hour})
+	client := New(Config{CircuitBreakerEnable: true, CircuitBreaker: breaker}, "test-health-breaker")
+	defer client.Close()
+
+	checker := client.HealthChecker(server.URL, time.Hour, HealthCheckConfig{})
+	defer checker.Stop()
+	waitFirstProbe(t, checker)
+
+	assert.Equal(t, CircuitBreakerOpen, breaker.State())
+	assert.False(t, checker.Healthy())
+}
+
+func TestHealthChecker_Stop(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-health-stop")
+	defer client.Close()
+
+	checker := client.HealthChecker("http://127.0.0.1:1", 10*time.Millisecond, HealthCheckConfig{})
+	checker.Stop()
+	checker.Stop()
+	assert.False(t, checker.Healthy())
+}
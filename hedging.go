@@ -0,0 +1,189 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Default hedging settings.
+const (
+	defaultHedgeDelay = 100 * time.Millisecond
+	defaultMaxHedges  = 1
+)
+
+// HedgingConfig contains settings for hedged (parallel backup) requests.
+// A hedged request fires an additional attempt when the previous one hasn't answered
+// within Delay and returns whichever response arrives first, cancelling the others.
+type HedgingConfig struct {
+	// Delay is the time to wait before firing a backup request (typically the p95 latency).
+	Delay time.Duration
+
+	// MaxHedges is the maximum number of backup requests fired in addition to the original one.
+	MaxHedges int
+
+	// Methods is the list of HTTP methods eligible for hedging (default: GET, HEAD, OPTIONS).
+	// POST and PATCH requests are hedged only when they carry an Idempotency-Key header.
+	Methods []string
+}
+
+// withDefaults applies default values to the hedging configuration.
+func (hc HedgingConfig) withDefaults() HedgingConfig {
+	if hc.Delay <= 0 {
+		hc.Delay = defaultHedgeDelay
+	}
+
+	if hc.MaxHedges <= 0 {
+		hc.MaxHedges = defaultMaxHedges
+	}
+
+	if len(hc.Methods) == 0 {
+		hc.Methods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	}
+
+	return hc
+}
+
+// isRequestHedgeable checks if a request is idempotent and may be sent several times in parallel.
+func (hc HedgingConfig) isRequestHedgeable(req *http.Request) bool {
+	if slices.Contains(hc.Methods, req.Method) {
+		return true
+	}
+
+	if req.Method == http.MethodPost || req.Method == http.MethodPatch {
+		return req.Header.Get("Idempotency-Key") != ""
+	}
+
+	return false
+}
+
+// hedgeResult holds the outcome of a single hedged request.
+type hedgeResult struct {
+	index int
+	resp  *http.Response
+	err   error
+}
+
+// doHedgedTransport executes the request with hedging when it is enabled and applicable,
+// falling back to a plain doTransport call otherwise.
+func (rt *RoundTripper) doHedgedTransport(retryCtx *retryContext, req *http.Request) (*http.Response, error) {
+	config := retryCtx.config
+	if !config.HedgingEnabled || !config.HedgingConfig.isRequestHedgeable(req) {
+		return rt.doTransport(req)
+	}
+
+	// Hedged copies need their own body, which is only possible when it was buffered
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if hasBody && !retryCtx.canReplayBody() {
+		return rt.doTransport(req)
+	}
+	if hasBody {
+		// Every copy gets a new body, so the attempt body is never sent. Closing it releases
+		// its reference to the pooled body buffer
+		_ = req.Body.Close()
+	}
+
+	cfg := config.HedgingConfig
+	total := cfg.MaxHedges + 1
+	results := make(chan hedgeResult, total)
+	cancels := make([]context.CancelFunc, 0, total)
+
+	launch := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		cancels = append(cancels, cancel)
+		hedgeReq := req.Clone(ctx)
+		var bodyErr error
+		if hasBody {
+			hedgeReq.Body, bodyErr = retryCtx.newAttemptBody()
+			hedgeReq.ContentLength = retryCtx.originalLength
+		}
+		index := len(cancels) - 1
+		go func() {
+			if bodyErr != nil {
+				results <- hedgeResult{index: index, err: bodyErr}
+				return
+			}
+			resp, err := rt.doTransport(hedgeReq)
+			results <- hedgeResult{index: index, resp: resp, err: err}
+		}()
+	}
+
+	// cancelOthers aborts every in-flight request except the winner
+	cancelOthers := func(winner int) {
+		for i, cancel := range cancels {
+			if i != winner {
+				cancel()
+			}
+		}
+	}
+
+	launch()
+	received := 0
+
+	clock := rt.clock()
+	timer := clock.NewTimer(cfg.Delay)
+	defer func() { timer.Stop() }()
+	// rearm restarts the delay before the next hedge with a new timer of the Clock
+	rearm := func() {
+		timer.Stop()
+		timer = clock.NewTimer(cfg.Delay)
+	}
+
+	var lastFailed *hedgeResult
+	for {
+		select {
+		case <-timer.C():
+			if len(cancels) < total {
+				launch()
+				rearm()
+			}
+		case res := <-results:
+			received++
+			if res.err == nil {
+				cancelOthers(res.index)
+				go discardHedgeResults(results, len(cancels)-received)
+				return finishHedgeResult(res, cancels[res.index])
+			}
+
+			// Keep the latest failure as a fallback and fire the next backup immediately
+			if lastFailed != nil {
+				closeResponseBody(lastFailed.resp)
+			}
+			lastFailed = &res
+			if len(cancels) < total {
+				launch()
+				rearm()
+			} else if received == len(cancels) {
+				cancelOthers(res.index)
+				return finishHedgeResult(res, cancels[res.index])
+			}
+		}
+	}
+}
+
+// finishHedgeResult ties the winner's context cancellation to closing its response body.
+func finishHedgeResult(res hedgeResult, cancel context.CancelFunc) (*http.Response, error) {
+	if res.resp == nil || res.resp.Body == nil {
+		cancel()
+		return res.resp, res.err
+	}
+
+	res.resp.Body = &contextAwareBody{ReadCloser: res.resp.Body, cancel: cancel}
+	return res.resp, res.err
+}
+
+// discardHedgeResults collects the losing hedged requests, releasing their connections.
+func discardHedgeResults(results <-chan hedgeResult, pending int) {
+	for range pending {
+		res := <-results
+		closeResponseBody(res.resp)
+	}
+}
+
+// closeResponseBody closes the body of a response that will never reach the caller.
+func closeResponseBody(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+}
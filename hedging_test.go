@@ -0,0 +1,257 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedgingConfig_WithDefaults(t *testing.T) {
+	t.Parallel()
+	cfg := HedgingConfig{}.withDefaults()
+
+	assert.Equal(t, defaultHedgeDelay, cfg.Delay)
+	assert.Equal(t, defaultMaxHedges, cfg.MaxHedges)
+	assert.Equal(t, []string{http.MethodGet, http.MethodHead, http.MethodOptions}, cfg.Methods)
+}
+
+func TestHedgingConfig_IsRequestHedgeable(t *testing.T) {
+	t.Parallel()
+	cfg := HedgingConfig{}.withDefaults()
+
+	get, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	post, _ := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	postWithKey, _ := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	postWithKey.Header.Set("Idempotency-Key", "key-1")
+
+	assert.True(t, cfg.isRequestHedgeable(get))
+	assert.False(t, cfg.isRequestHedgeable(post))
+	assert.True(t, cfg.isRequestHedgeable(postWithKey))
+}
+
+func TestHedging_BackupRequestWins(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// The original request hangs until it is cancelled by the winner
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("backup"))
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		HedgingEnabled: true,
+		HedgingConfig:  HedgingConfig{Delay: 50 * time.Millisecond},
+	}, "test-hedging-wins")
+	defer client.Close()
+
+	start := time.Now()
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "backup", string(body))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestHedging_FastResponseNoBackup(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		HedgingEnabled: true,
+		HedgingConfig:  HedgingConfig{Delay: 200 * time.Millisecond},
+	}, "test-hedging-fast")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestHedging_PostWithIdempotencyKeyReplaysBody(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	bodies := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		HedgingEnabled: true,
+		HedgingConfig:  HedgingConfig{Delay: 50 * time.Millisecond},
+	}, "test-hedging-post")
+	defer client.Close()
+
+	resp, err := client.Post(context.Background(), server.URL, strings.NewReader("payload"),
+		WithIdempotencyKey("hedge-key"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "payload", <-bodies)
+	assert.Equal(t, "payload", <-bodies)
+}
+
+// trackedBody counts the closes of request bodies.
+type trackedBody struct {
+	io.Reader
+	closes *int32
+	once   atomic.Bool
+}
+
+// Close counts the first close.
+func (b *trackedBody) Close() error {
+	if b.once.CompareAndSwap(false, true) {
+		atomic.AddInt32(b.closes, 1)
+	}
+	return nil
+}
+
+func TestHedging_ClosesReplacedBodies(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		HedgingEnabled: true,
+		HedgingConfig:  HedgingConfig{Delay: 50 * time.Millisecond},
+	}, "test-hedging-bodies")
+	defer client.Close()
+
+	var opens, closes int32
+	provider := func() (io.ReadCloser, error) {
+		atomic.AddInt32(&opens, 1)
+		return &trackedBody{Reader: strings.NewReader("payload"), closes: &closes}, nil
+	}
+	resp, err := client.Post(context.Background(), server.URL, nil,
+		WithBodyProvider(provider, int64(len("payload"))), WithIdempotencyKey("hedge-key"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&closes) == atomic.LoadInt32(&opens) },
+		time.Second, 5*time.Millisecond, "every request body is closed")
+}
+
+func TestHedging_UsesRequestConfig(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-hedging-request-config")
+	defer client.Close()
+	rt := client.httpClient.Transport.(*RoundTripper)
+
+	// The request configuration enables hedging although the client doesn't
+	config := rt.config
+	config.HedgingEnabled = true
+	config.HedgingConfig = HedgingConfig{Delay: 20 * time.Millisecond}.withDefaults()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := rt.doHedgedTransport(&retryContext{config: config}, req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestHedging_WaitsWithClock(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte("backup"))
+	}))
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	client := New(Config{
+		Clock:          clock,
+		HedgingEnabled: true,
+		HedgingConfig:  HedgingConfig{Delay: time.Hour, MaxHedges: 1},
+	}, "test-hedging-clock")
+	defer client.Close()
+
+	type result struct {
+		body string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		done <- result{string(body), err}
+	}()
+
+	// The backup request is sent once the hedge delay passes on the fake clock
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	res := <-done
+	require.NoError(t, res.err)
+	assert.Equal(t, "backup", res.body)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
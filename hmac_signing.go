@@ -0,0 +1,139 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HMACAlgorithm selects the hash function used for HMAC request signatures.
+type HMACAlgorithm string
+
+// Supported HMAC algorithms.
+const (
+	HMACSHA256 HMACAlgorithm = "sha256"
+	HMACSHA512 HMACAlgorithm = "sha512"
+)
+
+// Headers set by HMACSigningMiddleware next to the signature header.
+const (
+	HMACTimestampHeader = "X-Timestamp"
+	HMACNonceHeader     = "X-Nonce"
+)
+
+// HMACSigningMiddleware signs requests with an HMAC over the method, request URI,
+// timestamp, nonce and body:
+//
+//	METHOD + "\n" + REQUEST_URI + "\n" + TIMESTAMP + "\n" + NONCE + "\n" + BODY
+//
+// The hex-encoded signature goes to the configured header, the Unix timestamp to
+// X-Timestamp and a random nonce to X-Nonce. The signature is recomputed for every
+// retry attempt, so retried requests carry a fresh timestamp and nonce.
+type HMACSigningMiddleware struct {
+	secret     []byte
+	headerName string
+	algo       HMACAlgorithm
+	now        func() time.Time
+}
+
+// NewHMACSigningMiddleware creates a signing middleware. Add it to Config.Middlewares.
+func NewHMACSigningMiddleware(secret []byte, headerName string, algo HMACAlgorithm) *HMACSigningMiddleware {
+	return &HMACSigningMiddleware{
+		secret:     secret,
+		headerName: headerName,
+		algo:       algo,
+		now:        time.Now,
+	}
+}
+
+// Process implements the Middleware interface. Signing happens per attempt in PrepareAttempt.
+func (m *HMACSigningMiddleware) Process(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	return next(req)
+}
+
+// PrepareAttempt implements the AttemptMiddleware interface and signs the request of a single attempt.
+func (m *HMACSigningMiddleware) PrepareAttempt(req *http.Request, _ int) error {
+	newHash, err := m.hashFunc()
+	if err != nil {
+		return err
+	}
+
+	body, err := readBodyForSigning(req)
+	if err != nil {
+		return fmt.Errorf("failed to read request body for signing: %w", err)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(m.now().Unix(), 10)
+	nonceHex := hex.EncodeToString(nonce)
+
+	req.Header.Set(m.headerName, m.sign(newHash, req.Method, req.URL.RequestURI(), timestamp, nonceHex, body))
+	req.Header.Set(HMACTimestampHeader, timestamp)
+	req.Header.Set(HMACNonceHeader, nonceHex)
+	return nil
+}
+
+// sign computes the hex-encoded signature of the canonical request string.
+func (m *HMACSigningMiddleware) sign(
+	newHash func() hash.Hash, method, requestURI, timestamp, nonce string, body []byte,
+) string {
+	mac := hmac.New(newHash, m.secret)
+	for _, part := range []string{method, requestURI, timestamp, nonce} {
+		mac.Write([]byte(part))
+		mac.Write([]byte("\n"))
+	}
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hashFunc returns the hash constructor for the configured algorithm.
+func (m *HMACSigningMiddleware) hashFunc() (func() hash.Hash, error) {
+	switch m.algo {
+	case HMACSHA256, "":
+		return sha256.New, nil
+	case HMACSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported HMAC algorithm %q", m.algo)
+	}
+}
+
+// readBodyForSigning returns the request body without consuming it.
+func readBodyForSigning(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -0,0 +1,129 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyHMAC recomputes the signature the way a partner API would.
+func verifyHMAC(newHash func() hash.Hash, secret []byte, r *http.Request, body []byte) bool {
+	mac := hmac.New(newHash, secret)
+	canonical := strings.Join([]string{
+		r.Method, r.URL.RequestURI(), r.Header.Get(HMACTimestampHeader), r.Header.Get(HMACNonceHeader),
+	}, "\n") + "\n" + string(body)
+	mac.Write([]byte(canonical))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Signature")))
+}
+
+func TestHMACSigningMiddleware_ResignsEachAttempt(t *testing.T) {
+	t.Parallel()
+	secret := []byte("partner-secret")
+
+	var mu sync.Mutex
+	var nonces []string
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"amount":10}`, string(body))
+		assert.True(t, verifyHMAC(sha256.New, secret, r, body), "signature must be valid on every attempt")
+
+		mu.Lock()
+		defer mu.Unlock()
+		nonces = append(nonces, r.Header.Get(HMACNonceHeader))
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := retryBodyConfig()
+	config.Middlewares = []Middleware{NewHMACSigningMiddleware(secret, "X-Signature", HMACSHA256)}
+	client := New(config, "test-hmac-retry")
+	defer client.Close()
+
+	resp, err := client.Post(context.Background(), server.URL+"/payments?id=1", strings.NewReader(`{"amount":10}`),
+		WithIdempotencyKey("pay-1"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, nonces, 2)
+	assert.NotEqual(t, nonces[0], nonces[1], "each attempt needs a fresh nonce")
+}
+
+func TestHMACSigningMiddleware_SHA512AndNoBody(t *testing.T) {
+	t.Parallel()
+	secret := []byte("key")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, verifyHMAC(sha512.New, secret, r, nil))
+		assert.NotEmpty(t, r.Header.Get(HMACTimestampHeader))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		Middlewares: []Middleware{NewHMACSigningMiddleware(secret, "X-Signature", HMACSHA512)},
+	}, "test-hmac-sha512")
+	defer client.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/status", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, req.Header.Get("X-Signature"), "caller's request must not be modified")
+}
+
+func TestHMACSigningMiddleware_UnsupportedAlgorithm(t *testing.T) {
+	t.Parallel()
+	client := New(Config{
+		Middlewares: []Middleware{NewHMACSigningMiddleware([]byte("key"), "X-Signature", "md5")},
+	}, "test-hmac-unsupported")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), "http://example.invalid")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unsupported HMAC algorithm "md5"`)
+}
+
+func TestHMACSigningMiddleware_PrepareAttempt(t *testing.T) {
+	t.Parallel()
+	m := NewHMACSigningMiddleware([]byte("secret"), "X-Sig", HMACSHA256)
+	m.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/a?b=c", io.NopCloser(strings.NewReader("data")))
+	require.NoError(t, err)
+	require.NoError(t, m.PrepareAttempt(req, 1))
+
+	assert.Equal(t, "1700000000", req.Header.Get(HMACTimestampHeader))
+	assert.Len(t, req.Header.Get(HMACNonceHeader), 32)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("POST\n/a?b=c\n1700000000\n" + req.Header.Get(HMACNonceHeader) + "\ndata"))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Sig"))
+
+	// The body is still readable after signing
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(body))
+}
@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// RetryAttemptInfo describes a failed attempt that is about to be retried.
+type RetryAttemptInfo struct {
+	// Request is the original request
+	Request *http.Request
+	// Attempt is the number of the failed attempt, starting at 1
+	Attempt int
+	// Reason is the retry reason, as in the http_client_retries_total metric
+	Reason string
+	// StatusCode is the status of the failed attempt, zero without a response
+	StatusCode int
+	// Err is the error of the failed attempt, if any
+	Err error
+	// Delay is the wait before the next attempt
+	Delay time.Duration
+	// Elapsed is the time since the request started
+	Elapsed time.Duration
+}
+
+// RequestFinishedInfo describes the outcome of a request after all attempts.
+type RequestFinishedInfo struct {
+	// Request is the original request
+	Request *http.Request
+	// Attempts is the number of attempts made
+	Attempts int
+	// StatusCode is the status of the returned response, zero without a response
+	StatusCode int
+	// Err is the returned error, if any
+	Err error
+	// Elapsed is the time until the response headers of the last attempt (or the error)
+	Elapsed time.Duration
+}
+
+// notifyRetryAttempt calls Config.OnRetryAttempt if it is set.
+func notifyRetryAttempt(retryCtx *retryContext, attempt int, resp *http.Response, err error, delay time.Duration) {
+	hook := retryCtx.config.OnRetryAttempt
+	if hook == nil {
+		return
+	}
+
+	info := RetryAttemptInfo{
+		Request: retryCtx.originalReq,
+		Attempt: attempt,
+		Reason:  retryCtx.retryReason,
+		Err:     err,
+		Delay:   delay,
+		Elapsed: retryCtx.elapsed(),
+	}
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	}
+	hook(info)
+}
+
+// notifyRequestFinished calls Config.OnRequestFinished if it is set.
+func notifyRequestFinished(retryCtx *retryContext, resp *http.Response, err error) {
+	hook := retryCtx.config.OnRequestFinished
+	if hook == nil {
+		return
+	}
+
+	info := RequestFinishedInfo{
+		Request:  retryCtx.originalReq,
+		Attempts: retryCtx.attempts,
+		Err:      err,
+		Elapsed:  retryCtx.elapsed(),
+	}
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	}
+	hook(info)
+}
@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks_RetryAndFinish(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var retries []RetryAttemptInfo
+	var finished []RequestFinishedInfo
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+		OnRetryAttempt: func(info RetryAttemptInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			retries = append(retries, info)
+		},
+		OnRequestFinished: func(info RequestFinishedInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			finished = append(finished, info)
+		},
+	}, "test-hooks")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL+"/orders")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, retries, 2)
+	for i, info := range retries {
+		assert.Equal(t, i+1, info.Attempt)
+		assert.Equal(t, "status", info.Reason)
+		assert.Equal(t, http.StatusServiceUnavailable, info.StatusCode)
+		assert.NoError(t, info.Err)
+		assert.LessOrEqual(t, info.Delay, 5*time.Millisecond)
+		assert.Equal(t, "/orders", info.Request.URL.Path)
+	}
+	assert.Greater(t, retries[1].Elapsed, retries[0].Elapsed)
+
+	require.Len(t, finished, 1)
+	assert.Equal(t, 3, finished[0].Attempts)
+	assert.Equal(t, http.StatusOK, finished[0].StatusCode)
+	assert.NoError(t, finished[0].Err)
+	assert.GreaterOrEqual(t, finished[0].Elapsed, retries[1].Elapsed)
+}
+
+func TestHooks_FinishedWithError(t *testing.T) {
+	t.Parallel()
+	transportErr := errors.New("connection refused")
+	var finished RequestFinishedInfo
+	client := New(Config{
+		Transport:    &mockRoundTripper{errors: []error{transportErr, transportErr}},
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		OnRequestFinished: func(info RequestFinishedInfo) {
+			finished = info
+		},
+	}, "test-hooks-error")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), "http://example.com/")
+	require.Error(t, err)
+	assert.Equal(t, 2, finished.Attempts)
+	assert.Zero(t, finished.StatusCode)
+	assert.ErrorIs(t, finished.Err, transportErr)
+}
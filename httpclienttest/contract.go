@@ -0,0 +1,326 @@
+package httpclienttest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	httpclient "github.com/rurick/http-client"
+)
+
+// PactSpecificationVersion is the version of the Pact specification of contract files.
+const PactSpecificationVersion = "3.0.0"
+
+// defaultContractHeaders are the headers written to contracts by default.
+var defaultContractHeaders = []string{"Content-Type", "Accept"}
+
+// pathParamPattern matches a {name} placeholder of a path template.
+var pathParamPattern = regexp.MustCompile(`\{[^{}/]+\}`)
+
+// ContractOptions configures a ContractRecorder.
+type ContractOptions struct {
+	// Consumer is the name of the consumer, the service under test
+	Consumer string
+
+	// Provider is the name of the provider the client talks to
+	Provider string
+
+	// Transport answers the requests, e.g. a MockTransport or a provider stub
+	// (default: http.DefaultTransport)
+	Transport http.RoundTripper
+
+	// Headers are the request and response headers written to the contract in addition
+	// to Content-Type and Accept
+	Headers []string
+}
+
+// contractKey is the context key for the interaction set by WithInteraction.
+type contractKey struct{}
+
+// interactionInfo describes the interaction of a request.
+type interactionInfo struct {
+	description string
+	states      []string
+}
+
+// WithInteraction sets the description and the provider states of the interaction recorded
+// for the request. Without it the description is the method and the path template or path,
+// e.g. "GET /users/{id}".
+func WithInteraction(description string, providerStates ...string) httpclient.RequestOption {
+	return func(req *http.Request) {
+		info := interactionInfo{description: description, states: providerStates}
+		*req = *req.WithContext(context.WithValue(req.Context(), contractKey{}, info))
+	}
+}
+
+// ContractRecorder is an http.RoundTripper for Config.Transport that captures the requests of
+// a client and the responses of its transport as Pact interactions, so consumer tests produce
+// contracts without a separate mocking layer. Paths built with httpclient.WithPathParam get
+// regex matchers and query parameters set by httpclient.WithQueryParam, WithQueryParams or
+// WithQueryStruct get type matchers, so the provider may answer them with any value.
+type ContractRecorder struct {
+	options ContractOptions
+	headers []string // canonical names of the recorded headers
+
+	mu           sync.Mutex
+	interactions []*PactInteraction
+}
+
+// PactInteraction is an interaction of a Pact contract.
+type PactInteraction struct {
+	Description    string              `json:"description"`
+	ProviderStates []PactProviderState `json:"providerStates,omitempty"`
+	Request        PactRequest         `json:"request"`
+	Response       PactResponse        `json:"response"`
+}
+
+// PactProviderState is the state the provider must be in for an interaction.
+type PactProviderState struct {
+	Name string `json:"name"`
+}
+
+// PactRequest is the expected request of an interaction.
+type PactRequest struct {
+	Method        string              `json:"method"`
+	Path          string              `json:"path"`
+	Query         map[string][]string `json:"query,omitempty"`
+	Headers       map[string]string   `json:"headers,omitempty"`
+	Body          any                 `json:"body,omitempty"`
+	MatchingRules map[string]any      `json:"matchingRules,omitempty"`
+}
+
+// PactResponse is the response the provider must return for an interaction.
+type PactResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+}
+
+// pactMatchers are the matchers of a request part.
+type pactMatchers struct {
+	Matchers []map[string]string `json:"matchers"`
+}
+
+// pactFile is the content of a Pact contract file.
+type pactFile struct {
+	Consumer     pactParticipant    `json:"consumer"`
+	Provider     pactParticipant    `json:"provider"`
+	Interactions []*PactInteraction `json:"interactions"`
+	Metadata     map[string]any     `json:"metadata"`
+}
+
+// pactParticipant names the consumer or provider of a contract.
+type pactParticipant struct {
+	Name string `json:"name"`
+}
+
+// NewContractRecorder creates a recorder for the contract between the consumer and provider.
+func NewContractRecorder(options ContractOptions) *ContractRecorder {
+	if options.Transport == nil {
+		options.Transport = http.DefaultTransport
+	}
+	r := &ContractRecorder{options: options}
+	for _, names := range [][]string{defaultContractHeaders, options.Headers} {
+		for _, name := range names {
+			r.headers = append(r.headers, http.CanonicalHeaderKey(name))
+		}
+	}
+	return r
+}
+
+// RoundTrip sends the request with the transport and records the interaction. Every attempt
+// replaces the interaction with the same description and provider states, so a retried request
+// is recorded once, with the response of the last attempt.
+func (r *ContractRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	outgoing := req.Clone(req.Context())
+	if req.Body != nil {
+		outgoing.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.options.Transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &PactInteraction{
+		Request: r.pactRequest(req, body),
+		Response: PactResponse{
+			Status:  resp.StatusCode,
+			Headers: r.pactHeaders(resp.Header),
+			Body:    pactBody(resp.Header, respBody),
+		},
+	}
+	info, _ := req.Context().Value(contractKey{}).(interactionInfo)
+	interaction.Description = info.description
+	if interaction.Description == "" {
+		path := interaction.Request.Path
+		if template, ok := httpclient.PathTemplateFromContext(req.Context()); ok {
+			path = template
+		}
+		interaction.Description = req.Method + " " + path
+	}
+	for _, state := range info.states {
+		interaction.ProviderStates = append(interaction.ProviderStates, PactProviderState{Name: state})
+	}
+	r.add(interaction)
+	return resp, nil
+}
+
+// pactRequest builds the expected request with matchers for the path parameters and query
+// parameters set by request options.
+func (r *ContractRecorder) pactRequest(req *http.Request, body []byte) PactRequest {
+	pact := PactRequest{
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Headers: r.pactHeaders(req.Header),
+		Body:    pactBody(req.Header, body),
+	}
+	if pact.Path == "" {
+		pact.Path = "/"
+	}
+	if query := req.URL.Query(); len(query) > 0 {
+		pact.Query = query
+	}
+
+	rules := make(map[string]any)
+	if template, ok := httpclient.PathTemplateFromContext(req.Context()); ok {
+		if pattern := pathPattern(pact.Path, template); pattern != "" {
+			rules["path"] = pactMatchers{Matchers: []map[string]string{{"match": "regex", "regex": pattern}}}
+		}
+	}
+	query := make(map[string]pactMatchers)
+	for _, name := range httpclient.QueryParamsFromContext(req.Context()) {
+		if _, ok := pact.Query[name]; ok {
+			query[name] = pactMatchers{Matchers: []map[string]string{{"match": "type"}}}
+		}
+	}
+	if len(query) > 0 {
+		rules["query"] = query
+	}
+	if len(rules) > 0 {
+		pact.MatchingRules = rules
+	}
+	return pact
+}
+
+// pactHeaders returns the recorded headers present in header.
+func (r *ContractRecorder) pactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string)
+	for _, name := range r.headers {
+		if values := header.Values(name); len(values) > 0 {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// pactBody returns a JSON body as a JSON value and other bodies as a string.
+func pactBody(header http.Header, body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) && json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	return string(body)
+}
+
+// pathPattern returns a regex matching paths of the template, which may be relative to the
+// base URL path, e.g. "/v1/users/[^/]+" for "/v1/users/42" and "/users/{id}". It returns
+// an empty string if the path doesn't end with the template.
+func pathPattern(path, template string) string {
+	var suffix strings.Builder
+	last := 0
+	for _, match := range pathParamPattern.FindAllStringIndex(template, -1) {
+		suffix.WriteString(regexp.QuoteMeta(template[last:match[0]]))
+		suffix.WriteString(`[^/]+`)
+		last = match[1]
+	}
+	suffix.WriteString(regexp.QuoteMeta(template[last:]))
+
+	loc := regexp.MustCompile(suffix.String() + `$`).FindStringIndex(path)
+	if loc == nil {
+		return ""
+	}
+	return "^" + regexp.QuoteMeta(path[:loc[0]]) + suffix.String() + "$"
+}
+
+// add stores the interaction, replacing an earlier attempt of the same interaction.
+func (r *ContractRecorder) add(interaction *PactInteraction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.interactions {
+		if existing.Description == interaction.Description &&
+			slices.Equal(existing.ProviderStates, interaction.ProviderStates) {
+			r.interactions[i] = interaction
+			return
+		}
+	}
+	r.interactions = append(r.interactions, interaction)
+}
+
+// Interactions returns the recorded interactions.
+func (r *ContractRecorder) Interactions() []*PactInteraction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*PactInteraction(nil), r.interactions...)
+}
+
+// Pact returns the recorded contract as a Pact specification v3 JSON document.
+func (r *ContractRecorder) Pact() ([]byte, error) {
+	data, err := json.MarshalIndent(pactFile{
+		Consumer:     pactParticipant{Name: r.options.Consumer},
+		Provider:     pactParticipant{Name: r.options.Provider},
+		Interactions: r.Interactions(),
+		Metadata: map[string]any{
+			"pactSpecification": map[string]string{"version": PactSpecificationVersion},
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode contract: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Save writes the contract to "<Consumer>-<Provider>.json" in dir, the file name expected by
+// Pact tooling, and returns its path.
+func (r *ContractRecorder) Save(dir string) (string, error) {
+	data, err := r.Pact()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create contract directory: %w", err)
+	}
+	path := filepath.Join(dir, r.options.Consumer+"-"+r.options.Provider+".json")
+	return path, os.WriteFile(path, data, 0o600)
+}
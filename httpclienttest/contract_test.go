@@ -0,0 +1,100 @@
+package httpclienttest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpclient "github.com/rurick/http-client"
+)
+
+func TestContractRecorder_Pact(t *testing.T) {
+	t.Parallel()
+	mock := NewMockTransport()
+	mock.On(http.MethodGet, "/v1/users/42").Respond(http.StatusServiceUnavailable, "").Times(1)
+	mock.On(http.MethodGet, "/v1/users/42").RespondJSON(http.StatusOK, map[string]any{"id": 42, "name": "Ann"})
+	mock.On(http.MethodPost, "/v1/users").Respond(http.StatusCreated, "created")
+
+	recorder := NewContractRecorder(ContractOptions{Consumer: "web", Provider: "users", Transport: mock})
+	client := httpclient.New(httpclient.Config{
+		BaseURL:      "https://users.example.com/v1",
+		Transport:    recorder,
+		RetryEnabled: true,
+		RetryConfig:  httpclient.RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}, "test-contract-recorder")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), "/users/{id}",
+		httpclient.WithPathParam("id", 42),
+		httpclient.WithQueryParam("fields", "name"),
+		WithInteraction("a request for user 42", "user 42 exists"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":42,"name":"Ann"}`, readAll(t, resp))
+
+	resp, err = client.Post(context.Background(), "/users", strings.NewReader(`{"name":"Bob"}`),
+		httpclient.WithContentType("application/json"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	interactions := recorder.Interactions()
+	require.Len(t, interactions, 2, "retries are recorded once")
+
+	get := interactions[0]
+	assert.Equal(t, "a request for user 42", get.Description)
+	assert.Equal(t, []PactProviderState{{Name: "user 42 exists"}}, get.ProviderStates)
+	assert.Equal(t, "/v1/users/42", get.Request.Path)
+	assert.Equal(t, map[string][]string{"fields": {"name"}}, get.Request.Query)
+	assert.Equal(t, http.StatusOK, get.Response.Status)
+	assert.Equal(t, "application/json", get.Response.Headers["Content-Type"])
+
+	post := interactions[1]
+	assert.Equal(t, "POST /v1/users", post.Description)
+	assert.Nil(t, post.Request.MatchingRules)
+	assert.Equal(t, "created", post.Response.Body)
+
+	dir := filepath.Join(t.TempDir(), "pacts")
+	path, err := recorder.Save(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "web-users.json"), path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var pact struct {
+		Consumer     struct{ Name string }
+		Interactions []struct {
+			Request struct {
+				Body          map[string]string
+				MatchingRules struct {
+					Path  struct{ Matchers []map[string]string }
+					Query map[string]struct{ Matchers []map[string]string }
+				}
+			}
+		}
+		Metadata struct {
+			PactSpecification struct{ Version string }
+		}
+	}
+	require.NoError(t, json.Unmarshal(data, &pact))
+	assert.Equal(t, "web", pact.Consumer.Name)
+	assert.Equal(t, PactSpecificationVersion, pact.Metadata.PactSpecification.Version)
+	rules := pact.Interactions[0].Request.MatchingRules
+	assert.Equal(t, []map[string]string{{"match": "regex", "regex": `^/v1/users/[^/]+$`}}, rules.Path.Matchers)
+	assert.Equal(t, []map[string]string{{"match": "type"}}, rules.Query["fields"].Matchers)
+	assert.Equal(t, map[string]string{"name": "Bob"}, pact.Interactions[1].Request.Body)
+}
+
+func TestPathPattern(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, `^/users/[^/]+/orders/[^/]+$`, pathPattern("/users/1/orders/2", "/users/{id}/orders/{order}"))
+	assert.Equal(t, `^/api\.v2/items/[^/]+$`, pathPattern("/api.v2/items/a%2Fb", "/items/{id}"))
+	assert.Empty(t, pathPattern("/other/1", "/users/{id}"))
+}
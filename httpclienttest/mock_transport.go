@@ -0,0 +1,356 @@
+// Package httpclienttest provides test doubles for code using the httpclient package,
+// so unit tests don't need an httptest server.
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ErrUnexpectedRequest is returned for requests matching no expectation.
+var ErrUnexpectedRequest = errors.New("httpclienttest: unexpected request")
+
+// Call is a request received by a MockTransport.
+type Call struct {
+	// Request is the received request; its body was consumed, see Body
+	Request *http.Request
+	// Body is the request body
+	Body []byte
+	// Matched reports whether an expectation handled the request
+	Matched bool
+}
+
+// MockTransport is a programmable http.RoundTripper for Config.Transport.
+// Requests are matched against expectations in the order they were added;
+// an expectation that reached its Times limit is skipped.
+type MockTransport struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+}
+
+// NewMockTransport creates a mock transport without expectations.
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+// On adds an expectation for requests with the method and URL. An empty method matches
+// any method. A URL starting with "/" matches the path (and the query, if the URL has one),
+// other URLs match the full request URL; an empty URL matches any URL.
+func (m *MockTransport) On(method, url string) *Expectation {
+	e := &Expectation{method: method, url: url, status: http.StatusOK, header: make(http.Header)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// RoundTrip answers the request with the first matching expectation.
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	e := m.match(req, body)
+	m.calls = append(m.calls, Call{Request: req, Body: body, Matched: e != nil})
+	if e != nil {
+		e.calls++
+	}
+	m.mu.Unlock()
+
+	if e == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedRequest, req.Method, req.URL)
+	}
+	return e.respond(req)
+}
+
+// match returns the first expectation matching the request. Must be called with m.mu held.
+func (m *MockTransport) match(req *http.Request, body []byte) *Expectation {
+	for _, e := range m.expectations {
+		if e.times > 0 && e.calls >= e.times {
+			continue
+		}
+		if e.matches(req, body) {
+			return e
+		}
+	}
+	return nil
+}
+
+// Calls returns the received requests in order.
+func (m *MockTransport) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallCount returns the number of requests with the method and URL, matched as in On.
+func (m *MockTransport) CallCount(method, url string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	probe := &Expectation{method: method, url: url}
+	count := 0
+	for _, call := range m.calls {
+		if probe.matches(call.Request, call.Body) {
+			count++
+		}
+	}
+	return count
+}
+
+// AssertExpectations fails the test when an expectation wasn't called (or was called
+// fewer times than required by Times) or when a request matched no expectation.
+func (m *MockTransport) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, e := range m.expectations {
+		switch {
+		case e.times > 0 && e.calls < e.times:
+			t.Errorf("httpclienttest: expected %s to be called %d time(s), got %d", e, e.times, e.calls)
+			ok = false
+		case e.times == 0 && e.calls == 0 && !e.optional:
+			t.Errorf("httpclienttest: expected %s to be called", e)
+			ok = false
+		}
+	}
+	for _, call := range m.calls {
+		if !call.Matched {
+			t.Errorf("httpclienttest: unexpected request %s %s", call.Request.Method, call.Request.URL)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertNumberOfCalls fails the test unless n requests with the method and URL were received.
+func (m *MockTransport) AssertNumberOfCalls(t testing.TB, method, url string, n int) bool {
+	t.Helper()
+	if got := m.CallCount(method, url); got != n {
+		t.Errorf("httpclienttest: expected %d call(s) of %s %s, got %d", n, method, url, got)
+		return false
+	}
+	return true
+}
+
+// Expectation describes the requests it matches and how they are answered.
+// Its methods return the expectation for chaining and must be called before the requests are sent.
+type Expectation struct {
+	method    string
+	url       string
+	matchers  []func(req *http.Request, body []byte) bool
+	status    int
+	header    http.Header
+	body      []byte
+	err       error
+	responder func(req *http.Request) (*http.Response, error)
+	delay     time.Duration
+	times     int
+	optional  bool
+	calls     int
+}
+
+// String describes the expectation in assertion messages.
+func (e *Expectation) String() string {
+	method, url := e.method, e.url
+	if method == "" {
+		method = "*"
+	}
+	if url == "" {
+		url = "*"
+	}
+	return method + " " + url
+}
+
+// WithHeader matches requests with the header value.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	return e.Match(func(req *http.Request) bool { return req.Header.Get(key) == value })
+}
+
+// WithBody matches requests whose body satisfies the matcher, e.g. BodyContains or BodyJSON.
+func (e *Expectation) WithBody(matcher func(body []byte) bool) *Expectation {
+	e.matchers = append(e.matchers, func(_ *http.Request, body []byte) bool { return matcher(body) })
+	return e
+}
+
+// Match matches requests satisfying the predicate.
+func (e *Expectation) Match(predicate func(req *http.Request) bool) *Expectation {
+	e.matchers = append(e.matchers, func(req *http.Request, _ []byte) bool { return predicate(req) })
+	return e
+}
+
+// Respond answers with the status and body.
+func (e *Expectation) Respond(status int, body string) *Expectation {
+	e.status = status
+	e.body = []byte(body)
+	return e
+}
+
+// RespondJSON answers with the status and v encoded as JSON.
+func (e *Expectation) RespondJSON(status int, v interface{}) *Expectation {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpclienttest: encode response: %v", err))
+	}
+	e.status = status
+	e.body = data
+	e.header.Set("Content-Type", "application/json")
+	return e
+}
+
+// RespondWith answers with the result of fn, e.g. for responses depending on the request.
+func (e *Expectation) RespondWith(fn func(req *http.Request) (*http.Response, error)) *Expectation {
+	e.responder = fn
+	return e
+}
+
+// ResponseHeader sets a header of the response.
+func (e *Expectation) ResponseHeader(key, value string) *Expectation {
+	e.header.Set(key, value)
+	return e
+}
+
+// ReturnError fails matching requests with err, e.g. to simulate network errors.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Delay waits before answering. The wait ends early with the request context error.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Times limits the expectation to n requests; later requests go to the next matching
+// expectation. AssertExpectations then requires exactly n calls.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Once limits the expectation to a single request.
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// Maybe allows the expectation to receive no requests in AssertExpectations.
+func (e *Expectation) Maybe() *Expectation {
+	e.optional = true
+	return e
+}
+
+// matches reports whether the request matches the method, URL and matchers.
+func (e *Expectation) matches(req *http.Request, body []byte) bool {
+	if e.method != "" && !strings.EqualFold(e.method, req.Method) {
+		return false
+	}
+	if !matchURL(e.url, req) {
+		return false
+	}
+	for _, matcher := range e.matchers {
+		if !matcher(req, body) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchURL matches the request URL against an expectation URL as described in On.
+func matchURL(pattern string, req *http.Request) bool {
+	switch {
+	case pattern == "":
+		return true
+	case strings.HasPrefix(pattern, "/"):
+		if strings.Contains(pattern, "?") {
+			return pattern == req.URL.RequestURI()
+		}
+		return pattern == req.URL.Path
+	default:
+		return pattern == req.URL.String()
+	}
+}
+
+// respond builds the answer of the expectation.
+func (e *Expectation) respond(req *http.Request) (*http.Response, error) {
+	if e.delay > 0 {
+		timer := time.NewTimer(e.delay)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+	if e.responder != nil {
+		return e.responder(req)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}, nil
+}
+
+// BodyContains matches bodies containing s.
+func BodyContains(s string) func(body []byte) bool {
+	return func(body []byte) bool { return bytes.Contains(body, []byte(s)) }
+}
+
+// BodyEquals matches bodies equal to s.
+func BodyEquals(s string) func(body []byte) bool {
+	return func(body []byte) bool { return string(body) == s }
+}
+
+// BodyJSON matches JSON bodies semantically equal to v, ignoring formatting and key order.
+func BodyJSON(v interface{}) func(body []byte) bool {
+	expected, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpclienttest: encode expected body: %v", err))
+	}
+	var want interface{}
+	_ = json.Unmarshal(expected, &want)
+	return func(body []byte) bool {
+		var got interface{}
+		if err := json.Unmarshal(body, &got); err != nil {
+			return false
+		}
+		return jsonEqual(want, got)
+	}
+}
+
+// jsonEqual compares decoded JSON values.
+func jsonEqual(a, b interface{}) bool {
+	left, errA := json.Marshal(a)
+	right, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(left, right)
+}
@@ -0,0 +1,146 @@
+package httpclienttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpclient "github.com/rurick/http-client"
+)
+
+// recordingTB records assertion failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func readAll(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestMockTransport_Client(t *testing.T) {
+	t.Parallel()
+	mock := NewMockTransport()
+	mock.On(http.MethodGet, "/users/1").RespondJSON(http.StatusOK, map[string]string{"name": "Ann"})
+	mock.On(http.MethodPost, "https://api.example.com/users").
+		WithHeader("Content-Type", "application/json").
+		WithBody(BodyJSON(map[string]string{"name": "Bob"})).
+		Respond(http.StatusCreated, "created").
+		ResponseHeader("Location", "/users/2")
+
+	client := httpclient.New(httpclient.Config{Transport: mock}, "test-mock-transport")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), "https://api.example.com/users/1")
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `{"name":"Ann"}`, readAll(t, resp))
+
+	resp, err = client.Post(context.Background(), "https://api.example.com/users",
+		strings.NewReader(`{ "name": "Bob" }`), httpclient.WithContentType("application/json"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/users/2", resp.Header.Get("Location"))
+	assert.Equal(t, "created", readAll(t, resp))
+
+	assert.True(t, mock.AssertExpectations(t))
+	assert.True(t, mock.AssertNumberOfCalls(t, http.MethodPost, "/users", 1))
+	calls := mock.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, `{ "name": "Bob" }`, string(calls[1].Body))
+}
+
+func TestMockTransport_SequenceWithRetry(t *testing.T) {
+	t.Parallel()
+	mock := NewMockTransport()
+	mock.On(http.MethodGet, "/flaky").Respond(http.StatusServiceUnavailable, "").Once()
+	mock.On(http.MethodGet, "/flaky").ReturnError(errors.New("connection reset by peer")).Once()
+	mock.On(http.MethodGet, "/flaky").Respond(http.StatusOK, "ok")
+
+	client := httpclient.New(httpclient.Config{
+		Transport:    mock,
+		RetryEnabled: true,
+		RetryConfig:  httpclient.RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}, "test-mock-transport-retry")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), "http://svc/flaky")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", readAll(t, resp))
+	assert.Equal(t, 3, mock.CallCount(http.MethodGet, "/flaky"))
+	assert.True(t, mock.AssertExpectations(t))
+}
+
+func TestMockTransport_Unexpected(t *testing.T) {
+	t.Parallel()
+	mock := NewMockTransport()
+	mock.On(http.MethodGet, "/a").Respond(http.StatusOK, "")
+	mock.On(http.MethodGet, "/b?page=2").Respond(http.StatusOK, "").Times(2)
+	mock.On("", "").Respond(http.StatusNoContent, "").Maybe()
+
+	req, err := http.NewRequest(http.MethodGet, "http://svc/b?page=2", nil)
+	require.NoError(t, err)
+	resp, err := mock.RoundTrip(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	tb := &recordingTB{}
+	assert.False(t, mock.AssertExpectations(tb))
+	assert.Equal(t, []string{
+		"httpclienttest: expected GET /a to be called",
+		"httpclienttest: expected GET /b?page=2 to be called 2 time(s), got 1",
+	}, tb.errors)
+
+	strict := NewMockTransport()
+	strict.On(http.MethodGet, "/a").Respond(http.StatusOK, "")
+	req, err = http.NewRequest(http.MethodDelete, "http://svc/a", nil)
+	require.NoError(t, err)
+	_, err = strict.RoundTrip(req)
+	assert.ErrorIs(t, err, ErrUnexpectedRequest)
+
+	tb = &recordingTB{}
+	assert.False(t, strict.AssertExpectations(tb))
+	assert.Contains(t, tb.errors, "httpclienttest: unexpected request DELETE http://svc/a")
+	assert.False(t, strict.AssertNumberOfCalls(tb, http.MethodGet, "/a", 1))
+}
+
+func TestMockTransport_Delay(t *testing.T) {
+	t.Parallel()
+	mock := NewMockTransport()
+	mock.On(http.MethodGet, "").Delay(time.Minute).Respond(http.StatusOK, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://svc/slow", nil)
+	require.NoError(t, err)
+	_, err = mock.RoundTrip(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestBodyMatchers(t *testing.T) {
+	t.Parallel()
+	assert.True(t, BodyContains("needle")([]byte("haystack with needle")))
+	assert.False(t, BodyContains("needle")([]byte("haystack")))
+	assert.True(t, BodyEquals("exact")([]byte("exact")))
+	assert.True(t, BodyJSON(map[string]interface{}{"a": 1, "b": []int{2}})([]byte(`{"b":[2],"a":1}`)))
+	assert.False(t, BodyJSON(map[string]int{"a": 1})([]byte(`{"a":2}`)))
+	assert.False(t, BodyJSON(map[string]int{"a": 1})([]byte(`not json`)))
+}
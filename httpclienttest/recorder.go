@@ -0,0 +1,325 @@
+package httpclienttest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Redacted replaces secret header and query values in fixtures.
+const Redacted = "REDACTED"
+
+// ErrNoInteraction is returned in replay mode for requests without a recorded interaction.
+var ErrNoInteraction = errors.New("httpclienttest: no recorded interaction")
+
+// defaultRedactHeaders are always redacted from fixtures.
+var defaultRedactHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key",
+}
+
+// RecorderMode defines whether a Recorder records or replays interactions.
+type RecorderMode int
+
+const (
+	// ModeReplay answers requests from the fixture file without network access.
+	ModeReplay RecorderMode = iota
+	// ModeRecord sends requests to the real transport and records them.
+	ModeRecord
+	// ModeReplayOrRecord replays the fixture file if it exists and records it otherwise.
+	ModeReplayOrRecord
+)
+
+// RecorderOptions configures a Recorder.
+type RecorderOptions struct {
+	// Mode selects recording or replay (default: ModeReplay)
+	Mode RecorderMode
+
+	// Transport sends requests while recording (default: http.DefaultTransport)
+	Transport http.RoundTripper
+
+	// RedactHeaders are header names redacted in addition to Authorization,
+	// Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key
+	RedactHeaders []string
+
+	// RedactQueryParams are query parameters whose values are redacted, e.g. "api_key"
+	RedactQueryParams []string
+
+	// Sanitize edits every interaction before it is saved, e.g. to mask body fields.
+	// In replay it is applied to the incoming request (without a response) before matching
+	Sanitize func(*Interaction)
+}
+
+// Interaction is a recorded request/response pair, one per attempt.
+type Interaction struct {
+	Request  RecordedRequest   `json:"request"`
+	Response *RecordedResponse `json:"response,omitempty"`
+	// Error is the transport error of the attempt, if any
+	Error string `json:"error,omitempty"`
+}
+
+// RecordedRequest is the recorded part of a request.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the recorded part of a response. Bodies that aren't
+// valid UTF-8 are stored in BodyBase64.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// fixture is the content of a fixture file.
+type fixture struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper for Config.Transport that records real interactions
+// to a fixture file or replays them. As the transport of the client it sees every attempt,
+// so retries, the circuit breaker and middlewares behave in replay as they did when recording.
+type Recorder struct {
+	path    string
+	mode    RecorderMode
+	options RecorderOptions
+	headers map[string]bool // canonical names of redacted headers
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// NewRecorder creates a recorder for the fixture file. In replay modes an existing
+// fixture is loaded; ModeReplay fails when it is missing.
+func NewRecorder(path string, options RecorderOptions) (*Recorder, error) {
+	r := &Recorder{
+		path:    path,
+		mode:    options.Mode,
+		options: options,
+		headers: make(map[string]bool),
+	}
+	if r.options.Transport == nil {
+		r.options.Transport = http.DefaultTransport
+	}
+	for _, names := range [][]string{defaultRedactHeaders, options.RedactHeaders} {
+		for _, name := range names {
+			r.headers[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+
+	if r.mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && r.mode == ModeReplayOrRecord {
+		r.mode = ModeRecord
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("decode fixture %s: %w", path, err)
+	}
+	r.mode = ModeReplay
+	r.interactions = f.Interactions
+	r.used = make([]bool, len(f.Interactions))
+	return r, nil
+}
+
+// Recording reports whether the recorder records interactions.
+func (r *Recorder) Recording() bool {
+	return r.mode == ModeRecord
+}
+
+// RoundTrip records or replays the request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := r.recordRequest(req, body)
+
+	if r.mode == ModeReplay {
+		// Requests are matched as they were saved
+		if r.options.Sanitize != nil {
+			probe := &Interaction{Request: recorded}
+			r.options.Sanitize(probe)
+			recorded = probe.Request
+		}
+		return r.replay(req, recorded)
+	}
+	return r.record(req, body, recorded)
+}
+
+// recordRequest returns the sanitized request as stored in fixtures.
+func (r *Recorder) recordRequest(req *http.Request, body []byte) RecordedRequest {
+	u := *req.URL
+	if len(r.options.RedactQueryParams) > 0 {
+		query := u.Query()
+		for _, param := range r.options.RedactQueryParams {
+			if query.Has(param) {
+				query.Set(param, Redacted)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return RecordedRequest{
+		Method: req.Method,
+		URL:    u.String(),
+		Header: r.redactHeader(req.Header),
+		Body:   string(body),
+	}
+}
+
+// redactHeader returns a copy of the header with secret values redacted.
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	redacted := header.Clone()
+	for name := range redacted {
+		if r.headers[http.CanonicalHeaderKey(name)] {
+			redacted[name] = []string{Redacted}
+		}
+	}
+	return redacted
+}
+
+// replay answers with the first unused interaction of the same method, URL and body.
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	var interaction *Interaction
+	for i, candidate := range r.interactions {
+		if r.used[i] || !sameRequest(candidate.Request, recorded) {
+			continue
+		}
+		r.used[i] = true
+		interaction = candidate
+		break
+	}
+	r.mu.Unlock()
+
+	if interaction == nil {
+		return nil, fmt.Errorf("%w for %s %s in %s", ErrNoInteraction, req.Method, recorded.URL, r.path)
+	}
+	if interaction.Response == nil {
+		return nil, errors.New(interaction.Error)
+	}
+
+	resp := interaction.Response
+	body := []byte(resp.Body)
+	if resp.BodyBase64 != "" {
+		decoded, err := base64.StdEncoding.DecodeString(resp.BodyBase64)
+		if err != nil {
+			return nil, fmt.Errorf("decode recorded body: %w", err)
+		}
+		body = decoded
+	}
+	header := resp.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// sameRequest reports whether a request matches a recorded one; headers are ignored.
+func sameRequest(recorded, req RecordedRequest) bool {
+	return strings.EqualFold(recorded.Method, req.Method) && recorded.URL == req.URL && recorded.Body == req.Body
+}
+
+// record sends the request with the real transport and stores the interaction.
+func (r *Recorder) record(req *http.Request, body []byte, recorded RecordedRequest) (*http.Response, error) {
+	outgoing := req.Clone(req.Context())
+	if req.Body != nil {
+		outgoing.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	interaction := &Interaction{Request: recorded}
+	resp, err := r.options.Transport.RoundTrip(outgoing)
+	if err != nil {
+		interaction.Error = err.Error()
+		r.add(interaction)
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction.Response = &RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     r.redactHeader(resp.Header),
+	}
+	if utf8.Valid(respBody) {
+		interaction.Response.Body = string(respBody)
+	} else {
+		interaction.Response.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+	}
+	r.add(interaction)
+	return resp, nil
+}
+
+// add appends a sanitized interaction.
+func (r *Recorder) add(interaction *Interaction) {
+	if r.options.Sanitize != nil {
+		r.options.Sanitize(interaction)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interaction)
+}
+
+// Save writes the recorded interactions to the fixture file. It does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(fixture{Interactions: r.interactions}, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encode fixture: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("create fixture directory: %w", err)
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o600)
+}
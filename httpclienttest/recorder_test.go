@@ -0,0 +1,121 @@
+package httpclienttest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpclient "github.com/rurick/http-client"
+)
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write([]byte(`{"id":` + r.URL.Query().Get("id") + `}`))
+	}))
+	path := filepath.Join(t.TempDir(), "fixtures", "orders.json")
+
+	newClient := func(recorder *Recorder) *httpclient.Client {
+		return httpclient.New(httpclient.Config{
+			Transport:    recorder,
+			RetryEnabled: true,
+			RetryConfig:  httpclient.RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		}, "test-recorder")
+	}
+	get := func(client *httpclient.Client) (int, string) {
+		resp, err := client.Get(context.Background(), server.URL+"/orders?id=7&api_key=secret",
+			httpclient.WithBearerToken("secret"))
+		require.NoError(t, err)
+		return resp.StatusCode, readAll(t, resp)
+	}
+
+	recorder, err := NewRecorder(path, RecorderOptions{Mode: ModeReplayOrRecord, RedactQueryParams: []string{"api_key"}})
+	require.NoError(t, err)
+	assert.True(t, recorder.Recording())
+	client := newClient(recorder)
+	status, body := get(client)
+	require.NoError(t, client.Close())
+	require.NoError(t, recorder.Save())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"id":7}`, body)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.Contains(t, string(data), Redacted)
+
+	// Replay needs no server and sees the same attempts, including the retried 503
+	server.Close()
+	recorder, err = NewRecorder(path, RecorderOptions{Mode: ModeReplayOrRecord, RedactQueryParams: []string{"api_key"}})
+	require.NoError(t, err)
+	assert.False(t, recorder.Recording())
+	client = newClient(recorder)
+	defer client.Close()
+	status, body = get(client)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"id":7}`, body)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// Every interaction is replayed once
+	_, err = client.Get(context.Background(), server.URL+"/orders?id=7&api_key=other")
+	assert.ErrorIs(t, err, ErrNoInteraction)
+}
+
+func TestRecorder_SanitizeAndErrors(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "login.json")
+	mock := NewMockTransport()
+	mock.On(http.MethodPost, "/login").Respond(http.StatusOK, "\xff\xfe")
+	mock.On(http.MethodGet, "/down").ReturnError(assert.AnError)
+
+	sanitize := func(i *Interaction) {
+		i.Request.Body = strings.ReplaceAll(i.Request.Body, "hunter2", Redacted)
+	}
+	recorder, err := NewRecorder(path, RecorderOptions{Mode: ModeRecord, Transport: mock, Sanitize: sanitize})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "http://auth/login", strings.NewReader("password=hunter2"))
+	require.NoError(t, err)
+	resp, err := recorder.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, "\xff\xfe", readAll(t, resp))
+
+	req, err = http.NewRequest(http.MethodGet, "http://auth/down", nil)
+	require.NoError(t, err)
+	_, err = recorder.RoundTrip(req)
+	assert.ErrorIs(t, err, assert.AnError)
+	require.NoError(t, recorder.Save())
+
+	replayer, err := NewRecorder(path, RecorderOptions{Sanitize: sanitize})
+	require.NoError(t, err)
+
+	req, err = http.NewRequest(http.MethodPost, "http://auth/login", strings.NewReader("password=hunter2"))
+	require.NoError(t, err)
+	resp, err = replayer.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, "\xff\xfe", readAll(t, resp))
+
+	req, err = http.NewRequest(http.MethodGet, "http://auth/down", nil)
+	require.NoError(t, err)
+	_, err = replayer.RoundTrip(req)
+	require.Error(t, err)
+	assert.Equal(t, assert.AnError.Error(), err.Error())
+
+	_, err = NewRecorder(filepath.Join(t.TempDir(), "missing.json"), RecorderOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// idempotencyKeyHeader is the header carrying the idempotency key of a request.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyReplayHeaders are the response headers servers set to "true" when they return the
+// stored result of an earlier request with the same idempotency key instead of executing it.
+var idempotencyReplayHeaders = []string{
+	"Idempotency-Replayed",
+	"Idempotent-Replayed",
+	"X-Idempotency-Replayed",
+	"X-Idempotent-Replayed",
+}
+
+// IdempotencyStatus tells how the server handled a request with an idempotency key.
+type IdempotencyStatus string
+
+const (
+	// IdempotencyNone means the request had no Idempotency-Key and the response is no replay
+	IdempotencyNone IdempotencyStatus = ""
+	// IdempotencyExecuted means the server executed the request
+	IdempotencyExecuted IdempotencyStatus = "executed"
+	// IdempotencyReplayed means the server returned the stored result of an earlier execution
+	IdempotencyReplayed IdempotencyStatus = "replayed"
+	// IdempotencyInProgress means the server still processes a request with the same key
+	// (409 Conflict or 425 Too Early)
+	IdempotencyInProgress IdempotencyStatus = "in_progress"
+	// IdempotencyKeyReused means the key was already used with a different request
+	// (422 Unprocessable Entity)
+	IdempotencyKeyReused IdempotencyStatus = "key_reused"
+)
+
+// IdempotencyInfo describes how the server handled a request with an idempotency key.
+type IdempotencyInfo struct {
+	// Key is the Idempotency-Key of the request
+	Key string
+	// Status tells whether the server executed or replayed the request
+	Status IdempotencyStatus
+	// Attempts is the number of the attempt that got the response; the request was retried when > 1
+	Attempts int
+}
+
+// Replayed reports whether the server returned a stored result instead of executing the request.
+func (i IdempotencyInfo) Replayed() bool {
+	return i.Status == IdempotencyReplayed
+}
+
+// IdempotencyFromResponse tells whether the server executed or replayed the request of the
+// response, e.g. to tell a payment charged by a retry from one charged by an earlier attempt
+// that timed out: a retried request (Attempts > 1) with a replayed response was executed by
+// the earlier attempt.
+func IdempotencyFromResponse(resp *http.Response) IdempotencyInfo {
+	if resp == nil {
+		return IdempotencyInfo{}
+	}
+	info := IdempotencyInfo{Attempts: 1}
+	if resp.Request != nil {
+		info.Key = resp.Request.Header.Get(idempotencyKeyHeader)
+		info.Attempts = attemptFromContext(resp.Request.Context())
+	}
+
+	switch {
+	case isReplayedResponse(resp.Header):
+		info.Status = IdempotencyReplayed
+	case info.Key == "":
+		info.Status = IdempotencyNone
+	case resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusTooEarly:
+		info.Status = IdempotencyInProgress
+	case resp.StatusCode == http.StatusUnprocessableEntity:
+		info.Status = IdempotencyKeyReused
+	default:
+		info.Status = IdempotencyExecuted
+	}
+	return info
+}
+
+// Idempotency tells whether the server executed or replayed the request, see IdempotencyFromResponse.
+func (r *Response) Idempotency() IdempotencyInfo {
+	return IdempotencyFromResponse(r.Response)
+}
+
+// isReplayedResponse checks the replay headers of the response.
+func isReplayedResponse(header http.Header) bool {
+	for _, name := range idempotencyReplayHeaders {
+		if replayed, err := strconv.ParseBool(header.Get(name)); err == nil && replayed {
+			return true
+		}
+	}
+	return false
+}
+
+// isInProgressRetryable checks if the status says the server still processes an earlier
+// request with the same idempotency key, which RetryConfig.RetryInProgress retries.
+func (rc RetryConfig) isInProgressRetryable(req *http.Request, status int) bool {
+	return rc.RetryInProgress && (status == http.StatusConflict || status == http.StatusTooEarly) &&
+		req.Header.Get(idempotencyKeyHeader) != ""
+}
+
+// attemptKey is the context key of the attempt number of retried requests.
+type attemptKey struct{}
+
+// withAttempt records the attempt number in the context of retries.
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	if attempt <= 1 {
+		return ctx
+	}
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// attemptFromContext returns the attempt number recorded by withAttempt, 1 for first attempts.
+func attemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
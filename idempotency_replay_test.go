@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newInProgressServer answers the first request with status and the next ones with a replay.
+func newInProgressServer(status int, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(http.StatusCreated)
+	}))
+}
+
+func TestIdempotency_RetryInProgress(t *testing.T) {
+	t.Parallel()
+	for _, status := range []int{http.StatusConflict, http.StatusTooEarly} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			t.Parallel()
+			var calls atomic.Int32
+			server := newInProgressServer(status, &calls)
+			defer server.Close()
+
+			client := New(Config{
+				RetryEnabled: true,
+				RetryConfig: RetryConfig{
+					MaxAttempts:     3,
+					BaseDelay:       time.Millisecond,
+					MaxDelay:        time.Millisecond,
+					RetryInProgress: true,
+				},
+			}, "test-idempotency-in-progress")
+			defer client.Close()
+
+			resp, err := client.Post(context.Background(), server.URL, strings.NewReader(`{"amount":100}`),
+				WithIdempotencyKey("charge-42"))
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+			assert.Equal(t, IdempotencyInfo{Key: "charge-42", Status: IdempotencyReplayed, Attempts: 2},
+				IdempotencyFromResponse(resp))
+			assert.Equal(t, int32(2), calls.Load())
+		})
+	}
+}
+
+func TestIdempotency_InProgressNotRetried(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	server := newInProgressServer(http.StatusConflict, &calls)
+	defer server.Close()
+
+	retryConfig := RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, RetryInProgress: true}
+	client := New(Config{RetryEnabled: true, RetryConfig: retryConfig}, "test-idempotency-no-key")
+	defer client.Close()
+
+	// Without an Idempotency-Key a 409 is a real conflict
+	resp, err := client.Post(context.Background(), server.URL, strings.NewReader("{}"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, IdempotencyInfo{Status: IdempotencyNone, Attempts: 1}, IdempotencyFromResponse(resp))
+
+	calls.Store(0)
+	noRetry := New(Config{RetryEnabled: true}, "test-idempotency-disabled")
+	defer noRetry.Close()
+	typed, err := noRetry.Execute(context.Background(), http.MethodPatch, server.URL, strings.NewReader("{}"),
+		WithIdempotencyKey("order-7"))
+	require.NoError(t, err)
+	_, _ = typed.Bytes()
+	info := typed.Idempotency()
+	assert.Equal(t, IdempotencyInProgress, info.Status)
+	assert.False(t, info.Replayed())
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyFromResponse(t *testing.T) {
+	t.Parallel()
+	keyed := httptest.NewRequest(http.MethodPost, "http://example.com/charges", nil)
+	keyed.Header.Set("Idempotency-Key", "k1")
+	retried := keyed.WithContext(withAttempt(context.Background(), 3))
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+		header string
+		want   IdempotencyInfo
+	}{
+		{"executed", keyed, http.StatusCreated, "", IdempotencyInfo{Key: "k1", Status: IdempotencyExecuted, Attempts: 1}},
+		{"replayed", keyed, http.StatusCreated, "Idempotency-Replayed", IdempotencyInfo{Key: "k1", Status: IdempotencyReplayed, Attempts: 1}},
+		{"x header", retried, http.StatusOK, "X-Idempotency-Replayed", IdempotencyInfo{Key: "k1", Status: IdempotencyReplayed, Attempts: 3}},
+		{"key reused", keyed, http.StatusUnprocessableEntity, "", IdempotencyInfo{Key: "k1", Status: IdempotencyKeyReused, Attempts: 1}},
+		{"too early", retried, http.StatusTooEarly, "", IdempotencyInfo{Key: "k1", Status: IdempotencyInProgress, Attempts: 3}},
+		{"no request", nil, http.StatusOK, "Idempotent-Replayed", IdempotencyInfo{Status: IdempotencyReplayed, Attempts: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Request: tt.req}
+			if tt.header != "" {
+				resp.Header.Set(tt.header, "true")
+			}
+			assert.Equal(t, tt.want, IdempotencyFromResponse(resp))
+		})
+	}
+
+	assert.Equal(t, IdempotencyInfo{}, IdempotencyFromResponse(nil))
+	falseReplay := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Idempotency-Replayed": {"false"}}, Request: keyed}
+	assert.Equal(t, IdempotencyExecuted, IdempotencyFromResponse(falseReplay).Status)
+}
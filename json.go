@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxErrorBodyBytes limits how much of a non-2xx response body is kept in HTTPError.
+const maxErrorBodyBytes = 64 * 1024
+
+// GetJSON executes a GET request and decodes the JSON response body into target.
+// Non-2xx responses are returned as *HTTPError, translated by Config.ErrorTranslator.
+// The body is always drained and closed.
+func (c *Client) GetJSON(ctx context.Context, url string, target interface{}, opts ...RequestOption) error {
+	opts = append([]RequestOption{WithAccept("application/json")}, opts...)
+	resp, err := c.Get(ctx, url, opts...)
+	if err != nil {
+		return err
+	}
+	return c.translateError(decodeJSONResponse(resp, target))
+}
+
+// PostJSON encodes body as JSON, executes a POST request and decodes the JSON response into target.
+// Non-2xx responses are returned as *HTTPError, translated by Config.ErrorTranslator.
+// The body is always drained and closed.
+func (c *Client) PostJSON(
+	ctx context.Context, url string, body interface{}, target interface{}, opts ...RequestOption,
+) error {
+	opts = append([]RequestOption{WithAccept("application/json"), WithJSONBody(body)}, opts...)
+	resp, err := c.Post(ctx, url, nil, opts...)
+	if err != nil {
+		return err
+	}
+	return c.translateError(decodeJSONResponse(resp, target))
+}
+
+// decodeJSONResponse checks the response status and decodes the JSON body into target.
+// A nil target only validates the status and discards the body.
+func decodeJSONResponse(resp *http.Response, target interface{}) error {
+	defer drainAndClose(resp.Body)
+
+	if err := statusError(resp); err != nil {
+		return err
+	}
+
+	if target == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	return nil
+}
+
+// statusError returns a *HTTPError with the start of the body for a non-2xx response.
+func statusError(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+	httpErr := &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Headers: resp.Header}
+	if resp.Request != nil {
+		httpErr = NewHTTPError(resp, resp.Request)
+	}
+	httpErr.Body, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return httpErr
+}
@@ -0,0 +1,555 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchema is a compiled JSON Schema used to validate responses. It supports the validation
+// keywords of draft 2020-12 that describe API payloads: type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, uniqueItems, minProperties, maxProperties,
+// minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// multipleOf, allOf, anyOf, oneOf, not, the OpenAPI nullable and local $ref to "#", "#/$defs/..."
+// or "#/definitions/...". Annotations such as format, title and description are ignored.
+type JSONSchema struct {
+	root *schemaNode
+	refs map[string]*schemaNode // by JSON pointer, filled while compiling
+}
+
+// SchemaViolation is a value that doesn't match its schema.
+type SchemaViolation struct {
+	// Path is the JSON pointer of the value, e.g. "/items/0/id"; empty for the whole document
+	Path string
+	// Message describes the violation, e.g. "expected integer, got string"
+	Message string
+}
+
+// String formats the violation as "path: message".
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + v.Message
+}
+
+// schemaNode is a compiled schema or subschema.
+type schemaNode struct {
+	pointer string
+	always  *bool // set for the boolean schemas true and false
+	ref     string
+
+	types    []string
+	nullable bool
+	enum     []any
+	constant any
+	hasConst bool
+
+	properties           map[string]*schemaNode
+	required             []string
+	additionalProperties *schemaNode
+	minProperties        *int
+	maxProperties        *int
+
+	items       *schemaNode
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	allOf []*schemaNode
+	anyOf []*schemaNode
+	oneOf []*schemaNode
+	not   *schemaNode
+}
+
+// CompileJSONSchema parses a JSON Schema document.
+func CompileJSONSchema(schema []byte) (*JSONSchema, error) {
+	var doc any
+	if err := decodeJSONNumbers(schema, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	s := &JSONSchema{refs: make(map[string]*schemaNode)}
+	root, err := s.compile(doc, "")
+	if err != nil {
+		return nil, err
+	}
+	s.root = root
+	for _, node := range s.refs {
+		if err := s.checkRefs(node); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// MustCompileJSONSchema is like CompileJSONSchema but panics on an invalid schema.
+// It simplifies initialization of package-level schemas.
+func MustCompileJSONSchema(schema []byte) *JSONSchema {
+	s, err := CompileJSONSchema(schema)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// compile compiles the schema at the JSON pointer.
+func (s *JSONSchema) compile(doc any, pointer string) (*schemaNode, error) {
+	node := &schemaNode{pointer: pointer}
+	s.refs["#"+pointer] = node
+	if b, ok := doc.(bool); ok {
+		node.always = &b
+		return node, nil
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid JSON schema at %q: expected object or boolean", "#"+pointer)
+	}
+
+	var err error
+	sub := func(key string) (*schemaNode, error) {
+		value, ok := obj[key]
+		if !ok {
+			return nil, nil
+		}
+		return s.compile(value, pointer+"/"+escapePointer(key))
+	}
+	subs := func(key string) ([]*schemaNode, error) {
+		value, ok := obj[key]
+		if !ok {
+			return nil, nil
+		}
+		list, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid JSON schema at %q: %s must be an array", "#"+pointer, key)
+		}
+		nodes := make([]*schemaNode, len(list))
+		for i, item := range list {
+			if nodes[i], err = s.compile(item, pointer+"/"+key+"/"+strconv.Itoa(i)); err != nil {
+				return nil, err
+			}
+		}
+		return nodes, nil
+	}
+	number := func(key string) (*float64, error) {
+		value, ok := obj[key]
+		if !ok {
+			return nil, nil
+		}
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("invalid JSON schema at %q: %s must be a number", "#"+pointer, key)
+		}
+		f, err := n.Float64()
+		return &f, err
+	}
+	count := func(key string) (*int, error) {
+		f, err := number(key)
+		if f == nil || err != nil {
+			return nil, err
+		}
+		n := int(*f)
+		return &n, nil
+	}
+
+	for _, key := range []string{"$defs", "definitions"} {
+		defs, _ := obj[key].(map[string]any)
+		for name, def := range defs {
+			if _, err := s.compile(def, pointer+"/"+key+"/"+escapePointer(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if ref, ok := obj["$ref"].(string); ok {
+		node.ref = ref
+	}
+
+	switch t := obj["type"].(type) {
+	case string:
+		node.types = []string{t}
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				node.types = append(node.types, name)
+			}
+		}
+	}
+	node.nullable, _ = obj["nullable"].(bool)
+	if enum, ok := obj["enum"].([]any); ok {
+		for _, value := range enum {
+			node.enum = append(node.enum, normalizeJSON(value))
+		}
+	}
+	if value, ok := obj["const"]; ok {
+		node.constant, node.hasConst = normalizeJSON(value), true
+	}
+
+	if props, ok := obj["properties"].(map[string]any); ok {
+		node.properties = make(map[string]*schemaNode, len(props))
+		for name, prop := range props {
+			if node.properties[name], err = s.compile(prop, pointer+"/properties/"+escapePointer(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if required, ok := obj["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				node.required = append(node.required, name)
+			}
+		}
+	}
+	if node.additionalProperties, err = sub("additionalProperties"); err != nil {
+		return nil, err
+	}
+	if node.items, err = sub("items"); err != nil {
+		return nil, err
+	}
+	if node.not, err = sub("not"); err != nil {
+		return nil, err
+	}
+	if node.allOf, err = subs("allOf"); err != nil {
+		return nil, err
+	}
+	if node.anyOf, err = subs("anyOf"); err != nil {
+		return nil, err
+	}
+	if node.oneOf, err = subs("oneOf"); err != nil {
+		return nil, err
+	}
+	node.uniqueItems, _ = obj["uniqueItems"].(bool)
+
+	for key, target := range map[string]**int{
+		"minProperties": &node.minProperties, "maxProperties": &node.maxProperties,
+		"minItems": &node.minItems, "maxItems": &node.maxItems,
+		"minLength": &node.minLength, "maxLength": &node.maxLength,
+	} {
+		if *target, err = count(key); err != nil {
+			return nil, err
+		}
+	}
+	for key, target := range map[string]**float64{
+		"minimum": &node.minimum, "maximum": &node.maximum,
+		"exclusiveMinimum": &node.exclusiveMinimum, "exclusiveMaximum": &node.exclusiveMaximum,
+		"multipleOf": &node.multipleOf,
+	} {
+		if *target, err = number(key); err != nil {
+			return nil, err
+		}
+	}
+	if pattern, ok := obj["pattern"].(string); ok {
+		if node.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid JSON schema at %q: pattern: %w", "#"+pointer, err)
+		}
+	}
+	return node, nil
+}
+
+// checkRefs reports a $ref of the node that can't be resolved.
+func (s *JSONSchema) checkRefs(node *schemaNode) error {
+	if node.ref != "" && s.refs[node.ref] == nil {
+		return fmt.Errorf("invalid JSON schema at %q: unresolved $ref %q", "#"+node.pointer, node.ref)
+	}
+	return nil
+}
+
+// Validate checks the JSON document against the schema and returns the violations, sorted by
+// path; none if the document is valid.
+func (s *JSONSchema) Validate(document []byte) []SchemaViolation {
+	var value any
+	if err := decodeJSONNumbers(document, &value); err != nil {
+		return []SchemaViolation{{Message: "invalid JSON: " + err.Error()}}
+	}
+	return s.ValidateValue(value)
+}
+
+// ValidateValue checks a decoded JSON value, as produced by encoding/json, against the schema.
+func (s *JSONSchema) ValidateValue(value any) []SchemaViolation {
+	var violations []SchemaViolation
+	s.validate(s.root, normalizeJSON(value), "", &violations, 0)
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	return violations
+}
+
+// maxSchemaDepth stops recursive $ref schemas that never consume the value.
+const maxSchemaDepth = 256
+
+// validate appends the violations of the value at the JSON pointer path.
+func (s *JSONSchema) validate(node *schemaNode, value any, path string, violations *[]SchemaViolation, depth int) {
+	report := func(format string, args ...any) {
+		*violations = append(*violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if depth > maxSchemaDepth {
+		report("schema nesting is too deep")
+		return
+	}
+	if node.always != nil {
+		if !*node.always {
+			report("no value is allowed")
+		}
+		return
+	}
+	if node.ref != "" {
+		s.validate(s.refs[node.ref], value, path, violations, depth+1)
+	}
+	if value == nil && node.nullable {
+		return
+	}
+
+	if len(node.types) > 0 && !matchesType(node.types, value) {
+		report("expected %s, got %s", strings.Join(node.types, " or "), jsonType(value))
+		return
+	}
+	if node.enum != nil && !containsJSON(node.enum, value) {
+		report("value %s is not one of the allowed values", jsonText(value))
+	}
+	if node.hasConst && !reflect.DeepEqual(node.constant, value) {
+		report("expected %s, got %s", jsonText(node.constant), jsonText(value))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		s.validateObject(node, v, path, violations, depth)
+	case []any:
+		s.validateArray(node, v, path, violations, depth)
+	case string:
+		length := utf8.RuneCountInString(v)
+		if node.minLength != nil && length < *node.minLength {
+			report("length %d is less than %d", length, *node.minLength)
+		}
+		if node.maxLength != nil && length > *node.maxLength {
+			report("length %d is greater than %d", length, *node.maxLength)
+		}
+		if node.pattern != nil && !node.pattern.MatchString(v) {
+			report("does not match pattern %q", node.pattern.String())
+		}
+	case float64:
+		if node.minimum != nil && v < *node.minimum {
+			report("%v is less than the minimum %v", v, *node.minimum)
+		}
+		if node.maximum != nil && v > *node.maximum {
+			report("%v is greater than the maximum %v", v, *node.maximum)
+		}
+		if node.exclusiveMinimum != nil && v <= *node.exclusiveMinimum {
+			report("%v is not greater than %v", v, *node.exclusiveMinimum)
+		}
+		if node.exclusiveMaximum != nil && v >= *node.exclusiveMaximum {
+			report("%v is not less than %v", v, *node.exclusiveMaximum)
+		}
+		if node.multipleOf != nil && *node.multipleOf > 0 {
+			if q := v / *node.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+				report("%v is not a multiple of %v", v, *node.multipleOf)
+			}
+		}
+	}
+
+	for _, sub := range node.allOf {
+		s.validate(sub, value, path, violations, depth+1)
+	}
+	if len(node.anyOf) > 0 && s.countMatches(node.anyOf, value, path, depth) == 0 {
+		report("does not match any schema of anyOf")
+	}
+	if len(node.oneOf) > 0 {
+		if n := s.countMatches(node.oneOf, value, path, depth); n != 1 {
+			report("matches %d schemas of oneOf, expected exactly 1", n)
+		}
+	}
+	if node.not != nil && s.countMatches([]*schemaNode{node.not}, value, path, depth) == 1 {
+		report("must not match the schema of not")
+	}
+}
+
+// validateObject checks the keywords of objects.
+func (s *JSONSchema) validateObject(
+	node *schemaNode, obj map[string]any, path string, violations *[]SchemaViolation, depth int,
+) {
+	for _, name := range node.required {
+		if _, ok := obj[name]; !ok {
+			*violations = append(*violations, SchemaViolation{
+				Path:    path + "/" + escapePointer(name),
+				Message: "required property is missing",
+			})
+		}
+	}
+	if node.minProperties != nil && len(obj) < *node.minProperties {
+		*violations = append(*violations, SchemaViolation{
+			Path: path, Message: fmt.Sprintf("has %d properties, expected at least %d", len(obj), *node.minProperties),
+		})
+	}
+	if node.maxProperties != nil && len(obj) > *node.maxProperties {
+		*violations = append(*violations, SchemaViolation{
+			Path: path, Message: fmt.Sprintf("has %d properties, expected at most %d", len(obj), *node.maxProperties),
+		})
+	}
+	for name, value := range obj {
+		propPath := path + "/" + escapePointer(name)
+		if prop, ok := node.properties[name]; ok {
+			s.validate(prop, value, propPath, violations, depth+1)
+		} else if node.additionalProperties != nil {
+			if a := node.additionalProperties.always; a != nil && !*a {
+				*violations = append(*violations, SchemaViolation{Path: propPath, Message: "additional property is not allowed"})
+				continue
+			}
+			s.validate(node.additionalProperties, value, propPath, violations, depth+1)
+		}
+	}
+}
+
+// validateArray checks the keywords of arrays.
+func (s *JSONSchema) validateArray(
+	node *schemaNode, items []any, path string, violations *[]SchemaViolation, depth int,
+) {
+	if node.minItems != nil && len(items) < *node.minItems {
+		*violations = append(*violations, SchemaViolation{
+			Path: path, Message: fmt.Sprintf("has %d items, expected at least %d", len(items), *node.minItems),
+		})
+	}
+	if node.maxItems != nil && len(items) > *node.maxItems {
+		*violations = append(*violations, SchemaViolation{
+			Path: path, Message: fmt.Sprintf("has %d items, expected at most %d", len(items), *node.maxItems),
+		})
+	}
+	if node.uniqueItems {
+		for i := range items {
+			if containsJSON(items[:i], items[i]) {
+				*violations = append(*violations, SchemaViolation{
+					Path: path + "/" + strconv.Itoa(i), Message: "duplicates an earlier item",
+				})
+			}
+		}
+	}
+	if node.items != nil {
+		for i, item := range items {
+			s.validate(node.items, item, path+"/"+strconv.Itoa(i), violations, depth+1)
+		}
+	}
+}
+
+// countMatches returns the number of schemas the value matches.
+func (s *JSONSchema) countMatches(nodes []*schemaNode, value any, path string, depth int) int {
+	matches := 0
+	for _, node := range nodes {
+		var violations []SchemaViolation
+		s.validate(node, value, path, &violations, depth+1)
+		if len(violations) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// matchesType checks if the value has one of the JSON Schema types.
+func matchesType(types []string, value any) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON Schema type of a normalized value; whole numbers are "integer".
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// containsJSON checks if the list contains a value equal to the normalized value.
+func containsJSON(list []any, value any) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonText formats a value as JSON for messages.
+func jsonText(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// normalizeJSON converts numbers to float64 so that equal JSON values compare equal.
+func normalizeJSON(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = normalizeJSON(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = normalizeJSON(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// decodeJSONNumbers decodes a single JSON document keeping numbers as json.Number.
+func decodeJSONNumbers(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the JSON document")
+	}
+	return nil
+}
+
+// escapePointer escapes a JSON pointer token (RFC 6901).
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userSchema = MustCompileJSONSchema([]byte(`{
+	"type": "object",
+	"required": ["id", "name", "tags"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "minLength": 1, "maxLength": 10},
+		"email": {"type": ["string", "null"], "pattern": "@"},
+		"role": {"enum": ["admin", "user"]},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+		"address": {"$ref": "#/$defs/address"},
+		"score": {"type": "number", "exclusiveMaximum": 1, "multipleOf": 0.25}
+	},
+	"$defs": {
+		"address": {
+			"type": "object",
+			"required": ["city"],
+			"properties": {"city": {"type": "string"}}
+		}
+	}
+}`))
+
+func TestJSONSchema_Valid(t *testing.T) {
+	t.Parallel()
+	violations := userSchema.Validate([]byte(`{
+		"id": 7, "name": "Ann", "email": null, "role": "admin",
+		"tags": ["a", "b"], "address": {"city": "Riga"}, "score": 0.75
+	}`))
+	assert.Empty(t, violations)
+}
+
+func TestJSONSchema_Violations(t *testing.T) {
+	t.Parallel()
+	violations := userSchema.Validate([]byte(`{
+		"id": 1.5, "name": "", "email": "ann", "role": "guest",
+		"tags": ["a", "a", 3, "d"], "address": {}, "score": 1, "extra": true
+	}`))
+	assert.Equal(t, []SchemaViolation{
+		{Path: "/address/city", Message: "required property is missing"},
+		{Path: "/email", Message: `does not match pattern "@"`},
+		{Path: "/extra", Message: "additional property is not allowed"},
+		{Path: "/id", Message: "expected integer, got number"},
+		{Path: "/name", Message: "length 0 is less than 1"},
+		{Path: "/role", Message: `value "guest" is not one of the allowed values`},
+		{Path: "/score", Message: "1 is not less than 1"},
+		{Path: "/tags", Message: "has 4 items, expected at most 3"},
+		{Path: "/tags/1", Message: "duplicates an earlier item"},
+		{Path: "/tags/2", Message: "expected string, got integer"},
+	}, violations)
+
+	assert.Equal(t, []SchemaViolation{{Message: "expected object, got array"}}, userSchema.Validate([]byte(`[]`)))
+	assert.Equal(t, "/: expected object, got array", userSchema.Validate([]byte(`[]`))[0].String())
+	assert.Contains(t, userSchema.Validate([]byte(`{`))[0].Message, "invalid JSON")
+}
+
+func TestJSONSchema_Combinators(t *testing.T) {
+	t.Parallel()
+	schema := MustCompileJSONSchema([]byte(`{
+		"oneOf": [
+			{"type": "integer"},
+			{"type": "number", "minimum": 0}
+		],
+		"not": {"const": 42}
+	}`))
+	assert.Empty(t, schema.Validate([]byte(`-3`)))
+	assert.Equal(t, []SchemaViolation{{Message: "matches 2 schemas of oneOf, expected exactly 1"}}, schema.Validate([]byte(`3`)))
+	assert.Equal(t, []SchemaViolation{{Message: "does not match any schema of anyOf"}},
+		MustCompileJSONSchema([]byte(`{"anyOf": [{"type": "string"}, {"type": "null"}]}`)).Validate([]byte(`1`)))
+
+	nullable := MustCompileJSONSchema([]byte(`{"type": "string", "nullable": true}`))
+	assert.Empty(t, nullable.Validate([]byte(`null`)))
+}
+
+func TestCompileJSONSchema_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, schema := range []string{
+		`{"type": "object"`,
+		`[]`,
+		`{"minimum": "1"}`,
+		`{"pattern": "("}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"allOf": {}}`,
+	} {
+		_, err := CompileJSONSchema([]byte(schema))
+		require.Error(t, err, schema)
+	}
+}
@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTooManyElements is returned when a streamed JSON array exceeds JSONArrayLimits.MaxElements.
+var ErrTooManyElements = errors.New("JSON array exceeds the element limit")
+
+// JSONArrayLimits guards the incremental decoding of a JSON array.
+type JSONArrayLimits struct {
+	// MaxElements stops decoding with ErrTooManyElements after this many elements (0 means no limit)
+	MaxElements int
+	// MaxBytes stops decoding with *BodyTooLargeError once more bytes are read (0 means no limit)
+	MaxBytes int64
+}
+
+// GetJSONStream executes a GET request and passes a decoder reading the response body to fn,
+// so that large documents can be decoded piece by piece instead of being read into memory.
+// Non-2xx responses are returned as *HTTPError and the error returned by fn is returned
+// as is. The body is always closed.
+func (c *Client) GetJSONStream(
+	ctx context.Context, url string, fn func(dec *json.Decoder) error, opts ...RequestOption,
+) error {
+	return c.streamJSON(ctx, url, opts, func(body io.Reader, _ string) error {
+		return fn(json.NewDecoder(body))
+	})
+}
+
+// GetJSONArray executes a GET request whose response is a JSON array and calls fn with each
+// element as soon as it is decoded, e.g. for exports too large to hold in memory.
+// Returning an error from fn stops decoding and returns that error.
+func GetJSONArray[T any](
+	ctx context.Context, c *Client, url string, limits JSONArrayLimits, fn func(T) error, opts ...RequestOption,
+) error {
+	return c.streamJSON(ctx, url, opts, func(body io.Reader, requestURL string) error {
+		if limits.MaxBytes > 0 {
+			body = &limitedBody{
+				ReadCloser: io.NopCloser(body),
+				limit:      limits.MaxBytes,
+				remaining:  limits.MaxBytes,
+				url:        requestURL,
+			}
+		}
+		return decodeJSONArray(json.NewDecoder(body), requestURL, limits, fn)
+	})
+}
+
+// DecodeJSONArray decodes the next value of dec, a JSON array, element by element and calls
+// fn with each of them. A null value is an empty array. The decoder may be positioned inside
+// a larger document, e.g. at the value of an "items" field. MaxBytes is checked against the
+// decoder offset after each element; GetJSONArray also limits the bytes read from the body.
+func DecodeJSONArray[T any](dec *json.Decoder, limits JSONArrayLimits, fn func(T) error) error {
+	return decodeJSONArray(dec, "", limits, fn)
+}
+
+// streamJSON sends the GET request and calls decode with the body of a 2xx response.
+func (c *Client) streamJSON(
+	ctx context.Context, url string, opts []RequestOption, decode func(body io.Reader, url string) error,
+) error {
+	opts = append([]RequestOption{WithAccept("application/json")}, opts...)
+	resp, err := c.Get(ctx, url, opts...)
+	if err != nil {
+		return err
+	}
+	// Unlike GetJSON only the start of an unread rest is drained: a stream stopped early
+	// may leave hundreds of megabytes behind
+	defer DiscardBody(resp)
+
+	if err := statusError(resp); err != nil {
+		return err
+	}
+	requestURL := url
+	if resp.Request != nil {
+		requestURL = resp.Request.URL.String()
+	}
+	return decode(resp.Body, requestURL)
+}
+
+// decodeJSONArray implements DecodeJSONArray, naming url in *BodyTooLargeError.
+func decodeJSONArray[T any](dec *json.Decoder, url string, limits JSONArrayLimits, fn func(T) error) error {
+	start := dec.InputOffset()
+	token, err := dec.Token()
+	if err == io.EOF || (err == nil && token == nil) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode JSON array: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("failed to decode JSON array: unexpected %v at offset %d", token, dec.InputOffset())
+	}
+
+	count := 0
+	for dec.More() {
+		count++
+		if limits.MaxElements > 0 && count > limits.MaxElements {
+			return fmt.Errorf("%w of %d", ErrTooManyElements, limits.MaxElements)
+		}
+		var element T
+		if err := dec.Decode(&element); err != nil {
+			return fmt.Errorf("failed to decode JSON array element %d: %w", count-1, err)
+		}
+		if limits.MaxBytes > 0 && dec.InputOffset()-start > limits.MaxBytes {
+			return &BodyTooLargeError{Limit: limits.MaxBytes, URL: url}
+		}
+		if err := fn(element); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to decode JSON array: %w", err)
+	}
+	return nil
+}
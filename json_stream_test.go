@@ -0,0 +1,153 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonArrayServer streams a JSON array of n users.
+func jsonArrayServer(n int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("["))
+		for i := range n {
+			if i > 0 {
+				_, _ = w.Write([]byte(","))
+			}
+			_, _ = fmt.Fprintf(w, `{"id":%d,"name":"user-%d"}`, i, i)
+		}
+		_, _ = w.Write([]byte("]"))
+	}))
+}
+
+func TestGetJSONArray_DecodesElementsIncrementally(t *testing.T) {
+	t.Parallel()
+	server := jsonArrayServer(10000)
+	defer server.Close()
+
+	client := New(Config{}, "test-json-array")
+	defer client.Close()
+
+	var count int
+	err := GetJSONArray(context.Background(), client, server.URL, JSONArrayLimits{}, func(user jsonTestUser) error {
+		assert.Equal(t, count, user.ID)
+		count++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 10000, count)
+}
+
+func TestGetJSONArray_Limits(t *testing.T) {
+	t.Parallel()
+	server := jsonArrayServer(100)
+	defer server.Close()
+
+	client := New(Config{}, "test-json-array-limits")
+	defer client.Close()
+	ctx := context.Background()
+	skip := func(jsonTestUser) error { return nil }
+
+	err := GetJSONArray(ctx, client, server.URL, JSONArrayLimits{MaxElements: 10}, skip)
+	assert.ErrorIs(t, err, ErrTooManyElements)
+
+	err = GetJSONArray(ctx, client, server.URL, JSONArrayLimits{MaxBytes: 256}, skip)
+	var tooLarge *BodyTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, int64(256), tooLarge.Limit)
+	assert.Equal(t, server.URL, tooLarge.URL)
+
+	stop := errors.New("stop")
+	var seen int
+	err = GetJSONArray(ctx, client, server.URL, JSONArrayLimits{}, func(jsonTestUser) error {
+		seen++
+		if seen == 3 {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 3, seen)
+}
+
+func TestGetJSONStream(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		_, _ = w.Write([]byte(`{"total":2,"items":[{"id":1},{"id":2}]}`))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-json-stream")
+	defer client.Close()
+
+	var ids []int
+	err := client.GetJSONStream(context.Background(), server.URL, func(dec *json.Decoder) error {
+		for {
+			token, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if token == "items" {
+				return DecodeJSONArray(dec, JSONArrayLimits{}, func(user jsonTestUser) error {
+					ids = append(ids, user.ID)
+					return nil
+				})
+			}
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids)
+
+	err = client.GetJSONStream(context.Background(), server.URL+"/missing", func(*json.Decoder) error {
+		t.Fatal("decoder must not be called for an error response")
+		return nil
+	})
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	t.Parallel()
+	count := func(input string) (int, error) {
+		n := 0
+		dec := json.NewDecoder(strings.NewReader(input))
+		err := DecodeJSONArray(dec, JSONArrayLimits{}, func(json.RawMessage) error {
+			n++
+			return nil
+		})
+		return n, err
+	}
+
+	n, err := count(`[1, "two", {"three": 3}]`)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = count("null")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	_, err = count(`{"id": 1}`)
+	assert.ErrorContains(t, err, "failed to decode JSON array")
+
+	_, err = count(`[1, 2`)
+	assert.ErrorContains(t, err, "failed to decode JSON array")
+
+	dec := json.NewDecoder(strings.NewReader(`["aaaa", "bbbb", "cccc"]`))
+	err = DecodeJSONArray(dec, JSONArrayLimits{MaxBytes: 10}, func(string) error { return nil })
+	assert.True(t, IsBodyTooLargeError(err))
+}
@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonTestUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestClient_GetJSON(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":42,"name":"John"}`))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-get-json")
+	defer client.Close()
+
+	var user jsonTestUser
+	err := client.GetJSON(context.Background(), server.URL, &user)
+	require.NoError(t, err)
+	assert.Equal(t, jsonTestUser{ID: 42, Name: "John"}, user)
+}
+
+func TestClient_GetJSON_HTTPError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"not found"}`))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-get-json-error")
+	defer client.Close()
+
+	var user jsonTestUser
+	err := client.GetJSON(context.Background(), server.URL, &user)
+	require.Error(t, err)
+
+	var httpErr *HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	assert.Equal(t, http.MethodGet, httpErr.Method)
+	assert.JSONEq(t, `{"error":"not found"}`, string(httpErr.Body))
+}
+
+func TestClient_GetJSON_InvalidJSON(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{not json`))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-get-json-invalid")
+	defer client.Close()
+
+	var user jsonTestUser
+	err := client.GetJSON(context.Background(), server.URL, &user)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decode JSON response")
+}
+
+func TestClient_PostJSON(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var in jsonTestUser
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &in))
+
+		in.ID = 7
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(in)
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-post-json")
+	defer client.Close()
+
+	var created jsonTestUser
+	err := client.PostJSON(context.Background(), server.URL, jsonTestUser{Name: "Jane"}, &created)
+	require.NoError(t, err)
+	assert.Equal(t, jsonTestUser{ID: 7, Name: "Jane"}, created)
+}
+
+func TestClient_PostJSON_NoContent(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-post-json-no-content")
+	defer client.Close()
+
+	var out jsonTestUser
+	err := client.PostJSON(context.Background(), server.URL, map[string]string{"a": "b"}, &out)
+	require.NoError(t, err)
+	assert.Equal(t, jsonTestUser{}, out)
+}
@@ -0,0 +1,11 @@
+package httpclient
+
+import "github.com/rurick/http-client/jsonrpc"
+
+// JSONRPC returns a JSON-RPC 2.0 client of the endpoint at url, resolved against
+// Config.BaseURL. Calls are sent through this client, so they share its retries,
+// circuit breaker, metrics and middleware. Calls use POST, which is only retried when
+// RetryConfig.RetryMethods includes it.
+func (c *Client) JSONRPC(url string, opts ...jsonrpc.Option) *jsonrpc.Client {
+	return jsonrpc.NewClient(c, url, opts...)
+}
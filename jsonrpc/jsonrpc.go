@@ -0,0 +1,288 @@
+// Package jsonrpc implements a JSON-RPC 2.0 client over HTTP. Requests are sent through
+// a Doer, usually an *httpclient.Client created with Client.JSONRPC, so calls share its
+// retries, circuit breaker, metrics and middleware.
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// Version is the JSON-RPC protocol version sent with every request.
+const Version = "2.0"
+
+// Standard error codes of the JSON-RPC 2.0 specification.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// ErrNoResponse is set on a batch call the server didn't answer.
+var ErrNoResponse = errors.New("jsonrpc: no response for request")
+
+// Doer sends HTTP requests, e.g. *httpclient.Client or *http.Client.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Error is the error object of a JSON-RPC response.
+type Error struct {
+	// Code is the error code, e.g. CodeMethodNotFound
+	Code int `json:"code"`
+	// Message is a short description of the error
+	Message string `json:"message"`
+	// Data is additional information about the error, if the server sent any
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("jsonrpc: error %d: %s", e.Code, e.Message)
+}
+
+// IsError checks if err is a JSON-RPC error object with one of the codes,
+// or with any code if none are given.
+func IsError(err error, codes ...int) bool {
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	if len(codes) == 0 {
+		return true
+	}
+	for _, code := range codes {
+		if rpcErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusError is returned when the server answers with a non-2xx status and no
+// JSON-RPC response.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("jsonrpc: unexpected HTTP status %d", e.StatusCode)
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithRequestOptions applies the options to every HTTP request of the client,
+// e.g. httpclient.WithBearerToken.
+func WithRequestOptions(opts ...func(*http.Request)) Option {
+	return func(c *Client) {
+		c.requestOptions = append(c.requestOptions, opts...)
+	}
+}
+
+// Client calls the methods of a JSON-RPC endpoint. It is safe for concurrent use.
+type Client struct {
+	doer           Doer
+	url            string
+	requestOptions []func(*http.Request)
+	nextID         atomic.Uint64
+}
+
+// NewClient creates a client of the JSON-RPC endpoint at url.
+func NewClient(doer Doer, url string, opts ...Option) *Client {
+	c := &Client{doer: doer, url: url}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// request is a JSON-RPC request object; notifications have no id.
+type request struct {
+	JSONRPC string  `json:"jsonrpc"`
+	Method  string  `json:"method"`
+	Params  any     `json:"params,omitempty"`
+	ID      *uint64 `json:"id,omitempty"`
+}
+
+// response is a JSON-RPC response object.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// id returns the numeric id of the response, false for a null or foreign id.
+func (r *response) id() (uint64, bool) {
+	id, err := strconv.ParseUint(string(bytes.Trim(r.ID, `"`)), 10, 64)
+	return id, err == nil
+}
+
+// decode returns the error object of the response or decodes its result into result.
+func (r *response) decode(result any) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if result == nil || len(r.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.Result, result); err != nil {
+		return fmt.Errorf("jsonrpc: decode result: %w", err)
+	}
+	return nil
+}
+
+// newRequest creates a request object, with a new id unless it is a notification.
+func (c *Client) newRequest(method string, params any, notification bool) request {
+	req := request{JSONRPC: Version, Method: method, Params: params}
+	if !notification {
+		id := c.nextID.Add(1)
+		req.ID = &id
+	}
+	return req
+}
+
+// Call calls the method and decodes its result into result, which may be nil to
+// discard it. A JSON-RPC error response is returned as *Error.
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	req := c.newRequest(method, params, false)
+	body, err := c.post(ctx, req)
+	if err != nil {
+		return err
+	}
+	var resp response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("jsonrpc: decode response: %w", err)
+	}
+	if id, ok := resp.id(); ok && id != *req.ID {
+		return fmt.Errorf("jsonrpc: response id %d does not match request id %d", id, *req.ID)
+	}
+	return resp.decode(result)
+}
+
+// Notify sends a notification, a call the server doesn't answer.
+func (c *Client) Notify(ctx context.Context, method string, params any) error {
+	_, err := c.post(ctx, c.newRequest(method, params, true))
+	return err
+}
+
+// BatchCall is a call of a batch request.
+type BatchCall struct {
+	// Method is the name of the method to call
+	Method string
+	// Params are the parameters of the method, nil for none
+	Params any
+	// Result receives the decoded result; nil discards it
+	Result any
+	// Notification sends the call without an id, so the server doesn't answer it
+	Notification bool
+	// Error is set by Client.Batch: an *Error, a decode error or ErrNoResponse
+	Error error
+}
+
+// Batch sends the calls in a single batch request and correlates the responses by id.
+// The returned error covers the whole request; the outcome of each call is in its
+// Error field.
+func (c *Client) Batch(ctx context.Context, calls []*BatchCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	reqs := make([]request, len(calls))
+	pending := make(map[uint64]*BatchCall, len(calls))
+	for i, call := range calls {
+		reqs[i] = c.newRequest(call.Method, call.Params, call.Notification)
+		call.Error = nil
+		if !call.Notification {
+			pending[*reqs[i].ID] = call
+		}
+	}
+
+	body, err := c.post(ctx, reqs)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	var resps []response
+	if err := json.Unmarshal(body, &resps); err != nil {
+		// A server that can't process the batch answers with a single error object
+		var resp response
+		if json.Unmarshal(body, &resp) == nil && resp.Error != nil {
+			return resp.Error
+		}
+		return fmt.Errorf("jsonrpc: decode batch response: %w", err)
+	}
+
+	var orphan *Error // error of a response with a null id, e.g. an invalid request
+	for i := range resps {
+		id, ok := resps[i].id()
+		call, found := pending[id]
+		if !ok || !found {
+			if resps[i].Error != nil {
+				orphan = resps[i].Error
+			}
+			continue
+		}
+		call.Error = resps[i].decode(call.Result)
+		delete(pending, id)
+	}
+	for _, call := range pending {
+		if orphan != nil {
+			call.Error = orphan
+		} else {
+			call.Error = ErrNoResponse
+		}
+	}
+	return nil
+}
+
+// post sends the payload and returns the response body, empty for notifications.
+func (c *Client) post(ctx context.Context, payload any) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("jsonrpc: encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for _, opt := range c.requestOptions {
+		opt(req)
+	}
+
+	resp, err := c.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("jsonrpc: read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Some servers send error objects with a 4xx or 5xx status
+		var rpcResp response
+		if json.Unmarshal(body, &rpcResp) == nil && rpcResp.Error != nil {
+			return body, nil
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: body}
+	}
+	return body, nil
+}
@@ -0,0 +1,166 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer starts a server answering JSON-RPC requests with handle; a nil response
+// leaves the request unanswered like a notification.
+func newServer(t *testing.T, handle func(req map[string]any) map[string]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var batch []map[string]any
+		if json.Unmarshal(body, &batch) == nil {
+			var resps []map[string]any
+			for _, req := range batch {
+				if resp := handle(req); resp != nil {
+					resps = append(resps, resp)
+				}
+			}
+			if len(resps) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(resps)
+			return
+		}
+		var req map[string]any
+		require.NoError(t, json.Unmarshal(body, &req))
+		resp := handle(req)
+		if resp == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// arith answers "add" with the sum of its params and notifications with nothing.
+func arith(req map[string]any) map[string]any {
+	id, ok := req["id"]
+	if !ok {
+		return nil
+	}
+	resp := map[string]any{"jsonrpc": Version, "id": id}
+	if req["method"] != "add" {
+		resp["error"] = map[string]any{"code": CodeMethodNotFound, "message": "Method not found", "data": req["method"]}
+		return resp
+	}
+	var sum float64
+	for _, v := range req["params"].([]any) {
+		sum += v.(float64)
+	}
+	resp["result"] = sum
+	return resp
+}
+
+func TestCall(t *testing.T) {
+	t.Parallel()
+	server := newServer(t, arith)
+	client := NewClient(http.DefaultClient, server.URL)
+
+	var sum int
+	require.NoError(t, client.Call(context.Background(), "add", []int{1, 2, 3}, &sum))
+	assert.Equal(t, 6, sum)
+
+	err := client.Call(context.Background(), "sub", []int{1}, &sum)
+	var rpcErr *Error
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, CodeMethodNotFound, rpcErr.Code)
+	assert.JSONEq(t, `"sub"`, string(rpcErr.Data))
+	assert.True(t, IsError(err, CodeMethodNotFound))
+	assert.False(t, IsError(err, CodeInvalidParams))
+}
+
+func TestCall_MismatchedID(t *testing.T) {
+	t.Parallel()
+	server := newServer(t, func(map[string]any) map[string]any {
+		return map[string]any{"jsonrpc": Version, "id": 999, "result": 1}
+	})
+	client := NewClient(http.DefaultClient, server.URL)
+	require.ErrorContains(t, client.Call(context.Background(), "add", nil, nil), "does not match")
+}
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+	var received map[string]any
+	server := newServer(t, func(req map[string]any) map[string]any {
+		received = req
+		return nil
+	})
+	client := NewClient(http.DefaultClient, server.URL)
+
+	require.NoError(t, client.Notify(context.Background(), "log", map[string]string{"msg": "hi"}))
+	assert.Equal(t, "log", received["method"])
+	assert.NotContains(t, received, "id")
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+	server := newServer(t, arith)
+	client := NewClient(http.DefaultClient, server.URL)
+
+	var first, second int
+	calls := []*BatchCall{
+		{Method: "add", Params: []int{1, 2}, Result: &first},
+		{Method: "log", Params: []string{"x"}, Notification: true},
+		{Method: "mul", Params: []int{2, 3}},
+		{Method: "add", Params: []int{10, 20}, Result: &second},
+	}
+	require.NoError(t, client.Batch(context.Background(), calls))
+
+	assert.Equal(t, 3, first)
+	assert.Equal(t, 30, second)
+	require.NoError(t, calls[0].Error)
+	require.NoError(t, calls[1].Error)
+	assert.True(t, IsError(calls[2].Error, CodeMethodNotFound))
+	require.NoError(t, calls[3].Error)
+}
+
+func TestBatch_MissingResponse(t *testing.T) {
+	t.Parallel()
+	server := newServer(t, func(req map[string]any) map[string]any {
+		if req["method"] == "skip" {
+			return nil
+		}
+		return arith(req)
+	})
+	client := NewClient(http.DefaultClient, server.URL)
+
+	calls := []*BatchCall{{Method: "add", Params: []int{1}}, {Method: "skip"}}
+	require.NoError(t, client.Batch(context.Background(), calls))
+	require.NoError(t, calls[0].Error)
+	require.ErrorIs(t, calls[1].Error, ErrNoResponse)
+}
+
+func TestStatusError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := NewClient(http.DefaultClient, server.URL, WithRequestOptions(func(r *http.Request) {
+		r.Header.Set("X-Test", "1")
+	}))
+
+	err := client.Call(context.Background(), "add", nil, nil)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	assert.False(t, IsError(err))
+}
@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rurick/http-client/jsonrpc"
+)
+
+func TestClient_JSONRPC(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK, Body: `{"jsonrpc":"2.0","id":1,"result":"pong"}`},
+	)
+	defer server.Close()
+
+	client := New(Config{
+		BaseURL:      server.URL,
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			MaxAttempts:  2,
+			BaseDelay:    time.Millisecond,
+			MaxDelay:     time.Millisecond,
+			RetryMethods: []string{http.MethodPost},
+		},
+	}, "test-jsonrpc")
+	defer client.Close()
+
+	// The call is retried by the client and its body is sent again
+	var result string
+	rpc := client.JSONRPC("/rpc", jsonrpc.WithRequestOptions(WithBearerToken("secret")))
+	require.NoError(t, rpc.Call(context.Background(), "ping", nil, &result))
+	assert.Equal(t, "pong", result)
+	require.Equal(t, 2, server.GetRequestCount())
+
+	last := server.RequestLog[len(server.RequestLog)-1]
+	assert.Equal(t, "/rpc", last.URL)
+	assert.Equal(t, "Bearer secret", last.Headers["Authorization"])
+	assert.JSONEq(t, `{"jsonrpc":"2.0","method":"ping","id":1}`, last.Body)
+}
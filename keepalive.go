@@ -0,0 +1,223 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default keep-alive ping settings.
+const (
+	defaultKeepAliveInterval = 30 * time.Second
+	defaultKeepAliveTimeout  = 5 * time.Second
+)
+
+// KeepAliveConfig contains settings of background pings keeping connections to critical
+// hosts warm, so NAT and firewall mappings don't expire and dead connections are found by a
+// ping instead of the next request.
+type KeepAliveConfig struct {
+	// Hosts are the pinged origins, e.g. "https://api.example.com" or "api.example.com:8443"
+	// (https is assumed without a scheme)
+	Hosts []string
+
+	// Interval is the time between pings of a host (default: 30s). It should be shorter than
+	// the idle timeout of NATs and firewalls on the path and than TransportTuning.IdleConnTimeout
+	Interval time.Duration
+
+	// Method is the method of pings, http.MethodHead or http.MethodOptions (default: HEAD)
+	Method string
+
+	// Path is the path of pings (default: "/")
+	Path string
+
+	// Timeout limits a single ping (default: 5s)
+	Timeout time.Duration
+
+	// OnReachabilityChange is called when a host stops or starts answering pings
+	OnReachabilityChange func(host string, reachable bool)
+}
+
+// withDefaults applies default values to the keep-alive configuration.
+func (kc KeepAliveConfig) withDefaults() KeepAliveConfig {
+	if kc.Interval <= 0 {
+		kc.Interval = defaultKeepAliveInterval
+	}
+	if kc.Method == "" {
+		kc.Method = http.MethodHead
+	}
+	if kc.Path == "" {
+		kc.Path = "/"
+	}
+	if kc.Timeout <= 0 {
+		kc.Timeout = defaultKeepAliveTimeout
+	}
+	return kc
+}
+
+// ReachabilityState is the result of the latest keep-alive ping of a host.
+type ReachabilityState struct {
+	// Host is the pinged host as given in KeepAliveConfig.Hosts
+	Host string
+	// Reachable reports whether the host answered the ping with any status
+	Reachable bool
+	// LastPing is the time of the ping
+	LastPing time.Time
+	// Latency is the duration of the ping
+	Latency time.Duration
+	// Err is the error of a failed ping
+	Err error
+}
+
+// keepAlivePinger pings the hosts of KeepAliveConfig until it is stopped.
+type keepAlivePinger struct {
+	config    KeepAliveConfig
+	transport http.RoundTripper
+	userAgent string
+	metrics   *Metrics
+	clock     Clock
+	targets   map[string]*url.URL
+
+	mu     sync.Mutex
+	states map[string]ReachabilityState
+
+	// ctx is cancelled by close, aborting running pings
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// startKeepAlive starts pinging the configured hosts, or returns nil when there are none.
+// Hosts that are not valid origins are skipped, Config.Validate reports them.
+func (c *Client) startKeepAlive() *keepAlivePinger {
+	config := c.config.KeepAlive.withDefaults()
+	targets := make(map[string]*url.URL, len(config.Hosts))
+	for _, host := range config.Hosts {
+		if target, err := warmupURL(host); err == nil {
+			target.Path = config.Path
+			targets[host] = target
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	p := &keepAlivePinger{
+		config:    config,
+		transport: c.config.Transport,
+		userAgent: c.config.UserAgent,
+		metrics:   c.metrics,
+		clock:     clockOrDefault(c.config.Clock),
+		targets:   targets,
+		states:    make(map[string]ReachabilityState, len(targets)),
+		done:      make(chan struct{}),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	go p.run()
+	return p
+}
+
+// run pings the hosts every interval until the pinger is stopped.
+func (p *keepAlivePinger) run() {
+	defer close(p.done)
+	for {
+		p.pingAll()
+		timer := p.clock.NewTimer(p.config.Interval)
+		select {
+		case <-p.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+// pingAll pings every host concurrently.
+func (p *keepAlivePinger) pingAll() {
+	var wg sync.WaitGroup
+	for host, target := range p.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.ping(host, target)
+		}()
+	}
+	wg.Wait()
+}
+
+// ping sends a single ping straight to the transport, so it reuses and refreshes a pooled
+// connection. The transport replaces a connection that turns out to be dead, since pings
+// are idempotent. Any response counts as reachable.
+func (p *keepAlivePinger) ping(host string, target *url.URL) {
+	ctx, cancel := context.WithTimeout(p.ctx, p.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, p.config.Method, target.String(), nil)
+	if err == nil {
+		if p.userAgent != "" {
+			req.Header.Set("User-Agent", p.userAgent)
+		}
+		var resp *http.Response
+		if resp, err = p.transport.RoundTrip(req); err == nil {
+			// Draining lets the transport return the connection to the pool
+			drainAndClose(resp.Body)
+		} else {
+			err = classifyRequestError(req, err)
+		}
+	}
+
+	if p.ctx.Err() != nil {
+		// Pings aborted by close say nothing about the host
+		return
+	}
+	state := ReachabilityState{
+		Host:      host,
+		Reachable: err == nil,
+		LastPing:  p.clock.Now(),
+		Latency:   time.Since(start),
+		Err:       err,
+	}
+	p.mu.Lock()
+	previous, known := p.states[host]
+	p.states[host] = state
+	p.mu.Unlock()
+
+	// Hosts are assumed reachable until the first ping
+	wasReachable := !known || previous.Reachable
+	p.metrics.SetHostReachable(context.Background(), getHost(target), state.Reachable)
+	if wasReachable != state.Reachable && p.config.OnReachabilityChange != nil {
+		p.config.OnReachabilityChange(host, state.Reachable)
+	}
+}
+
+// reachability returns the latest ping results ordered by host.
+func (p *keepAlivePinger) reachability() []ReachabilityState {
+	p.mu.Lock()
+	states := make([]ReachabilityState, 0, len(p.states))
+	for _, state := range p.states {
+		states = append(states, state)
+	}
+	p.mu.Unlock()
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Host < states[j].Host })
+	return states
+}
+
+// close stops pinging and waits for aborted pings to finish.
+func (p *keepAlivePinger) close() {
+	p.closeOnce.Do(p.cancel)
+	<-p.done
+}
+
+// GetReachability returns the latest keep-alive ping result of every host in
+// Config.KeepAlive.Hosts that was pinged, ordered by host.
+func (c *Client) GetReachability() []ReachabilityState {
+	if c.keepAlive == nil {
+		return nil
+	}
+	return c.keepAlive.reachability()
+}
@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepAlive_PingsOverPooledConnection(t *testing.T) {
+	t.Parallel()
+	var pings, conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodOptions, r.Method)
+		assert.Equal(t, "/ping", r.URL.Path)
+		atomic.AddInt32(&pings, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+
+	reg := prometheus.NewRegistry()
+	clock := NewFakeClock(time.Unix(1000, 0))
+	var changes []bool
+	client := New(Config{
+		Clock:                clock,
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		KeepAlive: KeepAliveConfig{
+			Hosts:                []string{server.URL},
+			Method:               http.MethodOptions,
+			Path:                 "/ping",
+			Timeout:              time.Second,
+			OnReachabilityChange: func(_ string, reachable bool) { changes = append(changes, reachable) },
+		},
+	}, "test-keepalive")
+	defer client.Close()
+
+	for want := int32(1); want <= 3; want++ {
+		clock.BlockUntil(1)
+		require.Equal(t, want, atomic.LoadInt32(&pings))
+		clock.Advance(defaultKeepAliveInterval)
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&pings) > want }, 5*time.Second, time.Millisecond)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns), "pings reuse the pooled connection")
+
+	clock.BlockUntil(1)
+	states := client.GetReachability()
+	require.Len(t, states, 1)
+	assert.Equal(t, server.URL, states[0].Host)
+	assert.True(t, states[0].Reachable)
+	assert.Equal(t, clock.Now(), states[0].LastPing)
+	assert.NoError(t, states[0].Err)
+	labels := map[string]string{"client_name": "test-keepalive", "host": "127.0.0.1"}
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, MetricHostReachable, labels))
+
+	// A host that stops answering is reported once
+	server.Close()
+	clock.Advance(defaultKeepAliveInterval)
+	clock.BlockUntil(1)
+	states = client.GetReachability()
+	assert.False(t, states[0].Reachable)
+	assert.ErrorIs(t, states[0].Err, ErrConnection)
+	assert.Equal(t, 0.0, circuitBreakerMetric(t, reg, MetricHostReachable, labels))
+	assert.Equal(t, []bool{false}, changes)
+}
+
+func TestKeepAlive_Disabled(t *testing.T) {
+	t.Parallel()
+	client := New(Config{KeepAlive: KeepAliveConfig{Hosts: []string{"ftp://example.com"}}}, "test-keepalive-disabled")
+	defer client.Close()
+	assert.Nil(t, client.keepAlive)
+	assert.Nil(t, client.GetReachability())
+}
+
+func TestKeepAlive_Validate(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, Config{KeepAlive: KeepAliveConfig{Hosts: []string{"api.example.com:8443"}}}.Validate())
+
+	var configErr *ConfigurationError
+	err := Config{KeepAlive: KeepAliveConfig{Hosts: []string{"ftp://example.com"}}}.Validate()
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "KeepAlive.Hosts", configErr.Field)
+
+	err = Config{KeepAlive: KeepAliveConfig{Method: http.MethodGet}}.Validate()
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "KeepAlive.Method", configErr.Field)
+}
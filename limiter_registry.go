@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultLimiterRegistry is the process-wide registry of shared rate limits. Clients use it
+// only when it is set as RateLimiterConfig.Registry.
+var DefaultLimiterRegistry = NewLimiterRegistry()
+
+// LimiterRegistry shares one token bucket per host between clients, so that clients created
+// by different libraries in one process stay within the quota of an upstream together.
+// It is safe for concurrent use.
+type LimiterRegistry struct {
+	mu       sync.Mutex
+	limits   map[string]EndpointRateLimit // set by SetLimit
+	limiters map[string]*TokenBucketLimiter
+}
+
+// NewLimiterRegistry creates an empty registry.
+func NewLimiterRegistry() *LimiterRegistry {
+	return &LimiterRegistry{
+		limits:   make(map[string]EndpointRateLimit),
+		limiters: make(map[string]*TokenBucketLimiter),
+	}
+}
+
+// SetLimit sets the quota of the host shared by all clients of the registry, replacing its
+// bucket. A non-positive rate exempts the host from rate limiting. Without a limit the bucket
+// of a host is created with the RequestsPerSecond and BurstCapacity of the first client
+// sending a request there.
+func (r *LimiterRegistry) SetLimit(host string, limit EndpointRateLimit) {
+	host = strings.ToLower(host)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits[host] = limit.withDefaults()
+	delete(r.limiters, host)
+}
+
+// limiter returns the bucket of the host, creating it with the limit set by SetLimit or
+// with fallback. It returns nil for exempt hosts.
+func (r *LimiterRegistry) limiter(host string, fallback EndpointRateLimit, clock Clock) RateLimiter {
+	host = strings.ToLower(host)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if limiter, ok := r.limiters[host]; ok {
+		return limiter
+	}
+	limit, ok := r.limits[host]
+	if !ok {
+		limit = fallback.withDefaults()
+	}
+	if limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+	limiter := NewTokenBucketLimiterWithClock(limit.RequestsPerSecond, limit.BurstCapacity, clock)
+	r.limiters[host] = limiter
+	return limiter
+}
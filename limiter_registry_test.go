@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterRegistry_SharedPerHost(t *testing.T) {
+	t.Parallel()
+	registry := NewLimiterRegistry()
+	fallback := EndpointRateLimit{RequestsPerSecond: 5}
+
+	a := registry.limiter("API.example.com", fallback, nil)
+	b := registry.limiter("api.example.com", EndpointRateLimit{RequestsPerSecond: 50}, nil)
+	assert.Same(t, a, b)
+	assert.Equal(t, 5.0, a.(*TokenBucketLimiter).rate)
+	assert.NotSame(t, a, registry.limiter("other.example.com", fallback, nil))
+
+	registry.SetLimit("api.example.com", EndpointRateLimit{RequestsPerSecond: 2})
+	limited := registry.limiter("api.example.com", fallback, nil).(*TokenBucketLimiter)
+	assert.Equal(t, 2.0, limited.rate)
+	assert.Equal(t, 2, limited.capacity)
+
+	registry.SetLimit("internal.local", EndpointRateLimit{})
+	assert.Nil(t, registry.limiter("internal.local", fallback, nil))
+}
+
+func TestLimiterRegistry_ClientsShareBucket(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := NewLimiterRegistry()
+	newClient := func(name string) *Client {
+		return New(Config{
+			RateLimiterEnabled: true,
+			RateLimiterConfig: RateLimiterConfig{
+				RequestsPerSecond: 0.1,
+				BurstCapacity:     2,
+				Registry:          registry,
+			},
+		}, name)
+	}
+	first := newClient("test-shared-limiter-a")
+	defer first.Close()
+	second := newClient("test-shared-limiter-b")
+	defer second.Close()
+
+	get := func(c *Client) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		resp, err := c.Get(ctx, server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	require.NoError(t, get(first))
+	require.NoError(t, get(second))
+	// Both clients took a token from the same bucket of the host
+	assert.ErrorIs(t, get(first), ErrRateLimited)
+	assert.ErrorIs(t, get(second), ErrRateLimited)
+}
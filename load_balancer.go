@@ -0,0 +1,319 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultResolverRefreshInterval is how long resolved endpoints are reused.
+	defaultResolverRefreshInterval = 30 * time.Second
+	// ewmaDecay is the weight of the previous latency average.
+	ewmaDecay = 0.7
+)
+
+// ErrNoEndpoints is returned when a balanced service resolves to no endpoints.
+var ErrNoEndpoints = errors.New("no endpoints resolved")
+
+// Endpoint is a backend of a logical service.
+type Endpoint struct {
+	// Address is the "host:port" the requests are sent to
+	Address string
+}
+
+// Resolver returns the endpoints of a logical service.
+type Resolver interface {
+	Resolve(ctx context.Context, service string) ([]Endpoint, error)
+}
+
+// ResolverFunc adapts a function, e.g. a service discovery lookup, to the Resolver interface.
+type ResolverFunc func(ctx context.Context, service string) ([]Endpoint, error)
+
+// Resolve calls f(ctx, service).
+func (f ResolverFunc) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	return f(ctx, service)
+}
+
+// StaticResolver resolves services from a fixed list of addresses.
+type StaticResolver map[string][]string
+
+// Resolve returns the configured addresses of the service.
+func (r StaticResolver) Resolve(_ context.Context, service string) ([]Endpoint, error) {
+	addresses, ok := r[service]
+	if !ok {
+		return nil, fmt.Errorf("unknown service %q", service)
+	}
+	endpoints := make([]Endpoint, 0, len(addresses))
+	for _, address := range addresses {
+		endpoints = append(endpoints, Endpoint{Address: address})
+	}
+	return endpoints, nil
+}
+
+// SRVResolver resolves services with DNS SRV records. With empty Service and Proto
+// the service name itself is looked up, e.g. "_http._tcp.orders.svc.cluster.local".
+type SRVResolver struct {
+	// Service is the SRV service, e.g. "http"
+	Service string
+	// Proto is the SRV protocol, e.g. "tcp"
+	Proto string
+	// Resolver performs the lookups (default: net.DefaultResolver)
+	Resolver *net.Resolver
+}
+
+// Resolve looks up the SRV records of the service.
+func (r SRVResolver) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, records, err := resolver.LookupSRV(ctx, r.Service, r.Proto, service)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := make([]Endpoint, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		endpoints = append(endpoints, Endpoint{Address: net.JoinHostPort(host, strconv.Itoa(int(record.Port)))})
+	}
+	return endpoints, nil
+}
+
+// LoadBalancingStrategy defines how an endpoint is chosen for each attempt.
+type LoadBalancingStrategy int
+
+const (
+	// LoadBalancingRoundRobin rotates attempts across endpoints.
+	LoadBalancingRoundRobin LoadBalancingStrategy = iota
+	// LoadBalancingLeastInflight picks the endpoint with the fewest attempts awaiting a response.
+	LoadBalancingLeastInflight
+	// LoadBalancingEWMA picks the endpoint with the lowest moving average latency
+	// weighted by its in-flight attempts; failed attempts count as PerTryTimeout.
+	LoadBalancingEWMA
+)
+
+// String returns the strategy name.
+func (s LoadBalancingStrategy) String() string {
+	switch s {
+	case LoadBalancingRoundRobin:
+		return "round-robin"
+	case LoadBalancingLeastInflight:
+		return "least-inflight"
+	case LoadBalancingEWMA:
+		return "ewma"
+	default:
+		return "unknown"
+	}
+}
+
+// LoadBalancerConfig contains client-side load balancing settings.
+type LoadBalancerConfig struct {
+	// Services are the logical service names balanced by the client. A request is balanced
+	// when its URL host is one of them, e.g. "http://orders/api/v1/orders" for "orders"
+	Services []string
+
+	// Resolver returns the endpoints of a service
+	Resolver Resolver
+
+	// Strategy selects an endpoint for each attempt (default: LoadBalancingRoundRobin)
+	Strategy LoadBalancingStrategy
+
+	// RefreshInterval is how long resolved endpoints are reused (default: 30s).
+	// When resolving fails, the previous endpoints stay in use
+	RefreshInterval time.Duration
+}
+
+// withDefaults applies default values to the load balancer configuration.
+func (lc LoadBalancerConfig) withDefaults() LoadBalancerConfig {
+	if lc.RefreshInterval <= 0 {
+		lc.RefreshInterval = defaultResolverRefreshInterval
+	}
+
+	return lc
+}
+
+// lbEndpoint is an endpoint with its load statistics.
+type lbEndpoint struct {
+	address  string
+	inflight int
+	ewma     float64 // seconds, zero until the first attempt completes
+}
+
+// lbService is a balanced service with its resolved endpoints.
+type lbService struct {
+	name string
+
+	resolveMu  sync.Mutex // serializes resolving
+	mu         sync.Mutex
+	endpoints  []*lbEndpoint
+	resolvedAt time.Time
+	next       int // next index for round-robin
+}
+
+// loadBalancer routes attempts of balanced services to their endpoints.
+type loadBalancer struct {
+	resolver        Resolver
+	strategy        LoadBalancingStrategy
+	refreshInterval time.Duration
+	errorPenalty    time.Duration
+	services        map[string]*lbService
+}
+
+// newLoadBalancer creates a load balancer; errorPenalty is the latency of failed attempts for EWMA.
+func newLoadBalancer(config LoadBalancerConfig, errorPenalty time.Duration) *loadBalancer {
+	config = config.withDefaults()
+	lb := &loadBalancer{
+		resolver:        config.Resolver,
+		strategy:        config.Strategy,
+		refreshInterval: config.RefreshInterval,
+		errorPenalty:    errorPenalty,
+		services:        make(map[string]*lbService, len(config.Services)),
+	}
+	for _, name := range config.Services {
+		name = strings.ToLower(name)
+		lb.services[name] = &lbService{name: name}
+	}
+	return lb
+}
+
+// lbPick is an endpoint chosen for an attempt.
+type lbPick struct {
+	service  *lbService
+	endpoint *lbEndpoint
+	start    time.Time
+}
+
+// route rewrites the request host to an endpoint of its service. Requests to other hosts
+// are not balanced and get a nil pick. The request must own its URL.
+func (lb *loadBalancer) route(req *http.Request) (*lbPick, error) {
+	service, ok := lb.services[strings.ToLower(req.URL.Host)]
+	if !ok {
+		return nil, nil
+	}
+
+	endpoints, err := lb.resolve(req.Context(), service)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := lb.pick(service, endpoints)
+	u := *req.URL
+	u.Host = endpoint.address
+	req.URL = &u
+	return &lbPick{service: service, endpoint: endpoint, start: time.Now()}, nil
+}
+
+// resolve returns the service endpoints, resolving them when the cached ones are stale.
+// While one request refreshes stale endpoints, the others keep using them.
+func (lb *loadBalancer) resolve(ctx context.Context, service *lbService) ([]*lbEndpoint, error) {
+	service.mu.Lock()
+	endpoints, resolvedAt := service.endpoints, service.resolvedAt
+	service.mu.Unlock()
+	if len(endpoints) > 0 && time.Since(resolvedAt) < lb.refreshInterval {
+		return endpoints, nil
+	}
+
+	if len(endpoints) > 0 {
+		if !service.resolveMu.TryLock() {
+			return endpoints, nil
+		}
+	} else {
+		service.resolveMu.Lock()
+	}
+	defer service.resolveMu.Unlock()
+
+	// Another request may have resolved the endpoints meanwhile
+	service.mu.Lock()
+	endpoints, resolvedAt = service.endpoints, service.resolvedAt
+	service.mu.Unlock()
+	if len(endpoints) > 0 && time.Since(resolvedAt) < lb.refreshInterval {
+		return endpoints, nil
+	}
+
+	resolved, err := lb.resolver.Resolve(ctx, service.name)
+	if err == nil && len(resolved) == 0 {
+		err = ErrNoEndpoints
+	}
+	if err != nil {
+		if len(endpoints) > 0 {
+			return endpoints, nil
+		}
+		return nil, fmt.Errorf("resolve service %q: %w", service.name, err)
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	service.endpoints = mergeEndpoints(service.endpoints, resolved)
+	service.resolvedAt = time.Now()
+	return service.endpoints, nil
+}
+
+// mergeEndpoints builds the new endpoint list, keeping the statistics of known addresses.
+func mergeEndpoints(current []*lbEndpoint, resolved []Endpoint) []*lbEndpoint {
+	endpoints := make([]*lbEndpoint, 0, len(resolved))
+	for _, endpoint := range resolved {
+		i := slices.IndexFunc(current, func(e *lbEndpoint) bool { return e.address == endpoint.Address })
+		if i >= 0 {
+			endpoints = append(endpoints, current[i])
+		} else {
+			endpoints = append(endpoints, &lbEndpoint{address: endpoint.Address})
+		}
+	}
+	return endpoints
+}
+
+// pick selects an endpoint according to the strategy and counts the attempt as in-flight.
+func (lb *loadBalancer) pick(service *lbService, endpoints []*lbEndpoint) *lbEndpoint {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	// Ties are broken in round-robin order
+	start := service.next
+	service.next++
+	best := endpoints[start%len(endpoints)]
+	if lb.strategy != LoadBalancingRoundRobin {
+		for i := 1; i < len(endpoints); i++ {
+			candidate := endpoints[(start+i)%len(endpoints)]
+			if lb.load(candidate) < lb.load(best) {
+				best = candidate
+			}
+		}
+	}
+
+	best.inflight++
+	return best
+}
+
+// load returns the endpoint load for the strategy, lower is better.
+func (lb *loadBalancer) load(endpoint *lbEndpoint) float64 {
+	if lb.strategy == LoadBalancingEWMA {
+		return endpoint.ewma * float64(endpoint.inflight+1)
+	}
+	return float64(endpoint.inflight)
+}
+
+// done records the attempt result of the chosen endpoint.
+func (lb *loadBalancer) done(p *lbPick, failed bool) {
+	latency := time.Since(p.start)
+	if failed {
+		latency = max(latency, lb.errorPenalty)
+	}
+
+	p.service.mu.Lock()
+	defer p.service.mu.Unlock()
+
+	p.endpoint.inflight--
+	if p.endpoint.ewma == 0 {
+		p.endpoint.ewma = latency.Seconds()
+	} else {
+		p.endpoint.ewma = ewmaDecay*p.endpoint.ewma + (1-ewmaDecay)*latency.Seconds()
+	}
+}
@@ -0,0 +1,190 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBackendServer answers with its name and the request path.
+func newBackendServer(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(name + " " + r.URL.Path))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// serverAddress returns the "host:port" of a test server.
+func serverAddress(server *httptest.Server) string {
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// backendRequests sums http_client_backend_requests_total of the backend.
+func backendRequests(t *testing.T, reg *prometheus.Registry, backend string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	total := 0.0
+	for _, family := range families {
+		if family.GetName() != MetricBackendRequestsTotal {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "backend" && label.GetValue() == backend {
+					total += m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestLoadBalancer_RoundRobin(t *testing.T) {
+	t.Parallel()
+	a, b := newBackendServer(t, "a"), newBackendServer(t, "b")
+	other := newBackendServer(t, "other")
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		LoadBalancer: LoadBalancerConfig{
+			Services: []string{"orders"},
+			Resolver: StaticResolver{"orders": {serverAddress(a), serverAddress(b)}},
+		},
+	}, "test-lb-round-robin")
+	defer client.Close()
+
+	var bodies []string
+	for range 4 {
+		_, body := getRedirectBody(t, client, "http://orders/api/orders")
+		bodies = append(bodies, body)
+	}
+	assert.Equal(t, []string{"a /api/orders", "b /api/orders", "a /api/orders", "b /api/orders"}, bodies)
+	assert.Equal(t, 2.0, backendRequests(t, reg, serverAddress(a)))
+	assert.Equal(t, 2.0, backendRequests(t, reg, serverAddress(b)))
+
+	// Hosts that are not balanced services are left alone
+	_, body := getRedirectBody(t, client, other.URL+"/ping")
+	assert.Equal(t, "other /ping", body)
+}
+
+func TestLoadBalancer_LeastInflight(t *testing.T) {
+	t.Parallel()
+	lb := newLoadBalancer(LoadBalancerConfig{
+		Services: []string{"svc"},
+		Resolver: StaticResolver{"svc": {"a:80", "b:80", "c:80"}},
+		Strategy: LoadBalancingLeastInflight,
+	}, time.Second)
+
+	pick := func() *lbPick {
+		req, err := http.NewRequest(http.MethodGet, "http://svc/", nil)
+		require.NoError(t, err)
+		p, err := lb.route(req)
+		require.NoError(t, err)
+		assert.Equal(t, p.endpoint.address, req.URL.Host)
+		return p
+	}
+
+	first, second, third := pick(), pick(), pick()
+	assert.ElementsMatch(t, []string{"a:80", "b:80", "c:80"},
+		[]string{first.endpoint.address, second.endpoint.address, third.endpoint.address})
+
+	// The only endpoint without in-flight attempts wins
+	lb.done(second, false)
+	assert.Equal(t, second.endpoint.address, pick().endpoint.address)
+}
+
+func TestLoadBalancer_EWMA(t *testing.T) {
+	t.Parallel()
+	lb := newLoadBalancer(LoadBalancerConfig{
+		Services: []string{"svc"},
+		Resolver: StaticResolver{"svc": {"fast:80", "slow:80"}},
+		Strategy: LoadBalancingEWMA,
+	}, time.Second)
+	service := lb.services["svc"]
+	endpoints, err := lb.resolve(context.Background(), service)
+	require.NoError(t, err)
+
+	lb.done(&lbPick{service: service, endpoint: lb.pick(service, endpoints), start: time.Now().Add(-10 * time.Millisecond)}, false)
+	lb.done(&lbPick{service: service, endpoint: lb.pick(service, endpoints), start: time.Now().Add(-500 * time.Millisecond)}, false)
+
+	for range 3 {
+		p := lb.pick(service, endpoints)
+		assert.Equal(t, "fast:80", p.address)
+		lb.done(&lbPick{service: service, endpoint: p, start: time.Now()}, false)
+	}
+
+	// A failed attempt counts as the error penalty
+	fast := endpoints[0]
+	lb.done(&lbPick{service: service, endpoint: lb.pick(service, endpoints), start: time.Now()}, true)
+	assert.Greater(t, fast.ewma, 0.25)
+	assert.Equal(t, 0, fast.inflight)
+}
+
+func TestLoadBalancer_Resolve(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	var fail atomic.Bool
+	resolver := ResolverFunc(func(ctx context.Context, service string) ([]Endpoint, error) {
+		atomic.AddInt32(&calls, 1)
+		if fail.Load() {
+			return nil, errors.New("discovery unavailable")
+		}
+		return []Endpoint{{Address: "a:80"}}, nil
+	})
+	lb := newLoadBalancer(LoadBalancerConfig{
+		Services:        []string{"svc"},
+		Resolver:        resolver,
+		RefreshInterval: 20 * time.Millisecond,
+	}, time.Second)
+	service := lb.services["svc"]
+
+	endpoints, err := lb.resolve(context.Background(), service)
+	require.NoError(t, err)
+	_, err = lb.resolve(context.Background(), service)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Stale endpoints are kept when resolving fails
+	fail.Store(true)
+	time.Sleep(30 * time.Millisecond)
+	stale, err := lb.resolve(context.Background(), service)
+	require.NoError(t, err)
+	assert.Equal(t, endpoints, stale)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// Without endpoints the error is returned
+	empty := newLoadBalancer(LoadBalancerConfig{
+		Services: []string{"svc"},
+		Resolver: ResolverFunc(func(context.Context, string) ([]Endpoint, error) { return nil, nil }),
+	}, time.Second)
+	_, err = empty.resolve(context.Background(), empty.services["svc"])
+	assert.ErrorIs(t, err, ErrNoEndpoints)
+}
+
+func TestStaticResolver_UnknownService(t *testing.T) {
+	t.Parallel()
+	_, err := StaticResolver{}.Resolve(context.Background(), "missing")
+	assert.Error(t, err)
+}
+
+func TestLoadBalancingStrategyString(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "round-robin", LoadBalancingRoundRobin.String())
+	assert.Equal(t, "least-inflight", LoadBalancingLeastInflight.String())
+	assert.Equal(t, "ewma", LoadBalancingEWMA.String())
+	assert.Equal(t, "unknown", LoadBalancingStrategy(7).String())
+}
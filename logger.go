@@ -0,0 +1,222 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// LogLevel is the severity of a client log record.
+type LogLevel int
+
+// Log levels. The zero value selects the default level of an event.
+const (
+	LogLevelDebug LogLevel = iota + 1
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	// LogLevelOff disables the event
+	LogLevelOff
+)
+
+// String returns the lowercase level name.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	case LogLevelOff:
+		return "off"
+	default:
+		return "default"
+	}
+}
+
+// Logger receives structured log records of the client. keysAndValues alternate
+// between string keys and values, as in log/slog.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, keysAndValues ...any)
+}
+
+// LogLevels sets the level each kind of event is logged at; LogLevelOff disables it.
+type LogLevels struct {
+	// Retry is the level of retry decisions (default: info)
+	Retry LogLevel
+	// CircuitBreaker is the level of circuit breaker transitions (default: warn)
+	CircuitBreaker LogLevel
+	// RateLimiter is the level of requests waiting for the rate limiter (default: debug)
+	RateLimiter LogLevel
+	// Request is the level of the canonical log line: one record per request summarizing all
+	// its attempts (default: off). When it's enabled, Retry and RateLimiter default to off
+	Request LogLevel
+	// RequestFailed is the level of canonical log lines of failed requests: errors and 5xx
+	// statuses (default: Request)
+	RequestFailed LogLevel
+}
+
+// withDefaults returns a copy of the levels with default values.
+func (l LogLevels) withDefaults() LogLevels {
+	if l.Request == 0 {
+		l.Request = LogLevelOff
+	}
+	if l.RequestFailed == 0 {
+		l.RequestFailed = l.Request
+	}
+	if l.requestLogEnabled() {
+		// The canonical log line replaces the per-attempt records
+		if l.Retry == 0 {
+			l.Retry = LogLevelOff
+		}
+		if l.RateLimiter == 0 {
+			l.RateLimiter = LogLevelOff
+		}
+	}
+	if l.Retry == 0 {
+		l.Retry = LogLevelInfo
+	}
+	if l.CircuitBreaker == 0 {
+		l.CircuitBreaker = LogLevelWarn
+	}
+	if l.RateLimiter == 0 {
+		l.RateLimiter = LogLevelDebug
+	}
+	return l
+}
+
+// logEvent writes a record unless the logger is unset or the event is disabled.
+func logEvent(ctx context.Context, logger Logger, level LogLevel, msg string, keysAndValues ...any) {
+	if logger == nil || level == LogLevelOff {
+		return
+	}
+	if labels := requestLabelsFromContext(ctx); len(labels) > 0 {
+		// Clip so the labels never overwrite the caller's slice
+		keysAndValues = slices.Clip(keysAndValues)
+		for _, label := range labels {
+			keysAndValues = append(keysAndValues, label.key, label.value)
+		}
+	}
+	logger.Log(ctx, level, msg, keysAndValues...)
+}
+
+// slogLogger adapts a *slog.Logger.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger adapts a log/slog logger; nil uses slog.Default().
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+// Log implements the Logger interface.
+func (l *slogLogger) Log(ctx context.Context, level LogLevel, msg string, keysAndValues ...any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	l.logger.Log(ctx, slogLevel(level), msg, keysAndValues...)
+}
+
+// slogLevel maps a client level to a slog level.
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelWarn:
+		return slog.LevelWarn
+	case LogLevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// ZapSugaredLogger is the part of *zap.SugaredLogger used by NewZapLogger.
+type ZapSugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// zapLogger adapts a zap sugared logger.
+type zapLogger struct {
+	logger ZapSugaredLogger
+}
+
+// NewZapLogger adapts a zap logger, e.g. NewZapLogger(zapLogger.Sugar()).
+func NewZapLogger(logger ZapSugaredLogger) Logger {
+	return &zapLogger{logger: logger}
+}
+
+// Log implements the Logger interface.
+func (l *zapLogger) Log(_ context.Context, level LogLevel, msg string, keysAndValues ...any) {
+	switch level {
+	case LogLevelDebug:
+		l.logger.Debugw(msg, keysAndValues...)
+	case LogLevelWarn:
+		l.logger.Warnw(msg, keysAndValues...)
+	case LogLevelError:
+		l.logger.Errorw(msg, keysAndValues...)
+	default:
+		l.logger.Infow(msg, keysAndValues...)
+	}
+}
+
+// LeveledPrintfLogger is a logger with printf-style methods per level, e.g. *logrus.Logger or *logrus.Entry.
+type LeveledPrintfLogger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// printfLogger adapts a LeveledPrintfLogger.
+type printfLogger struct {
+	logger LeveledPrintfLogger
+}
+
+// NewLogrusLogger adapts a logrus logger or entry. Key-value pairs are appended to the
+// message as key=value, so it also fits other printf-style leveled loggers.
+func NewLogrusLogger(logger LeveledPrintfLogger) Logger {
+	return &printfLogger{logger: logger}
+}
+
+// Log implements the Logger interface.
+func (l *printfLogger) Log(_ context.Context, level LogLevel, msg string, keysAndValues ...any) {
+	line := formatKeyValues(msg, keysAndValues)
+	switch level {
+	case LogLevelDebug:
+		l.logger.Debugf("%s", line)
+	case LogLevelWarn:
+		l.logger.Warnf("%s", line)
+	case LogLevelError:
+		l.logger.Errorf("%s", line)
+	default:
+		l.logger.Infof("%s", line)
+	}
+}
+
+// formatKeyValues appends key=value pairs to the message.
+func formatKeyValues(msg string, keysAndValues []any) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		b.WriteByte(' ')
+		if i+1 == len(keysAndValues) {
+			fmt.Fprintf(&b, "!BADKEY=%v", keysAndValues[i])
+			break
+		}
+		fmt.Fprintf(&b, "%v=%v", keysAndValues[i], keysAndValues[i+1])
+	}
+	return b.String()
+}
@@ -0,0 +1,186 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logRecord is a record captured by recordingLogger.
+type logRecord struct {
+	level LogLevel
+	msg   string
+	kv    []any
+}
+
+// recordingLogger captures log records.
+type recordingLogger struct {
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (l *recordingLogger) Log(_ context.Context, level LogLevel, msg string, keysAndValues ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, logRecord{level: level, msg: msg, kv: keysAndValues})
+}
+
+func (l *recordingLogger) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	messages := make([]string, 0, len(l.records))
+	for _, record := range l.records {
+		messages = append(messages, record.level.String()+" "+record.msg)
+	}
+	return messages
+}
+
+func TestLogger_RetryWithSlog(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}, "test-logger-slog", WithLogger(NewSlogLogger(logger)))
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL+"/orders")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	out := buf.String()
+	assert.Contains(t, out, `level=INFO msg="retrying request"`)
+	assert.Contains(t, out, "method=GET")
+	assert.Contains(t, out, "path=/orders attempt=1 max_attempts=2 reason=status status=503")
+}
+
+func TestLogger_CircuitBreakerAndRateLimiter(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusInternalServerError},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	client := New(Config{
+		Logger:               logger,
+		LogLevels:            LogLevels{RateLimiter: LogLevelInfo},
+		CircuitBreakerEnable: true,
+		CircuitBreaker: NewCircuitBreakerWithConfig(CircuitBreakerConfig{
+			FailureThreshold: 1,
+			SuccessThreshold: 1,
+			Timeout:          time.Millisecond,
+		}),
+		RateLimiterEnabled: true,
+		RateLimiterConfig:  RateLimiterConfig{RequestsPerSecond: 20, BurstCapacity: 1},
+	}, "test-logger-cb")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	time.Sleep(5 * time.Millisecond)
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, []string{
+		"warn circuit breaker state changed",
+		"warn circuit breaker state changed",
+		"info waited for rate limiter",
+		"warn circuit breaker state changed",
+	}, logger.messages())
+	assert.Equal(t, []any{"client", "test-logger-cb", "from", "closed", "to", "open"}, logger.records[0].kv)
+}
+
+func TestLogger_LevelOff(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	client := New(Config{
+		Logger:       logger,
+		LogLevels:    LogLevels{Retry: LogLevelOff},
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}, "test-logger-off")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Empty(t, logger.messages())
+}
+
+// fakeZapLogger records calls of the zap sugared logger methods.
+type fakeZapLogger struct {
+	calls []string
+}
+
+func (l *fakeZapLogger) record(level, msg string, kv []interface{}) {
+	l.calls = append(l.calls, fmt.Sprintf("%s %s %v", level, msg, kv))
+}
+
+func (l *fakeZapLogger) Debugw(msg string, kv ...interface{}) { l.record("debug", msg, kv) }
+func (l *fakeZapLogger) Infow(msg string, kv ...interface{})  { l.record("info", msg, kv) }
+func (l *fakeZapLogger) Warnw(msg string, kv ...interface{})  { l.record("warn", msg, kv) }
+func (l *fakeZapLogger) Errorw(msg string, kv ...interface{}) { l.record("error", msg, kv) }
+
+// fakeLogrusLogger records calls of the logrus printf methods.
+type fakeLogrusLogger struct {
+	lines []string
+}
+
+func (l *fakeLogrusLogger) Debugf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "debug "+fmt.Sprintf(format, args...))
+}
+
+func (l *fakeLogrusLogger) Infof(format string, args ...interface{}) {
+	l.lines = append(l.lines, "info "+fmt.Sprintf(format, args...))
+}
+
+func (l *fakeLogrusLogger) Warnf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "warn "+fmt.Sprintf(format, args...))
+}
+
+func (l *fakeLogrusLogger) Errorf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "error "+fmt.Sprintf(format, args...))
+}
+
+func TestLogger_Adapters(t *testing.T) {
+	t.Parallel()
+	zap := &fakeZapLogger{}
+	NewZapLogger(zap).Log(context.Background(), LogLevelWarn, "circuit open", "host", "api")
+	NewZapLogger(zap).Log(context.Background(), LogLevelDebug, "waited")
+	assert.Equal(t, []string{"warn circuit open [host api]", "debug waited []"}, zap.calls)
+
+	logrus := &fakeLogrusLogger{}
+	NewLogrusLogger(logrus).Log(context.Background(), LogLevelError, "failed", "attempt", 2, "odd")
+	NewLogrusLogger(logrus).Log(context.Background(), LogLevelInfo, "100%", "rate", "5%")
+	assert.Equal(t, []string{"error failed attempt=2 !BADKEY=odd", "info 100% rate=5%"}, logrus.lines)
+
+	var buf bytes.Buffer
+	NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil))).Log(context.Background(), LogLevelError, "boom", "k", "v")
+	assert.True(t, strings.Contains(buf.String(), `level=ERROR msg=boom k=v`), buf.String())
+}
@@ -7,6 +7,8 @@ type Metrics struct {
 	clientName string
 	enabled    bool
 	provider   MetricsProvider
+	// dropHost replaces host labels with "-"
+	dropHost bool
 }
 
 // NewMetrics creates a new metrics instance with Prometheus provider by default.
@@ -45,58 +47,191 @@ func NewMetricsWithProvider(meterName string, provider MetricsProvider) *Metrics
 
 // RecordRequest records metrics for a request.
 func (m *Metrics) RecordRequest(ctx context.Context, method, host, path, status string, retry, hasError bool) {
-	if !m.enabled || m.provider == nil {
+	if !m.active(ctx) {
 		return
 	}
-	m.provider.RecordRequest(ctx, method, host, path, status, retry, hasError)
+	m.provider.RecordRequest(ctx, method, m.hostLabel(host), path, status, retry, hasError)
 }
 
 // RecordDuration records request duration.
 func (m *Metrics) RecordDuration(ctx context.Context, duration float64, method, host, path, status string, attempt int) {
-	if !m.enabled || m.provider == nil {
+	if !m.active(ctx) {
 		return
 	}
-	m.provider.RecordDuration(ctx, duration, method, host, path, status, attempt)
+	m.provider.RecordDuration(ctx, duration, method, m.hostLabel(host), path, status, attempt)
 }
 
 // RecordRetry records a retry metric.
 func (m *Metrics) RecordRetry(ctx context.Context, reason, method, host, path string) {
-	if !m.enabled || m.provider == nil {
+	if !m.active(ctx) {
 		return
 	}
-	m.provider.RecordRetry(ctx, reason, method, host, path)
+	m.provider.RecordRetry(ctx, reason, method, m.hostLabel(host), path)
 }
 
 // RecordRequestSize records request size.
 func (m *Metrics) RecordRequestSize(ctx context.Context, size int64, method, host, path string) {
-	if !m.enabled || m.provider == nil {
+	if !m.active(ctx) {
 		return
 	}
-	m.provider.RecordRequestSize(ctx, size, method, host, path)
+	m.provider.RecordRequestSize(ctx, size, method, m.hostLabel(host), path)
 }
 
 // RecordResponseSize records response size.
 func (m *Metrics) RecordResponseSize(ctx context.Context, size int64, method, host, path, status string) {
-	if !m.enabled || m.provider == nil {
+	if !m.active(ctx) {
 		return
 	}
-	m.provider.RecordResponseSize(ctx, size, method, host, path, status)
+	m.provider.RecordResponseSize(ctx, size, method, m.hostLabel(host), path, status)
 }
 
 // IncrementInflight increments the active requests counter.
 func (m *Metrics) IncrementInflight(ctx context.Context, method, host, path string) {
-	if !m.enabled || m.provider == nil {
+	if !m.active(ctx) {
 		return
 	}
-	m.provider.InflightInc(ctx, method, host, path)
+	m.provider.InflightInc(ctx, method, m.hostLabel(host), path)
 }
 
 // DecrementInflight decrements the active requests counter.
 func (m *Metrics) DecrementInflight(ctx context.Context, method, host, path string) {
-	if !m.enabled || m.provider == nil {
+	if !m.active(ctx) {
 		return
 	}
-	m.provider.InflightDec(ctx, method, host, path)
+	m.provider.InflightDec(ctx, method, m.hostLabel(host), path)
+}
+
+// RecordPhaseDuration records a connection phase duration if the provider supports it.
+func (m *Metrics) RecordPhaseDuration(ctx context.Context, seconds float64, phase, method, host string) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(PhaseMetricsProvider); ok {
+		p.RecordPhaseDuration(ctx, seconds, phase, method, m.hostLabel(host))
+	}
+}
+
+// RecordRedirect records a followed redirect if the provider supports it.
+func (m *Metrics) RecordRedirect(ctx context.Context, method, host, status string) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(RedirectMetricsProvider); ok {
+		p.RecordRedirect(ctx, method, m.hostLabel(host), status)
+	}
+}
+
+// SetThrottled reports the throttle state of a host if the provider supports it.
+func (m *Metrics) SetThrottled(ctx context.Context, host string, throttled bool) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(ThrottleMetricsProvider); ok {
+		p.SetThrottled(ctx, m.hostLabel(host), throttled)
+	}
+}
+
+// SetCircuitBreakerState reports the breaker state for a host if the provider supports it.
+func (m *Metrics) SetCircuitBreakerState(ctx context.Context, host string, state CircuitBreakerState) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(CircuitBreakerMetricsProvider); ok {
+		p.SetCircuitBreakerState(ctx, m.hostLabel(host), state)
+	}
+}
+
+// RecordCircuitBreakerTransition records a breaker state change if the provider supports it.
+func (m *Metrics) RecordCircuitBreakerTransition(ctx context.Context, from, to CircuitBreakerState) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(CircuitBreakerMetricsProvider); ok {
+		p.RecordCircuitBreakerTransition(ctx, from, to)
+	}
+}
+
+// RecordShortCircuit records a request rejected by an open breaker if the provider supports it.
+func (m *Metrics) RecordShortCircuit(ctx context.Context, method, host string) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(CircuitBreakerMetricsProvider); ok {
+		p.RecordShortCircuit(ctx, method, m.hostLabel(host))
+	}
+}
+
+// RecordBackendRequest records an attempt sent to a load-balanced backend if the provider supports it.
+func (m *Metrics) RecordBackendRequest(ctx context.Context, service, backend, status string, seconds float64) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(BackendMetricsProvider); ok {
+		p.RecordBackendRequest(ctx, service, backend, status, seconds)
+	}
+}
+
+// RecordRetryBudgetExhausted records a retry denied by the retry budget if the provider supports it.
+func (m *Metrics) RecordRetryBudgetExhausted(ctx context.Context, method, host string) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(RetryBudgetMetricsProvider); ok {
+		p.RecordRetryBudgetExhausted(ctx, method, m.hostLabel(host))
+	}
+}
+
+// RecordWarmupConnection records a warm-up connection attempt if the provider supports it.
+func (m *Metrics) RecordWarmupConnection(ctx context.Context, host, result string) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(WarmupMetricsProvider); ok {
+		p.RecordWarmupConnection(ctx, m.hostLabel(host), result)
+	}
+}
+
+// SetSLOStatus reports the compliance of an SLO objective if the provider supports it.
+func (m *Metrics) SetSLOStatus(ctx context.Context, slo, objective string, compliance, burnRate float64) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(SLOMetricsProvider); ok {
+		p.SetSLOStatus(ctx, slo, objective, compliance, burnRate)
+	}
+}
+
+// RecordWebhookDelivery records a webhook delivery attempt if the provider supports it.
+func (m *Metrics) RecordWebhookDelivery(ctx context.Context, destination, result string) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(WebhookMetricsProvider); ok {
+		p.RecordWebhookDelivery(ctx, m.hostLabel(destination), result)
+	}
+}
+
+// SetHostReachable reports the reachability of a pinged host if the provider supports it.
+func (m *Metrics) SetHostReachable(ctx context.Context, host string, reachable bool) {
+	if !m.active(ctx) {
+		return
+	}
+	if p, ok := m.provider.(ReachabilityMetricsProvider); ok {
+		p.SetHostReachable(ctx, m.hostLabel(host), reachable)
+	}
+}
+
+// active reports whether metrics are recorded for the request with the context.
+func (m *Metrics) active(ctx context.Context) bool {
+	return m.enabled && m.provider != nil && ctx.Value(metricsSkippedKey{}) == nil
+}
+
+// hostLabel returns the host label value, "-" if host labels are dropped.
+func (m *Metrics) hostLabel(host string) string {
+	if m.dropHost {
+		return "-"
+	}
+	return host
 }
 
 // Close releases metrics resources.
@@ -0,0 +1,252 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// MetricsHandle exposes where the metrics of a client are recorded, whatever the backend,
+// e.g. for a library embedding the client to serve them from its own endpoint.
+type MetricsHandle struct {
+	// Backend is the backend selected by Config.MetricsBackend ("" when metrics are disabled)
+	Backend MetricsBackend
+
+	// Registerers are the Prometheus registerers the metrics are registered in: the
+	// PrometheusRegisterer of the Prometheus backend, then Config.PrometheusRegisterers
+	Registerers []prometheus.Registerer
+
+	// MeterProvider records the metrics of the OpenTelemetry backend (nil for other backends)
+	MeterProvider metric.MeterProvider
+
+	// Provider is the provider the client records metrics with
+	Provider MetricsProvider
+}
+
+// MetricsHandle returns the backend, registerers and meter provider of the client metrics.
+func (c *Client) MetricsHandle() MetricsHandle {
+	return c.metricsHandle
+}
+
+// newMetricsHandle selects the metrics providers of the configuration. It fails with a
+// *ConfigurationError if Config.UniqueMetricsNames rejects the name; metrics are disabled then.
+func newMetricsHandle(config Config, meterName string) (MetricsHandle, error) {
+	if config.MetricsEnabled != nil && !*config.MetricsEnabled {
+		return MetricsHandle{Provider: NewNoopMetricsProvider()}, nil
+	}
+
+	handle := MetricsHandle{Backend: config.MetricsBackend}
+	var providers []MetricsProvider
+	switch config.MetricsBackend {
+	case MetricsBackendOpenTelemetry:
+		handle.MeterProvider = config.OTelMeterProvider
+		if handle.MeterProvider == nil {
+			handle.MeterProvider = otel.GetMeterProvider()
+		}
+		provider := NewOpenTelemetryMetricsProviderWithLabels(
+			meterName, handle.MeterProvider, config.MetricsLabels.StaticLabels)
+		provider.requestLabels = config.MetricsLabels.RequestLabels
+		providers = append(providers, provider)
+	default: // Prometheus by default
+		reg := config.PrometheusRegisterer
+		if reg == nil {
+			reg = prometheus.DefaultRegisterer
+		}
+		handle.Registerers = append(handle.Registerers, reg)
+	}
+
+	for _, reg := range config.PrometheusRegisterers {
+		if reg != nil && !slices.Contains(handle.Registerers, reg) {
+			handle.Registerers = append(handle.Registerers, reg)
+		}
+	}
+	for _, reg := range handle.Registerers {
+		provider, err := acquirePrometheusMetricsProvider(meterName, reg, config.MetricsLabels.StaticLabels,
+			config.MetricsBuckets, config.MetricsLabels.RequestLabels, config.UniqueMetricsNames)
+		if err != nil {
+			_ = multiMetricsProvider(providers).Close()
+			return MetricsHandle{Provider: NewNoopMetricsProvider()}, err
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 1 {
+		handle.Provider = providers[0]
+	} else {
+		handle.Provider = multiMetricsProvider(providers)
+	}
+	return handle, nil
+}
+
+// multiMetricsProvider records metrics with several providers.
+type multiMetricsProvider []MetricsProvider
+
+// RecordRequest records a request metric with every provider.
+func (m multiMetricsProvider) RecordRequest(ctx context.Context, method, host, path, status string, retry, hasError bool) {
+	for _, p := range m {
+		p.RecordRequest(ctx, method, host, path, status, retry, hasError)
+	}
+}
+
+// RecordDuration records request duration with every provider.
+func (m multiMetricsProvider) RecordDuration(ctx context.Context, seconds float64, method, host, path, status string, attempt int) {
+	for _, p := range m {
+		p.RecordDuration(ctx, seconds, method, host, path, status, attempt)
+	}
+}
+
+// RecordRetry records a retry attempt with every provider.
+func (m multiMetricsProvider) RecordRetry(ctx context.Context, reason, method, host, path string) {
+	for _, p := range m {
+		p.RecordRetry(ctx, reason, method, host, path)
+	}
+}
+
+// RecordRequestSize records request size with every provider.
+func (m multiMetricsProvider) RecordRequestSize(ctx context.Context, bytes int64, method, host, path string) {
+	for _, p := range m {
+		p.RecordRequestSize(ctx, bytes, method, host, path)
+	}
+}
+
+// RecordResponseSize records response size with every provider.
+func (m multiMetricsProvider) RecordResponseSize(ctx context.Context, bytes int64, method, host, path, status string) {
+	for _, p := range m {
+		p.RecordResponseSize(ctx, bytes, method, host, path, status)
+	}
+}
+
+// InflightInc increments the active requests counter of every provider.
+func (m multiMetricsProvider) InflightInc(ctx context.Context, method, host, path string) {
+	for _, p := range m {
+		p.InflightInc(ctx, method, host, path)
+	}
+}
+
+// InflightDec decrements the active requests counter of every provider.
+func (m multiMetricsProvider) InflightDec(ctx context.Context, method, host, path string) {
+	for _, p := range m {
+		p.InflightDec(ctx, method, host, path)
+	}
+}
+
+// RecordPhaseDuration records a connection phase duration with providers supporting it.
+func (m multiMetricsProvider) RecordPhaseDuration(ctx context.Context, seconds float64, phase, method, host string) {
+	for _, p := range m {
+		if pp, ok := p.(PhaseMetricsProvider); ok {
+			pp.RecordPhaseDuration(ctx, seconds, phase, method, host)
+		}
+	}
+}
+
+// RecordRedirect records a followed redirect with providers supporting it.
+func (m multiMetricsProvider) RecordRedirect(ctx context.Context, method, host, status string) {
+	for _, p := range m {
+		if rp, ok := p.(RedirectMetricsProvider); ok {
+			rp.RecordRedirect(ctx, method, host, status)
+		}
+	}
+}
+
+// SetThrottled sets the throttled state of the host in providers supporting it.
+func (m multiMetricsProvider) SetThrottled(ctx context.Context, host string, throttled bool) {
+	for _, p := range m {
+		if tp, ok := p.(ThrottleMetricsProvider); ok {
+			tp.SetThrottled(ctx, host, throttled)
+		}
+	}
+}
+
+// SetCircuitBreakerState sets the breaker state in providers supporting it.
+func (m multiMetricsProvider) SetCircuitBreakerState(ctx context.Context, host string, state CircuitBreakerState) {
+	for _, p := range m {
+		if cp, ok := p.(CircuitBreakerMetricsProvider); ok {
+			cp.SetCircuitBreakerState(ctx, host, state)
+		}
+	}
+}
+
+// RecordCircuitBreakerTransition records a breaker state change with providers supporting it.
+func (m multiMetricsProvider) RecordCircuitBreakerTransition(ctx context.Context, from, to CircuitBreakerState) {
+	for _, p := range m {
+		if cp, ok := p.(CircuitBreakerMetricsProvider); ok {
+			cp.RecordCircuitBreakerTransition(ctx, from, to)
+		}
+	}
+}
+
+// RecordShortCircuit records a request rejected by the breaker with providers supporting it.
+func (m multiMetricsProvider) RecordShortCircuit(ctx context.Context, method, host string) {
+	for _, p := range m {
+		if cp, ok := p.(CircuitBreakerMetricsProvider); ok {
+			cp.RecordShortCircuit(ctx, method, host)
+		}
+	}
+}
+
+// RecordBackendRequest records a load-balanced attempt with providers supporting it.
+func (m multiMetricsProvider) RecordBackendRequest(ctx context.Context, service, backend, status string, seconds float64) {
+	for _, p := range m {
+		if bp, ok := p.(BackendMetricsProvider); ok {
+			bp.RecordBackendRequest(ctx, service, backend, status, seconds)
+		}
+	}
+}
+
+// RecordRetryBudgetExhausted records a retry denied by the budget with providers supporting it.
+func (m multiMetricsProvider) RecordRetryBudgetExhausted(ctx context.Context, method, host string) {
+	for _, p := range m {
+		if bp, ok := p.(RetryBudgetMetricsProvider); ok {
+			bp.RecordRetryBudgetExhausted(ctx, method, host)
+		}
+	}
+}
+
+// RecordWarmupConnection records a warm-up connection attempt with providers supporting it.
+func (m multiMetricsProvider) RecordWarmupConnection(ctx context.Context, host, result string) {
+	for _, p := range m {
+		if wp, ok := p.(WarmupMetricsProvider); ok {
+			wp.RecordWarmupConnection(ctx, host, result)
+		}
+	}
+}
+
+// SetSLOStatus sets the compliance of an SLO objective in providers supporting it.
+func (m multiMetricsProvider) SetSLOStatus(ctx context.Context, slo, objective string, compliance, burnRate float64) {
+	for _, p := range m {
+		if sp, ok := p.(SLOMetricsProvider); ok {
+			sp.SetSLOStatus(ctx, slo, objective, compliance, burnRate)
+		}
+	}
+}
+
+// RecordWebhookDelivery records a webhook delivery attempt with providers supporting it.
+func (m multiMetricsProvider) RecordWebhookDelivery(ctx context.Context, destination, result string) {
+	for _, p := range m {
+		if wp, ok := p.(WebhookMetricsProvider); ok {
+			wp.RecordWebhookDelivery(ctx, destination, result)
+		}
+	}
+}
+
+// SetHostReachable sets the reachability of the host in providers supporting it.
+func (m multiMetricsProvider) SetHostReachable(ctx context.Context, host string, reachable bool) {
+	for _, p := range m {
+		if rp, ok := p.(ReachabilityMetricsProvider); ok {
+			rp.SetHostReachable(ctx, host, reachable)
+		}
+	}
+}
+
+// Close closes every provider.
+func (m multiMetricsProvider) Close() error {
+	var errs []error
+	for _, p := range m {
+		errs = append(errs, p.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricsHandle_MultipleRegistries(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	primary, extra := prometheus.NewRegistry(), prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:        MetricsBackendPrometheus,
+		PrometheusRegisterer:  primary,
+		PrometheusRegisterers: []prometheus.Registerer{extra, primary, nil},
+	}, "test-metrics-handle-multi")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	handle := client.MetricsHandle()
+	assert.Equal(t, MetricsBackendPrometheus, handle.Backend)
+	assert.Equal(t, []prometheus.Registerer{primary, extra}, handle.Registerers)
+	assert.Nil(t, handle.MeterProvider)
+
+	labels := map[string]string{"client_name": "test-metrics-handle-multi", "status": "200"}
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, primary, MetricRequestsTotal, labels))
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, extra, MetricRequestsTotal, labels))
+}
+
+func TestMetricsHandle_OpenTelemetryWithPrometheus(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		OTelMeterProvider:     meterProvider,
+		PrometheusRegisterers: []prometheus.Registerer{reg},
+	}, "test-metrics-handle-otel")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	handle := client.MetricsHandle()
+	assert.Equal(t, MetricsBackendOpenTelemetry, handle.Backend)
+	assert.Equal(t, meterProvider, handle.MeterProvider)
+	assert.Equal(t, []prometheus.Registerer{reg}, handle.Registerers)
+
+	labels := map[string]string{"client_name": "test-metrics-handle-otel", "status": "200"}
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, MetricRequestsTotal, labels))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var found bool
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			found = found || m.Name == MetricRequestsTotal
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestMetricsHandle_Defaults(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-metrics-handle-default")
+	defer client.Close()
+	handle := client.MetricsHandle()
+	assert.Equal(t, MetricsBackendOpenTelemetry, handle.Backend)
+	assert.Equal(t, otel.GetMeterProvider(), handle.MeterProvider)
+	assert.Empty(t, handle.Registerers)
+	assert.IsType(t, &OpenTelemetryMetricsProvider{}, handle.Provider)
+
+	disabled := false
+	noMetrics := New(Config{MetricsEnabled: &disabled}, "test-metrics-handle-disabled")
+	defer noMetrics.Close()
+	handle = noMetrics.MetricsHandle()
+	assert.Empty(t, handle.Backend)
+	assert.IsType(t, &NoopMetricsProvider{}, handle.Provider)
+}
+
+// closeErrorProvider is a provider failing to close.
+type closeErrorProvider struct {
+	NoopMetricsProvider
+	err error
+}
+
+func (p *closeErrorProvider) Close() error { return p.err }
+
+func TestMultiMetricsProvider_Close(t *testing.T) {
+	t.Parallel()
+	errClose := errors.New("close failed")
+	providers := multiMetricsProvider{NewNoopMetricsProvider(), &closeErrorProvider{err: errClose}}
+	assert.ErrorIs(t, providers.Close(), errClose)
+	assert.NoError(t, multiMetricsProvider{NewNoopMetricsProvider()}.Close())
+}
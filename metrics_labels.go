@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// OtherPathLabel is the path label of requests matching none of MetricsLabelsConfig.URLTemplates.
+const OtherPathLabel = "other"
+
+// MetricsLabelsConfig controls the labels of client metrics to keep their cardinality low.
+type MetricsLabelsConfig struct {
+	// DropHost records "-" instead of the request host in all host labels
+	DropHost bool
+
+	// URLTemplates set the path label to the first matching template such as "/users/{id}";
+	// other paths are recorded as OtherPathLabel. Path labels are recorded even without
+	// IncludePathInMetrics
+	URLTemplates []string
+
+	// PathNormalizer returns the path label of a request; it takes precedence over the
+	// WithPathParam templates and URLTemplates
+	PathNormalizer func(req *http.Request) string
+
+	// StaticLabels are constant labels added to every metric, e.g. team or env.
+	// Clients sharing a Prometheus registerer must use the same label names
+	StaticLabels map[string]string
+
+	// RequestLabels are the label keys of WithLabel added to the request metrics, span attributes
+	// and log fields, e.g. "operation"; other keys are ignored. Requests without the label record
+	// an empty value. Clients sharing a Prometheus registerer must use the same label names
+	RequestLabels []string
+}
+
+// metricPath returns the path label of the request: the PathNormalizer result, the
+// WithPathParam template or the matched URL template.
+func (rt *RoundTripper) metricPath(req *http.Request) string {
+	labels := rt.config.MetricsLabels
+	template, templated := PathTemplateFromContext(req.Context())
+	switch {
+	case labels.PathNormalizer != nil:
+		return labels.PathNormalizer(req)
+	case templated:
+		return template
+	case len(labels.URLTemplates) > 0:
+		if template := MatchURLTemplate(labels.URLTemplates, req.URL.Path); template != "" {
+			return template
+		}
+		return OtherPathLabel
+	default:
+		return getPath(req.URL, rt.config.IncludePathInMetrics)
+	}
+}
+
+// staticLabelsKey returns the labels in a canonical "name=value" form for cache keys.
+func staticLabelsKey(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricsLabels_Prometheus(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		MetricsLabels: MetricsLabelsConfig{
+			DropHost:     true,
+			URLTemplates: []string{"/users/{id}"},
+			StaticLabels: map[string]string{"team": "payments", "env": "test"},
+		},
+	}, "test-metrics-labels")
+	defer client.Close()
+
+	for _, path := range []string{"/users/1", "/users/2", "/health"} {
+		resp, err := client.Get(context.Background(), server.URL+path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	labels := map[string]string{"client_name": "test-metrics-labels", "host": "-", "team": "payments", "env": "test"}
+	labels["path"] = "/users/{id}"
+	assert.Equal(t, 2.0, circuitBreakerMetric(t, reg, "http_client_requests_total", labels))
+	labels["path"] = OtherPathLabel
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, "http_client_requests_total", labels))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "host" {
+					assert.Equal(t, "-", label.GetValue(), family.GetName())
+				}
+			}
+		}
+	}
+}
+
+func TestMetricsLabels_PathNormalizer(t *testing.T) {
+	t.Parallel()
+	rt := &RoundTripper{config: Config{MetricsLabels: MetricsLabelsConfig{
+		URLTemplates: []string{"/users/{id}"},
+		PathNormalizer: func(req *http.Request) string {
+			return strings.ToLower(req.URL.Path)
+		},
+	}}}
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/Users/42", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/users/42", rt.metricPath(req))
+
+	rt.config.MetricsLabels.PathNormalizer = nil
+	assert.Equal(t, OtherPathLabel, rt.metricPath(req))
+
+	rt.config.MetricsLabels.URLTemplates = nil
+	assert.Equal(t, "-", rt.metricPath(req))
+	rt.config.IncludePathInMetrics = true
+	assert.Equal(t, "/Users/42", rt.metricPath(req))
+}
+
+func TestMetricsLabels_OpenTelemetryStaticLabels(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	reader := sdkmetric.NewManualReader()
+	client := New(Config{
+		MetricsBackend:    MetricsBackendOpenTelemetry,
+		OTelMeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		MetricsLabels: MetricsLabelsConfig{
+			DropHost:     true,
+			StaticLabels: map[string]string{"team": "payments"},
+		},
+	}, "test-metrics-labels-otel")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var found bool
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if m.Name != "http_client_requests_total" || !ok {
+				continue
+			}
+			for _, point := range sum.DataPoints {
+				team, _ := point.Attributes.Value(attribute.Key("team"))
+				host, _ := point.Attributes.Value(attribute.Key("host"))
+				assert.Equal(t, "payments", team.AsString())
+				assert.Equal(t, "-", host.AsString())
+				found = true
+			}
+		}
+	}
+	assert.True(t, found)
+}
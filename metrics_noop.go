@@ -33,6 +33,44 @@ func (n *NoopMetricsProvider) InflightInc(_ context.Context, _, _, _ string) {}
 // InflightDec does nothing.
 func (n *NoopMetricsProvider) InflightDec(_ context.Context, _, _, _ string) {}
 
+// RecordPhaseDuration does nothing.
+func (n *NoopMetricsProvider) RecordPhaseDuration(_ context.Context, _ float64, _, _, _ string) {}
+
+// RecordRedirect does nothing.
+func (n *NoopMetricsProvider) RecordRedirect(_ context.Context, _, _, _ string) {}
+
+// SetThrottled does nothing.
+func (n *NoopMetricsProvider) SetThrottled(_ context.Context, _ string, _ bool) {}
+
+// SetCircuitBreakerState does nothing.
+func (n *NoopMetricsProvider) SetCircuitBreakerState(_ context.Context, _ string, _ CircuitBreakerState) {
+}
+
+// RecordCircuitBreakerTransition does nothing.
+func (n *NoopMetricsProvider) RecordCircuitBreakerTransition(_ context.Context, _, _ CircuitBreakerState) {
+}
+
+// RecordShortCircuit does nothing.
+func (n *NoopMetricsProvider) RecordShortCircuit(_ context.Context, _, _ string) {}
+
+// RecordBackendRequest does nothing.
+func (n *NoopMetricsProvider) RecordBackendRequest(_ context.Context, _, _, _ string, _ float64) {}
+
+// RecordRetryBudgetExhausted does nothing.
+func (n *NoopMetricsProvider) RecordRetryBudgetExhausted(_ context.Context, _, _ string) {}
+
+// RecordWarmupConnection does nothing.
+func (n *NoopMetricsProvider) RecordWarmupConnection(_ context.Context, _, _ string) {}
+
+// SetSLOStatus does nothing.
+func (n *NoopMetricsProvider) SetSLOStatus(_ context.Context, _, _ string, _, _ float64) {}
+
+// RecordWebhookDelivery does nothing.
+func (n *NoopMetricsProvider) RecordWebhookDelivery(_ context.Context, _, _ string) {}
+
+// SetHostReachable does nothing.
+func (n *NoopMetricsProvider) SetHostReachable(_ context.Context, _ string, _ bool) {}
+
 // Close returns nil.
 func (n *NoopMetricsProvider) Close() error {
 	return nil
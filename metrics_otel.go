@@ -2,8 +2,8 @@ package httpclient
 
 import (
 	"context"
-	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 
 	"go.opentelemetry.io/otel"
@@ -19,27 +19,54 @@ type otelInstruments struct {
 	reqSize  metric.Float64Histogram
 	respSize metric.Float64Histogram
 	inflight metric.Int64UpDownCounter
+	phase    metric.Float64Histogram
+	redirect metric.Int64Counter
+	throttle metric.Int64Gauge
+	cbState  metric.Int64Gauge
+	cbChange metric.Int64Counter
+	cbShort  metric.Int64Counter
+	backend  metric.Int64Counter
+	backendD metric.Float64Histogram
+	budget   metric.Int64Counter
+	warmup   metric.Int64Counter
+	sloComp  metric.Float64Gauge
+	sloBurn  metric.Float64Gauge
+	webhook  metric.Int64Counter
+	reach    metric.Int64Gauge
 }
 
 // globalOtelInstruments caches instruments by MeterProvider.
-var globalOtelInstruments sync.Map // map[string]*otelInstruments
+var globalOtelInstruments sync.Map // map[metric.MeterProvider]*otelInstruments
 
 // OpenTelemetryMetricsProvider is a provider for collecting metrics via OpenTelemetry.
 type OpenTelemetryMetricsProvider struct {
 	clientName string
 	inst       *otelInstruments
+	// staticLabels adds the static labels to every measurement
+	staticLabels metric.MeasurementOption
+	// attrs caches the attribute sets of the per-request instruments
+	attrs *otelAttrCache
+	// requestLabels are the label names of WithLabel values added to request metrics
+	requestLabels []string
 }
 
 // NewOpenTelemetryMetricsProvider creates a new OpenTelemetry metrics provider.
 func NewOpenTelemetryMetricsProvider(clientName string, mp metric.MeterProvider) *OpenTelemetryMetricsProvider {
+	return NewOpenTelemetryMetricsProviderWithLabels(clientName, mp, nil)
+}
+
+// NewOpenTelemetryMetricsProviderWithLabels creates an OpenTelemetry metrics provider whose
+// measurements carry the static labels as attributes, e.g. team or env.
+func NewOpenTelemetryMetricsProviderWithLabels(
+	clientName string, mp metric.MeterProvider, staticLabels map[string]string,
+) *OpenTelemetryMetricsProvider {
 	if mp == nil {
 		mp = otel.GetMeterProvider()
 	}
 
-	// Use MeterProvider address as cache key
-	providerKey := fmt.Sprintf("%p", mp)
-
-	inst, exists := globalOtelInstruments.Load(providerKey)
+	// Use the MeterProvider itself as cache key: an address string could be
+	// reused by a new provider after the old one is garbage collected
+	inst, exists := globalOtelInstruments.Load(mp)
 	if !exists {
 		meter := mp.Meter("github.com/rurick/http-client")
 
@@ -80,6 +107,80 @@ func NewOpenTelemetryMetricsProvider(clientName string, mp metric.MeterProvider)
 			metric.WithDescription("Number of HTTP client requests currently in-flight"),
 		)
 
+		phase, _ := meter.Float64Histogram(
+			MetricPhaseDuration,
+			metric.WithDescription("HTTP client connection phase duration in seconds"),
+			metric.WithUnit("s"),
+			metric.WithExplicitBucketBoundaries(DefaultDurationBuckets...),
+		)
+
+		redirect, _ := meter.Int64Counter(
+			MetricRedirectsTotal,
+			metric.WithDescription("Total number of redirects followed by the HTTP client"),
+		)
+
+		throttle, _ := meter.Int64Gauge(
+			MetricThrottled,
+			metric.WithDescription("Whether the HTTP client throttles requests to the host after 429 responses (1 or 0)"),
+		)
+
+		cbState, _ := meter.Int64Gauge(
+			MetricCircuitBreakerState,
+			metric.WithDescription("Circuit breaker state observed by requests to the host (1 for the current state, 0 otherwise)"),
+		)
+
+		cbChange, _ := meter.Int64Counter(
+			MetricCircuitBreakerTransitions,
+			metric.WithDescription("Total number of circuit breaker state transitions"),
+		)
+
+		cbShort, _ := meter.Int64Counter(
+			MetricCircuitBreakerShortCircuits,
+			metric.WithDescription("Total number of HTTP client requests rejected by an open circuit breaker"),
+		)
+
+		backend, _ := meter.Int64Counter(
+			MetricBackendRequestsTotal,
+			metric.WithDescription("Total number of HTTP client attempts sent to load-balanced backends"),
+		)
+
+		backendD, _ := meter.Float64Histogram(
+			MetricBackendRequestDuration,
+			metric.WithDescription("HTTP client attempt duration per load-balanced backend in seconds"),
+			metric.WithUnit("s"),
+			metric.WithExplicitBucketBoundaries(DefaultDurationBuckets...),
+		)
+
+		budget, _ := meter.Int64Counter(
+			MetricRetryBudgetExhausted,
+			metric.WithDescription("Total number of HTTP client retries denied by the retry budget"),
+		)
+
+		warmup, _ := meter.Int64Counter(
+			MetricWarmupConnections,
+			metric.WithDescription("Total number of HTTP client warm-up connection attempts"),
+		)
+
+		sloComp, _ := meter.Float64Gauge(
+			MetricSLOCompliance,
+			metric.WithDescription("Share of HTTP client requests meeting the SLO objective in its rolling window"),
+		)
+
+		sloBurn, _ := meter.Float64Gauge(
+			MetricSLOBurnRate,
+			metric.WithDescription("Rate at which HTTP client requests spend the SLO error budget (above 1 the SLO is burning)"),
+		)
+
+		webhook, _ := meter.Int64Counter(
+			MetricWebhookDeliveries,
+			metric.WithDescription("Total number of webhook delivery attempts per destination"),
+		)
+
+		reach, _ := meter.Int64Gauge(
+			MetricHostReachable,
+			metric.WithDescription("Whether the host answered the latest HTTP client keep-alive ping (1 or 0)"),
+		)
+
 		newInst := &otelInstruments{
 			requests: requests,
 			retries:  retries,
@@ -87,101 +188,289 @@ func NewOpenTelemetryMetricsProvider(clientName string, mp metric.MeterProvider)
 			reqSize:  reqSize,
 			respSize: respSize,
 			inflight: inflight,
+			phase:    phase,
+			redirect: redirect,
+			throttle: throttle,
+			cbState:  cbState,
+			cbChange: cbChange,
+			cbShort:  cbShort,
+			backend:  backend,
+			backendD: backendD,
+			budget:   budget,
+			warmup:   warmup,
+			sloComp:  sloComp,
+			sloBurn:  sloBurn,
+			webhook:  webhook,
+			reach:    reach,
 		}
 
 		// Store in cache
-		globalOtelInstruments.Store(providerKey, newInst)
+		globalOtelInstruments.Store(mp, newInst)
 		inst = newInst
 	}
 
+	attrs := make([]attribute.KeyValue, 0, len(staticLabels))
+	for name, value := range staticLabels {
+		attrs = append(attrs, attribute.String(name, value))
+	}
 	return &OpenTelemetryMetricsProvider{
-		clientName: clientName,
-		inst:       inst.(*otelInstruments),
+		clientName:   clientName,
+		inst:         inst.(*otelInstruments),
+		staticLabels: metric.WithAttributes(attrs...),
+		attrs:        newOtelAttrCache(defaultOtelAttrCacheSize, attrs...),
 	}
 }
 
 // RecordRequest records a request metric.
 func (o *OpenTelemetryMetricsProvider) RecordRequest(ctx context.Context, method, host, path, status string, retry, hasError bool) {
+	key := otelAttrKey{kind: otelAttrRequest, method: method, host: host, path: path, status: status, retry: retry, hasError: hasError}
+	entry := o.requestAttrs(ctx, key, func() []attribute.KeyValue {
+		return []attribute.KeyValue{
+			attribute.String("client_name", o.clientName),
+			attribute.String("method", method),
+			attribute.String("host", host),
+			attribute.String("path", path),
+			attribute.String("status", status),
+			attribute.Bool("retry", retry),
+			attribute.Bool("error", hasError),
+		}
+	})
+	o.inst.requests.Add(ctx, 1, entry.add...)
+}
+
+// RecordDuration records request duration.
+func (o *OpenTelemetryMetricsProvider) RecordDuration(ctx context.Context, seconds float64, method, host, path, status string, attempt int) {
+	key := otelAttrKey{kind: otelAttrDuration, method: method, host: host, path: path, status: status, attempt: attempt}
+	entry := o.requestAttrs(ctx, key, func() []attribute.KeyValue {
+		return []attribute.KeyValue{
+			attribute.String("client_name", o.clientName),
+			attribute.String("method", method),
+			attribute.String("host", host),
+			attribute.String("path", path),
+			attribute.String("status", status),
+			attribute.String("attempt", strconv.Itoa(attempt)),
+		}
+	})
+	o.inst.duration.Record(ctx, seconds, entry.record...)
+}
+
+// RecordRetry records a retry attempt metric.
+func (o *OpenTelemetryMetricsProvider) RecordRetry(ctx context.Context, reason, method, host, path string) {
+	key := otelAttrKey{kind: otelAttrRetry, method: method, host: host, path: path, label: reason}
+	entry := o.requestAttrs(ctx, key, func() []attribute.KeyValue {
+		return []attribute.KeyValue{
+			attribute.String("client_name", o.clientName),
+			attribute.String("reason", reason),
+			attribute.String("method", method),
+			attribute.String("host", host),
+			attribute.String("path", path),
+		}
+	})
+	o.inst.retries.Add(ctx, 1, entry.add...)
+}
+
+// endpointAttrs returns the attribute set shared by the request size and in-flight instruments.
+func (o *OpenTelemetryMetricsProvider) endpointAttrs(ctx context.Context, method, host, path string) *otelAttrEntry {
+	key := otelAttrKey{kind: otelAttrEndpoint, method: method, host: host, path: path}
+	return o.requestAttrs(ctx, key, func() []attribute.KeyValue {
+		return []attribute.KeyValue{
+			attribute.String("client_name", o.clientName),
+			attribute.String("method", method),
+			attribute.String("host", host),
+			attribute.String("path", path),
+		}
+	})
+}
+
+// requestAttrs returns the attribute set of a request instrument, adding the request labels of ctx.
+func (o *OpenTelemetryMetricsProvider) requestAttrs(
+	ctx context.Context, key otelAttrKey, build func() []attribute.KeyValue,
+) *otelAttrEntry {
+	if len(o.requestLabels) == 0 {
+		return o.attrs.get(key, build)
+	}
+	values := appendRequestLabelValues(ctx, make([]string, 0, len(o.requestLabels)), o.requestLabels)
+	key.requestLabels = strings.Join(values, "\x00")
+	return o.attrs.get(key, func() []attribute.KeyValue {
+		attrs := build()
+		for i, name := range o.requestLabels {
+			attrs = append(attrs, attribute.String(name, values[i]))
+		}
+		return attrs
+	})
+}
+
+// RecordRequestSize records request size.
+func (o *OpenTelemetryMetricsProvider) RecordRequestSize(ctx context.Context, bytes int64, method, host, path string) {
+	o.inst.reqSize.Record(ctx, float64(bytes), o.endpointAttrs(ctx, method, host, path).record...)
+}
+
+// RecordResponseSize records response size.
+func (o *OpenTelemetryMetricsProvider) RecordResponseSize(ctx context.Context, bytes int64, method, host, path, status string) {
+	key := otelAttrKey{kind: otelAttrResponseSize, method: method, host: host, path: path, status: status}
+	entry := o.requestAttrs(ctx, key, func() []attribute.KeyValue {
+		return []attribute.KeyValue{
+			attribute.String("client_name", o.clientName),
+			attribute.String("method", method),
+			attribute.String("host", host),
+			attribute.String("path", path),
+			attribute.String("status", status),
+		}
+	})
+	o.inst.respSize.Record(ctx, float64(bytes), entry.record...)
+}
+
+// InflightInc increments the active requests counter.
+func (o *OpenTelemetryMetricsProvider) InflightInc(ctx context.Context, method, host, path string) {
+	o.inst.inflight.Add(ctx, 1, o.endpointAttrs(ctx, method, host, path).add...)
+}
+
+// InflightDec decrements the active requests counter.
+func (o *OpenTelemetryMetricsProvider) InflightDec(ctx context.Context, method, host, path string) {
+	o.inst.inflight.Add(ctx, -1, o.endpointAttrs(ctx, method, host, path).add...)
+}
+
+// RecordPhaseDuration records a connection phase duration.
+func (o *OpenTelemetryMetricsProvider) RecordPhaseDuration(ctx context.Context, seconds float64, phase, method, host string) {
+	key := otelAttrKey{kind: otelAttrPhase, method: method, host: host, label: phase}
+	entry := o.attrs.get(key, func() []attribute.KeyValue {
+		return []attribute.KeyValue{
+			attribute.String("client_name", o.clientName),
+			attribute.String("phase", phase),
+			attribute.String("method", method),
+			attribute.String("host", host),
+		}
+	})
+	o.inst.phase.Record(ctx, seconds, entry.record...)
+}
+
+// RecordRedirect records a followed redirect.
+func (o *OpenTelemetryMetricsProvider) RecordRedirect(ctx context.Context, method, host, status string) {
 	attrs := []attribute.KeyValue{
 		attribute.String("client_name", o.clientName),
 		attribute.String("method", method),
 		attribute.String("host", host),
-		attribute.String("path", path),
 		attribute.String("status", status),
-		attribute.Bool("retry", retry),
-		attribute.Bool("error", hasError),
 	}
-	o.inst.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+	o.inst.redirect.Add(ctx, 1, metric.WithAttributes(attrs...), o.staticLabels)
 }
 
-// RecordDuration records request duration.
-func (o *OpenTelemetryMetricsProvider) RecordDuration(ctx context.Context, seconds float64, method, host, path, status string, attempt int) {
+// SetThrottled records whether requests to the host are throttled.
+func (o *OpenTelemetryMetricsProvider) SetThrottled(ctx context.Context, host string, throttled bool) {
 	attrs := []attribute.KeyValue{
 		attribute.String("client_name", o.clientName),
-		attribute.String("method", method),
 		attribute.String("host", host),
-		attribute.String("path", path),
-		attribute.String("status", status),
-		attribute.String("attempt", strconv.Itoa(attempt)),
 	}
-	o.inst.duration.Record(ctx, seconds, metric.WithAttributes(attrs...))
+	var value int64
+	if throttled {
+		value = 1
+	}
+	o.inst.throttle.Record(ctx, value, metric.WithAttributes(attrs...), o.staticLabels)
 }
 
-// RecordRetry records a retry attempt metric.
-func (o *OpenTelemetryMetricsProvider) RecordRetry(ctx context.Context, reason, method, host, path string) {
+// SetCircuitBreakerState records 1 for the current state and 0 for the other states.
+func (o *OpenTelemetryMetricsProvider) SetCircuitBreakerState(ctx context.Context, host string, state CircuitBreakerState) {
+	for _, s := range circuitBreakerStates {
+		key := otelAttrKey{kind: otelAttrCircuitBreaker, host: host, label: s.String()}
+		entry := o.attrs.get(key, func() []attribute.KeyValue {
+			return []attribute.KeyValue{
+				attribute.String("client_name", o.clientName),
+				attribute.String("host", host),
+				attribute.String("state", s.String()),
+			}
+		})
+		var value int64
+		if s == state {
+			value = 1
+		}
+		o.inst.cbState.Record(ctx, value, entry.record...)
+	}
+}
+
+// RecordCircuitBreakerTransition records a breaker state change.
+func (o *OpenTelemetryMetricsProvider) RecordCircuitBreakerTransition(ctx context.Context, from, to CircuitBreakerState) {
 	attrs := []attribute.KeyValue{
 		attribute.String("client_name", o.clientName),
-		attribute.String("reason", reason),
-		attribute.String("method", method),
-		attribute.String("host", host),
-		attribute.String("path", path),
+		attribute.String("from", from.String()),
+		attribute.String("to", to.String()),
 	}
-	o.inst.retries.Add(ctx, 1, metric.WithAttributes(attrs...))
+	o.inst.cbChange.Add(ctx, 1, metric.WithAttributes(attrs...), o.staticLabels)
 }
 
-// RecordRequestSize records request size.
-func (o *OpenTelemetryMetricsProvider) RecordRequestSize(ctx context.Context, bytes int64, method, host, path string) {
+// RecordShortCircuit records a request rejected by an open breaker.
+func (o *OpenTelemetryMetricsProvider) RecordShortCircuit(ctx context.Context, method, host string) {
 	attrs := []attribute.KeyValue{
 		attribute.String("client_name", o.clientName),
 		attribute.String("method", method),
 		attribute.String("host", host),
-		attribute.String("path", path),
 	}
-	o.inst.reqSize.Record(ctx, float64(bytes), metric.WithAttributes(attrs...))
+	o.inst.cbShort.Add(ctx, 1, metric.WithAttributes(attrs...), o.staticLabels)
 }
 
-// RecordResponseSize records response size.
-func (o *OpenTelemetryMetricsProvider) RecordResponseSize(ctx context.Context, bytes int64, method, host, path, status string) {
+// RecordBackendRequest records an attempt sent to a load-balanced backend.
+func (o *OpenTelemetryMetricsProvider) RecordBackendRequest(ctx context.Context, service, backend, status string, seconds float64) {
+	attrs := []attribute.KeyValue{
+		attribute.String("client_name", o.clientName),
+		attribute.String("service", service),
+		attribute.String("backend", backend),
+	}
+	o.inst.backendD.Record(ctx, seconds, metric.WithAttributes(attrs...), o.staticLabels)
+	attrs = append(attrs, attribute.String("status", status))
+	o.inst.backend.Add(ctx, 1, metric.WithAttributes(attrs...), o.staticLabels)
+}
+
+// RecordRetryBudgetExhausted records a retry denied by the retry budget.
+func (o *OpenTelemetryMetricsProvider) RecordRetryBudgetExhausted(ctx context.Context, method, host string) {
 	attrs := []attribute.KeyValue{
 		attribute.String("client_name", o.clientName),
 		attribute.String("method", method),
 		attribute.String("host", host),
-		attribute.String("path", path),
-		attribute.String("status", status),
 	}
-	o.inst.respSize.Record(ctx, float64(bytes), metric.WithAttributes(attrs...))
+	o.inst.budget.Add(ctx, 1, metric.WithAttributes(attrs...), o.staticLabels)
 }
 
-// InflightInc increments the active requests counter.
-func (o *OpenTelemetryMetricsProvider) InflightInc(ctx context.Context, method, host, path string) {
+// RecordWarmupConnection records a warm-up connection attempt.
+func (o *OpenTelemetryMetricsProvider) RecordWarmupConnection(ctx context.Context, host, result string) {
 	attrs := []attribute.KeyValue{
 		attribute.String("client_name", o.clientName),
-		attribute.String("method", method),
 		attribute.String("host", host),
-		attribute.String("path", path),
+		attribute.String("result", result),
 	}
-	o.inst.inflight.Add(ctx, 1, metric.WithAttributes(attrs...))
+	o.inst.warmup.Add(ctx, 1, metric.WithAttributes(attrs...), o.staticLabels)
 }
 
-// InflightDec decrements the active requests counter.
-func (o *OpenTelemetryMetricsProvider) InflightDec(ctx context.Context, method, host, path string) {
+// SetSLOStatus records the compliance and burn rate of an SLO objective.
+func (o *OpenTelemetryMetricsProvider) SetSLOStatus(ctx context.Context, slo, objective string, compliance, burnRate float64) {
+	attrs := metric.WithAttributes(
+		attribute.String("client_name", o.clientName),
+		attribute.String("slo", slo),
+		attribute.String("objective", objective),
+	)
+	o.inst.sloComp.Record(ctx, compliance, attrs, o.staticLabels)
+	o.inst.sloBurn.Record(ctx, burnRate, attrs, o.staticLabels)
+}
+
+// RecordWebhookDelivery records a webhook delivery attempt.
+func (o *OpenTelemetryMetricsProvider) RecordWebhookDelivery(ctx context.Context, destination, result string) {
+	attrs := []attribute.KeyValue{
+		attribute.String("client_name", o.clientName),
+		attribute.String("destination", destination),
+		attribute.String("result", result),
+	}
+	o.inst.webhook.Add(ctx, 1, metric.WithAttributes(attrs...), o.staticLabels)
+}
+
+// SetHostReachable records whether the host answered the latest keep-alive ping.
+func (o *OpenTelemetryMetricsProvider) SetHostReachable(ctx context.Context, host string, reachable bool) {
 	attrs := []attribute.KeyValue{
 		attribute.String("client_name", o.clientName),
-		attribute.String("method", method),
 		attribute.String("host", host),
-		attribute.String("path", path),
 	}
-	o.inst.inflight.Add(ctx, -1, metric.WithAttributes(attrs...))
+	var value int64
+	if reachable {
+		value = 1
+	}
+	o.inst.reach.Record(ctx, value, metric.WithAttributes(attrs...), o.staticLabels)
 }
 
 // Close releases resources.
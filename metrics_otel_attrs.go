@@ -0,0 +1,105 @@
+package httpclient
+
+import (
+	"container/list"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// defaultOtelAttrCacheSize bounds the number of pre-bound attribute sets per provider.
+const defaultOtelAttrCacheSize = 1024
+
+// otelAttrKind identifies the instrument an attribute set belongs to.
+type otelAttrKind uint8
+
+const (
+	otelAttrRequest otelAttrKind = iota
+	otelAttrDuration
+	otelAttrRetry
+	otelAttrEndpoint
+	otelAttrResponseSize
+	otelAttrPhase
+	otelAttrCircuitBreaker
+)
+
+// otelAttrKey is the label values of a measurement. label holds the reason, phase or
+// breaker state, attempt the attempt number of the instrument, when it has one, and
+// requestLabels the joined request label values.
+type otelAttrKey struct {
+	kind                              otelAttrKind
+	method, host, path, status, label string
+	requestLabels                     string
+	attempt                           int
+	retry, hasError                   bool
+}
+
+// otelAttrEntry is a pre-bound attribute set. The option slices are shared by all
+// measurements with the same labels, so recording does not allocate.
+type otelAttrEntry struct {
+	key    otelAttrKey
+	add    []metric.AddOption
+	record []metric.RecordOption
+}
+
+// otelAttrCache is an LRU cache of attribute sets by label values.
+type otelAttrCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[otelAttrKey]*list.Element
+	static  []attribute.KeyValue
+}
+
+// newOtelAttrCache creates a cache holding up to size attribute sets.
+func newOtelAttrCache(size int, static ...attribute.KeyValue) *otelAttrCache {
+	return &otelAttrCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[otelAttrKey]*list.Element),
+		static:  static,
+	}
+}
+
+// get returns the attribute set for the key, building it from the attributes returned
+// by build and the static labels on a miss.
+func (c *otelAttrCache) get(key otelAttrKey, build func() []attribute.KeyValue) *otelAttrEntry {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.mu.Unlock()
+		return elem.Value.(*otelAttrEntry)
+	}
+	c.mu.Unlock()
+
+	// Build outside the lock: a concurrent miss builds the same set twice at worst
+	attrs := append(build(), c.static...)
+	option := metric.WithAttributeSet(attribute.NewSet(attrs...))
+	entry := &otelAttrEntry{
+		key:    key,
+		add:    []metric.AddOption{option},
+		record: []metric.RecordOption{option},
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*otelAttrEntry)
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	if c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*otelAttrEntry).key)
+	}
+	return entry
+}
+
+// len returns the number of cached attribute sets.
+func (c *otelAttrCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
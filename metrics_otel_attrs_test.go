@@ -0,0 +1,173 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestOtelAttrCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	cache := newOtelAttrCache(2)
+	builds := 0
+	get := func(path string) {
+		cache.get(otelAttrKey{kind: otelAttrRequest, path: path}, func() []attribute.KeyValue {
+			builds++
+			return []attribute.KeyValue{attribute.String("path", path)}
+		})
+	}
+
+	get("/a")
+	get("/b")
+	get("/a")
+	get("/c") // evicts /b
+	assert.Equal(t, 3, builds)
+	assert.Equal(t, 2, cache.len())
+
+	get("/a")
+	assert.Equal(t, 3, builds)
+	get("/b")
+	assert.Equal(t, 4, builds)
+}
+
+func TestOpenTelemetryMetricsProvider_CachedAttributes(t *testing.T) {
+	t.Parallel()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	provider := NewOpenTelemetryMetricsProviderWithLabels("test-otel-attrs", mp, map[string]string{"team": "payments"})
+	ctx := context.Background()
+	for range 3 {
+		provider.RecordRequest(ctx, "GET", "example.com", "/users", "200", false, false)
+	}
+	provider.RecordRequest(ctx, "GET", "example.com", "/users", "500", true, true)
+	provider.RecordDuration(ctx, 0.1, "GET", "example.com", "/users", "200", 2)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	points := map[string]int64{}
+	var attempt string
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				if m.Name != MetricRequestsTotal {
+					continue
+				}
+				for _, point := range data.DataPoints {
+					team, _ := point.Attributes.Value("team")
+					status, _ := point.Attributes.Value("status")
+					retry, _ := point.Attributes.Value("retry")
+					assert.Equal(t, "payments", team.AsString())
+					assert.Equal(t, status.AsString() == "500", retry.AsBool())
+					points[status.AsString()] = point.Value
+				}
+			case metricdata.Histogram[float64]:
+				if m.Name == MetricRequestDuration {
+					value, _ := data.DataPoints[0].Attributes.Value("attempt")
+					attempt = value.AsString()
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{"200": 3, "500": 1}, points)
+	assert.Equal(t, "2", attempt)
+}
+
+// Not parallel: AllocsPerRun counts the allocations of all goroutines.
+func TestOpenTelemetryMetricsProvider_RecordingDoesNotAllocate(t *testing.T) {
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+	provider := NewOpenTelemetryMetricsProvider("test-otel-allocs", mp)
+
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(100, func() {
+		provider.InflightInc(ctx, "GET", "example.com", "/health")
+		provider.RecordRequest(ctx, "GET", "example.com", "/health", "200", false, false)
+		provider.RecordDuration(ctx, 0.001, "GET", "example.com", "/health", "200", 1)
+		provider.InflightDec(ctx, "GET", "example.com", "/health")
+	})
+	assert.Zero(t, allocs)
+}
+
+// BenchmarkOpenTelemetryMetrics_PerRequest measures the OpenTelemetry metrics calls made
+// for a request without retries.
+func BenchmarkOpenTelemetryMetrics_PerRequest(b *testing.B) {
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+	metrics := NewMetricsWithProvider("benchmark-otel-calls", NewOpenTelemetryMetricsProvider("benchmark-otel-calls", mp))
+	defer metrics.Close()
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	for range b.N {
+		metrics.IncrementInflight(ctx, "GET", "example.com", "/health")
+		metrics.RecordRequestSize(ctx, 0, "GET", "example.com", "/health")
+		metrics.RecordRequest(ctx, "GET", "example.com", "/health", "200", false, false)
+		metrics.RecordDuration(ctx, 0.001, "GET", "example.com", "/health", "200", 1)
+		metrics.RecordResponseSize(ctx, 2, "GET", "example.com", "/health", "200")
+		metrics.DecrementInflight(ctx, "GET", "example.com", "/health")
+	}
+}
+
+// BenchmarkRoundTrip_OpenTelemetry measures a request without retries through the client
+// with the default OpenTelemetry metrics.
+func BenchmarkRoundTrip_OpenTelemetry(b *testing.B) {
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+	client := New(Config{Transport: staticTransport{}, OTelMeterProvider: mp}, "benchmark-otel")
+	defer client.Close()
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		resp, err := client.Get(ctx, "http://example.com/health")
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+}
+
+// reusedResponseTransport answers every request with the same response, so that the
+// benchmark counts only the allocations of the client.
+type reusedResponseTransport struct {
+	resp *http.Response
+}
+
+func (t *reusedResponseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.resp.Body = http.NoBody
+	t.resp.Request = req
+	return t.resp, nil
+}
+
+// BenchmarkRoundTripper_NoRetry measures the RoundTripper alone, without the http.Client
+// around it, for a request without retries.
+func BenchmarkRoundTripper_NoRetry(b *testing.B) {
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+	transport := &reusedResponseTransport{resp: &http.Response{StatusCode: http.StatusOK, Header: make(http.Header)}}
+	client := New(Config{Transport: transport, OTelMeterProvider: mp}, "benchmark-roundtripper")
+	defer client.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://example.com/health", nil)
+	require.NoError(b, err)
+	rt := client.httpClient.Transport
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+}
@@ -2,8 +2,8 @@ package httpclient
 
 import (
 	"context"
-	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -17,100 +17,284 @@ type prometheusGlobalMetrics struct {
 	InflightRequests *prometheus.GaugeVec
 	RequestSize      *prometheus.HistogramVec
 	ResponseSize     *prometheus.HistogramVec
+	PhaseDuration    *prometheus.HistogramVec
+	RedirectsTotal   *prometheus.CounterVec
+	Throttled        *prometheus.GaugeVec
+	CircuitState     *prometheus.GaugeVec
+	CircuitChanges   *prometheus.CounterVec
+	ShortCircuits    *prometheus.CounterVec
+	BackendRequests  *prometheus.CounterVec
+	BackendDuration  *prometheus.HistogramVec
+	BudgetExhausted  *prometheus.CounterVec
+	WarmupConns      *prometheus.CounterVec
+	SLOCompliance    *prometheus.GaugeVec
+	SLOBurnRate      *prometheus.GaugeVec
+	WebhookDelivery  *prometheus.CounterVec
+	HostReachable    *prometheus.GaugeVec
+
+	// reg is the registerer of the vectors and registered the vectors this set registered itself
+	reg        prometheus.Registerer
+	registered []prometheus.Collector
+	// clients counts the open providers by client name, guarded by prometheusRegistrations.mu
+	clients map[string]int
 }
 
-// globalPrometheusMetrics caches registered metrics by registerer.
-var globalPrometheusMetrics sync.Map // map[string]*prometheusGlobalMetrics
+// prometheusMetricsKey identifies a set of registered metrics.
+type prometheusMetricsKey struct {
+	reg           prometheus.Registerer
+	labels        string // canonical static labels, see staticLabelsKey
+	requestLabels string // names of MetricsLabelsConfig.RequestLabels
+}
 
 // PrometheusMetricsProvider is a provider for collecting metrics via Prometheus.
 type PrometheusMetricsProvider struct {
 	clientName string
 	metrics    *prometheusGlobalMetrics
+	// requestLabels are the label names of WithLabel values added to request metrics
+	requestLabels []string
+	closeOnce     sync.Once
 }
 
 // NewPrometheusMetricsProvider creates a new Prometheus metrics provider.
 func NewPrometheusMetricsProvider(clientName string, reg prometheus.Registerer) *PrometheusMetricsProvider {
+	return NewPrometheusMetricsProviderWithLabels(clientName, reg, nil)
+}
+
+// NewPrometheusMetricsProviderWithLabels creates a Prometheus metrics provider whose metrics
+// carry the static labels, e.g. team or env. All providers of a registerer must use the same
+// label names, otherwise registration panics. Close the provider to remove its series.
+func NewPrometheusMetricsProviderWithLabels(
+	clientName string, reg prometheus.Registerer, staticLabels map[string]string,
+) *PrometheusMetricsProvider {
+	return newPrometheusMetricsProvider(clientName, reg, staticLabels, MetricsBuckets{}, nil)
+}
+
+// newPrometheusMetricsProvider creates a Prometheus metrics provider with static labels,
+// histogram buckets and the request labels added to request metrics.
+func newPrometheusMetricsProvider(
+	clientName string, reg prometheus.Registerer, staticLabels map[string]string, buckets MetricsBuckets,
+	requestLabels []string,
+) *PrometheusMetricsProvider {
+	provider, _ := acquirePrometheusMetricsProvider(clientName, reg, staticLabels, buckets, requestLabels, false)
+	return provider
+}
+
+// acquirePrometheusMetricsProvider creates a Prometheus metrics provider holding a reference to
+// the metrics registered in reg. With unique it fails if an open provider of reg has the same
+// client name.
+func acquirePrometheusMetricsProvider(
+	clientName string, reg prometheus.Registerer, staticLabels map[string]string, buckets MetricsBuckets,
+	requestLabels []string, unique bool,
+) (*PrometheusMetricsProvider, error) {
 	if reg == nil {
 		reg = prometheus.DefaultRegisterer
 	}
-
-	// Use registerer address as cache key
-	registryKey := fmt.Sprintf("%p", reg)
-
-	metrics, exists := globalPrometheusMetrics.Load(registryKey)
-	if !exists {
-		// Create and register metrics
-		newMetrics := &prometheusGlobalMetrics{
-			RequestsTotal: prometheus.NewCounterVec(
-				prometheus.CounterOpts{
-					Name: MetricRequestsTotal,
-					Help: "Total number of HTTP client requests",
-				},
-				[]string{"client_name", "method", "host", "path", "status", "retry", "error"},
-			),
-			RequestDuration: prometheus.NewHistogramVec(
-				prometheus.HistogramOpts{
-					Name:    MetricRequestDuration,
-					Help:    "HTTP client request duration in seconds",
-					Buckets: DefaultDurationBuckets,
-				},
-				[]string{"client_name", "method", "host", "path", "status", "attempt"},
-			),
-			RetriesTotal: prometheus.NewCounterVec(
-				prometheus.CounterOpts{
-					Name: MetricRetriesTotal,
-					Help: "Total number of HTTP client retries",
-				},
-				[]string{"client_name", "reason", "method", "host", "path"},
-			),
-			InflightRequests: prometheus.NewGaugeVec(
-				prometheus.GaugeOpts{
-					Name: MetricInflightRequests,
-					Help: "Number of HTTP client requests currently in-flight",
-				},
-				[]string{"client_name", "method", "host", "path"},
-			),
-			RequestSize: prometheus.NewHistogramVec(
-				prometheus.HistogramOpts{
-					Name:    MetricRequestSizeBytes,
-					Help:    "HTTP client request size in bytes",
-					Buckets: DefaultSizeBuckets,
-				},
-				[]string{"client_name", "method", "host", "path"},
-			),
-			ResponseSize: prometheus.NewHistogramVec(
-				prometheus.HistogramOpts{
-					Name:    MetricResponseSizeBytes,
-					Help:    "HTTP client response size in bytes",
-					Buckets: DefaultSizeBuckets,
-				},
-				[]string{"client_name", "method", "host", "path", "status"},
-			),
-		}
-
-		// Register all metrics
-		reg.MustRegister(
-			newMetrics.RequestsTotal,
-			newMetrics.RequestDuration,
-			newMetrics.RetriesTotal,
-			newMetrics.InflightRequests,
-			newMetrics.RequestSize,
-			newMetrics.ResponseSize,
-		)
-
-		// Store in cache
-		globalPrometheusMetrics.Store(registryKey, newMetrics)
-		metrics = newMetrics
+	// Use the registerer itself as cache key: an address string could be
+	// reused by a new registerer after the old one is garbage collected
+	key := prometheusMetricsKey{
+		reg:           reg,
+		labels:        staticLabelsKey(staticLabels),
+		requestLabels: strings.Join(requestLabels, "\x00"),
+	}
+	metrics, err := acquirePrometheusMetrics(key, clientName, unique, func() *prometheusGlobalMetrics {
+		return newPrometheusGlobalMetrics(reg, prometheus.Labels(staticLabels), buckets.withDefaults(), requestLabels)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &PrometheusMetricsProvider{
-		clientName: clientName,
-		metrics:    metrics.(*prometheusGlobalMetrics),
+		clientName:    clientName,
+		metrics:       metrics,
+		requestLabels: requestLabels,
+	}, nil
+}
+
+// newPrometheusGlobalMetrics creates the metric vectors and registers them in reg.
+func newPrometheusGlobalMetrics(
+	reg prometheus.Registerer, constLabels prometheus.Labels, buckets MetricsBuckets, requestLabels []string,
+) *prometheusGlobalMetrics {
+	// Request metrics also carry the request labels
+	requestLabelNames := func(names ...string) []string {
+		return append(names, requestLabels...)
+	}
+	// Create and register metrics
+	var registered []prometheus.Collector
+	metrics := &prometheusGlobalMetrics{
+		RequestsTotal: registerCollector(reg, &registered, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        MetricRequestsTotal,
+				ConstLabels: constLabels,
+				Help:        "Total number of HTTP client requests",
+			},
+			requestLabelNames("client_name", "method", "host", "path", "status", "retry", "error"),
+		)),
+		RequestDuration: registerCollector(reg, &registered, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:        MetricRequestDuration,
+				ConstLabels: constLabels,
+				Help:        "HTTP client request duration in seconds",
+				Buckets:     buckets.Duration,
+			},
+			requestLabelNames("client_name", "method", "host", "path", "status", "attempt"),
+		)),
+		RetriesTotal: registerCollector(reg, &registered, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        MetricRetriesTotal,
+				ConstLabels: constLabels,
+				Help:        "Total number of HTTP client retries",
+			},
+			requestLabelNames("client_name", "reason", "method", "host", "path"),
+		)),
+		InflightRequests: registerCollector(reg, &registered, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        MetricInflightRequests,
+				ConstLabels: constLabels,
+				Help:        "Number of HTTP client requests currently in-flight",
+			},
+			requestLabelNames("client_name", "method", "host", "path"),
+		)),
+		RequestSize: registerCollector(reg, &registered, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:        MetricRequestSizeBytes,
+				ConstLabels: constLabels,
+				Help:        "HTTP client request size in bytes",
+				Buckets:     buckets.RequestSize,
+			},
+			requestLabelNames("client_name", "method", "host", "path"),
+		)),
+		ResponseSize: registerCollector(reg, &registered, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:        MetricResponseSizeBytes,
+				ConstLabels: constLabels,
+				Help:        "HTTP client response size in bytes",
+				Buckets:     buckets.ResponseSize,
+			},
+			requestLabelNames("client_name", "method", "host", "path", "status"),
+		)),
+		PhaseDuration: registerCollector(reg, &registered, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:        MetricPhaseDuration,
+				ConstLabels: constLabels,
+				Help:        "HTTP client connection phase duration in seconds",
+				Buckets:     DefaultDurationBuckets,
+			},
+			[]string{"client_name", "phase", "method", "host"},
+		)),
+		RedirectsTotal: registerCollector(reg, &registered, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        MetricRedirectsTotal,
+				ConstLabels: constLabels,
+				Help:        "Total number of redirects followed by the HTTP client",
+			},
+			[]string{"client_name", "method", "host", "status"},
+		)),
+		Throttled: registerCollector(reg, &registered, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        MetricThrottled,
+				ConstLabels: constLabels,
+				Help:        "Whether the HTTP client throttles requests to the host after 429 responses (1 or 0)",
+			},
+			[]string{"client_name", "host"},
+		)),
+		CircuitState: registerCollector(reg, &registered, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        MetricCircuitBreakerState,
+				ConstLabels: constLabels,
+				Help:        "Circuit breaker state observed by requests to the host (1 for the current state, 0 otherwise)",
+			},
+			[]string{"client_name", "host", "state"},
+		)),
+		CircuitChanges: registerCollector(reg, &registered, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        MetricCircuitBreakerTransitions,
+				ConstLabels: constLabels,
+				Help:        "Total number of circuit breaker state transitions",
+			},
+			[]string{"client_name", "from", "to"},
+		)),
+		ShortCircuits: registerCollector(reg, &registered, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        MetricCircuitBreakerShortCircuits,
+				ConstLabels: constLabels,
+				Help:        "Total number of HTTP client requests rejected by an open circuit breaker",
+			},
+			[]string{"client_name", "method", "host"},
+		)),
+		BackendRequests: registerCollector(reg, &registered, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        MetricBackendRequestsTotal,
+				ConstLabels: constLabels,
+				Help:        "Total number of HTTP client attempts sent to load-balanced backends",
+			},
+			[]string{"client_name", "service", "backend", "status"},
+		)),
+		BackendDuration: registerCollector(reg, &registered, prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:        MetricBackendRequestDuration,
+				ConstLabels: constLabels,
+				Help:        "HTTP client attempt duration per load-balanced backend in seconds",
+				Buckets:     DefaultDurationBuckets,
+			},
+			[]string{"client_name", "service", "backend"},
+		)),
+		BudgetExhausted: registerCollector(reg, &registered, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        MetricRetryBudgetExhausted,
+				ConstLabels: constLabels,
+				Help:        "Total number of HTTP client retries denied by the retry budget",
+			},
+			[]string{"client_name", "method", "host"},
+		)),
+		WarmupConns: registerCollector(reg, &registered, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        MetricWarmupConnections,
+				ConstLabels: constLabels,
+				Help:        "Total number of HTTP client warm-up connection attempts",
+			},
+			[]string{"client_name", "host", "result"},
+		)),
+		SLOCompliance: registerCollector(reg, &registered, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        MetricSLOCompliance,
+				ConstLabels: constLabels,
+				Help:        "Share of HTTP client requests meeting the SLO objective in its rolling window",
+			},
+			[]string{"client_name", "slo", "objective"},
+		)),
+		SLOBurnRate: registerCollector(reg, &registered, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        MetricSLOBurnRate,
+				ConstLabels: constLabels,
+				Help:        "Rate at which HTTP client requests spend the SLO error budget (above 1 the SLO is burning)",
+			},
+			[]string{"client_name", "slo", "objective"},
+		)),
+		WebhookDelivery: registerCollector(reg, &registered, prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name:        MetricWebhookDeliveries,
+				ConstLabels: constLabels,
+				Help:        "Total number of webhook delivery attempts per destination",
+			},
+			[]string{"client_name", "destination", "result"},
+		)),
+		HostReachable: registerCollector(reg, &registered, prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name:        MetricHostReachable,
+				ConstLabels: constLabels,
+				Help:        "Whether the host answered the latest HTTP client keep-alive ping (1 or 0)",
+			},
+			[]string{"client_name", "host"},
+		)),
 	}
+	metrics.reg = reg
+	metrics.registered = registered
+	metrics.clients = make(map[string]int)
+	return metrics
 }
 
 // RecordRequest records a request metric.
-func (p *PrometheusMetricsProvider) RecordRequest(_ context.Context, method, host, path, status string, retry, hasError bool) {
+func (p *PrometheusMetricsProvider) RecordRequest(ctx context.Context, method, host, path, status string, retry, hasError bool) {
 	retryStr := "false"
 	if retry {
 		retryStr = "true"
@@ -119,41 +303,130 @@ func (p *PrometheusMetricsProvider) RecordRequest(_ context.Context, method, hos
 	if hasError {
 		errorStr = "true"
 	}
-	p.metrics.RequestsTotal.WithLabelValues(p.clientName, method, host, path, status, retryStr, errorStr).Inc()
+	p.metrics.RequestsTotal.WithLabelValues(p.labelValues(ctx, p.clientName, method, host, path, status, retryStr, errorStr)...).Inc()
 }
 
 // RecordDuration records request duration.
-func (p *PrometheusMetricsProvider) RecordDuration(_ context.Context, seconds float64, method, host, path, status string, attempt int) {
+func (p *PrometheusMetricsProvider) RecordDuration(ctx context.Context, seconds float64, method, host, path, status string, attempt int) {
 	attemptStr := strconv.Itoa(attempt)
-	p.metrics.RequestDuration.WithLabelValues(p.clientName, method, host, path, status, attemptStr).Observe(seconds)
+	p.metrics.RequestDuration.WithLabelValues(p.labelValues(ctx, p.clientName, method, host, path, status, attemptStr)...).Observe(seconds)
 }
 
 // RecordRetry records a retry attempt metric.
-func (p *PrometheusMetricsProvider) RecordRetry(_ context.Context, reason, method, host, path string) {
-	p.metrics.RetriesTotal.WithLabelValues(p.clientName, reason, method, host, path).Inc()
+func (p *PrometheusMetricsProvider) RecordRetry(ctx context.Context, reason, method, host, path string) {
+	p.metrics.RetriesTotal.WithLabelValues(p.labelValues(ctx, p.clientName, reason, method, host, path)...).Inc()
 }
 
 // RecordRequestSize records request size.
-func (p *PrometheusMetricsProvider) RecordRequestSize(_ context.Context, bytes int64, method, host, path string) {
-	p.metrics.RequestSize.WithLabelValues(p.clientName, method, host, path).Observe(float64(bytes))
+func (p *PrometheusMetricsProvider) RecordRequestSize(ctx context.Context, bytes int64, method, host, path string) {
+	p.metrics.RequestSize.WithLabelValues(p.labelValues(ctx, p.clientName, method, host, path)...).Observe(float64(bytes))
 }
 
 // RecordResponseSize records response size.
-func (p *PrometheusMetricsProvider) RecordResponseSize(_ context.Context, bytes int64, method, host, path, status string) {
-	p.metrics.ResponseSize.WithLabelValues(p.clientName, method, host, path, status).Observe(float64(bytes))
+func (p *PrometheusMetricsProvider) RecordResponseSize(ctx context.Context, bytes int64, method, host, path, status string) {
+	p.metrics.ResponseSize.WithLabelValues(p.labelValues(ctx, p.clientName, method, host, path, status)...).Observe(float64(bytes))
 }
 
 // InflightInc increments the active requests counter.
-func (p *PrometheusMetricsProvider) InflightInc(_ context.Context, method, host, path string) {
-	p.metrics.InflightRequests.WithLabelValues(p.clientName, method, host, path).Inc()
+func (p *PrometheusMetricsProvider) InflightInc(ctx context.Context, method, host, path string) {
+	p.metrics.InflightRequests.WithLabelValues(p.labelValues(ctx, p.clientName, method, host, path)...).Inc()
 }
 
 // InflightDec decrements the active requests counter.
-func (p *PrometheusMetricsProvider) InflightDec(_ context.Context, method, host, path string) {
-	p.metrics.InflightRequests.WithLabelValues(p.clientName, method, host, path).Dec()
+func (p *PrometheusMetricsProvider) InflightDec(ctx context.Context, method, host, path string) {
+	p.metrics.InflightRequests.WithLabelValues(p.labelValues(ctx, p.clientName, method, host, path)...).Dec()
+}
+
+// RecordPhaseDuration records a connection phase duration.
+func (p *PrometheusMetricsProvider) RecordPhaseDuration(_ context.Context, seconds float64, phase, method, host string) {
+	p.metrics.PhaseDuration.WithLabelValues(p.clientName, phase, method, host).Observe(seconds)
+}
+
+// RecordRedirect records a followed redirect.
+func (p *PrometheusMetricsProvider) RecordRedirect(_ context.Context, method, host, status string) {
+	p.metrics.RedirectsTotal.WithLabelValues(p.clientName, method, host, status).Inc()
+}
+
+// SetThrottled sets whether requests to the host are throttled.
+func (p *PrometheusMetricsProvider) SetThrottled(_ context.Context, host string, throttled bool) {
+	var value float64
+	if throttled {
+		value = 1
+	}
+	p.metrics.Throttled.WithLabelValues(p.clientName, host).Set(value)
+}
+
+// SetCircuitBreakerState sets the current state to 1 and the other states to 0.
+func (p *PrometheusMetricsProvider) SetCircuitBreakerState(_ context.Context, host string, state CircuitBreakerState) {
+	for _, s := range circuitBreakerStates {
+		var value float64
+		if s == state {
+			value = 1
+		}
+		p.metrics.CircuitState.WithLabelValues(p.clientName, host, s.String()).Set(value)
+	}
+}
+
+// RecordCircuitBreakerTransition records a breaker state change.
+func (p *PrometheusMetricsProvider) RecordCircuitBreakerTransition(_ context.Context, from, to CircuitBreakerState) {
+	p.metrics.CircuitChanges.WithLabelValues(p.clientName, from.String(), to.String()).Inc()
+}
+
+// RecordShortCircuit records a request rejected by an open breaker.
+func (p *PrometheusMetricsProvider) RecordShortCircuit(_ context.Context, method, host string) {
+	p.metrics.ShortCircuits.WithLabelValues(p.clientName, method, host).Inc()
+}
+
+// RecordBackendRequest records an attempt sent to a load-balanced backend.
+func (p *PrometheusMetricsProvider) RecordBackendRequest(_ context.Context, service, backend, status string, seconds float64) {
+	p.metrics.BackendRequests.WithLabelValues(p.clientName, service, backend, status).Inc()
+	p.metrics.BackendDuration.WithLabelValues(p.clientName, service, backend).Observe(seconds)
+}
+
+// RecordRetryBudgetExhausted records a retry denied by the retry budget.
+func (p *PrometheusMetricsProvider) RecordRetryBudgetExhausted(_ context.Context, method, host string) {
+	p.metrics.BudgetExhausted.WithLabelValues(p.clientName, method, host).Inc()
+}
+
+// RecordWarmupConnection records a warm-up connection attempt.
+func (p *PrometheusMetricsProvider) RecordWarmupConnection(_ context.Context, host, result string) {
+	p.metrics.WarmupConns.WithLabelValues(p.clientName, host, result).Inc()
+}
+
+// SetSLOStatus sets the compliance and burn rate of an SLO objective.
+func (p *PrometheusMetricsProvider) SetSLOStatus(_ context.Context, slo, objective string, compliance, burnRate float64) {
+	p.metrics.SLOCompliance.WithLabelValues(p.clientName, slo, objective).Set(compliance)
+	p.metrics.SLOBurnRate.WithLabelValues(p.clientName, slo, objective).Set(burnRate)
+}
+
+// RecordWebhookDelivery records a webhook delivery attempt.
+func (p *PrometheusMetricsProvider) RecordWebhookDelivery(_ context.Context, destination, result string) {
+	p.metrics.WebhookDelivery.WithLabelValues(p.clientName, destination, result).Inc()
+}
+
+// SetHostReachable sets whether the host answered the latest keep-alive ping.
+func (p *PrometheusMetricsProvider) SetHostReachable(_ context.Context, host string, reachable bool) {
+	var value float64
+	if reachable {
+		value = 1
+	}
+	p.metrics.HostReachable.WithLabelValues(p.clientName, host).Set(value)
+}
+
+// labelValues appends the values of the request labels of ctx to the label values.
+func (p *PrometheusMetricsProvider) labelValues(ctx context.Context, values ...string) []string {
+	if len(p.requestLabels) == 0 {
+		return values
+	}
+	return appendRequestLabelValues(ctx, values, p.requestLabels)
 }
 
-// Close releases resources.
+// Close releases the reference of the provider to the registered metrics: the series of the
+// client name are deleted when no other open provider of the registerer uses the name, and the
+// metrics are unregistered when no open provider uses them.
 func (p *PrometheusMetricsProvider) Close() error {
+	p.closeOnce.Do(func() {
+		releasePrometheusMetrics(p.metrics, p.clientName)
+	})
 	return nil
 }
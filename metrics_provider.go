@@ -10,6 +10,35 @@ const (
 	MetricInflightRequests  = "http_client_inflight_requests"
 	MetricRequestSizeBytes  = "http_client_request_size_bytes"
 	MetricResponseSizeBytes = "http_client_response_size_bytes"
+	MetricPhaseDuration     = "http_client_phase_duration_seconds"
+	MetricRedirectsTotal    = "http_client_redirects_total"
+	MetricThrottled         = "http_client_throttled"
+
+	MetricCircuitBreakerState         = "http_client_circuit_breaker_state"
+	MetricCircuitBreakerTransitions   = "http_client_circuit_breaker_transitions_total"
+	MetricCircuitBreakerShortCircuits = "http_client_circuit_breaker_short_circuited_total"
+
+	MetricBackendRequestsTotal   = "http_client_backend_requests_total"
+	MetricBackendRequestDuration = "http_client_backend_request_duration_seconds"
+
+	MetricRetryBudgetExhausted = "http_client_retry_budget_exhausted_total"
+
+	MetricWarmupConnections = "http_client_warmup_connections_total"
+
+	MetricSLOCompliance = "http_client_slo_compliance"
+	MetricSLOBurnRate   = "http_client_slo_burn_rate"
+
+	MetricWebhookDeliveries = "http_client_webhook_deliveries_total"
+
+	MetricHostReachable = "http_client_host_reachable"
+)
+
+// Connection phases recorded when Config.HTTPTraceEnabled is set.
+const (
+	PhaseDNS     = "dns"
+	PhaseConnect = "connect"
+	PhaseTLS     = "tls"
+	PhaseTTFB    = "ttfb"
 )
 
 // DefaultDurationBuckets contains default buckets for request duration histograms (in seconds).
@@ -23,6 +52,34 @@ var DefaultSizeBuckets = []float64{
 	256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216,
 }
 
+// MetricsBuckets sets histogram buckets of the Prometheus backend. Empty fields use the defaults.
+// Buckets apply when the metrics are registered with a registerer, so clients sharing a
+// registerer share the buckets of the first client. Configure OpenTelemetry buckets with Views.
+type MetricsBuckets struct {
+	// Duration contains buckets of request durations in seconds (default: DefaultDurationBuckets)
+	Duration []float64
+
+	// RequestSize contains buckets of request sizes in bytes (default: DefaultSizeBuckets)
+	RequestSize []float64
+
+	// ResponseSize contains buckets of response sizes in bytes (default: DefaultSizeBuckets)
+	ResponseSize []float64
+}
+
+// withDefaults returns a copy of the buckets with default values.
+func (b MetricsBuckets) withDefaults() MetricsBuckets {
+	if len(b.Duration) == 0 {
+		b.Duration = DefaultDurationBuckets
+	}
+	if len(b.RequestSize) == 0 {
+		b.RequestSize = DefaultSizeBuckets
+	}
+	if len(b.ResponseSize) == 0 {
+		b.ResponseSize = DefaultSizeBuckets
+	}
+	return b
+}
+
 // MetricsProvider defines the interface for various metrics backends.
 type MetricsProvider interface {
 	// RecordRequest records a request metric (path is the request path, e.g. /api/users).
@@ -50,6 +107,94 @@ type MetricsProvider interface {
 	Close() error
 }
 
+// PhaseMetricsProvider is an optional interface for providers that record
+// connection phase durations (DNS, connect, TLS handshake, time to first byte).
+// Providers that don't implement it simply skip these metrics.
+type PhaseMetricsProvider interface {
+	// RecordPhaseDuration records the duration of a connection phase in seconds
+	RecordPhaseDuration(ctx context.Context, seconds float64, phase, method, host string)
+}
+
+// RedirectMetricsProvider is an optional interface for providers that count followed redirects.
+// Providers that don't implement it simply skip these metrics.
+type RedirectMetricsProvider interface {
+	// RecordRedirect records a followed redirect (host of the redirecting response and its status)
+	RecordRedirect(ctx context.Context, method, host, status string)
+}
+
+// ThrottleMetricsProvider is an optional interface for providers that report hosts
+// throttled after 429 responses (see RateLimiterConfig.AdaptiveThrottling).
+// Providers that don't implement it simply skip these metrics.
+type ThrottleMetricsProvider interface {
+	// SetThrottled sets whether requests to the host are currently throttled
+	SetThrottled(ctx context.Context, host string, throttled bool)
+}
+
+// CircuitBreakerMetricsProvider is an optional interface for providers that export
+// circuit breaker state, transitions and requests rejected by an open breaker.
+// Providers that don't implement it simply skip these metrics.
+type CircuitBreakerMetricsProvider interface {
+	// SetCircuitBreakerState sets the breaker state observed by requests to the host
+	SetCircuitBreakerState(ctx context.Context, host string, state CircuitBreakerState)
+
+	// RecordCircuitBreakerTransition records a breaker state change
+	RecordCircuitBreakerTransition(ctx context.Context, from, to CircuitBreakerState)
+
+	// RecordShortCircuit records a request rejected because the breaker is open
+	RecordShortCircuit(ctx context.Context, method, host string)
+}
+
+// BackendMetricsProvider is an optional interface for providers that record attempts
+// per load-balanced backend (see Config.LoadBalancer).
+// Providers that don't implement it simply skip these metrics.
+type BackendMetricsProvider interface {
+	// RecordBackendRequest records an attempt sent to the backend of a service and its duration in seconds
+	RecordBackendRequest(ctx context.Context, service, backend, status string, seconds float64)
+}
+
+// RetryBudgetMetricsProvider is an optional interface for providers that count retries
+// denied by the retry budget (see Config.RetryBudgetEnabled).
+// Providers that don't implement it simply skip this metric.
+type RetryBudgetMetricsProvider interface {
+	// RecordRetryBudgetExhausted records a retry denied because the retry budget is spent
+	RecordRetryBudgetExhausted(ctx context.Context, method, host string)
+}
+
+// WarmupMetricsProvider is an optional interface for providers that count connections
+// opened by Client.Warmup.
+// Providers that don't implement it simply skip this metric.
+type WarmupMetricsProvider interface {
+	// RecordWarmupConnection records a warm-up connection attempt to the host and its result
+	// (WarmupResultSuccess or WarmupResultError)
+	RecordWarmupConnection(ctx context.Context, host, result string)
+}
+
+// SLOMetricsProvider is an optional interface for providers that export the rolling
+// compliance of Config.SLOs.
+// Providers that don't implement it simply skip these metrics.
+type SLOMetricsProvider interface {
+	// SetSLOStatus sets the share of good requests and the burn rate of an SLO objective
+	// (SLOObjectiveLatency or SLOObjectiveErrors)
+	SetSLOStatus(ctx context.Context, slo, objective string, compliance, burnRate float64)
+}
+
+// WebhookMetricsProvider is an optional interface for providers that count deliveries of
+// a WebhookSender per destination.
+// Providers that don't implement it simply skip this metric.
+type WebhookMetricsProvider interface {
+	// RecordWebhookDelivery records a delivery attempt to the destination host and its result
+	// (WebhookResultDelivered, WebhookResultRetried or WebhookResultFailed)
+	RecordWebhookDelivery(ctx context.Context, destination, result string)
+}
+
+// ReachabilityMetricsProvider is an optional interface for providers that report the
+// reachability of hosts pinged by Config.KeepAlive.
+// Providers that don't implement it simply skip this metric.
+type ReachabilityMetricsProvider interface {
+	// SetHostReachable sets whether the host answered the latest keep-alive ping
+	SetHostReachable(ctx context.Context, host string, reachable bool)
+}
+
 // MetricsBackend defines the type of metrics backend.
 type MetricsBackend string
 
@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// prometheusRegistrations tracks the registered metric sets and the providers using them, so
+// the metrics are shared by clients and unregistered when the last client is closed.
+var prometheusRegistrations = struct {
+	mu   sync.Mutex
+	sets map[prometheusMetricsKey]*prometheusGlobalMetrics
+}{sets: make(map[prometheusMetricsKey]*prometheusGlobalMetrics)}
+
+// acquirePrometheusMetrics returns the metric set of the key, registering it with create on
+// first use, and counts a provider with the client name. With unique it fails if an open
+// provider of the set has the same client name.
+func acquirePrometheusMetrics(
+	key prometheusMetricsKey, clientName string, unique bool, create func() *prometheusGlobalMetrics,
+) (*prometheusGlobalMetrics, error) {
+	prometheusRegistrations.mu.Lock()
+	defer prometheusRegistrations.mu.Unlock()
+
+	metrics, exists := prometheusRegistrations.sets[key]
+	if !exists {
+		metrics = create()
+		prometheusRegistrations.sets[key] = metrics
+	}
+	if unique && metrics.clients[clientName] > 0 {
+		return nil, NewConfigurationError("UniqueMetricsNames", clientName,
+			"client name is already used by an open client of the Prometheus registerer")
+	}
+	metrics.clients[clientName]++
+	return metrics, nil
+}
+
+// releasePrometheusMetrics removes a provider with the client name from the metric set. The
+// series of the client name are deleted with its last provider and the metrics are
+// unregistered with the last provider of the set.
+func releasePrometheusMetrics(metrics *prometheusGlobalMetrics, clientName string) {
+	prometheusRegistrations.mu.Lock()
+	defer prometheusRegistrations.mu.Unlock()
+
+	if metrics.clients[clientName] == 0 {
+		return
+	}
+	metrics.clients[clientName]--
+	if metrics.clients[clientName] > 0 {
+		return
+	}
+	delete(metrics.clients, clientName)
+	metrics.deleteClient(clientName)
+
+	if len(metrics.clients) > 0 {
+		return
+	}
+	for _, collector := range metrics.registered {
+		metrics.reg.Unregister(collector)
+	}
+	for key, set := range prometheusRegistrations.sets {
+		if set == metrics {
+			delete(prometheusRegistrations.sets, key)
+		}
+	}
+}
+
+// deleteClient deletes the series of the client name from all vectors.
+func (m *prometheusGlobalMetrics) deleteClient(clientName string) {
+	labels := prometheus.Labels{"client_name": clientName}
+	for _, vec := range []interface{ DeletePartialMatch(prometheus.Labels) int }{
+		m.RequestsTotal, m.RequestDuration, m.RetriesTotal, m.InflightRequests, m.RequestSize,
+		m.ResponseSize, m.PhaseDuration, m.RedirectsTotal, m.Throttled, m.CircuitState,
+		m.CircuitChanges, m.ShortCircuits, m.BackendRequests, m.BackendDuration,
+		m.BudgetExhausted, m.WarmupConns, m.SLOCompliance, m.SLOBurnRate,
+		m.WebhookDelivery, m.HostReachable,
+	} {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
+// registerCollector registers the collector in reg and records it in registered. An equal
+// collector already registered in reg is reused instead of panicking.
+func registerCollector[T prometheus.Collector](reg prometheus.Registerer, registered *[]prometheus.Collector, collector T) T {
+	if err := reg.Register(collector); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	*registered = append(*registered, collector)
+	return collector
+}
@@ -0,0 +1,146 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatheredFamilies returns the number of metric families with series in the registry.
+func gatheredFamilies(t *testing.T, reg *prometheus.Registry) int {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	return len(families)
+}
+
+func TestPrometheusRegistration_ReleasedOnClose(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	config := Config{MetricsBackend: MetricsBackendPrometheus, PrometheusRegisterer: reg}
+	first := New(config, "test-registration")
+	second := New(config, "test-registration")
+	other := New(config, "test-registration-other")
+
+	for _, client := range []*Client{first, other} {
+		resp, err := client.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	labels := map[string]string{"client_name": "test-registration"}
+	otherLabels := map[string]string{"client_name": "test-registration-other"}
+
+	// The series stay while a client with the name is open
+	require.NoError(t, first.Close())
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, "http_client_requests_total", labels))
+
+	require.NoError(t, second.Close())
+	require.NoError(t, second.Close())
+	assert.Equal(t, 0.0, circuitBreakerMetric(t, reg, "http_client_requests_total", labels))
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, "http_client_requests_total", otherLabels))
+
+	// The last client unregisters the collectors, so the names can be registered again
+	require.NoError(t, other.Close())
+	assert.Zero(t, gatheredFamilies(t, reg))
+	again := New(config, "test-registration")
+	defer again.Close()
+	resp, err := again.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, "http_client_requests_total", labels))
+}
+
+func TestPrometheusRegistration_UniqueNames(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	config := Config{MetricsBackend: MetricsBackendPrometheus, PrometheusRegisterer: reg, UniqueMetricsNames: true}
+	first, err := NewValidated(config, "test-registration-unique")
+	require.NoError(t, err)
+
+	_, err = NewValidated(config, "test-registration-unique")
+	var configErr *ConfigurationError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "UniqueMetricsNames", configErr.Field)
+
+	duplicate := New(config, "test-registration-unique")
+	defer duplicate.Close()
+	_, err = duplicate.Get(context.Background(), server.URL)
+	require.ErrorAs(t, err, &configErr)
+
+	// Scoped clients share the registerer of their parent
+	_, err = first.Scope("test-registration-unique", ScopeConfig{}).Get(context.Background(), server.URL)
+	require.ErrorAs(t, err, &configErr)
+
+	require.NoError(t, first.Close())
+	reopened, err := NewValidated(config, "test-registration-unique")
+	require.NoError(t, err)
+	require.NoError(t, reopened.Close())
+}
+
+func TestPrometheusRegistration_ScopesReleasedWithParent(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{MetricsBackend: MetricsBackendPrometheus, PrometheusRegisterer: reg}, "test-registration-parent")
+	scoped := client.Scope("test-registration-scope", ScopeConfig{})
+	resp, err := scoped.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Positive(t, gatheredFamilies(t, reg))
+
+	require.NoError(t, client.Close())
+	assert.Zero(t, gatheredFamilies(t, reg))
+}
+
+func TestPrometheusRegistration_NestedScopesReleased(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	for _, closeScope := range []bool{false, true} {
+		reg := prometheus.NewRegistry()
+		client := New(Config{MetricsBackend: MetricsBackendPrometheus, PrometheusRegisterer: reg}, "test-registration-nested")
+		scoped := client.Scope("test-registration-nested-scope", ScopeConfig{})
+		nested := scoped.Scope("test-registration-nested-child", ScopeConfig{})
+		resp, err := nested.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		require.Positive(t, gatheredFamilies(t, reg))
+
+		// The nested scope is released with the scope it was created from
+		if closeScope {
+			require.NoError(t, scoped.Close())
+		}
+		require.NoError(t, client.Close())
+		assert.Zero(t, gatheredFamilies(t, reg))
+	}
+}
+
+func TestRegisterCollector_ReusesRegistered(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewRegistry()
+	opts := prometheus.CounterOpts{Name: "test_registration_total", Help: "Test counter"}
+	existing := prometheus.NewCounterVec(opts, []string{"client_name"})
+	reg.MustRegister(existing)
+
+	var registered []prometheus.Collector
+	reused := registerCollector(reg, &registered, prometheus.NewCounterVec(opts, []string{"client_name"}))
+	assert.Same(t, existing, reused)
+	assert.Empty(t, registered)
+
+	assert.Panics(t, func() {
+		registerCollector(reg, &registered, prometheus.NewCounterVec(opts, []string{"method"}))
+	})
+}
@@ -0,0 +1,35 @@
+package httpclient
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+)
+
+// metricsSkippedKey is the context key marking requests recorded without client metrics.
+type metricsSkippedKey struct{}
+
+// WithMetricsDisabled records no client metrics for this request, e.g. for very frequent
+// health checks. Client.GetMetrics still counts the request.
+func WithMetricsDisabled() RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.noMetrics = true
+		})
+	}
+}
+
+// sampleMetrics marks the request context when the request is recorded without metrics:
+// requests with WithMetricsDisabled and requests left out by Config.MetricsSampleRate.
+func (rt *RoundTripper) sampleMetrics(req *http.Request) *http.Request {
+	overrides := getRequestOverrides(req.Context())
+	rate := rt.config.MetricsSampleRate
+	skip := overrides != nil && overrides.noMetrics
+	if !skip && rate > 0 && rate < 1 {
+		skip = rand.Float64() >= rate
+	}
+	if !skip {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), metricsSkippedKey{}, true))
+}
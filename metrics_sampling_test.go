@@ -0,0 +1,152 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticTransport answers every request with an empty 200 response without network I/O.
+type staticTransport struct{}
+
+func (staticTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+// requestsTotal returns the number of requests recorded by the client metrics.
+func requestsTotal(t *testing.T, reg *prometheus.Registry, clientName string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var total float64
+	for _, family := range families {
+		if family.GetName() != "http_client_requests_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "client_name" && label.GetValue() == clientName {
+					total += m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestWithMetricsDisabled(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		Transport:            staticTransport{},
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+	}, "test-metrics-disabled")
+	defer client.Close()
+
+	for _, opts := range [][]RequestOption{{WithMetricsDisabled()}, nil} {
+		resp, err := client.Get(context.Background(), "http://example.com/health", opts...)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.InDelta(t, 1.0, requestsTotal(t, reg, "test-metrics-disabled"), 0.001)
+	assert.Equal(t, int64(2), client.GetMetrics().TotalRequests, "GetMetrics counts every request")
+}
+
+func TestMetricsSampleRate(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		Transport:            staticTransport{},
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		MetricsSampleRate:    0.25,
+	}, "test-metrics-sampling")
+	defer client.Close()
+
+	const requests = 2000
+	for range requests {
+		resp, err := client.Get(context.Background(), "http://example.com/health")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.InDelta(t, requests*0.25, requestsTotal(t, reg, "test-metrics-sampling"), requests*0.05)
+
+	assert.ErrorContains(t, Config{MetricsSampleRate: 1.5}.Validate(), "MetricsSampleRate")
+}
+
+// BenchmarkRoundTrip_Metrics compares the overhead of concurrent requests with every request
+// recorded, with sampling and with metrics disabled for the request.
+func BenchmarkRoundTrip_Metrics(b *testing.B) {
+	benchmarks := []struct {
+		name       string
+		sampleRate float64
+		opts       []RequestOption
+	}{
+		{name: "all"},
+		{name: "sampled-1%", sampleRate: 0.01},
+		{name: "disabled", opts: []RequestOption{WithMetricsDisabled()}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			client := New(Config{
+				Transport:            staticTransport{},
+				MetricsBackend:       MetricsBackendPrometheus,
+				PrometheusRegisterer: prometheus.NewRegistry(),
+				MetricsSampleRate:    bm.sampleRate,
+			}, "benchmark-metrics")
+			defer client.Close()
+
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					resp, err := client.Get(ctx, "http://example.com/health", bm.opts...)
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					_ = resp.Body.Close()
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkMetrics_PerRequest measures the metrics calls made for a request when it is
+// recorded and when it is skipped by WithMetricsDisabled or sampling.
+func BenchmarkMetrics_PerRequest(b *testing.B) {
+	metrics := NewMetricsWithProvider("benchmark-metrics-calls",
+		newPrometheusMetricsProvider("benchmark-metrics-calls", prometheus.NewRegistry(), nil, MetricsBuckets{}, nil))
+	defer metrics.Close()
+
+	contexts := map[string]context.Context{
+		"recorded": context.Background(),
+		"skipped":  context.WithValue(context.Background(), metricsSkippedKey{}, true),
+	}
+	for name, ctx := range contexts {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				metrics.IncrementInflight(ctx, "GET", "example.com", "/health")
+				metrics.RecordRequestSize(ctx, 0, "GET", "example.com", "/health")
+				metrics.RecordRequest(ctx, "GET", "example.com", "/health", "200", false, false)
+				metrics.RecordDuration(ctx, 0.001, "GET", "example.com", "/health", "200", 1)
+				metrics.RecordResponseSize(ctx, 2, "GET", "example.com", "/health", "200")
+				metrics.DecrementInflight(ctx, "GET", "example.com", "/health")
+			}
+		})
+	}
+}
@@ -2,7 +2,12 @@ package httpclient
 
 import (
 	"context"
+	"net/http"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNewMetrics tests creation of Metrics
@@ -169,3 +174,42 @@ func TestMetrics_EdgeCases(t *testing.T) {
 
 	// If we reached here without panic, the test passed
 }
+
+func TestMetricsBuckets_Prometheus(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Body: "ok"})
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		MetricsBuckets: MetricsBuckets{
+			Duration:     []float64{5, 10, 30},
+			ResponseSize: []float64{1, 100},
+		},
+	}, "test-metrics-buckets")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	bounds := func(name string) []float64 {
+		families, err := reg.Gather()
+		require.NoError(t, err)
+		for _, family := range families {
+			if family.GetName() != name || len(family.GetMetric()) == 0 {
+				continue
+			}
+			var result []float64
+			for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+				result = append(result, bucket.GetUpperBound())
+			}
+			return result
+		}
+		return nil
+	}
+	assert.Equal(t, []float64{5, 10, 30}, bounds(MetricRequestDuration))
+	assert.Equal(t, []float64{1, 100}, bounds(MetricResponseSizeBytes))
+}
@@ -0,0 +1,144 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Middleware intercepts every logical request executed by the client.
+// Middlewares wrap the retry loop, so a middleware sees one call per request
+// regardless of the number of attempts.
+type Middleware interface {
+	Process(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error)
+}
+
+// MiddlewareFunc adapts an ordinary function to the Middleware interface.
+type MiddlewareFunc func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error)
+
+// Process implements the Middleware interface.
+func (f MiddlewareFunc) Process(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	return f(req, next)
+}
+
+// AttemptMiddleware updates the request of every physical attempt inside the retry loop,
+// e.g. to recompute a signature or set timestamp and attempt-number headers on retries.
+// Attempts are numbered from 1. Add it to Config.AttemptMiddlewares, or to Config.Middlewares
+// when the type also implements Middleware.
+type AttemptMiddleware interface {
+	PrepareAttempt(req *http.Request, attempt int) error
+}
+
+// AttemptMiddlewareFunc adapts an ordinary function to the AttemptMiddleware interface.
+type AttemptMiddlewareFunc func(req *http.Request, attempt int) error
+
+// PrepareAttempt implements the AttemptMiddleware interface.
+func (f AttemptMiddlewareFunc) PrepareAttempt(req *http.Request, attempt int) error {
+	return f(req, attempt)
+}
+
+// AttemptHeader is the default header set by NewAttemptHeaderMiddleware.
+const AttemptHeader = "X-Attempt"
+
+// NewAttemptHeaderMiddleware returns an attempt middleware setting the attempt number
+// (1 for the first attempt) in the header, X-Attempt when header is empty.
+func NewAttemptHeaderMiddleware(header string) AttemptMiddleware {
+	if header == "" {
+		header = AttemptHeader
+	}
+	return AttemptMiddlewareFunc(func(req *http.Request, attempt int) error {
+		req.Header.Set(header, strconv.Itoa(attempt))
+		return nil
+	})
+}
+
+// attemptInterceptor is implemented by middlewares that wrap the transport call of every
+// physical attempt, inside the circuit breaker, e.g. to inject faults handled by the retry loop.
+type attemptInterceptor interface {
+	interceptAttempt(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error)
+}
+
+// Compile-time check that the circuit breaker middleware satisfies the interface.
+var _ Middleware = (*CircuitBreakerMiddleware)(nil)
+
+// Compile-time check that the signing middleware runs for every attempt.
+var _ AttemptMiddleware = (*HMACSigningMiddleware)(nil)
+
+// Compile-time check that fault injection runs for every attempt.
+var _ attemptInterceptor = (*FaultInjectionMiddleware)(nil)
+
+// chainMiddlewares builds the handler chain. The first middleware is the outermost one.
+func chainMiddlewares(
+	middlewares []Middleware,
+	final func(*http.Request) (*http.Response, error),
+) func(*http.Request) (*http.Response, error) {
+	handler := final
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		mw := middlewares[i]
+		next := handler
+		handler = func(req *http.Request) (*http.Response, error) {
+			return mw.Process(req, next)
+		}
+	}
+	return handler
+}
+
+// prepareAttempt lets attempt middlewares update the request before it is sent: first the
+// attempt-aware entries of Config.Middlewares, then Config.AttemptMiddlewares.
+// Headers are copied first so changes never leak into the caller's request.
+func (rt *RoundTripper) prepareAttempt(req *http.Request, attempt int) error {
+	copied := false
+	prepare := func(mw AttemptMiddleware) error {
+		if !copied {
+			req.Header = req.Header.Clone()
+			copied = true
+		}
+		return mw.PrepareAttempt(req, attempt)
+	}
+
+	for _, mw := range rt.config.Middlewares {
+		if preparer, ok := mw.(AttemptMiddleware); ok {
+			if err := prepare(preparer); err != nil {
+				return err
+			}
+		}
+	}
+	for _, mw := range rt.config.AttemptMiddlewares {
+		if err := prepare(mw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendAttempt sends an attempt to the base transport, through the attempt-aware
+// middlewares when there are middlewares.
+func (rt *RoundTripper) sendAttempt(req *http.Request) (*http.Response, error) {
+	if len(rt.config.Middlewares) == 0 {
+		// Calling the transport directly avoids allocating the rt.base.RoundTrip method value
+		return rt.base.RoundTrip(req)
+	}
+	return rt.interceptAttempt(req, rt.base.RoundTrip)
+}
+
+// interceptAttempt sends an attempt through the attempt-aware middlewares.
+// The first middleware is the outermost one.
+func (rt *RoundTripper) interceptAttempt(
+	req *http.Request,
+	send func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	handler := send
+	for i := len(rt.config.Middlewares) - 1; i >= 0; i-- {
+		interceptor, ok := rt.config.Middlewares[i].(attemptInterceptor)
+		if !ok {
+			continue
+		}
+		next := handler
+		handler = func(r *http.Request) (*http.Response, error) {
+			return interceptor.interceptAttempt(r, next)
+		}
+	}
+	return handler(req)
+}
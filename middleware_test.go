@@ -0,0 +1,138 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewares_Order(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "first,second", r.Header.Get("X-Chain"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string) Middleware {
+		return MiddlewareFunc(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			mu.Lock()
+			calls = append(calls, name+":before")
+			mu.Unlock()
+
+			if prev := req.Header.Get("X-Chain"); prev != "" {
+				req.Header.Set("X-Chain", prev+","+name)
+			} else {
+				req.Header.Set("X-Chain", name)
+			}
+			resp, err := next(req)
+
+			mu.Lock()
+			calls = append(calls, name+":after")
+			mu.Unlock()
+			return resp, err
+		})
+	}
+
+	client := New(Config{Middlewares: []Middleware{record("first"), record("second")}}, "test-middleware-order")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, []string{"first:before", "second:before", "second:after", "first:after"}, calls)
+}
+
+func TestMiddlewares_RunOncePerLogicalRequest(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	var calls int
+	mw := MiddlewareFunc(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		calls++
+		return next(req)
+	})
+
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: 1},
+		Middlewares:  []Middleware{mw},
+	}, "test-middleware-once")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 2, server.GetRequestCount())
+}
+
+func TestAttemptMiddlewares_RunForEveryAttempt(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	var signed []int
+	sign := AttemptMiddlewareFunc(func(req *http.Request, attempt int) error {
+		signed = append(signed, attempt)
+		req.Header.Set("X-Signature", "sig-"+req.Header.Get(AttemptHeader))
+		return nil
+	})
+	client := New(Config{
+		AttemptMiddlewares: []AttemptMiddleware{NewAttemptHeaderMiddleware(""), sign},
+		RetryEnabled:       true,
+		RetryConfig:        RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	}, "test-attempt-middlewares")
+	defer client.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, []int{1, 2, 3}, signed)
+	require.Len(t, server.RequestLog, 3)
+	for i, logged := range server.RequestLog {
+		assert.Equal(t, strconv.Itoa(i+1), logged.Headers[AttemptHeader])
+		assert.Equal(t, "sig-"+strconv.Itoa(i+1), logged.Headers["X-Signature"])
+	}
+	// The caller's request is never modified
+	assert.Empty(t, req.Header.Get(AttemptHeader))
+}
+
+func TestAttemptMiddlewares_Error(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	failing := AttemptMiddlewareFunc(func(*http.Request, int) error { return errors.New("signer unavailable") })
+	client := New(Config{AttemptMiddlewares: []AttemptMiddleware{failing}}, "test-attempt-middleware-error")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "signer unavailable")
+	assert.Equal(t, 0, server.GetRequestCount())
+}
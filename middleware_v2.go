@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// NextV2 continues the middleware chain with the context and request.
+type NextV2 func(ctx context.Context, req *http.Request) (*http.Response, error)
+
+// MiddlewareV2 is a middleware receiving the request context explicitly. Values attached
+// to the context passed to next are visible to later middlewares and to the client,
+// e.g. per-request configuration set with ContextWithOptions or typed values of a ContextKey.
+// Add it to Config.Middlewares with AdaptMiddlewareV2.
+type MiddlewareV2 interface {
+	Process(ctx context.Context, req *http.Request, next NextV2) (*http.Response, error)
+}
+
+// MiddlewareV2Func adapts an ordinary function to the MiddlewareV2 interface.
+type MiddlewareV2Func func(ctx context.Context, req *http.Request, next NextV2) (*http.Response, error)
+
+// Process implements the MiddlewareV2 interface.
+func (f MiddlewareV2Func) Process(ctx context.Context, req *http.Request, next NextV2) (*http.Response, error) {
+	return f(ctx, req, next)
+}
+
+// AdaptMiddlewareV2 turns a MiddlewareV2 into a Middleware for Config.Middlewares.
+func AdaptMiddlewareV2(mw MiddlewareV2) Middleware {
+	return &middlewareV2Adapter{mw: mw}
+}
+
+// middlewareV2Adapter runs a MiddlewareV2 in the Middleware chain.
+type middlewareV2Adapter struct {
+	mw MiddlewareV2
+}
+
+// Process implements the Middleware interface.
+func (a *middlewareV2Adapter) Process(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	return a.mw.Process(req.Context(), req, func(ctx context.Context, r *http.Request) (*http.Response, error) {
+		if ctx != nil && ctx != r.Context() {
+			r = r.WithContext(ctx)
+		}
+		return next(r)
+	})
+}
+
+// ContextKey is a typed key for values passed between middlewares through the context.
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewContextKey creates a key; the name is only used in String.
+func NewContextKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{name: name}
+}
+
+// WithValue returns a copy of ctx carrying the value.
+func (k *ContextKey[T]) WithValue(ctx context.Context, value T) context.Context {
+	return context.WithValue(ctx, k, value)
+}
+
+// Value returns the value stored in ctx and whether it was set.
+func (k *ContextKey[T]) Value(ctx context.Context) (T, bool) {
+	value, ok := ctx.Value(k).(T)
+	return value, ok
+}
+
+// String returns the key name.
+func (k *ContextKey[T]) String() string {
+	return "httpclient context key " + k.name
+}
+
+// ContextWithOptions returns a copy of ctx carrying the per-request configuration of the
+// options, e.g. WithRetryPolicy, WithMaxAttempts, WithNoRetry, WithRequestTimeout or
+// WithFallback. The client applies it to requests sent with the context. Options that
+// change headers, the URL or the body have no effect here.
+func ContextWithOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	req := (&http.Request{Header: make(http.Header), URL: &url.URL{}, Body: http.NoBody}).WithContext(ctx)
+	for _, opt := range opts {
+		opt(req)
+	}
+	return req.Context()
+}
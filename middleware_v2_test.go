@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareV2_TypedValues(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	tenantKey := NewContextKey[string]("tenant")
+	var seen string
+	setTenant := MiddlewareV2Func(func(ctx context.Context, req *http.Request, next NextV2) (*http.Response, error) {
+		return next(tenantKey.WithValue(ctx, "acme"), req)
+	})
+	readTenant := MiddlewareV2Func(func(ctx context.Context, req *http.Request, next NextV2) (*http.Response, error) {
+		seen, _ = tenantKey.Value(ctx)
+		return next(ctx, req)
+	})
+	client := New(Config{
+		Middlewares: []Middleware{AdaptMiddlewareV2(setTenant), AdaptMiddlewareV2(readTenant)},
+	}, "test-middleware-v2-values")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "acme", seen)
+
+	_, ok := tenantKey.Value(context.Background())
+	assert.False(t, ok)
+	assert.Equal(t, "httpclient context key tenant", tenantKey.String())
+}
+
+func TestMiddlewareV2_RetryOverride(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusServiceUnavailable})
+	defer server.Close()
+
+	retryCritical := MiddlewareV2Func(func(ctx context.Context, req *http.Request, next NextV2) (*http.Response, error) {
+		return next(ContextWithOptions(ctx, WithRetryPolicy(RetryConfig{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    time.Millisecond,
+		})), req)
+	})
+	client := New(Config{Middlewares: []Middleware{AdaptMiddlewareV2(retryCritical)}}, "test-middleware-v2-retry")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 3, server.GetRequestCount())
+}
@@ -0,0 +1,254 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default OAuth2 settings.
+const (
+	defaultOAuth2RefreshBefore = 30 * time.Second
+	defaultOAuth2Timeout       = 10 * time.Second
+)
+
+// Token is an OAuth2 access token.
+type Token struct {
+	AccessToken string
+	TokenType   string
+	// Expiry is the token expiration time; zero means the token doesn't expire
+	Expiry time.Time
+}
+
+// TokenSource provides access tokens for OAuth2Middleware.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// OAuth2Config contains client credentials grant settings.
+type OAuth2Config struct {
+	// TokenURL is the token endpoint of the authorization server
+	TokenURL string
+
+	// ClientID and ClientSecret identify the client
+	ClientID     string
+	ClientSecret string
+
+	// Scopes are the requested scopes (optional)
+	Scopes []string
+
+	// EndpointParams are extra form parameters sent to the token endpoint, e.g. "audience"
+	EndpointParams url.Values
+
+	// CredentialsInBody sends client_id and client_secret as form parameters
+	// instead of HTTP Basic authentication
+	CredentialsInBody bool
+
+	// RefreshBefore is how long before expiry the token is refreshed (default: 30s)
+	RefreshBefore time.Duration
+
+	// HTTPClient is used for token requests (default: a plain client with 10s timeout)
+	HTTPClient *http.Client
+}
+
+// withDefaults applies default values to the OAuth2 configuration.
+func (oc OAuth2Config) withDefaults() OAuth2Config {
+	if oc.RefreshBefore == 0 {
+		oc.RefreshBefore = defaultOAuth2RefreshBefore
+	}
+
+	if oc.HTTPClient == nil {
+		oc.HTTPClient = &http.Client{Timeout: defaultOAuth2Timeout}
+	}
+
+	return oc
+}
+
+// ClientCredentialsTokenSource fetches tokens with the OAuth2 client credentials grant
+// and caches them until shortly before expiry. It is safe for concurrent use.
+type ClientCredentialsTokenSource struct {
+	config OAuth2Config
+	now    func() time.Time
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewClientCredentialsTokenSource creates a caching client credentials token source.
+func NewClientCredentialsTokenSource(config OAuth2Config) *ClientCredentialsTokenSource {
+	return &ClientCredentialsTokenSource{
+		config: config.withDefaults(),
+		now:    time.Now,
+	}
+}
+
+// Token returns the cached token or fetches a new one when it is missing or about to expire.
+// Concurrent callers wait for a single token request.
+func (s *ClientCredentialsTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.isFresh(s.token) {
+		return s.token, nil
+	}
+
+	token, err := s.fetchToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// Invalidate drops the cached token if it is still the stale one, forcing a refresh.
+func (s *ClientCredentialsTokenSource) Invalidate(stale *Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == stale {
+		s.token = nil
+	}
+}
+
+// isFresh reports whether the token can be used without refreshing.
+func (s *ClientCredentialsTokenSource) isFresh(token *Token) bool {
+	return token.Expiry.IsZero() || s.now().Add(s.config.RefreshBefore).Before(token.Expiry)
+}
+
+// tokenResponse is the token endpoint response body.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// fetchToken requests a new token from the token endpoint.
+func (s *ClientCredentialsTokenSource) fetchToken(ctx context.Context) (*Token, error) {
+	form := url.Values{}
+	for key, values := range s.config.EndpointParams {
+		form[key] = values
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(s.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.config.Scopes, " "))
+	}
+	if s.config.CredentialsInBody {
+		form.Set("client_id", s.config.ClientID)
+		form.Set("client_secret", s.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !s.config.CredentialsInBody {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	resp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: token request failed: %w", err)
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		httpErr := NewHTTPError(resp, req)
+		httpErr.Body, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return nil, fmt.Errorf("oauth2: token request failed: %w", httpErr)
+	}
+
+	var body tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("oauth2: failed to decode token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return nil, fmt.Errorf("oauth2: token response has no access_token")
+	}
+
+	token := &Token{AccessToken: body.AccessToken, TokenType: body.TokenType}
+	if body.ExpiresIn > 0 {
+		token.Expiry = s.now().Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// tokenInvalidator is implemented by token sources that support forced refresh.
+type tokenInvalidator interface {
+	Invalidate(stale *Token)
+}
+
+// OAuth2Middleware injects access tokens from a TokenSource into requests.
+// When the server answers 401 and the source supports Invalidate, the token is
+// refreshed and the request is retried once, provided its body can be replayed.
+type OAuth2Middleware struct {
+	source TokenSource
+}
+
+// NewOAuth2Middleware creates a middleware that authorizes requests with tokens from source.
+func NewOAuth2Middleware(source TokenSource) *OAuth2Middleware {
+	return &OAuth2Middleware{source: source}
+}
+
+// NewClientCredentialsMiddleware creates an OAuth2Middleware backed by a client credentials token source.
+func NewClientCredentialsMiddleware(config OAuth2Config) *OAuth2Middleware {
+	return NewOAuth2Middleware(NewClientCredentialsTokenSource(config))
+}
+
+// Process implements the Middleware interface.
+func (m *OAuth2Middleware) Process(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	token, err := m.source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := next(authorizeRequest(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	invalidator, ok := m.source.(tokenInvalidator)
+	if !ok {
+		return resp, nil
+	}
+	retryReq, ok := replayableRequest(req)
+	if !ok {
+		return resp, nil
+	}
+
+	invalidator.Invalidate(token)
+	token, err = m.source.Token(req.Context())
+	if err != nil {
+		drainAndClose(retryReq.Body)
+		return resp, nil
+	}
+	drainAndClose(resp.Body)
+
+	retryReq.Header.Set("Authorization", tokenHeader(token))
+	return next(retryReq)
+}
+
+// authorizeRequest returns a copy of the request with the token in the Authorization header.
+func authorizeRequest(req *http.Request, token *Token) *http.Request {
+	authorized := req.Clone(req.Context())
+	authorized.Header.Set("Authorization", tokenHeader(token))
+	return authorized
+}
+
+// tokenHeader formats the Authorization header value for the token.
+func tokenHeader(token *Token) string {
+	if token.TokenType == "" || strings.EqualFold(token.TokenType, "bearer") {
+		return "Bearer " + token.AccessToken
+	}
+	return token.TokenType + " " + token.AccessToken
+}
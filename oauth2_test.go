@@ -0,0 +1,158 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenServer issues tokens "token-1", "token-2", ... valid for expiresIn seconds.
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	t.Helper()
+	var issued int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+		assert.Equal(t, "read write", r.Form.Get("scope"))
+
+		n := atomic.AddInt32(&issued, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func oauth2TestConfig(tokenURL string) OAuth2Config {
+	return OAuth2Config{
+		TokenURL:     tokenURL,
+		ClientID:     "client",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"read", "write"},
+	}
+}
+
+func TestOAuth2Middleware_CachesToken(t *testing.T) {
+	t.Parallel()
+	tokenServer, issued := newTokenServer(t, 3600)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	client := New(Config{
+		Middlewares: []Middleware{NewClientCredentialsMiddleware(oauth2TestConfig(tokenServer.URL))},
+	}, "test-oauth2-cache")
+	defer client.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(context.Background(), api.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(issued))
+}
+
+func TestOAuth2Middleware_RefreshesOn401(t *testing.T) {
+	t.Parallel()
+	tokenServer, issued := newTokenServer(t, 3600)
+
+	var bodies []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	client := New(Config{
+		Middlewares: []Middleware{NewClientCredentialsMiddleware(oauth2TestConfig(tokenServer.URL))},
+	}, "test-oauth2-401")
+	defer client.Close()
+
+	resp, err := client.Post(context.Background(), api.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(issued))
+	assert.Equal(t, []string{"payload", "payload"}, bodies)
+}
+
+func TestOAuth2Middleware_TokenErrorFailsRequest(t *testing.T) {
+	t.Parallel()
+	tokenServer, _ := newTokenServer(t, 3600)
+
+	config := oauth2TestConfig(tokenServer.URL)
+	config.ClientSecret = "wrong"
+	client := New(Config{
+		Middlewares: []Middleware{NewClientCredentialsMiddleware(config)},
+	}, "test-oauth2-error")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), "http://api.invalid")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "oauth2: token request failed")
+	assert.True(t, IsHTTPError(err))
+}
+
+func TestClientCredentialsTokenSource_RefreshesBeforeExpiry(t *testing.T) {
+	t.Parallel()
+	tokenServer, issued := newTokenServer(t, 60)
+
+	source := NewClientCredentialsTokenSource(oauth2TestConfig(tokenServer.URL))
+	now := time.Now()
+	source.now = func() time.Time { return now }
+
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	// Still outside the 30s refresh window
+	now = now.Add(20 * time.Second)
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	// Within the refresh window a new token is fetched
+	now = now.Add(15 * time.Second)
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token.AccessToken)
+	assert.Equal(t, int32(2), atomic.LoadInt32(issued))
+
+	// Invalidating a token that was already replaced keeps the current one
+	source.Invalidate(&Token{AccessToken: "token-1"})
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token.AccessToken)
+}
+
+func TestTokenHeader(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "Bearer abc", tokenHeader(&Token{AccessToken: "abc"}))
+	assert.Equal(t, "Bearer abc", tokenHeader(&Token{AccessToken: "abc", TokenType: "bearer"}))
+	assert.Equal(t, "MAC abc", tokenHeader(&Token{AccessToken: "abc", TokenType: "MAC"}))
+}
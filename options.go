@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // RequestOption is a functional option for configuring HTTP requests.
@@ -62,6 +63,21 @@ func WithAccept(accept string) RequestOption {
 	return WithHeader("Accept", accept)
 }
 
+// WithIfNoneMatch sets the If-None-Match header, so the server answers 304 Not Modified
+// when the resource still has the ETag. Unquoted values are quoted.
+func WithIfNoneMatch(etag string) RequestOption {
+	if etag != "*" && !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = `"` + etag + `"`
+	}
+	return WithHeader("If-None-Match", etag)
+}
+
+// WithIfModifiedSince sets the If-Modified-Since header, so the server answers 304 Not Modified
+// when the resource hasn't changed since t.
+func WithIfModifiedSince(t time.Time) RequestOption {
+	return WithHeader("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+}
+
 // applyOptions applies all RequestOption to the request.
 func applyOptions(req *http.Request, opts []RequestOption) {
 	for _, opt := range opts {
@@ -83,7 +99,7 @@ func WithJSONBody(v interface{}) RequestOption {
 			if err != nil {
 				// In a real application it's better to return an error, but for compatibility with current API
 				// set empty body and add header with error for debugging
-				req.Body = io.NopCloser(strings.NewReader(""))
+				setBytesBody(req, nil)
 				req.Header.Set("X-JSON-Marshal-Error", err.Error())
 				return
 			}
@@ -91,8 +107,7 @@ func WithJSONBody(v interface{}) RequestOption {
 			data = dataBytes
 		}
 
-		req.Body = io.NopCloser(bytes.NewReader(data))
-		req.ContentLength = int64(len(data))
+		setBytesBody(req, data)
 		req.Header.Set("Content-Type", "application/json")
 	}
 }
@@ -101,9 +116,7 @@ func WithJSONBody(v interface{}) RequestOption {
 // Content-Type to application/x-www-form-urlencoded.
 func WithFormBody(values url.Values) RequestOption {
 	return func(req *http.Request) {
-		encoded := values.Encode()
-		req.Body = io.NopCloser(strings.NewReader(encoded))
-		req.ContentLength = int64(len(encoded))
+		setBytesBody(req, []byte(values.Encode()))
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 }
@@ -113,12 +126,11 @@ func WithXMLBody(v interface{}) RequestOption {
 	return func(req *http.Request) {
 		data, err := xml.Marshal(v)
 		if err != nil {
-			req.Body = io.NopCloser(strings.NewReader(""))
+			setBytesBody(req, nil)
 			req.Header.Set("X-XML-Marshal-Error", err.Error())
 			return
 		}
-		req.Body = io.NopCloser(bytes.NewReader(data))
-		req.ContentLength = int64(len(data))
+		setBytesBody(req, data)
 		req.Header.Set("Content-Type", "application/xml")
 	}
 }
@@ -126,8 +138,7 @@ func WithXMLBody(v interface{}) RequestOption {
 // WithTextBody sets the request body as the specified string and sets Content-Type to text/plain.
 func WithTextBody(text string) RequestOption {
 	return func(req *http.Request) {
-		req.Body = io.NopCloser(strings.NewReader(text))
-		req.ContentLength = int64(len(text))
+		setBytesBody(req, []byte(text))
 		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
 	}
 }
@@ -136,6 +147,9 @@ func WithTextBody(text string) RequestOption {
 // Useful when full control over the request body is needed.
 func WithRawBody(body io.Reader) RequestOption {
 	return func(req *http.Request) {
+		// A previous body factory no longer matches the new body
+		req.GetBody = nil
+
 		if body == nil {
 			req.Body = http.NoBody
 			req.ContentLength = 0
@@ -175,8 +189,32 @@ func WithMultipartFormData(fields map[string]string, boundary string) RequestOpt
 		}
 		fmt.Fprintf(&buf, "--%s--\r\n", boundary)
 
-		req.Body = io.NopCloser(&buf)
-		req.ContentLength = int64(buf.Len())
+		setBytesBody(req, buf.Bytes())
 		req.Header.Set("Content-Type", fmt.Sprintf("multipart/form-data; boundary=%s", boundary))
 	}
 }
+
+// WithBodyProvider sets the request body from a factory that is called again for every
+// retry attempt, so large or streaming bodies can be replayed without buffering in memory.
+// contentLength is the body size in bytes, or -1 if unknown.
+func WithBodyProvider(provider func() (io.ReadCloser, error), contentLength int64) RequestOption {
+	return func(req *http.Request) {
+		body, err := provider()
+		if err != nil {
+			// The error surfaces when the transport reads the body
+			body = io.NopCloser(errReader{err: err})
+		}
+		req.Body = body
+		req.GetBody = provider
+		req.ContentLength = contentLength
+	}
+}
+
+// setBytesBody sets an in-memory body together with a matching GetBody factory.
+func setBytesBody(req *http.Request, data []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassthrough_KeepsContentEncoding(t *testing.T) {
+	t.Parallel()
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, _ = gw.Write([]byte(strings.Repeat("proxied ", 100)))
+	_ = gw.Close()
+
+	var accepted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted = append(accepted, r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	client := New(Config{Passthrough: true}, "test-passthrough")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.Equal(t, int64(compressed.Len()), resp.ContentLength)
+	assert.False(t, resp.Uncompressed)
+	assert.Equal(t, compressed.Bytes(), raw)
+
+	// The caller's Accept-Encoding is forwarded as is
+	resp, err = client.Get(context.Background(), server.URL, WithHeader("Accept-Encoding", "br, gzip"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, []string{"", "br, gzip"}, accepted)
+}
+
+func TestPassthrough_DoesNotCompressRequests(t *testing.T) {
+	t.Parallel()
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(strings.Repeat("a", 4096)))
+	require.NoError(t, err)
+
+	req, err = compressRequestBody(req, Config{CompressRequests: true, CompressMinBytes: 1, Passthrough: true})
+	require.NoError(t, err)
+	assert.Empty(t, req.Header.Get("Content-Encoding"))
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Len(t, body, 4096)
+}
+
+func TestPassthrough_Validate(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, Config{Passthrough: true}.Validate())
+	require.NoError(t, Config{Passthrough: true, Transport: &http.Transport{DisableCompression: true}}.Validate())
+
+	var configErr *ConfigurationError
+	err := Config{Passthrough: true, Transport: &http.Transport{}}.Validate()
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "Transport.DisableCompression", configErr.Field)
+
+	err = Config{Passthrough: true, CompressRequests: true}.Validate()
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "Passthrough", configErr.Field)
+}
@@ -0,0 +1,128 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// pathParamsKey is the context key for the path parameters of a request.
+type pathParamsKey struct{}
+
+// pathTemplateKey is the context key for the path template of a resolved request.
+type pathTemplateKey struct{}
+
+// pathParamPattern matches a {name} placeholder of a path template.
+var pathParamPattern = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// WithPathParam replaces the {name} placeholder of the request path with the value,
+// escaped as a single path segment, e.g. client.Get(ctx, "/users/{id}", WithPathParam("id", 42)).
+// The values "." and ".." fail the request, since they would change the path.
+// The template, not the resolved path, is the endpoint label in metrics and span names.
+func WithPathParam(name string, value any) RequestOption {
+	return func(req *http.Request) {
+		existing, _ := req.Context().Value(pathParamsKey{}).(map[string]string)
+		params := make(map[string]string, len(existing)+1)
+		for k, v := range existing {
+			params[k] = v
+		}
+		params[name] = fmt.Sprint(value)
+		*req = *req.WithContext(context.WithValue(req.Context(), pathParamsKey{}, params))
+	}
+}
+
+// PathTemplateFromContext returns the path template of the request with the context,
+// e.g. "/users/{id}", when the path was built with WithPathParam.
+func PathTemplateFromContext(ctx context.Context) (string, bool) {
+	template, ok := ctx.Value(pathTemplateKey{}).(string)
+	return template, ok && template != ""
+}
+
+// parseBaseURL parses Config.BaseURL. An empty base URL returns nil.
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	if baseURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, NewConfigurationError("BaseURL", baseURL, err.Error())
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, NewConfigurationError("BaseURL", baseURL, "must be an absolute URL")
+	}
+	if u.Path == "" {
+		// JoinPath drops the leading slash of paths joined to an empty base path
+		u.Path = "/"
+	}
+	return u, nil
+}
+
+// resolveURL expands the path parameters of the request and joins relative URLs to Config.BaseURL.
+func (c *Client) resolveURL(req *http.Request) (*http.Request, error) {
+	params, _ := req.Context().Value(pathParamsKey{}).(map[string]string)
+	relative := req.URL.Scheme == "" && req.URL.Host == ""
+	if relative && c.baseURLErr != nil {
+		return nil, c.baseURLErr
+	}
+	// Without BaseURL relative URLs are left to Failover and LoadBalancer
+	relative = relative && c.baseURL != nil
+	if params == nil && !relative {
+		return req, nil
+	}
+
+	ctx := req.Context()
+	u := *req.URL
+	if params != nil {
+		template := u.Path
+		path, rawPath, err := expandPathTemplate(template, params)
+		if err != nil {
+			return nil, err
+		}
+		u.Path, u.RawPath = path, rawPath
+		ctx = context.WithValue(ctx, pathTemplateKey{}, template)
+	}
+	if relative {
+		resolved := c.baseURL.JoinPath(u.EscapedPath())
+		resolved.RawQuery = u.RawQuery
+		resolved.Fragment = u.Fragment
+		u = *resolved
+	}
+
+	req = req.Clone(ctx)
+	req.URL = &u
+	if relative {
+		req.Host = u.Host
+	}
+	return req, nil
+}
+
+// expandPathTemplate replaces the {name} placeholders of the template with the escaped
+// parameter values and returns the decoded and the escaped path.
+func expandPathTemplate(template string, params map[string]string) (path, rawPath string, err error) {
+	var decoded, escaped strings.Builder
+	last := 0
+	for _, match := range pathParamPattern.FindAllStringSubmatchIndex(template, -1) {
+		literal := template[last:match[0]]
+		decoded.WriteString(literal)
+		escaped.WriteString((&url.URL{Path: literal}).EscapedPath())
+
+		name := template[match[2]:match[3]]
+		value, ok := params[name]
+		if !ok {
+			return "", "", fmt.Errorf("path parameter %q of %q has no value", name, template)
+		}
+		if value == "." || value == ".." {
+			// PathEscape keeps dot segments, which would then move the request up the path
+			return "", "", fmt.Errorf("path parameter %q of %q must not be a dot segment %q", name, template, value)
+		}
+		decoded.WriteString(value)
+		escaped.WriteString(url.PathEscape(value))
+		last = match[1]
+	}
+	decoded.WriteString(template[last:])
+	escaped.WriteString((&url.URL{Path: template[last:]}).EscapedPath())
+	return decoded.String(), escaped.String(), nil
+}
@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPathTemplate_BaseURLAndParams(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	client := New(Config{
+		BaseURL:              server.URL + "/v1/",
+		TracingEnabled:       true,
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		MetricsLabels:        MetricsLabelsConfig{DropHost: true},
+	}, "test-path-template")
+	defer client.Close()
+	client.httpClient.Transport.(*RoundTripper).tracer = &Tracer{tracer: provider.Tracer("test")}
+
+	for _, id := range []any{42, "a/b c"} {
+		resp, err := client.Get(context.Background(), "/users/{id}/orders?limit=5",
+			WithPathParam("id", id))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	require.Len(t, server.RequestLog, 2)
+	assert.Equal(t, "/v1/users/42/orders?limit=5", server.RequestLog[0].URL)
+	assert.Equal(t, "/v1/users/a%2Fb%20c/orders?limit=5", server.RequestLog[1].URL)
+
+	labels := map[string]string{"client_name": "test-path-template", "host": "-", "path": "/users/{id}/orders"}
+	assert.Equal(t, 2.0, circuitBreakerMetric(t, reg, "http_client_requests_total", labels))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "HTTP GET /users/{id}/orders", spans[0].Name)
+}
+
+func TestPathTemplate_Errors(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-path-template-errors")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), "http://example.com/users/{id}/orders/{order}",
+		WithPathParam("id", 1))
+	assert.ErrorContains(t, err, `path parameter "order" of "/users/{id}/orders/{order}" has no value`)
+
+	// Dot segments would escape the templated path
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+	based := New(Config{BaseURL: server.URL + "/api"}, "test-path-template-dots")
+	defer based.Close()
+	for _, value := range []string{"..", "."} {
+		_, err = based.Get(context.Background(), "/users/{id}/orders", WithPathParam("id", value))
+		assert.ErrorContains(t, err, `path parameter "id" of "/users/{id}/orders" must not be a dot segment`)
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+
+	invalid := New(Config{BaseURL: "example.com/api"}, "test-path-template-invalid")
+	defer invalid.Close()
+	_, err = invalid.Get(context.Background(), "/users")
+	var configErr *ConfigurationError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "BaseURL", configErr.Field)
+	assert.ErrorContains(t, err, "must be an absolute URL")
+	assert.ErrorContains(t, Config{BaseURL: "example.com/api"}.Validate(), "BaseURL")
+}
+
+func TestParseBaseURL_WithoutPath(t *testing.T) {
+	t.Parallel()
+	base, err := parseBaseURL("http://api.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "http://api.example.com/users", base.JoinPath("/users").String())
+}
+
+func TestExpandPathTemplate(t *testing.T) {
+	t.Parallel()
+	path, rawPath, err := expandPathTemplate("/files/{dir}/{name}.txt", map[string]string{
+		"dir":  "a b",
+		"name": "?x",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/files/a b/?x.txt", path)
+	assert.Equal(t, "/files/a%20b/%3Fx.txt", rawPath)
+}
@@ -0,0 +1,481 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults of presigned multipart transfers.
+const (
+	defaultPresignedPartSize     = 8 << 20
+	defaultPresignedConcurrency  = 4
+	defaultPresignedPartAttempts = 3
+	presignedPartBaseDelay       = 200 * time.Millisecond
+	presignedPartMaxDelay        = 5 * time.Second
+)
+
+// amzChecksumSHA256Header carries the SHA-256 checksum of an S3 upload part.
+const amzChecksumSHA256Header = "X-Amz-Checksum-Sha256"
+
+// MultipartUpload describes an S3-compatible multipart upload through presigned URLs. The
+// upload is created and its URLs are presigned by the service owning the credentials, so
+// the client needs no AWS SDK.
+type MultipartUpload struct {
+	// PartURL returns the presigned UploadPart URL of the part number, starting at 1.
+	// It is called for every attempt, so it may presign URLs on demand
+	PartURL func(ctx context.Context, partNumber int) (string, error)
+
+	// CompleteURL is the presigned CompleteMultipartUpload URL; empty leaves completion
+	// to the caller with the returned parts
+	CompleteURL string
+
+	// AbortURL is the presigned AbortMultipartUpload URL called when the upload fails;
+	// empty leaves the parts to the bucket lifecycle rules
+	AbortURL string
+
+	// PartSize is the size of every part but the last (default: 8 MiB). S3 requires at
+	// least 5 MiB
+	PartSize int64
+
+	// Concurrency is the number of parts uploaded at once (default: 4)
+	Concurrency int
+
+	// MaxPartAttempts is the number of attempts of a part on top of the client retry
+	// policy (default: 3)
+	MaxPartAttempts int
+
+	// PartChecksum is the checksum sent with every part: ChecksumMD5 as Content-MD5 or
+	// ChecksumSHA256 as x-amz-checksum-sha256 (default: ChecksumMD5)
+	PartChecksum ChecksumAlgorithm
+
+	// DisablePartChecksum uploads parts without a checksum, e.g. when the presigned URLs
+	// don't allow the header
+	DisablePartChecksum bool
+
+	// OnProgress is called with the uploaded and total bytes after every part
+	OnProgress func(uploaded, total int64)
+}
+
+// withDefaults applies default values to the upload configuration.
+func (u MultipartUpload) withDefaults() MultipartUpload {
+	if u.PartSize <= 0 {
+		u.PartSize = defaultPresignedPartSize
+	}
+	if u.Concurrency <= 0 {
+		u.Concurrency = defaultPresignedConcurrency
+	}
+	if u.MaxPartAttempts <= 0 {
+		u.MaxPartAttempts = defaultPresignedPartAttempts
+	}
+	if u.PartChecksum == "" {
+		u.PartChecksum = ChecksumMD5
+	}
+	return u
+}
+
+// UploadedPart is a part of a multipart upload, as listed in CompleteMultipartUpload.
+type UploadedPart struct {
+	PartNumber     int    `xml:"PartNumber"`
+	ETag           string `xml:"ETag"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256,omitempty"`
+	Size           int64  `xml:"-"`
+}
+
+// S3Error is an error response of an S3-compatible service.
+type S3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+	RequestID  string `xml:"RequestId"`
+}
+
+// Error implements the error interface.
+func (e *S3Error) Error() string {
+	return fmt.Sprintf("s3 error %s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// UploadMultipart uploads size bytes of src in parts to presigned UploadPart URLs and
+// completes the upload at CompleteURL. Parts are uploaded concurrently, each with its
+// checksum and up to MaxPartAttempts attempts. A failed upload is aborted at AbortURL.
+// The parts are returned in order, e.g. to complete the upload on the server.
+func (c *Client) UploadMultipart(
+	ctx context.Context, src io.ReaderAt, size int64, upload MultipartUpload,
+) ([]UploadedPart, error) {
+	upload = upload.withDefaults()
+	if upload.PartURL == nil {
+		return nil, NewConfigurationError("MultipartUpload.PartURL", nil, "must be set")
+	}
+	if upload.PartChecksum != ChecksumMD5 && upload.PartChecksum != ChecksumSHA256 {
+		return nil, NewConfigurationError("MultipartUpload.PartChecksum", upload.PartChecksum, "must be md5 or sha-256")
+	}
+	if size < 0 {
+		return nil, NewConfigurationError("size", size, "must not be negative")
+	}
+
+	count := max(int((size+upload.PartSize-1)/upload.PartSize), 1)
+	parts := make([]UploadedPart, count)
+	progress := newTransferProgress(size, upload.OnProgress)
+	err := runParts(ctx, count, upload.Concurrency, func(ctx context.Context, i int) error {
+		offset := int64(i) * upload.PartSize
+		data := make([]byte, min(upload.PartSize, size-offset))
+		if _, err := src.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("read part %d: %w", i+1, err)
+		}
+		part, err := c.uploadPart(ctx, upload, i+1, data)
+		if err != nil {
+			return err
+		}
+		parts[i] = part
+		progress.add(part.Size)
+		return nil
+	})
+	if err == nil && upload.CompleteURL != "" {
+		err = c.completeMultipart(ctx, upload.CompleteURL, parts)
+	}
+	if err != nil {
+		if upload.AbortURL != "" {
+			c.abortMultipart(context.WithoutCancel(ctx), upload.AbortURL)
+		}
+		return nil, err
+	}
+	return parts, nil
+}
+
+// uploadPart uploads a part with up to MaxPartAttempts attempts.
+func (c *Client) uploadPart(ctx context.Context, upload MultipartUpload, number int, data []byte) (UploadedPart, error) {
+	part := UploadedPart{PartNumber: number, Size: int64(len(data))}
+	var opts []RequestOption
+	if !upload.DisablePartChecksum {
+		h := upload.PartChecksum.newHash()
+		h.Write(data)
+		sum := base64.StdEncoding.EncodeToString(h.Sum(nil))
+		if upload.PartChecksum == ChecksumSHA256 {
+			part.ChecksumSHA256 = sum
+			opts = append(opts, WithHeader(amzChecksumSHA256Header, sum))
+		} else {
+			opts = append(opts, WithHeader("Content-MD5", sum))
+		}
+	}
+
+	err := c.retryPart(ctx, upload.MaxPartAttempts, func() error {
+		url, err := upload.PartURL(ctx, number)
+		if err != nil {
+			return err
+		}
+		resp, err := c.Put(ctx, url, bytes.NewReader(data), opts...)
+		if err != nil {
+			return err
+		}
+		defer drainAndClose(resp.Body)
+		if err := s3StatusError(resp); err != nil {
+			return err
+		}
+		part.ETag = resp.Header.Get("ETag")
+		if part.ETag == "" {
+			return errors.New("response has no ETag")
+		}
+		return nil
+	})
+	if err != nil {
+		return part, fmt.Errorf("upload part %d: %w", number, err)
+	}
+	return part, nil
+}
+
+// completeMultipart completes the upload with the parts.
+func (c *Client) completeMultipart(ctx context.Context, url string, parts []UploadedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUpload"`
+		Parts   []UploadedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := c.Post(ctx, url, bytes.NewReader(body), WithContentType("application/xml"))
+	if err != nil {
+		return fmt.Errorf("complete multipart upload: %w", err)
+	}
+	defer drainAndClose(resp.Body)
+	if err := s3StatusError(resp); err != nil {
+		return fmt.Errorf("complete multipart upload: %w", err)
+	}
+
+	// CompleteMultipartUpload may fail after sending 200 OK, with an Error document as the body
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("complete multipart upload: %w", err)
+	}
+	if s3Err := parseS3Error(resp.StatusCode, data); s3Err != nil {
+		return fmt.Errorf("complete multipart upload: %w", s3Err)
+	}
+	return nil
+}
+
+// abortMultipart aborts the upload; errors are ignored since the upload already failed.
+func (c *Client) abortMultipart(ctx context.Context, url string) {
+	resp, err := c.Delete(ctx, url)
+	if err == nil {
+		drainAndClose(resp.Body)
+	}
+}
+
+// s3StatusError returns the S3 error document of a non-2xx response, or *HTTPError if the
+// body isn't one.
+func s3StatusError(resp *http.Response) error {
+	err := statusError(resp)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		if s3Err := parseS3Error(resp.StatusCode, httpErr.Body); s3Err != nil {
+			return s3Err
+		}
+	}
+	return err
+}
+
+// parseS3Error parses an S3 Error document, nil if data isn't one.
+func parseS3Error(status int, data []byte) *S3Error {
+	var doc struct {
+		XMLName xml.Name
+		S3Error
+	}
+	if xml.Unmarshal(data, &doc) != nil || doc.XMLName.Local != "Error" {
+		return nil
+	}
+	doc.S3Error.StatusCode = status
+	return &doc.S3Error
+}
+
+// MultipartDownload configures Client.DownloadMultipart.
+type MultipartDownload struct {
+	// PartSize is the size of every range request (default: 8 MiB)
+	PartSize int64
+
+	// Concurrency is the number of ranges downloaded at once (default: 4)
+	Concurrency int
+
+	// MaxPartAttempts is the number of attempts of a range on top of the client retry
+	// policy (default: 3)
+	MaxPartAttempts int
+
+	// OnProgress is called with the downloaded and total bytes after every range
+	OnProgress func(downloaded, total int64)
+}
+
+// withDefaults applies default values to the download configuration.
+func (d MultipartDownload) withDefaults() MultipartDownload {
+	if d.PartSize <= 0 {
+		d.PartSize = defaultPresignedPartSize
+	}
+	if d.Concurrency <= 0 {
+		d.Concurrency = defaultPresignedConcurrency
+	}
+	if d.MaxPartAttempts <= 0 {
+		d.MaxPartAttempts = defaultPresignedPartAttempts
+	}
+	return d
+}
+
+// DownloadMultipart downloads the resource at url, e.g. a presigned GET URL, into dst with
+// concurrent Range requests of PartSize bytes. The first range reveals the size and ETag;
+// the other ranges are requested with If-Match, so a resource replaced during the download
+// fails with 412 instead of mixing versions. A server ignoring Range, or known from Probe not
+// to support it, is read in a single response. It returns the number of bytes written.
+func (c *Client) DownloadMultipart(
+	ctx context.Context, url string, dst io.WriterAt, download MultipartDownload,
+) (int64, error) {
+	download = download.withDefaults()
+
+	var rangeOpts []RequestOption
+	if c.rangeSupport(url) != RangeSupportNone {
+		rangeOpts = append(rangeOpts, WithHeader("Range", fmt.Sprintf("bytes=0-%d", download.PartSize-1)))
+	}
+	var first *http.Response
+	err := c.retryPart(ctx, download.MaxPartAttempts, func() error {
+		resp, err := c.Get(ctx, url, rangeOpts...)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			// An empty resource has no satisfiable range
+			first = resp
+			return nil
+		}
+		if err := s3StatusError(resp); err != nil {
+			drainAndClose(resp.Body)
+			return err
+		}
+		first = resp
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("download range 1: %w", err)
+	}
+	defer drainAndClose(first.Body)
+
+	switch first.StatusCode {
+	case http.StatusRequestedRangeNotSatisfiable:
+		return 0, nil
+	case http.StatusPartialContent:
+	default:
+		// Range is not supported: the whole resource is in the response
+		written, err := io.Copy(io.NewOffsetWriter(dst, 0), first.Body)
+		if err == nil && download.OnProgress != nil {
+			download.OnProgress(written, written)
+		}
+		return written, err
+	}
+
+	total := contentRangeTotal(first)
+	if total < 0 {
+		return 0, errors.New("download range 1: Content-Range has no total size")
+	}
+	firstSize := min(download.PartSize, total)
+	progress := newTransferProgress(total, download.OnProgress)
+	if err := copyRange(dst, first.Body, 0, firstSize); err != nil {
+		return 0, fmt.Errorf("download range 1: %w", err)
+	}
+	progress.add(firstSize)
+
+	var opts []RequestOption
+	if etag := first.Header.Get("ETag"); etag != "" {
+		opts = append(opts, WithHeader("If-Match", etag))
+	}
+	count := int((total + download.PartSize - 1) / download.PartSize)
+	err = runParts(ctx, count-1, download.Concurrency, func(ctx context.Context, i int) error {
+		start := int64(i+1) * download.PartSize
+		end := min(start+download.PartSize, total) - 1
+		err := c.retryPart(ctx, download.MaxPartAttempts, func() error {
+			resp, err := c.Get(ctx, url, append(opts, WithHeader("Range", fmt.Sprintf("bytes=%d-%d", start, end)))...)
+			if err != nil {
+				return err
+			}
+			defer drainAndClose(resp.Body)
+			if err := s3StatusError(resp); err != nil {
+				return err
+			}
+			if resp.StatusCode != http.StatusPartialContent || contentRangeStart(resp) != start {
+				return fmt.Errorf("unexpected response %d with Content-Range %q", resp.StatusCode, resp.Header.Get("Content-Range"))
+			}
+			return copyRange(dst, resp.Body, start, end-start+1)
+		})
+		if err != nil {
+			return fmt.Errorf("download range %d: %w", i+2, err)
+		}
+		progress.add(end - start + 1)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// copyRange writes exactly size bytes of body to dst at offset.
+func copyRange(dst io.WriterAt, body io.Reader, offset, size int64) error {
+	written, err := io.Copy(io.NewOffsetWriter(dst, offset), io.LimitReader(body, size))
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// retryPart calls fn up to attempts times with exponential backoff between the attempts,
+// waiting with Config.Clock.
+func (c *Client) retryPart(ctx context.Context, attempts int, fn func() error) error {
+	clock := clockOrDefault(c.config.Clock)
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if delay := CalculateBackoffDelay(attempt, presignedPartBaseDelay, presignedPartMaxDelay, 0); delay > 0 {
+			timer := clock.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return errors.Join(err, ctx.Err())
+			case <-timer.C():
+			}
+		}
+		if err = fn(); err == nil || ctx.Err() != nil || statusCodeOf(err) == http.StatusPreconditionFailed {
+			// A changed resource fails every attempt
+			return err
+		}
+	}
+	return err
+}
+
+// statusCodeOf returns the HTTP status of an S3Error or HTTPError, zero for other errors.
+func statusCodeOf(err error) int {
+	var s3Err *S3Error
+	if errors.As(err, &s3Err) {
+		return s3Err.StatusCode
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// runParts calls fn for the parts 0..count-1 with up to concurrency calls at once. The first
+// error cancels the context of the other calls and is returned.
+func runParts(ctx context.Context, count, concurrency int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, count) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+	for i := 0; i < count && ctx.Err() == nil; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return context.Cause(ctx)
+}
+
+// transferProgress sums the bytes of finished parts and reports them in order.
+type transferProgress struct {
+	mu    sync.Mutex
+	done  int64
+	total int64
+	fn    func(done, total int64)
+}
+
+// newTransferProgress creates a progress reporter; fn may be nil.
+func newTransferProgress(total int64, fn func(done, total int64)) *transferProgress {
+	return &transferProgress{total: total, fn: fn}
+}
+
+// add records a finished part.
+func (p *transferProgress) add(n int64) {
+	if p.fn == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	p.fn(p.done, p.total)
+}
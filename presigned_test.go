@@ -0,0 +1,263 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // Content-MD5 of the test parts
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is an S3-compatible multipart upload endpoint.
+type fakeS3 struct {
+	mu        sync.Mutex
+	parts     map[string][]byte
+	failOnce  map[string]bool
+	completed []UploadedPart
+	aborted   atomic.Int32
+	// completeBody is the body of a 200 OK CompleteMultipartUpload response
+	completeBody string
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		sum := md5.Sum(body) //nolint:gosec // see import
+		if r.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `<Error><Code>BadDigest</Code><Message>digest mismatch</Message></Error>`)
+			return
+		}
+		if s.failOnce[r.URL.Path] {
+			delete(s.failOnce, r.URL.Path)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, `<Error><Code>SlowDown</Code><Message>reduce your request rate</Message></Error>`)
+			return
+		}
+		s.parts[r.URL.Path] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sum))
+	case http.MethodPost:
+		var doc struct {
+			Parts []UploadedPart `xml:"Part"`
+		}
+		_ = xml.NewDecoder(r.Body).Decode(&doc)
+		s.completed = doc.Parts
+		_, _ = io.WriteString(w, s.completeBody)
+	case http.MethodDelete:
+		s.aborted.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	s3 := &fakeS3{parts: make(map[string][]byte), failOnce: make(map[string]bool)}
+	server := httptest.NewServer(s3)
+	t.Cleanup(server.Close)
+	return s3, server
+}
+
+func TestUploadMultipart(t *testing.T) {
+	t.Parallel()
+	s3, server := newFakeS3(t)
+	s3.failOnce["/part/2"] = true
+	s3.completeBody = `<CompleteMultipartUploadResult><ETag>"abc-3"</ETag></CompleteMultipartUploadResult>`
+
+	client := New(Config{}, "test-upload-multipart")
+	defer client.Close()
+
+	payload := bytes.Repeat([]byte("0123456789"), 25) // 250 bytes in parts of 100
+	var progress []int64
+	parts, err := client.UploadMultipart(context.Background(), bytes.NewReader(payload), int64(len(payload)), MultipartUpload{
+		PartURL: func(_ context.Context, n int) (string, error) {
+			return fmt.Sprintf("%s/part/%d", server.URL, n), nil
+		},
+		CompleteURL: server.URL + "/complete",
+		AbortURL:    server.URL + "/abort",
+		PartSize:    100,
+		Concurrency: 2,
+		OnProgress:  func(uploaded, _ int64) { progress = append(progress, uploaded) },
+	})
+	require.NoError(t, err)
+
+	require.Len(t, parts, 3)
+	for i, part := range parts {
+		assert.Equal(t, i+1, part.PartNumber)
+		assert.NotEmpty(t, part.ETag)
+	}
+	assert.Equal(t, int64(50), parts[2].Size)
+	assert.Equal(t, payload, bytes.Join([][]byte{s3.parts["/part/1"], s3.parts["/part/2"], s3.parts["/part/3"]}, nil))
+	assert.Equal(t, parts, withoutSizes(s3.completed, parts))
+	require.Len(t, progress, 3)
+	assert.Equal(t, int64(250), progress[2])
+	assert.Zero(t, s3.aborted.Load())
+}
+
+func TestUploadMultipart_CompleteErrorAborts(t *testing.T) {
+	t.Parallel()
+	s3, server := newFakeS3(t)
+	s3.completeBody = `<?xml version="1.0"?><Error><Code>InternalError</Code><Message>try again</Message></Error>`
+
+	client := New(Config{}, "test-upload-multipart-abort")
+	defer client.Close()
+
+	_, err := client.UploadMultipart(context.Background(), strings.NewReader("data"), 4, MultipartUpload{
+		PartURL:      func(context.Context, int) (string, error) { return server.URL + "/part/1", nil },
+		CompleteURL:  server.URL + "/complete",
+		AbortURL:     server.URL + "/abort",
+		PartChecksum: ChecksumMD5,
+	})
+	var s3Err *S3Error
+	require.ErrorAs(t, err, &s3Err)
+	assert.Equal(t, "InternalError", s3Err.Code)
+	assert.Equal(t, http.StatusOK, s3Err.StatusCode)
+	assert.Equal(t, int32(1), s3.aborted.Load())
+
+	_, err = client.UploadMultipart(context.Background(), strings.NewReader("data"), 4, MultipartUpload{
+		PartURL:      func(context.Context, int) (string, error) { return server.URL, nil },
+		PartChecksum: ChecksumSHA512,
+	})
+	var configErr *ConfigurationError
+	require.ErrorAs(t, err, &configErr)
+}
+
+func TestDownloadMultipart(t *testing.T) {
+	t.Parallel()
+	payload := bytes.Repeat([]byte("abcdefghij"), 30)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Range") != "bytes=0-63" {
+			assert.Equal(t, `"v1"`, r.Header.Get("If-Match"))
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-download-multipart")
+	defer client.Close()
+
+	dst := &memWriterAt{}
+	var last int64
+	written, err := client.DownloadMultipart(context.Background(), server.URL, dst, MultipartDownload{
+		PartSize:    64,
+		Concurrency: 3,
+		OnProgress:  func(downloaded, total int64) { last = downloaded; assert.Equal(t, int64(300), total) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(payload)), written)
+	assert.Equal(t, payload, dst.bytes())
+	assert.Equal(t, int64(300), last)
+	assert.Equal(t, int32(5), requests.Load())
+}
+
+func TestDownloadMultipart_ChangedResource(t *testing.T) {
+	t.Parallel()
+	var version atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The resource is replaced after the first range
+		w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, version.Add(1)))
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-download-multipart-changed")
+	defer client.Close()
+
+	_, err := client.DownloadMultipart(context.Background(), server.URL, &memWriterAt{}, MultipartDownload{PartSize: 50})
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusPreconditionFailed, httpErr.StatusCode)
+	// A precondition failure isn't retried
+	assert.Equal(t, int32(2), version.Load())
+}
+
+func TestDownloadMultipart_WithoutRange(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "whole body")
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-download-multipart-norange")
+	defer client.Close()
+
+	dst := &memWriterAt{}
+	written, err := client.DownloadMultipart(context.Background(), server.URL, dst, MultipartDownload{PartSize: 4})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), written)
+	assert.Equal(t, "whole body", string(dst.bytes()))
+}
+
+// memWriterAt is an in-memory io.WriterAt.
+type memWriterAt struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (m *memWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if end := int(off) + len(p); end > len(m.data) {
+		m.data = append(m.data, make([]byte, end-len(m.data))...)
+	}
+	copy(m.data[off:], p)
+	return len(p), nil
+}
+
+func (m *memWriterAt) bytes() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data
+}
+
+// withoutSizes returns the completed parts with the sizes of uploaded, which aren't sent.
+func withoutSizes(completed, uploaded []UploadedPart) []UploadedPart {
+	result := make([]UploadedPart, len(completed))
+	for i, part := range completed {
+		part.Size = uploaded[i].Size
+		result[i] = part
+	}
+	return result
+}
+
+func TestRetryPart_WaitsWithClock(t *testing.T) {
+	t.Parallel()
+	clock := NewFakeClock(time.Now())
+	client := New(Config{Clock: clock}, "test-retry-part-clock")
+	defer client.Close()
+
+	var calls atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		done <- client.retryPart(context.Background(), 2, func() error {
+			if calls.Add(1) == 1 {
+				return &S3Error{StatusCode: http.StatusServiceUnavailable}
+			}
+			return nil
+		})
+	}()
+
+	// The backoff before the second attempt waits for the fake clock
+	clock.BlockUntil(1)
+	assert.Equal(t, int32(1), calls.Load())
+	clock.Advance(presignedPartBaseDelay)
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PriorityHeaderName is the header of the Extensible Prioritization Scheme (RFC 9218).
+const PriorityHeaderName = "Priority"
+
+// Urgency levels of RFC 9218: 0 is the most urgent, 7 the least.
+const (
+	MinUrgency     = 0
+	DefaultUrgency = 3
+	MaxUrgency     = 7
+)
+
+// PriorityParams are the parameters of a Priority header (RFC 9218).
+type PriorityParams struct {
+	// Urgency is from 0 (highest) to 7 (lowest), 3 by default
+	Urgency int
+	// Incremental tells that the response can be processed as its chunks arrive,
+	// e.g. progressive images, so the server may interleave it with other responses
+	Incremental bool
+}
+
+// DefaultPriorityParams returns the priority assumed without a Priority header.
+func DefaultPriorityParams() PriorityParams {
+	return PriorityParams{Urgency: DefaultUrgency}
+}
+
+// String formats the parameters as a Priority header value, omitting defaults,
+// e.g. "u=5, i". The default priority is an empty string.
+func (p PriorityParams) String() string {
+	var params []string
+	if p.Urgency != DefaultUrgency {
+		params = append(params, "u="+strconv.Itoa(min(max(p.Urgency, MinUrgency), MaxUrgency)))
+	}
+	if p.Incremental {
+		params = append(params, "i")
+	}
+	return strings.Join(params, ", ")
+}
+
+// ParsePriority parses a Priority header value. Unknown parameters and invalid values are
+// ignored, as RFC 9218 requires, so the result falls back to the defaults.
+func ParsePriority(value string) PriorityParams {
+	params := DefaultPriorityParams()
+	for _, member := range strings.Split(value, ",") {
+		key, val, hasValue := strings.Cut(strings.TrimSpace(member), "=")
+		// Parameters of a dictionary member don't change its meaning here
+		val, _, _ = strings.Cut(val, ";")
+		switch strings.TrimSpace(key) {
+		case "u":
+			if u, err := strconv.Atoi(strings.TrimSpace(val)); err == nil && u >= MinUrgency && u <= MaxUrgency {
+				params.Urgency = u
+			}
+		case "i":
+			val = strings.TrimSpace(val)
+			if !hasValue || val == "?1" {
+				params.Incremental = true
+			} else if val == "?0" {
+				params.Incremental = false
+			}
+		}
+	}
+	return params
+}
+
+// ResponsePriority returns the priority a server announced in the Priority header of its
+// response, which tells intermediaries how the server scheduled it. False if the response
+// has no Priority header.
+func ResponsePriority(resp *http.Response) (PriorityParams, bool) {
+	value := resp.Header.Get(PriorityHeaderName)
+	if value == "" {
+		return PriorityParams{}, false
+	}
+	return ParsePriority(value), true
+}
+
+// WithPriorityHeader sets the Priority header (RFC 9218) of the request, asking the server and
+// intermediaries to schedule its response with the urgency and incrementality of params.
+// The default priority removes the header.
+func WithPriorityHeader(params PriorityParams) RequestOption {
+	return func(req *http.Request) {
+		if value := params.String(); value != "" {
+			req.Header.Set(PriorityHeaderName, value)
+		} else {
+			req.Header.Del(PriorityHeaderName)
+		}
+	}
+}
+
+// priorityParams maps a client-side priority onto an RFC 9218 urgency: PriorityHigh is
+// u=1, PriorityNormal the default u=3 and PriorityLow u=5.
+func (p Priority) priorityParams() PriorityParams {
+	return PriorityParams{Urgency: min(max(DefaultUrgency-2*int(p), MinUrgency), MaxUrgency)}
+}
@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityParams_StringAndParse(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "", DefaultPriorityParams().String())
+	assert.Equal(t, "u=5, i", PriorityParams{Urgency: 5, Incremental: true}.String())
+	assert.Equal(t, "i", PriorityParams{Urgency: DefaultUrgency, Incremental: true}.String())
+	assert.Equal(t, "u=7", PriorityParams{Urgency: 12}.String())
+
+	tests := map[string]PriorityParams{
+		"":                {Urgency: 3},
+		"u=5, i":          {Urgency: 5, Incremental: true},
+		"i=?1,u=0":        {Urgency: 0, Incremental: true},
+		"u=1;x=y, i=?0":   {Urgency: 1},
+		"u=9, foo=bar, i": {Urgency: 3, Incremental: true},
+		"u=abc":           {Urgency: 3},
+	}
+	for value, expected := range tests {
+		assert.Equal(t, expected, ParsePriority(value), value)
+	}
+}
+
+func TestPriority_PriorityParams(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 1, PriorityHigh.priorityParams().Urgency)
+	assert.Equal(t, DefaultUrgency, PriorityNormal.priorityParams().Urgency)
+	assert.Equal(t, 5, PriorityLow.priorityParams().Urgency)
+	assert.Equal(t, MaxUrgency, Priority(-10).priorityParams().Urgency)
+}
+
+func TestPriorityHeaders(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Headers: map[string]string{"Priority": "u=6"}})
+	defer server.Close()
+
+	get := func(client *Client, opts ...RequestOption) string {
+		resp, err := client.Get(context.Background(), server.URL, opts...)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return server.GetLastRequest().Headers["Priority"]
+	}
+
+	client := New(Config{PriorityHeaders: true}, "test-priority-headers")
+	defer client.Close()
+	assert.Equal(t, "u=5", get(client, WithPriority(PriorityLow)))
+	assert.Equal(t, "u=1", get(client, WithPriority(PriorityHigh)))
+	assert.Empty(t, get(client))
+	assert.Equal(t, "u=7, i", get(client, WithPriority(PriorityLow), WithPriorityHeader(PriorityParams{Urgency: 7, Incremental: true})))
+
+	disabled := New(Config{}, "test-priority-headers-disabled")
+	defer disabled.Close()
+	assert.Empty(t, get(disabled, WithPriority(PriorityLow)))
+
+	resp, err := disabled.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	params, ok := ResponsePriority(resp)
+	assert.True(t, ok)
+	assert.Equal(t, PriorityParams{Urgency: 6}, params)
+}
@@ -0,0 +1,190 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// defaultMaxQueueDepth is the default number of requests waiting for a slot when MaxInflight is set.
+const defaultMaxQueueDepth = 100
+
+// Priority defines the order in which queued requests get a free slot and are shed.
+type Priority int
+
+// Request priorities. PriorityNormal is used when WithPriority is not specified.
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// String returns the priority name.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+// WithPriority sets the priority of the request in the client-side queue (see Config.MaxInflight).
+func WithPriority(p Priority) RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.priority = p
+		})
+	}
+}
+
+// QueueFullError is returned when a request is rejected or shed because the queue is full.
+type QueueFullError struct {
+	// Priority is the priority of the rejected request
+	Priority Priority
+	// MaxQueueDepth is the queue capacity
+	MaxQueueDepth int
+}
+
+// Error implements the error interface.
+func (e *QueueFullError) Error() string {
+	return fmt.Sprintf("request queue is full (%d waiting): %s priority request rejected", e.MaxQueueDepth, e.Priority)
+}
+
+// queueWaiter is a request waiting for a slot.
+type queueWaiter struct {
+	priority Priority
+	ready    chan struct{} // closed when the slot is granted or the waiter is shed
+	err      error         // set before ready is closed when the waiter is shed
+}
+
+// requestQueue limits concurrent requests and queues the rest by priority.
+// When the queue is full, a new request displaces the newest waiter of a lower priority.
+type requestQueue struct {
+	maxInflight int
+	maxDepth    int
+
+	mu       sync.Mutex
+	inflight int
+	waiting  map[Priority][]*queueWaiter
+	depth    int
+}
+
+// newRequestQueue creates a queue allowing maxInflight concurrent requests and maxDepth waiters.
+func newRequestQueue(maxInflight, maxDepth int) *requestQueue {
+	if maxDepth < 0 {
+		maxDepth = 0
+	}
+	return &requestQueue{
+		maxInflight: maxInflight,
+		maxDepth:    maxDepth,
+		waiting:     make(map[Priority][]*queueWaiter),
+	}
+}
+
+// acquire waits for a free slot. Every successful acquire must be followed by release.
+func (q *requestQueue) acquire(ctx context.Context, priority Priority) error {
+	q.mu.Lock()
+	if q.inflight < q.maxInflight && q.depth == 0 {
+		q.inflight++
+		q.mu.Unlock()
+		return nil
+	}
+
+	if q.depth >= q.maxDepth {
+		victim := q.lowestWaiterBelow(priority)
+		if victim == nil {
+			q.mu.Unlock()
+			return &QueueFullError{Priority: priority, MaxQueueDepth: q.maxDepth}
+		}
+		q.remove(victim)
+		victim.err = &QueueFullError{Priority: victim.priority, MaxQueueDepth: q.maxDepth}
+		close(victim.ready)
+	}
+
+	w := &queueWaiter{priority: priority, ready: make(chan struct{})}
+	q.waiting[priority] = append(q.waiting[priority], w)
+	q.depth++
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return w.err
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	select {
+	case <-w.ready:
+		// The slot was granted (or the waiter shed) concurrently with cancellation
+		q.mu.Unlock()
+		if w.err == nil {
+			q.release()
+		}
+	default:
+		q.remove(w)
+		q.mu.Unlock()
+	}
+	return ctx.Err()
+}
+
+// release frees a slot, handing it over to the highest priority waiter.
+func (q *requestQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if next := q.highestWaiter(); next != nil {
+		// The slot passes to the waiter, inflight stays the same
+		q.remove(next)
+		close(next.ready)
+		return
+	}
+	q.inflight--
+}
+
+// highestWaiter returns the oldest waiter of the highest priority.
+func (q *requestQueue) highestWaiter() *queueWaiter {
+	var best *queueWaiter
+	for priority, waiters := range q.waiting {
+		if len(waiters) > 0 && (best == nil || priority > best.priority) {
+			best = waiters[0]
+		}
+	}
+	return best
+}
+
+// lowestWaiterBelow returns the newest waiter of the lowest priority below p.
+func (q *requestQueue) lowestWaiterBelow(p Priority) *queueWaiter {
+	var worst *queueWaiter
+	for priority, waiters := range q.waiting {
+		if len(waiters) > 0 && priority < p && (worst == nil || priority < worst.priority) {
+			worst = waiters[len(waiters)-1]
+		}
+	}
+	return worst
+}
+
+// remove deletes the waiter from the queue.
+func (q *requestQueue) remove(w *queueWaiter) {
+	waiters := q.waiting[w.priority]
+	for i, candidate := range waiters {
+		if candidate == w {
+			q.waiting[w.priority] = append(waiters[:i:i], waiters[i+1:]...)
+			q.depth--
+			return
+		}
+	}
+}
+
+// requestPriority returns the priority set by WithPriority.
+func requestPriority(ctx context.Context) Priority {
+	if overrides := getRequestOverrides(ctx); overrides != nil {
+		return overrides.priority
+	}
+	return PriorityNormal
+}
@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acquireAsync starts acquire in a goroutine and returns its result channel once the waiter is queued.
+func acquireAsync(t *testing.T, q *requestQueue, ctx context.Context, p Priority) <-chan error {
+	t.Helper()
+	q.mu.Lock()
+	depth := q.depth
+	q.mu.Unlock()
+
+	result := make(chan error, 1)
+	go func() { result <- q.acquire(ctx, p) }()
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.depth == depth+1
+	}, time.Second, time.Millisecond)
+	return result
+}
+
+func TestRequestQueue_GrantsByPriority(t *testing.T) {
+	t.Parallel()
+	q := newRequestQueue(1, 10)
+	ctx := context.Background()
+	require.NoError(t, q.acquire(ctx, PriorityNormal))
+
+	low := acquireAsync(t, q, ctx, PriorityLow)
+	normal := acquireAsync(t, q, ctx, PriorityNormal)
+	high := acquireAsync(t, q, ctx, PriorityHigh)
+
+	q.release()
+	require.NoError(t, <-high)
+	q.release()
+	require.NoError(t, <-normal)
+	q.release()
+	require.NoError(t, <-low)
+	q.release()
+
+	assert.Equal(t, 0, q.inflight)
+	assert.Equal(t, 0, q.depth)
+}
+
+func TestRequestQueue_ShedsLowerPriority(t *testing.T) {
+	t.Parallel()
+	q := newRequestQueue(1, 1)
+	ctx := context.Background()
+	require.NoError(t, q.acquire(ctx, PriorityNormal))
+
+	low := acquireAsync(t, q, ctx, PriorityLow)
+
+	// A high priority request displaces the queued low priority one
+	high := make(chan error, 1)
+	go func() { high <- q.acquire(ctx, PriorityHigh) }()
+
+	var full *QueueFullError
+	require.True(t, errors.As(<-low, &full))
+	assert.Equal(t, PriorityLow, full.Priority)
+	assert.Equal(t, 1, full.MaxQueueDepth)
+
+	// Nothing below normal is queued now, so a new normal request is rejected
+	err := q.acquire(ctx, PriorityNormal)
+	require.True(t, errors.As(err, &full))
+	assert.Equal(t, PriorityNormal, full.Priority)
+
+	q.release()
+	require.NoError(t, <-high)
+	q.release()
+}
+
+func TestRequestQueue_CancelledWaiterLeavesQueue(t *testing.T) {
+	t.Parallel()
+	q := newRequestQueue(1, 1)
+	require.NoError(t, q.acquire(context.Background(), PriorityNormal))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	waiter := acquireAsync(t, q, ctx, PriorityNormal)
+	cancel()
+	assert.ErrorIs(t, <-waiter, context.Canceled)
+
+	q.release()
+	assert.Equal(t, 0, q.inflight)
+	assert.Equal(t, 0, q.depth)
+}
+
+func TestMaxInflight_ShedsLowPriorityRequests(t *testing.T) {
+	t.Parallel()
+	server, calls, release := newGatedServer(t)
+
+	client := New(Config{MaxInflight: 1, MaxQueueDepth: 1}, "test-priority-queue")
+	defer client.Close()
+	rt := client.httpClient.Transport.(*RoundTripper)
+
+	var wg sync.WaitGroup
+	results := make(map[Priority]error)
+	var mu sync.Mutex
+	run := func(p Priority) {
+		defer wg.Done()
+		resp, err := client.Get(context.Background(), server.URL, WithPriority(p))
+		if err == nil {
+			resp.Body.Close()
+		}
+		mu.Lock()
+		results[p] = err
+		mu.Unlock()
+	}
+	queued := func(depth int) func() bool {
+		return func() bool {
+			rt.queue.mu.Lock()
+			defer rt.queue.mu.Unlock()
+			return rt.queue.depth == depth
+		}
+	}
+
+	wg.Add(3)
+	go run(PriorityNormal)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(calls) == 1 }, time.Second, time.Millisecond)
+	go run(PriorityLow)
+	require.Eventually(t, queued(1), time.Second, time.Millisecond)
+	go run(PriorityHigh)
+
+	// The low priority request is shed as soon as the high priority one arrives
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		_, done := results[PriorityLow]
+		return done
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	var full *QueueFullError
+	assert.True(t, errors.As(results[PriorityLow], &full))
+	assert.NoError(t, results[PriorityNormal])
+	assert.NoError(t, results[PriorityHigh])
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
@@ -0,0 +1,215 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultProbeTTL is how long Probe results are cached by default.
+const defaultProbeTTL = 5 * time.Minute
+
+// RangeSupport tells whether a server accepts Range requests.
+type RangeSupport int
+
+// Range support reported by Capabilities.
+const (
+	// RangeSupportUnknown means the server announced nothing, so Range requests may still work
+	RangeSupportUnknown RangeSupport = iota
+	// RangeSupportBytes means the server accepts byte ranges
+	RangeSupportBytes
+	// RangeSupportNone means the server announced Accept-Ranges: none
+	RangeSupportNone
+)
+
+// String returns the name of the range support.
+func (r RangeSupport) String() string {
+	switch r {
+	case RangeSupportBytes:
+		return "bytes"
+	case RangeSupportNone:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
+// Capabilities are the features of a server found by Client.Probe.
+type Capabilities struct {
+	// Host is the host the capabilities are cached for
+	Host string
+	// AllowedMethods lists the methods from the Allow and Access-Control-Allow-Methods
+	// headers; nil if the server announced none
+	AllowedMethods []string
+	// Ranges tells whether Range requests are supported
+	Ranges RangeSupport
+	// AcceptEncoding lists the request content codings the server accepts (RFC 7694)
+	AcceptEncoding []string
+	// Validators is set when responses carry an ETag or Last-Modified for conditional requests
+	Validators bool
+	// ContentLength is the size of the probed resource, -1 if unknown
+	ContentLength int64
+	// ContentType is the media type of the probed resource
+	ContentType string
+	// Protocol is the HTTP version of the response, e.g. "HTTP/2.0"
+	Protocol string
+	// Server is the Server header
+	Server string
+	// ProbedAt is the time of the probe
+	ProbedAt time.Time
+}
+
+// Allows checks if the server allows the method. Unknown methods are allowed.
+func (c *Capabilities) Allows(method string) bool {
+	if c.AllowedMethods == nil {
+		return true
+	}
+	for _, allowed := range c.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// probeCache stores Capabilities per host.
+type probeCache struct {
+	mu    sync.Mutex
+	hosts map[string]*Capabilities
+}
+
+// newProbeCache creates an empty cache.
+func newProbeCache() *probeCache {
+	return &probeCache{hosts: make(map[string]*Capabilities)}
+}
+
+// get returns the capabilities of the host probed within ttl.
+func (p *probeCache) get(host string, now time.Time, ttl time.Duration) (*Capabilities, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	caps, ok := p.hosts[host]
+	if !ok || now.Sub(caps.ProbedAt) >= ttl {
+		return nil, false
+	}
+	return caps, true
+}
+
+// set stores the capabilities of their host.
+func (p *probeCache) set(caps *Capabilities) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hosts[caps.Host] = caps
+}
+
+// Probe finds the capabilities of the server of url with HEAD and OPTIONS requests and caches
+// them per host for Config.ProbeTTL. A fresh cached result is returned without requests.
+// Servers rejecting HEAD are probed with a single-byte Range GET instead. Download and
+// DownloadMultipart use the cached Range support instead of guessing.
+func (c *Client) Probe(ctx context.Context, url string) (*Capabilities, error) {
+	host := c.requestHost(url)
+	now := clockOrDefault(c.config.Clock).Now()
+	if caps, ok := c.probes.get(host, now, c.config.ProbeTTL); ok {
+		return caps, nil
+	}
+
+	caps := &Capabilities{Host: host, ContentLength: -1, ProbedAt: now}
+	resp, err := c.Head(ctx, url)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		caps.readAllow(resp.Header)
+		drainAndClose(resp.Body)
+		resp, err = c.Get(ctx, url, WithHeader("Range", "bytes=0-0"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	drainAndClose(resp.Body)
+	if err := statusError(resp); err != nil {
+		return nil, err
+	}
+	caps.readResponse(resp)
+
+	if resp, err := c.options(ctx, url); err == nil {
+		// Servers often reject OPTIONS; only its headers matter
+		caps.readAllow(resp.Header)
+		drainAndClose(resp.Body)
+	} else if ctx.Err() != nil {
+		return nil, err
+	}
+
+	c.probes.set(caps)
+	return caps, nil
+}
+
+// options executes an OPTIONS request.
+func (c *Client) options(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodOptions, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+// CachedCapabilities returns the capabilities of the host from an earlier Probe, if they
+// are still fresh.
+func (c *Client) CachedCapabilities(host string) (*Capabilities, bool) {
+	return c.probes.get(host, clockOrDefault(c.config.Clock).Now(), c.config.ProbeTTL)
+}
+
+// rangeSupport returns the cached Range support of the server of url.
+func (c *Client) rangeSupport(url string) RangeSupport {
+	if caps, ok := c.CachedCapabilities(c.requestHost(url)); ok {
+		return caps.Ranges
+	}
+	return RangeSupportUnknown
+}
+
+// requestHost returns the host a request to rawURL goes to, resolving relative URLs against
+// Config.BaseURL.
+func (c *Client) requestHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if u.Host == "" && c.baseURL != nil {
+		return c.baseURL.Host
+	}
+	return u.Host
+}
+
+// readResponse records the capabilities announced by a HEAD or Range GET response.
+func (c *Capabilities) readResponse(resp *http.Response) {
+	c.readAllow(resp.Header)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		c.Ranges = RangeSupportBytes
+		c.ContentLength = contentRangeTotal(resp)
+	case strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes"):
+		c.Ranges = RangeSupportBytes
+	case strings.EqualFold(resp.Header.Get("Accept-Ranges"), "none"):
+		c.Ranges = RangeSupportNone
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		c.ContentLength = resp.ContentLength
+	}
+	c.AcceptEncoding = splitHeaderList(resp.Header.Values("Accept-Encoding"))
+	c.Validators = resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+	c.ContentType = resp.Header.Get("Content-Type")
+	c.Protocol = resp.Proto
+	c.Server = resp.Header.Get("Server")
+}
+
+// readAllow adds the methods of the Allow and Access-Control-Allow-Methods headers.
+func (c *Capabilities) readAllow(header http.Header) {
+	for _, name := range []string{"Allow", "Access-Control-Allow-Methods"} {
+		for _, method := range splitHeaderList(header.Values(name)) {
+			method = strings.ToUpper(method)
+			if !slices.Contains(c.AllowedMethods, method) {
+				c.AllowedMethods = append(c.AllowedMethods, method)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbe_CapabilitiesAndCache(t *testing.T) {
+	t.Parallel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Accept-Encoding", "gzip, br")
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Length", "1024")
+			w.Header().Set("Server", "test")
+		case http.MethodOptions:
+			w.Header().Set("Allow", "GET, HEAD")
+			w.Header().Add("Access-Control-Allow-Methods", "put,GET")
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	client := New(Config{Clock: clock, ProbeTTL: time.Minute}, "test-probe")
+	defer client.Close()
+
+	caps, err := client.Probe(context.Background(), server.URL+"/file")
+	require.NoError(t, err)
+	assert.Equal(t, server.Listener.Addr().String(), caps.Host)
+	assert.Equal(t, RangeSupportBytes, caps.Ranges)
+	assert.Equal(t, []string{"GET", "HEAD", "PUT"}, caps.AllowedMethods)
+	assert.True(t, caps.Allows("put"))
+	assert.False(t, caps.Allows(http.MethodDelete))
+	assert.Equal(t, []string{"gzip", "br"}, caps.AcceptEncoding)
+	assert.True(t, caps.Validators)
+	assert.Equal(t, int64(1024), caps.ContentLength)
+	assert.Equal(t, "application/octet-stream", caps.ContentType)
+	assert.Equal(t, "HTTP/1.1", caps.Protocol)
+	assert.Equal(t, "test", caps.Server)
+	assert.Equal(t, int32(2), requests.Load())
+
+	cached, err := client.Probe(context.Background(), server.URL+"/other")
+	require.NoError(t, err)
+	assert.Same(t, caps, cached)
+	assert.Equal(t, int32(2), requests.Load())
+
+	clock.Advance(time.Minute)
+	_, ok := client.CachedCapabilities(caps.Host)
+	assert.False(t, ok)
+	_, err = client.Probe(context.Background(), server.URL+"/file")
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), requests.Load())
+}
+
+func TestProbe_HeadNotAllowed(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Allow", "GET")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case http.MethodGet:
+			assert.Equal(t, "bytes=0-0", r.Header.Get("Range"))
+			http.ServeContent(w, r, "payload.bin", time.Time{}, bytes.NewReader(downloadPayload))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-probe-head")
+	defer client.Close()
+
+	caps, err := client.Probe(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, RangeSupportBytes, caps.Ranges)
+	assert.Equal(t, int64(len(downloadPayload)), caps.ContentLength)
+	assert.Equal(t, []string{"GET"}, caps.AllowedMethods)
+}
+
+func TestProbe_Error(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusNotFound})
+	defer server.Close()
+
+	client := New(Config{}, "test-probe-error")
+	defer client.Close()
+
+	_, err := client.Probe(context.Background(), server.URL)
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	_, ok := client.CachedCapabilities(server.Listener.Addr().String())
+	assert.False(t, ok)
+}
+
+func TestProbe_DownloadWithoutRanges(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Range"))
+		w.Header().Set("Accept-Ranges", "none")
+		_, _ = w.Write(downloadPayload)
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-probe-download")
+	defer client.Close()
+
+	caps, err := client.Probe(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, RangeSupportNone, caps.Ranges)
+
+	_, err = client.Download(context.Background(), server.URL, &bytes.Buffer{}, WithResumeFrom(100))
+	require.ErrorIs(t, err, errRangesUnsupported)
+
+	dst := &memWriterAt{}
+	written, err := client.DownloadMultipart(context.Background(), server.URL, dst, MultipartDownload{PartSize: 1024})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(downloadPayload)), written)
+}
@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// RequestProfile is a named preset of request settings, e.g. "slow-external" or
+// "payment-critical", registered in Config.Profiles and selected per request with WithProfile.
+// Zero fields keep the settings of the client.
+type RequestProfile struct {
+	// Timeout replaces Config.Timeout, see WithRequestTimeout
+	Timeout time.Duration
+
+	// PerTryTimeout replaces Config.PerTryTimeout
+	PerTryTimeout time.Duration
+
+	// Retry enables retries with this configuration, see WithRetryPolicy.
+	// TimeBudget sets a time budget for the retries
+	Retry *RetryConfig
+
+	// DisableRetry disables retries, see WithNoRetry
+	DisableRetry bool
+
+	// Labels are request labels added to the metrics of the request, see WithLabel.
+	// Only keys listed in MetricsLabelsConfig.RequestLabels are recorded
+	Labels map[string]string
+
+	// DisableMetrics skips recording metrics, see WithMetricsDisabled
+	DisableMetrics bool
+}
+
+// WithProfile applies the settings of the named profile of Config.Profiles to this request.
+// Other request options win over the profile regardless of their order, e.g.
+// WithProfile("slow-external") with WithMaxAttempts(5) keeps the retry delays of the profile.
+// Requests with an unknown profile fail without being sent.
+func WithProfile(name string) RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.profile = name
+		})
+	}
+}
+
+// applyProfile merges the profile selected with WithProfile into the request overrides.
+func (c *Client) applyProfile(req *http.Request) (*http.Request, error) {
+	overrides := getRequestOverrides(req.Context())
+	if overrides == nil || overrides.profile == "" {
+		return req, nil
+	}
+	profile, ok := c.config.Profiles[overrides.profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown request profile %q", overrides.profile)
+	}
+
+	merged := *overrides
+	merged.profile = ""
+	if merged.timeout == 0 {
+		merged.timeout = profile.Timeout
+	}
+	if merged.perTryTimeout == 0 {
+		merged.perTryTimeout = profile.PerTryTimeout
+	}
+	if merged.retryConfig == nil {
+		// WithMaxAttempts is applied on top of the retry configuration of the profile
+		merged.retryConfig = profile.Retry
+	}
+	if merged.retryConfig == nil && merged.maxAttempts == 0 {
+		merged.noRetry = merged.noRetry || profile.DisableRetry
+	}
+	if len(profile.Labels) > 0 {
+		labels := maps.Clone(profile.Labels)
+		maps.Copy(labels, merged.labels)
+		merged.labels = labels
+	}
+	merged.noMetrics = merged.noMetrics || profile.DisableMetrics
+	return req.WithContext(context.WithValue(req.Context(), requestOverridesKey{}, &merged)), nil
+}
+
+// validateProfiles returns the problems of the request profiles.
+func validateProfiles(profiles map[string]RequestProfile) []error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		profile := profiles[name]
+		field := fmt.Sprintf("Profiles[%q]", name)
+		if name == "" {
+			errs = append(errs, NewConfigurationError("Profiles", name, "must not have an empty name"))
+		}
+		if profile.Timeout < 0 {
+			errs = append(errs, NewConfigurationError(field+".Timeout", profile.Timeout, "must not be negative"))
+		}
+		if profile.PerTryTimeout < 0 {
+			errs = append(errs, NewConfigurationError(field+".PerTryTimeout", profile.PerTryTimeout, "must not be negative"))
+		}
+		if profile.Retry != nil && profile.DisableRetry {
+			errs = append(errs, NewConfigurationError(field+".DisableRetry", profile.DisableRetry, "conflicts with Retry"))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,143 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testProfiles are the profiles shared by the profile tests.
+var testProfiles = map[string]RequestProfile{
+	"fast-internal": {
+		PerTryTimeout: 50 * time.Millisecond,
+		DisableRetry:  true,
+		Labels:        map[string]string{"profile": "fast-internal"},
+	},
+	"slow-external": {
+		Timeout: 5 * time.Second,
+		Retry:   &RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		Labels:  map[string]string{"profile": "slow-external", "tier": "external"},
+	},
+}
+
+func TestWithProfile_AppliesPreset(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	var seen map[string]string
+	client := New(Config{
+		Profiles:      testProfiles,
+		MetricsLabels: MetricsLabelsConfig{RequestLabels: []string{"profile", "tier"}},
+		Middlewares:   []Middleware{labelsRecorder(&seen)},
+	}, "test-profile-preset")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL,
+		WithProfile("slow-external"), WithLabel("tier", "partner"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, server.GetRequestCount())
+	// Request options win over the profile
+	assert.Equal(t, map[string]string{"profile": "slow-external", "tier": "partner"}, seen)
+}
+
+func TestWithProfile_RequestOptionsWin(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	client := New(Config{Profiles: testProfiles}, "test-profile-options")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL, WithMaxAttempts(1), WithProfile("slow-external"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 1, server.GetRequestCount())
+}
+
+func TestWithProfile_PerTryTimeout(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := New(Config{Profiles: testProfiles}, "test-profile-per-try")
+	defer client.Close()
+
+	start := time.Now()
+	_, err := client.Get(context.Background(), server.URL, WithProfile("fast-internal"))
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestWithProfile_Unknown(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	client := New(Config{Profiles: testProfiles}, "test-profile-unknown")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL, WithProfile("payment-critical"))
+	require.ErrorContains(t, err, `unknown request profile "payment-critical"`)
+	assert.Equal(t, 0, server.GetRequestCount())
+}
+
+func TestValidateProfiles(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, Config{Profiles: testProfiles}.Validate())
+
+	var configErr *ConfigurationError
+	err := Config{Profiles: map[string]RequestProfile{
+		"broken": {Timeout: -time.Second},
+	}}.Validate()
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, `Profiles["broken"].Timeout`, configErr.Field)
+
+	err = Config{Profiles: map[string]RequestProfile{
+		"conflicting": {Retry: &RetryConfig{}, DisableRetry: true},
+	}}.Validate()
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, `Profiles["conflicting"].DisableRetry`, configErr.Field)
+}
+
+func TestWithProfile_MaxAttemptsKeepsProfileRetry(t *testing.T) {
+	t.Parallel()
+	client := New(Config{Profiles: map[string]RequestProfile{
+		"slow": {Retry: &RetryConfig{MaxAttempts: 2, BaseDelay: 7 * time.Second, MaxDelay: time.Minute}},
+	}}, "test-profile-max-attempts")
+	defer client.Close()
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	applyOptions(req, []RequestOption{WithMaxAttempts(5), WithProfile("slow")})
+	req, err = client.applyProfile(req)
+	require.NoError(t, err)
+
+	config := client.httpClient.Transport.(*RoundTripper).requestConfig(req)
+	assert.True(t, config.RetryEnabled)
+	assert.Equal(t, 5, config.RetryConfig.MaxAttempts)
+	assert.Equal(t, 7*time.Second, config.RetryConfig.BaseDelay)
+	assert.Equal(t, time.Minute, config.RetryConfig.MaxDelay)
+}
@@ -0,0 +1,167 @@
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WithProxy sends this request through the specified proxy, overriding Config.ProxyURL,
+// Config.ProxyFunc and Config.NoProxy. An empty proxyURL forces a direct connection.
+// It only takes effect with the transport built by the client (Config.Transport is nil).
+func WithProxy(proxyURL string) RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.proxyURL = proxyURL
+			o.proxySet = true
+		})
+	}
+}
+
+// newProxyFunc builds the http.Transport Proxy function from the configuration.
+// Precedence: WithProxy, then Config.NoProxy bypass, then ProxyFunc, ProxyURL and
+// finally the HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables.
+func newProxyFunc(c Config) func(*http.Request) (*url.URL, error) {
+	selectProxy := http.ProxyFromEnvironment
+	switch {
+	case c.ProxyFunc != nil:
+		selectProxy = c.ProxyFunc
+	case c.ProxyURL != "":
+		proxyURL, err := parseProxyURL(c.ProxyURL)
+		selectProxy = func(*http.Request) (*url.URL, error) {
+			return proxyURL, err
+		}
+	}
+
+	bypass := parseNoProxy(c.NoProxy)
+
+	return func(req *http.Request) (*url.URL, error) {
+		if overrides := getRequestOverrides(req.Context()); overrides != nil && overrides.proxySet {
+			if overrides.proxyURL == "" {
+				return nil, nil
+			}
+			return parseProxyURL(overrides.proxyURL)
+		}
+
+		if bypass.matches(req.URL) {
+			return nil, nil
+		}
+		return selectProxy(req)
+	}
+}
+
+// parseProxyURL parses a proxy address, defaulting to the http scheme like HTTP_PROXY does.
+func parseProxyURL(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		// Addresses like "proxy:3128" have no scheme
+		raw = "http://" + raw
+	}
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", raw, err)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", raw)
+	}
+	return proxyURL, nil
+}
+
+// noProxyRule is a single NO_PROXY entry.
+type noProxyRule struct {
+	domain         string
+	port           string
+	subdomainsOnly bool
+	ip             net.IP
+	network        *net.IPNet
+}
+
+// noProxyList is a parsed NO_PROXY-style bypass list.
+type noProxyList struct {
+	all   bool
+	rules []noProxyRule
+}
+
+// parseNoProxy parses entries like "example.com", ".example.com", "host:8080", "10.0.0.1",
+// "10.0.0.0/8" and "*".
+func parseNoProxy(entries []string) noProxyList {
+	var list noProxyList
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			list.all = true
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			list.rules = append(list.rules, noProxyRule{network: network})
+			continue
+		}
+
+		host, port := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			host, port = h, p
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			list.rules = append(list.rules, noProxyRule{ip: ip, port: port})
+			continue
+		}
+
+		list.rules = append(list.rules, noProxyRule{
+			domain:         strings.TrimPrefix(host, "."),
+			port:           port,
+			subdomainsOnly: strings.HasPrefix(host, "."),
+		})
+	}
+	return list
+}
+
+// matches reports whether the URL should bypass the proxy.
+func (l noProxyList) matches(u *url.URL) bool {
+	if l.all {
+		return true
+	}
+	if len(l.rules) == 0 {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = defaultPort(u.Scheme)
+	}
+	ip := net.ParseIP(host)
+
+	for _, rule := range l.rules {
+		if rule.port != "" && rule.port != port {
+			continue
+		}
+		switch {
+		case rule.network != nil:
+			if ip != nil && rule.network.Contains(ip) {
+				return true
+			}
+		case rule.ip != nil:
+			if ip != nil && rule.ip.Equal(ip) {
+				return true
+			}
+		case strings.HasSuffix(host, "."+rule.domain):
+			return true
+		case !rule.subdomainsOnly && host == rule.domain:
+			return true
+		}
+	}
+	return false
+}
+
+// defaultPort returns the default port for a URL scheme.
+func defaultPort(scheme string) string {
+	if scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
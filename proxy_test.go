@@ -0,0 +1,144 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProxy starts a server that answers proxied requests itself and counts them.
+func newTestProxy(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		w.Header().Set("X-Proxied-Host", r.URL.Host)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, &proxied
+}
+
+func TestProxyURL_RoutesThroughProxy(t *testing.T) {
+	t.Parallel()
+	proxy, proxied := newTestProxy(t)
+
+	client := New(Config{ProxyURL: proxy.URL}, "test-proxy-url")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), "http://upstream.example/path")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "upstream.example", resp.Header.Get("X-Proxied-Host"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(proxied))
+}
+
+func TestNoProxy_BypassesProxy(t *testing.T) {
+	t.Parallel()
+	proxy, proxied := newTestProxy(t)
+	target := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer target.Close()
+
+	client := New(Config{ProxyURL: proxy.URL, NoProxy: []string{"127.0.0.0/8"}}, "test-no-proxy")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), target.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, 1, target.GetRequestCount())
+	assert.Equal(t, int32(0), atomic.LoadInt32(proxied))
+}
+
+func TestWithProxy_PerRequest(t *testing.T) {
+	t.Parallel()
+	proxy, proxied := newTestProxy(t)
+	target := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer target.Close()
+
+	client := New(Config{ProxyURL: proxy.URL}, "test-with-proxy")
+	defer client.Close()
+
+	// An empty proxy forces a direct connection for this request only
+	resp, err := client.Get(context.Background(), target.URL, WithProxy(""))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, target.GetRequestCount())
+	assert.Equal(t, int32(0), atomic.LoadInt32(proxied))
+
+	resp, err = client.Get(context.Background(), target.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(1), atomic.LoadInt32(proxied))
+}
+
+func TestProxyFunc_TakesPrecedence(t *testing.T) {
+	t.Parallel()
+	proxy, proxied := newTestProxy(t)
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	client := New(Config{
+		ProxyURL: "http://unused.invalid:1",
+		ProxyFunc: func(*http.Request) (*url.URL, error) {
+			return proxyURL, nil
+		},
+	}, "test-proxy-func")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), "http://upstream.example/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(1), atomic.LoadInt32(proxied))
+}
+
+func TestParseNoProxy(t *testing.T) {
+	t.Parallel()
+	list := parseNoProxy([]string{"example.com", ".internal", "api.test:8443", "10.0.0.1", "192.168.0.0/16"})
+
+	tests := []struct {
+		url    string
+		bypass bool
+	}{
+		{"http://example.com", true},
+		{"https://sub.example.com", true},
+		{"http://notexample.com", false},
+		{"http://svc.internal", true},
+		{"http://internal", false},
+		{"https://api.test:8443", true},
+		{"https://api.test", false},
+		{"http://10.0.0.1:9000", true},
+		{"http://192.168.4.20", true},
+		{"http://172.16.0.1", false},
+	}
+	for _, tc := range tests {
+		u, err := url.Parse(tc.url)
+		require.NoError(t, err)
+		assert.Equal(t, tc.bypass, list.matches(u), tc.url)
+	}
+
+	all, _ := url.Parse("http://anything")
+	assert.True(t, parseNoProxy([]string{"*"}).matches(all))
+	assert.False(t, parseNoProxy(nil).matches(all))
+}
+
+func TestParseProxyURL(t *testing.T) {
+	t.Parallel()
+	u, err := parseProxyURL("proxy.local:3128")
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.local:3128", u.String())
+
+	u, err = parseProxyURL("socks5://proxy.local:1080")
+	require.NoError(t, err)
+	assert.Equal(t, "socks5", u.Scheme)
+
+	_, err = parseProxyURL("http://[::1")
+	assert.Error(t, err)
+}
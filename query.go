@@ -0,0 +1,219 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithQueryParam sets a single URL query parameter, replacing existing values with the same key.
+func WithQueryParam(key, value string) RequestOption {
+	return func(req *http.Request) {
+		query := req.URL.Query()
+		query.Set(key, value)
+		req.URL.RawQuery = query.Encode()
+		addQueryParamNames(req, key)
+	}
+}
+
+// WithQueryParams sets multiple URL query parameters.
+func WithQueryParams(params map[string]string) RequestOption {
+	return func(req *http.Request) {
+		query := req.URL.Query()
+		names := make([]string, 0, len(params))
+		for key, value := range params {
+			query.Set(key, value)
+			names = append(names, key)
+		}
+		req.URL.RawQuery = query.Encode()
+		addQueryParamNames(req, names...)
+	}
+}
+
+// WithQueryStruct encodes the exported fields of a struct (or pointer to struct) into URL query
+// parameters. Field names come from the `url` tag: `url:"name"`, `url:"name,omitempty"` or `url:"-"`
+// to skip the field. Untagged fields use the field name. Slices produce repeated parameters and
+// time.Time values are formatted as RFC 3339.
+func WithQueryStruct(v interface{}) RequestOption {
+	return func(req *http.Request) {
+		values, err := encodeQueryStruct(v)
+		if err != nil {
+			// Same approach as WithJSONBody: keep the request and expose the error for debugging
+			req.Header.Set("X-Query-Encode-Error", err.Error())
+			return
+		}
+
+		query := req.URL.Query()
+		names := make([]string, 0, len(values))
+		for key, vals := range values {
+			query[key] = vals
+			names = append(names, key)
+		}
+		req.URL.RawQuery = query.Encode()
+		addQueryParamNames(req, names...)
+	}
+}
+
+// queryParamsKey is the context key for the names of the query parameters set by request options.
+type queryParamsKey struct{}
+
+// addQueryParamNames records the names of query parameters set by a request option.
+func addQueryParamNames(req *http.Request, names ...string) {
+	existing := QueryParamsFromContext(req.Context())
+	merged := slices.Clone(existing)
+	for _, name := range names {
+		if !slices.Contains(merged, name) {
+			merged = append(merged, name)
+		}
+	}
+	slices.Sort(merged)
+	*req = *req.WithContext(context.WithValue(req.Context(), queryParamsKey{}, merged))
+}
+
+// QueryParamsFromContext returns the sorted names of the query parameters set by WithQueryParam,
+// WithQueryParams or WithQueryStruct on the request with the context. Like path templates, they
+// tell which parts of the URL vary between requests, e.g. for contract matchers.
+func QueryParamsFromContext(ctx context.Context) []string {
+	names, _ := ctx.Value(queryParamsKey{}).([]string)
+	return names
+}
+
+// encodeQueryStruct converts a struct into url.Values using `url` field tags.
+func encodeQueryStruct(v interface{}) (url.Values, error) {
+	values := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("query struct: expected struct, got %T", v)
+	}
+
+	if err := encodeQueryFields(values, rv); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// encodeQueryFields adds the fields of a struct value to values, flattening embedded structs.
+func encodeQueryFields(values url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		omitEmpty := opts == "omitempty"
+
+		fv := rv.Field(i)
+		if field.Anonymous && name == "" && indirectKind(fv) == reflect.Struct && !isTimeValue(fv) {
+			if fv.Kind() == reflect.Ptr && fv.IsNil() {
+				continue
+			}
+			if err := encodeQueryFields(values, reflect.Indirect(fv)); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		if err := addQueryValue(values, name, fv); err != nil {
+			return fmt.Errorf("query struct field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// addQueryValue formats a field value and adds it under name.
+func addQueryValue(values url.Values, name string, fv reflect.Value) error {
+	for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	if fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array {
+		for i := 0; i < fv.Len(); i++ {
+			if err := addQueryValue(values, name, fv.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s, err := formatQueryValue(fv)
+	if err != nil {
+		return err
+	}
+	values.Add(name, s)
+	return nil
+}
+
+// formatQueryValue formats a scalar value for a query string.
+func formatQueryValue(fv reflect.Value) (string, error) {
+	if isTimeValue(fv) {
+		return fv.Interface().(time.Time).Format(time.RFC3339), nil
+	}
+	if stringer, ok := fv.Interface().(fmt.Stringer); ok {
+		return stringer.String(), nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", fv.Type())
+	}
+}
+
+// indirectKind returns the kind of the value, looking through a pointer type.
+func indirectKind(fv reflect.Value) reflect.Kind {
+	if fv.Kind() == reflect.Ptr {
+		return fv.Type().Elem().Kind()
+	}
+	return fv.Kind()
+}
+
+// isTimeValue reports whether the value is a time.Time or a pointer to it.
+func isTimeValue(fv reflect.Value) bool {
+	t := fv.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == reflect.TypeOf(time.Time{})
+}
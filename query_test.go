@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQueryParam_EscapesValues(t *testing.T) {
+	t.Parallel()
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/search?page=1", nil)
+	require.NoError(t, err)
+
+	applyOptions(req, []RequestOption{
+		WithQueryParam("q", "a&b=c d"),
+		WithQueryParams(map[string]string{"page": "2", "lang": "ru"}),
+	})
+
+	assert.Equal(t, "a&b=c d", req.URL.Query().Get("q"))
+	assert.Equal(t, "2", req.URL.Query().Get("page"))
+	assert.Equal(t, "ru", req.URL.Query().Get("lang"))
+	assert.Equal(t, "lang=ru&page=2&q=a%26b%3Dc+d", req.URL.RawQuery)
+}
+
+type queryFilter struct {
+	Limit int `url:"limit"`
+}
+
+type querySearch struct {
+	queryFilter
+	Query   string    `url:"q"`
+	Tags    []string  `url:"tag"`
+	Active  *bool     `url:"active,omitempty"`
+	Since   time.Time `url:"since,omitempty"`
+	Score   float64   `url:"score,omitempty"`
+	Secret  string    `url:"-"`
+	Plain   string
+	private string
+}
+
+func TestEncodeQueryStruct(t *testing.T) {
+	t.Parallel()
+	active := false
+	values, err := encodeQueryStruct(&querySearch{
+		queryFilter: queryFilter{Limit: 10},
+		Query:       "go http",
+		Tags:        []string{"a", "b"},
+		Active:      &active,
+		Since:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Secret:      "hidden",
+		Plain:       "value",
+		private:     "ignored",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "10", values.Get("limit"))
+	assert.Equal(t, "go http", values.Get("q"))
+	assert.Equal(t, []string{"a", "b"}, values["tag"])
+	assert.Equal(t, "false", values.Get("active"))
+	assert.Equal(t, "2024-01-02T03:04:05Z", values.Get("since"))
+	assert.Equal(t, "value", values.Get("Plain"))
+	assert.NotContains(t, values, "score")
+	assert.NotContains(t, values, "Secret")
+	assert.NotContains(t, values, "private")
+}
+
+func TestEncodeQueryStruct_RejectsNonStruct(t *testing.T) {
+	t.Parallel()
+	_, err := encodeQueryStruct("not a struct")
+	assert.Error(t, err)
+
+	_, err = encodeQueryStruct(struct {
+		Nested map[string]string `url:"nested"`
+	}{Nested: map[string]string{"a": "b"}})
+	assert.Error(t, err)
+}
+
+func TestWithQueryStruct_SendsQuery(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	client := New(Config{}, "test-query-struct")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL+"/items", WithQueryStruct(querySearch{
+		Query: "x/y",
+		Tags:  []string{"one"},
+	}))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, 1, server.GetRequestCount())
+	assert.Equal(t, "/items?Plain=&limit=0&q=x%2Fy&tag=one", server.RequestLog[0].URL)
+}
+
+func TestQueryParamsFromContext(t *testing.T) {
+	t.Parallel()
+	req, err := http.NewRequest(http.MethodGet, "https://api.example.com/search?q=go", nil)
+	require.NoError(t, err)
+	assert.Nil(t, QueryParamsFromContext(req.Context()))
+
+	applyOptions(req, []RequestOption{
+		WithQueryParam("page", "2"),
+		WithQueryParams(map[string]string{"limit": "10", "page": "3"}),
+		WithQueryStruct(struct {
+			Sort string `url:"sort"`
+		}{Sort: "name"}),
+	})
+	assert.Equal(t, []string{"limit", "page", "sort"}, QueryParamsFromContext(req.Context()))
+	assert.Equal(t, "limit=10&page=3&q=go&sort=name", req.URL.RawQuery)
+}
@@ -0,0 +1,112 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// errRaceLost is the cancellation cause of requests that lost to the first success of
+// Client.FirstSuccess. Their attempts are recorded with the "cancelled" status label.
+var errRaceLost = errors.New("cancelled: another request succeeded first")
+
+// cancelledStatusLabel is the status label of attempts cancelled after losing a race.
+const cancelledStatusLabel = "cancelled"
+
+// raceResult is the outcome of a single request of FirstSuccess.
+type raceResult struct {
+	index int
+	resp  *http.Response
+	err   error
+}
+
+// Race sends GET requests to the URLs concurrently and returns the first successful
+// response, cancelling the others, e.g. to pick the fastest of several mirrors.
+// See FirstSuccess.
+func (c *Client) Race(ctx context.Context, urls ...string) (*http.Response, error) {
+	reqs := make([]*http.Request, 0, len(urls))
+	for _, url := range urls {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	return c.FirstSuccess(ctx, reqs...)
+}
+
+// FirstSuccess sends the requests concurrently and returns the first response with a status
+// below 400. The other requests are cancelled and their responses closed; their attempts are
+// recorded in metrics with the "cancelled" status and without error. When no request
+// succeeds, the error joins the error of every request in order, with *HTTPError for
+// unsuccessful responses. Requests run in the context ctx, replacing their own.
+func (c *Client) FirstSuccess(ctx context.Context, reqs ...*http.Request) (*http.Response, error) {
+	if len(reqs) == 0 {
+		return nil, errors.New("no requests to send")
+	}
+
+	results := make(chan raceResult, len(reqs))
+	cancels := make([]context.CancelCauseFunc, len(reqs))
+	for i, req := range reqs {
+		raceCtx, cancel := context.WithCancelCause(ctx)
+		cancels[i] = cancel
+		go func() {
+			resp, err := c.do(req.WithContext(raceCtx))
+			results <- raceResult{index: i, resp: resp, err: err}
+		}()
+	}
+
+	errs := make([]error, len(reqs))
+	for received := 1; received <= len(reqs); received++ {
+		res := <-results
+		if res.err == nil && res.resp.StatusCode < http.StatusBadRequest {
+			for i, cancel := range cancels {
+				if i != res.index {
+					cancel(errRaceLost)
+				}
+			}
+			go discardRaceResults(results, len(reqs)-received)
+			return finishRaceResult(res.resp, cancels[res.index]), nil
+		}
+
+		if res.err != nil {
+			cancels[res.index](nil)
+			errs[res.index] = res.err
+			continue
+		}
+		req := reqs[res.index]
+		if res.resp.Request != nil {
+			// The sent request has the URL resolved against Config.BaseURL
+			req = res.resp.Request
+		}
+		httpErr := NewHTTPError(res.resp, req)
+		httpErr.Body, _ = io.ReadAll(io.LimitReader(res.resp.Body, maxErrorBodyBytes))
+		drainAndClose(res.resp.Body)
+		cancels[res.index](nil)
+		errs[res.index] = httpErr
+	}
+	return nil, errors.Join(errs...)
+}
+
+// finishRaceResult ties the winner's context cancellation to closing its response body.
+func finishRaceResult(resp *http.Response, cancel context.CancelCauseFunc) *http.Response {
+	if resp.Body == nil {
+		cancel(nil)
+		return resp
+	}
+	resp.Body = &contextAwareBody{ReadCloser: resp.Body, cancel: func() { cancel(nil) }}
+	return resp
+}
+
+// discardRaceResults collects the cancelled requests, releasing their connections.
+func discardRaceResults(results <-chan raceResult, pending int) {
+	for range pending {
+		closeResponseBody((<-results).resp)
+	}
+}
+
+// lostRace reports whether the request was cancelled after losing to another request.
+func lostRace(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errRaceLost)
+}
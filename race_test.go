@@ -0,0 +1,89 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMirrorServer answers with its name after delay, or blocks until the request is cancelled.
+func newMirrorServer(t *testing.T, name string, status int, delay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(name))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRace_ReturnsFirstSuccess(t *testing.T) {
+	t.Parallel()
+	slow := newMirrorServer(t, "slow", http.StatusOK, time.Minute)
+	failing := newMirrorServer(t, "failing", http.StatusServiceUnavailable, 0)
+	fast := newMirrorServer(t, "fast", http.StatusOK, 20*time.Millisecond)
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+	}, "test-race")
+	defer client.Close()
+
+	resp, err := client.Race(context.Background(), slow.URL, failing.URL, fast.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "fast", string(body))
+
+	// The cancelled request is labelled as such instead of counting as an error
+	cancelled := map[string]string{"client_name": "test-race", "status": cancelledStatusLabel, "error": "false"}
+	require.Eventually(t, func() bool {
+		return circuitBreakerMetric(t, reg, MetricRequestsTotal, cancelled) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, MetricRequestsTotal,
+		map[string]string{"client_name": "test-race", "status": "503", "error": "false"}))
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, MetricRequestsTotal,
+		map[string]string{"client_name": "test-race", "status": "200"}))
+}
+
+func TestFirstSuccess_AggregatesErrors(t *testing.T) {
+	t.Parallel()
+	missing := newMirrorServer(t, "missing", http.StatusNotFound, 0)
+	failing := newMirrorServer(t, "failing", http.StatusBadGateway, 10*time.Millisecond)
+
+	client := New(Config{}, "test-first-success")
+	defer client.Close()
+
+	first, err := http.NewRequest(http.MethodGet, missing.URL, nil)
+	require.NoError(t, err)
+	second, err := http.NewRequest(http.MethodPost, failing.URL, strings.NewReader("payload"))
+	require.NoError(t, err)
+
+	_, err = client.FirstSuccess(context.Background(), first, second)
+	require.Error(t, err)
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+	assert.Equal(t, "missing", string(httpErr.Body))
+	assert.Contains(t, err.Error(), "502")
+
+	_, err = client.FirstSuccess(context.Background())
+	assert.Error(t, err)
+	_, err = client.Race(context.Background(), "http://[::1]:namedport")
+	assert.Error(t, err)
+}
@@ -21,11 +21,18 @@ type TokenBucketLimiter struct {
 	capacity int        // maximum bucket capacity
 	tokens   float64    // current number of tokens
 	lastTime time.Time  // last update time
+	clock    Clock      // source of time and timers
 	mu       sync.Mutex // concurrent access protection
 }
 
 // NewTokenBucketLimiter creates a new rate limiter with the specified parameters.
 func NewTokenBucketLimiter(rate float64, capacity int) *TokenBucketLimiter {
+	return NewTokenBucketLimiterWithClock(rate, capacity, nil)
+}
+
+// NewTokenBucketLimiterWithClock creates a new rate limiter that uses clock (nil for real time).
+func NewTokenBucketLimiterWithClock(rate float64, capacity int, clock Clock) *TokenBucketLimiter {
+	clock = clockOrDefault(clock)
 	if rate <= 0 {
 		panic("rate must be positive")
 	}
@@ -37,7 +44,8 @@ func NewTokenBucketLimiter(rate float64, capacity int) *TokenBucketLimiter {
 		rate:     rate,
 		capacity: capacity,
 		tokens:   float64(capacity), // start with full bucket
-		lastTime: time.Now(),
+		lastTime: clock.Now(),
+		clock:    clock,
 	}
 }
 
@@ -70,13 +78,13 @@ func (tb *TokenBucketLimiter) Wait(ctx context.Context) error {
 
 		// Calculate wait time to get next token
 		deficit := 1.0 - tb.tokens
-		waitTime := time.Duration(deficit/tb.rate) * time.Second
+		waitTime := time.Duration(deficit / tb.rate * float64(time.Second))
 		tb.mu.Unlock()
 
 		// Wait either until token appears or context is cancelled
-		timer := time.NewTimer(waitTime)
+		timer := tb.clock.NewTimer(waitTime)
 		select {
-		case <-timer.C:
+		case <-timer.C():
 			timer.Stop()
 			// Repeat check (return to start of loop)
 			continue
@@ -90,7 +98,7 @@ func (tb *TokenBucketLimiter) Wait(ctx context.Context) error {
 // refill refills the bucket with tokens based on elapsed time.
 // must be called under mutex lock.
 func (tb *TokenBucketLimiter) refill() {
-	now := time.Now()
+	now := tb.clock.Now()
 	elapsed := now.Sub(tb.lastTime).Seconds()
 	tb.lastTime = now
 
@@ -99,6 +107,14 @@ func (tb *TokenBucketLimiter) refill() {
 	tb.tokens = minFloat64(tb.tokens+tokensToAdd, float64(tb.capacity))
 }
 
+// setRate changes the rate of the bucket, keeping the tokens gathered at the previous rate.
+func (tb *TokenBucketLimiter) setRate(rate float64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	tb.rate = rate
+}
+
 // minFloat64 returns the minimum of two float64 values.
 func minFloat64(a, b float64) float64 {
 	if a < b {
@@ -1,33 +1,188 @@
 package httpclient
 
 import (
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 )
 
 // RateLimiterRoundTripper is a wrapper for RoundTripper with rate limiting.
 type RateLimiterRoundTripper struct {
-	base    http.RoundTripper
-	config  RateLimiterConfig
-	limiter RateLimiter // global limiter
+	base      http.RoundTripper
+	config    RateLimiterConfig
+	limiter   RateLimiter       // global limiter
+	endpoints []endpointLimiter // per-endpoint limiters, most specific first
+	throttle  *hostThrottle     // nil unless adaptive or preemptive throttling is enabled
+	// onWait is called after a request waited for a token
+	onWait func(req *http.Request, waited time.Duration)
+}
+
+// endpointLimiter is the limiter of requests matching an endpoint pattern.
+type endpointLimiter struct {
+	host    string // exact host, "*.domain" or empty for any host
+	path    string // path prefix or empty for any path
+	limiter RateLimiter
 }
 
 // NewRateLimiterRoundTripper creates a new RoundTripper with rate limiting.
 func NewRateLimiterRoundTripper(base http.RoundTripper, config RateLimiterConfig) *RateLimiterRoundTripper {
 	config = config.withDefaults()
-	return &RateLimiterRoundTripper{
-		base:    base,
-		config:  config,
-		limiter: NewTokenBucketLimiter(config.RequestsPerSecond, config.BurstCapacity),
+	rt := &RateLimiterRoundTripper{
+		base:      base,
+		config:    config,
+		limiter:   NewTokenBucketLimiterWithClock(config.RequestsPerSecond, config.BurstCapacity, config.Clock),
+		endpoints: newEndpointLimiters(config.Endpoints, config.Clock),
+	}
+	if config.AdaptiveThrottling || config.PreemptiveThrottling {
+		rt.throttle = newHostThrottle(config)
 	}
+	return rt
 }
 
 // RoundTrip executes an HTTP request with rate limiting.
 func (rt *RateLimiterRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := getHost(req.URL)
+
+	// Wait until the host is no longer throttled after 429 responses.
+	if rt.throttle != nil {
+		if err := rt.throttle.wait(req.Context(), host); err != nil {
+			return nil, classifyRequestError(req, err, ErrRateLimited)
+		}
+	}
+
 	// Wait for token availability.
-	if err := rt.limiter.Wait(req.Context()); err != nil {
-		return nil, err
+	if limiter := rt.limiterFor(req); limiter != nil && !limiter.Allow() {
+		clock := clockOrDefault(rt.config.Clock)
+		start := clock.Now()
+		if err := limiter.Wait(req.Context()); err != nil {
+			return nil, classifyRequestError(req, err, ErrRateLimited)
+		}
+		if rt.onWait != nil {
+			rt.onWait(req, clock.Now().Sub(start))
+		}
 	}
 
 	// Execute request through base RoundTripper.
-	return rt.base.RoundTrip(req)
+	resp, err := rt.base.RoundTrip(req)
+	if rt.throttle != nil && err == nil {
+		rt.throttle.observe(host, resp)
+	}
+	return resp, err
+}
+
+// CloseIdleConnections closes idle connections of the base transport.
+func (rt *RateLimiterRoundTripper) CloseIdleConnections() {
+	closeIdleConnections(rt.base)
+}
+
+// ThrottleState returns the hosts currently throttled after 429 responses or exhausted quotas.
+// It is empty unless RateLimiterConfig.AdaptiveThrottling or PreemptiveThrottling is enabled.
+func (rt *RateLimiterRoundTripper) ThrottleState() []ThrottleState {
+	if rt.throttle == nil {
+		return nil
+	}
+	return rt.throttle.states()
+}
+
+// limiterFor returns the limiter of the most specific matching endpoint, or the global one,
+// shared per host when RateLimiterConfig.Registry is set. A nil limiter means the request is
+// not limited.
+func (rt *RateLimiterRoundTripper) limiterFor(req *http.Request) RateLimiter {
+	for _, endpoint := range rt.endpoints {
+		if endpoint.matches(req) {
+			return endpoint.limiter
+		}
+	}
+	if rt.config.Registry != nil {
+		fallback := EndpointRateLimit{RequestsPerSecond: rt.config.RequestsPerSecond, BurstCapacity: rt.config.BurstCapacity}
+		return rt.config.Registry.limiter(getHost(req.URL), fallback, rt.config.Clock)
+	}
+	return rt.limiter
+}
+
+// newEndpointLimiters creates limiters for endpoint patterns, ordered from the most specific.
+// Patterns with a non-positive rate are exempt from rate limiting.
+func newEndpointLimiters(endpoints map[string]EndpointRateLimit, clock Clock) []endpointLimiter {
+	limiters := make([]endpointLimiter, 0, len(endpoints))
+	for pattern, limit := range endpoints {
+		host, path := parseEndpointPattern(pattern)
+		endpoint := endpointLimiter{host: host, path: path}
+		if limit.RequestsPerSecond > 0 {
+			limit = limit.withDefaults()
+			endpoint.limiter = NewTokenBucketLimiterWithClock(limit.RequestsPerSecond, limit.BurstCapacity, clock)
+		}
+		limiters = append(limiters, endpoint)
+	}
+
+	sort.Slice(limiters, func(i, j int) bool {
+		a, b := limiters[i], limiters[j]
+		if len(a.path) != len(b.path) {
+			return len(a.path) > len(b.path)
+		}
+		if a.hostRank() != b.hostRank() {
+			return a.hostRank() > b.hostRank()
+		}
+		return len(a.host) > len(b.host)
+	})
+	return limiters
+}
+
+// parseEndpointPattern splits "host/path" into a lowercase host and a path prefix without trailing slash.
+func parseEndpointPattern(pattern string) (host, path string) {
+	pattern = strings.TrimSpace(pattern)
+	if i := strings.Index(pattern, "/"); i >= 0 {
+		host, path = pattern[:i], pattern[i:]
+	} else {
+		host = pattern
+	}
+	return strings.ToLower(host), strings.TrimSuffix(path, "/")
+}
+
+// hostRank orders host patterns: exact hosts, then wildcard hosts, then any host.
+func (e endpointLimiter) hostRank() int {
+	switch {
+	case e.host == "":
+		return 0
+	case strings.HasPrefix(e.host, "*."):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matches reports whether the request URL matches the endpoint pattern.
+func (e endpointLimiter) matches(req *http.Request) bool {
+	if e.host != "" && !matchEndpointHost(e.host, req.URL) {
+		return false
+	}
+	if e.path == "" {
+		return true
+	}
+	path := req.URL.Path
+	return path == e.path || strings.HasPrefix(path, e.path+"/")
+}
+
+// matchEndpointHost matches a host pattern with optional port and "*." prefix against the request URL.
+// Patterns without a port match any port.
+func matchEndpointHost(pattern string, u *url.URL) bool {
+	if patternHost, port, err := net.SplitHostPort(pattern); err == nil {
+		urlPort := u.Port()
+		if urlPort == "" {
+			urlPort = defaultPort(u.Scheme)
+		}
+		if port != urlPort {
+			return false
+		}
+		pattern = patternHost
+	}
+	pattern = strings.Trim(pattern, "[]")
+
+	host := strings.ToLower(u.Hostname())
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+domain)
+	}
+	return host == pattern
 }
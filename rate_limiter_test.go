@@ -399,3 +399,68 @@ func TestTokenBucketLimiter_CapacityLimit(t *testing.T) {
 
 	assert.Equal(t, 3, count, "should have exactly 3 tokens after long wait")
 }
+
+// TC021: Requests use the bucket of the most specific endpoint pattern
+func TestRateLimiterRoundTripper_EndpointSelection(t *testing.T) {
+	transport := NewRateLimiterRoundTripper(http.DefaultTransport, RateLimiterConfig{
+		Endpoints: map[string]EndpointRateLimit{
+			"api.example.com/v1/search": {RequestsPerSecond: 5},
+			"api.example.com":           {RequestsPerSecond: 50},
+			"*.example.com":             {RequestsPerSecond: 100},
+			"/health":                   {}, // exempt
+			"internal.local:8443":       {RequestsPerSecond: 1},
+		},
+	})
+
+	limiterFor := func(rawURL string) RateLimiter {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		return transport.limiterFor(req)
+	}
+	rate := func(l RateLimiter) float64 {
+		require.NotNil(t, l)
+		return l.(*TokenBucketLimiter).rate
+	}
+
+	assert.Equal(t, 5.0, rate(limiterFor("https://api.example.com/v1/search?q=go")))
+	assert.Equal(t, 5.0, rate(limiterFor("https://API.example.com:8080/v1/search/suggest")))
+	assert.Equal(t, 50.0, rate(limiterFor("https://api.example.com/v1/searching")))
+	assert.Equal(t, 100.0, rate(limiterFor("https://cdn.example.com/")))
+	assert.Equal(t, 1.0, rate(limiterFor("https://internal.local:8443/")))
+	assert.Equal(t, 10.0, rate(limiterFor("https://internal.local/"))) // global
+	assert.Equal(t, 10.0, rate(limiterFor("https://example.com/")))    // global
+	assert.Nil(t, limiterFor("https://api.example.com/health"))        // exempt
+	assert.Nil(t, limiterFor("http://other.host/health/live"))         // exempt
+}
+
+// TC022: Endpoint buckets are independent of each other
+func TestRateLimiterRoundTripper_EndpointBuckets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		RateLimiterEnabled: true,
+		RateLimiterConfig: RateLimiterConfig{
+			RequestsPerSecond: 100,
+			Endpoints: map[string]EndpointRateLimit{
+				"/search": {RequestsPerSecond: 2, BurstCapacity: 1},
+			},
+		},
+	}, "test-endpoint-rate-limit")
+	defer client.Close()
+
+	get := func(path string) time.Duration {
+		start := time.Now()
+		resp, err := client.Get(context.Background(), server.URL+path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return time.Since(start)
+	}
+
+	get("/search")
+	// Other endpoints are not slowed down by the exhausted search bucket
+	assert.Less(t, get("/users"), 200*time.Millisecond)
+	assert.GreaterOrEqual(t, get("/search"), 300*time.Millisecond)
+}
@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minRateLimitResetEpoch separates delta-seconds from Unix times in RateLimit-Reset values:
+// larger values (after September 2001) are Unix times, as sent in X-RateLimit-Reset by GitHub.
+const minRateLimitResetEpoch = 1_000_000_000
+
+// RateLimitInfo is the request quota announced by the RateLimit-Limit, RateLimit-Remaining
+// and RateLimit-Reset response headers or their X-RateLimit-* variants.
+type RateLimitInfo struct {
+	// Limit is the number of requests allowed in the window, -1 when not announced
+	Limit int64
+	// Remaining is the number of requests left in the window, -1 when not announced
+	Remaining int64
+	// Reset is the time until the quota resets, zero when not announced
+	Reset time.Duration
+}
+
+// RateLimitFromResponse returns the quota announced by the response headers. It reports
+// false when the response has none of them.
+func RateLimitFromResponse(resp *http.Response) (RateLimitInfo, bool) {
+	if resp == nil {
+		return RateLimitInfo{}, false
+	}
+	return parseRateLimit(resp.Header, time.Now())
+}
+
+// RateLimit returns the quota announced by the response headers, see RateLimitFromResponse.
+func (r *Response) RateLimit() (RateLimitInfo, bool) {
+	return RateLimitFromResponse(r.Response)
+}
+
+// parseRateLimit parses the RateLimit-* headers, falling back to X-RateLimit-*.
+// Reset is given in seconds or, for large values, as a Unix time relative to now.
+func parseRateLimit(header http.Header, now time.Time) (RateLimitInfo, bool) {
+	info := RateLimitInfo{Limit: -1, Remaining: -1}
+	found := false
+	if limit, ok := rateLimitHeader(header, "RateLimit-Limit", "X-RateLimit-Limit"); ok {
+		info.Limit, found = limit, true
+	}
+	if remaining, ok := rateLimitHeader(header, "RateLimit-Remaining", "X-RateLimit-Remaining"); ok {
+		info.Remaining, found = remaining, true
+	}
+	if reset, ok := rateLimitHeader(header, "RateLimit-Reset", "X-RateLimit-Reset"); ok {
+		found = true
+		if reset >= minRateLimitResetEpoch {
+			info.Reset = max(time.Unix(reset, 0).Sub(now), 0)
+		} else {
+			info.Reset = time.Duration(reset) * time.Second
+		}
+	}
+	return info, found
+}
+
+// rateLimitHeader returns the leading non-negative integer of the first present header.
+// Quota policies following the number, e.g. "100, 100;w=60", are ignored.
+func rateLimitHeader(header http.Header, keys ...string) (int64, bool) {
+	for _, key := range keys {
+		value := header.Get(key)
+		if value == "" {
+			continue
+		}
+		if i := strings.IndexAny(value, ",;"); i >= 0 {
+			value = value[:i]
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// throttle returns the period until the reset and the rate spreading the remaining
+// requests over it. A zero rate means requests wait until the reset.
+func (i RateLimitInfo) throttle() (time.Duration, float64) {
+	if i.Reset <= 0 {
+		return 0, 0
+	}
+	if i.Remaining > 0 {
+		return i.Reset, float64(i.Remaining) / i.Reset.Seconds()
+	}
+	return i.Reset, 0
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    RateLimitInfo
+		found   bool
+	}{
+		{"none", nil, RateLimitInfo{Limit: -1, Remaining: -1}, false},
+		{
+			"draft headers",
+			map[string]string{"RateLimit-Limit": "100", "RateLimit-Remaining": "42", "RateLimit-Reset": "30"},
+			RateLimitInfo{Limit: 100, Remaining: 42, Reset: 30 * time.Second},
+			true,
+		},
+		{
+			"quota policy",
+			map[string]string{"RateLimit-Limit": "100, 100;w=60, 1000;w=3600", "RateLimit-Remaining": "7"},
+			RateLimitInfo{Limit: 100, Remaining: 7},
+			true,
+		},
+		{
+			"github epoch reset",
+			map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000090"},
+			RateLimitInfo{Limit: 5000, Remaining: 0, Reset: 90 * time.Second},
+			true,
+		},
+		{
+			"epoch reset in the past",
+			map[string]string{"X-RateLimit-Reset": "1699999000"},
+			RateLimitInfo{Limit: -1, Remaining: -1},
+			true,
+		},
+		{
+			"draft headers win",
+			map[string]string{"RateLimit-Remaining": "3", "X-RateLimit-Remaining": "9"},
+			RateLimitInfo{Limit: -1, Remaining: 3},
+			true,
+		},
+		{"invalid", map[string]string{"RateLimit-Remaining": "-1", "RateLimit-Reset": "soon"}, RateLimitInfo{Limit: -1, Remaining: -1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+			got, found := parseRateLimit(header, now)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResponse_RateLimit(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"X-RateLimit-Limit":     "60",
+			"X-RateLimit-Remaining": "59",
+			"X-RateLimit-Reset":     "60",
+		},
+	})
+	defer server.Close()
+
+	client := New(Config{}, "test-response-rate-limit")
+	defer client.Close()
+
+	resp, err := client.Execute(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	defer resp.Close()
+
+	info, ok := resp.RateLimit()
+	require.True(t, ok)
+	assert.Equal(t, RateLimitInfo{Limit: 60, Remaining: 59, Reset: time.Minute}, info)
+
+	_, ok = RateLimitFromResponse(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxRedirects matches the net/http default redirect limit.
+const defaultMaxRedirects = 10
+
+// RedirectAuthPolicy controls credentials on redirects to another origin.
+type RedirectAuthPolicy int
+
+const (
+	// RedirectAuthDefault keeps the net/http behavior: credentials are forwarded to the same
+	// host and its subdomains, regardless of scheme and port.
+	RedirectAuthDefault RedirectAuthPolicy = iota
+	// RedirectAuthStrip removes credentials whenever the scheme, host or port changes.
+	RedirectAuthStrip
+	// RedirectAuthPreserve forwards credentials to every redirect target.
+	RedirectAuthPreserve
+)
+
+// redirectAuthHeaders are the credential headers handled by RedirectAuthPolicy.
+var redirectAuthHeaders = []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"}
+
+// WithNoFollowRedirects returns redirect responses to the caller instead of following them.
+func WithNoFollowRedirects() RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.noFollowRedirects = true
+		})
+	}
+}
+
+// checkRedirect implements http.Client.CheckRedirect using the client configuration.
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if overrides := getRequestOverrides(req.Context()); overrides != nil && overrides.noFollowRedirects {
+		return http.ErrUseLastResponse
+	}
+	if c.config.MaxRedirects < 0 {
+		return http.ErrUseLastResponse
+	}
+	if len(via) > c.config.MaxRedirects {
+		return fmt.Errorf("stopped after %d redirects", c.config.MaxRedirects)
+	}
+
+	applyRedirectAuthPolicy(req, via[0], c.config.RedirectAuthPolicy)
+
+	if c.config.RedirectPolicy != nil {
+		if err := c.config.RedirectPolicy(req, via); err != nil {
+			return err
+		}
+	}
+
+	prev := via[len(via)-1]
+	status := "0"
+	if req.Response != nil {
+		status = strconv.Itoa(req.Response.StatusCode)
+	}
+	c.metrics.RecordRedirect(req.Context(), prev.Method, getHost(prev.URL), status)
+	return nil
+}
+
+// applyRedirectAuthPolicy adjusts the credentials that net/http copied from the initial request.
+func applyRedirectAuthPolicy(req, initial *http.Request, policy RedirectAuthPolicy) {
+	switch policy {
+	case RedirectAuthStrip:
+		if sameOrigin(req, initial) {
+			return
+		}
+		for _, name := range redirectAuthHeaders {
+			req.Header.Del(name)
+		}
+	case RedirectAuthPreserve:
+		for _, name := range redirectAuthHeaders {
+			if values := initial.Header.Values(name); len(values) > 0 && req.Header.Get(name) == "" {
+				req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+			}
+		}
+	}
+}
+
+// sameOrigin reports whether both requests have the same scheme, host and port.
+func sameOrigin(a, b *http.Request) bool {
+	return strings.EqualFold(a.URL.Scheme, b.URL.Scheme) &&
+		strings.EqualFold(a.URL.Hostname(), b.URL.Hostname()) &&
+		effectivePort(a) == effectivePort(b)
+}
+
+// effectivePort returns the explicit port or the scheme default.
+func effectivePort(req *http.Request) string {
+	if port := req.URL.Port(); port != "" {
+		return port
+	}
+	return defaultPort(req.URL.Scheme)
+}
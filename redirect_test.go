@@ -0,0 +1,187 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRedirectChainServer redirects /hops/N to /hops/N-1 and answers "done" at /hops/0.
+func newRedirectChainServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hops/"))
+		if n > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/hops/%d", n-1), http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("done"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newAuthEchoServer returns the received Authorization header as the body.
+func newAuthEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newRedirectToServer redirects every request to target.
+func newRedirectToServer(t *testing.T, target string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target, http.StatusTemporaryRedirect)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// redirectCount sums http_client_redirects_total in the registry.
+func redirectCount(t *testing.T, reg *prometheus.Registry) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	total := 0.0
+	for _, family := range families {
+		if family.GetName() != MetricRedirectsTotal {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			total += m.GetCounter().GetValue()
+		}
+	}
+	return total
+}
+
+// getRedirectBody performs a GET request and reads the whole body.
+func getRedirectBody(t *testing.T, client *Client, url string, opts ...RequestOption) (*http.Response, string) {
+	t.Helper()
+	resp, err := client.Get(context.Background(), url, opts...)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestRedirects_FollowedAndCounted(t *testing.T) {
+	t.Parallel()
+	server := newRedirectChainServer(t)
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+	}, "test-redirects")
+	defer client.Close()
+
+	resp, body := getRedirectBody(t, client, server.URL+"/hops/3")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "done", body)
+	assert.Equal(t, 3.0, redirectCount(t, reg))
+}
+
+func TestRedirects_MaxRedirects(t *testing.T) {
+	t.Parallel()
+	server := newRedirectChainServer(t)
+
+	client := New(Config{MaxRedirects: 2}, "test-redirects-max")
+	defer client.Close()
+
+	_, body := getRedirectBody(t, client, server.URL+"/hops/2")
+	assert.Equal(t, "done", body)
+
+	_, err := client.Get(context.Background(), server.URL+"/hops/3")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stopped after 2 redirects")
+}
+
+func TestRedirects_Disabled(t *testing.T) {
+	t.Parallel()
+	server := newRedirectChainServer(t)
+
+	client := New(Config{MaxRedirects: -1}, "test-redirects-disabled")
+	defer client.Close()
+
+	resp, _ := getRedirectBody(t, client, server.URL+"/hops/1")
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "/hops/0", resp.Header.Get("Location"))
+}
+
+func TestWithNoFollowRedirects(t *testing.T) {
+	t.Parallel()
+	server := newRedirectChainServer(t)
+
+	client := New(Config{}, "test-redirects-option")
+	defer client.Close()
+
+	resp, _ := getRedirectBody(t, client, server.URL+"/hops/1", WithNoFollowRedirects())
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	resp, _ = getRedirectBody(t, client, server.URL+"/hops/1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRedirects_Policy(t *testing.T) {
+	t.Parallel()
+	server := newRedirectChainServer(t)
+	errForbidden := errors.New("redirect forbidden")
+
+	client := New(Config{
+		RedirectPolicy: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Path == "/hops/0" {
+				return errForbidden
+			}
+			return nil
+		},
+	}, "test-redirects-policy")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL+"/hops/2")
+	assert.ErrorIs(t, err, errForbidden)
+}
+
+func TestRedirects_AuthPolicy(t *testing.T) {
+	t.Parallel()
+	echo := newAuthEchoServer(t)
+	// Same host on another port: net/http forwards credentials
+	sameHost := newRedirectToServer(t, echo.URL)
+	// Another host name for the same server: net/http strips credentials
+	otherHost := newRedirectToServer(t, strings.Replace(echo.URL, "127.0.0.1", "localhost", 1))
+
+	tests := []struct {
+		name      string
+		policy    RedirectAuthPolicy
+		start     string
+		wantToken string
+	}{
+		{"default keeps for same host", RedirectAuthDefault, sameHost.URL, "Bearer secret"},
+		{"strip on port change", RedirectAuthStrip, sameHost.URL, ""},
+		{"default strips for other host", RedirectAuthDefault, otherHost.URL, ""},
+		{"preserve for other host", RedirectAuthPreserve, otherHost.URL, "Bearer secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := New(Config{RedirectAuthPolicy: tt.policy}, "test-redirects-auth")
+			defer client.Close()
+
+			_, body := getRedirectBody(t, client, tt.start, WithBearerToken("secret"))
+			assert.Equal(t, tt.wantToken, body)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// clientContextKey is the context key of the client stored by NewContext.
+type clientContextKey struct{}
+
+// NewContext returns a copy of ctx carrying the client.
+func NewContext(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client)
+}
+
+// FromContext returns the client stored in ctx by NewContext, or nil.
+func FromContext(ctx context.Context) *Client {
+	client, _ := ctx.Value(clientContextKey{}).(*Client)
+	return client
+}
+
+// registry holds the clients registered by name.
+var registry sync.Map // map[string]*Client
+
+// Register makes the client available by name through Get, replacing a client
+// registered earlier under the same name.
+func Register(name string, client *Client) {
+	registry.Store(name, client)
+}
+
+// Unregister removes the client registered under the name.
+func Unregister(name string) {
+	registry.Delete(name)
+}
+
+// Get returns the client registered under the name, or nil.
+func Get(name string) *Client {
+	client, _ := Lookup(name)
+	return client
+}
+
+// Lookup returns the client registered under the name and whether it exists.
+func Lookup(name string) (*Client, bool) {
+	value, ok := registry.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return value.(*Client), true
+}
+
+// RegisteredNames returns the sorted names of the registered clients.
+func RegisteredNames() []string {
+	var names []string
+	registry.Range(func(key, _ any) bool {
+		names = append(names, key.(string))
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
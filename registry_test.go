@@ -0,0 +1,52 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextClient(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-context-client")
+	defer client.Close()
+
+	assert.Nil(t, FromContext(context.Background()))
+	ctx := NewContext(context.Background(), client)
+	assert.Same(t, client, FromContext(ctx))
+
+	var fromHandler *Client
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		fromHandler = FromContext(r.Context())
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	handler.ServeHTTP(nil, req)
+	assert.Same(t, client, fromHandler)
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+	payments := New(Config{}, "test-registry-payments")
+	defer payments.Close()
+	replacement := New(Config{}, "test-registry-payments-v2")
+	defer replacement.Close()
+
+	assert.Nil(t, Get("test-registry-payments"))
+	Register("test-registry-payments", payments)
+	defer Unregister("test-registry-payments")
+	assert.Same(t, payments, Get("test-registry-payments"))
+	assert.Contains(t, RegisteredNames(), "test-registry-payments")
+
+	Register("test-registry-payments", replacement)
+	client, ok := Lookup("test-registry-payments")
+	assert.True(t, ok)
+	assert.Same(t, replacement, client)
+
+	Unregister("test-registry-payments")
+	_, ok = Lookup("test-registry-payments")
+	assert.False(t, ok)
+}
@@ -0,0 +1,186 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledBodySize caps the buffers returned to bodyBufferPool. Larger buffers are left
+// to the garbage collector so that one big upload doesn't pin its memory in the pool.
+const maxPooledBodySize = 1 << 20
+
+// bodyBufferPool holds the buffers of request bodies buffered for retries.
+var bodyBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// preparedBody describes how the request body can be replayed on further attempts.
+type preparedBody struct {
+	data       []byte                        // buffered body
+	buffer     *bodyBuffer                   // pooled buffer holding data
+	getBody    func() (io.ReadCloser, error) // body factory used instead of buffering
+	replayable bool                          // false when the body can be sent only once
+}
+
+// bodyBuffer is a request body buffered in a pooled buffer. The request holds a reference
+// and every attempt body holds one until it is closed: the transport may close the body
+// of an attempt after RoundTrip returns. The buffer goes back to the pool with the last
+// reference.
+type bodyBuffer struct {
+	buf  *bytes.Buffer
+	refs atomic.Int32
+}
+
+// newBodyBuffer returns a buffer from the pool referenced by the request.
+func newBodyBuffer() *bodyBuffer {
+	b := &bodyBuffer{buf: bodyBufferPool.Get().(*bytes.Buffer)}
+	b.refs.Store(1)
+	return b
+}
+
+// newReader returns an attempt body reading the buffered body.
+func (b *bodyBuffer) newReader() io.ReadCloser {
+	b.refs.Add(1)
+	r := &bodyBufferReader{owner: b}
+	r.Reset(b.buf.Bytes())
+	return r
+}
+
+// release drops a reference and returns the buffer to the pool after the last one.
+func (b *bodyBuffer) release() {
+	if b.refs.Add(-1) != 0 {
+		return
+	}
+	if b.buf.Cap() <= maxPooledBodySize {
+		b.buf.Reset()
+		bodyBufferPool.Put(b.buf)
+	}
+	b.buf = nil
+}
+
+// errBodyClosed is returned by reads of a closed attempt body.
+var errBodyClosed = errors.New("http: read on closed request body")
+
+// bodyBufferReader is the body of one attempt. Reads and Close are serialized: the
+// transport may close the body from another goroutine, and once closed the buffer may
+// already belong to another request.
+type bodyBufferReader struct {
+	bytes.Reader
+	mu     sync.Mutex
+	owner  *bodyBuffer
+	closed bool
+}
+
+// Read reads the buffered body.
+func (r *bodyBufferReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, errBodyClosed
+	}
+	return r.Reader.Read(p)
+}
+
+// WriteTo writes the buffered body to w.
+func (r *bodyBufferReader) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, errBodyClosed
+	}
+	return r.Reader.WriteTo(w)
+}
+
+// Close releases the reference of the attempt to the buffer. Closing twice is a no-op.
+func (r *bodyBufferReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		r.Reset(nil)
+		r.owner.release()
+	}
+	return nil
+}
+
+// prepareRequestBody prepares the request body for retry.
+// Bodies with a GetBody factory (set by http.NewRequest or WithBodyProvider) are replayed
+// through it without buffering. Other bodies are buffered in memory unless they exceed
+// RetryConfig.MaxBufferedBodyBytes, in which case the request is sent only once.
+func (rt *RoundTripper) prepareRequestBody(req *http.Request, config Config) (preparedBody, error) {
+	if req.Body == nil || req.Body == http.NoBody || !config.RetryEnabled && !config.HedgingEnabled {
+		// No body to prepare or neither retry nor hedging is enabled
+		return preparedBody{replayable: true}, nil
+	}
+
+	if req.GetBody != nil {
+		return preparedBody{getBody: req.GetBody, replayable: true}, nil
+	}
+
+	limit := config.RetryConfig.MaxBufferedBodyBytes
+	if limit > 0 && req.ContentLength > limit {
+		return preparedBody{}, nil
+	}
+
+	reader := io.Reader(req.Body)
+	if limit > 0 {
+		reader = io.LimitReader(req.Body, limit+1)
+	}
+
+	buffer := newBodyBuffer()
+	if req.ContentLength > 0 {
+		buffer.buf.Grow(int(req.ContentLength))
+	}
+	if _, err := buffer.buf.ReadFrom(reader); err != nil {
+		buffer.release()
+		return preparedBody{}, err
+	}
+	originalBody := buffer.buf.Bytes()
+	if originalBody == nil {
+		// An empty body is still a body that can be replayed
+		originalBody = []byte{}
+	}
+
+	if limit > 0 && int64(len(originalBody)) > limit {
+		// Too large to buffer: stream the already read prefix followed by the rest.
+		// The stream keeps the prefix, so the buffer is not returned to the pool
+		req.Body = &replayBody{
+			Reader: io.MultiReader(bytes.NewReader(originalBody), req.Body),
+			closer: req.Body,
+		}
+		return preparedBody{}, nil
+	}
+	_ = req.Body.Close() // Ignore error on close
+
+	// Restore for first request
+	req.Body = buffer.newReader()
+	return preparedBody{data: originalBody, buffer: buffer, replayable: true}, nil
+}
+
+// newAttemptBody returns a fresh body for a repeated attempt, or nil when there is no body.
+func (rc *retryContext) newAttemptBody() (io.ReadCloser, error) {
+	if rc.getBody != nil {
+		return rc.getBody()
+	}
+	if len(rc.originalBody) > 0 {
+		return rc.bodyBuffer.newReader(), nil
+	}
+	return nil, nil
+}
+
+// releaseBody returns the buffered body to the pool once the attempts no longer need it.
+func (rc *retryContext) releaseBody() {
+	if rc.bodyBuffer != nil {
+		rc.bodyBuffer.release()
+		rc.bodyBuffer = nil
+	}
+}
+
+// canReplayBody reports whether the request body can be sent more than once.
+func (rc *retryContext) canReplayBody() bool {
+	return rc.getBody != nil || rc.originalBody != nil
+}
@@ -0,0 +1,217 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// opaqueReader hides the concrete reader type so http.NewRequest can't set GetBody.
+type opaqueReader struct {
+	io.Reader
+}
+
+func retryBodyConfig() Config {
+	return Config{
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			MaxAttempts:  3,
+			BaseDelay:    time.Millisecond,
+			MaxDelay:     5 * time.Millisecond,
+			RetryMethods: []string{http.MethodPost, http.MethodPut},
+		},
+	}
+}
+
+func TestWithBodyProvider_ReplaysBodyOnRetry(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	var calls int32
+	provider := func() (io.ReadCloser, error) {
+		atomic.AddInt32(&calls, 1)
+		return io.NopCloser(strings.NewReader("streamed payload")), nil
+	}
+
+	client := New(retryBodyConfig(), "test-body-provider")
+	defer client.Close()
+
+	resp, err := client.Post(context.Background(), server.URL, nil, WithBodyProvider(provider, 16))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	require.Equal(t, 2, server.GetRequestCount())
+	for _, logged := range server.RequestLog {
+		assert.Equal(t, "streamed payload", logged.Body)
+	}
+}
+
+func TestMaxBufferedBodyBytes_LargeBodySentOnce(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	config := retryBodyConfig()
+	config.RetryConfig.MaxBufferedBodyBytes = 8
+
+	client := New(config, "test-body-threshold")
+	defer client.Close()
+
+	body := opaqueReader{Reader: strings.NewReader("this body is larger than eight bytes")}
+	resp, err := client.Post(context.Background(), server.URL, body)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, 1, server.GetRequestCount())
+	assert.Equal(t, "this body is larger than eight bytes", server.RequestLog[0].Body)
+}
+
+func TestMaxBufferedBodyBytes_SmallBodyBuffered(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	config := retryBodyConfig()
+	config.RetryConfig.MaxBufferedBodyBytes = 1024
+
+	client := New(config, "test-body-threshold-small")
+	defer client.Close()
+
+	resp, err := client.Post(context.Background(), server.URL, opaqueReader{Reader: strings.NewReader("small")})
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, server.GetRequestCount())
+	assert.Equal(t, "small", server.RequestLog[1].Body)
+}
+
+func TestBodyOptions_UpdateGetBody(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	client := New(retryBodyConfig(), "test-body-options-getbody")
+	defer client.Close()
+
+	// The reader passed to Post sets GetBody, which WithJSONBody must replace
+	resp, err := client.Post(context.Background(), server.URL, strings.NewReader("stale"),
+		WithJSONBody(map[string]string{"fresh": "body"}))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, 2, server.GetRequestCount())
+	assert.JSONEq(t, `{"fresh":"body"}`, server.RequestLog[1].Body)
+}
+
+func TestBodyBuffer_ReturnsToPoolAfterLastReference(t *testing.T) {
+	t.Parallel()
+	buffer := newBodyBuffer()
+	_, err := buffer.buf.WriteString("payload")
+	require.NoError(t, err)
+
+	first := buffer.newReader()
+	second := buffer.newReader()
+	data, err := io.ReadAll(first)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(data))
+
+	buffer.release() // the request is done, the attempts still hold the buffer
+	require.NoError(t, first.Close())
+	require.NoError(t, first.Close())
+	assert.NotNil(t, buffer.buf)
+
+	_, err = first.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, errBodyClosed)
+	require.NoError(t, second.Close())
+	assert.Nil(t, buffer.buf)
+}
+
+func TestBufferedBody_ReplayedFromPoolOnRetry(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	client := New(retryBodyConfig(), "test-pooled-body")
+	defer client.Close()
+
+	for _, payload := range []string{"first payload", "second"} {
+		resp, err := client.Post(context.Background(), server.URL, opaqueReader{strings.NewReader(payload)})
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	require.Len(t, server.RequestLog, 4)
+	for i, want := range []string{"first payload", "first payload", "first payload", "second"} {
+		assert.Equal(t, want, server.RequestLog[i].Body)
+	}
+}
+
+// bodyReadingTransport reads and closes the request body like a real transport.
+type bodyReadingTransport struct{}
+
+func (bodyReadingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		_ = req.Body.Close()
+	}
+	return staticTransport{}.RoundTrip(req)
+}
+
+// BenchmarkRoundTrip_BufferedBody measures POST requests whose body is buffered for retries.
+func BenchmarkRoundTrip_BufferedBody(b *testing.B) {
+	for _, size := range []int{1 << 10, 64 << 10} {
+		b.Run(strconv.Itoa(size>>10)+"KB", func(b *testing.B) {
+			config := retryBodyConfig()
+			config.Transport = bodyReadingTransport{}
+			metricsEnabled := false
+			config.MetricsEnabled = &metricsEnabled
+			client := New(config, "benchmark-buffered-body")
+			defer client.Close()
+
+			payload := strings.Repeat("x", size)
+			ctx := context.Background()
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					resp, err := client.Post(ctx, "http://example.com/upload", opaqueReader{strings.NewReader(payload)})
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					_ = resp.Body.Close()
+				}
+			})
+		})
+	}
+}
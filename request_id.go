@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the default header carrying the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID, e.g. the ID of an
+// incoming request, for RequestIDMiddleware to propagate.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// RequestIDConfig contains settings for RequestIDMiddleware.
+type RequestIDConfig struct {
+	// Header is the header carrying the ID (default: X-Request-ID)
+	Header string
+
+	// ContextKeys are context keys with string correlation IDs set by other frameworks,
+	// checked after ContextWithRequestID
+	ContextKeys []any
+
+	// Generate creates IDs of requests without one (default: UUIDv7)
+	Generate func() string
+}
+
+// withDefaults applies default values to the request ID configuration.
+func (c RequestIDConfig) withDefaults() RequestIDConfig {
+	if c.Header == "" {
+		c.Header = RequestIDHeader
+	}
+	if c.Generate == nil {
+		c.Generate = newRequestID
+	}
+	return c
+}
+
+// newRequestID returns a UUIDv7, or a random UUID if the clock can't be read.
+func newRequestID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// RequestIDMiddleware sets a correlation ID header on every request: the header already
+// set on the request, the ID of the context (ContextWithRequestID or RequestIDConfig.ContextKeys)
+// or a new ID. All attempts of a request share the ID. The ID is stored in the request
+// context for RequestIDFromContext, added to the client span as the http.request_id attribute
+// and to retry logs as request_id. It isn't a metrics label because every request has a new value.
+type RequestIDMiddleware struct {
+	config RequestIDConfig
+}
+
+// NewRequestIDMiddleware creates a new request ID middleware.
+func NewRequestIDMiddleware(config RequestIDConfig) *RequestIDMiddleware {
+	return &RequestIDMiddleware{config: config.withDefaults()}
+}
+
+// Process implements the Middleware interface.
+func (m *RequestIDMiddleware) Process(
+	req *http.Request,
+	next func(*http.Request) (*http.Response, error),
+) (*http.Response, error) {
+	ctx := req.Context()
+	id := req.Header.Get(m.config.Header)
+	if id == "" {
+		id = m.contextID(ctx)
+	}
+	if id == "" {
+		id = m.config.Generate()
+	}
+
+	req = req.Clone(ContextWithRequestID(ctx, id))
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Set(m.config.Header, id)
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(attribute.String("http.request_id", id))
+	}
+	return next(req)
+}
+
+// contextID returns the correlation ID stored in the context, or "".
+func (m *RequestIDMiddleware) contextID(ctx context.Context) string {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return id
+	}
+	for _, key := range m.config.ContextKeys {
+		if id, ok := ctx.Value(key).(string); ok && id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// frameworkKey is a context key of a correlation ID set by another framework.
+type frameworkKey struct{}
+
+func TestRequestIDMiddleware_Sources(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	client := New(Config{
+		Middlewares: []Middleware{NewRequestIDMiddleware(RequestIDConfig{ContextKeys: []any{frameworkKey{}}})},
+	}, "test-request-id")
+	defer client.Close()
+
+	get := func(ctx context.Context, opts ...RequestOption) string {
+		t.Helper()
+		resp, err := client.Get(ctx, server.URL, opts...)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return server.GetLastRequest().Headers[http.CanonicalHeaderKey(RequestIDHeader)]
+	}
+
+	generated := get(context.Background())
+	parsed, err := uuid.Parse(generated)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), parsed.Version())
+	assert.NotEqual(t, generated, get(context.Background()))
+
+	assert.Equal(t, "incoming-1", get(ContextWithRequestID(context.Background(), "incoming-1")))
+	assert.Equal(t, "framework-1", get(context.WithValue(context.Background(), frameworkKey{}, "framework-1")))
+	assert.Equal(t, "explicit-1", get(ContextWithRequestID(context.Background(), "incoming-1"),
+		WithHeader(RequestIDHeader, "explicit-1")))
+}
+
+func TestRequestIDMiddleware_SharedByRetries(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		Logger:       logger,
+		Middlewares: []Middleware{NewRequestIDMiddleware(RequestIDConfig{
+			Header:   "X-Correlation-ID",
+			Generate: func() string { return "fixed-id" },
+		})},
+	}, "test-request-id-retries")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	require.Len(t, server.RequestLog, 2)
+	for _, req := range server.RequestLog {
+		assert.Equal(t, "fixed-id", req.Headers["X-Correlation-Id"])
+	}
+	require.NotEmpty(t, logger.records)
+	assert.Contains(t, logger.records[0].kv, "request_id")
+	assert.Contains(t, logger.records[0].kv, "fixed-id")
+}
+
+func TestRequestIDMiddleware_SpanAttribute(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	client := New(Config{
+		TracingEnabled: true,
+		Middlewares:    []Middleware{NewRequestIDMiddleware(RequestIDConfig{})},
+	}, "test-request-id-span")
+	defer client.Close()
+	client.httpClient.Transport.(*RoundTripper).tracer = &Tracer{tracer: provider.Tracer("test")}
+
+	resp, err := client.Get(ContextWithRequestID(context.Background(), "span-id"), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes, attribute.String("http.request_id", "span-id"))
+}
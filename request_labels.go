@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+)
+
+// requestLabelsKey is the context key of the request labels set with WithLabel.
+type requestLabelsKey struct{}
+
+// requestLabel is a request label allowed by MetricsLabelsConfig.RequestLabels.
+type requestLabel struct {
+	key, value string
+}
+
+// reservedLabelNames are the labels recorded by the client itself.
+var reservedLabelNames = []string{
+	"client_name", "method", "host", "path", "status", "retry", "error", "attempt", "reason",
+	"phase", "state", "from", "to", "service", "backend", "result", "le",
+}
+
+// labelNamePattern matches valid Prometheus label names.
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// WithLabel sets a request label such as operation="charge_card". The label is added to the
+// request metrics, the span attributes and the log fields of the request when its key is
+// listed in MetricsLabelsConfig.RequestLabels; other keys are ignored to bound the cardinality.
+func WithLabel(key, value string) RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			labels := make(map[string]string, len(o.labels)+1)
+			maps.Copy(labels, o.labels)
+			labels[key] = value
+			o.labels = labels
+		})
+	}
+}
+
+// RequestLabelsFromContext returns the request labels set with WithLabel and allowed by
+// MetricsLabelsConfig.RequestLabels, e.g. for middlewares. It returns nil without labels.
+func RequestLabelsFromContext(ctx context.Context) map[string]string {
+	labels := requestLabelsFromContext(ctx)
+	if len(labels) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(labels))
+	for _, label := range labels {
+		result[label.key] = label.value
+	}
+	return result
+}
+
+// applyRequestLabels stores the allowed labels of the request in its context, in the order
+// of MetricsLabelsConfig.RequestLabels.
+func (rt *RoundTripper) applyRequestLabels(req *http.Request) *http.Request {
+	names := rt.config.MetricsLabels.RequestLabels
+	overrides := getRequestOverrides(req.Context())
+	if len(names) == 0 || overrides == nil || len(overrides.labels) == 0 {
+		return req
+	}
+	labels := make([]requestLabel, 0, len(names))
+	for _, name := range names {
+		if value, ok := overrides.labels[name]; ok {
+			labels = append(labels, requestLabel{key: name, value: value})
+		}
+	}
+	if len(labels) == 0 {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), requestLabelsKey{}, labels))
+}
+
+// requestLabelsFromContext returns the labels stored by applyRequestLabels.
+func requestLabelsFromContext(ctx context.Context) []requestLabel {
+	if ctx == nil {
+		return nil
+	}
+	labels, _ := ctx.Value(requestLabelsKey{}).([]requestLabel)
+	return labels
+}
+
+// appendRequestLabelValues appends the values of the named request labels of ctx to values;
+// unset labels get an empty value.
+func appendRequestLabelValues(ctx context.Context, values []string, names []string) []string {
+	labels := requestLabelsFromContext(ctx)
+	for _, name := range names {
+		var value string
+		for _, label := range labels {
+			if label.key == name {
+				value = label.value
+				break
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// validateRequestLabels checks that the request label names are valid, unique and don't
+// collide with the labels of the client or the static labels.
+func validateRequestLabels(labels MetricsLabelsConfig) []error {
+	var errs []error
+	for i, name := range labels.RequestLabels {
+		switch _, static := labels.StaticLabels[name]; {
+		case !labelNamePattern.MatchString(name):
+			errs = append(errs, NewConfigurationError("MetricsLabels.RequestLabels", name, "is not a valid label name"))
+		case slices.Contains(reservedLabelNames, name):
+			errs = append(errs, NewConfigurationError("MetricsLabels.RequestLabels", name, "is a built-in label"))
+		case static:
+			errs = append(errs, NewConfigurationError("MetricsLabels.RequestLabels", name, "is a static label"))
+		case slices.Contains(labels.RequestLabels[:i], name):
+			errs = append(errs, NewConfigurationError("MetricsLabels.RequestLabels", name, "is duplicated"))
+		}
+	}
+	return errs
+}
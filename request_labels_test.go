@@ -0,0 +1,159 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// labelsRecorder stores the request labels of the last request in seen.
+func labelsRecorder(seen *map[string]string) Middleware {
+	return MiddlewareFunc(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		*seen = RequestLabelsFromContext(req.Context())
+		return next(req)
+	})
+}
+
+func TestWithLabel_MetricsSpansAndLogs(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	var seen map[string]string
+	reg := prometheus.NewRegistry()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	logger := &recordingLogger{}
+	client := New(Config{
+		RetryEnabled:         true,
+		RetryConfig:          RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		TracingEnabled:       true,
+		Logger:               logger,
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		MetricsLabels:        MetricsLabelsConfig{RequestLabels: []string{"operation"}},
+		Middlewares:          []Middleware{labelsRecorder(&seen)},
+	}, "test-request-labels")
+	defer client.Close()
+	client.httpClient.Transport.(*RoundTripper).tracer = &Tracer{tracer: provider.Tracer("test")}
+
+	resp, err := client.Get(context.Background(), server.URL,
+		WithLabel("operation", "charge_card"), WithLabel("customer", "c-42"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	// Keys missing from the allowlist are dropped
+	assert.Equal(t, map[string]string{"operation": "charge_card"}, seen)
+
+	resp, err = client.Post(context.Background(), server.URL, nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Nil(t, seen)
+
+	charged := map[string]string{"method": "GET", "status": "200", "operation": "charge_card"}
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, "http_client_requests_total", charged))
+	retried := map[string]string{"reason": "status", "operation": "charge_card"}
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, "http_client_retries_total", retried))
+	unlabeled := map[string]string{"method": "POST", "operation": ""}
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, "http_client_requests_total", unlabeled))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Contains(t, spans[0].Attributes, attribute.String("operation", "charge_card"))
+	assert.NotContains(t, spans[0].Attributes, attribute.String("customer", "c-42"))
+
+	require.NotEmpty(t, logger.records)
+	kv := logger.records[0].kv
+	assert.Equal(t, []any{"operation", "charge_card"}, kv[len(kv)-2:])
+}
+
+func TestWithLabel_NotAllowed(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	seen := map[string]string{}
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		Middlewares:          []Middleware{labelsRecorder(&seen)},
+	}, "test-request-labels-off")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL, WithLabel("operation", "charge_card"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Nil(t, seen)
+	assert.Equal(t, 0.0, circuitBreakerMetric(t, reg, "http_client_requests_total",
+		map[string]string{"operation": "charge_card"}))
+}
+
+func TestValidate_RequestLabels(t *testing.T) {
+	t.Parallel()
+	config := Config{MetricsLabels: MetricsLabelsConfig{
+		StaticLabels:  map[string]string{"team": "payments"},
+		RequestLabels: []string{"operation", "status", "team", "bad-name", "operation"},
+	}}
+	err := config.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "status")
+	assert.ErrorContains(t, err, "is a built-in label")
+	assert.ErrorContains(t, err, "is a static label")
+	assert.ErrorContains(t, err, "is not a valid label name")
+	assert.ErrorContains(t, err, "is duplicated")
+
+	config.MetricsLabels.RequestLabels = []string{"operation", "tenant"}
+	assert.NoError(t, config.Validate())
+}
+
+func TestWithLabel_OpenTelemetry(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK}, TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+	client := New(Config{
+		MetricsBackend:    MetricsBackendOpenTelemetry,
+		OTelMeterProvider: mp,
+		MetricsLabels:     MetricsLabelsConfig{RequestLabels: []string{"operation"}},
+	}, "test-request-labels-otel")
+	defer client.Close()
+
+	for _, operation := range []string{"charge_card", "refund"} {
+		resp, err := client.Get(context.Background(), server.URL, WithLabel("operation", operation))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	operations := map[string]int64{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if data, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == MetricRequestsTotal {
+				for _, point := range data.DataPoints {
+					operation, _ := point.Attributes.Value("operation")
+					operations[operation.AsString()] = point.Value
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{"charge_card": 1, "refund": 1}, operations)
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// requestLogKey is the context key of the canonical log line of a request.
+type requestLogKey struct{}
+
+// requestLog collects the fields of the canonical log line of one logical request.
+type requestLog struct {
+	attemptDurations []time.Duration
+	retryReasons     []string
+	// rateLimitWait is the total wait for the rate limiter in nanoseconds, added by each attempt
+	rateLimitWait atomic.Int64
+}
+
+// requestLogEnabled checks if canonical log lines are logged at any level.
+func (l LogLevels) requestLogEnabled() bool {
+	return l.Request != LogLevelOff || l.RequestFailed != LogLevelOff
+}
+
+// withRequestLog starts collecting the canonical log line of the request when it's enabled.
+func (rt *RoundTripper) withRequestLog(req *http.Request) (*http.Request, *requestLog) {
+	if rt.config.Logger == nil || !rt.config.LogLevels.requestLogEnabled() {
+		return req, nil
+	}
+	log := &requestLog{}
+	return req.WithContext(context.WithValue(req.Context(), requestLogKey{}, log)), log
+}
+
+// addRateLimitWait adds the rate limiter wait of an attempt to the canonical log line.
+func addRateLimitWait(ctx context.Context, waited time.Duration) {
+	if log, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		log.rateLimitWait.Add(int64(waited))
+	}
+}
+
+// logRequest writes the canonical log line summarizing all attempts of the request.
+func (rt *RoundTripper) logRequest(retryCtx *retryContext, requestSize int64, resp *http.Response, err error) {
+	log := retryCtx.log
+	if log == nil {
+		return
+	}
+	req := retryCtx.originalReq
+	status := statusCode(resp)
+	keysAndValues := []any{
+		"client", rt.metrics.clientName,
+		"method", req.Method,
+		"host", retryCtx.host,
+		"endpoint", rt.logEndpoint(req),
+		"status", status,
+		"attempts", retryCtx.attempts,
+		"duration", retryCtx.elapsed(),
+		"attempt_durations", log.attemptDurations,
+		"bytes_sent", requestSize,
+	}
+	if resp != nil {
+		keysAndValues = append(keysAndValues, "bytes_received", resp.ContentLength)
+	}
+	if id, ok := RequestIDFromContext(retryCtx.ctx); ok {
+		keysAndValues = append(keysAndValues, "request_id", id)
+	}
+	if len(log.retryReasons) > 0 {
+		keysAndValues = append(keysAndValues, "retry_reasons", log.retryReasons)
+	}
+	if rt.config.CircuitBreakerEnable && rt.config.CircuitBreaker != nil {
+		keysAndValues = append(keysAndValues, "circuit_breaker", rt.config.CircuitBreaker.State().String())
+	}
+	if rt.config.RateLimiterEnabled {
+		keysAndValues = append(keysAndValues, "rate_limit_wait", time.Duration(log.rateLimitWait.Load()))
+	}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err.Error())
+	}
+
+	level := rt.config.LogLevels.Request
+	if err != nil || status >= http.StatusInternalServerError {
+		level = rt.config.LogLevels.RequestFailed
+	}
+	logEvent(retryCtx.ctx, rt.config.Logger, level, "http request", keysAndValues...)
+}
+
+// logEndpoint returns the path template of the request, or its path without a template.
+// Unlike metric paths, it's never dropped or collapsed: log lines don't create series.
+func (rt *RoundTripper) logEndpoint(req *http.Request) string {
+	if template, ok := PathTemplateFromContext(req.Context()); ok {
+		return template
+	}
+	if templates := rt.config.MetricsLabels.URLTemplates; len(templates) > 0 {
+		if template := MatchURLTemplate(templates, req.URL.Path); template != "" {
+			return template
+		}
+	}
+	return req.URL.Path
+}
@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordFields returns the key-value pairs of a log record as a map.
+func recordFields(record logRecord) map[string]any {
+	fields := make(map[string]any, len(record.kv)/2)
+	for i := 0; i+1 < len(record.kv); i += 2 {
+		fields[record.kv[i].(string)] = record.kv[i+1]
+	}
+	return fields
+}
+
+func TestRequestLog_SummarizesAttempts(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK, Body: "ok"},
+	)
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	client := New(Config{
+		Logger:               logger,
+		LogLevels:            LogLevels{Request: LogLevelInfo},
+		RetryEnabled:         true,
+		RetryConfig:          RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		CircuitBreakerEnable: true,
+		RateLimiterEnabled:   true,
+		RateLimiterConfig:    RateLimiterConfig{RequestsPerSecond: 1000, BurstCapacity: 10},
+		MetricsLabels:        MetricsLabelsConfig{URLTemplates: []string{"/users/{id}"}},
+	}, "test-request-log")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL+"/users/42")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// Retry and rate limiter records are replaced by the single line
+	require.Equal(t, []string{"info http request"}, logger.messages())
+	fields := recordFields(logger.records[0])
+	assert.Equal(t, "test-request-log", fields["client"])
+	assert.Equal(t, http.MethodGet, fields["method"])
+	assert.Equal(t, "/users/{id}", fields["endpoint"])
+	assert.Equal(t, http.StatusOK, fields["status"])
+	assert.Equal(t, 2, fields["attempts"])
+	assert.Len(t, fields["attempt_durations"], 2)
+	assert.Equal(t, []string{"status"}, fields["retry_reasons"])
+	assert.Equal(t, "closed", fields["circuit_breaker"])
+	assert.Equal(t, int64(2), fields["bytes_received"])
+	assert.Contains(t, fields, "rate_limit_wait")
+	assert.Contains(t, fields, "duration")
+	assert.NotContains(t, fields, "error")
+}
+
+func TestRequestLog_FailedLevel(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusBadGateway})
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	client := New(Config{
+		Logger:    logger,
+		LogLevels: LogLevels{RequestFailed: LogLevelError},
+	}, "test-request-log-failed")
+	defer client.Close()
+
+	// Only failed requests are logged when Request stays off
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, []string{"error http request"}, logger.messages())
+	assert.Equal(t, http.StatusBadGateway, recordFields(logger.records[0])["status"])
+
+	failing := New(Config{
+		Logger:    logger,
+		LogLevels: LogLevels{Request: LogLevelDebug},
+		Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("boom")
+		}),
+	}, "test-request-log-error")
+	defer failing.Close()
+
+	_, err = failing.Get(context.Background(), server.URL)
+	require.Error(t, err)
+	require.Len(t, logger.records, 2)
+	assert.Equal(t, LogLevelDebug, logger.records[1].level)
+	assert.Contains(t, recordFields(logger.records[1])["error"], "boom")
+}
+
+func TestLogLevels_RequestDefaults(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, LogLevels{
+		Retry:          LogLevelInfo,
+		CircuitBreaker: LogLevelWarn,
+		RateLimiter:    LogLevelDebug,
+		Request:        LogLevelOff,
+		RequestFailed:  LogLevelOff,
+	}, LogLevels{}.withDefaults())
+
+	assert.Equal(t, LogLevels{
+		Retry:          LogLevelOff,
+		CircuitBreaker: LogLevelWarn,
+		RateLimiter:    LogLevelOff,
+		Request:        LogLevelInfo,
+		RequestFailed:  LogLevelInfo,
+	}, LogLevels{Request: LogLevelInfo}.withDefaults())
+}
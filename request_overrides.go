@@ -0,0 +1,134 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// requestOverridesKey is the context key for per-request configuration overrides.
+type requestOverridesKey struct{}
+
+// requestOverrides holds configuration that applies to a single request only.
+type requestOverrides struct {
+	timeout           time.Duration
+	perTryTimeout     time.Duration
+	retryConfig       *RetryConfig
+	maxAttempts       int
+	noRetry           bool
+	proxyURL          string
+	proxySet          bool
+	compress          bool
+	noDecompress      bool
+	noFollowRedirects bool
+	noMetrics         bool
+	priority          Priority
+	fallback          FallbackFunc
+	labels            map[string]string
+	checksum          *expectedChecksum
+	responseSchema    *JSONSchema
+	profile           string
+}
+
+// WithRequestTimeout sets the overall timeout for this request, replacing Config.Timeout.
+// The timeout covers all retry attempts and reading the response body.
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.timeout = d
+		})
+	}
+}
+
+// WithRetryPolicy enables retries for this request using the specified retry configuration
+// instead of Config.RetryConfig. Unset fields get the usual defaults.
+func WithRetryPolicy(config RetryConfig) RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.retryConfig = &config
+		})
+	}
+}
+
+// WithMaxAttempts sets the maximum number of attempts for this request.
+// Values greater than 1 enable retries even if they are disabled for the client.
+func WithMaxAttempts(n int) RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.maxAttempts = n
+		})
+	}
+}
+
+// WithNoRetry disables retries for this request.
+func WithNoRetry() RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.noRetry = true
+		})
+	}
+}
+
+// setRequestOverride updates the request overrides stored in the request context.
+// Overrides are copied on write so requests sharing a parent context don't affect each other.
+func setRequestOverride(req *http.Request, update func(*requestOverrides)) {
+	var overrides requestOverrides
+	if existing := getRequestOverrides(req.Context()); existing != nil {
+		overrides = *existing
+	}
+	update(&overrides)
+	*req = *req.WithContext(context.WithValue(req.Context(), requestOverridesKey{}, &overrides))
+}
+
+// getRequestOverrides returns the request overrides from the context, or nil if there are none.
+func getRequestOverrides(ctx context.Context) *requestOverrides {
+	overrides, _ := ctx.Value(requestOverridesKey{}).(*requestOverrides)
+	return overrides
+}
+
+// apply returns a copy of the configuration with the overrides applied.
+func (o *requestOverrides) apply(cfg Config) Config {
+	if o.timeout > 0 {
+		cfg.Timeout = o.timeout
+	}
+
+	if o.perTryTimeout > 0 {
+		cfg.PerTryTimeout = o.perTryTimeout
+	}
+
+	if o.retryConfig != nil {
+		cfg.RetryEnabled = true
+		cfg.RetryConfig = o.retryConfig.withDefaults()
+	}
+
+	if o.maxAttempts > 0 {
+		if o.maxAttempts > 1 && !cfg.RetryEnabled {
+			cfg.RetryEnabled = true
+			cfg.RetryConfig = cfg.RetryConfig.withDefaults()
+		}
+		cfg.RetryEnabled = cfg.RetryEnabled && o.maxAttempts > 1
+		cfg.RetryConfig.MaxAttempts = o.maxAttempts
+	}
+
+	if o.noRetry {
+		cfg.RetryEnabled = false
+	}
+
+	if o.compress {
+		cfg.CompressRequests = true
+	}
+
+	if o.fallback != nil {
+		cfg.Fallback = o.fallback
+	}
+
+	return cfg
+}
+
+// requestConfig returns the configuration effective for the request.
+func (rt *RoundTripper) requestConfig(req *http.Request) Config {
+	if overrides := getRequestOverrides(req.Context()); overrides != nil {
+		return overrides.apply(rt.config)
+	}
+	return rt.config
+}
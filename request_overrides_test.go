@@ -0,0 +1,141 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithNoRetry_DisablesRetryForRequest(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	client := New(retryBodyConfig(), "test-override-no-retry")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL, WithNoRetry())
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 1, server.GetRequestCount())
+}
+
+func TestWithMaxAttempts_EnablesRetryForRequest(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	client := New(Config{}, "test-override-max-attempts")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL, WithMaxAttempts(2))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 2, server.GetRequestCount())
+}
+
+func TestWithRetryPolicy_ReplacesClientRetryConfig(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	client := New(Config{}, "test-override-retry-policy")
+	defer client.Close()
+
+	policy := RetryConfig{
+		MaxAttempts:  3,
+		BaseDelay:    time.Millisecond,
+		MaxDelay:     5 * time.Millisecond,
+		RetryMethods: []string{http.MethodPost},
+	}
+	resp, err := client.Post(context.Background(), server.URL,
+		opaqueReader{Reader: strings.NewReader("payload")}, WithRetryPolicy(policy))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, server.GetRequestCount())
+	assert.Equal(t, "payload", server.RequestLog[1].Body)
+}
+
+func TestWithRequestTimeout(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusOK, Delay: 150 * time.Millisecond},
+		TestResponse{StatusCode: http.StatusOK, Delay: 150 * time.Millisecond},
+	)
+	defer server.Close()
+
+	client := New(Config{Timeout: 50 * time.Millisecond}, "test-override-timeout")
+	defer client.Close()
+
+	// A longer per-request timeout lifts the client-wide limit
+	resp, err := client.Get(context.Background(), server.URL, WithRequestTimeout(2*time.Second))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Without the override the client-wide timeout applies
+	_, err = client.Get(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.True(t, isTimeoutError(err))
+}
+
+func TestWithRequestTimeout_ShorterThanClientTimeout(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK, Delay: 300 * time.Millisecond})
+	defer server.Close()
+
+	client := New(Config{}, "test-override-short-timeout")
+	defer client.Close()
+
+	start := time.Now()
+	_, err := client.Get(context.Background(), server.URL, WithRequestTimeout(50*time.Millisecond))
+	require.Error(t, err)
+	assert.True(t, isTimeoutError(err))
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+}
+
+func TestRequestOverrides_Apply(t *testing.T) {
+	t.Parallel()
+	base := Config{RetryEnabled: true, RetryConfig: RetryConfig{MaxAttempts: 5}}.withDefaults()
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	require.NoError(t, err)
+	applyOptions(req, []RequestOption{WithMaxAttempts(2), WithRequestTimeout(time.Second)})
+
+	cfg := getRequestOverrides(req.Context()).apply(base)
+	assert.True(t, cfg.RetryEnabled)
+	assert.Equal(t, 2, cfg.RetryConfig.MaxAttempts)
+	assert.Equal(t, time.Second, cfg.Timeout)
+
+	// Overrides are copied on write and don't leak into requests sharing the context
+	derived := req.Clone(req.Context())
+	applyOptions(derived, []RequestOption{WithNoRetry()})
+	assert.False(t, getRequestOverrides(derived.Context()).apply(base).RetryEnabled)
+	assert.True(t, getRequestOverrides(req.Context()).apply(base).RetryEnabled)
+
+	// WithMaxAttempts(1) turns retries off
+	single := req.Clone(req.Context())
+	applyOptions(single, []RequestOption{WithMaxAttempts(1)})
+	assert.Equal(t, 1, getMaxAttempts(getRequestOverrides(single.Context()).apply(base)))
+}
@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RequestValidationError is returned for requests rejected by Config.RequestValidators. The
+// request is not sent, so no attempt, retry or circuit breaker failure is counted.
+type RequestValidationError struct {
+	Method string
+	URL    string
+	// Rule names the violated built-in rule, e.g. "max_url_length"; empty for custom validators
+	Rule string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *RequestValidationError) Error() string {
+	return fmt.Sprintf("request validation failed: %s %s: %v", e.Method, e.URL, e.Err)
+}
+
+// Unwrap returns the error of the validator.
+func (e *RequestValidationError) Unwrap() error {
+	return e.Err
+}
+
+// IsRequestValidationError checks if a request was rejected by Config.RequestValidators.
+func IsRequestValidationError(err error) bool {
+	var invalid *RequestValidationError
+	return errors.As(err, &invalid)
+}
+
+// validateRequest runs Config.RequestValidators in order and returns the first failure as a
+// *RequestValidationError.
+func (rt *RoundTripper) validateRequest(req *http.Request) error {
+	for _, validate := range rt.config.RequestValidators {
+		err := validate(req)
+		if err == nil {
+			continue
+		}
+		var invalid *RequestValidationError
+		if !errors.As(err, &invalid) {
+			invalid = &RequestValidationError{Err: err}
+		}
+		invalid.Method = req.Method
+		invalid.URL = req.URL.String()
+		return invalid
+	}
+	return nil
+}
+
+// MaxURLLengthValidator rejects requests whose URL is longer than n bytes, e.g. the 8 KB
+// limit of many servers and proxies.
+func MaxURLLengthValidator(n int) func(*http.Request) error {
+	return func(req *http.Request) error {
+		if length := len(req.URL.String()); length > n {
+			return &RequestValidationError{
+				Rule: "max_url_length",
+				Err:  fmt.Errorf("URL length %d exceeds %d", length, n),
+			}
+		}
+		return nil
+	}
+}
+
+// ForbiddenHeadersValidator rejects requests carrying any of the headers, e.g. internal
+// headers that must not leak to third parties.
+func ForbiddenHeadersValidator(names ...string) func(*http.Request) error {
+	return func(req *http.Request) error {
+		for _, name := range names {
+			if _, ok := req.Header[http.CanonicalHeaderKey(name)]; ok {
+				return &RequestValidationError{
+					Rule: "forbidden_header",
+					Err:  fmt.Errorf("header %s is forbidden", http.CanonicalHeaderKey(name)),
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// RequiredHeadersValidator rejects requests to host without all of the headers, e.g.
+// Authorization for an API that answers 400 or 401 otherwise. An empty host applies to all
+// hosts. Headers set by AttemptMiddlewares are added after validation and don't count.
+func RequiredHeadersValidator(host string, names ...string) func(*http.Request) error {
+	return func(req *http.Request) error {
+		if host != "" && !strings.EqualFold(req.URL.Hostname(), host) {
+			return nil
+		}
+		for _, name := range names {
+			if req.Header.Get(name) == "" {
+				return &RequestValidationError{
+					Rule: "required_header",
+					Err:  fmt.Errorf("header %s is required", http.CanonicalHeaderKey(name)),
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// MaxBodySizeValidator rejects requests whose body is larger than n bytes. Only bodies of
+// known length are checked: streamed bodies with ContentLength -1 pass.
+func MaxBodySizeValidator(n int64) func(*http.Request) error {
+	return func(req *http.Request) error {
+		if req.ContentLength > n {
+			return &RequestValidationError{
+				Rule: "max_body_size",
+				Err:  fmt.Errorf("body size %d exceeds %d", req.ContentLength, n),
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestValidators_FailFast(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	errMissingTenant := errors.New("tenant is missing")
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond},
+		RequestValidators: []func(*http.Request) error{
+			MaxURLLengthValidator(64),
+			func(req *http.Request) error {
+				if req.URL.Query().Get("tenant") == "" {
+					return errMissingTenant
+				}
+				return nil
+			},
+		},
+	}, "test-request-validators")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL+"/orders?tenant=a&filter="+strings.Repeat("x", 64))
+	var invalid *RequestValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, "max_url_length", invalid.Rule)
+	assert.Equal(t, http.MethodGet, invalid.Method)
+	assert.Contains(t, invalid.URL, "/orders?tenant=a")
+
+	_, err = client.Get(context.Background(), server.URL+"/orders")
+	require.ErrorAs(t, err, &invalid)
+	assert.Empty(t, invalid.Rule)
+	assert.ErrorIs(t, err, errMissingTenant)
+	assert.True(t, IsRequestValidationError(err))
+
+	// Invalid requests never reach the server
+	assert.Empty(t, server.RequestLog)
+
+	resp, err := client.Get(context.Background(), server.URL+"/orders?tenant=a")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Len(t, server.RequestLog, 1)
+}
+
+func TestRequestValidators_SeeMiddlewareHeaders(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	client := New(Config{
+		Middlewares: []Middleware{MiddlewareFunc(
+			func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+				req.Header.Set("Authorization", "Bearer token")
+				return next(req)
+			})},
+		RequestValidators: []func(*http.Request) error{RequiredHeadersValidator("", "Authorization")},
+	}, "test-request-validators-middleware")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+}
+
+func TestRequestValidators_BuiltIns(t *testing.T) {
+	t.Parallel()
+	request := func(url string, body string, headers ...string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		return req
+	}
+	tests := []struct {
+		name      string
+		validator func(*http.Request) error
+		req       *http.Request
+		rule      string
+	}{
+		{"url ok", MaxURLLengthValidator(30), request("http://api.example.com/a", ""), ""},
+		{"url too long", MaxURLLengthValidator(20), request("http://api.example.com/a", ""), "max_url_length"},
+		{"forbidden", ForbiddenHeadersValidator("x-internal-user"), request("http://api.example.com", "", "X-Internal-User", "42"), "forbidden_header"},
+		{"not forbidden", ForbiddenHeadersValidator("X-Internal-User"), request("http://api.example.com", ""), ""},
+		{"required missing", RequiredHeadersValidator("API.example.com", "Authorization"), request("http://api.example.com", ""), "required_header"},
+		{"required present", RequiredHeadersValidator("api.example.com", "Authorization"), request("http://api.example.com", "", "Authorization", "Bearer t"), ""},
+		{"other host", RequiredHeadersValidator("api.example.com", "Authorization"), request("http://cdn.example.com", ""), ""},
+		{"body ok", MaxBodySizeValidator(5), request("http://api.example.com", "12345"), ""},
+		{"body too large", MaxBodySizeValidator(4), request("http://api.example.com", "12345"), "max_body_size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.validator(tt.req)
+			if tt.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var invalid *RequestValidationError
+			require.ErrorAs(t, err, &invalid)
+			assert.Equal(t, tt.rule, invalid.Rule)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Response wraps *http.Response with helpers for reading the body.
+// The body is read at most once: Bytes, String, JSON, XML and Decode cache it, and every
+// reading helper closes the underlying body, so callers don't need to close it themselves.
+type Response struct {
+	*http.Response
+
+	decoders *DecoderRegistry
+	mu       sync.Mutex
+	body     []byte
+	read     bool
+	readErr  error
+}
+
+// newResponse wraps a raw response decoded by the registry (nil uses DefaultDecoders).
+func newResponse(resp *http.Response, decoders *DecoderRegistry) *Response {
+	if decoders == nil {
+		decoders = DefaultDecoders
+	}
+	return &Response{Response: resp, decoders: decoders}
+}
+
+// DoTyped executes an HTTP request and returns the response as *Response.
+func (c *Client) DoTyped(req *http.Request) (*Response, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+		return nil, err
+	}
+	return newResponse(resp, c.config.Decoders), nil
+}
+
+// Execute builds a request with the specified method, URL, body and options,
+// executes it and returns the response as *Response.
+func (c *Client) Execute(
+	ctx context.Context, method, url string, body io.Reader, opts ...RequestOption,
+) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	applyOptions(req, opts)
+	return c.DoTyped(req)
+}
+
+// IsSuccess reports whether the response has a 2xx status code.
+func (r *Response) IsSuccess() bool {
+	return r.StatusCode >= http.StatusOK && r.StatusCode < http.StatusMultipleChoices
+}
+
+// Bytes reads the whole response body and closes it. Repeated calls return the cached body.
+func (r *Response) Bytes() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.read {
+		r.read = true
+		if r.Response.Body != nil {
+			r.body, r.readErr = io.ReadAll(r.Response.Body)
+			_ = r.Response.Body.Close()
+		}
+	}
+	return r.body, r.readErr
+}
+
+// String returns the response body as a string.
+func (r *Response) String() (string, error) {
+	data, err := r.Bytes()
+	return string(data), err
+}
+
+// JSON decodes the JSON response body into v.
+func (r *Response) JSON(v interface{}) error {
+	data, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	return nil
+}
+
+// XML decodes the XML response body into v.
+func (r *Response) XML(v interface{}) error {
+	data, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode XML response: %w", err)
+	}
+	return nil
+}
+
+// Decode decodes the response body into v with the decoder registered for its Content-Type
+// in Config.Decoders, e.g. JSON, XML, MessagePack or Protocol Buffers.
+func (r *Response) Decode(v any) error {
+	data, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	return r.decoders.Decode(r.Header.Get("Content-Type"), data, v)
+}
+
+// SaveTo streams the response body into w and closes it, returning the number of bytes written.
+// If the body was already read, the cached body is written instead.
+func (r *Response) SaveTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.read {
+		if r.readErr != nil {
+			return 0, r.readErr
+		}
+		return io.Copy(w, bytes.NewReader(r.body))
+	}
+
+	r.read = true
+	if r.Response.Body == nil {
+		return 0, nil
+	}
+	defer r.Response.Body.Close()
+
+	n, err := io.Copy(w, r.Response.Body)
+	if err != nil {
+		r.readErr = err
+	}
+	return n, err
+}
+
+// Close drains and closes the response body if it hasn't been read.
+func (r *Response) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.read {
+		return nil
+	}
+	r.read = true
+	drainAndClose(r.Response.Body)
+	return nil
+}
@@ -0,0 +1,49 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+)
+
+// errNoInterceptedResponse is returned when an interceptor drops the response without an error.
+var errNoInterceptedResponse = errors.New("response interceptor returned no response")
+
+// ResponseInterceptor processes the final response of every request before it is returned
+// to the caller, e.g. to normalize headers, unwrap envelopes or map error responses to
+// domain errors. Interceptors run after middlewares, retries and fallback, in the order of
+// Config.ResponseInterceptors, and are skipped for requests that failed with an error.
+// Returning an error discards the response: its body is closed and the caller gets the error.
+type ResponseInterceptor interface {
+	Process(resp *http.Response, req *http.Request) (*http.Response, error)
+}
+
+// ResponseInterceptorFunc adapts an ordinary function to the ResponseInterceptor interface.
+type ResponseInterceptorFunc func(resp *http.Response, req *http.Request) (*http.Response, error)
+
+// Process implements the ResponseInterceptor interface.
+func (f ResponseInterceptorFunc) Process(resp *http.Response, req *http.Request) (*http.Response, error) {
+	return f(resp, req)
+}
+
+// interceptResponse passes a successful response through the response interceptors.
+func (rt *RoundTripper) interceptResponse(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	for _, interceptor := range rt.config.ResponseInterceptors {
+		next, err := interceptor.Process(resp, req)
+		if err == nil && next == nil {
+			err = errNoInterceptedResponse
+		}
+		if err != nil {
+			closeResponseBody(resp)
+			if next != resp {
+				closeResponseBody(next)
+			}
+			return nil, err
+		}
+		resp = next
+	}
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNotFound = errors.New("not found")
+
+func TestResponseInterceptors_Chain(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"X-Legacy-Request-Id": "42"},
+		Body:       `{"data":{"name":"widget"}}`,
+	})
+	defer server.Close()
+
+	var order []string
+	normalize := ResponseInterceptorFunc(func(resp *http.Response, _ *http.Request) (*http.Response, error) {
+		order = append(order, "normalize")
+		resp.Header.Set("X-Request-Id", resp.Header.Get("X-Legacy-Request-Id"))
+		resp.Header.Del("X-Legacy-Request-Id")
+		return resp, nil
+	})
+	unwrap := ResponseInterceptorFunc(func(resp *http.Response, _ *http.Request) (*http.Response, error) {
+		order = append(order, "unwrap")
+		defer resp.Body.Close()
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(strings.NewReader(string(envelope.Data)))
+		resp.ContentLength = int64(len(envelope.Data))
+		return resp, nil
+	})
+
+	client := New(Config{
+		ResponseInterceptors: []ResponseInterceptor{normalize, unwrap},
+		Middlewares: []Middleware{MiddlewareFunc(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			order = append(order, "middleware")
+			return next(req)
+		})},
+	}, "test-response-interceptors")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "42", resp.Header.Get("X-Request-Id"))
+	assert.Empty(t, resp.Header.Get("X-Legacy-Request-Id"))
+	assert.Equal(t, `{"name":"widget"}`, readBody(t, resp))
+	assert.Equal(t, []string{"middleware", "normalize", "unwrap"}, order)
+}
+
+func TestResponseInterceptors_ErrorMapping(t *testing.T) {
+	t.Parallel()
+	body := &closeTracker{Reader: strings.NewReader("missing")}
+	transport := &mockRoundTripper{responses: []*http.Response{
+		{StatusCode: http.StatusNotFound, Header: make(http.Header), Body: body},
+	}}
+	mapErrors := ResponseInterceptorFunc(func(resp *http.Response, _ *http.Request) (*http.Response, error) {
+		if resp.StatusCode == http.StatusNotFound {
+			return resp, errNotFound
+		}
+		return resp, nil
+	})
+	client := New(Config{
+		Transport:            transport,
+		ResponseInterceptors: []ResponseInterceptor{mapErrors},
+	}, "test-response-interceptor-errors")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), "http://example.com/items/1")
+	assert.ErrorIs(t, err, errNotFound)
+	assert.Nil(t, resp)
+	assert.True(t, body.closed)
+}
+
+func TestResponseInterceptors_SkippedOnError(t *testing.T) {
+	t.Parallel()
+	called := false
+	rt := &RoundTripper{config: Config{ResponseInterceptors: []ResponseInterceptor{
+		ResponseInterceptorFunc(func(resp *http.Response, _ *http.Request) (*http.Response, error) {
+			called = true
+			return resp, nil
+		}),
+	}}}
+
+	_, err := rt.interceptResponse(nil, nil, errNotFound)
+	assert.ErrorIs(t, err, errNotFound)
+	assert.False(t, called)
+
+	dropping := &RoundTripper{config: Config{ResponseInterceptors: []ResponseInterceptor{
+		ResponseInterceptorFunc(func(*http.Response, *http.Request) (*http.Response, error) { return nil, nil }),
+	}}}
+	_, err = dropping.interceptResponse(nil, &http.Response{StatusCode: http.StatusOK}, nil)
+	assert.ErrorIs(t, err, errNoInterceptedResponse)
+}
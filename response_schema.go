@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// SchemaValidationMode selects what happens to responses violating their JSON Schema.
+type SchemaValidationMode int
+
+const (
+	// SchemaValidationEnforce fails requests whose response violates its schema with
+	// *SchemaValidationError
+	SchemaValidationEnforce SchemaValidationMode = iota
+	// SchemaValidationReport returns the response and reports the violations to
+	// Config.OnSchemaViolation and the logger
+	SchemaValidationReport
+	// SchemaValidationOff skips validation, e.g. in production
+	SchemaValidationOff
+)
+
+// SchemaValidationError is returned for a response that violates its JSON Schema.
+type SchemaValidationError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Violations lists the values that don't match the schema, sorted by path
+	Violations []SchemaViolation
+}
+
+// Error implements the error interface.
+func (e *SchemaValidationError) Error() string {
+	msg := fmt.Sprintf("response schema validation failed: %s %s: %s", e.Method, e.URL, e.Violations[0])
+	if len(e.Violations) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(e.Violations)-1)
+	}
+	return msg
+}
+
+// IsSchemaValidationError checks if a response violated its JSON Schema.
+func IsSchemaValidationError(err error) bool {
+	var invalid *SchemaValidationError
+	return errors.As(err, &invalid)
+}
+
+// WithResponseSchema validates the JSON response of the request against the schema,
+// replacing the schema registered in Config.ResponseSchemas.
+func WithResponseSchema(schema *JSONSchema) RequestOption {
+	return func(req *http.Request) {
+		setRequestOverride(req, func(o *requestOverrides) {
+			o.responseSchema = schema
+		})
+	}
+}
+
+// responseSchema returns the schema of the request: the one of WithResponseSchema or the one
+// registered for "METHOD path" or "path", where path is the path template or the URL path.
+func (rt *RoundTripper) responseSchema(req *http.Request) *JSONSchema {
+	if overrides := getRequestOverrides(req.Context()); overrides != nil && overrides.responseSchema != nil {
+		return overrides.responseSchema
+	}
+	if len(rt.config.ResponseSchemas) == 0 {
+		return nil
+	}
+	path, ok := PathTemplateFromContext(req.Context())
+	if !ok {
+		path = req.URL.Path
+	}
+	if schema, ok := rt.config.ResponseSchemas[req.Method+" "+path]; ok {
+		return schema
+	}
+	return rt.config.ResponseSchemas[path]
+}
+
+// validateResponseSchema validates successful JSON responses against the schema of the
+// request. The body is buffered and replaced so that the caller can still read it.
+func (rt *RoundTripper) validateResponseSchema(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+	if err != nil || resp == nil || rt.config.SchemaValidation == SchemaValidationOff ||
+		resp.StatusCode < 200 || resp.StatusCode >= 300 || resp.Body == nil || resp.Body == http.NoBody {
+		return resp, err
+	}
+	schema := rt.responseSchema(req)
+	if schema == nil {
+		return resp, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) ||
+		resp.Header.Get("Content-Encoding") != "" {
+		return resp, nil
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read response for schema validation: %w", readErr)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	violations := schema.Validate(body)
+	if len(violations) == 0 {
+		return resp, nil
+	}
+	invalid := &SchemaValidationError{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Violations: violations,
+	}
+	if rt.config.SchemaValidation == SchemaValidationEnforce {
+		return nil, invalid
+	}
+	logEvent(req.Context(), rt.config.Logger, LogLevelWarn, "response violates its schema",
+		"method", req.Method, "url", invalid.URL, "violations", len(violations), "first", violations[0].String())
+	if rt.config.OnSchemaViolation != nil {
+		rt.config.OnSchemaViolation(invalid)
+	}
+	return resp, nil
+}
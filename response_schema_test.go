@@ -0,0 +1,100 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseSchema_Enforce(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       `{"id": "7", "tags": []}`,
+	})
+	defer server.Close()
+
+	client := New(Config{
+		BaseURL:         server.URL,
+		ResponseSchemas: map[string]*JSONSchema{"GET /users/{id}": userSchema},
+	}, "test-response-schema")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), "/users/{id}", WithPathParam("id", 7))
+	var invalid *SchemaValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.True(t, IsSchemaValidationError(err))
+	assert.Equal(t, http.StatusOK, invalid.StatusCode)
+	assert.Equal(t, []SchemaViolation{
+		{Path: "/id", Message: "expected integer, got string"},
+		{Path: "/name", Message: "required property is missing"},
+	}, invalid.Violations)
+	assert.Contains(t, err.Error(), "GET "+server.URL+"/users/7: /id: expected integer, got string (and 1 more)")
+
+	// Other endpoints have no schema
+	resp, err := client.Get(context.Background(), "/users")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// A per-request schema takes precedence
+	resp, err = client.Get(context.Background(), "/users/{id}", WithPathParam("id", 7),
+		WithResponseSchema(MustCompileJSONSchema([]byte(`{"type": "object"}`))))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.JSONEq(t, `{"id": "7", "tags": []}`, string(body))
+}
+
+func TestResponseSchema_ReportAndOff(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/problem+json"},
+		Body:       `[]`,
+	})
+	defer server.Close()
+
+	var reported []*SchemaValidationError
+	report := New(Config{
+		SchemaValidation:  SchemaValidationReport,
+		OnSchemaViolation: func(err *SchemaValidationError) { reported = append(reported, err) },
+	}, "test-response-schema-report")
+	defer report.Close()
+
+	resp, err := report.Get(context.Background(), server.URL, WithResponseSchema(userSchema))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "[]", string(body))
+	require.Len(t, reported, 1)
+	assert.Equal(t, "expected object, got array", reported[0].Violations[0].Message)
+
+	off := New(Config{SchemaValidation: SchemaValidationOff}, "test-response-schema-off")
+	defer off.Close()
+	resp, err = off.Get(context.Background(), server.URL, WithResponseSchema(userSchema))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+}
+
+func TestResponseSchema_SkipsNonJSONAndErrors(t *testing.T) {
+	t.Parallel()
+	text := NewTestServer(TestResponse{StatusCode: http.StatusOK, Headers: map[string]string{"Content-Type": "text/plain"}, Body: "ok"})
+	defer text.Close()
+	failed := NewTestServer(TestResponse{StatusCode: http.StatusNotFound, Headers: map[string]string{"Content-Type": "application/json"}, Body: `{}`})
+	defer failed.Close()
+
+	client := New(Config{}, "test-response-schema-skip")
+	defer client.Close()
+	for _, url := range []string{text.URL, failed.URL} {
+		resp, err := client.Get(context.Background(), url, WithResponseSchema(userSchema))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+}
@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeTracker records whether the body was closed.
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestExecute_ReturnsTypedResponse(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       `{"name":"widget","count":3}`,
+	})
+	defer server.Close()
+
+	client := New(Config{}, "test-typed-response")
+	defer client.Close()
+
+	resp, err := client.Execute(context.Background(), http.MethodGet, server.URL, nil, WithAccept("application/json"))
+	require.NoError(t, err)
+
+	assert.True(t, resp.IsSuccess())
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var payload struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	require.NoError(t, resp.JSON(&payload))
+	assert.Equal(t, "widget", payload.Name)
+	assert.Equal(t, 3, payload.Count)
+
+	// The body is cached after the first read
+	text, err := resp.String()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"widget","count":3}`, text)
+}
+
+func TestDoTyped_NonSuccessStatus(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusNotFound, Body: "missing"})
+	defer server.Close()
+
+	client := New(Config{}, "test-typed-not-found")
+	defer client.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.DoTyped(req)
+	require.NoError(t, err)
+	assert.False(t, resp.IsSuccess())
+
+	body, err := resp.Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, "missing", string(body))
+}
+
+func TestResponse_XML(t *testing.T) {
+	t.Parallel()
+	resp := newResponse(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString(`<item><id>7</id></item>`)),
+	}, nil)
+
+	var item struct {
+		ID int `xml:"id"`
+	}
+	require.NoError(t, resp.XML(&item))
+	assert.Equal(t, 7, item.ID)
+
+	assert.Error(t, newResponse(&http.Response{
+		Body: io.NopCloser(bytes.NewBufferString("not xml")),
+	}, nil).XML(&item))
+}
+
+func TestResponse_SaveToClosesBody(t *testing.T) {
+	t.Parallel()
+	body := &closeTracker{Reader: bytes.NewBufferString("file contents")}
+	resp := newResponse(&http.Response{StatusCode: http.StatusOK, Body: body}, nil)
+
+	var buf bytes.Buffer
+	n, err := resp.SaveTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), n)
+	assert.Equal(t, "file contents", buf.String())
+	assert.True(t, body.closed)
+}
+
+func TestResponse_SaveToAfterBytes(t *testing.T) {
+	t.Parallel()
+	resp := newResponse(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewBufferString("cached")),
+	}, nil)
+
+	_, err := resp.Bytes()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = resp.SaveTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "cached", buf.String())
+}
+
+func TestResponse_Close(t *testing.T) {
+	t.Parallel()
+	body := &closeTracker{Reader: bytes.NewBufferString("unused")}
+	resp := newResponse(&http.Response{StatusCode: http.StatusNoContent, Body: body}, nil)
+
+	require.NoError(t, resp.Close())
+	assert.True(t, body.closed)
+	assert.True(t, resp.IsSuccess())
+}
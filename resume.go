@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// resumableBody is the body of a GET response that resumes with a Range request from the
+// last received byte when reading fails, see Config.ResumeDownloads.
+type resumableBody struct {
+	rt  *RoundTripper
+	req *http.Request   // the request of the response, before the client's changes
+	ctx context.Context // the context of the request with its timeout
+	io.ReadCloser
+	offset    int64
+	total     int64 // Content-Length of the response, -1 if unknown
+	validator string
+	resumes   int
+}
+
+// resumeResponseBody makes the body of a complete GET response resumable when
+// Config.ResumeDownloads is set and the server supports ranges: the response has
+// Accept-Ranges: bytes, a strong ETag or Last-Modified and no content coding.
+func (rt *RoundTripper) resumeResponseBody(ctx context.Context, req *http.Request, resp *http.Response, err error) {
+	if !rt.config.ResumeDownloads || err != nil || resp == nil || resp.Body == nil || resp.Body == http.NoBody ||
+		req.Method != http.MethodGet || req.Header.Get("Range") != "" || resp.StatusCode != http.StatusOK ||
+		resp.Uncompressed || resp.Header.Get("Content-Encoding") != "" ||
+		!strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes") {
+		return
+	}
+	validator := rangeValidator(resp)
+	if validator == "" {
+		return
+	}
+	resp.Body = &resumableBody{
+		rt:         rt,
+		req:        req,
+		ctx:        ctx,
+		ReadCloser: resp.Body,
+		total:      resp.ContentLength,
+		validator:  validator,
+	}
+}
+
+// Read reads the body, resuming the transfer after connection failures.
+func (b *resumableBody) Read(p []byte) (int, error) {
+	for {
+		n, err := b.ReadCloser.Read(p)
+		b.offset += int64(n)
+		if err == io.EOF && b.total >= 0 && b.offset < b.total {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == nil || err == io.EOF || !b.resumable(err) {
+			return n, err
+		}
+
+		if resumeErr := b.resume(); resumeErr != nil {
+			return n, fmt.Errorf("%w; resuming the download failed: %w", err, resumeErr)
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// resumable reports whether the read error may be fixed by resuming. Reads ended by the
+// cancellation or the timeout of the request are final.
+func (b *resumableBody) resumable(err error) bool {
+	if b.ctx.Err() != nil {
+		return false
+	}
+	// The timeout may fail the read just before the context reports it
+	if deadline, ok := b.ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return false
+	}
+	return b.resumes < b.rt.config.MaxDownloadResumes && !errors.Is(err, http.ErrBodyReadAfterClose)
+}
+
+// resume replaces the failed body with the remaining bytes. If-Range makes the server send
+// the whole changed resource instead, which is rejected.
+func (b *resumableBody) resume() error {
+	b.resumes++
+	_ = b.ReadCloser.Close()
+
+	req := b.req.Clone(b.req.Context())
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", b.offset))
+	req.Header.Set("If-Range", b.validator)
+	resp, err := b.rt.roundTrip(req, nil)
+	if err != nil {
+		return err
+	}
+
+	// The whole or wrong representation may be large: it's closed without being read
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		_ = resp.Body.Close()
+		return errResourceChanged
+	case resp.StatusCode != http.StatusPartialContent:
+		discardBody(resp, b.rt.config.RetryDrainLimit)
+		return NewHTTPError(resp, req)
+	case contentRangeStart(resp) != b.offset || resp.Header.Get("Content-Encoding") != "":
+		_ = resp.Body.Close()
+		return fmt.Errorf("unexpected Content-Range %q, expected start %d",
+			resp.Header.Get("Content-Range"), b.offset)
+	}
+	b.ReadCloser = resp.Body
+	return nil
+}
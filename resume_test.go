@@ -0,0 +1,148 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyRangeServer serves content with range support. The first cuts responses break the
+// connection after half of their bytes. Request i gets the ETag etags[i], "v1" by default.
+func newFlakyRangeServer(t *testing.T, content []byte, cuts int32, etags ...string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var requests int32
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		ranges = append(ranges, r.Header.Get("Range"))
+		etag := `"v1"`
+		if int(n) <= len(etags) {
+			etag = etags[n-1]
+		}
+		w.Header().Set("ETag", etag)
+		if n > cuts {
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			return
+		}
+
+		start := 0
+		if r.Header.Get("Range") != "" {
+			start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.Header.Get("Range"), "bytes="), "-"))
+			w.Header().Set("Content-Range", "bytes "+strconv.Itoa(start)+"-"+strconv.Itoa(len(content)-1)+"/"+strconv.Itoa(len(content)))
+		}
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)-start))
+		if start > 0 {
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		_, _ = w.Write(content[start : start+(len(content)-start)/2])
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		_ = conn.Close()
+	}))
+	t.Cleanup(server.Close)
+	return server, &ranges
+}
+
+func TestResumeDownloads_ResumesFromLastByte(t *testing.T) {
+	t.Parallel()
+	content := []byte(strings.Repeat("0123456789", 10000))
+	server, ranges := newFlakyRangeServer(t, content, 2)
+
+	client := New(Config{ResumeDownloads: true}, "test-resume-downloads")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, content, body)
+	assert.Equal(t, []string{"", "bytes=50000-", "bytes=75000-"}, *ranges)
+}
+
+func TestResumeDownloads_ChangedResource(t *testing.T) {
+	t.Parallel()
+	content := []byte(strings.Repeat("x", 10000))
+	server, _ := newFlakyRangeServer(t, content, 1, `"v1"`, `"v2"`)
+
+	client := New(Config{ResumeDownloads: true}, "test-resume-downloads-changed")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.ErrorIs(t, err, errResourceChanged)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestResumeDownloads_Limits(t *testing.T) {
+	t.Parallel()
+	content := []byte(strings.Repeat("y", 10000))
+
+	// Without the flag the failure reaches the caller
+	server, ranges := newFlakyRangeServer(t, content, 1)
+	client := New(Config{}, "test-resume-downloads-off")
+	defer client.Close()
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, []string{""}, *ranges)
+
+	// Resumes stop at MaxDownloadResumes
+	server, ranges = newFlakyRangeServer(t, content, 5)
+	limited := New(Config{ResumeDownloads: true, MaxDownloadResumes: 2}, "test-resume-downloads-limit")
+	defer limited.Close()
+	resp, err = limited.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Len(t, *ranges, 3)
+}
+
+func TestResumeDownloads_RequestTimeout(t *testing.T) {
+	t.Parallel()
+	var ranges atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			ranges.Add(1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", "10")
+		_, _ = w.Write([]byte("01234"))
+		w.(http.Flusher).Flush()
+		// The rest of the body never comes
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := New(Config{ResumeDownloads: true}, "test-resume-downloads-timeout")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL, WithRequestTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	_, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	// The request's own timeout ends the download instead of starting a Range request
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "resuming the download failed")
+	assert.Zero(t, ranges.Load())
+}
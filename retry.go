@@ -12,6 +12,12 @@ const (
 	RetryReasonTimeout    = "timeout"
 	RetryReasonNetwork    = "net"
 	RetryReasonPreConnect = "pre-connect"
+	RetryReasonCustom     = "custom"
+	// RetryReasonInProgress retries 409 and 425 responses, see RetryConfig.RetryInProgress
+	RetryReasonInProgress = "in-progress"
+	// RetryReasonChecksum retries bodies that don't match WithExpectedChecksum, see
+	// RetryConfig.RetryChecksumMismatch
+	RetryReasonChecksum = "checksum"
 )
 
 // preConnectErrorStrings contains error substrings indicating TCP-level failures
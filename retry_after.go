@@ -0,0 +1,100 @@
+package httpclient
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfterDecision tells the client how to handle a Retry-After delay longer than
+// RetryConfig.MaxRetryAfter.
+type RetryAfterDecision int
+
+const (
+	// RetryAfterCap waits MaxRetryAfter instead of the requested delay.
+	RetryAfterCap RetryAfterDecision = iota
+	// RetryAfterGiveUp stops retrying and returns the response that asked for the delay.
+	RetryAfterGiveUp
+	// RetryAfterHonor waits the whole requested delay.
+	RetryAfterHonor
+)
+
+// maxRetryAfterSeconds is the longest delta-seconds value representable as a time.Duration.
+const maxRetryAfterSeconds = float64(math.MaxInt64) / float64(time.Second)
+
+// retryAfterDateFormats are the date layouts accepted besides the RFC 7231 ones parsed by
+// http.ParseTime: zone names other than GMT and numeric zones sent by some servers.
+var retryAfterDateFormats = []string{time.RFC1123, time.RFC1123Z}
+
+// parseRetryAfterAt parses a Retry-After value given in seconds, optionally fractional
+// (e.g. "1.5"), or as an HTTP date in any RFC 7231 format. Dates are relative to now.
+// Invalid values and dates in the past return 0.
+func parseRetryAfterAt(retryAfter string, now time.Time) time.Duration {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if retryAfter == "" {
+		return 0
+	}
+
+	if isRetryAfterSeconds(retryAfter) {
+		seconds, err := strconv.ParseFloat(retryAfter, 64)
+		if err != nil || seconds <= 0 {
+			return 0
+		}
+		if seconds >= maxRetryAfterSeconds {
+			return time.Duration(math.MaxInt64)
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	t, err := http.ParseTime(retryAfter)
+	for _, layout := range retryAfterDateFormats {
+		if err == nil {
+			break
+		}
+		t, err = time.Parse(layout, retryAfter)
+	}
+	if err != nil {
+		return 0
+	}
+	return max(t.Sub(now), 0)
+}
+
+// isRetryAfterSeconds checks if a Retry-After value is delta-seconds: digits with at most
+// one decimal point.
+func isRetryAfterSeconds(value string) bool {
+	digits, dot := false, false
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits
+}
+
+// limitRetryAfter applies MaxRetryAfter to a delay requested by the response. It returns
+// false when the retry should be abandoned.
+func (rc RetryConfig) limitRetryAfter(resp *http.Response, delay time.Duration) (time.Duration, bool) {
+	if rc.MaxRetryAfter <= 0 || delay <= rc.MaxRetryAfter {
+		return delay, true
+	}
+
+	decision := RetryAfterCap
+	if rc.OnLongRetryAfter != nil {
+		decision = rc.OnLongRetryAfter(resp, delay)
+	}
+	switch decision {
+	case RetryAfterGiveUp:
+		return 0, false
+	case RetryAfterHonor:
+		return delay, true
+	default:
+		return rc.MaxRetryAfter, true
+	}
+}
@@ -0,0 +1,140 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfterAt(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{" 3 ", 3 * time.Second},
+		{"1.5", 1500 * time.Millisecond},
+		{".25", 250 * time.Millisecond},
+		{"0", 0},
+		{"-5", 0},
+		{"1e3", 0},
+		{"NaN", 0},
+		{"1.2.3", 0},
+		{"99999999999999999999", time.Duration(1<<63 - 1)},
+		{"Fri, 01 Mar 2024 12:00:30 GMT", 30 * time.Second},
+		{"Friday, 01-Mar-24 12:01:00 GMT", time.Minute},
+		{"Fri Mar  1 12:00:10 2024", 10 * time.Second},
+		{"Fri, 01 Mar 2024 15:00:05 +0300", 5 * time.Second},
+		{"Fri, 01 Mar 2024 11:00:00 GMT", 0},
+		{"tomorrow", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseRetryAfterAt(tt.value, now), "Retry-After %q", tt.value)
+	}
+}
+
+func TestRetryConfig_LimitRetryAfter(t *testing.T) {
+	t.Parallel()
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests}
+	config := RetryConfig{}.withDefaults()
+
+	delay, ok := config.limitRetryAfter(resp, 5*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, delay)
+
+	delay, ok = config.limitRetryAfter(resp, time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, defaultMaxRetryAfter, delay)
+
+	var asked time.Duration
+	config.OnLongRetryAfter = func(r *http.Response, delay time.Duration) RetryAfterDecision {
+		assert.Same(t, resp, r)
+		asked = delay
+		return RetryAfterHonor
+	}
+	delay, ok = config.limitRetryAfter(resp, time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, delay)
+	assert.Equal(t, time.Hour, asked)
+
+	config.OnLongRetryAfter = func(*http.Response, time.Duration) RetryAfterDecision { return RetryAfterGiveUp }
+	_, ok = config.limitRetryAfter(resp, time.Hour)
+	assert.False(t, ok)
+
+	config.MaxRetryAfter = -1
+	delay, ok = config.limitRetryAfter(resp, time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, delay)
+}
+
+// retryAfterServer answers the first request with 429 and the Retry-After value.
+func retryAfterServer(retryAfter string) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return server, &requests
+}
+
+func TestClient_MaxRetryAfter(t *testing.T) {
+	t.Parallel()
+	server, requests := retryAfterServer("3600")
+	defer server.Close()
+
+	client := New(Config{
+		Timeout:      5 * time.Second,
+		RetryEnabled: true,
+		RetryConfig:  RetryConfig{MaxAttempts: 2, MaxRetryAfter: 20 * time.Millisecond},
+	}, "test-max-retry-after")
+	defer client.Close()
+
+	start := time.Now()
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClient_OnLongRetryAfterGiveUp(t *testing.T) {
+	t.Parallel()
+	server, requests := retryAfterServer("0.5")
+	defer server.Close()
+
+	var asked time.Duration
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			MaxAttempts:   3,
+			MaxRetryAfter: 100 * time.Millisecond,
+			OnLongRetryAfter: func(_ *http.Response, delay time.Duration) RetryAfterDecision {
+				asked = delay
+				return RetryAfterGiveUp
+			},
+		},
+	}, "test-on-long-retry-after")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, 500*time.Millisecond, asked)
+}
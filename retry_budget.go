@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Default retry budget settings.
+	defaultRetryBudgetRatio        = 0.2
+	defaultRetryBudgetMinPerSecond = 10.0
+	defaultRetryBudgetWindow       = 10 * time.Second
+)
+
+// RetryBudgetConfig limits retries to a share of the requests over a sliding window,
+// so an upstream outage doesn't multiply the client traffic.
+type RetryBudgetConfig struct {
+	// Ratio is the share of requests that may be retried (default: 0.2)
+	Ratio float64
+
+	// MinRetriesPerSecond allows retries at low traffic regardless of Ratio (default: 10)
+	MinRetriesPerSecond float64
+
+	// Window is the sliding window requests and retries are counted over (default: 10s)
+	Window time.Duration
+}
+
+// withDefaults applies default values to the retry budget configuration.
+func (rb RetryBudgetConfig) withDefaults() RetryBudgetConfig {
+	if rb.Ratio <= 0 {
+		rb.Ratio = defaultRetryBudgetRatio
+	}
+
+	if rb.MinRetriesPerSecond <= 0 {
+		rb.MinRetriesPerSecond = defaultRetryBudgetMinPerSecond
+	}
+
+	if rb.Window <= 0 {
+		rb.Window = defaultRetryBudgetWindow
+	}
+
+	return rb
+}
+
+// retryBudget counts requests and retries over a rolling window.
+// Requests are recorded as successes and retries as failures of the window.
+type retryBudget struct {
+	ratio      float64
+	minRetries int // retries allowed per window regardless of ratio
+
+	mu     sync.Mutex
+	window *rollingWindow
+}
+
+// newRetryBudget creates a retry budget from the configuration.
+func newRetryBudget(config RetryBudgetConfig) *retryBudget {
+	config = config.withDefaults()
+	return &retryBudget{
+		ratio:      config.Ratio,
+		minRetries: int(config.MinRetriesPerSecond * config.Window.Seconds()),
+		window:     newRollingWindow(config.Window),
+	}
+}
+
+// deposit records a new request.
+func (b *retryBudget) deposit(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.window.record(now, false)
+}
+
+// withdraw records a retry if the budget allows it.
+func (b *retryBudget) withdraw(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	requests, retries := b.window.counts(now)
+	if retries >= b.minRetries && float64(retries) >= b.ratio*float64(requests) {
+		return false
+	}
+	b.window.record(now, true)
+	return true
+}
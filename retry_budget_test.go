@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryBudget_Withdraw(t *testing.T) {
+	t.Parallel()
+	budget := newRetryBudget(RetryBudgetConfig{Ratio: 0.2, MinRetriesPerSecond: 0.2, Window: 10 * time.Second})
+	now := time.Now()
+
+	// Two retries per window are allowed regardless of the traffic
+	assert.True(t, budget.withdraw(now))
+	assert.True(t, budget.withdraw(now))
+	assert.False(t, budget.withdraw(now))
+
+	// Above the minimum, 20% of the requests may be retried
+	for range 20 {
+		budget.deposit(now)
+	}
+	assert.True(t, budget.withdraw(now))
+	assert.True(t, budget.withdraw(now))
+	assert.False(t, budget.withdraw(now))
+
+	// The budget refills as the window slides
+	assert.True(t, budget.withdraw(now.Add(11*time.Second)))
+}
+
+func TestRetryBudgetConfig_Defaults(t *testing.T) {
+	t.Parallel()
+	config := Config{RetryBudgetEnabled: true}.withDefaults()
+	assert.Equal(t, 0.2, config.RetryBudget.Ratio)
+	assert.Equal(t, 10.0, config.RetryBudget.MinRetriesPerSecond)
+	assert.Equal(t, 10*time.Second, config.RetryBudget.Window)
+}
+
+func TestRetryBudget_Exhausted(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		RetryEnabled:         true,
+		RetryConfig:          RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		RetryBudgetEnabled:   true,
+		RetryBudget:          RetryBudgetConfig{MinRetriesPerSecond: 0.2},
+	}, "test-retry-budget")
+	defer client.Close()
+
+	// The first request spends both retries of the window
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	resp, err = client.Get(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	var budgetErr *RetryBudgetExhaustedError
+	require.True(t, errors.As(err, &budgetErr))
+	assert.Equal(t, 1, budgetErr.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, budgetErr.LastStatus)
+
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, MetricRetryBudgetExhausted, map[string]string{
+		"client_name": "test-retry-budget",
+		"method":      http.MethodGet,
+	}))
+}
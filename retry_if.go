@@ -0,0 +1,103 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// maxRetryPeekBytes is the part of the response body inspected by RetryOnJSONField.
+const maxRetryPeekBytes = 64 << 10
+
+// RetryOnErrors returns a RetryConfig.RetryIf predicate matching errors wrapping any of targets.
+func RetryOnErrors(targets ...error) func(resp *http.Response, err error) bool {
+	return func(_ *http.Response, err error) bool {
+		if err == nil {
+			return false
+		}
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RetryOnJSONField returns a RetryConfig.RetryIf predicate matching JSON responses whose
+// field equals one of values, e.g. RetryOnJSONField("status", "retry_later").
+// Nested fields are separated by dots ("error.code"); numbers and booleans are compared
+// in their JSON form. The body is peeked with PeekResponseBody and stays readable.
+func RetryOnJSONField(field string, values ...string) func(resp *http.Response, err error) bool {
+	path := strings.Split(field, ".")
+	return func(resp *http.Response, err error) bool {
+		if err != nil || resp == nil {
+			return false
+		}
+		body, peekErr := PeekResponseBody(resp, maxRetryPeekBytes)
+		if peekErr != nil {
+			return false
+		}
+		value, ok := jsonField(body, path)
+		return ok && slices.Contains(values, value)
+	}
+}
+
+// PeekResponseBody returns up to n leading bytes of the response body and rewinds it,
+// so the body can still be read in full. It is meant for RetryConfig.RetryIf predicates.
+func PeekResponseBody(resp *http.Response, n int64) ([]byte, error) {
+	if resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return nil, nil
+	}
+
+	peeked, err := io.ReadAll(io.LimitReader(resp.Body, n))
+	resp.Body = &peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(peeked), resp.Body),
+		Closer: resp.Body,
+	}
+	return peeked, err
+}
+
+// peekedBody replays the peeked bytes before the rest of the original body.
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// jsonField returns the value at the path of a JSON object as a string.
+func jsonField(body []byte, path []string) (string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", false
+	}
+
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if value, ok = object[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		if v {
+			return "true", true
+		}
+		return "false", true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryIf_JSONField(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			_, _ = w.Write([]byte(`{"status":"retry_later"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    time.Millisecond,
+			RetryIf:     RetryOnJSONField("status", "retry_later"),
+		},
+	}, "test-retry-if-json")
+	defer client.Close()
+
+	resp, body := getRedirectBody(t, client, server.URL)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"status":"ok"}`, body)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// Method limits still apply to custom retries
+	atomic.StoreInt32(&calls, 0)
+	resp, err := client.Post(context.Background(), server.URL, strings.NewReader("{}"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"status":"retry_later"}`, string(data))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// errQuotaRefresh is a transport error retried only through RetryIf.
+var errQuotaRefresh = errors.New("quota refresh in progress")
+
+func TestRetryIf_Errors(t *testing.T) {
+	t.Parallel()
+	transport := &mockRoundTripper{
+		errors:    []error{fmt.Errorf("gateway: %w", errQuotaRefresh), nil},
+		responses: []*http.Response{nil, {StatusCode: http.StatusOK, Body: http.NoBody}},
+	}
+
+	client := New(Config{
+		Transport:    transport,
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			MaxAttempts: 2,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    time.Millisecond,
+			RetryIf:     RetryOnErrors(errQuotaRefresh),
+		},
+	}, "test-retry-if-errors")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), "http://example.com/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 2, transport.callCount)
+
+	assert.False(t, RetryOnErrors(errQuotaRefresh)(nil, errors.New("other")))
+	assert.False(t, RetryOnErrors(errQuotaRefresh)(nil, nil))
+}
+
+func TestPeekResponseBody(t *testing.T) {
+	t.Parallel()
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader("hello world"))}
+
+	peeked, err := PeekResponseBody(resp, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(peeked))
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+	assert.NoError(t, resp.Body.Close())
+
+	peeked, err = PeekResponseBody(&http.Response{Body: http.NoBody}, 5)
+	require.NoError(t, err)
+	assert.Nil(t, peeked)
+}
+
+func TestJSONField(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name  string
+		body  string
+		field string
+		value string
+		found bool
+	}{
+		{name: "string", body: `{"status":"retry_later"}`, field: "status", value: "retry_later", found: true},
+		{name: "nested number", body: `{"error":{"code":1005}}`, field: "error.code", value: "1005", found: true},
+		{name: "bool", body: `{"retry":true}`, field: "retry", value: "true", found: true},
+		{name: "missing", body: `{"status":"ok"}`, field: "error.code"},
+		{name: "object", body: `{"error":{"code":1}}`, field: "error"},
+		{name: "not an object", body: `["retry_later"]`, field: "status"},
+		{name: "invalid", body: `<html>`, field: "status"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			value, found := jsonField([]byte(tc.body), strings.Split(tc.field, "."))
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
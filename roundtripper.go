@@ -1,7 +1,6 @@
 package httpclient
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -14,7 +13,10 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -41,73 +43,261 @@ type retryContext struct {
 	ctx            context.Context
 	originalReq    *http.Request
 	originalBody   []byte
-	originalLength int64 // Store original ContentLength
+	bodyBuffer     *bodyBuffer                   // Pooled buffer holding originalBody
+	originalLength int64                         // Store original ContentLength
+	getBody        func() (io.ReadCloser, error) // Body factory used instead of buffering
+	config         Config                        // Configuration effective for this request
 	host           string
 	path           string // Request path for metrics
 	span           trace.Span
-	startTime      time.Time
+	startTime      time.Time // Start of the request, measured with Config.Clock
 	maxAttempts    int
+	attempts       int    // Attempts made so far
+	retryReason    string // Reason of the upcoming retry
+	// budgetExhausted is set when a retry was denied by the retry budget
+	budgetExhausted bool
+	// noExpect is set when the server rejected Expect: 100-continue with 417 Expectation Failed
+	noExpect bool
+	// checksumVerified is set when the attempt verified WithExpectedChecksum on a buffered body
+	checksumVerified bool
+	// log collects the canonical log line, nil when it's disabled
+	log *requestLog
+}
+
+// elapsed returns the time since the start of the request, measured with Config.Clock.
+func (rc *retryContext) elapsed() time.Duration {
+	return clockOrDefault(rc.config.Clock).Now().Sub(rc.startTime)
 }
 
 // RoundTripper implements http.RoundTripper with automatic metrics and retry.
 type RoundTripper struct {
-	base    http.RoundTripper
-	config  Config
-	metrics *Metrics
-	tracer  *Tracer
+	base     http.RoundTripper
+	config   Config
+	metrics  *Metrics
+	tracer   *Tracer
+	inflight *inflightGroup // set when Config.DeduplicateInflight is enabled
+	queue    *requestQueue  // set when Config.MaxInflight is positive
+	failover *failoverGroup // set when Config.Failover has targets
+	balancer *loadBalancer  // set when Config.LoadBalancer has a resolver
+	// retryBudget is set when Config.RetryBudgetEnabled is true
+	retryBudget *retryBudget
+	stats       *clientStats    // statistics of Client.GetMetrics
+	drain       *drainTracker   // set by New to reject requests after Close
+	events      *eventBus       // handlers subscribed with Client.OnEvent
+	latency     *latencyTracker // attempt latency per host for RetryConfig.TimeBudget
+	slo         *sloTracker     // set when Config.SLOs has objectives
+	// authRejections is set when Config.AuthRejectionCooldown is positive
+	authRejections *authRejections
 }
 
 // RoundTrip executes an HTTP request with automatic metrics and retry.
 func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !rt.drain.begin() {
+		return nil, &ClientClosedError{Method: req.Method, URL: req.URL.String()}
+	}
+	req = rt.applyHeaders(req)
+	req = rt.applyRequestLabels(req)
 	ctx, span := rt.setupTracing(req)
 	if span != nil {
 		defer span.End()
 	}
-	req = req.WithContext(ctx)
+	if ctx != req.Context() {
+		req = req.WithContext(ctx)
+	}
+	if span != nil {
+		req = rt.injectTraceContext(req)
+	}
+
+	var resp *http.Response
+	var err error
+	if len(rt.config.Middlewares) == 0 {
+		resp, err = rt.dedupRoundTrip(req, span)
+	} else {
+		resp, err = chainMiddlewares(rt.config.Middlewares, func(r *http.Request) (*http.Response, error) {
+			return rt.dedupRoundTrip(r, span)
+		})(req)
+	}
+	resp, err = rt.interceptResponse(req, resp, err)
+	resp, err = rt.validateResponseSchema(req, resp, err)
+	rt.finishSpan(span, resp, err)
+	return rt.drain.track(resp, err), classifyRequestError(req, err)
+}
+
+// CloseIdleConnections closes idle connections of the base transport.
+func (rt *RoundTripper) CloseIdleConnections() {
+	closeIdleConnections(rt.base)
+}
+
+// dedupRoundTrip collapses concurrent identical requests when deduplication is enabled.
+func (rt *RoundTripper) dedupRoundTrip(req *http.Request, span trace.Span) (*http.Response, error) {
+	// Reject invalid requests before any attempt, after middlewares have set their headers
+	if len(rt.config.RequestValidators) > 0 {
+		if err := rt.validateRequest(req); err != nil {
+			return nil, err
+		}
+	}
+	if checksum := requestChecksum(req); checksum != nil && checksum.err != nil {
+		return nil, checksum.err
+	}
+	if rt.authRejections != nil {
+		if err := rt.authRejections.check(req, rt.clock().Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp *http.Response
+	var err error
+	if rt.inflight == nil || !isDeduplicable(req) {
+		resp, err = rt.roundTrip(req, span)
+	} else {
+		resp, err = rt.inflight.do(req, func(shared *http.Request) (*http.Response, error) {
+			return rt.roundTrip(shared, span)
+		})
+	}
+	if rt.authRejections != nil && rt.authRejections.record(req, resp, rt.clock().Now()) {
+		logEvent(req.Context(), rt.config.Logger, LogLevelWarn, "credentials rejected, pausing requests",
+			"method", req.Method, "url", req.URL.String(), "status", resp.StatusCode,
+			"cooldown", rt.config.AuthRejectionCooldown)
+	}
+	return resp, err
+}
+
+// roundTrip executes the request with metrics and retry once all middlewares have run.
+func (rt *RoundTripper) roundTrip(req *http.Request, span trace.Span) (*http.Response, error) {
+	req = rt.sampleMetrics(req)
+	original := req
+	ctx := req.Context()
+	if rt.events.active() {
+		rt.events.emit(RequestStarted{Request: req, Time: rt.clock().Now()})
+	}
 	host := getHost(req.URL)
-	path := getPath(req.URL, rt.config.IncludePathInMetrics)
+	path := rt.metricPath(req)
+	config := rt.requestConfig(req)
+
+	// Apply per-request timeout override
+	var cancel context.CancelFunc
+	if overrides := getRequestOverrides(ctx); overrides != nil && overrides.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, overrides.timeout)
+		req = req.WithContext(ctx)
+	}
+	req, log := rt.withRequestLog(req)
+	ctx = req.Context()
+
+	if err := validateDeadline(ctx, config, validatedAttempts(config.RetryEnabled, config.RetryConfig)); err != nil {
+		if cancel != nil {
+			cancel()
+		}
+		return nil, err
+	}
+
+	// Wait for a slot when the number of concurrent requests is limited
+	if rt.queue != nil {
+		if err := rt.queue.acquire(ctx, requestPriority(ctx)); err != nil {
+			if cancel != nil {
+				cancel()
+			}
+			return nil, err
+		}
+		defer rt.queue.release()
+	}
 
 	// Manage active request metrics
 	rt.metrics.IncrementInflight(ctx, req.Method, host, path)
 	defer rt.metrics.DecrementInflight(ctx, req.Method, host, path)
 
+	req, err := compressRequestBody(req, config)
+	if err != nil {
+		if cancel != nil {
+			cancel()
+		}
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+
+	req, decode := prepareAcceptEncoding(req, config)
+
 	// Record request size
 	requestSize := getRequestSize(req)
 	rt.metrics.RecordRequestSize(ctx, requestSize, req.Method, host, path)
 
 	// Prepare request body for retry
-	originalBody, err := rt.prepareRequestBody(req)
+	body, err := rt.prepareRequestBody(req, config)
 	if err != nil {
+		if cancel != nil {
+			cancel()
+		}
 		return nil, fmt.Errorf("failed to read request body: %w", err)
 	}
 
-	// Execute retry loop
+	maxAttempts := getMaxAttempts(config)
+	if !body.replayable {
+		// The body can't be sent twice, so the request gets exactly one attempt
+		maxAttempts = 1
+	}
+
+	// Execute retry loop. retryCtx does not escape and stays on the stack
 	retryCtx := &retryContext{
 		ctx:            ctx,
 		originalReq:    req,
-		originalBody:   originalBody,
+		originalBody:   body.data,
+		bodyBuffer:     body.buffer,
 		originalLength: req.ContentLength, // Store original ContentLength
+		getBody:        body.getBody,
+		config:         config,
 		host:           host,
 		path:           path,
 		span:           span,
-		startTime:      time.Now(),
-		maxAttempts:    rt.getMaxAttempts(),
+		startTime:      rt.clock().Now(),
+		maxAttempts:    maxAttempts,
+		log:            log,
+	}
+
+	if rt.retryBudget != nil {
+		rt.retryBudget.deposit(rt.clock().Now())
+	}
+
+	resp, err := rt.executeWithRetry(retryCtx)
+	retryCtx.releaseBody()
+	resp, err = applyFallback(req, resp, err, config)
+	notifyRequestFinished(retryCtx, resp, err)
+	if rt.events.active() {
+		rt.events.emit(RequestFinished{
+			Request:    req,
+			Attempts:   retryCtx.attempts,
+			StatusCode: statusCode(resp),
+			Err:        err,
+			Elapsed:    retryCtx.elapsed(),
+		})
 	}
-
-	return rt.executeWithRetry(retryCtx)
+	rt.stats.recordRequest(req.Method, host, resp, err, retryCtx.elapsed())
+	if rt.slo != nil && !lostRace(ctx) {
+		rt.slo.record(rt.metrics, rt.clock().Now(), req, host, resp, err, retryCtx.elapsed())
+	}
+	rt.logRequest(retryCtx, requestSize, resp, err)
+	rt.resumeResponseBody(ctx, original, resp, err)
+	if decode {
+		decodeResponseBody(resp, config)
+	}
+	resp = verifyChecksum(req, resp, retryCtx.checksumVerified)
+	rt.limitResponseBody(resp)
+	if cancel != nil {
+		// The request timeout also covers reading the response body
+		resp = rt.wrapResponseBody(resp, err, cancel)
+	}
+	return resp, err
 }
 
-// calculateRetryDelay calculates the delay before the next attempt.
-func (rt *RoundTripper) calculateRetryDelay(attempt int, resp *http.Response) time.Duration {
-	config := rt.config.RetryConfig
-
+// calculateRetryDelay calculates the delay before the next attempt. It returns false when
+// the Retry-After delay exceeds MaxRetryAfter and OnLongRetryAfter gives up.
+func (rt *RoundTripper) calculateRetryDelay(
+	config RetryConfig, attempt int, resp *http.Response,
+) (time.Duration, bool) {
 	// Check Retry-After header
 	if delay := rt.parseRetryAfterHeader(config, resp); delay > 0 {
-		return delay
+		return config.limitRetryAfter(resp, delay)
 	}
 
 	// Use exponential backoff with full jitter
-	return CalculateBackoffDelay(attempt, config.BaseDelay, config.MaxDelay, config.Jitter)
+	return CalculateBackoffDelay(attempt, config.BaseDelay, config.MaxDelay, config.Jitter), true
 }
 
 // parseRetryAfterHeader parses the Retry-After header.
@@ -116,27 +306,15 @@ func (rt *RoundTripper) parseRetryAfterHeader(config RetryConfig, resp *http.Res
 		return 0
 	}
 
-	retryAfter := resp.Header.Get("Retry-After")
-	if retryAfter == "" {
-		return 0
-	}
-
-	// Try to parse as number of seconds
-	if seconds, err := strconv.Atoi(retryAfter); err == nil {
-		return time.Duration(seconds) * time.Second
-	}
-
-	// Try to parse as date
-	if t, err := time.Parse(time.RFC1123, retryAfter); err == nil {
-		return time.Until(t)
-	}
-
-	return 0
+	return parseRetryAfterAt(resp.Header.Get("Retry-After"), rt.clock().Now())
 }
 
 // getRetryReasonWithConfig is similar to getRetryReason, but uses status policy from RetryConfig.
 func getRetryReasonWithConfig(cfg RetryConfig, err error, status int) string {
 	if err != nil {
+		if cfg.RetryChecksumMismatch && IsChecksumMismatchError(err) {
+			return RetryReasonChecksum
+		}
 		if isPreConnectError(err) {
 			return RetryReasonPreConnect
 		}
@@ -159,11 +337,23 @@ func getRetryReasonWithConfig(cfg RetryConfig, err error, status int) string {
 // doTransport executes the actual HTTP request, optionally through CircuitBreaker.
 func (rt *RoundTripper) doTransport(req *http.Request) (*http.Response, error) {
 	if rt.config.CircuitBreakerEnable && rt.config.CircuitBreaker != nil {
-		return rt.config.CircuitBreaker.Execute(func() (*http.Response, error) {
-			return rt.base.RoundTrip(req)
+		resp, err := rt.config.CircuitBreaker.Execute(func() (*http.Response, error) {
+			return rt.sendAttempt(req)
 		})
+		rt.recordCircuitBreaker(req, err)
+		return resp, err
 	}
-	return rt.base.RoundTrip(req)
+	return rt.sendAttempt(req)
+}
+
+// recordCircuitBreaker exports the breaker state seen by the request and counts short-circuited requests.
+func (rt *RoundTripper) recordCircuitBreaker(req *http.Request, err error) {
+	ctx := req.Context()
+	host := getHost(req.URL)
+	if errors.Is(err, ErrCircuitBreakerOpen) {
+		rt.metrics.RecordShortCircuit(ctx, req.Method, host)
+	}
+	rt.metrics.SetCircuitBreakerState(ctx, host, rt.config.CircuitBreaker.State())
 }
 
 // shouldRetryAttempt makes a decision about retrying an attempt and returns the reason.
@@ -180,47 +370,81 @@ func shouldRetryAttempt(
 	}
 
 	// By status — use policy from RetryConfig
-	if err == nil && !cfg.RetryConfig.isStatusRetryable(status) {
+	inProgress := err == nil && cfg.RetryConfig.isInProgressRetryable(req, status)
+	if err == nil && !inProgress && !cfg.RetryConfig.isStatusRetryable(status) {
 		return false, ""
 	}
 
-	if attempt >= maxAttempts {
+	if !canRetryAttempt(cfg, req, attempt, maxAttempts, err, deadline) {
 		return false, ""
 	}
+	if inProgress {
+		return true, RetryReasonInProgress
+	}
 
-	// For pre-connect errors (connection refused, reset, etc.) retry is safe
-	// for any HTTP method because the request was never sent to the server.
-	if !isPreConnectError(err) && !cfg.RetryConfig.isRequestRetryable(req) {
+	reason := getRetryReasonWithConfig(cfg.RetryConfig, err, status)
+	if reason == "" {
 		return false, ""
 	}
+	return true, reason
+}
 
-	if !deadline.IsZero() && time.Until(deadline) <= 0 {
-		return false, ""
+// shouldRetryResult extends shouldRetryAttempt with the RetryConfig.RetryIf predicate,
+// which retries results the default policy doesn't, e.g. a 200 with an error in the body.
+func shouldRetryResult(
+	cfg Config, req *http.Request, attempt, maxAttempts int, resp *http.Response, err error, deadline time.Time,
+) (bool, string) {
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
 	}
 
-	reason := getRetryReasonWithConfig(cfg.RetryConfig, err, status)
-	if reason == "" {
+	if retry, reason := shouldRetryAttempt(cfg, req, attempt, maxAttempts, err, status, deadline); retry {
+		return retry, reason
+	}
+
+	retryIf := cfg.RetryConfig.RetryIf
+	if !cfg.RetryEnabled || retryIf == nil || errors.Is(err, ErrCircuitBreakerOpen) {
 		return false, ""
 	}
-	return true, reason
+	if !canRetryAttempt(cfg, req, attempt, maxAttempts, err, deadline) || !retryIf(resp, err) {
+		return false, ""
+	}
+	return true, RetryReasonCustom
+}
+
+// canRetryAttempt checks the attempt limit, the request method and the deadline.
+func canRetryAttempt(cfg Config, req *http.Request, attempt, maxAttempts int, err error, deadline time.Time) bool {
+	if attempt >= maxAttempts {
+		return false
+	}
+
+	// For pre-connect errors (connection refused, reset, etc.) retry is safe
+	// for any HTTP method because the request was never sent to the server.
+	if !isPreConnectError(err) && !cfg.RetryConfig.isRequestRetryable(req) {
+		return false
+	}
+
+	return deadline.IsZero() || time.Until(deadline) > 0
 }
 
 // recordAttemptMetrics logs metrics for a single attempt.
 func (rt *RoundTripper) recordAttemptMetrics(
-	ctx context.Context, method, host, path string, resp *http.Response, status int, attempt int,
+	ctx context.Context, method, host, path string, resp *http.Response, statusLabel string, attempt int,
 	isRetry bool, isError bool, duration time.Duration,
 ) {
-	rt.metrics.RecordRequest(ctx, method, host, path, strconv.Itoa(status), isRetry, isError)
-	rt.metrics.RecordDuration(ctx, duration.Seconds(), method, host, path, strconv.Itoa(status), attempt)
+	rt.metrics.RecordRequest(ctx, method, host, path, statusLabel, isRetry, isError)
+	rt.metrics.RecordDuration(ctx, duration.Seconds(), method, host, path, statusLabel, attempt)
 	if resp != nil {
 		responseSize := getResponseSize(resp)
-		rt.metrics.RecordResponseSize(ctx, responseSize, method, host, path, strconv.Itoa(status))
+		rt.metrics.RecordResponseSize(ctx, responseSize, method, host, path, statusLabel)
 	}
 }
 
 // recordRetry logs a retry metric.
 func (rt *RoundTripper) recordRetry(ctx context.Context, reason, method, host, path string) {
 	rt.metrics.RecordRetry(ctx, reason, method, host, path)
+	rt.stats.recordRetry(host)
 }
 
 // isNetworkError checks if an error is a network error.
@@ -334,7 +558,9 @@ func (rt *RoundTripper) setupTracing(req *http.Request) (context.Context, trace.
 		return ctx, nil
 	}
 
-	ctx, span := rt.tracer.StartSpan(ctx, fmt.Sprintf("HTTP %s", req.Method))
+	options := rt.config.TracingOptions
+	name, route := options.spanName(req)
+	ctx, span := rt.tracer.StartSpan(ctx, name)
 
 	// Add attributes to span
 	span.SetAttributes(
@@ -342,32 +568,60 @@ func (rt *RoundTripper) setupTracing(req *http.Request) (context.Context, trace.
 		attribute.String("http.url", req.URL.String()),
 		attribute.String("http.host", req.URL.Host),
 	)
+	if route != "" {
+		span.SetAttributes(attribute.String("http.route", route))
+	}
+	for _, label := range requestLabelsFromContext(ctx) {
+		span.SetAttributes(attribute.String(label.key, label.value))
+	}
+	if options.Attributes != nil {
+		span.SetAttributes(options.Attributes(req)...)
+	}
 
 	return ctx, span
 }
 
-// prepareRequestBody prepares the request body for retry.
-func (rt *RoundTripper) prepareRequestBody(req *http.Request) ([]byte, error) {
-	if req.Body == nil || !rt.config.RetryEnabled {
-		// No body to prepare or retry disabled
-		return nil, nil
+// finishSpan sets the response attributes and the error status of the request span.
+func (rt *RoundTripper) finishSpan(span trace.Span, resp *http.Response, err error) {
+	if span == nil {
+		return
 	}
 
-	originalBody, err := io.ReadAll(req.Body)
-	if err != nil {
-		return nil, err
+	options := rt.config.TracingOptions
+	if options.ResponseAttributes != nil {
+		span.SetAttributes(options.ResponseAttributes(resp, err)...)
 	}
-	_ = req.Body.Close() // Ignore error on close
 
-	// Restore for first request
-	req.Body = io.NopCloser(bytes.NewReader(originalBody))
-	return originalBody, nil
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case resp != nil && resp.StatusCode >= options.ErrorStatusThreshold:
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+}
+
+// injectTraceContext returns a copy of the request carrying the trace context headers
+// of its span, so downstream services join the trace. The caller's headers are not modified.
+func (rt *RoundTripper) injectTraceContext(req *http.Request) *http.Request {
+	propagator := rt.config.TracePropagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+
+	// Clone copies the headers
+	req = req.Clone(req.Context())
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	propagator.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	return req
 }
 
 // getMaxAttempts returns the maximum number of attempts.
-func (rt *RoundTripper) getMaxAttempts() int {
-	if rt.config.RetryEnabled {
-		return rt.config.RetryConfig.MaxAttempts
+func getMaxAttempts(config Config) int {
+	if config.RetryEnabled {
+		return config.RetryConfig.MaxAttempts
 	}
 	return 1
 }
@@ -379,16 +633,26 @@ func (rt *RoundTripper) executeWithRetry(retryCtx *retryContext) (*http.Response
 
 	for attempt := 1; attempt <= retryCtx.maxAttempts; attempt++ {
 		resp, err := rt.executeSingleAttempt(retryCtx, attempt)
+		retryCtx.attempts = attempt
 		lastResponse = resp
 		lastError = err
 
 		// Check if we need to retry
 		if !rt.shouldRetryResponse(retryCtx, attempt, resp, err) {
+			if retryCtx.budgetExhausted {
+				rt.logRetry(retryCtx, "retry budget exhausted", attempt, resp, err)
+				return nil, rt.retryBudgetExhausted(attempt, resp, err)
+			}
 			return resp, err
 		}
 
 		// Wait before next attempt
-		if !rt.waitForRetry(retryCtx, attempt, resp) {
+		if !rt.waitForRetry(retryCtx, attempt, resp, err) {
+			if ctxErr := retryCtx.ctx.Err(); ctxErr != nil && resp != nil {
+				// The body may have been discarded before the wait
+				closeResponseBody(resp)
+				return nil, ctxErr
+			}
 			return lastResponse, lastError
 		}
 	}
@@ -408,11 +672,60 @@ func (rt *RoundTripper) executeWithRetry(retryCtx *retryContext) (*http.Response
 	return lastResponse, lastError
 }
 
+// retryBudgetExhausted closes the response of the last attempt and returns the budget error.
+func (rt *RoundTripper) retryBudgetExhausted(attempts int, resp *http.Response, err error) error {
+	budgetErr := &RetryBudgetExhaustedError{Attempts: attempts, LastError: err}
+	if resp != nil {
+		budgetErr.LastStatus = resp.StatusCode
+		closeResponseBody(resp)
+	}
+	return budgetErr
+}
+
+// logRetry logs a retry decision about the attempt.
+func (rt *RoundTripper) logRetry(
+	retryCtx *retryContext, msg string, attempt int, resp *http.Response, err error, extra ...any,
+) {
+	if rt.config.Logger == nil {
+		return
+	}
+	keysAndValues := []any{
+		"method", retryCtx.originalReq.Method,
+		"host", retryCtx.host,
+		"path", retryCtx.originalReq.URL.Path,
+		"attempt", attempt,
+		"max_attempts", retryCtx.maxAttempts,
+	}
+	if id, ok := RequestIDFromContext(retryCtx.ctx); ok {
+		keysAndValues = append(keysAndValues, "request_id", id)
+	}
+	if retryCtx.retryReason != "" {
+		keysAndValues = append(keysAndValues, "reason", retryCtx.retryReason)
+	}
+	if resp != nil {
+		keysAndValues = append(keysAndValues, "status", resp.StatusCode)
+	}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err.Error())
+	}
+	keysAndValues = append(keysAndValues, extra...)
+	logEvent(retryCtx.ctx, rt.config.Logger, rt.config.LogLevels.Retry, msg, keysAndValues...)
+}
+
 // executeSingleAttempt executes a single HTTP request attempt.
 func (rt *RoundTripper) executeSingleAttempt(retryCtx *retryContext, attempt int) (*http.Response, error) {
 	// Create context with per-try timeout
-	attemptCtx, cancel := context.WithTimeout(retryCtx.ctx, rt.config.PerTryTimeout)
+	attemptCtx, cancel := context.WithTimeout(withAttempt(retryCtx.ctx, attempt), retryCtx.config.PerTryTimeout)
+
+	// Collect connection phase timings when enabled
+	var ct *connTrace
+	if rt.config.HTTPTraceEnabled {
+		attemptCtx, ct = withConnTrace(attemptCtx)
+	}
 	attemptReq := retryCtx.originalReq.WithContext(attemptCtx)
+	if retryCtx.noExpect && expectsContinue(attemptReq) {
+		attemptReq = withoutExpect(attemptReq)
+	}
 
 	// Restore request body for retry attempts
 	if attempt > 1 {
@@ -421,34 +734,107 @@ func (rt *RoundTripper) executeSingleAttempt(retryCtx *retryContext, attempt int
 		// even for empty bodies (where originalBody may be []byte{})
 		attemptReq.ContentLength = retryCtx.originalLength
 
-		if len(retryCtx.originalBody) > 0 {
-			attemptReq.Body = io.NopCloser(bytes.NewReader(retryCtx.originalBody))
-		} else if retryCtx.originalLength == 0 {
+		body, err := retryCtx.newAttemptBody()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		if body != nil || retryCtx.originalLength == 0 {
 			// For empty bodies set nil body
-			attemptReq.Body = nil
+			attemptReq.Body = body
+		}
+	}
+
+	// Send the attempt to a healthy failover target
+	var target *failoverTarget
+	if rt.failover != nil {
+		var err error
+		if target, err = rt.failover.route(attemptReq); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	// Send the attempt of a balanced service to one of its endpoints
+	var backend *lbPick
+	if rt.balancer != nil {
+		var err error
+		if backend, err = rt.balancer.route(attemptReq); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	// Let attempt-aware middlewares (e.g. request signing) update this attempt
+	if err := rt.prepareAttempt(attemptReq, attempt); err != nil {
+		if backend != nil {
+			rt.balancer.done(backend, true)
 		}
+		cancel()
+		return nil, fmt.Errorf("failed to prepare request attempt: %w", err)
 	}
 
 	// Remember attempt start time for accurate measurement
-	attemptStart := time.Now()
+	attemptStart := rt.clock().Now()
+	if rt.events.active() {
+		rt.events.emit(AttemptStarted{Request: retryCtx.originalReq, Attempt: attempt, Time: attemptStart})
+	}
 
-	// Execute request
-	resp, err := rt.doTransport(attemptReq)
+	// Execute request (hedged when enabled)
+	resp, err := rt.doHedgedTransport(retryCtx, attemptReq)
+	if err == nil && resp.StatusCode == http.StatusExpectationFailed && expectsContinue(attemptReq) {
+		resp, err = rt.resendWithoutExpect(retryCtx, attemptReq, resp)
+	}
+	if err == nil && checksumRetried(retryCtx.config) && resp.Header.Get("Content-Encoding") == "" {
+		resp, err = verifyAttemptChecksum(attemptReq, resp, retryCtx.config.MaxResponseBodyBytes)
+		retryCtx.checksumVerified = err == nil
+	}
+	if target != nil && retryCtx.ctx.Err() == nil {
+		// Cancellation by the caller says nothing about the target health
+		rt.failover.record(target, resp, err)
+	}
+	if backend != nil {
+		rt.recordBackend(retryCtx, backend, resp, err)
+	}
+	if ct != nil {
+		rt.recordConnTrace(retryCtx, attempt, ct)
+	}
 
 	// If timeout error occurred, replace it with detailed one
 	if err != nil {
-		err = rt.enhanceTimeoutError(err, attemptReq, rt.config, attempt, retryCtx.maxAttempts, time.Since(attemptStart))
+		err = rt.enhanceTimeoutError(err, attemptReq, retryCtx.config, attempt, retryCtx.maxAttempts, rt.clock().Now().Sub(attemptStart))
+	}
+	if rt.events.active() {
+		rt.events.emit(AttemptFinished{
+			Request:    retryCtx.originalReq,
+			Attempt:    attempt,
+			StatusCode: statusCode(resp),
+			Err:        err,
+			Duration:   rt.clock().Now().Sub(attemptStart),
+		})
 	}
 
 	// Handle response body
 	resp = rt.wrapResponseBody(resp, err, cancel)
 
 	// Record metrics and update tracing
-	rt.recordAttemptResults(retryCtx, attempt, resp, err)
+	rt.recordAttemptResults(retryCtx, attempt, attemptStart, resp, err)
 
 	return resp, err
 }
 
+// recordBackend updates the load balancer statistics and the backend metrics of the attempt.
+func (rt *RoundTripper) recordBackend(retryCtx *retryContext, backend *lbPick, resp *http.Response, err error) {
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	rt.balancer.done(backend, isTargetFailure(resp, err))
+	rt.metrics.RecordBackendRequest(
+		retryCtx.ctx, backend.service.name, backend.endpoint.address, strconv.Itoa(status), time.Since(backend.start).Seconds(),
+	)
+}
+
 // wrapResponseBody wraps the response body for context management.
 func (rt *RoundTripper) wrapResponseBody(resp *http.Response, err error, cancel context.CancelFunc) *http.Response {
 	if err == nil && resp != nil && resp.Body != nil {
@@ -463,8 +849,16 @@ func (rt *RoundTripper) wrapResponseBody(resp *http.Response, err error, cancel
 }
 
 // recordAttemptResults records metrics and updates tracing.
-func (rt *RoundTripper) recordAttemptResults(retryCtx *retryContext, attempt int, resp *http.Response, err error) {
-	duration := time.Since(retryCtx.startTime)
+func (rt *RoundTripper) recordAttemptResults(
+	retryCtx *retryContext, attempt int, attemptStart time.Time, resp *http.Response, err error,
+) {
+	duration := rt.clock().Now().Sub(attemptStart)
+	if retryCtx.log != nil {
+		retryCtx.log.attemptDurations = append(retryCtx.log.attemptDurations, duration)
+	}
+	if retryCtx.config.RetryConfig.TimeBudget > 0 {
+		rt.latency.record(retryCtx.host, duration)
+	}
 	isRetry := attempt > 1
 	status := 0
 	isError := err != nil
@@ -472,16 +866,19 @@ func (rt *RoundTripper) recordAttemptResults(retryCtx *retryContext, attempt int
 		status = resp.StatusCode
 	}
 
+	// Attempts cancelled by FirstSuccess after another request won are not failures
+	statusLabel := strconv.Itoa(status)
+	if isError && lostRace(retryCtx.ctx) {
+		statusLabel, isError = cancelledStatusLabel, false
+	}
+
 	// Record metrics
 	rt.recordAttemptMetrics(
-		retryCtx.ctx, retryCtx.originalReq.Method, retryCtx.host, retryCtx.path, resp, status, attempt, isRetry, isError, duration,
+		retryCtx.ctx, retryCtx.originalReq.Method, retryCtx.host, retryCtx.path, resp, statusLabel, attempt, isRetry, isError, duration,
 	)
 
 	// Update span
 	rt.updateSpan(retryCtx.span, status, attempt, isRetry, isError, duration)
-
-	// Reset time for next attempt
-	retryCtx.startTime = time.Now()
 }
 
 // updateSpan updates span attributes.
@@ -501,17 +898,22 @@ func (rt *RoundTripper) updateSpan(
 
 // shouldRetryResponse checks if the request should be retried.
 func (rt *RoundTripper) shouldRetryResponse(retryCtx *retryContext, attempt int, resp *http.Response, err error) bool {
-	status := 0
-	if resp != nil {
-		status = resp.StatusCode
-	}
-
 	deadline, _ := retryCtx.ctx.Deadline()
-	shouldRetry, retryReason := shouldRetryAttempt(
-		rt.config, retryCtx.originalReq, attempt, retryCtx.maxAttempts, err, status, deadline,
+	shouldRetry, retryReason := shouldRetryResult(
+		retryCtx.config, retryCtx.originalReq, attempt, retryCtx.maxAttempts, resp, err, deadline,
 	)
 
+	if shouldRetry && rt.retryBudget != nil && !rt.retryBudget.withdraw(rt.clock().Now()) {
+		retryCtx.budgetExhausted = true
+		rt.metrics.RecordRetryBudgetExhausted(retryCtx.ctx, retryCtx.originalReq.Method, retryCtx.host)
+		return false
+	}
+
 	if shouldRetry {
+		retryCtx.retryReason = retryReason
+		if retryCtx.log != nil {
+			retryCtx.log.retryReasons = append(retryCtx.log.retryReasons, retryReason)
+		}
 		rt.recordRetry(retryCtx.ctx, retryReason, retryCtx.originalReq.Method, retryCtx.host, retryCtx.path)
 	}
 
@@ -519,9 +921,13 @@ func (rt *RoundTripper) shouldRetryResponse(retryCtx *retryContext, attempt int,
 }
 
 // waitForRetry waits before the next attempt.
-func (rt *RoundTripper) waitForRetry(retryCtx *retryContext, attempt int, resp *http.Response) bool {
+func (rt *RoundTripper) waitForRetry(retryCtx *retryContext, attempt int, resp *http.Response, err error) bool {
 	// Calculate delay
-	delay := rt.calculateRetryDelay(attempt, resp)
+	delay, ok := rt.calculateRetryDelay(retryCtx.config.RetryConfig, attempt, resp)
+	if !ok {
+		rt.logRetry(retryCtx, "Retry-After exceeds MaxRetryAfter, giving up", attempt, resp, err)
+		return false
+	}
 
 	// Check that delay doesn't exceed remaining time
 	if deadline, ok := retryCtx.ctx.Deadline(); ok {
@@ -530,16 +936,43 @@ func (rt *RoundTripper) waitForRetry(retryCtx *retryContext, attempt int, resp *
 			return false // Not enough time
 		}
 	}
+	if !rt.fitsTimeBudget(retryCtx, delay) {
+		rt.logRetry(retryCtx, "time budget exhausted", attempt, resp, err, "delay", delay)
+		return false
+	}
+
+	notifyRetryAttempt(retryCtx, attempt, resp, err, delay)
+	if rt.events.active() {
+		rt.events.emit(RetryScheduled{
+			Request:    retryCtx.originalReq,
+			Attempt:    attempt,
+			Reason:     retryCtx.retryReason,
+			StatusCode: statusCode(resp),
+			Err:        err,
+			Delay:      delay,
+		})
+	}
+	rt.logRetry(retryCtx, "retrying request", attempt, resp, err, "delay", delay)
+
+	// The next attempt supersedes the response: free its connection during the wait
+	discardBody(resp, rt.config.RetryDrainLimit)
 
 	// Wait
+	timer := rt.clock().NewTimer(delay)
+	defer timer.Stop()
 	select {
 	case <-retryCtx.ctx.Done():
 		return false
-	case <-time.After(delay):
+	case <-timer.C():
 		return true
 	}
 }
 
+// clock returns the configured clock or the real one.
+func (rt *RoundTripper) clock() Clock {
+	return clockOrDefault(rt.config.Clock)
+}
+
 // enhanceTimeoutError enhances timeout errors by adding detailed context.
 func (rt *RoundTripper) enhanceTimeoutError(
 	err error,
@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ScopeConfig overrides settings of a scoped client created by Client.Scope. Zero fields keep
+// the settings of the parent client.
+type ScopeConfig struct {
+	// BaseURL resolves relative request URLs of the scoped client; a relative BaseURL is
+	// joined to the BaseURL of the parent, e.g. "orders" under "https://api.example.com/v1"
+	BaseURL string
+
+	// DefaultHeaders are added to the DefaultHeaders of the parent, replacing headers of the same name
+	DefaultHeaders map[string]string
+
+	// Retry replaces the retry settings of the parent and enables retries
+	Retry *RetryConfig
+
+	// DisableRetry disables retries of the scoped client
+	DisableRetry bool
+
+	// Timeout replaces the overall timeout of the parent
+	Timeout time.Duration
+
+	// URLTemplates replace the MetricsLabels.URLTemplates of the parent
+	URLTemplates []string
+}
+
+// Scope returns a lightweight client derived from c, e.g. one per downstream endpoint. The scoped
+// client shares the transport, connection pool, rate limiter, circuit breaker, retry budget and
+// request queue of c, while overrides replace its base URL, headers, retries and timeout. Its
+// metrics are recorded with name as the client_name label and GetMetrics counts only its requests.
+//
+// Closing c closes its scoped clients too; closing a scoped client only stops its Enqueue workers
+// and releases its metrics.
+func (c *Client) Scope(name string, overrides ScopeConfig) *Client {
+	config := c.config
+	if overrides.BaseURL != "" {
+		config.BaseURL = overrides.BaseURL
+		if u, err := url.Parse(overrides.BaseURL); err == nil && !u.IsAbs() && c.baseURL != nil {
+			config.BaseURL = c.baseURL.JoinPath(u.EscapedPath()).String()
+		}
+	}
+	if len(overrides.DefaultHeaders) > 0 {
+		config.DefaultHeaders = maps.Clone(config.DefaultHeaders)
+		if config.DefaultHeaders == nil {
+			config.DefaultHeaders = make(map[string]string, len(overrides.DefaultHeaders))
+		}
+		maps.Copy(config.DefaultHeaders, overrides.DefaultHeaders)
+	}
+	if overrides.Retry != nil {
+		config.RetryEnabled = true
+		config.RetryConfig = overrides.Retry.withDefaults()
+	}
+	if overrides.DisableRetry {
+		config.RetryEnabled = false
+	}
+	if overrides.Timeout > 0 {
+		config.Timeout = overrides.Timeout
+	}
+	if overrides.URLTemplates != nil {
+		config.MetricsLabels.URLTemplates = overrides.URLTemplates
+	}
+
+	metricsHandle, metricsErr := newMetricsHandle(config, name)
+	metrics := NewMetricsWithProvider(name, metricsHandle.Provider)
+	metrics.dropHost = config.MetricsLabels.DropHost
+
+	// The scoped RoundTripper shares the base transport and the limits of the parent
+	rt := *c.httpClient.Transport.(*RoundTripper)
+	rt.config = config
+	rt.metrics = metrics
+	rt.stats = newClientStats()
+	rt.events = newEventBus(c.events)
+
+	httpClient := &http.Client{
+		Transport: &rt,
+		Timeout:   config.Timeout,
+	}
+	scoped := &Client{
+		httpClient:    httpClient,
+		config:        config,
+		metrics:       metrics,
+		metricsHandle: metricsHandle,
+		metricsErr:    metricsErr,
+		tracer:        c.tracer,
+		name:          name,
+		limiter:       c.limiter,
+		drain:         c.drain,
+		pool:          c.pool,
+		events:        rt.events,
+		parent:        c,
+		probes:        c.probes,
+		keepAlive:     c.keepAlive,
+	}
+	scoped.baseURL, scoped.baseURLErr = parseBaseURL(config.BaseURL)
+	httpClient.CheckRedirect = scoped.checkRedirect
+
+	c.scopesMu.Lock()
+	if c.scopes == nil {
+		c.scopes = make(map[*Client]struct{})
+	}
+	c.scopes[scoped] = struct{}{}
+	c.scopesMu.Unlock()
+	return scoped
+}
+
+// closeScopes releases the metrics of the open scoped clients of c and of their own scopes.
+func (c *Client) closeScopes() {
+	c.scopesMu.Lock()
+	scopes := c.scopes
+	c.scopes = nil
+	c.scopesMu.Unlock()
+	for scoped := range scopes {
+		scoped.closeScopes()
+		_ = scoped.metrics.Close()
+	}
+}
@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope_InheritsAndOverrides(t *testing.T) {
+	t.Parallel()
+	type seen struct{ path, tenant, scope string }
+	requests := make(chan seen, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- seen{r.URL.Path, r.Header.Get("X-Tenant"), r.Header.Get("X-Scope")}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		BaseURL:         server.URL + "/v1",
+		DefaultHeaders:  map[string]string{"X-Tenant": "acme", "X-Scope": "root"},
+		TransportTuning: TransportTuning{TrackConnections: true},
+	}, "test-scope-parent")
+	defer client.Close()
+	orders := client.Scope("test-scope-orders", ScopeConfig{
+		BaseURL:        "orders",
+		DefaultHeaders: map[string]string{"X-Scope": "orders"},
+	})
+
+	for _, c := range []*Client{orders, client} {
+		resp, err := c.Get(context.Background(), "/42")
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	assert.Equal(t, seen{"/v1/orders/42", "acme", "orders"}, <-requests)
+	assert.Equal(t, seen{"/v1/42", "acme", "root"}, <-requests)
+	assert.Equal(t, "root", client.GetConfig().DefaultHeaders["X-Scope"])
+
+	// Both clients use the same connection pool
+	stats := client.PoolStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(1), stats[0].Opened)
+	assert.Equal(t, int64(2), stats[0].Acquired)
+	assert.Equal(t, stats, orders.PoolStats())
+
+	assert.Equal(t, int64(1), orders.GetMetrics().TotalRequests)
+	assert.Equal(t, int64(1), client.GetMetrics().TotalRequests)
+}
+
+func TestScope_Retry(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{}, "test-scope-retry")
+	defer client.Close()
+	retrying := client.Scope("test-scope-retry-enabled", ScopeConfig{
+		Retry: &RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	})
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	calls.Store(0)
+	resp, err = retrying.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+
+	noRetry := retrying.Scope("test-scope-retry-disabled", ScopeConfig{DisableRetry: true})
+	assert.False(t, noRetry.GetConfig().RetryEnabled)
+}
+
+func TestScope_MetricsName(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+	}, "test-scope-metrics")
+	defer client.Close()
+	users := client.Scope("test-scope-metrics-users", ScopeConfig{URLTemplates: []string{"/users/{id}"}})
+
+	resp, err := users.Get(context.Background(), server.URL+"/users/7")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	labels := map[string]string{"client_name": "test-scope-metrics-users", "path": "/users/{id}"}
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, MetricRequestsTotal, labels))
+	assert.Equal(t, []prometheus.Registerer{reg}, users.MetricsHandle().Registerers)
+}
+
+func TestScope_Close(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusOK})
+	defer server.Close()
+
+	client := New(Config{}, "test-scope-close")
+	scoped := client.Scope("test-scope-close-child", ScopeConfig{})
+
+	require.NoError(t, scoped.Close())
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	require.NoError(t, client.Close())
+	_, err = scoped.Get(context.Background(), server.URL)
+	var closedErr *ClientClosedError
+	assert.ErrorAs(t, err, &closedErr)
+}
@@ -0,0 +1,266 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SLO objectives, the objective label of the SLO metrics.
+const (
+	SLOObjectiveLatency = "latency"
+	SLOObjectiveErrors  = "errors"
+)
+
+// sloWindowBuckets is the number of buckets the rolling window of an SLO is split into.
+const sloWindowBuckets = 10
+
+// SLO is a latency and error rate objective of an endpoint, tracked from the client's own
+// requests (see Config.SLOs). A zero LatencyTarget or MaxErrorRate disables that objective.
+type SLO struct {
+	// Name identifies the SLO in metrics and status, e.g. "get-user"
+	Name string
+	// Method limits the SLO to requests with the method; empty matches all methods
+	Method string
+	// Host limits the SLO to requests to the host; empty matches all hosts
+	Host string
+	// Path is a path template matched like MetricsLabels.URLTemplates, e.g. "/users/{id}";
+	// empty matches all paths
+	Path string
+	// LatencyTarget is the latency Percentile of the requests must stay within, e.g. 300ms
+	LatencyTarget time.Duration
+	// Percentile is the share of requests that must meet LatencyTarget (default: 0.99)
+	Percentile float64
+	// MaxErrorRate is the share of requests allowed to fail with an error or a 5xx status,
+	// e.g. 0.001
+	MaxErrorRate float64
+	// Window is the rolling window of observations (default: 5m)
+	Window time.Duration
+	// MinRequests is the number of requests in the window before the SLO can burn (default: 10)
+	MinRequests int64
+}
+
+// withDefaults returns a copy of the SLO with default values.
+func (s SLO) withDefaults() SLO {
+	if s.Percentile == 0 {
+		s.Percentile = 0.99
+	}
+	if s.Window == 0 {
+		s.Window = 5 * time.Minute
+	}
+	if s.MinRequests == 0 {
+		s.MinRequests = 10
+	}
+	return s
+}
+
+// matches checks if the request belongs to the endpoint of the SLO.
+func (s SLO) matches(req *http.Request, host string) bool {
+	if s.Method != "" && !strings.EqualFold(s.Method, req.Method) {
+		return false
+	}
+	if s.Host != "" && !strings.EqualFold(s.Host, host) {
+		return false
+	}
+	if s.Path == "" {
+		return true
+	}
+	if template, ok := PathTemplateFromContext(req.Context()); ok && template == s.Path {
+		return true
+	}
+	return MatchURLTemplate([]string{s.Path}, req.URL.Path) != ""
+}
+
+// SLOStatus is the rolling compliance of an SLO.
+type SLOStatus struct {
+	// SLO is the objective with default values
+	SLO SLO
+	// Requests is the number of requests in the window
+	Requests int64
+	// ErrorRate is the share of failed requests
+	ErrorRate float64
+	// SlowRate is the share of requests slower than LatencyTarget
+	SlowRate float64
+	// ErrorBurnRate is ErrorRate relative to MaxErrorRate: above 1 the error budget burns
+	// faster than the SLO allows
+	ErrorBurnRate float64
+	// LatencyBurnRate is SlowRate relative to the share allowed by Percentile
+	LatencyBurnRate float64
+	// Burning is set when a burn rate is above 1 with at least MinRequests requests
+	Burning bool
+}
+
+// SLOStatus returns the rolling compliance of Config.SLOs, in their order.
+func (c *Client) SLOStatus() []SLOStatus {
+	rt, ok := c.httpClient.Transport.(*RoundTripper)
+	if !ok || rt.slo == nil {
+		return nil
+	}
+	return rt.slo.status(rt.clock().Now())
+}
+
+// validateSLOs checks that the SLOs have unique names, objectives and valid ratios.
+func validateSLOs(slos []SLO) []error {
+	var errs []error
+	seen := make(map[string]bool, len(slos))
+	for i, slo := range slos {
+		field := fmt.Sprintf("SLOs[%d]", i)
+		switch {
+		case slo.Name == "":
+			errs = append(errs, NewConfigurationError(field+".Name", slo.Name, "must not be empty"))
+		case seen[slo.Name]:
+			errs = append(errs, NewConfigurationError(field+".Name", slo.Name, "is duplicated"))
+		}
+		seen[slo.Name] = true
+		if slo.LatencyTarget <= 0 && slo.MaxErrorRate <= 0 {
+			errs = append(errs, NewConfigurationError(field, slo.Name, "needs LatencyTarget or MaxErrorRate"))
+		}
+		if slo.Percentile < 0 || slo.Percentile >= 1 {
+			errs = append(errs, NewConfigurationError(field+".Percentile", slo.Percentile, "must be between 0 and 1"))
+		}
+		if slo.MaxErrorRate < 0 || slo.MaxErrorRate >= 1 {
+			errs = append(errs, NewConfigurationError(field+".MaxErrorRate", slo.MaxErrorRate, "must be between 0 and 1"))
+		}
+		if slo.Window < 0 {
+			errs = append(errs, NewConfigurationError(field+".Window", slo.Window, "must not be negative"))
+		}
+	}
+	return errs
+}
+
+// sloCounts are the observations of a window bucket.
+type sloCounts struct {
+	requests int64
+	failed   int64
+	slow     int64
+}
+
+// sloState is the rolling window of an SLO.
+type sloState struct {
+	slo     SLO
+	bucket  time.Duration
+	counts  [sloWindowBuckets]sloCounts
+	index   [sloWindowBuckets]int64 // bucket number since the Unix epoch of each slot
+	burning bool
+}
+
+// record adds a request to the bucket of now.
+func (s *sloState) record(now time.Time, failed, slow bool) {
+	n := now.UnixNano() / int64(s.bucket)
+	slot := n % sloWindowBuckets
+	if s.index[slot] != n {
+		s.index[slot] = n
+		s.counts[slot] = sloCounts{}
+	}
+	counts := &s.counts[slot]
+	counts.requests++
+	if failed {
+		counts.failed++
+	}
+	if slow {
+		counts.slow++
+	}
+}
+
+// status sums the buckets of the window ending at now.
+func (s *sloState) status(now time.Time) SLOStatus {
+	n := now.UnixNano() / int64(s.bucket)
+	var total sloCounts
+	for slot := range s.counts {
+		if n-s.index[slot] < sloWindowBuckets {
+			total.requests += s.counts[slot].requests
+			total.failed += s.counts[slot].failed
+			total.slow += s.counts[slot].slow
+		}
+	}
+
+	status := SLOStatus{SLO: s.slo, Requests: total.requests}
+	if total.requests > 0 {
+		status.ErrorRate = float64(total.failed) / float64(total.requests)
+		status.SlowRate = float64(total.slow) / float64(total.requests)
+	}
+	if s.slo.MaxErrorRate > 0 {
+		status.ErrorBurnRate = status.ErrorRate / s.slo.MaxErrorRate
+	}
+	if s.slo.LatencyTarget > 0 {
+		status.LatencyBurnRate = status.SlowRate / (1 - s.slo.Percentile)
+	}
+	status.Burning = total.requests >= s.slo.MinRequests && (status.ErrorBurnRate > 1 || status.LatencyBurnRate > 1)
+	return status
+}
+
+// sloTracker tracks Config.SLOs.
+type sloTracker struct {
+	mu       sync.Mutex
+	states   []*sloState
+	onChange func(SLOStatus)
+}
+
+// newSLOTracker creates a tracker of the SLOs, nil without SLOs.
+func newSLOTracker(slos []SLO, onChange func(SLOStatus)) *sloTracker {
+	if len(slos) == 0 {
+		return nil
+	}
+	t := &sloTracker{onChange: onChange}
+	for _, slo := range slos {
+		slo = slo.withDefaults()
+		t.states = append(t.states, &sloState{slo: slo, bucket: max(slo.Window/sloWindowBuckets, time.Millisecond)})
+	}
+	return t
+}
+
+// record adds a finished request to the matching SLOs, updates their metrics and reports
+// SLOs that started burning or recovered.
+func (t *sloTracker) record(
+	metrics *Metrics, now time.Time, req *http.Request, host string, resp *http.Response, err error,
+	latency time.Duration,
+) {
+	failed := err != nil || statusCode(resp) >= http.StatusInternalServerError
+	var changed []SLOStatus
+
+	t.mu.Lock()
+	var statuses []SLOStatus
+	for _, state := range t.states {
+		if !state.slo.matches(req, host) {
+			continue
+		}
+		state.record(now, failed, state.slo.LatencyTarget > 0 && latency > state.slo.LatencyTarget)
+		status := state.status(now)
+		if status.Burning != state.burning {
+			state.burning = status.Burning
+			changed = append(changed, status)
+		}
+		statuses = append(statuses, status)
+	}
+	t.mu.Unlock()
+
+	// Gauges are updated for requests skipped by MetricsSampleRate too
+	ctx := context.Background()
+	for _, status := range statuses {
+		if status.SLO.LatencyTarget > 0 {
+			metrics.SetSLOStatus(ctx, status.SLO.Name, SLOObjectiveLatency, 1-status.SlowRate, status.LatencyBurnRate)
+		}
+		if status.SLO.MaxErrorRate > 0 {
+			metrics.SetSLOStatus(ctx, status.SLO.Name, SLOObjectiveErrors, 1-status.ErrorRate, status.ErrorBurnRate)
+		}
+	}
+	if t.onChange != nil {
+		for _, status := range changed {
+			t.onChange(status)
+		}
+	}
+}
+
+// status returns the status of all SLOs.
+func (t *sloTracker) status(now time.Time) []SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := make([]SLOStatus, 0, len(t.states))
+	for _, state := range t.states {
+		statuses = append(statuses, state.status(now))
+	}
+	return statuses
+}
@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSLO_BurningAndRecovery(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusOK},
+		TestResponse{StatusCode: http.StatusInternalServerError},
+		TestResponse{StatusCode: http.StatusInternalServerError},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	var mu sync.Mutex
+	var changes []SLOStatus
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		SLOs: []SLO{
+			{Name: "get-user", Method: http.MethodGet, Path: "/users/{id}", MaxErrorRate: 0.25, MinRequests: 3},
+			{Name: "other", Path: "/orders", MaxErrorRate: 0.1},
+		},
+		OnSLOChange: func(status SLOStatus) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, status)
+		},
+	}, "test-slo")
+	defer client.Close()
+
+	for range 3 {
+		resp, err := client.Get(context.Background(), server.URL+"/users/42")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	// 2 of 3 requests failed: the error budget of 25% burns at 2.67x
+	mu.Lock()
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].Burning)
+	assert.Equal(t, "get-user", changes[0].SLO.Name)
+	assert.InDelta(t, 2.0/3, changes[0].ErrorRate, 0.001)
+	mu.Unlock()
+	labels := map[string]string{"client_name": "test-slo", "slo": "get-user", "objective": SLOObjectiveErrors}
+	assert.InDelta(t, 1.0/3, circuitBreakerMetric(t, reg, MetricSLOCompliance, labels), 0.001)
+	assert.InDelta(t, 8.0/3, circuitBreakerMetric(t, reg, MetricSLOBurnRate, labels), 0.001)
+
+	statuses := client.SLOStatus()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, int64(3), statuses[0].Requests)
+	assert.Zero(t, statuses[1].Requests)
+	assert.Equal(t, 0.99, statuses[1].SLO.Percentile)
+
+	// Enough successful requests bring the error rate back under the objective
+	server.Reset()
+	for range 6 {
+		resp, err := client.Get(context.Background(), server.URL+"/users/42")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		server.Reset()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, changes, 2)
+	assert.False(t, changes[1].Burning)
+}
+
+func TestSLOState_RollingWindow(t *testing.T) {
+	t.Parallel()
+	tracker := newSLOTracker([]SLO{{Name: "search", LatencyTarget: 100 * time.Millisecond, MinRequests: 1}}, nil)
+	metrics := NewDisabledMetrics("test-slo-window")
+	req, err := http.NewRequest(http.MethodGet, "http://api.example.com/search", nil)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for range 99 {
+		tracker.record(metrics, now, req, "api.example.com", &http.Response{StatusCode: http.StatusOK}, nil, 10*time.Millisecond)
+	}
+	tracker.record(metrics, now, req, "api.example.com", nil, errors.New("boom"), 200*time.Millisecond)
+
+	status := tracker.status(now)[0]
+	assert.Equal(t, int64(100), status.Requests)
+	assert.InDelta(t, 0.01, status.SlowRate, 0.0001)
+	assert.InDelta(t, 1.0, status.LatencyBurnRate, 0.0001)
+	assert.False(t, status.Burning)
+	// MaxErrorRate is unset, so errors don't burn
+	assert.Zero(t, status.ErrorBurnRate)
+
+	// Observations expire with the window
+	assert.Equal(t, int64(100), tracker.status(now.Add(4*time.Minute))[0].Requests)
+	assert.Zero(t, tracker.status(now.Add(6*time.Minute))[0].Requests)
+}
+
+func TestValidateSLOs(t *testing.T) {
+	t.Parallel()
+	errs := validateSLOs([]SLO{
+		{Name: "ok", LatencyTarget: time.Second},
+		{Name: "ok", MaxErrorRate: 0.01},
+		{Name: "", MaxErrorRate: 0.01},
+		{Name: "no-objective"},
+		{Name: "bad-ratios", LatencyTarget: time.Second, Percentile: 1, MaxErrorRate: 2},
+	})
+	var fields []string
+	for _, err := range errs {
+		var configErr *ConfigurationError
+		require.ErrorAs(t, err, &configErr)
+		fields = append(fields, configErr.Field)
+	}
+	assert.Equal(t, []string{
+		"SLOs[1].Name", "SLOs[2].Name", "SLOs[3]", "SLOs[4].Percentile", "SLOs[4].MaxErrorRate",
+	}, fields)
+}
@@ -0,0 +1,188 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type getStatusRequest struct {
+	XMLName xml.Name `xml:"urn:fns GetStatus"`
+	INN     string   `xml:"INN"`
+}
+
+type getStatusResponse struct {
+	XMLName xml.Name `xml:"GetStatusResponse"`
+	Status  string   `xml:"Status"`
+}
+
+func TestPostSOAP_SOAP11(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "text/xml"},
+		Body: `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/">` +
+			`<s:Header/><s:Body><GetStatusResponse xmlns="urn:fns"><Status>active</Status></GetStatusResponse>` +
+			`</s:Body></s:Envelope>`,
+	})
+	defer server.Close()
+
+	client := New(Config{}, "test-soap")
+	defer client.Close()
+
+	var resp getStatusResponse
+	err := client.PostSOAP(context.Background(), server.URL, SOAPRequest{
+		Action: "urn:fns/GetStatus",
+		Body:   getStatusRequest{INN: "7707083893"},
+	}, &resp)
+	require.NoError(t, err)
+	assert.Equal(t, "active", resp.Status)
+
+	req := server.RequestLog[0]
+	assert.Equal(t, "text/xml; charset=utf-8", req.Headers["Content-Type"])
+	assert.Equal(t, `"urn:fns/GetStatus"`, req.Headers["Soapaction"])
+	assert.Contains(t, req.Body, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">`)
+	assert.Contains(t, req.Body, `<soap:Body><GetStatus xmlns="urn:fns"><INN>7707083893</INN></GetStatus></soap:Body>`)
+	assert.NotContains(t, req.Body, "soap:Header")
+}
+
+func TestPostSOAP_Faults(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{
+			StatusCode: http.StatusInternalServerError,
+			Body: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>` +
+				`<faultcode>soap:Client</faultcode><faultstring>Invalid INN</faultstring>` +
+				`<detail><ValidationError xmlns="urn:fns"><Field>INN</Field></ValidationError></detail>` +
+				`</soap:Fault></soap:Body></soap:Envelope>`,
+		},
+		TestResponse{
+			StatusCode: http.StatusInternalServerError,
+			Body: `<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><env:Fault>` +
+				`<env:Code><env:Value>env:Sender</env:Value><env:Subcode><env:Value>fns:Throttled</env:Value></env:Subcode></env:Code>` +
+				`<env:Reason><env:Text xml:lang="en">Too many requests</env:Text></env:Reason>` +
+				`<env:Role>urn:fns:gateway</env:Role>` +
+				`</env:Fault></env:Body></env:Envelope>`,
+		},
+		TestResponse{StatusCode: http.StatusBadGateway, Body: "bad gateway"},
+	)
+	defer server.Close()
+
+	client := New(Config{}, "test-soap-faults")
+	defer client.Close()
+
+	err := client.PostSOAP(context.Background(), server.URL, SOAPRequest{Body: getStatusRequest{}}, nil)
+	var fault *SOAPFault
+	require.ErrorAs(t, err, &fault)
+	assert.True(t, IsSOAPFault(err))
+	assert.Equal(t, http.StatusInternalServerError, fault.StatusCode)
+	assert.Equal(t, "soap:Client", fault.Code)
+	assert.Equal(t, "Invalid INN", fault.Reason)
+	var detail struct {
+		Field string `xml:"Field"`
+	}
+	require.NoError(t, fault.DecodeDetail(&detail))
+	assert.Equal(t, "INN", detail.Field)
+
+	err = client.PostSOAP(context.Background(), server.URL, SOAPRequest{Version: SOAP12, Body: getStatusRequest{}}, nil)
+	require.ErrorAs(t, err, &fault)
+	assert.Equal(t, "env:Sender", fault.Code)
+	assert.Equal(t, "fns:Throttled", fault.Subcode)
+	assert.Equal(t, "Too many requests", fault.Reason)
+	assert.Equal(t, "urn:fns:gateway", fault.Actor)
+	assert.EqualError(t, fault, "soap fault env:Sender/fns:Throttled: Too many requests")
+	assert.Error(t, fault.DecodeDetail(&detail))
+
+	// A status without a fault is an HTTPError
+	err = client.PostSOAP(context.Background(), server.URL, SOAPRequest{Body: getStatusRequest{}}, nil)
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadGateway, httpErr.StatusCode)
+	assert.False(t, IsSOAPFault(err))
+}
+
+func TestWithSOAPEnvelope_SOAP12(t *testing.T) {
+	t.Parallel()
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/soap", nil)
+	require.NoError(t, err)
+
+	WithSOAPEnvelope(SOAPRequest{
+		Version: SOAP12,
+		Action:  "urn:fns/GetStatus",
+		Headers: []any{`<fns:Client xmlns:fns="urn:fns">mobile</fns:Client>`},
+		Body:    []byte(`<GetStatus xmlns="urn:fns"/>`),
+	})(req)
+
+	assert.Equal(t, `application/soap+xml; charset=utf-8; action="urn:fns/GetStatus"`, req.Header.Get("Content-Type"))
+	assert.Empty(t, req.Header.Get("SOAPAction"))
+	data, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	body := string(data)
+	assert.Contains(t, body, `xmlns:soap="http://www.w3.org/2003/05/soap-envelope"`)
+	assert.Contains(t, body, `<soap:Header><fns:Client xmlns:fns="urn:fns">mobile</fns:Client></soap:Header>`)
+
+	WithSOAPEnvelope(SOAPRequest{Body: make(chan int)})(req)
+	assert.NotEmpty(t, req.Header.Get("X-XML-Marshal-Error"))
+}
+
+func TestWSSecurity(t *testing.T) {
+	t.Parallel()
+	envelope, err := SOAPRequest{
+		Headers: []any{&WSSecurity{Username: "user", Password: "p&ss", Digest: true, TTL: time.Minute, MustUnderstand: true}},
+		Body:    getStatusRequest{INN: "1"},
+	}.envelope()
+	require.NoError(t, err)
+
+	// The header is valid XML within the envelope
+	var parsed struct {
+		Header struct {
+			Security struct {
+				MustUnderstand string `xml:"mustUnderstand,attr"`
+				Timestamp      struct {
+					Created string `xml:"Created"`
+					Expires string `xml:"Expires"`
+				} `xml:"Timestamp"`
+				Token struct {
+					Username string `xml:"Username"`
+					Password struct {
+						Type  string `xml:"Type,attr"`
+						Value string `xml:",chardata"`
+					} `xml:"Password"`
+					Nonce   string `xml:"Nonce"`
+					Created string `xml:"Created"`
+				} `xml:"UsernameToken"`
+			} `xml:"http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd Security"`
+		} `xml:"Header"`
+	}
+	require.NoError(t, xml.Unmarshal(envelope, &parsed))
+	security := parsed.Header.Security
+	assert.Equal(t, "1", security.MustUnderstand)
+	assert.Equal(t, "user", security.Token.Username)
+	assert.True(t, strings.HasSuffix(security.Token.Password.Type, "#PasswordDigest"))
+
+	nonce, err := base64.StdEncoding.DecodeString(security.Token.Nonce)
+	require.NoError(t, err)
+	assert.Len(t, nonce, 16)
+	assert.Equal(t, wsPasswordDigest(nonce, security.Token.Created, "p&ss"), security.Token.Password.Value)
+
+	created, err := time.Parse(wssCreatedTimeLayout, security.Timestamp.Created)
+	require.NoError(t, err)
+	expires, err := time.Parse(wssCreatedTimeLayout, security.Timestamp.Expires)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, expires.Sub(created))
+
+	// The plain text password is escaped
+	envelope, err = SOAPRequest{Headers: []any{&WSSecurity{Username: "user", Password: "p&ss"}}}.envelope()
+	require.NoError(t, err)
+	assert.Contains(t, string(envelope), `#PasswordText">p&amp;ss</wsse:Password>`)
+	assert.NotContains(t, string(envelope), "wsu:Timestamp")
+	assert.NotContains(t, string(envelope), "mustUnderstand")
+}
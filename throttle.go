@@ -0,0 +1,211 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultThrottlePeriod is how long a host is throttled after a 429 without rate limit hints.
+	defaultThrottlePeriod = time.Second
+	// defaultMaxThrottlePeriod caps the throttle period announced by the server.
+	defaultMaxThrottlePeriod = time.Minute
+)
+
+// ThrottleState describes a host throttled after a 429 Too Many Requests response or a
+// nearly exhausted quota.
+type ThrottleState struct {
+	// Host is the throttled host
+	Host string
+	// Until is the time the throttling ends
+	Until time.Time
+	// RequestsPerSecond is the lowered rate, zero means requests wait until Until
+	RequestsPerSecond float64
+}
+
+// hostThrottle lowers the rate of requests to hosts that responded with 429 or announced
+// a nearly exhausted quota.
+type hostThrottle struct {
+	defaultPeriod time.Duration
+	maxPeriod     time.Duration
+	// adaptive throttles hosts after 429 responses
+	adaptive bool
+	// preemptive throttles hosts whose remaining quota is at or below threshold
+	preemptive bool
+	threshold  int64
+	// onChange is called when a host becomes throttled or the throttling ends
+	onChange func(host string, throttled bool)
+	clock    Clock
+
+	mu    sync.Mutex
+	hosts map[string]*throttleEntry
+}
+
+// throttleEntry is the throttle state of a single host.
+type throttleEntry struct {
+	state   ThrottleState
+	limiter *TokenBucketLimiter // nil while requests are paused
+	timer   Timer               // ends the throttling
+	renewed chan struct{}       // closed when the throttling is renewed before the timer fires
+}
+
+// newHostThrottle creates a throttle using the configured periods.
+func newHostThrottle(config RateLimiterConfig) *hostThrottle {
+	return &hostThrottle{
+		defaultPeriod: config.ThrottlePeriod,
+		maxPeriod:     config.MaxThrottlePeriod,
+		adaptive:      config.AdaptiveThrottling,
+		preemptive:    config.PreemptiveThrottling,
+		threshold:     int64(config.PreemptiveThreshold),
+		clock:         clockOrDefault(config.Clock),
+		hosts:         make(map[string]*throttleEntry),
+	}
+}
+
+// observe throttles the host when the response is 429, using Retry-After or RateLimit-* hints,
+// or when the response announces a remaining quota at or below the preemptive threshold.
+func (t *hostThrottle) observe(host string, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	now := t.clock.Now()
+	var period time.Duration
+	var rate float64
+	switch {
+	case t.adaptive && resp.StatusCode == http.StatusTooManyRequests:
+		period, rate = throttleHint(resp.Header, now)
+		if period <= 0 {
+			period, rate = t.defaultPeriod, 0
+		}
+	case t.preemptive:
+		info, ok := parseRateLimit(resp.Header, now)
+		if !ok || info.Remaining < 0 || info.Remaining > t.threshold {
+			return
+		}
+		if period, rate = info.throttle(); period <= 0 {
+			return
+		}
+	default:
+		return
+	}
+	period = min(period, t.maxPeriod)
+
+	entry := &throttleEntry{
+		state: ThrottleState{
+			Host:              host,
+			Until:             now.Add(period),
+			RequestsPerSecond: rate,
+		},
+		timer:   t.clock.NewTimer(period),
+		renewed: make(chan struct{}),
+	}
+
+	t.mu.Lock()
+	previous := t.hosts[host]
+	if rate > 0 {
+		entry.limiter = t.renewLimiter(previous, rate)
+	}
+	if previous != nil {
+		previous.timer.Stop()
+		close(previous.renewed)
+	}
+	t.hosts[host] = entry
+	t.mu.Unlock()
+	go func() {
+		select {
+		case <-entry.timer.C():
+			t.expire(host, entry)
+		case <-entry.renewed:
+		}
+	}()
+
+	if previous == nil && t.onChange != nil {
+		t.onChange(host, true)
+	}
+}
+
+// renewLimiter returns the limiter of a host throttled to rate. A renewed throttling keeps
+// the tokens of the previous limiter, so repeated 429 responses don't hand out extra requests.
+func (t *hostThrottle) renewLimiter(previous *throttleEntry, rate float64) *TokenBucketLimiter {
+	if previous != nil && previous.limiter != nil {
+		previous.limiter.setRate(rate)
+		return previous.limiter
+	}
+	limiter := NewTokenBucketLimiterWithClock(rate, 1, t.clock)
+	if previous != nil {
+		// The host was paused: the lowered rate starts without a token
+		limiter.tokens = 0
+	}
+	return limiter
+}
+
+// expire ends the throttling of the host unless it was renewed.
+func (t *hostThrottle) expire(host string, entry *throttleEntry) {
+	t.mu.Lock()
+	current := t.hosts[host] == entry
+	if current {
+		delete(t.hosts, host)
+	}
+	t.mu.Unlock()
+
+	if current && t.onChange != nil {
+		t.onChange(host, false)
+	}
+}
+
+// wait blocks while the host is paused or until the lowered rate allows a request.
+func (t *hostThrottle) wait(ctx context.Context, host string) error {
+	t.mu.Lock()
+	entry := t.hosts[host]
+	t.mu.Unlock()
+	if entry == nil {
+		return nil
+	}
+
+	if entry.limiter != nil {
+		return entry.limiter.Wait(ctx)
+	}
+
+	delay := entry.state.Until.Sub(t.clock.Now())
+	if delay <= 0 {
+		return nil
+	}
+	timer := t.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// states returns the active throttle states ordered by host.
+func (t *hostThrottle) states() []ThrottleState {
+	t.mu.Lock()
+	states := make([]ThrottleState, 0, len(t.hosts))
+	for _, entry := range t.hosts {
+		states = append(states, entry.state)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Host < states[j].Host })
+	return states
+}
+
+// throttleHint returns the throttle period and the allowed rate from 429 response headers.
+// Retry-After pauses requests. A reset of the RateLimit-* or X-RateLimit-* quota with
+// requests remaining spreads them over the reset period, otherwise requests are paused.
+// Dates are relative to now.
+func throttleHint(header http.Header, now time.Time) (time.Duration, float64) {
+	if delay := parseRetryAfterAt(header.Get("Retry-After"), now); delay > 0 {
+		return delay, 0
+	}
+
+	info, _ := parseRateLimit(header, now)
+	return info.throttle()
+}
@@ -0,0 +1,310 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newThrottlingServer answers the first request with 429 and the given headers, then 200.
+func newThrottlingServer(t *testing.T, headers map[string]string) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// throttledGauge returns http_client_throttled for the client and host.
+func throttledGauge(t *testing.T, reg *prometheus.Registry, host string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != MetricThrottled {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "host" && label.GetValue() == host {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	return -1
+}
+
+func TestThrottleHint(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantPeriod time.Duration
+		wantRate   float64
+	}{
+		{"no hints", nil, 0, 0},
+		{"retry after", map[string]string{"Retry-After": "3"}, 3 * time.Second, 0},
+		{"retry after wins", map[string]string{"Retry-After": "2", "RateLimit-Reset": "10"}, 2 * time.Second, 0},
+		{"exhausted", map[string]string{"RateLimit-Remaining": "0", "RateLimit-Reset": "5"}, 5 * time.Second, 0},
+		{"remaining", map[string]string{"RateLimit-Remaining": "20", "RateLimit-Reset": "10"}, 10 * time.Second, 2},
+		{"x-ratelimit", map[string]string{"X-RateLimit-Remaining": "10", "X-RateLimit-Reset": "5"}, 5 * time.Second, 2},
+		{"invalid reset", map[string]string{"RateLimit-Reset": "soon"}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+			period, rate := throttleHint(header, time.Now())
+			assert.Equal(t, tt.wantPeriod, period)
+			assert.Equal(t, tt.wantRate, rate)
+		})
+	}
+}
+
+func TestAdaptiveThrottling_PausesHost(t *testing.T) {
+	t.Parallel()
+	server, calls := newThrottlingServer(t, map[string]string{"Retry-After": "30"})
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+		RateLimiterEnabled:   true,
+		RateLimiterConfig: RateLimiterConfig{
+			RequestsPerSecond:  100,
+			AdaptiveThrottling: true,
+			MaxThrottlePeriod:  300 * time.Millisecond,
+		},
+	}, "test-throttle-pause")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	host := getHost(resp.Request.URL)
+	states := client.GetThrottleState()
+	require.Len(t, states, 1)
+	assert.Equal(t, host, states[0].Host)
+	assert.Zero(t, states[0].RequestsPerSecond)
+	// Retry-After is capped by MaxThrottlePeriod
+	assert.WithinDuration(t, time.Now().Add(300*time.Millisecond), states[0].Until, 100*time.Millisecond)
+	assert.Equal(t, 1.0, throttledGauge(t, reg, host))
+
+	// A request during the pause fails fast when its context expires
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Get(ctx, server.URL)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	// Otherwise it waits for the pause to end
+	start := time.Now()
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+	require.Eventually(t, func() bool { return len(client.GetThrottleState()) == 0 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0.0, throttledGauge(t, reg, host))
+}
+
+func TestAdaptiveThrottling_FakeClock(t *testing.T) {
+	t.Parallel()
+	server, calls := newThrottlingServer(t, map[string]string{"Retry-After": "30"})
+
+	clock := NewFakeClock(time.Unix(1000, 0))
+	client := New(Config{
+		Clock:              clock,
+		RateLimiterEnabled: true,
+		RateLimiterConfig:  RateLimiterConfig{RequestsPerSecond: 100, BurstCapacity: 10, AdaptiveThrottling: true},
+	}, "test-throttle-fake-clock")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	states := client.GetThrottleState()
+	require.Len(t, states, 1)
+	assert.Equal(t, clock.Now().Add(30*time.Second), states[0].Until)
+
+	done := make(chan int, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if !assert.NoError(t, err) {
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+
+	// The expiry timer and the waiting request
+	clock.BlockUntil(2)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, http.StatusOK, <-done)
+	require.Eventually(t, func() bool { return len(client.GetThrottleState()) == 0 }, time.Second, time.Millisecond)
+}
+
+func TestAdaptiveThrottling_LowersRate(t *testing.T) {
+	t.Parallel()
+	server, _ := newThrottlingServer(t, map[string]string{
+		"RateLimit-Remaining": "5",
+		"RateLimit-Reset":     "1",
+	})
+
+	client := New(Config{
+		MetricsEnabled:     new(bool),
+		RateLimiterEnabled: true,
+		RateLimiterConfig: RateLimiterConfig{
+			RequestsPerSecond:  100,
+			AdaptiveThrottling: true,
+		},
+	}, "test-throttle-rate")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	states := client.GetThrottleState()
+	require.Len(t, states, 1)
+	assert.Equal(t, 5.0, states[0].RequestsPerSecond)
+
+	// The first request uses the single token, the next one waits for 1/5 s
+	start := time.Now()
+	for range 2 {
+		resp, err = client.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestAdaptiveThrottling_DefaultPeriod(t *testing.T) {
+	t.Parallel()
+	server, _ := newThrottlingServer(t, nil)
+
+	client := New(Config{
+		RateLimiterEnabled: true,
+		RateLimiterConfig: RateLimiterConfig{
+			AdaptiveThrottling: true,
+			ThrottlePeriod:     100 * time.Millisecond,
+		},
+	}, "test-throttle-default")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Len(t, client.GetThrottleState(), 1)
+	require.Eventually(t, func() bool { return len(client.GetThrottleState()) == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestAdaptiveThrottling_Disabled(t *testing.T) {
+	t.Parallel()
+	server, _ := newThrottlingServer(t, map[string]string{"Retry-After": "30"})
+
+	client := New(Config{RateLimiterEnabled: true}, "test-throttle-disabled")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, client.GetThrottleState())
+}
+
+func TestPreemptiveThrottling(t *testing.T) {
+	t.Parallel()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1")
+		} else {
+			w.Header().Set("X-RateLimit-Remaining", "99")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		RateLimiterEnabled: true,
+		RateLimiterConfig: RateLimiterConfig{
+			PreemptiveThrottling: true,
+			MaxThrottlePeriod:    200 * time.Millisecond,
+		},
+	}, "test-throttle-preemptive")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	states := client.GetThrottleState()
+	require.Len(t, states, 1)
+	assert.Zero(t, states[0].RequestsPerSecond)
+
+	// The exhausted quota pauses the next request until the capped reset
+	start := time.Now()
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+
+	// A quota above the threshold doesn't throttle
+	require.Eventually(t, func() bool { return len(client.GetThrottleState()) == 0 }, time.Second, 10*time.Millisecond)
+	resp, err = client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, client.GetThrottleState())
+}
+
+func TestAdaptiveThrottling_RenewKeepsTokens(t *testing.T) {
+	t.Parallel()
+	clock := NewFakeClock(time.Unix(1000, 0))
+	throttle := newHostThrottle(RateLimiterConfig{
+		AdaptiveThrottling: true,
+		ThrottlePeriod:     time.Second,
+		MaxThrottlePeriod:  time.Minute,
+		Clock:              clock,
+	})
+	tooMany := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Ratelimit-Remaining": {"5"}, "Ratelimit-Reset": {"10"}},
+	}
+	// A cancelled context fails the wait unless a token is available right away
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	throttle.observe("api", tooMany)
+	require.NoError(t, throttle.wait(cancelled, "api"))
+	throttle.observe("api", tooMany)
+	assert.ErrorIs(t, throttle.wait(cancelled, "api"), context.Canceled)
+
+	clock.Advance(2 * time.Second)
+	assert.NoError(t, throttle.wait(cancelled, "api"))
+}
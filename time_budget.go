@@ -0,0 +1,73 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultTimeBudgetMaxAttempts caps the attempts within a RetryConfig.TimeBudget
+// when MaxAttempts is unset.
+const defaultTimeBudgetMaxAttempts = 100
+
+// latencyTracker keeps a moving average of the attempt latency per host, used to estimate
+// whether another attempt fits into the RetryConfig.TimeBudget.
+type latencyTracker struct {
+	mu    sync.Mutex
+	hosts map[string]float64 // seconds
+}
+
+// newLatencyTracker creates an empty latency tracker.
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{hosts: make(map[string]float64)}
+}
+
+// record adds the latency of an attempt to the average of the host.
+func (t *latencyTracker) record(host string, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ewma, ok := t.hosts[host]; ok {
+		t.hosts[host] = ewmaDecay*ewma + (1-ewmaDecay)*latency.Seconds()
+	} else {
+		t.hosts[host] = latency.Seconds()
+	}
+}
+
+// estimate returns the expected latency of an attempt to the host, zero if unknown.
+func (t *latencyTracker) estimate(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Duration(t.hosts[host] * float64(time.Second))
+}
+
+// validatedAttempts returns the number of attempts the timeouts must cover. Retries within
+// a TimeBudget adapt to the remaining time, so only the first attempt counts.
+func validatedAttempts(retryEnabled bool, retry RetryConfig) int {
+	switch {
+	case !retryEnabled:
+		return 1
+	case retry.TimeBudget > 0:
+		return 1
+	default:
+		return retry.MaxAttempts
+	}
+}
+
+// fitsTimeBudget checks if an attempt after delay is expected to finish within the
+// RetryConfig.TimeBudget of the request and its context deadline.
+func (rt *RoundTripper) fitsTimeBudget(retryCtx *retryContext, delay time.Duration) bool {
+	budget := retryCtx.config.RetryConfig.TimeBudget
+	if budget <= 0 {
+		return true
+	}
+	deadline := retryCtx.startTime.Add(budget)
+	if ctxDeadline, ok := retryCtx.ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	expected := rt.latency.estimate(retryCtx.host)
+	if perTry := retryCtx.config.PerTryTimeout; perTry > 0 && expected > perTry {
+		// An attempt never takes longer than its timeout
+		expected = perTry
+	}
+	return delay+expected <= deadline.Sub(rt.clock().Now())
+}
@@ -0,0 +1,155 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeBudget_RetriesBeyondMaxAttempts(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusServiceUnavailable})
+	defer server.Close()
+
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			BaseDelay:  5 * time.Millisecond,
+			MaxDelay:   10 * time.Millisecond,
+			TimeBudget: 200 * time.Millisecond,
+		},
+	}, "test-time-budget")
+	defer client.Close()
+
+	start := time.Now()
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Greater(t, server.GetRequestCount(), defaultMaxAttempts)
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestTimeBudget_StopsWhenAttemptDoesNotFit(t *testing.T) {
+	t.Parallel()
+	slow := TestResponse{StatusCode: http.StatusServiceUnavailable, Delay: 80 * time.Millisecond}
+	server := NewTestServer(slow, slow, slow, slow)
+	defer server.Close()
+
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			BaseDelay:  time.Millisecond,
+			MaxDelay:   time.Millisecond,
+			TimeBudget: 200 * time.Millisecond,
+		},
+	}, "test-time-budget-slow")
+	defer client.Close()
+
+	// The third attempt would end after the budget
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 2, server.GetRequestCount())
+}
+
+func TestTimeBudget_ContextDeadline(t *testing.T) {
+	t.Parallel()
+	slow := TestResponse{StatusCode: http.StatusServiceUnavailable, Delay: 80 * time.Millisecond}
+	server := NewTestServer(slow, slow)
+	defer server.Close()
+
+	client := New(Config{
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			BaseDelay:  time.Millisecond,
+			MaxDelay:   time.Millisecond,
+			TimeBudget: time.Minute,
+		},
+	}, "test-time-budget-deadline")
+	defer client.Close()
+
+	// The earlier context deadline leaves no room for a retry, so the response is returned
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+	resp, err := client.Get(ctx, server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 1, server.GetRequestCount())
+}
+
+func TestTimeBudget_FakeClock(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusServiceUnavailable})
+	defer server.Close()
+
+	clock := NewFakeClock(time.Now())
+	client := New(Config{
+		Timeout:      time.Hour,
+		Clock:        clock,
+		RetryEnabled: true,
+		RetryConfig: RetryConfig{
+			BaseDelay:  time.Minute,
+			MaxDelay:   time.Minute,
+			TimeBudget: 5 * time.Minute,
+		},
+	}, "test-time-budget-clock")
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	// Every retry wait passes a minute of the budget on the fake clock
+	for waiting := true; waiting; {
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+			waiting = false
+		case <-time.After(time.Millisecond):
+			clock.Advance(time.Minute)
+		}
+	}
+	// Attempts take no time on the fake clock: the first retry is immediate and the
+	// others start a minute apart, the last one at the end of the budget
+	assert.LessOrEqual(t, server.GetRequestCount(), 7)
+}
+
+func TestTimeBudget_Defaults(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, defaultTimeBudgetMaxAttempts, RetryConfig{TimeBudget: time.Second}.withDefaults().MaxAttempts)
+	assert.Equal(t, 5, RetryConfig{TimeBudget: time.Second, MaxAttempts: 5}.withDefaults().MaxAttempts)
+
+	// Retries within the budget adapt to the remaining time, so the timeouts cover one attempt
+	config := Config{
+		Timeout:       time.Second,
+		PerTryTimeout: 800 * time.Millisecond,
+		RetryEnabled:  true,
+		RetryConfig:   RetryConfig{TimeBudget: time.Second},
+	}
+	assert.NoError(t, config.ValidateTimeouts())
+}
+
+func TestLatencyTracker(t *testing.T) {
+	t.Parallel()
+	tracker := newLatencyTracker()
+	assert.Zero(t, tracker.estimate("api.example.com"))
+
+	tracker.record("api.example.com", 100*time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, tracker.estimate("api.example.com"))
+
+	tracker.record("api.example.com", 200*time.Millisecond)
+	assert.InDelta(t, float64(130*time.Millisecond), float64(tracker.estimate("api.example.com")), float64(time.Millisecond))
+	assert.Zero(t, tracker.estimate("other.example.com"))
+}
@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+)
+
+// TLSConfig contains TLS settings for the transport built by the client.
+// It is ignored when Config.Transport is set.
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM files with the client certificate for mutual TLS
+	CertFile string
+	KeyFile  string
+
+	// RootCAs is the set of root certificates used to verify servers (default: system roots)
+	RootCAs *x509.CertPool
+
+	// InsecureSkipVerify disables server certificate verification. Use only in tests
+	InsecureSkipVerify bool
+
+	// MinVersion is the minimum TLS version, e.g. tls.VersionTLS13 (default: TLS 1.2)
+	MinVersion uint16
+}
+
+// isZero reports whether no TLS settings were provided.
+func (tc TLSConfig) isZero() bool {
+	return tc.CertFile == "" && tc.KeyFile == "" && tc.RootCAs == nil &&
+		!tc.InsecureSkipVerify && tc.MinVersion == 0
+}
+
+// clientTLSConfig applies the settings on top of a copy of base, which may be nil.
+// A client certificate that fails to load is reported when the server asks for it,
+// so the error surfaces from the request instead of being lost in New.
+func (tc TLSConfig) clientTLSConfig(base *tls.Config) *tls.Config {
+	cfg := &tls.Config{}
+	if base != nil {
+		cfg = base.Clone()
+	}
+	cfg.RootCAs = tc.RootCAs
+	cfg.InsecureSkipVerify = tc.InsecureSkipVerify //nolint:gosec // explicitly requested by the user
+	cfg.MinVersion = tc.MinVersion
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+
+	if tc.CertFile != "" || tc.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			err = fmt.Errorf("failed to load client certificate: %w", err)
+			cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return nil, err
+			}
+		} else {
+			cfg.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	return cfg
+}
@@ -0,0 +1,141 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeClientCertificate generates a self-signed client certificate and writes it as PEM files.
+func writeClientCertificate(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile, cert
+}
+
+// newMTLSServer starts a TLS server that requires a client certificate signed by clientCert.
+func newMTLSServer(t *testing.T, clientCert *x509.Certificate) *httptest.Server {
+	t.Helper()
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Client-CN", r.TLS.PeerCertificates[0].Subject.CommonName)
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMutualTLS_WithClientOptions(t *testing.T) {
+	t.Parallel()
+	certFile, keyFile, cert := writeClientCertificate(t)
+	server := newMTLSServer(t, cert)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	client := New(Config{}, "test-mtls",
+		WithClientCertificate(certFile, keyFile),
+		WithRootCAs(roots),
+		WithTLSMinVersion(tls.VersionTLS12),
+	)
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "test-client", resp.Header.Get("X-Client-CN"))
+}
+
+func TestMutualTLS_WithoutCertificateFails(t *testing.T) {
+	t.Parallel()
+	_, _, cert := writeClientCertificate(t)
+	server := newMTLSServer(t, cert)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	client := New(Config{TLSConfig: TLSConfig{RootCAs: roots}}, "test-mtls-missing")
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL)
+	assert.Error(t, err)
+}
+
+func TestTLSConfig_CertificateLoadErrorSurfaces(t *testing.T) {
+	t.Parallel()
+	_, _, cert := writeClientCertificate(t)
+	server := newMTLSServer(t, cert)
+
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	client := New(Config{}, "test-mtls-load-error",
+		WithClientCertificate(missing, missing),
+		WithInsecureSkipVerify(true),
+	)
+	defer client.Close()
+
+	_, err := client.Get(context.Background(), server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load client certificate")
+}
+
+func TestTLSConfig_AppliedToTransport(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-tls-transport", WithInsecureSkipVerify(true), WithTLSMinVersion(tls.VersionTLS13))
+	defer client.Close()
+
+	transport := client.GetConfig().Transport.(*http.Transport)
+	require.NotNil(t, transport.TLSClientConfig)
+	assert.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+	assert.Equal(t, uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+
+	plain := New(Config{}, "test-tls-default")
+	defer plain.Close()
+	if defaultTLS := plain.GetConfig().Transport.(*http.Transport).TLSClientConfig; defaultTLS != nil {
+		assert.False(t, defaultTLS.InsecureSkipVerify)
+		assert.Zero(t, defaultTLS.MinVersion)
+	}
+}
@@ -2,11 +2,92 @@ package httpclient
 
 import (
 	"context"
+	"net/http"
+	"strings"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultErrorStatusThreshold is the lowest status code marking a span as an error.
+const defaultErrorStatusThreshold = http.StatusInternalServerError
+
+// TracingOptions customizes the spans created when Config.TracingEnabled is set.
+type TracingOptions struct {
+	// SpanNameFormatter names the span of a request
+	// (default: "HTTP GET /users/{id}" with a matching URL template, "HTTP GET" otherwise)
+	SpanNameFormatter func(req *http.Request) string
+
+	// URLTemplates are low-cardinality path templates such as "/users/{id}", where {name}
+	// matches one path segment; the first match is set as the http.route attribute
+	URLTemplates []string
+
+	// Attributes returns attributes added when the span starts
+	Attributes func(req *http.Request) []attribute.KeyValue
+
+	// ResponseAttributes returns attributes added when the request finished
+	ResponseAttributes func(resp *http.Response, err error) []attribute.KeyValue
+
+	// ErrorStatusThreshold marks spans of responses with this or a higher status code as errors;
+	// failed requests are always errors (default: 500)
+	ErrorStatusThreshold int
+}
+
+// withDefaults returns a copy of the options with default values.
+func (o TracingOptions) withDefaults() TracingOptions {
+	if o.ErrorStatusThreshold == 0 {
+		o.ErrorStatusThreshold = defaultErrorStatusThreshold
+	}
+	return o
+}
+
+// spanName returns the span name of the request and its WithPathParam template or matched URL template.
+func (o TracingOptions) spanName(req *http.Request) (name, route string) {
+	route, ok := PathTemplateFromContext(req.Context())
+	if !ok {
+		route = MatchURLTemplate(o.URLTemplates, req.URL.Path)
+	}
+	if o.SpanNameFormatter != nil {
+		return o.SpanNameFormatter(req), route
+	}
+	if route != "" {
+		return "HTTP " + req.Method + " " + route, route
+	}
+	return "HTTP " + req.Method, route
+}
+
+// MatchURLTemplate returns the first template matching the path, or "" if none matches.
+// A {name} segment matches any single non-empty path segment, other segments must be equal.
+func MatchURLTemplate(templates []string, path string) string {
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, template := range templates {
+		if matchTemplateSegments(strings.Split(strings.Trim(template, "/"), "/"), pathSegments) {
+			return template
+		}
+	}
+	return ""
+}
+
+// matchTemplateSegments compares template segments with path segments.
+func matchTemplateSegments(template, path []string) bool {
+	if len(template) != len(path) {
+		return false
+	}
+	for i, segment := range template {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if path[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // Tracer is a wrapper for OpenTelemetry tracing.
 type Tracer struct {
 	tracer trace.Tracer
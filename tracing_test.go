@@ -2,8 +2,18 @@ package httpclient
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -81,3 +91,125 @@ func TestTracerSpanFromContext(t *testing.T) {
 		t.Error("SpanFromContext should return a valid span from context with span")
 	}
 }
+
+func TestTracing_PropagatesTraceContext(t *testing.T) {
+	t.Parallel()
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer server.Close()
+
+	client := New(Config{
+		TracingEnabled:  true,
+		TracePropagator: propagation.TraceContext{},
+	}, "test-trace-propagation")
+	defer client.Close()
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	received := <-headers
+	assert.True(t, strings.HasPrefix(received.Get("Traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+	// The caller's request is not modified
+	assert.Empty(t, req.Header.Get("Traceparent"))
+}
+
+func TestTracing_NoPropagationWhenDisabled(t *testing.T) {
+	t.Parallel()
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer server.Close()
+
+	client := New(Config{TracePropagator: propagation.TraceContext{}}, "test-trace-propagation-off")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Empty(t, (<-headers).Get("Traceparent"))
+}
+
+func TestTracingOptions_Spans(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusOK, Headers: map[string]string{"Content-Type": "application/json"}},
+		TestResponse{StatusCode: http.StatusNotFound},
+	)
+	defer server.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	client := New(Config{
+		TracingEnabled: true,
+		TracingOptions: TracingOptions{
+			URLTemplates: []string{"/users/{id}/orders", "/users/{id}"},
+			Attributes: func(req *http.Request) []attribute.KeyValue {
+				return []attribute.KeyValue{attribute.String("tenant", req.Header.Get("X-Tenant"))}
+			},
+			ResponseAttributes: func(resp *http.Response, _ error) []attribute.KeyValue {
+				return []attribute.KeyValue{attribute.String("content.type", resp.Header.Get("Content-Type"))}
+			},
+			ErrorStatusThreshold: http.StatusBadRequest,
+		},
+	}, "test-tracing-options")
+	defer client.Close()
+	client.httpClient.Transport.(*RoundTripper).tracer = &Tracer{tracer: provider.Tracer("test")}
+
+	for _, path := range []string{"/users/42", "/health"} {
+		resp, err := client.Get(context.Background(), server.URL+path, WithHeader("X-Tenant", "acme"))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "HTTP GET /users/{id}", spans[0].Name)
+	assert.Equal(t, sdkcodes.Unset, spans[0].Status.Code)
+	assert.Contains(t, spans[0].Attributes, attribute.String("http.route", "/users/{id}"))
+	assert.Contains(t, spans[0].Attributes, attribute.String("tenant", "acme"))
+	assert.Contains(t, spans[0].Attributes, attribute.String("content.type", "application/json"))
+
+	assert.Equal(t, "HTTP GET", spans[1].Name)
+	assert.Equal(t, sdkcodes.Error, spans[1].Status.Code)
+}
+
+func TestTracingOptions_SpanNameFormatter(t *testing.T) {
+	t.Parallel()
+	options := TracingOptions{
+		SpanNameFormatter: func(req *http.Request) string { return req.Method + " " + req.URL.Host },
+		URLTemplates:      []string{"/items/{id}"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "http://api.example.com/items/7", nil)
+	name, route := options.spanName(req)
+	assert.Equal(t, "POST api.example.com", name)
+	assert.Equal(t, "/items/{id}", route)
+}
+
+func TestMatchURLTemplate(t *testing.T) {
+	t.Parallel()
+	templates := []string{"/users/{id}", "/users/{id}/orders/{order}", "/users/me"}
+	assert.Equal(t, "/users/{id}", MatchURLTemplate(templates, "/users/42"))
+	assert.Equal(t, "/users/{id}", MatchURLTemplate(templates, "/users/me/"))
+	assert.Equal(t, "/users/{id}/orders/{order}", MatchURLTemplate(templates, "/users/42/orders/7"))
+	assert.Empty(t, MatchURLTemplate(templates, "/users"))
+	assert.Empty(t, MatchURLTemplate(templates, "/users/42/orders"))
+	assert.Empty(t, MatchURLTemplate(templates, "/users//"))
+}
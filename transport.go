@@ -0,0 +1,146 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// Default connection pool settings.
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultDialTimeout         = 30 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+)
+
+// TransportTuning contains connection pool and dialer settings for the transport
+// built by the client. It is ignored when Config.Transport is set.
+type TransportTuning struct {
+	// MaxIdleConns limits idle connections across all hosts (default: 100)
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits idle connections kept per host (default: 10)
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost limits total connections per host, including active ones (default: 0 - unlimited)
+	MaxConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept in the pool (default: 90s)
+	IdleConnTimeout time.Duration
+
+	// TLSHandshakeTimeout limits the TLS handshake duration (default: 10s)
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout limits the wait for response headers after the request is written
+	// (default: 0 - limited only by PerTryTimeout)
+	ResponseHeaderTimeout time.Duration
+
+	// DialTimeout limits TCP connection establishment (default: 30s)
+	DialTimeout time.Duration
+
+	// KeepAlive is the TCP keep-alive period (default: 30s)
+	KeepAlive time.Duration
+
+	// DisableKeepAlives disables HTTP keep-alives, using each connection for a single request
+	DisableKeepAlives bool
+
+	// AddressFamily selects the IP addresses dialed for dual-stack hosts (default: AddressFamilyAny)
+	AddressFamily AddressFamily
+
+	// FallbackDelay is how long the dialer waits for the preferred address family before
+	// racing the other one (default: 300ms, negative: dial addresses one by one)
+	FallbackDelay time.Duration
+
+	// StaticAddresses pins hosts to IP addresses dialed instead of resolving the host,
+	// e.g. {"api.example.com": {"10.0.0.5", "2001:db8::5"}}; the port comes from the URL
+	StaticAddresses map[string][]string
+
+	// TrackConnections records the connections of the transport for Client.PoolStats
+	TrackConnections bool
+
+	// ConnMaxLifetime closes idle connections once they are older than this, so long-lived
+	// connections don't outlive NAT or firewall sessions (default: 0 - no limit).
+	// It implies TrackConnections
+	ConnMaxLifetime time.Duration
+
+	// ExpectContinueTimeout is how long a request with Expect: 100-continue (see
+	// WithExpectContinue) waits for the server's 100 Continue before sending the body
+	// (default: 1s)
+	ExpectContinueTimeout time.Duration
+}
+
+// withDefaults applies default values to the transport tuning.
+func (tt TransportTuning) withDefaults() TransportTuning {
+	if tt.MaxIdleConns == 0 {
+		tt.MaxIdleConns = defaultMaxIdleConns
+	}
+
+	if tt.MaxIdleConnsPerHost == 0 {
+		tt.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+
+	if tt.IdleConnTimeout == 0 {
+		tt.IdleConnTimeout = defaultIdleConnTimeout
+	}
+
+	if tt.TLSHandshakeTimeout == 0 {
+		tt.TLSHandshakeTimeout = defaultTLSHandshakeTimeout
+	}
+
+	if tt.DialTimeout == 0 {
+		tt.DialTimeout = defaultDialTimeout
+	}
+
+	if tt.KeepAlive == 0 {
+		tt.KeepAlive = defaultKeepAlive
+	}
+
+	if tt.ExpectContinueTimeout == 0 {
+		tt.ExpectContinueTimeout = defaultExpectContinueTimeout
+	}
+
+	return tt
+}
+
+// newTransport builds the client's own http.Transport from the configuration.
+// It starts from a clone of http.DefaultTransport to keep its proxy and HTTP/2 behavior.
+func newTransport(c Config) *http.Transport {
+	tuning := c.TransportTuning
+
+	var transport *http.Transport
+	if base, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = base.Clone()
+	} else {
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment, ForceAttemptHTTP2: true}
+	}
+
+	transport.DialContext = newDialContext(tuning)
+	transport.Proxy = newProxyFunc(c)
+	transport.MaxIdleConns = tuning.MaxIdleConns
+	transport.MaxIdleConnsPerHost = tuning.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = tuning.MaxConnsPerHost
+	transport.IdleConnTimeout = tuning.IdleConnTimeout
+	transport.TLSHandshakeTimeout = tuning.TLSHandshakeTimeout
+	transport.ResponseHeaderTimeout = tuning.ResponseHeaderTimeout
+	transport.DisableKeepAlives = tuning.DisableKeepAlives
+	transport.ExpectContinueTimeout = tuning.ExpectContinueTimeout
+	transport.DisableCompression = c.Passthrough
+
+	if !c.TLSConfig.isZero() {
+		transport.TLSClientConfig = c.TLSConfig.clientTLSConfig(transport.TLSClientConfig)
+	}
+
+	// Cache TLS sessions, so new connections to a host resume them with an abbreviated
+	// handshake, e.g. after Warmup
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if transport.TLSClientConfig.ClientSessionCache == nil {
+		transport.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+
+	return transport
+}
@@ -0,0 +1,38 @@
+package httpclient
+
+import "net/http"
+
+// wrapTransport applies the Config.TransportMiddleware wrappers to the base transport.
+// The first wrapper is the outermost one; nil wrappers and wrappers returning nil are skipped.
+func wrapTransport(base http.RoundTripper, wrappers []func(http.RoundTripper) http.RoundTripper) http.RoundTripper {
+	if len(wrappers) == 0 {
+		return base
+	}
+	wrapped := base
+	for i := len(wrappers) - 1; i >= 0; i-- {
+		if wrappers[i] == nil {
+			continue
+		}
+		if next := wrappers[i](wrapped); next != nil {
+			wrapped = next
+		}
+	}
+	return &wrappedTransport{RoundTripper: wrapped, base: base}
+}
+
+// wrappedTransport keeps the idle connections of the base transport closable through
+// wrappers that don't forward CloseIdleConnections.
+type wrappedTransport struct {
+	http.RoundTripper
+	base http.RoundTripper
+}
+
+// CloseIdleConnections closes idle connections of the outermost wrapper, or of the base
+// transport if the wrapper doesn't support it.
+func (t *wrappedTransport) CloseIdleConnections() {
+	if _, ok := t.RoundTripper.(interface{ CloseIdleConnections() }); ok {
+		closeIdleConnections(t.RoundTripper)
+		return
+	}
+	closeIdleConnections(t.base)
+}
@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransportMiddleware_WrapsEveryAttempt(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(
+		TestResponse{StatusCode: http.StatusServiceUnavailable},
+		TestResponse{StatusCode: http.StatusOK},
+	)
+	defer server.Close()
+
+	var mu sync.Mutex
+	var order []string
+	wrapper := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				req = req.Clone(req.Context())
+				req.Header.Set("X-Wrapped-By", req.Header.Get("X-Wrapped-By")+name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{
+		RetryEnabled:         true,
+		RetryConfig:          RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		TransportMiddleware:  []func(http.RoundTripper) http.RoundTripper{wrapper("outer"), nil, wrapper("inner")},
+		MetricsBackend:       MetricsBackendPrometheus,
+		PrometheusRegisterer: reg,
+	}, "test-transport-middleware")
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, []string{"outer", "inner", "outer", "inner"}, order)
+	require.Len(t, server.RequestLog, 2)
+	assert.Equal(t, "outerinner", server.RequestLog[1].Headers["X-Wrapped-By"])
+	// Metrics stay outside the wrappers and see both attempts
+	assert.Equal(t, 1.0, circuitBreakerMetric(t, reg, "http_client_retries_total",
+		map[string]string{"client_name": "test-transport-middleware"}))
+}
+
+// idleCloser counts CloseIdleConnections calls.
+type idleCloser struct {
+	http.RoundTripper
+	closed atomic.Int32
+}
+
+func (c *idleCloser) CloseIdleConnections() { c.closed.Add(1) }
+
+func TestTransportMiddleware_CloseIdleConnections(t *testing.T) {
+	t.Parallel()
+	base := &idleCloser{RoundTripper: http.DefaultTransport}
+	passthrough := func(next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(next.RoundTrip)
+	}
+	client := New(Config{
+		Transport:           base,
+		TransportMiddleware: []func(http.RoundTripper) http.RoundTripper{passthrough},
+	}, "test-transport-middleware-idle")
+
+	require.NoError(t, client.Close())
+	assert.Equal(t, int32(1), base.closed.Load())
+}
@@ -0,0 +1,71 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportTuning_Defaults(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-transport-defaults")
+	defer client.Close()
+
+	transport, ok := client.GetConfig().Transport.(*http.Transport)
+	require.True(t, ok, "client should build its own *http.Transport")
+	assert.NotSame(t, http.DefaultTransport, transport)
+	assert.Equal(t, defaultMaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 0, transport.MaxConnsPerHost)
+	assert.Equal(t, defaultIdleConnTimeout, transport.IdleConnTimeout)
+	assert.Equal(t, defaultTLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	assert.NotNil(t, transport.DialContext)
+	assert.NotNil(t, transport.Proxy, "proxy from environment should be preserved")
+}
+
+func TestTransportTuning_FromConfig(t *testing.T) {
+	t.Parallel()
+	client := New(Config{
+		TransportTuning: TransportTuning{
+			MaxIdleConns:          500,
+			MaxIdleConnsPerHost:   50,
+			MaxConnsPerHost:       80,
+			IdleConnTimeout:       30 * time.Second,
+			ResponseHeaderTimeout: 3 * time.Second,
+			DisableKeepAlives:     true,
+		},
+	}, "test-transport-config")
+	defer client.Close()
+
+	transport := client.GetConfig().Transport.(*http.Transport)
+	assert.Equal(t, 500, transport.MaxIdleConns)
+	assert.Equal(t, 50, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 80, transport.MaxConnsPerHost)
+	assert.Equal(t, 30*time.Second, transport.IdleConnTimeout)
+	assert.Equal(t, 3*time.Second, transport.ResponseHeaderTimeout)
+	assert.True(t, transport.DisableKeepAlives)
+	assert.Equal(t, defaultTLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+}
+
+func TestWithTransportTuning(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-transport-option", WithTransportTuning(TransportTuning{MaxConnsPerHost: 7}))
+	defer client.Close()
+
+	transport := client.GetConfig().Transport.(*http.Transport)
+	assert.Equal(t, 7, transport.MaxConnsPerHost)
+	assert.Equal(t, defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+}
+
+func TestTransportTuning_IgnoredForCustomTransport(t *testing.T) {
+	t.Parallel()
+	custom := &http.Transport{MaxIdleConns: 3}
+	client := New(Config{Transport: custom, TransportTuning: TransportTuning{MaxIdleConns: 500}}, "test-transport-custom")
+	defer client.Close()
+
+	assert.Same(t, custom, client.GetConfig().Transport)
+	assert.Equal(t, 3, custom.MaxIdleConns)
+}
@@ -0,0 +1,209 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Results of warm-up connections recorded in http_client_warmup_connections_total.
+const (
+	WarmupResultSuccess = "success"
+	WarmupResultError   = "error"
+)
+
+// WarmupResult is the outcome of warming up connections to a host.
+type WarmupResult struct {
+	// Host is the warmed up host as given to Warmup
+	Host string
+	// Connections is the number of connections opened; HTTP/2 hosts multiplex the
+	// warm-up requests over a single connection
+	Connections int
+	// Duration is how long the warm-up of the host took
+	Duration time.Duration
+	// Err joins the errors of the failed warm-up requests
+	Err error
+}
+
+// Warmup opens up to n connections to each host in parallel, so the first requests after
+// startup reuse pooled connections instead of paying for DNS, TCP and TLS handshakes.
+// Hosts are origins such as "https://api.example.com" or "api.example.com:8443"
+// (https is assumed without a scheme).
+//
+// The connections are opened by concurrent HEAD / requests sent straight to the transport
+// (a single one for HTTP/2 hosts, whose requests share a connection):
+// they skip middlewares, retries, rate limiting and the circuit breaker, and any response
+// counts as a warmed up connection. Connections stay idle in the pool, so n above
+// TransportTuning.MaxIdleConnsPerHost opens connections that are closed right away; n is
+// capped by MaxConnsPerHost of an *http.Transport. A host whose first warm-up request fails
+// is not tried again.
+// Every connection attempt is recorded in http_client_warmup_connections_total.
+// The error joins the errors of all hosts.
+func (c *Client) Warmup(ctx context.Context, hosts []string, n int) ([]WarmupResult, error) {
+	n = max(n, 1)
+	if transport, ok := c.config.Transport.(*http.Transport); ok && transport.MaxConnsPerHost > 0 {
+		n = min(n, transport.MaxConnsPerHost)
+	}
+	results := make([]WarmupResult, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.warmupHost(ctx, host, n)
+		}()
+	}
+	wg.Wait()
+
+	errs := make([]error, 0, len(results))
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("warm-up of %s: %w", result.Host, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// warmupHost opens n connections to the host. The first request finds out the protocol:
+// an HTTP/2 connection serves all requests, so no more are opened. Otherwise n requests are
+// sent concurrently and each holds its connection until all of them got one, so they can't
+// reuse each other's connections.
+func (c *Client) warmupHost(ctx context.Context, host string, n int) WarmupResult {
+	start := time.Now()
+	result := WarmupResult{Host: host}
+	target, err := warmupURL(host)
+	if err != nil {
+		result.Err = err
+		c.metrics.RecordWarmupConnection(ctx, host, WarmupResultError)
+		return result
+	}
+	metricsHost := getHost(target)
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	record := func(opened bool, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+			c.metrics.RecordWarmupConnection(ctx, metricsHost, WarmupResultError)
+		case opened:
+			result.Connections++
+			c.metrics.RecordWarmupConnection(ctx, metricsHost, WarmupResultSuccess)
+		}
+	}
+
+	proto, opened, err := c.warmupRequest(ctx, target, nil)
+	record(opened, err)
+	if err == nil && proto < 2 {
+		barrier := newWarmupBarrier(n)
+		var wg sync.WaitGroup
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, opened, err := c.warmupRequest(ctx, target, barrier)
+				record(opened, err)
+			}()
+		}
+		wg.Wait()
+	}
+
+	result.Duration = time.Since(start)
+	result.Err = errors.Join(errs...)
+	return result
+}
+
+// warmupBarrier holds the connections of concurrent warm-up requests until every request
+// got a connection or failed.
+type warmupBarrier struct {
+	pending atomic.Int32
+	done    chan struct{}
+}
+
+// newWarmupBarrier creates a barrier for n requests.
+func newWarmupBarrier(n int) *warmupBarrier {
+	b := &warmupBarrier{done: make(chan struct{})}
+	b.pending.Store(int32(n))
+	return b
+}
+
+// arrive marks a request as connected or failed.
+func (b *warmupBarrier) arrive() {
+	if b.pending.Add(-1) == 0 {
+		close(b.done)
+	}
+}
+
+// wait blocks until all requests arrived or the context is done.
+func (b *warmupBarrier) wait(ctx context.Context) {
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+	}
+}
+
+// warmupRequest sends a HEAD request and returns the HTTP major version of the response
+// and whether the request opened a new connection. With a barrier the request holds its
+// connection until the barrier opens.
+func (c *Client) warmupRequest(ctx context.Context, target *url.URL, barrier *warmupBarrier) (int, bool, error) {
+	var opened, arrived bool
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			opened = !info.Reused
+			if barrier != nil {
+				arrived = true
+				barrier.arrive()
+				barrier.wait(ctx)
+			}
+		},
+	}
+	if barrier != nil {
+		defer func() {
+			if !arrived {
+				barrier.arrive()
+			}
+		}()
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodHead, target.String(), nil)
+	if err != nil {
+		return 0, false, err
+	}
+	if c.config.UserAgent != "" {
+		req.Header.Set("User-Agent", c.config.UserAgent)
+	}
+	resp, err := c.config.Transport.RoundTrip(req)
+	if err != nil {
+		return 0, false, classifyRequestError(req, err)
+	}
+	// Draining lets the transport return the connection to the pool
+	drainAndClose(resp.Body)
+	return resp.ProtoMajor, opened, nil
+}
+
+// warmupURL returns the root URL of a host given as an origin or host[:port].
+func warmupURL(host string) (*url.URL, error) {
+	raw := host
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid warm-up host %q: %w", host, err)
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid warm-up host %q", host)
+	}
+	return &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}, nil
+}
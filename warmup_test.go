@@ -0,0 +1,149 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConnCountingServer creates an unstarted server counting accepted connections.
+func newConnCountingServer(opened *atomic.Int32) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+	return server
+}
+
+// warmupCounter returns http_client_warmup_connections_total for the result.
+func warmupCounter(t *testing.T, reg *prometheus.Registry, result string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var total float64
+	for _, family := range families {
+		if family.GetName() != MetricWarmupConnections {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "result" && label.GetValue() == result {
+					total += m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestClient_Warmup(t *testing.T) {
+	t.Parallel()
+	var opened atomic.Int32
+	server := newConnCountingServer(&opened)
+	server.Start()
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{MetricsBackend: MetricsBackendPrometheus, PrometheusRegisterer: reg}, "test-warmup")
+	defer client.Close()
+
+	results, err := client.Warmup(context.Background(), []string{server.URL}, 3)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, server.URL, results[0].Host)
+	assert.Equal(t, 3, results[0].Connections)
+	assert.Equal(t, int32(3), opened.Load())
+	assert.Equal(t, 3.0, warmupCounter(t, reg, WarmupResultSuccess))
+
+	// Requests reuse the warmed up connections
+	for range 3 {
+		resp, err := client.Get(context.Background(), server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, int32(3), opened.Load())
+}
+
+func TestClient_WarmupHTTP2(t *testing.T) {
+	t.Parallel()
+	var opened atomic.Int32
+	server := newConnCountingServer(&opened)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	client := New(Config{TLSConfig: TLSConfig{RootCAs: roots}}, "test-warmup-h2")
+	defer client.Close()
+
+	results, err := client.Warmup(context.Background(), []string{server.URL}, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 1, results[0].Connections)
+	assert.Equal(t, int32(1), opened.Load())
+
+	resp, err := client.Get(context.Background(), server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, int32(1), opened.Load())
+}
+
+func TestClient_WarmupErrors(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewRegistry()
+	client := New(Config{MetricsBackend: MetricsBackendPrometheus, PrometheusRegisterer: reg}, "test-warmup-errors")
+	defer client.Close()
+
+	refused := closedPortURL(t)
+	results, err := client.Warmup(context.Background(), []string{"ftp://example.com", refused}, 2)
+	require.Error(t, err)
+	require.Len(t, results, 2)
+	assert.ErrorContains(t, results[0].Err, `invalid warm-up host "ftp://example.com"`)
+	assert.ErrorIs(t, results[1].Err, ErrConnection)
+	assert.Zero(t, results[1].Connections)
+	// The refused host is not tried again after the first request
+	assert.Equal(t, 2.0, warmupCounter(t, reg, WarmupResultError))
+}
+
+func TestClient_WarmupMaxConnsPerHost(t *testing.T) {
+	t.Parallel()
+	var opened atomic.Int32
+	server := newConnCountingServer(&opened)
+	server.Start()
+	defer server.Close()
+
+	client := New(Config{TransportTuning: TransportTuning{MaxConnsPerHost: 2}}, "test-warmup-max-conns")
+	defer client.Close()
+
+	results, err := client.Warmup(context.Background(), []string{server.URL}, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 2, results[0].Connections)
+	assert.Equal(t, int32(2), opened.Load())
+}
+
+func TestWarmupURL(t *testing.T) {
+	t.Parallel()
+	u, err := warmupURL("api.example.com:8443")
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com:8443/", u.String())
+
+	u, err = warmupURL("http://api.example.com/ignored?q=1")
+	require.NoError(t, err)
+	assert.Equal(t, "http://api.example.com/", u.String())
+
+	_, err = warmupURL("https://")
+	assert.Error(t, err)
+}
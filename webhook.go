@@ -0,0 +1,303 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers of the Standard Webhooks signature scheme set by WebhookSender.
+const (
+	WebhookIDHeader        = "Webhook-Id"
+	WebhookTimestampHeader = "Webhook-Timestamp"
+	WebhookSignatureHeader = "Webhook-Signature"
+)
+
+// Results of webhook deliveries, the result label of the webhook delivery metric.
+const (
+	WebhookResultDelivered = "delivered"
+	WebhookResultRetried   = "retried"
+	WebhookResultFailed    = "failed"
+)
+
+// ErrDuplicateWebhook is returned by WebhookSender.Send for an event already sent to the
+// destination within WebhookConfig.DedupeWindow.
+var ErrDuplicateWebhook = errors.New("webhook event already sent to the destination")
+
+// ErrInvalidWebhookSignature is returned by VerifyWebhookSignature when no signature matches.
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// WebhookRetrySchedule is the default delivery schedule of WebhookSender: after the first
+// attempt the event is redelivered after 5 seconds, 5 minutes, 30 minutes, 2 hours, 5 hours
+// and twice after 10 hours, about 27 hours in total.
+var WebhookRetrySchedule = []time.Duration{
+	5 * time.Second,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	5 * time.Hour,
+	10 * time.Hour,
+	10 * time.Hour,
+}
+
+// WebhookConfig contains settings of a WebhookSender.
+type WebhookConfig struct {
+	// Secret is the key of the HMAC-SHA256 signatures
+	Secret []byte
+
+	// Schedule lists the delays before the redeliveries of an event (default: WebhookRetrySchedule)
+	Schedule []time.Duration
+
+	// DedupeWindow is how long an event ID is remembered per destination (default: 24h)
+	DedupeWindow time.Duration
+
+	// Workers is the number of concurrent deliveries (default: 4)
+	Workers int
+
+	// MaxQueueSize is the maximum number of pending deliveries (default: 1000)
+	MaxQueueSize int
+
+	// Store persists pending deliveries (default: NewMemoryQueueStore())
+	Store QueueStore
+
+	// OnDelivered is called after an event is delivered
+	OnDelivered func(delivery WebhookDelivery)
+
+	// OnFailed is called when an event is dropped after the last attempt of the schedule
+	OnFailed func(delivery WebhookDelivery, err error)
+}
+
+// withDefaults applies default values to the webhook configuration.
+func (c WebhookConfig) withDefaults() WebhookConfig {
+	if len(c.Schedule) == 0 {
+		c.Schedule = WebhookRetrySchedule
+	}
+	if c.DedupeWindow <= 0 {
+		c.DedupeWindow = 24 * time.Hour
+	}
+	return c
+}
+
+// WebhookEvent is an event sent by a WebhookSender.
+type WebhookEvent struct {
+	// ID identifies the event for deduplication by the sender and the receiver
+	ID string
+	// Payload is encoded as JSON; string and []byte values are sent as is
+	Payload any
+}
+
+// WebhookDelivery describes a finished delivery of an event.
+type WebhookDelivery struct {
+	// EventID is the ID of the event
+	EventID string
+	// URL is the destination of the event
+	URL string
+	// Attempts is the number of delivery attempts
+	Attempts int
+}
+
+// WebhookSender delivers signed webhook events with a retry schedule over hours. Events are
+// queued like Client.Enqueue requests, each delivery is signed with a fresh timestamp
+// following the Standard Webhooks scheme:
+//
+//	Webhook-Signature: v1,<base64 HMAC-SHA256 of ID + "." + TIMESTAMP + "." + BODY>
+//
+// and deliveries are counted per destination host in the webhook delivery metric.
+type WebhookSender struct {
+	client *Client
+	config WebhookConfig
+	clock  Clock
+
+	mu    sync.Mutex
+	sent  map[string]time.Time // by destination and event ID
+	swept time.Time            // last time expired keys were dropped
+}
+
+// NewWebhookSender creates a sender delivering events through a client scoped from c with
+// name as the client_name label. The retries of the client are disabled: the schedule
+// replaces them. The deliveries pending in WebhookConfig.Store are resumed right away; an error
+// loading them is returned. Close stops the deliveries.
+func (c *Client) NewWebhookSender(name string, config WebhookConfig) (*WebhookSender, error) {
+	config = config.withDefaults()
+	s := &WebhookSender{
+		client: c.Scope(name, ScopeConfig{DisableRetry: true}),
+		config: config,
+		clock:  clockOrDefault(c.config.Clock),
+		sent:   make(map[string]time.Time),
+	}
+	s.client.config.AsyncQueue = AsyncQueueConfig{
+		Workers:        config.Workers,
+		MaxQueueSize:   config.MaxQueueSize,
+		Schedule:       config.Schedule,
+		Store:          config.Store,
+		BeforeDelivery: s.sign,
+		OnDelivered:    s.delivered,
+		OnFailed:       s.failed,
+	}
+	if _, err := s.client.startQueue(); err != nil {
+		_ = s.client.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Send queues the event for delivery to url. An event already sent to url within the
+// DedupeWindow returns ErrDuplicateWebhook.
+func (s *WebhookSender) Send(ctx context.Context, url string, event WebhookEvent) error {
+	if event.ID == "" {
+		return NewConfigurationError("WebhookEvent.ID", event.ID, "must not be empty")
+	}
+	var body []byte
+	switch payload := event.Payload.(type) {
+	case string:
+		body = []byte(payload)
+	case []byte:
+		body = payload
+	default:
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+		body = data
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	key := url + "\n" + event.ID
+	if !s.remember(key) {
+		return ErrDuplicateWebhook
+	}
+	_, err = s.client.Enqueue(req, WithContentType("application/json"), WithHeader(WebhookIDHeader, event.ID))
+	if err != nil {
+		s.forget(key)
+	}
+	return err
+}
+
+// Close stops the deliveries. Pending events stay in the store.
+func (s *WebhookSender) Close() error {
+	return s.client.Close()
+}
+
+// remember records the key unless it was sent within the dedupe window. Expired keys are
+// dropped at most once per window, so sends don't scan the keys each time.
+func (s *WebhookSender) remember(key string) bool {
+	now := s.clock.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.swept) >= s.config.DedupeWindow {
+		for k, sent := range s.sent {
+			if now.Sub(sent) >= s.config.DedupeWindow {
+				delete(s.sent, k)
+			}
+		}
+		s.swept = now
+	}
+	if sent, ok := s.sent[key]; ok && now.Sub(sent) < s.config.DedupeWindow {
+		return false
+	}
+	s.sent[key] = now
+	return true
+}
+
+// forget removes a key of an event that couldn't be queued.
+func (s *WebhookSender) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sent, key)
+}
+
+// sign sets the timestamp and signature headers of a delivery.
+func (s *WebhookSender) sign(req *http.Request, queued QueuedRequest) error {
+	if queued.Attempts > 0 {
+		s.client.metrics.RecordWebhookDelivery(req.Context(), req.URL.Hostname(), WebhookResultRetried)
+	}
+	timestamp := strconv.FormatInt(s.clock.Now().Unix(), 10)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	signature := webhookSignature(s.config.Secret, queued.Header.Get(WebhookIDHeader), timestamp, queued.Body)
+	req.Header.Set(WebhookSignatureHeader, "v1,"+signature)
+	return nil
+}
+
+// delivered records and reports a delivered event.
+func (s *WebhookSender) delivered(queued QueuedRequest, _ *http.Response) {
+	delivery := webhookDelivery(queued)
+	s.client.metrics.RecordWebhookDelivery(context.Background(), hostOf(delivery.URL), WebhookResultDelivered)
+	if s.config.OnDelivered != nil {
+		s.config.OnDelivered(delivery)
+	}
+}
+
+// failed records and reports an event dropped after the last attempt.
+func (s *WebhookSender) failed(queued QueuedRequest, err error) {
+	delivery := webhookDelivery(queued)
+	s.client.metrics.RecordWebhookDelivery(context.Background(), hostOf(delivery.URL), WebhookResultFailed)
+	if s.config.OnFailed != nil {
+		s.config.OnFailed(delivery, err)
+	}
+}
+
+// webhookDelivery describes the queued delivery of an event.
+func webhookDelivery(queued QueuedRequest) WebhookDelivery {
+	return WebhookDelivery{EventID: queued.Header.Get(WebhookIDHeader), URL: queued.URL, Attempts: queued.Attempts}
+}
+
+// hostOf returns the host name of the URL, empty if it doesn't parse.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// webhookSignature computes the base64 HMAC-SHA256 of the signed content of a webhook.
+func webhookSignature(secret []byte, id, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks the signature headers of a webhook sent by a WebhookSender or
+// another Standard Webhooks sender. Timestamps further than tolerance from now are rejected;
+// a zero tolerance skips the check.
+func VerifyWebhookSignature(secret []byte, header http.Header, body []byte, tolerance time.Duration) error {
+	id := header.Get(WebhookIDHeader)
+	timestamp := header.Get(WebhookTimestampHeader)
+	if id == "" || timestamp == "" {
+		return ErrInvalidWebhookSignature
+	}
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return ErrInvalidWebhookSignature
+		}
+		if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidWebhookSignature)
+		}
+	}
+
+	expected := webhookSignature(secret, id, timestamp, body)
+	// Several space-separated signatures are sent while secrets rotate
+	for _, signature := range strings.Fields(header.Get(WebhookSignatureHeader)) {
+		version, value, ok := strings.Cut(signature, ",")
+		if ok && version == "v1" && hmac.Equal([]byte(value), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidWebhookSignature
+}
@@ -0,0 +1,204 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSender_DeliversSignedEvents(t *testing.T) {
+	t.Parallel()
+	secret := []byte("whsec")
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, VerifyWebhookSignature(secret, r.Header, body, time.Minute))
+		assert.Equal(t, "evt_1", r.Header.Get(WebhookIDHeader))
+		assert.JSONEq(t, `{"type":"invoice.paid"}`, string(body))
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := New(Config{MetricsBackend: MetricsBackendPrometheus, PrometheusRegisterer: reg}, "test-webhook")
+	defer client.Close()
+
+	delivered := make(chan WebhookDelivery, 1)
+	sender, err := client.NewWebhookSender("test-webhook-sender", WebhookConfig{
+		Secret:      secret,
+		Schedule:    []time.Duration{time.Millisecond},
+		OnDelivered: func(delivery WebhookDelivery) { delivered <- delivery },
+	})
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.NoError(t, sender.Send(context.Background(), server.URL, WebhookEvent{
+		ID:      "evt_1",
+		Payload: map[string]string{"type": "invoice.paid"},
+	}))
+
+	select {
+	case delivery := <-delivered:
+		assert.Equal(t, "evt_1", delivery.EventID)
+		assert.Equal(t, server.URL, delivery.URL)
+		assert.Equal(t, 2, delivery.Attempts)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	labels := map[string]string{"client_name": "test-webhook-sender", "destination": "127.0.0.1"}
+	labels["result"] = WebhookResultDelivered
+	assert.InDelta(t, 1, circuitBreakerMetric(t, reg, MetricWebhookDeliveries, labels), 0)
+	labels["result"] = WebhookResultRetried
+	assert.InDelta(t, 1, circuitBreakerMetric(t, reg, MetricWebhookDeliveries, labels), 0)
+}
+
+func TestWebhookSender_DedupesAndFails(t *testing.T) {
+	t.Parallel()
+	server := NewTestServer(TestResponse{StatusCode: http.StatusInternalServerError})
+	defer server.Close()
+
+	client := New(Config{}, "test-webhook-dedupe")
+	defer client.Close()
+
+	failed := make(chan WebhookDelivery, 2)
+	sender, err := client.NewWebhookSender("test-webhook-dedupe-sender", WebhookConfig{
+		Secret:   []byte("whsec"),
+		Schedule: []time.Duration{time.Millisecond, time.Millisecond},
+		OnFailed: func(delivery WebhookDelivery, err error) {
+			assert.True(t, IsHTTPError(err))
+			failed <- delivery
+		},
+	})
+	require.NoError(t, err)
+	defer sender.Close()
+
+	ctx := context.Background()
+	require.NoError(t, sender.Send(ctx, server.URL, WebhookEvent{ID: "evt_1", Payload: `{}`}))
+	require.ErrorIs(t, sender.Send(ctx, server.URL, WebhookEvent{ID: "evt_1", Payload: `{}`}), ErrDuplicateWebhook)
+	// The same event may go to another destination
+	require.NoError(t, sender.Send(ctx, server.URL+"/other", WebhookEvent{ID: "evt_1", Payload: `{}`}))
+
+	var configErr *ConfigurationError
+	require.ErrorAs(t, sender.Send(ctx, server.URL, WebhookEvent{}), &configErr)
+
+	for range 2 {
+		select {
+		case delivery := <-failed:
+			assert.Equal(t, 3, delivery.Attempts)
+		case <-time.After(5 * time.Second):
+			t.Fatal("event was not dropped")
+		}
+	}
+	// Retries of the client are disabled, each attempt is a single request
+	assert.Equal(t, 6, server.GetRequestCount())
+}
+
+func TestWebhookSender_ResumesStoredDeliveries(t *testing.T) {
+	t.Parallel()
+	secret := []byte("whsec")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, VerifyWebhookSignature(secret, r.Header, body, time.Minute))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// A delivery left in the store by a previous process
+	store := NewMemoryQueueStore()
+	require.NoError(t, store.Save(context.Background(), QueuedRequest{
+		ID:     "req_1",
+		Method: http.MethodPost,
+		URL:    server.URL,
+		Header: http.Header{WebhookIDHeader: {"evt_1"}, "Content-Type": {"application/json"}},
+		Body:   []byte(`{}`),
+	}))
+
+	client := New(Config{}, "test-webhook-resume")
+	defer client.Close()
+
+	delivered := make(chan WebhookDelivery, 1)
+	sender, err := client.NewWebhookSender("test-webhook-resume-sender", WebhookConfig{
+		Secret:      secret,
+		Store:       store,
+		OnDelivered: func(delivery WebhookDelivery) { delivered <- delivery },
+	})
+	require.NoError(t, err)
+	defer sender.Close()
+
+	select {
+	case delivery := <-delivered:
+		assert.Equal(t, "evt_1", delivery.EventID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stored event was not delivered")
+	}
+
+	// An error loading the store is returned
+	_, err = client.NewWebhookSender("test-webhook-resume-broken", WebhookConfig{Store: failingQueueStore{}})
+	assert.ErrorIs(t, err, errStoreUnavailable)
+}
+
+// errStoreUnavailable is returned by failingQueueStore.
+var errStoreUnavailable = errors.New("store unavailable")
+
+// failingQueueStore is a QueueStore that can't be loaded.
+type failingQueueStore struct{ QueueStore }
+
+func (failingQueueStore) Load(context.Context) ([]QueuedRequest, error) {
+	return nil, errStoreUnavailable
+}
+
+func TestWebhookSender_DedupeWindow(t *testing.T) {
+	t.Parallel()
+	clock := NewFakeClock(time.Unix(1700000000, 0))
+	s := &WebhookSender{config: WebhookConfig{}.withDefaults(), clock: clock, sent: make(map[string]time.Time)}
+
+	assert.True(t, s.remember("a"))
+	clock.Advance(time.Hour)
+	assert.True(t, s.remember("b"))
+	assert.False(t, s.remember("a"))
+
+	// Keys expire on lookup, and are dropped once per window
+	clock.Advance(23 * time.Hour)
+	assert.True(t, s.remember("a"))
+	assert.False(t, s.remember("a"))
+	clock.Advance(24 * time.Hour)
+	assert.True(t, s.remember("c"))
+	assert.NotContains(t, s.sent, "b")
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	t.Parallel()
+	secret := []byte("whsec")
+	body := []byte(`{"id":1}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := http.Header{}
+	header.Set(WebhookIDHeader, "evt_1")
+	header.Set(WebhookTimestampHeader, timestamp)
+
+	// A rotated secret sends several signatures
+	header.Set(WebhookSignatureHeader, "v1,b2xk v1,"+webhookSignature(secret, "evt_1", timestamp, body))
+	require.NoError(t, VerifyWebhookSignature(secret, header, body, time.Minute))
+	require.ErrorIs(t, VerifyWebhookSignature([]byte("other"), header, body, time.Minute), ErrInvalidWebhookSignature)
+	require.ErrorIs(t, VerifyWebhookSignature(secret, header, []byte(`{"id":2}`), time.Minute), ErrInvalidWebhookSignature)
+
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	header.Set(WebhookTimestampHeader, old)
+	header.Set(WebhookSignatureHeader, "v1,"+webhookSignature(secret, "evt_1", old, body))
+	require.ErrorIs(t, VerifyWebhookSignature(secret, header, body, time.Minute), ErrInvalidWebhookSignature)
+	require.NoError(t, VerifyWebhookSignature(secret, header, body, 0))
+}
@@ -0,0 +1,183 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrNoWebSocketDialer is returned by Client.DialWebSocket when Config.WebSocketDialer is not set.
+var ErrNoWebSocketDialer = errors.New("websocket dialer is not configured")
+
+// errWebSocketNotDialed is returned when a middleware answers the handshake without dialing.
+var errWebSocketNotDialed = errors.New("websocket handshake was not performed by the middleware chain")
+
+// WebSocketConn is the connection returned by a WebSocketDialer, e.g. *websocket.Conn
+// of the wrapped library. Callers type-assert it to the concrete type.
+type WebSocketConn interface{}
+
+// WebSocketDialOptions carries the client's settings to a WebSocketDialer.
+type WebSocketDialOptions struct {
+	// Header contains handshake headers after request options and middlewares were applied
+	Header http.Header
+
+	// TLSClientConfig is the client's TLS configuration (nil means library defaults)
+	TLSClientConfig *tls.Config
+
+	// Proxy selects the proxy for the handshake, honoring ProxyURL, ProxyFunc and NoProxy
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// NetDialContext dials TCP connections with the client's dial timeout and keep-alive
+	NetDialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// HTTPClient performs the handshake over the client's base transport,
+	// for libraries that accept an *http.Client (nhooyr.io/websocket)
+	HTTPClient *http.Client
+}
+
+// WebSocketDialer establishes a WebSocket connection. Adapters map the options onto the
+// dialer of a WebSocket library; for gorilla/websocket:
+//
+//	httpclient.WebSocketDialerFunc(func(ctx context.Context, url string, o httpclient.WebSocketDialOptions) (
+//		httpclient.WebSocketConn, *http.Response, error,
+//	) {
+//		d := websocket.Dialer{Proxy: o.Proxy, TLSClientConfig: o.TLSClientConfig, NetDialContext: o.NetDialContext}
+//		return d.DialContext(ctx, url, o.Header)
+//	})
+type WebSocketDialer interface {
+	DialWebSocket(ctx context.Context, url string, opts WebSocketDialOptions) (WebSocketConn, *http.Response, error)
+}
+
+// WebSocketDialerFunc adapts an ordinary function to the WebSocketDialer interface.
+type WebSocketDialerFunc func(ctx context.Context, url string, opts WebSocketDialOptions) (
+	WebSocketConn, *http.Response, error,
+)
+
+// DialWebSocket implements the WebSocketDialer interface.
+func (f WebSocketDialerFunc) DialWebSocket(
+	ctx context.Context, url string, opts WebSocketDialOptions,
+) (WebSocketConn, *http.Response, error) {
+	return f(ctx, url, opts)
+}
+
+// DialWebSocket establishes a WebSocket connection to a ws:// or wss:// URL using
+// Config.WebSocketDialer. The handshake request goes through request options and
+// Config.Middlewares (auth, signing) and is traced like HTTP requests; retries,
+// metrics and the circuit breaker don't apply. The returned connection is the
+// dialer's connection type.
+func (c *Client) DialWebSocket(
+	ctx context.Context, rawURL string, opts ...RequestOption,
+) (WebSocketConn, *http.Response, error) {
+	dialer := c.config.WebSocketDialer
+	if dialer == nil {
+		return nil, nil, ErrNoWebSocketDialer
+	}
+	if c.drain.isClosed() {
+		return nil, nil, &ClientClosedError{Method: http.MethodGet, URL: rawURL}
+	}
+
+	// Middlewares work with HTTP URLs; the dialer receives the original WebSocket URL
+	httpURL, err := webSocketHandshakeURL(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	applyOptions(req, opts)
+
+	rt, _ := c.httpClient.Transport.(*RoundTripper)
+	if rt != nil {
+		reqCtx, span := rt.setupTracing(req)
+		if span != nil {
+			defer span.End()
+			span.SetAttributes(attribute.Bool("websocket", true))
+		}
+		req = req.WithContext(reqCtx)
+	}
+
+	dialOpts := c.webSocketDialOptions()
+	var conn WebSocketConn
+	final := func(r *http.Request) (*http.Response, error) {
+		if rt != nil {
+			r = r.Clone(r.Context())
+			if err := rt.prepareAttempt(r, 1); err != nil {
+				return nil, fmt.Errorf("failed to prepare websocket handshake: %w", err)
+			}
+		}
+		wsURL, err := webSocketDialURL(r.URL)
+		if err != nil {
+			return nil, err
+		}
+		opts := dialOpts
+		opts.Header = r.Header
+		var resp *http.Response
+		conn, resp, err = dialer.DialWebSocket(r.Context(), wsURL, opts)
+		return resp, err
+	}
+
+	resp, err := chainMiddlewares(c.config.Middlewares, final)(req)
+	if err == nil && conn == nil {
+		err = errWebSocketNotDialed
+	}
+	return conn, resp, err
+}
+
+// webSocketDialOptions collects TLS, proxy and dial settings from the client's transport.
+func (c *Client) webSocketDialOptions() WebSocketDialOptions {
+	opts := WebSocketDialOptions{
+		Proxy:      newProxyFunc(c.config),
+		HTTPClient: &http.Client{Transport: c.config.Transport},
+	}
+	if !c.config.TLSConfig.isZero() {
+		opts.TLSClientConfig = c.config.TLSConfig.clientTLSConfig(nil)
+	}
+
+	if transport, ok := c.config.Transport.(*http.Transport); ok {
+		opts.Proxy = transport.Proxy
+		opts.NetDialContext = transport.DialContext
+		if transport.TLSClientConfig != nil {
+			opts.TLSClientConfig = transport.TLSClientConfig.Clone()
+		}
+	}
+	return opts
+}
+
+// webSocketHandshakeURL converts a ws:// or wss:// URL into the http(s) URL of the handshake.
+func webSocketHandshakeURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme = "https"
+	default:
+		return "", fmt.Errorf("unsupported websocket URL scheme %q", u.Scheme)
+	}
+	return u.String(), nil
+}
+
+// webSocketDialURL converts the handshake URL back to a ws:// or wss:// URL.
+func webSocketDialURL(u *url.URL) (string, error) {
+	dial := *u
+	switch dial.Scheme {
+	case "http":
+		dial.Scheme = "ws"
+	case "https":
+		dial.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("unsupported websocket URL scheme %q", u.Scheme)
+	}
+	return dial.String(), nil
+}
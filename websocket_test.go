@@ -0,0 +1,97 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDialer captures the dial arguments and returns a fake connection.
+type recordingDialer struct {
+	url  string
+	opts WebSocketDialOptions
+}
+
+func (d *recordingDialer) DialWebSocket(
+	_ context.Context, url string, opts WebSocketDialOptions,
+) (WebSocketConn, *http.Response, error) {
+	d.url = url
+	d.opts = opts
+	return "conn", &http.Response{StatusCode: http.StatusSwitchingProtocols, Header: http.Header{}}, nil
+}
+
+func TestDialWebSocket_SharesClientConfiguration(t *testing.T) {
+	t.Parallel()
+	dialer := &recordingDialer{}
+	auth := MiddlewareFunc(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		assert.Equal(t, "https", req.URL.Scheme)
+		req.Header.Set("Authorization", "Bearer token")
+		return next(req)
+	})
+
+	client := New(Config{
+		WebSocketDialer: dialer,
+		Middlewares:     []Middleware{auth, NewHMACSigningMiddleware([]byte("secret"), "X-Signature", HMACSHA256)},
+		ProxyURL:        "http://proxy.internal:3128",
+		TLSConfig:       TLSConfig{InsecureSkipVerify: true},
+	}, "test-websocket")
+	defer client.Close()
+
+	conn, resp, err := client.DialWebSocket(context.Background(), "wss://example.com/stream?x=1",
+		WithHeader("X-Client", "test"))
+	require.NoError(t, err)
+	assert.Equal(t, "conn", conn)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	assert.Equal(t, "wss://example.com/stream?x=1", dialer.url)
+	assert.Equal(t, "Bearer token", dialer.opts.Header.Get("Authorization"))
+	assert.Equal(t, "test", dialer.opts.Header.Get("X-Client"))
+	assert.NotEmpty(t, dialer.opts.Header.Get("X-Signature"))
+	require.NotNil(t, dialer.opts.TLSClientConfig)
+	assert.True(t, dialer.opts.TLSClientConfig.InsecureSkipVerify)
+	assert.NotNil(t, dialer.opts.NetDialContext)
+	require.NotNil(t, dialer.opts.HTTPClient)
+
+	proxyReq, _ := http.NewRequest(http.MethodGet, "https://example.com/stream", nil)
+	proxy, err := dialer.opts.Proxy(proxyReq)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy.internal:3128", proxy.Host)
+}
+
+func TestDialWebSocket_NoDialer(t *testing.T) {
+	t.Parallel()
+	client := New(Config{}, "test-websocket-none")
+	defer client.Close()
+
+	_, _, err := client.DialWebSocket(context.Background(), "ws://example.com")
+	assert.ErrorIs(t, err, ErrNoWebSocketDialer)
+}
+
+func TestDialWebSocket_MiddlewareShortCircuit(t *testing.T) {
+	t.Parallel()
+	deny := MiddlewareFunc(func(*http.Request, func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	client := New(Config{WebSocketDialer: &recordingDialer{}, Middlewares: []Middleware{deny}}, "test-websocket-deny")
+	defer client.Close()
+
+	_, _, err := client.DialWebSocket(context.Background(), "ws://example.com")
+	assert.ErrorIs(t, err, errWebSocketNotDialed)
+}
+
+func TestWebSocketHandshakeURL(t *testing.T) {
+	t.Parallel()
+	u, err := webSocketHandshakeURL("ws://example.com/a")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/a", u)
+
+	u, err = webSocketHandshakeURL("WSS://example.com/a")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", u)
+
+	_, err = webSocketHandshakeURL("ftp://example.com")
+	assert.Error(t, err)
+}